@@ -0,0 +1,98 @@
+package anthropic
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// charsPerToken approximates prompt size without a tokenizer. It overestimates
+// for English prose, so budgeted prompts err on the small side.
+const charsPerToken = 4
+
+// minSectionTokens is the smallest remainder worth keeping a trimmed section
+// for; below it the section is dropped.
+const minSectionTokens = 64
+
+// sectionOverheadTokens allows for the heading a template wraps around each
+// included section.
+const sectionOverheadTokens = 16
+
+// budgetOmittedNotice marks where a section was trimmed to fit the budget.
+const budgetOmittedNotice = "[Earlier context omitted to fit the context budget]\n"
+
+// EstimateTokens returns a rough token count for text.
+func EstimateTokens(text string) int {
+	return (len(text) + charsPerToken - 1) / charsPerToken
+}
+
+// budgetedSections returns the optional context fields in the order they are
+// kept when the budget runs short: progress from the ticket's earlier run
+// first, background material last. Everything else, including the ticket and
+// its acceptance criteria, is always included.
+func budgetedSections(data *AgentPromptData) []*string {
+	return []*string{
+		&data.ResumeContext,
+		&data.ConversationSummary,
+		&data.ExtraContext,
+		&data.CodeContext,
+		&data.RetrievedHistory,
+	}
+}
+
+// fitContextBudget returns data with its optional context trimmed so the
+// rendered prompt stays within data.ContextBudget. The prompt is first
+// rendered without optional context to measure what is always sent; the
+// remaining budget is then handed out in section order. A section that
+// doesn't fit keeps its most recent (trailing) content, and later sections
+// are dropped.
+func (pb *PromptBuilder) fitContextBudget(agentType string, data AgentPromptData) (AgentPromptData, error) {
+	bare := data
+	for _, section := range budgetedSections(&bare) {
+		*section = ""
+	}
+	parts, err := pb.buildCachedPrompt(agentType, bare)
+	if err != nil {
+		return data, err
+	}
+
+	remaining := data.ContextBudget - EstimateTokens(joinBlockText(pb.BuildSystemBlocks(parts)))
+	for _, section := range budgetedSections(&data) {
+		tokens := EstimateTokens(*section)
+		switch {
+		case tokens == 0:
+		case tokens+sectionOverheadTokens <= remaining:
+			remaining -= tokens + sectionOverheadTokens
+		case remaining-sectionOverheadTokens >= minSectionTokens:
+			*section = trimToTokens(*section, remaining-sectionOverheadTokens)
+			remaining = 0
+		default:
+			*section = ""
+		}
+	}
+	return data, nil
+}
+
+// trimToTokens keeps the end of text within tokens, starting at a line
+// boundary where possible.
+func trimToTokens(text string, tokens int) string {
+	keep := tokens*charsPerToken - len(budgetOmittedNotice)
+	if keep <= 0 {
+		return ""
+	}
+	tail := text[len(text)-keep:]
+	for len(tail) > 0 && !utf8.RuneStart(tail[0]) {
+		tail = tail[1:]
+	}
+	if i := strings.IndexByte(tail, '\n'); i >= 0 && i < len(tail)-1 {
+		tail = tail[i+1:]
+	}
+	return budgetOmittedNotice + tail
+}
+
+func joinBlockText(blocks []SystemBlock) string {
+	var sb strings.Builder
+	for _, block := range blocks {
+		sb.WriteString(block.Text)
+	}
+	return sb.String()
+}
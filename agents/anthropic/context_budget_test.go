@@ -0,0 +1,87 @@
+package anthropic
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const budgetTestTemplate = `You are a backend developer.
+
+## Ticket Context
+{{if .ResumeContext}}
+## Resuming Earlier Work
+{{.ResumeContext}}
+{{end}}
+{{if .ExtraContext}}
+## Additional Context
+{{.ExtraContext}}
+{{end}}`
+
+func TestBuildCachedPromptTrimsOversizedContextToBudget(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "dev-backend.md"), []byte(budgetTestTemplate), 0o600); err != nil {
+		t.Fatalf("failed to write template: %v", err)
+	}
+	pb, err := NewPromptBuilder(dir)
+	if err != nil {
+		t.Fatalf("failed to create prompt builder: %v", err)
+	}
+
+	var resume, extra strings.Builder
+	for i := 1; i <= 500; i++ {
+		fmt.Fprintf(&resume, "progress line %d\n", i)
+		fmt.Fprintf(&extra, "background line %d\n", i)
+	}
+	data := AgentPromptData{
+		TicketJSON:    `{"id": "T-1", "acceptance_criteria": ["Rejects empty passwords"]}`,
+		ResumeContext: resume.String(),
+		ExtraContext:  extra.String(),
+		ContextBudget: 1000,
+	}
+
+	parts, err := pb.BuildCachedPrompt("dev-backend", data)
+	if err != nil {
+		t.Fatalf("BuildCachedPrompt failed: %v", err)
+	}
+	prompt := joinBlockText(pb.BuildSystemBlocks(parts))
+
+	if got := EstimateTokens(prompt); got > data.ContextBudget {
+		t.Errorf("expected prompt within %d tokens, got %d", data.ContextBudget, got)
+	}
+	if !strings.Contains(prompt, "Rejects empty passwords") {
+		t.Error("expected the ticket and its acceptance criteria to always be included")
+	}
+	if !strings.Contains(prompt, budgetOmittedNotice) || !strings.Contains(prompt, "progress line 500\n") {
+		t.Error("expected resume context trimmed to its most recent lines")
+	}
+	if strings.Contains(prompt, "progress line 1\n") {
+		t.Error("expected the oldest resume context to be dropped")
+	}
+	if strings.Contains(prompt, "background line") {
+		t.Error("expected lower-priority context to be dropped once the budget is spent")
+	}
+
+	// The same input always trims the same way
+	again, err := pb.BuildCachedPrompt("dev-backend", data)
+	if err != nil {
+		t.Fatalf("BuildCachedPrompt failed: %v", err)
+	}
+	if !reflect.DeepEqual(parts, again) {
+		t.Error("expected trimming to be deterministic")
+	}
+
+	// A budget with room to spare leaves the context untouched
+	data.ContextBudget = 100000
+	parts, err = pb.BuildCachedPrompt("dev-backend", data)
+	if err != nil {
+		t.Fatalf("BuildCachedPrompt failed: %v", err)
+	}
+	prompt = joinBlockText(pb.BuildSystemBlocks(parts))
+	if !strings.Contains(prompt, resume.String()) || !strings.Contains(prompt, extra.String()) {
+		t.Error("expected full context within a generous budget")
+	}
+}
@@ -96,12 +96,22 @@ type AgentPromptData struct {
 	ConsultationJSON string   `json:"consultationJson,omitempty"`
 	ExtraContext     string   `json:"extraContext,omitempty"`
 
+	// Targeted re-review
+	BugsToVerify interface{} `json:"bugsToVerify,omitempty"`
+
+	// Resumed dev work
+	ResumeContext string `json:"resumeContext,omitempty"`
+
+	// Code related to a dev ticket, retrieved from the RAG index
+	CodeContext string `json:"codeContext,omitempty"`
+
 	// PRD collaboration
 	Conversation        interface{} `json:"conversation,omitempty"`
 	CurrentRound        int         `json:"currentRound,omitempty"`
 	CurrentPrompt       string      `json:"currentPrompt,omitempty"`
 	Agent               string      `json:"agent,omitempty"`
 	FocusAreas          []string    `json:"focusAreas,omitempty"`
+	PRDTemplate         []string    `json:"prdTemplate,omitempty"`
 	ConversationSummary string      `json:"conversationSummary,omitempty"`
 	PRD                 string      `json:"prd,omitempty"`
 	FinalExpertInputs   interface{} `json:"finalExpertInputs,omitempty"`
@@ -109,6 +119,10 @@ type AgentPromptData struct {
 	// RAG-retrieved context (formatted as markdown strings for template usage)
 	RetrievedPatterns string `json:"retrievedPatterns,omitempty"`
 	RetrievedHistory  string `json:"retrievedHistory,omitempty"`
+
+	// ContextBudget caps the estimated prompt size in tokens; 0 means unlimited.
+	// Optional context is trimmed to fit, see fitContextBudget.
+	ContextBudget int `json:"-"`
 }
 
 // RetrievedChunk represents a RAG-retrieved content chunk.
@@ -118,8 +132,20 @@ type RetrievedChunk struct {
 	Similarity float64 `json:"similarity"`
 }
 
-// BuildCachedPrompt constructs a prompt with separate cached and dynamic parts.
+// BuildCachedPrompt constructs a prompt with separate cached and dynamic parts,
+// trimming optional context to the data's context budget.
 func (pb *PromptBuilder) BuildCachedPrompt(agentType string, data AgentPromptData) (*CachedPromptParts, error) {
+	if data.ContextBudget > 0 {
+		fitted, err := pb.fitContextBudget(agentType, data)
+		if err != nil {
+			return nil, err
+		}
+		data = fitted
+	}
+	return pb.buildCachedPrompt(agentType, data)
+}
+
+func (pb *PromptBuilder) buildCachedPrompt(agentType string, data AgentPromptData) (*CachedPromptParts, error) {
 	parts := &CachedPromptParts{
 		StaticPrefix:  make([]SystemBlock, 0),
 		DynamicSuffix: make([]SystemBlock, 0),
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/madhatter5501/Factory/agents/anthropic"
@@ -13,6 +14,12 @@ import (
 	"github.com/madhatter5501/Factory/kanban"
 )
 
+// agentUserPrompt is the user message sent alongside every agent system prompt.
+const agentUserPrompt = "Execute the task described in the system prompt. Output your results as specified."
+
+// defaultAgentMaxTokens is the response token limit for agents without a configured max_tokens.
+const defaultAgentMaxTokens = 16384
+
 // ConfigStore interface for looking up provider configurations.
 type ConfigStore interface {
 	GetAgentProviderConfig(agentType string) (*provider.AgentProviderConfig, error)
@@ -29,6 +36,8 @@ type APISpawner struct {
 	model           string
 	providerFactory *provider.Factory
 	configStore     ConfigStore
+	contextBudgets  map[string]int
+	clampWarned     sync.Map // Models whose clamped context budget has been reported
 }
 
 // APISpawnerConfig configures the API spawner.
@@ -45,6 +54,10 @@ type APISpawnerConfig struct {
 	// Multi-provider support
 	ProviderFactory *provider.Factory
 	ConfigStore     ConfigStore
+
+	// ContextBudgets caps prompt size in tokens by model. Models without an
+	// entry get their context window minus the response token limit.
+	ContextBudgets map[string]int
 }
 
 // NewAPISpawner creates a new API-based agent spawner.
@@ -74,6 +87,7 @@ func NewAPISpawner(cfg APISpawnerConfig) (*APISpawner, error) {
 		model:           cfg.Model,
 		providerFactory: providerFactory,
 		configStore:     cfg.ConfigStore,
+		contextBudgets:  cfg.ContextBudgets,
 	}
 
 	// Initialize RAG if enabled
@@ -92,35 +106,55 @@ func NewAPISpawner(cfg APISpawnerConfig) (*APISpawner, error) {
 	return s, nil
 }
 
-// SpawnAgent runs an agent using the configured provider.
-func (s *APISpawner) SpawnAgent(ctx context.Context, agentType AgentType, data PromptData, workDir string) (*AgentResult, error) {
-	startTime := time.Now()
-
-	// Create context with timeout
-	ctx, cancel := context.WithTimeout(ctx, s.timeout)
-	defer cancel()
-
-	// Get provider config for this agent type
+// resolveProvider returns the provider and model an agent runs on, and the
+// agent's provider config for generation settings. A ticket's provider
+// override takes precedence over the agent's config.
+func (s *APISpawner) resolveProvider(agentType AgentType, ticket *kanban.Ticket) (string, string, *provider.AgentProviderConfig) {
 	providerName := "anthropic"
 	modelName := s.model
+	var providerConfig *provider.AgentProviderConfig
 	if s.configStore != nil {
 		cfg, err := s.configStore.GetAgentProviderConfig(string(agentType))
 		if err == nil && cfg != nil {
 			providerName = cfg.Provider
 			modelName = cfg.Model
+			providerConfig = cfg
+		}
+	}
+	if ticket != nil && ticket.ProviderOverride != nil {
+		// Generation settings tuned for another provider don't carry over
+		if ticket.ProviderOverride.Provider != providerName {
+			providerConfig = nil
 		}
+		providerName = ticket.ProviderOverride.Provider
+		modelName = ticket.ProviderOverride.Model
 	}
+
 	// Fallback to default model if not set
 	if modelName == "" {
-		if defaultModel, ok := provider.DefaultModels[providerName]; ok {
+		if defaultModel := provider.DefaultModel(providerName); defaultModel != "" {
 			modelName = defaultModel
 		} else {
 			modelName = provider.ModelAnthropicSonnet4
 		}
 	}
+	return providerName, modelName, providerConfig
+}
+
+// SpawnAgent runs an agent using the configured provider.
+func (s *APISpawner) SpawnAgent(ctx context.Context, agentType AgentType, data PromptData, workDir string) (*AgentResult, error) {
+	startTime := time.Now()
+
+	// Create context with timeout
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	providerName, modelName, providerConfig := s.resolveProvider(agentType, data.Ticket)
+	temperature, maxTokens := providerConfig.GenerationParams(defaultAgentMaxTokens)
 
 	// Convert to API prompt data
 	promptData := s.convertPromptData(data, agentType)
+	promptData.ContextBudget = s.contextBudget(modelName, maxTokens)
 
 	// Summarize conversation if multi-round PRD
 	if data.Conversation != nil && data.CurrentRound > 1 {
@@ -151,16 +185,16 @@ func (s *APISpawner) SpawnAgent(ctx context.Context, agentType AgentType, data P
 		ticketID = data.Ticket.ID
 	}
 
-	var output string
+	var reply apiReply
 	var callErr error
 
 	// Route to appropriate provider
 	if providerName == "anthropic" {
 		// Use Anthropic-specific path with prompt caching
-		output, callErr = s.callAnthropicWithCaching(ctx, agentType, promptData, modelName, ticketID)
+		reply, callErr = s.callAnthropicWithCaching(ctx, agentType, promptData, modelName, temperature, maxTokens, ticketID, data.reportPrompt)
 	} else {
 		// Use generic provider interface
-		output, callErr = s.callGenericProvider(ctx, agentType, promptData, providerName, modelName)
+		reply, callErr = s.callGenericProvider(ctx, agentType, promptData, providerName, modelName, temperature, maxTokens, data.reportPrompt)
 	}
 
 	if callErr != nil {
@@ -170,9 +204,15 @@ func (s *APISpawner) SpawnAgent(ctx context.Context, agentType AgentType, data P
 			TicketID:  ticketID,
 			Error:     fmt.Sprintf("API call failed: %v", callErr),
 			Duration:  time.Since(startTime),
+			RunMetadata: kanban.RunMetadata{
+				Model:      modelName,
+				ExitReason: "error",
+			},
+			FailureCategory: ClassifyFailure(callErr, nil),
 		}, callErr
 	}
 
+	output := reply.text
 	result := &AgentResult{
 		Success:   true,
 		AgentType: agentType,
@@ -180,6 +220,12 @@ func (s *APISpawner) SpawnAgent(ctx context.Context, agentType AgentType, data P
 		Output:    output,
 		Duration:  time.Since(startTime),
 		ExitCode:  0,
+		RunMetadata: kanban.RunMetadata{
+			Model:       modelName,
+			TokenInput:  reply.inputTokens,
+			TokenOutput: reply.outputTokens,
+			ExitReason:  reply.stopReason,
+		},
 	}
 
 	// Check for standard markers
@@ -198,43 +244,77 @@ func (s *APISpawner) SpawnAgent(ctx context.Context, agentType AgentType, data P
 	return result, nil
 }
 
+// contextBudget returns the prompt token budget for a model: the configured
+// budget if any, otherwise whatever its context window leaves after the
+// response. A response limit that leaves less than a quarter of the window is
+// a misconfiguration; the budget is clamped to that quarter so the prompt is
+// still trimmed rather than sent unbounded, with a warning once per model.
+func (s *APISpawner) contextBudget(model string, maxTokens int) int {
+	if budget := s.contextBudgets[model]; budget > 0 {
+		return budget
+	}
+	window := provider.ContextWindow(model)
+	budget := window - maxTokens
+	if minBudget := window / 4; budget < minBudget {
+		if _, warned := s.clampWarned.LoadOrStore(model, true); !warned {
+			fmt.Printf("[api-spawner] Warning: max tokens %d leaves %d of %s's %d-token context window for the prompt; using %d\n",
+				maxTokens, budget, model, window, minBudget)
+		}
+		budget = minBudget
+	}
+	return budget
+}
+
+// apiReply is the text and usage of one model response.
+type apiReply struct {
+	text         string
+	stopReason   string
+	inputTokens  int
+	outputTokens int
+}
+
 // callAnthropicWithCaching uses the Anthropic-specific prompt caching path.
 func (s *APISpawner) callAnthropicWithCaching(
 	ctx context.Context,
 	agentType AgentType,
 	promptData anthropic.AgentPromptData,
 	model string,
+	temperature *float64,
+	maxTokens int,
 	ticketID string,
-) (string, error) {
+	reportPrompt func(systemPrompt, userPrompt string),
+) (apiReply, error) {
 	// Build cached prompt
 	parts, err := s.promptBuilder.BuildCachedPrompt(string(agentType), promptData)
 	if err != nil {
-		return "", fmt.Errorf("failed to build prompt: %w", err)
+		return apiReply{}, fmt.Errorf("failed to build prompt: %w", err)
 	}
 
 	// Create API request with system blocks
 	systemBlocks := s.promptBuilder.BuildSystemBlocks(parts)
 	req := &anthropic.CreateMessageRequest{
-		Model:     model,
-		MaxTokens: 16384,
-		System:    systemBlocks,
+		Model:       model,
+		MaxTokens:   maxTokens,
+		Temperature: temperature,
+		System:      systemBlocks,
 		Messages: []anthropic.Message{
 			{
 				Role: "user",
 				Content: []anthropic.ContentBlock{
 					{
 						Type: "text",
-						Text: "Execute the task described in the system prompt. Output your results as specified.",
+						Text: agentUserPrompt,
 					},
 				},
 			},
 		},
 	}
+	reportPrompt(joinSystemBlocks(systemBlocks), agentUserPrompt)
 
 	// Send request with tracking
 	resp, err := s.client.CreateMessageWithTracking(ctx, req, string(agentType), ticketID)
 	if err != nil {
-		return "", err
+		return apiReply{}, err
 	}
 
 	if s.verbose {
@@ -243,7 +323,12 @@ func (s *APISpawner) callAnthropicWithCaching(
 			usage.CacheHitRate*100, usage.EstimatedSavings)
 	}
 
-	return resp.GetText(), nil
+	return apiReply{
+		text:         resp.GetText(),
+		stopReason:   resp.StopReason,
+		inputTokens:  resp.Usage.InputTokens + resp.Usage.CacheCreationInput + resp.Usage.CacheReadInput,
+		outputTokens: resp.Usage.OutputTokens,
+	}, nil
 }
 
 // callGenericProvider uses the provider interface for non-Anthropic providers.
@@ -253,17 +338,20 @@ func (s *APISpawner) callGenericProvider(
 	promptData anthropic.AgentPromptData,
 	providerName string,
 	model string,
-) (string, error) {
+	temperature *float64,
+	maxTokens int,
+	reportPrompt func(systemPrompt, userPrompt string),
+) (apiReply, error) {
 	// Get provider
 	prov, err := s.providerFactory.GetProvider(providerName)
 	if err != nil {
-		return "", fmt.Errorf("failed to get provider %s: %w", providerName, err)
+		return apiReply{}, fmt.Errorf("failed to get provider %s: %w", providerName, err)
 	}
 
 	// Build prompt using the prompt builder (without caching)
 	parts, err := s.promptBuilder.BuildCachedPrompt(string(agentType), promptData)
 	if err != nil {
-		return "", fmt.Errorf("failed to build prompt: %w", err)
+		return apiReply{}, fmt.Errorf("failed to build prompt: %w", err)
 	}
 
 	// Combine prompt parts into a single system prompt
@@ -271,24 +359,31 @@ func (s *APISpawner) callGenericProvider(
 
 	// Create provider-agnostic request
 	req := &provider.MessageRequest{
-		Model:     model,
-		MaxTokens: 16384,
-		System:    systemPrompt,
+		Model:       model,
+		MaxTokens:   maxTokens,
+		Temperature: temperature,
+		System:      systemPrompt,
 		Messages: []provider.Message{
 			{
 				Role:    "user",
-				Content: "Execute the task described in the system prompt. Output your results as specified.",
+				Content: agentUserPrompt,
 			},
 		},
 	}
+	reportPrompt(systemPrompt, agentUserPrompt)
 
 	// Call provider
 	resp, err := prov.CreateMessage(ctx, req)
 	if err != nil {
-		return "", err
+		return apiReply{}, err
 	}
 
-	return resp.Content, nil
+	return apiReply{
+		text:         resp.Content,
+		stopReason:   resp.StopReason,
+		inputTokens:  resp.Usage.InputTokens,
+		outputTokens: resp.Usage.OutputTokens,
+	}, nil
 }
 
 // combinePromptParts combines cached prompt parts into a single string for non-Anthropic providers.
@@ -314,6 +409,17 @@ func (s *APISpawner) combinePromptParts(parts *anthropic.CachedPromptParts) stri
 	return strings.TrimSpace(sb.String())
 }
 
+// joinSystemBlocks flattens Anthropic system blocks into the text the model receives.
+func joinSystemBlocks(blocks []anthropic.SystemBlock) string {
+	texts := make([]string, 0, len(blocks))
+	for _, block := range blocks {
+		if block.Text != "" {
+			texts = append(texts, block.Text)
+		}
+	}
+	return strings.Join(texts, "\n\n")
+}
+
 // convertPromptData converts the existing PromptData to the API format.
 func (s *APISpawner) convertPromptData(data PromptData, agentType AgentType) anthropic.AgentPromptData {
 	promptData := anthropic.AgentPromptData{
@@ -326,10 +432,14 @@ func (s *APISpawner) convertPromptData(data PromptData, agentType AgentType) ant
 		Questions:        data.Questions,
 		ConsultationJSON: data.ConsultationJSON,
 		ExtraContext:     data.ExtraContext,
+		BugsToVerify:     data.BugsToVerify,
+		ResumeContext:    data.ResumeContext,
+		CodeContext:      data.CodeContext,
 		CurrentRound:     data.CurrentRound,
 		CurrentPrompt:    data.CurrentPrompt,
 		Agent:            data.Agent,
 		FocusAreas:       data.FocusAreas,
+		PRDTemplate:      data.PRDTemplate,
 		PRD:              data.PRD,
 	}
 
@@ -572,6 +682,64 @@ func (r *RAGRetriever) RetrievePatterns(
 	return chunks, nil
 }
 
+// CodeContextRetriever finds indexed code relevant to a ticket for dev prompts.
+type CodeContextRetriever interface {
+	RetrieveCodeContext(ctx context.Context, ticket *kanban.Ticket, domain string, limit int) (string, error)
+}
+
+// RetrieveCodeContext returns up to limit indexed code snippets relevant to the
+// ticket's description and file patterns, formatted for a prompt.
+func (r *RAGRetriever) RetrieveCodeContext(ctx context.Context, ticket *kanban.Ticket, domain string, limit int) (string, error) {
+	if r.retriever == nil || limit <= 0 {
+		return "", nil
+	}
+
+	ticketCtx := rag.TicketContext{
+		ID:          ticket.ID,
+		Title:       ticket.Title,
+		Description: ticket.Description,
+		Domain:      domain,
+		Keywords:    filePatternKeywords(ticket.Files),
+	}
+	opts := rag.DefaultRetrievalOptions()
+	opts.MaxCodeExamples = limit
+	retrieved, err := r.retriever.RetrieveForTicket(ctx, ticketCtx, opts)
+	if err != nil {
+		return "", err
+	}
+
+	var chunks []anthropic.RetrievedChunk
+	for _, c := range retrieved.CodeExamples {
+		if len(chunks) == limit {
+			break
+		}
+		chunks = append(chunks, anthropic.RetrievedChunk{
+			Source:     c.Source,
+			Content:    c.Content,
+			Similarity: c.Similarity,
+		})
+	}
+	return formatRetrievedChunks(chunks), nil
+}
+
+// filePatternKeywords turns file globs like "src/api/*.go" into search terms
+// by dropping the glob syntax.
+func filePatternKeywords(patterns []string) []string {
+	var keywords []string
+	for _, pattern := range patterns {
+		path := strings.Map(func(r rune) rune {
+			if strings.ContainsRune("*?[]{}", r) {
+				return -1
+			}
+			return r
+		}, pattern)
+		if path = strings.Trim(path, "/."); path != "" {
+			keywords = append(keywords, path)
+		}
+	}
+	return keywords
+}
+
 // Close closes the RAG retriever resources.
 func (r *RAGRetriever) Close() error {
 	if r.store != nil {
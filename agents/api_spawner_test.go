@@ -0,0 +1,65 @@
+package agents
+
+import (
+	"testing"
+
+	"github.com/madhatter5501/Factory/agents/provider"
+	"github.com/madhatter5501/Factory/kanban"
+)
+
+// staticConfigStore returns the same provider config for every agent type.
+type staticConfigStore struct {
+	cfg *provider.AgentProviderConfig
+}
+
+func (s staticConfigStore) GetAgentProviderConfig(agentType string) (*provider.AgentProviderConfig, error) {
+	return s.cfg, nil
+}
+
+func TestContextBudgetIsClampedForSmallWindows(t *testing.T) {
+	spawner := &APISpawner{contextBudgets: map[string]int{"pinned-model": 5000}}
+
+	if got := spawner.contextBudget("pinned-model", 100000); got != 5000 {
+		t.Errorf("Expected the configured budget, got %d", got)
+	}
+	window := provider.ContextWindow("unknown-model")
+	if got := spawner.contextBudget("unknown-model", 4096); got != window-4096 {
+		t.Errorf("Expected the window minus the response limit, got %d", got)
+	}
+	// A response limit as large as the window would leave a negative budget
+	if got := spawner.contextBudget("unknown-model", window); got != window/4 {
+		t.Errorf("Expected the budget clamped to %d, got %d", window/4, got)
+	}
+	// The clamp is reported once per model, not on every call
+	if _, warned := spawner.clampWarned.Load("unknown-model"); !warned {
+		t.Error("Expected the clamp to be recorded as reported")
+	}
+	if _, warned := spawner.clampWarned.Load("pinned-model"); warned {
+		t.Error("Expected no clamp report for a model with a configured budget")
+	}
+}
+
+func TestTicketProviderOverrideTakesPrecedence(t *testing.T) {
+	temperature := 0.2
+	spawner := &APISpawner{configStore: staticConfigStore{&provider.AgentProviderConfig{
+		AgentType:   string(AgentTypeDevBackend),
+		Provider:    "anthropic",
+		Model:       provider.ModelAnthropicSonnet4,
+		Temperature: &temperature,
+	}}}
+
+	ticket := &kanban.Ticket{ID: "SEC-1"}
+	providerName, model, cfg := spawner.resolveProvider(AgentTypeDevBackend, ticket)
+	if providerName != "anthropic" || model != provider.ModelAnthropicSonnet4 || cfg == nil {
+		t.Fatalf("Expected the agent default without an override, got %s/%s", providerName, model)
+	}
+
+	ticket.ProviderOverride = &kanban.ProviderOverride{Provider: "openai", Model: provider.ModelOpenAIGPT4o}
+	providerName, model, cfg = spawner.resolveProvider(AgentTypeDevBackend, ticket)
+	if providerName != "openai" || model != provider.ModelOpenAIGPT4o {
+		t.Errorf("Expected the ticket's openai/%s, got %s/%s", provider.ModelOpenAIGPT4o, providerName, model)
+	}
+	if cfg != nil {
+		t.Error("Expected anthropic generation settings not to carry over to openai")
+	}
+}
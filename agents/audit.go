@@ -3,6 +3,8 @@ package agents
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"time"
 
@@ -11,8 +13,8 @@ import (
 
 // AuditLogger provides audit logging for agent operations.
 type AuditLogger interface {
-	// LogPromptSent records the prompt sent to an agent.
-	LogPromptSent(runID, ticketID, agent, prompt string) error
+	// LogPromptSent records the system and user prompt sent to an agent.
+	LogPromptSent(runID, ticketID, agent, systemPrompt, userPrompt string) error
 
 	// LogResponseReceived records the response from an agent.
 	LogResponseReceived(runID, ticketID, agent, response string, tokenIn, tokenOut int, durationMs int) error
@@ -54,16 +56,53 @@ func generateID() string {
 	return time.Now().Format("20060102-150405.000000")
 }
 
-// LogPromptSent records the prompt sent to an agent.
-func (l *StoreAuditLogger) LogPromptSent(runID, ticketID, agent, prompt string) error {
+// SentPrompt is the prompt recorded in a prompt_sent audit event.
+type SentPrompt struct {
+	SystemPrompt     string `json:"system_prompt"`
+	UserPrompt       string `json:"user_prompt"`
+	SystemPromptHash string `json:"system_prompt_sha256"`
+	Truncated        bool   `json:"truncated,omitempty"`
+}
+
+// ParseSentPrompt decodes the event data of a prompt_sent audit entry.
+// Entries recorded before prompts were captured hold a plain summary, which is
+// returned as the user prompt.
+func ParseSentPrompt(eventData string) SentPrompt {
+	var sent SentPrompt
+	if err := json.Unmarshal([]byte(eventData), &sent); err != nil || sent.SystemPromptHash == "" {
+		return SentPrompt{UserPrompt: eventData}
+	}
+	return sent
+}
+
+// LogPromptSent records the system and user prompt sent to an agent.
+// The system prompt hash is computed before truncation so runs can be compared
+// even when the stored text is cut short.
+func (l *StoreAuditLogger) LogPromptSent(runID, ticketID, agent, systemPrompt, userPrompt string) error {
 	if !l.enabled {
 		return nil
 	}
 
+	hash := sha256.Sum256([]byte(systemPrompt))
+	sent := SentPrompt{
+		SystemPrompt:     systemPrompt,
+		UserPrompt:       userPrompt,
+		SystemPromptHash: hex.EncodeToString(hash[:]),
+	}
+
 	// Truncate very long prompts for storage efficiency (keep first 50KB)
-	eventData := prompt
-	if len(eventData) > 50000 {
-		eventData = eventData[:50000] + "\n...[truncated]"
+	if len(sent.SystemPrompt) > 50000 {
+		sent.SystemPrompt = sent.SystemPrompt[:50000] + "\n...[truncated]"
+		sent.Truncated = true
+	}
+	if len(sent.UserPrompt) > 50000 {
+		sent.UserPrompt = sent.UserPrompt[:50000] + "\n...[truncated]"
+		sent.Truncated = true
+	}
+
+	eventDataJSON, err := json.Marshal(sent)
+	if err != nil {
+		eventDataJSON = []byte("{}")
 	}
 
 	entry := &kanban.AuditEntry{
@@ -72,7 +111,7 @@ func (l *StoreAuditLogger) LogPromptSent(runID, ticketID, agent, prompt string)
 		TicketID:  ticketID,
 		Agent:     agent,
 		EventType: kanban.AuditEventPromptSent,
-		EventData: eventData,
+		EventData: string(eventDataJSON),
 		CreatedAt: time.Now(),
 	}
 
@@ -171,7 +210,7 @@ func (l *StoreAuditLogger) LogError(runID, ticketID, agent, errorMsg string) err
 // NoOpAuditLogger is an audit logger that does nothing (for when logging is disabled).
 type NoOpAuditLogger struct{}
 
-func (l *NoOpAuditLogger) LogPromptSent(_, _, _, _ string) error { return nil }
+func (l *NoOpAuditLogger) LogPromptSent(_, _, _, _, _ string) error { return nil }
 func (l *NoOpAuditLogger) LogResponseReceived(_, _, _, _ string, _, _, _ int) error {
 	return nil
 }
@@ -196,24 +235,39 @@ func NewAuditingSpawner(inner AgentSpawner, logger AuditLogger) *AuditingSpawner
 func (s *AuditingSpawner) SpawnAgent(ctx context.Context, agentType AgentType, data PromptData, workDir string) (*AgentResult, error) {
 	startTime := time.Now()
 
-	// Generate run ID for correlation
-	runID := ""
+	// Use the caller's run ID for correlation, generating one if none was given
+	runID := data.RunID
 	ticketID := ""
 	if data.Ticket != nil {
 		ticketID = data.Ticket.ID
-		runID = ticketID + "-" + string(agentType) + "-" + startTime.Format("20060102-150405")
-	} else {
-		runID = string(agentType) + "-" + startTime.Format("20060102-150405")
+	}
+	if runID == "" {
+		if ticketID != "" {
+			runID = ticketID + "-" + string(agentType) + "-" + startTime.Format("20060102-150405")
+		} else {
+			runID = string(agentType) + "-" + startTime.Format("20060102-150405")
+		}
 	}
 
-	// Log the prompt (we'll render it ourselves for logging).
-	// Note: The actual prompt rendering happens inside the spawner, so we log the data we have.
-	promptSummary := formatPromptSummary(agentType, data)
-	_ = s.logger.LogPromptSent(runID, ticketID, string(agentType), promptSummary) // Non-fatal, continue on error
+	// Log the exact prompt when the inner spawner renders it
+	promptLogged := false
+	callerHook := data.OnPrompt
+	data.OnPrompt = func(systemPrompt, userPrompt string) {
+		promptLogged = true
+		_ = s.logger.LogPromptSent(runID, ticketID, string(agentType), systemPrompt, userPrompt) // Non-fatal, continue on error
+		if callerHook != nil {
+			callerHook(systemPrompt, userPrompt)
+		}
+	}
 
 	// Run the actual agent
 	result, err := s.inner.SpawnAgent(ctx, agentType, data, workDir)
 
+	// Spawners that don't report their prompt get a summary of the prompt data instead
+	if !promptLogged {
+		_ = s.logger.LogPromptSent(runID, ticketID, string(agentType), "", formatPromptSummary(agentType, data))
+	}
+
 	durationMs := int(time.Since(startTime).Milliseconds())
 
 	if err != nil {
@@ -272,6 +326,10 @@ func formatPromptSummary(agentType AgentType, data PromptData) string {
 		summary["extra_context"] = truncateForSummary(data.ExtraContext, 500)
 	}
 
+	if data.ResumeContext != "" {
+		summary["resume_context"] = truncateForSummary(data.ResumeContext, 500)
+	}
+
 	jsonBytes, err := json.MarshalIndent(summary, "", "  ")
 	if err != nil {
 		return "{}"
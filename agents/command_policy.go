@@ -0,0 +1,58 @@
+package agents
+
+import (
+	"strings"
+)
+
+// CommandPolicy restricts the shell commands a CLI agent may run. Entries are
+// command prefixes, enforced by the claude CLI as Bash permission rules, so
+// "git" covers "git status". Deny entries win over allow entries.
+type CommandPolicy struct {
+	Allow []string `json:"allow,omitempty"` // Commands the agent may run; empty allows any not denied
+	Deny  []string `json:"deny,omitempty"`  // Commands the agent may never run
+}
+
+// policyFileTools are the non-shell tools a policy-restricted agent keeps, so
+// it can still read and edit its worktree.
+var policyFileTools = []string{"Read", "Edit", "MultiEdit", "Write", "Glob", "Grep", "LS", "TodoWrite"}
+
+// cliArgs returns the claude CLI flags enforcing the policy. Instead of
+// skipping permission checks, the agent accepts file edits and may only run
+// the allowed commands; anything else is refused since --print can't prompt.
+func (p CommandPolicy) cliArgs() []string {
+	allowed := append([]string(nil), policyFileTools...)
+	if len(p.Allow) == 0 {
+		allowed = append(allowed, "Bash")
+	}
+	for _, prefix := range p.Allow {
+		allowed = append(allowed, bashRule(prefix))
+	}
+
+	args := []string{"--permission-mode", "acceptEdits", "--allowedTools", strings.Join(allowed, ",")}
+	if len(p.Deny) > 0 {
+		denied := make([]string, 0, len(p.Deny))
+		for _, prefix := range p.Deny {
+			denied = append(denied, bashRule(prefix))
+		}
+		args = append(args, "--disallowedTools", strings.Join(denied, ","))
+	}
+	return args
+}
+
+// CommandPolicyFor returns the policy for an agent type from policies keyed
+// by agent type, where "dev" covers every dev agent without its own entry.
+func CommandPolicyFor(policies map[string]CommandPolicy, agentType AgentType) (CommandPolicy, bool) {
+	if p, ok := policies[string(agentType)]; ok {
+		return p, true
+	}
+	if strings.HasPrefix(string(agentType), "dev-") {
+		p, ok := policies["dev"]
+		return p, ok
+	}
+	return CommandPolicy{}, false
+}
+
+// bashRule formats a command prefix as a claude CLI Bash permission rule.
+func bashRule(prefix string) string {
+	return "Bash(" + strings.Join(strings.Fields(prefix), " ") + ":*)"
+}
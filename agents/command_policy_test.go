@@ -0,0 +1,112 @@
+package agents
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCommandPolicyCLIArgs(t *testing.T) {
+	fileTools := "Read,Edit,MultiEdit,Write,Glob,Grep,LS,TodoWrite"
+	tests := []struct {
+		name   string
+		policy CommandPolicy
+		want   []string
+	}{
+		{
+			"allow and deny",
+			CommandPolicy{Allow: []string{"terraform   plan", "git"}, Deny: []string{"git push"}},
+			[]string{"--permission-mode", "acceptEdits", "--allowedTools", fileTools + ",Bash(terraform plan:*),Bash(git:*)", "--disallowedTools", "Bash(git push:*)"},
+		},
+		{
+			"deny only keeps the shell",
+			CommandPolicy{Deny: []string{"rm"}},
+			[]string{"--permission-mode", "acceptEdits", "--allowedTools", fileTools + ",Bash", "--disallowedTools", "Bash(rm:*)"},
+		},
+	}
+	for _, tt := range tests {
+		if got := tt.policy.cliArgs(); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: cliArgs() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSpawnerLaunchesInfraAgentWithCommandAllowlist(t *testing.T) {
+	dir := t.TempDir()
+
+	// A stand-in CLI that echoes the flags it was launched with
+	claude := filepath.Join(dir, "claude")
+	if err := os.WriteFile(claude, []byte("#!/bin/sh\nprintf '%s\\n' \"$@\"\n"), 0o700); err != nil {
+		t.Fatalf("failed to write fake CLI: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, string(AgentTypeDevInfra)+".md"), []byte("Provision the ticket"), 0o600); err != nil {
+		t.Fatalf("failed to write prompt: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, string(AgentTypeQA)+".md"), []byte("Review the ticket"), 0o600); err != nil {
+		t.Fatalf("failed to write prompt: %v", err)
+	}
+
+	s := NewSpawner(dir, time.Minute, false, "")
+	s.claudePath = claude
+	s.SetCommandPolicies(map[string]CommandPolicy{
+		"dev": {Allow: []string{"terraform plan"}, Deny: []string{"terraform destroy"}},
+	})
+
+	result, err := s.SpawnAgent(context.Background(), AgentTypeDevInfra, PromptData{}, dir)
+	if err != nil {
+		t.Fatalf("SpawnAgent failed: %v", err)
+	}
+	args := strings.Split(strings.TrimSpace(result.Output), "\n")
+	if containsArg(args, "--dangerously-skip-permissions") {
+		t.Errorf("Expected a policy-restricted agent not to skip permissions, got %v", args)
+	}
+	if !containsArg(args, "Read,Edit,MultiEdit,Write,Glob,Grep,LS,TodoWrite,Bash(terraform plan:*)") {
+		t.Errorf("Expected only file tools and allowed commands, got %v", args)
+	}
+	if !containsArg(args, "Bash(terraform destroy:*)") {
+		t.Errorf("Expected denied commands to be disallowed, got %v", args)
+	}
+
+	// Agents without a policy keep the unrestricted launch
+	result, err = s.SpawnAgent(context.Background(), AgentTypeQA, PromptData{}, dir)
+	if err != nil {
+		t.Fatalf("SpawnAgent failed: %v", err)
+	}
+	if !strings.Contains(result.Output, "--dangerously-skip-permissions") {
+		t.Errorf("Expected unrestricted QA launch, got %q", result.Output)
+	}
+}
+
+func TestValidateAgentEnvironmentWarnsOnUnsandboxedInfra(t *testing.T) {
+	s := NewSpawner(t.TempDir(), time.Minute, false, "")
+
+	hasWarning := func() bool {
+		for _, e := range s.ValidateAgentEnvironment() {
+			if strings.Contains(e, "dev-infra") {
+				return true
+			}
+		}
+		return false
+	}
+	if !hasWarning() {
+		t.Error("Expected a warning for infra agents without a command policy")
+	}
+
+	s.SetCommandPolicies(map[string]CommandPolicy{"dev-infra": {Allow: []string{"terraform plan"}}})
+	if hasWarning() {
+		t.Error("Expected no warning once infra agents have a command policy")
+	}
+}
+
+func containsArg(args []string, want string) bool {
+	for _, a := range args {
+		if a == want {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,83 @@
+package agents
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os/exec"
+	"regexp"
+	"strconv"
+
+	"github.com/madhatter5501/Factory/agents/provider"
+)
+
+// FailureCategory says why an agent run failed, so the orchestrator can decide
+// whether to retry it, send the ticket back or block it.
+type FailureCategory string
+
+const (
+	// FailureProviderTransient is a rate limit, server error or dropped
+	// connection from the model provider; the same run may well succeed later.
+	FailureProviderTransient FailureCategory = "provider_transient"
+	// FailureTimeout is a run that exceeded the agent timeout.
+	FailureTimeout FailureCategory = "timeout"
+	// FailureTaskFailure is an agent that ran but didn't complete its task,
+	// such as a review that found bugs.
+	FailureTaskFailure FailureCategory = "task_failure"
+	// FailureSetupError is a run that couldn't start: a missing CLI or
+	// provider key, a rejected credential or a prompt that failed to render.
+	FailureSetupError FailureCategory = "setup_error"
+)
+
+// Retryable reports whether a run failing this way is worth retrying as is.
+func (c FailureCategory) Retryable() bool {
+	return c == FailureProviderTransient || c == FailureTimeout
+}
+
+// apiStatusRe extracts the HTTP status from provider API errors.
+var apiStatusRe = regexp.MustCompile(`\(status (\d{3})\)`)
+
+// ClassifyFailure categorizes a failed run from the spawn error and the
+// result, either of which may be nil. Successful runs have no category.
+func ClassifyFailure(err error, result *AgentResult) FailureCategory {
+	if err == nil && (result == nil || result.Success) {
+		return ""
+	}
+	if result != nil && result.FailureCategory != "" {
+		return result.FailureCategory
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) || (result != nil && result.ExitReason == "timeout") {
+		return FailureTimeout
+	}
+	var notAvailable provider.ErrProviderNotAvailable
+	if errors.As(err, &notAvailable) || errors.Is(err, exec.ErrNotFound) {
+		return FailureSetupError
+	}
+
+	var message string
+	if err != nil {
+		message = err.Error()
+	}
+	if result != nil {
+		message += "\n" + result.Error
+	}
+	if m := apiStatusRe.FindStringSubmatch(message); m != nil {
+		status, _ := strconv.Atoi(m[1])
+		switch {
+		case status == 429 || status >= 500:
+			return FailureProviderTransient
+		case status == 401 || status == 403:
+			return FailureSetupError
+		}
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		if netErr.Timeout() {
+			return FailureTimeout
+		}
+		return FailureProviderTransient
+	}
+	return FailureTaskFailure
+}
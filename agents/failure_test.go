@@ -0,0 +1,75 @@
+package agents
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/madhatter5501/Factory/agents/provider"
+	"github.com/madhatter5501/Factory/kanban"
+)
+
+// timeoutError is a network error that timed out.
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func TestClassifyFailure(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		result *AgentResult
+		want   FailureCategory
+	}{
+		{"success", nil, &AgentResult{Success: true}, ""},
+		{"rate limited", errors.New("API error (status 429): slow down"), nil, FailureProviderTransient},
+		{"overloaded", nil, &AgentResult{Error: "API call failed: API error (status 529): overloaded"}, FailureProviderTransient},
+		{"server error", fmt.Errorf("call failed: %w", errors.New("OpenAI API error (status 502): bad gateway")), nil, FailureProviderTransient},
+		{"connection timeout", timeoutError{}, nil, FailureTimeout},
+		{"deadline", fmt.Errorf("API call: %w", context.DeadlineExceeded), nil, FailureTimeout},
+		{"CLI timed out", errors.New("signal: killed"), &AgentResult{Error: "", RunMetadata: kanban.RunMetadata{ExitReason: "timeout"}}, FailureTimeout},
+		{"missing provider key", provider.ErrProviderNotAvailable("openai"), nil, FailureSetupError},
+		{"missing CLI", &exec.Error{Name: "claude", Err: exec.ErrNotFound}, nil, FailureSetupError},
+		{"rejected key", errors.New("API error (status 401): invalid x-api-key"), nil, FailureSetupError},
+		{"bad request", errors.New("API error (status 400): prompt too long"), nil, FailureTaskFailure},
+		{"agent gave up", errors.New("exit status 1"), &AgentResult{Error: "tests still failing", ExitCode: 1}, FailureTaskFailure},
+		{"review found bugs", nil, &AgentResult{Output: `{"status": "failed"}`}, FailureTaskFailure},
+		{"already classified", errors.New("template: no such agent"), &AgentResult{FailureCategory: FailureSetupError}, FailureSetupError},
+	}
+	for _, tt := range tests {
+		if got := ClassifyFailure(tt.err, tt.result); got != tt.want {
+			t.Errorf("%s: ClassifyFailure() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCLIRejectsNonAnthropicOverride(t *testing.T) {
+	spawner := NewSpawner(t.TempDir(), time.Minute, false, "")
+	ticket := &kanban.Ticket{ID: "T-1", ProviderOverride: &kanban.ProviderOverride{Provider: "openai"}}
+
+	result, err := spawner.SpawnAgent(context.Background(), AgentTypeDevBackend, PromptData{Ticket: ticket}, t.TempDir())
+	if err == nil || !strings.Contains(err.Error(), "openai") {
+		t.Fatalf("Expected the openai override to be rejected, got %v", err)
+	}
+	if result.FailureCategory != FailureSetupError || result.TicketID != "T-1" {
+		t.Errorf("Expected a setup error for T-1, got %+v", result)
+	}
+}
+
+func TestPromptRenderFailureIsSetupError(t *testing.T) {
+	spawner := NewSpawner(t.TempDir(), time.Minute, false, "")
+
+	result, err := spawner.SpawnAgent(context.Background(), AgentTypeQA, PromptData{}, t.TempDir())
+	if err == nil {
+		t.Fatal("Expected a missing prompt template to fail the spawn")
+	}
+	if result.FailureCategory != FailureSetupError {
+		t.Errorf("Expected %s, got %q", FailureSetupError, result.FailureCategory)
+	}
+}
@@ -0,0 +1,87 @@
+package agents
+
+import "context"
+
+// AgentLimiter caps how many agents are in flight at once across everything
+// sharing it: pipeline agents, background agents, and chat. A nil limiter
+// never blocks.
+type AgentLimiter struct {
+	slots chan struct{}
+}
+
+// NewAgentLimiter creates a limiter allowing max agents at once, or nil
+// (unlimited) when max is not positive.
+func NewAgentLimiter(max int) *AgentLimiter {
+	if max <= 0 {
+		return nil
+	}
+	return &AgentLimiter{slots: make(chan struct{}, max)}
+}
+
+// Acquire waits for a free slot, returning the context's error if it is done
+// first. Every successful Acquire must be paired with a Release.
+func (l *AgentLimiter) Acquire(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+	select {
+	case l.slots <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Release frees a slot taken by Acquire.
+func (l *AgentLimiter) Release() {
+	if l == nil {
+		return
+	}
+	<-l.slots
+}
+
+// InFlight returns the number of agents currently holding a slot.
+func (l *AgentLimiter) InFlight() int {
+	if l == nil {
+		return 0
+	}
+	return len(l.slots)
+}
+
+// Max returns the ceiling, or 0 when unlimited.
+func (l *AgentLimiter) Max() int {
+	if l == nil {
+		return 0
+	}
+	return cap(l.slots)
+}
+
+// LimitedSpawner wraps an AgentSpawner so every spawn holds a limiter slot
+// for as long as the agent runs.
+type LimitedSpawner struct {
+	inner   AgentSpawner
+	limiter *AgentLimiter
+}
+
+// NewLimitedSpawner creates a spawner wrapper that waits for a free slot
+// before each spawn.
+func NewLimitedSpawner(inner AgentSpawner, limiter *AgentLimiter) *LimitedSpawner {
+	return &LimitedSpawner{
+		inner:   inner,
+		limiter: limiter,
+	}
+}
+
+// SpawnAgent waits for a slot, then runs the agent.
+func (s *LimitedSpawner) SpawnAgent(ctx context.Context, agentType AgentType, data PromptData, workDir string) (*AgentResult, error) {
+	if err := s.limiter.Acquire(ctx); err != nil {
+		return nil, err
+	}
+	defer s.limiter.Release()
+	return s.inner.SpawnAgent(ctx, agentType, data, workDir)
+}
+
+// ValidateAgentEnvironment checks the wrapped spawner's environment.
+func (s *LimitedSpawner) ValidateAgentEnvironment() []string {
+	return s.inner.ValidateAgentEnvironment()
+}
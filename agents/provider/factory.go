@@ -9,12 +9,14 @@ import (
 type Factory struct {
 	mu        sync.RWMutex
 	providers map[string]Provider
+	health    map[string]Health // Last key check per provider
 }
 
 // NewFactory creates a new provider factory.
 func NewFactory() *Factory {
 	return &Factory{
 		providers: make(map[string]Provider),
+		health:    make(map[string]Health),
 	}
 }
 
@@ -58,6 +60,14 @@ func (f *Factory) GetProvider(name string) (Provider, error) {
 	return p, nil
 }
 
+// Register adds a provider under its name, replacing any cached instance.
+func (f *Factory) Register(p Provider) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.providers[p.Name()] = p
+	delete(f.health, p.Name())
+}
+
 // GetAvailableProviders returns info about all providers with availability status.
 func (f *Factory) GetAvailableProviders() []ProviderInfo {
 	providers := AllProviders()
@@ -0,0 +1,118 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// HealthStatus is the outcome of validating a provider's API key.
+type HealthStatus string
+
+// Provider health statuses.
+const (
+	HealthReachable     HealthStatus = "reachable"
+	HealthUnauthorized  HealthStatus = "unauthorized"
+	HealthRateLimited   HealthStatus = "rate_limited"
+	HealthUnreachable   HealthStatus = "unreachable"
+	HealthNotConfigured HealthStatus = "not_configured"
+)
+
+// healthCheckTimeout bounds a single validation request.
+const healthCheckTimeout = 15 * time.Second
+
+// Health is the result of a provider key check. It never includes the key.
+type Health struct {
+	Provider   string       `json:"provider"`
+	Status     HealthStatus `json:"status"`
+	HTTPStatus int          `json:"http_status,omitempty"` // Status code of a failed check, when known
+	CheckedAt  time.Time    `json:"checked_at"`
+}
+
+// statusCodeRe extracts the HTTP status from provider API errors.
+var statusCodeRe = regexp.MustCompile(`\(status (\d{3})\)`)
+
+// CheckHealth validates a provider's key with a 1-token request to its default model.
+func CheckHealth(ctx context.Context, p Provider) Health {
+	health := Health{Provider: p.Name(), CheckedAt: time.Now()}
+	if !p.Available() {
+		health.Status = HealthNotConfigured
+		return health
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+
+	_, err := p.CreateMessage(ctx, &MessageRequest{
+		Model:     DefaultModel(p.Name()),
+		MaxTokens: 1,
+		Messages:  []Message{{Role: "user", Content: "ping"}},
+	})
+	if err == nil {
+		health.Status = HealthReachable
+		return health
+	}
+
+	health.Status = HealthUnreachable
+	if m := statusCodeRe.FindStringSubmatch(err.Error()); m != nil {
+		health.HTTPStatus, _ = strconv.Atoi(m[1])
+	}
+	switch health.HTTPStatus {
+	case 401, 403:
+		health.Status = HealthUnauthorized
+	case 429:
+		health.Status = HealthRateLimited
+	}
+	return health
+}
+
+// ProviderHealth checks every supported provider, reusing results younger than
+// maxAge so repeated polling doesn't spend quota.
+func (f *Factory) ProviderHealth(ctx context.Context, maxAge time.Duration) []Health {
+	providers := AllProviders()
+	results := make([]Health, 0, len(providers))
+	for _, info := range providers {
+		f.mu.RLock()
+		cached, ok := f.health[info.Name]
+		f.mu.RUnlock()
+		if ok && time.Since(cached.CheckedAt) < maxAge {
+			results = append(results, cached)
+			continue
+		}
+
+		p, err := f.GetProvider(info.Name)
+		if err != nil {
+			results = append(results, Health{Provider: info.Name, Status: HealthNotConfigured, CheckedAt: time.Now()})
+			continue
+		}
+		health := CheckHealth(ctx, p)
+
+		f.mu.Lock()
+		f.health[info.Name] = health
+		f.mu.Unlock()
+		results = append(results, health)
+	}
+	return results
+}
+
+// IsTransientError reports whether a failed provider call is worth retrying:
+// rate limiting, server errors, and failures that never got an HTTP response
+// (timeouts, dropped connections). Auth and request errors, an unconfigured
+// provider and cancellation are not.
+func IsTransientError(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	var notAvailable ErrProviderNotAvailable
+	if errors.As(err, &notAvailable) {
+		return false
+	}
+	m := statusCodeRe.FindStringSubmatch(err.Error())
+	if m == nil {
+		return true
+	}
+	status, _ := strconv.Atoi(m[1])
+	return status == 429 || status >= 500
+}
@@ -0,0 +1,30 @@
+package provider
+
+// ModelPrice is a model's list price in USD per million tokens.
+type ModelPrice struct {
+	Input  float64 `json:"input"`
+	Output float64 `json:"output"`
+}
+
+// ModelPrices holds list prices for the supported models.
+var ModelPrices = map[string]ModelPrice{
+	ModelAnthropicSonnet4:    {Input: 3.00, Output: 15.00},
+	ModelAnthropicHaiku35:    {Input: 0.80, Output: 4.00},
+	ModelAnthropicOpus45:     {Input: 5.00, Output: 25.00},
+	ModelOpenAIGPT4o:         {Input: 2.50, Output: 10.00},
+	ModelOpenAIGPT4:          {Input: 30.00, Output: 60.00},
+	ModelOpenAIGPT35Turbo:    {Input: 0.50, Output: 1.50},
+	ModelGoogleGemini20Flash: {Input: 0.10, Output: 0.40},
+	ModelGoogleGemini15Pro:   {Input: 1.25, Output: 5.00},
+	ModelGoogleGemini15Flash: {Input: 0.075, Output: 0.30},
+}
+
+// EstimateCost returns the estimated USD cost of the given token counts on a model.
+// Models without a known price cost 0.
+func EstimateCost(model string, inputTokens, outputTokens int64) float64 {
+	price, ok := ModelPrices[model]
+	if !ok {
+		return 0
+	}
+	return (float64(inputTokens)*price.Input + float64(outputTokens)*price.Output) / 1_000_000
+}
@@ -78,9 +78,46 @@ type AgentProviderConfig struct {
 	Provider     string    `json:"provider"`                // anthropic, openai, google
 	Model        string    `json:"model"`                   // Model identifier
 	SystemPrompt string    `json:"system_prompt,omitempty"` // Custom system prompt override
+	Temperature  *float64  `json:"temperature,omitempty"`   // Sampling temperature; nil uses the provider default
+	MaxTokens    int       `json:"max_tokens,omitempty"`    // Response token limit; 0 uses the caller's default
 	UpdatedAt    time.Time `json:"updated_at"`
 }
 
+// MaxResponseTokens is the largest max_tokens an agent may be configured with.
+const MaxResponseTokens = 128000
+
+// ValidateGenerationParams checks an agent's temperature and max tokens against
+// the provider's accepted ranges: Anthropic takes temperatures from 0 to 1,
+// OpenAI and Google from 0 to 2.
+func ValidateGenerationParams(providerName string, temperature *float64, maxTokens int) error {
+	if temperature != nil {
+		maxTemperature := 2.0
+		if providerName == "anthropic" {
+			maxTemperature = 1.0
+		}
+		if *temperature < 0 || *temperature > maxTemperature {
+			return fmt.Errorf("temperature must be between 0 and %g for %s", maxTemperature, providerName)
+		}
+	}
+	if maxTokens < 0 || maxTokens > MaxResponseTokens {
+		return fmt.Errorf("max_tokens must be between 1 and %d, or 0 for the default", MaxResponseTokens)
+	}
+	return nil
+}
+
+// GenerationParams returns the temperature and max tokens to request, using
+// defaultMaxTokens when the config doesn't set one. A nil config uses defaults.
+func (c *AgentProviderConfig) GenerationParams(defaultMaxTokens int) (temperature *float64, maxTokens int) {
+	if c == nil {
+		return nil, defaultMaxTokens
+	}
+	maxTokens = c.MaxTokens
+	if maxTokens == 0 {
+		maxTokens = defaultMaxTokens
+	}
+	return c.Temperature, maxTokens
+}
+
 // ProviderInfo describes an available provider and its models.
 type ProviderInfo struct { //nolint:revive // keeping explicit name for clarity alongside ModelInfo
 	Name        string      `json:"name"`
@@ -92,10 +129,11 @@ type ProviderInfo struct { //nolint:revive // keeping explicit name for clarity
 
 // ModelInfo describes an available model.
 type ModelInfo struct {
-	ID          string `json:"id"`          // Model identifier for API
-	Name        string `json:"name"`        // Human-readable name
-	Description string `json:"description"` // Brief description
-	Recommended bool   `json:"recommended"` // Recommended default
+	ID            string `json:"id"`             // Model identifier for API
+	Name          string `json:"name"`           // Human-readable name
+	Description   string `json:"description"`    // Brief description
+	Recommended   bool   `json:"recommended"`    // Recommended default
+	ContextWindow int    `json:"context_window"` // Input plus output tokens per request
 }
 
 // BaseProvider provides common functionality for providers.
@@ -154,6 +192,16 @@ var DefaultModels = map[string]string{
 	"google":    ModelGoogleGemini20Flash,
 }
 
+// DefaultModel returns the built-in default model for a provider, or "" if the provider is unknown.
+func DefaultModel(providerName string) string {
+	return DefaultModels[providerName]
+}
+
+// DefaultModelConfigKey returns the config key that overrides a provider's default model.
+func DefaultModelConfigKey(providerName string) string {
+	return "default_model_" + providerName
+}
+
 // AllProviders returns info about all supported providers.
 func AllProviders() []ProviderInfo {
 	return []ProviderInfo{
@@ -162,9 +210,9 @@ func AllProviders() []ProviderInfo {
 			DisplayName: "Anthropic",
 			EnvVar:      "ANTHROPIC_API_KEY",
 			Models: []ModelInfo{
-				{ID: ModelAnthropicSonnet4, Name: "Sonnet 4", Description: "Best quality/cost balance", Recommended: true},
-				{ID: ModelAnthropicHaiku35, Name: "Haiku 3.5", Description: "Fast and cost-effective"},
-				{ID: ModelAnthropicOpus45, Name: "Opus 4.5", Description: "Most capable"},
+				{ID: ModelAnthropicSonnet4, Name: "Sonnet 4", Description: "Best quality/cost balance", Recommended: true, ContextWindow: 200000},
+				{ID: ModelAnthropicHaiku35, Name: "Haiku 3.5", Description: "Fast and cost-effective", ContextWindow: 200000},
+				{ID: ModelAnthropicOpus45, Name: "Opus 4.5", Description: "Most capable", ContextWindow: 200000},
 			},
 		},
 		{
@@ -172,9 +220,9 @@ func AllProviders() []ProviderInfo {
 			DisplayName: "OpenAI",
 			EnvVar:      "OPENAI_API_KEY",
 			Models: []ModelInfo{
-				{ID: ModelOpenAIGPT4o, Name: "GPT-4o", Description: "Latest multimodal model", Recommended: true},
-				{ID: ModelOpenAIGPT4, Name: "GPT-4", Description: "High capability"},
-				{ID: ModelOpenAIGPT35Turbo, Name: "GPT-3.5 Turbo", Description: "Fast and cost-effective"},
+				{ID: ModelOpenAIGPT4o, Name: "GPT-4o", Description: "Latest multimodal model", Recommended: true, ContextWindow: 128000},
+				{ID: ModelOpenAIGPT4, Name: "GPT-4", Description: "High capability", ContextWindow: 8192},
+				{ID: ModelOpenAIGPT35Turbo, Name: "GPT-3.5 Turbo", Description: "Fast and cost-effective", ContextWindow: 16385},
 			},
 		},
 		{
@@ -182,10 +230,26 @@ func AllProviders() []ProviderInfo {
 			DisplayName: "Google",
 			EnvVar:      "GOOGLE_API_KEY",
 			Models: []ModelInfo{
-				{ID: ModelGoogleGemini20Flash, Name: "Gemini 2.0 Flash", Description: "Latest fast model", Recommended: true},
-				{ID: ModelGoogleGemini15Pro, Name: "Gemini 1.5 Pro", Description: "High capability"},
-				{ID: ModelGoogleGemini15Flash, Name: "Gemini 1.5 Flash", Description: "Fast and efficient"},
+				{ID: ModelGoogleGemini20Flash, Name: "Gemini 2.0 Flash", Description: "Latest fast model", Recommended: true, ContextWindow: 1048576},
+				{ID: ModelGoogleGemini15Pro, Name: "Gemini 1.5 Pro", Description: "High capability", ContextWindow: 2097152},
+				{ID: ModelGoogleGemini15Flash, Name: "Gemini 1.5 Flash", Description: "Fast and efficient", ContextWindow: 1048576},
 			},
 		},
 	}
 }
+
+// DefaultContextWindow is assumed for models without known metadata.
+const DefaultContextWindow = 128000
+
+// ContextWindow returns a model's context window in tokens, or
+// DefaultContextWindow if the model is unknown.
+func ContextWindow(model string) int {
+	for _, p := range AllProviders() {
+		for _, m := range p.Models {
+			if m.ID == model && m.ContextWindow > 0 {
+				return m.ContextWindow
+			}
+		}
+	}
+	return DefaultContextWindow
+}
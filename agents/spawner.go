@@ -74,6 +74,12 @@ type AgentResult struct {
 	Duration  time.Duration `json:"duration"`
 	Error     string        `json:"error,omitempty"`
 	ExitCode  int           `json:"exitCode"`
+
+	// Why the run failed; empty for successful runs
+	FailureCategory FailureCategory `json:"failureCategory,omitempty"`
+
+	// Model, token usage, and exit reason, as far as the spawner knows them
+	kanban.RunMetadata
 }
 
 // Spawner manages spawning and running AI agents.
@@ -83,6 +89,10 @@ type Spawner struct {
 	timeout      time.Duration // Timeout for agent runs
 	verbose      bool          // Print agent output
 	defaultModel string        // Default model (empty uses CLI default)
+
+	// Shell command restrictions by agent type ("dev" covers every dev agent);
+	// agents without one run with permission checks skipped
+	commandPolicies map[string]CommandPolicy
 }
 
 // NewSpawner creates a new agent spawner.
@@ -108,6 +118,12 @@ func NewSpawner(promptsDir string, timeout time.Duration, verbose bool, model st
 	}
 }
 
+// SetCommandPolicies restricts the shell commands agents may run, keyed by
+// agent type with "dev" covering every dev agent.
+func (s *Spawner) SetCommandPolicies(policies map[string]CommandPolicy) {
+	s.commandPolicies = policies
+}
+
 // PromptData contains data passed to prompt templates.
 type PromptData struct {
 	Ticket       *kanban.Ticket        `json:"ticket"`
@@ -131,12 +147,22 @@ type PromptData struct {
 	Domain       string `json:"domain,omitempty"`
 	ExtraContext string `json:"extraContext,omitempty"`
 
+	// For targeted re-review: bugs this reviewer found earlier that dev has since fixed
+	BugsToVerify []kanban.Bug `json:"bugsToVerify,omitempty"`
+
+	// For resuming dev work: progress left in the worktree by an earlier, unfinished run
+	ResumeContext string `json:"resumeContext,omitempty"`
+
+	// For dev agents: indexed code relevant to the ticket, retrieved from the RAG index
+	CodeContext string `json:"codeContext,omitempty"`
+
 	// For collaborative PRD discussion
 	Conversation        *kanban.PRDConversation       `json:"conversation,omitempty"`
 	CurrentRound        int                           `json:"currentRound,omitempty"`
 	CurrentPrompt       string                        `json:"currentPrompt,omitempty"`
 	Agent               string                        `json:"agent,omitempty"`               // dev, qa, ux, security
 	FocusAreas          []string                      `json:"focusAreas,omitempty"`          // Specific questions for this agent
+	PRDTemplate         []string                      `json:"prdTemplate,omitempty"`         // Sections the final PRD must contain
 	ConversationSummary string                        `json:"conversationSummary,omitempty"` // Summary for breakdown
 	PRD                 string                        `json:"prd,omitempty"`                 // Final PRD for breakdown
 	FinalExpertInputs   map[string]kanban.ExpertInput `json:"finalExpertInputs,omitempty"`   // Last round's expert inputs
@@ -144,19 +170,44 @@ type PromptData struct {
 	// RAG-retrieved context (API mode only)
 	RetrievedPatterns string `json:"retrievedPatterns,omitempty"` // Relevant code patterns
 	RetrievedHistory  string `json:"retrievedHistory,omitempty"`  // Relevant conversation history
+
+	// Run correlation (not rendered into prompts)
+	RunID    string                                `json:"-"` // Orchestrator run ID, used to correlate audit entries
+	OnPrompt func(systemPrompt, userPrompt string) `json:"-"` // Called with the exact prompt before it is sent
+}
+
+// reportPrompt notifies the OnPrompt hook, if any, of the prompt about to be sent.
+func (d PromptData) reportPrompt(systemPrompt, userPrompt string) {
+	if d.OnPrompt != nil {
+		d.OnPrompt(systemPrompt, userPrompt)
+	}
 }
 
-// SpawnAgent runs an agent with the given configuration.
+// SpawnAgent runs an agent with the given configuration. The CLI only runs
+// Anthropic models, so a ticket pinned to another provider fails as a setup
+// error rather than silently running on Anthropic.
 func (s *Spawner) SpawnAgent(ctx context.Context, agentType AgentType, data PromptData, workDir string) (*AgentResult, error) {
 	startTime := time.Now()
 
+	if t := data.Ticket; t != nil && t.ProviderOverride != nil && t.ProviderOverride.Provider != "anthropic" {
+		err := fmt.Errorf("ticket %s is pinned to provider %s, which CLI mode can't run; use API mode or clear the override", t.ID, t.ProviderOverride.Provider)
+		return &AgentResult{
+			Success:         false,
+			AgentType:       agentType,
+			TicketID:        t.ID,
+			Error:           err.Error(),
+			FailureCategory: FailureSetupError,
+		}, err
+	}
+
 	// Load and render prompt template
 	prompt, err := s.renderPrompt(agentType, data)
 	if err != nil {
 		return &AgentResult{
-			Success:   false,
-			AgentType: agentType,
-			Error:     fmt.Sprintf("failed to render prompt: %v", err),
+			Success:         false,
+			AgentType:       agentType,
+			Error:           fmt.Sprintf("failed to render prompt: %v", err),
+			FailureCategory: FailureSetupError,
 		}, err
 	}
 
@@ -164,13 +215,26 @@ func (s *Spawner) SpawnAgent(ctx context.Context, agentType AgentType, data Prom
 	ctx, cancel := context.WithTimeout(ctx, s.timeout)
 	defer cancel()
 
-	// Get appropriate model for this agent type
+	// Get appropriate model for this agent type, or the ticket's Anthropic override
 	model := GetModelForAgent(agentType, s.defaultModel)
+	if t := data.Ticket; t != nil && t.ProviderOverride != nil && t.ProviderOverride.Model != "" {
+		model = t.ProviderOverride.Model
+	}
 
-	// Run claude CLI with model selection
-	result, err := s.runClaude(ctx, prompt, workDir, model)
+	// The CLI receives the rendered template on stdin; it is the agent's effective system prompt
+	data.reportPrompt(prompt, "")
+
+	// Run claude CLI with model selection and the agent's command restrictions
+	var policy *CommandPolicy
+	if p, ok := CommandPolicyFor(s.commandPolicies, agentType); ok {
+		policy = &p
+	}
+	result, err := s.runClaude(ctx, prompt, workDir, model, policy)
 	result.AgentType = agentType
 	result.Duration = time.Since(startTime)
+	result.Model = model
+	result.ExitReason = cliExitReason(ctx, result, err)
+	result.FailureCategory = ClassifyFailure(err, result)
 
 	if data.Ticket != nil {
 		result.TicketID = data.Ticket.ID
@@ -179,12 +243,17 @@ func (s *Spawner) SpawnAgent(ctx context.Context, agentType AgentType, data Prom
 	return result, err
 }
 
-// runClaude executes the claude CLI with the given prompt.
-func (s *Spawner) runClaude(ctx context.Context, prompt string, workDir string, model string) (*AgentResult, error) {
+// runClaude executes the claude CLI with the given prompt. A nil policy skips
+// permission checks entirely.
+func (s *Spawner) runClaude(ctx context.Context, prompt string, workDir string, model string, policy *CommandPolicy) (*AgentResult, error) {
 	// Build command args
 	args := []string{
-		"--print",                        // Print output instead of interactive
-		"--dangerously-skip-permissions", // Skip permission prompts for automation
+		"--print", // Print output instead of interactive
+	}
+	if policy != nil {
+		args = append(args, policy.cliArgs()...)
+	} else {
+		args = append(args, "--dangerously-skip-permissions") // Skip permission prompts for automation
 	}
 
 	// Add model flag if specified (prevents inheriting expensive CLI defaults like Opus)
@@ -231,6 +300,21 @@ func (s *Spawner) runClaude(ctx context.Context, prompt string, workDir string,
 	return result, err
 }
 
+// cliExitReason describes why a CLI agent stopped.
+func cliExitReason(ctx context.Context, result *AgentResult, err error) string {
+	switch {
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		return "timeout"
+	case ctx.Err() != nil:
+		return "cancelled"
+	case err == nil:
+		return "completed"
+	case result.ExitCode != 0:
+		return fmt.Sprintf("exit_code_%d", result.ExitCode)
+	}
+	return "error"
+}
+
 // templateFuncs provides custom functions for prompt templates.
 var templateFuncs = template.FuncMap{
 	"title": cases.Title(language.English).String, // Title cases a string (e.g., "dev" -> "Dev").
@@ -366,5 +450,10 @@ func (s *Spawner) ValidateAgentEnvironment() []string {
 		}
 	}
 
+	// Infra agents run shell commands against real environments
+	if _, ok := CommandPolicyFor(s.commandPolicies, AgentTypeDevInfra); !ok {
+		errors = append(errors, "dev-infra agents run shell commands unrestricted: configure a command policy (command_policies) to sandbox them")
+	}
+
 	return errors
 }
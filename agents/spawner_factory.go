@@ -53,9 +53,15 @@ type SpawnerConfig struct {
 	RAGEnabled   bool   `json:"rag_enabled"`
 	VectorDBPath string `json:"vector_db_path,omitempty"`
 
+	// API mode prompt size limits in tokens by model, overriding the model's context window
+	ContextBudgets map[string]int `json:"context_budgets,omitempty"`
+
 	// Indexing settings
 	IndexOnStartup bool     `json:"index_on_startup"`
 	IndexPatterns  []string `json:"index_patterns,omitempty"`
+
+	// CLI mode shell command restrictions by agent type ("dev" covers every dev agent)
+	CommandPolicies map[string]CommandPolicy `json:"command_policies,omitempty"`
 }
 
 // DefaultSpawnerConfig returns a default configuration.
@@ -118,6 +124,8 @@ func (f *SpawnerFactory) createAPISpawner() (*APISpawner, error) {
 		Model:        f.config.Model,
 		RAGEnabled:   f.config.RAGEnabled,
 		VectorDBPath: f.config.VectorDBPath,
+
+		ContextBudgets: f.config.ContextBudgets,
 	}
 
 	spawner, err := NewAPISpawner(cfg)
@@ -172,7 +180,9 @@ func (f *SpawnerFactory) indexExpertPrompts(spawner *APISpawner) error {
 
 // createCLISpawner creates a CLI-based spawner.
 func (f *SpawnerFactory) createCLISpawner() (*Spawner, error) {
-	return NewSpawner(f.config.PromptsDir, f.config.Timeout, f.config.Verbose, f.config.Model), nil
+	spawner := NewSpawner(f.config.PromptsDir, f.config.Timeout, f.config.Verbose, f.config.Model)
+	spawner.SetCommandPolicies(f.config.CommandPolicies)
+	return spawner, nil
 }
 
 // GetMode returns the current mode.
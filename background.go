@@ -5,9 +5,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/madhatter5501/Factory/internal/notify"
 	"github.com/madhatter5501/Factory/kanban"
 )
 
@@ -15,10 +17,11 @@ import (
 type BackgroundAgentType string
 
 const (
-	BackgroundPM       BackgroundAgentType = "PM"
-	BackgroundSecurity BackgroundAgentType = "Security"
-	BackgroundGatherer BackgroundAgentType = "Gatherer"
-	BackgroundWorktree BackgroundAgentType = "Worktree"
+	BackgroundPM        BackgroundAgentType = "PM"
+	BackgroundSecurity  BackgroundAgentType = "Security"
+	BackgroundGatherer  BackgroundAgentType = "Gatherer"
+	BackgroundWorktree  BackgroundAgentType = "Worktree"
+	BackgroundRetention BackgroundAgentType = "Retention"
 )
 
 // BackgroundAgentStatus represents the current state of a background agent.
@@ -33,6 +36,7 @@ type BackgroundAgentStatus struct {
 // BackgroundAgentManager manages always-running background agents.
 type BackgroundAgentManager struct {
 	orchestrator *Orchestrator
+	merger       branchMerger // Performs merge queue merges; the orchestrator's worktree manager
 	agents       map[BackgroundAgentType]*backgroundAgent
 	mu           sync.RWMutex
 	stopCh       chan struct{}
@@ -50,6 +54,7 @@ type backgroundAgent struct {
 func NewBackgroundAgentManager(o *Orchestrator) *BackgroundAgentManager {
 	m := &BackgroundAgentManager{
 		orchestrator: o,
+		merger:       o.worktree,
 		agents:       make(map[BackgroundAgentType]*backgroundAgent),
 		stopCh:       make(chan struct{}),
 	}
@@ -59,6 +64,7 @@ func NewBackgroundAgentManager(o *Orchestrator) *BackgroundAgentManager {
 	m.registerAgent(BackgroundSecurity, 2*time.Minute, m.runSecurityBackground)
 	m.registerAgent(BackgroundGatherer, 5*time.Minute, m.runGathererBackground)
 	m.registerAgent(BackgroundWorktree, 30*time.Second, m.runWorktreeBackground)
+	m.registerAgent(BackgroundRetention, time.Hour, m.runRetentionBackground)
 
 	return m
 }
@@ -135,6 +141,12 @@ func (m *BackgroundAgentManager) runAgentLoop(ctx context.Context, agent *backgr
 }
 
 func (m *BackgroundAgentManager) executeAgentCycle(ctx context.Context, agent *backgroundAgent) {
+	// Background agents count toward the global agent ceiling like any other
+	if err := m.orchestrator.limiter.Acquire(ctx); err != nil {
+		return
+	}
+	defer m.orchestrator.limiter.Release()
+
 	m.updateAgentStatus(agent, "Running", "Starting cycle")
 
 	if err := agent.runFunc(ctx); err != nil {
@@ -189,6 +201,13 @@ func (m *BackgroundAgentManager) runPMBackground(ctx context.Context) error {
 	// Perform PM check-ins on IN_DEV tickets
 	m.performPMCheckins(ctx, state)
 
+	// Defer tickets left waiting on the user past the configured window, and
+	// escalate tickets blocked on something the system can't resolve
+	if checkinStore, ok := state.(PMCheckinStore); ok {
+		m.deferIdleAwaitingUser(checkinStore, state, getAwaitingUserPolicy(checkinStore))
+		m.escalateLongBlocked(checkinStore, state, getBlockedEscalationPolicy(checkinStore))
+	}
+
 	// Check iteration progress
 	if stats[kanban.StatusDone] > 0 {
 		total := stats[kanban.StatusReady] + stats[kanban.StatusInDev] +
@@ -238,10 +257,10 @@ func (m *BackgroundAgentManager) performPMCheckins(ctx context.Context, state ka
 	}
 
 	// Get all tickets in active development stages
-	inDevTickets := state.GetTicketsByStatus(kanban.StatusInDev)
-	inQATickets := state.GetTicketsByStatus(kanban.StatusInQA)
-	inUXTickets := state.GetTicketsByStatus(kanban.StatusInUX)
-	inSecTickets := state.GetTicketsByStatus(kanban.StatusInSec)
+	inDevTickets := unpaused(state.GetTicketsByStatus(kanban.StatusInDev))
+	inQATickets := unpaused(state.GetTicketsByStatus(kanban.StatusInQA))
+	inUXTickets := unpaused(state.GetTicketsByStatus(kanban.StatusInUX))
+	inSecTickets := unpaused(state.GetTicketsByStatus(kanban.StatusInSec))
 
 	// Combine all active tickets
 	activeTickets := append(inDevTickets, inQATickets...)
@@ -440,6 +459,195 @@ func (m *BackgroundAgentManager) createCheckinConversation(
 	return convID
 }
 
+// AwaitingUserPolicy controls auto-deferral of tickets left idle in AWAITING_USER.
+type AwaitingUserPolicy struct {
+	IdleWindow time.Duration // Idle time before the final nudge (0 disables the policy)
+	NudgeGrace time.Duration // Time after the nudge before the ticket is deferred
+	Target     kanban.Status // BACKLOG or ICEBOX
+}
+
+// getAwaitingUserPolicy reads the auto-defer policy from config. It is opt-in:
+// without awaiting_user_idle_hours the policy is disabled.
+func getAwaitingUserPolicy(store PMCheckinStore) AwaitingUserPolicy {
+	policy := AwaitingUserPolicy{
+		NudgeGrace: 24 * time.Hour,
+		Target:     kanban.StatusBacklog,
+	}
+
+	if v, err := store.GetConfigValue("awaiting_user_idle_hours"); err == nil && v != "" {
+		if hours, err := strconv.Atoi(v); err == nil && hours > 0 {
+			policy.IdleWindow = time.Duration(hours) * time.Hour
+		}
+	}
+	if v, err := store.GetConfigValue("awaiting_user_nudge_grace_hours"); err == nil && v != "" {
+		if hours, err := strconv.Atoi(v); err == nil && hours >= 0 {
+			policy.NudgeGrace = time.Duration(hours) * time.Hour
+		}
+	}
+	if v, err := store.GetConfigValue("awaiting_user_defer_status"); err == nil && v != "" {
+		if status := kanban.Status(strings.ToUpper(v)); status == kanban.StatusIcebox || status == kanban.StatusBacklog {
+			policy.Target = status
+		}
+	}
+
+	return policy
+}
+
+// deferIdleAwaitingUser handles AWAITING_USER tickets the user has not touched
+// within the policy's idle window. The PM first posts a final nudge check-in; if
+// the ticket is still untouched once the grace period after the nudge passes,
+// it is moved to the policy's target status.
+func (m *BackgroundAgentManager) deferIdleAwaitingUser(checkinStore PMCheckinStore, state kanban.StateStore, policy AwaitingUserPolicy) {
+	if policy.IdleWindow <= 0 {
+		return
+	}
+
+	for _, ticket := range unpaused(state.GetTicketsByStatus(kanban.StatusAwaitingUser)) {
+		if time.Since(ticket.UpdatedAt) < policy.IdleWindow {
+			continue
+		}
+
+		// A nudge only counts if the ticket has not been touched since it was sent
+		lastCheckin, _ := checkinStore.GetLastPMCheckin(ticket.ID)
+		nudged := lastCheckin != nil && lastCheckin.CheckinType == kanban.CheckinTypeGuidance &&
+			lastCheckin.CreatedAt.After(ticket.UpdatedAt)
+
+		if !nudged {
+			m.nudgeAwaitingUser(checkinStore, &ticket, policy)
+			continue
+		}
+		if time.Since(lastCheckin.CreatedAt) < policy.NudgeGrace {
+			continue
+		}
+
+		note := fmt.Sprintf("Auto-deferred to %s after %.0f hours waiting on the user", policy.Target,
+			time.Since(ticket.UpdatedAt).Hours())
+		if err := state.UpdateTicketStatus(ticket.ID, policy.Target, "PM", note); err != nil {
+			m.orchestrator.logger.Error("Failed to defer idle ticket", "ticket", ticket.ID, "error", err)
+			continue
+		}
+		m.orchestrator.logger.Info("PM deferred idle awaiting-user ticket", "ticket", ticket.ID, "target", policy.Target)
+	}
+}
+
+// nudgeAwaitingUser records the final PM check-in asking the user to respond
+// before an idle ticket is deferred.
+func (m *BackgroundAgentManager) nudgeAwaitingUser(checkinStore PMCheckinStore, ticket *kanban.Ticket, policy AwaitingUserPolicy) {
+	checkin := &kanban.PMCheckin{
+		ID:          fmt.Sprintf("checkin-%s-%d", ticket.ID, time.Now().Unix()),
+		TicketID:    ticket.ID,
+		CheckinType: kanban.CheckinTypeGuidance,
+		Summary: fmt.Sprintf("Ticket %s has been waiting on you since %s", ticket.ID,
+			ticket.UpdatedAt.Format("2006-01-02")),
+		ActionRequired: fmt.Sprintf("Review and confirm the requirements, or the ticket moves to %s in %.0f hours",
+			policy.Target, policy.NudgeGrace.Hours()),
+		CreatedAt: time.Now(),
+	}
+	checkin.ConversationID = m.createCheckinConversation(checkinStore, ticket, checkin)
+
+	if err := checkinStore.AddPMCheckin(checkin); err != nil {
+		m.orchestrator.logger.Error("Failed to record awaiting-user nudge", "ticket", ticket.ID, "error", err)
+		return
+	}
+	m.orchestrator.logger.Info("PM nudged idle awaiting-user ticket", "ticket", ticket.ID)
+}
+
+// BlockedEscalationPolicy controls escalation of tickets left blocked on
+// reasons the system is not addressing itself.
+type BlockedEscalationPolicy struct {
+	Threshold time.Duration // Time blocked before escalating (0 disables the policy)
+	Target    string        // Who the notification is for; empty for all operators
+}
+
+// getBlockedEscalationPolicy reads the escalation policy from config. It is
+// opt-in: without blocked_escalation_hours the policy is disabled.
+func getBlockedEscalationPolicy(store PMCheckinStore) BlockedEscalationPolicy {
+	var policy BlockedEscalationPolicy
+	if v, err := store.GetConfigValue("blocked_escalation_hours"); err == nil && v != "" {
+		if hours, err := strconv.Atoi(v); err == nil && hours > 0 {
+			policy.Threshold = time.Duration(hours) * time.Hour
+		}
+	}
+	if v, err := store.GetConfigValue("blocked_escalation_target"); err == nil {
+		policy.Target = strings.TrimSpace(v)
+	}
+	return policy
+}
+
+// escalateLongBlocked notifies operators about tickets blocked longer than the
+// policy's threshold on a reason the system isn't managing, and records a
+// blocker check-in for each. A ticket is escalated once per time it is blocked.
+func (m *BackgroundAgentManager) escalateLongBlocked(checkinStore PMCheckinStore, state kanban.StateStore, policy BlockedEscalationPolicy) {
+	if policy.Threshold <= 0 {
+		return
+	}
+
+	blocked := unpaused(state.GetTicketsByStatus(kanban.StatusBlocked))
+	if len(blocked) == 0 {
+		return
+	}
+	allTickets, _ := state.GetAllTickets()
+
+	for _, ticket := range blocked {
+		since := blockedSince(&ticket)
+		if time.Since(since) < policy.Threshold {
+			continue
+		}
+
+		reason := ticket.BlockedReason
+		if reason == nil {
+			reason = ticket.ComputeBlockedReason(allTickets)
+		}
+		if reason == nil || reason.IsManaged {
+			continue
+		}
+
+		// Already escalated since the ticket was blocked
+		lastCheckin, _ := checkinStore.GetLastPMCheckin(ticket.ID)
+		if lastCheckin != nil && lastCheckin.CheckinType == kanban.CheckinTypeBlocker &&
+			lastCheckin.CreatedAt.After(since) {
+			continue
+		}
+
+		hours := time.Since(since).Hours()
+		checkin := &kanban.PMCheckin{
+			ID:             fmt.Sprintf("checkin-%s-%d", ticket.ID, time.Now().Unix()),
+			TicketID:       ticket.ID,
+			CheckinType:    kanban.CheckinTypeBlocker,
+			Summary:        fmt.Sprintf("Ticket %s has been blocked for %.0f hours: %s", ticket.ID, hours, reason.Summary),
+			Findings:       &kanban.PMCheckinFindings{Blockers: []string{reason.Summary}},
+			ActionRequired: "Resolve the blocker; the system is not addressing it",
+			CreatedAt:      time.Now(),
+		}
+		checkin.ConversationID = m.createCheckinConversation(checkinStore, &ticket, checkin)
+
+		if err := checkinStore.AddPMCheckin(checkin); err != nil {
+			m.orchestrator.logger.Error("Failed to record blocked escalation", "ticket", ticket.ID, "error", err)
+			continue
+		}
+		m.orchestrator.notify(notify.Notification{
+			Event:    "ticket:blocked-escalation",
+			Title:    fmt.Sprintf("%s blocked for %.0f hours", ticket.ID, hours),
+			Message:  fmt.Sprintf("%s: %s", ticket.Title, reason.Summary),
+			TicketID: ticket.ID,
+			Target:   policy.Target,
+			At:       checkin.CreatedAt,
+		})
+		m.orchestrator.logger.Info("PM escalated long-blocked ticket", "ticket", ticket.ID, "category", reason.Category)
+	}
+}
+
+// blockedSince returns when a blocked ticket entered BLOCKED, falling back to
+// its last update when the history doesn't say.
+func blockedSince(ticket *kanban.Ticket) time.Time {
+	for i := len(ticket.History) - 1; i >= 0; i-- {
+		if entry := ticket.History[i]; entry.Status == kanban.StatusBlocked && !entry.At.IsZero() {
+			return entry.At
+		}
+	}
+	return ticket.UpdatedAt
+}
+
 // runSecurityBackground is the Security agent's background work loop.
 // It proactively scans for security issues.
 func (m *BackgroundAgentManager) runSecurityBackground(ctx context.Context) error {
@@ -498,6 +706,30 @@ func (m *BackgroundAgentManager) runGathererBackground(ctx context.Context) erro
 	return nil
 }
 
+// RunPruneStore is the interface for pruning old agent run output.
+type RunPruneStore interface {
+	PruneExpiredRuns() (int, error)
+}
+
+// runRetentionBackground prunes finished agent runs past the configured
+// retention so run output doesn't accumulate forever.
+func (m *BackgroundAgentManager) runRetentionBackground(ctx context.Context) error {
+	pruneStore, ok := m.orchestrator.state.(RunPruneStore)
+	if !ok {
+		return nil
+	}
+
+	m.updateAgentStatus(m.agents[BackgroundRetention], "Running", "Pruning old run output")
+	pruned, err := pruneStore.PruneExpiredRuns()
+	if err != nil {
+		return fmt.Errorf("failed to prune runs: %w", err)
+	}
+	if pruned > 0 {
+		m.orchestrator.logger.Info("Pruned expired agent runs", "runs", pruned)
+	}
+	return nil
+}
+
 // healStuckDevTickets detects tickets stuck in IN_DEV with no active agent and resets them.
 // This handles cases where an agent failed but didn't properly reset the ticket status.
 func (m *BackgroundAgentManager) healStuckDevTickets(state kanban.StateStore, activeRuns []kanban.AgentRun) {
@@ -510,7 +742,7 @@ func (m *BackgroundAgentManager) healStuckDevTickets(state kanban.StateStore, ac
 	}
 
 	// Find IN_DEV tickets without an active running agent
-	inDevTickets := state.GetTicketsByStatus(kanban.StatusInDev)
+	inDevTickets := unpaused(state.GetTicketsByStatus(kanban.StatusInDev))
 	for _, ticket := range inDevTickets {
 		if activeTickets[ticket.ID] {
 			continue // This ticket has an active agent, skip
@@ -5,16 +5,21 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"flag"
 	"fmt"
 	"log/slog"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"strings"
 	"syscall"
 	"time"
 
 	factory "github.com/madhatter5501/Factory"
+	"github.com/madhatter5501/Factory/agents"
 	"github.com/madhatter5501/Factory/internal/db"
+	"github.com/madhatter5501/Factory/internal/notify"
 	"github.com/madhatter5501/Factory/internal/web"
 	"github.com/madhatter5501/Factory/kanban"
 )
@@ -34,15 +39,18 @@ func main() {
 		timeout       = flag.Duration("timeout", 30*time.Minute, "Agent timeout")
 		interval      = flag.Duration("interval", 10*time.Second, "Cycle interval")
 		autoMerge     = flag.Bool("auto-merge", false, "Auto-merge completed tickets")
+		mergeApproval = flag.Bool("require-merge-approval", false, "Hold signed-off tickets for human approval before merge")
 		verbose       = flag.Bool("verbose", true, "Verbose output")
 		dryRun        = flag.Bool("dry-run", false, "Don't actually run agents")
 		showVersion   = flag.Bool("version", false, "Show version")
 		initBoard     = flag.Bool("init", false, "Initialize a new kanban board")
 		status        = flag.Bool("status", false, "Show board status")
+		statusFormat  = flag.String("format", "text", "Output format for --status: text or json")
 		autoStart     = flag.Bool("auto", false, "Auto-start orchestrator (agents process work)")
 		cliMode       = flag.Bool("cli", false, "Run in CLI mode (orchestrator without dashboard)")
 		dashboardPort = flag.String("port", "8080", "Dashboard server port")
 		dbPath        = flag.String("db", "factory.db", "SQLite database path")
+		profile       = flag.String("profile", "", "Config profile to activate: a saved profile name or a JSON profile file")
 	)
 	flag.Parse()
 
@@ -65,17 +73,197 @@ func main() {
 	config.AgentTimeout = *timeout
 	config.CycleInterval = *interval
 	config.AutoMerge = *autoMerge
+	config.RequireMergeApproval = *mergeApproval
 	config.Verbose = *verbose
 	config.DryRun = *dryRun
 	config.BareRepo = *bareRepo
 
 	// Read database config values as fallbacks
 	store := db.NewStore(database)
+	store.OnStatusChange(notify.StatusChangeHook(store, slog.Default()))
+	if *profile != "" {
+		name, err := activateProfile(store, *profile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to activate profile: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Activated config profile %q\n", name)
+	}
 	if config.BareRepo == "" {
 		if v, _ := store.GetConfigValue("bare_repo"); v != "" {
 			config.BareRepo = v
 		}
 	}
+	if !config.RequireMergeApproval {
+		if v, _ := store.GetConfigValue("require_merge_approval"); v == "true" {
+			config.RequireMergeApproval = true
+		}
+	}
+	if v, _ := store.GetConfigValue("done_soak_seconds"); v != "" {
+		// Seconds signed-off tickets wait in PENDING_DONE before DONE (0 disables)
+		var seconds int
+		if _, err := fmt.Sscanf(v, "%d", &seconds); err == nil && seconds >= 0 {
+			config.DoneSoakPeriod = time.Duration(seconds) * time.Second
+		}
+	}
+	if v, _ := store.GetConfigValue("worktree_retention_seconds"); v != "" {
+		// Seconds merged worktrees are kept before cleanup (0 removes at merge)
+		var seconds int
+		if _, err := fmt.Sscanf(v, "%d", &seconds); err == nil && seconds >= 0 {
+			config.WorktreeRetention = time.Duration(seconds) * time.Second
+		}
+	}
+	if v, _ := store.GetConfigValue("sequential_parallel_groups"); v == "true" {
+		config.SequentialParallelGroups = true
+	}
+	if v, _ := store.GetConfigValue("notify_iteration_digest"); v == "true" {
+		config.NotifyIterationDigest = true
+	}
+	if v, _ := store.GetConfigValue("max_sub_tickets_per_prd"); v != "" {
+		var maxSubTickets int
+		if _, err := fmt.Sscanf(v, "%d", &maxSubTickets); err == nil && maxSubTickets >= 0 {
+			config.MaxSubTicketsPerPRD = maxSubTickets
+		}
+	}
+	if v, _ := store.GetConfigValue("fast_track_min_criteria"); v != "" {
+		// Acceptance criteria a ticket needs to skip PRD expert review (0 disables fast-track)
+		var criteria int
+		if _, err := fmt.Sscanf(v, "%d", &criteria); err == nil && criteria >= 0 {
+			config.FastTrackMinCriteria = criteria
+		}
+	}
+	if v, _ := store.GetConfigValue("fast_track_min_description_len"); v != "" {
+		var length int
+		if _, err := fmt.Sscanf(v, "%d", &length); err == nil && length >= 0 {
+			config.FastTrackMinDescriptionLen = length
+		}
+	}
+	if v, _ := store.GetConfigValue("fast_track_max_description_len"); v != "" {
+		// 0 puts no upper bound on the description
+		var length int
+		if _, err := fmt.Sscanf(v, "%d", &length); err == nil && length >= 0 {
+			config.FastTrackMaxDescriptionLen = length
+		}
+	}
+	if v, _ := store.GetConfigValue("unverifiable_criteria_status"); v != "" {
+		// Only statuses where a human picks the ticket up make sense here
+		switch status := kanban.Status(v); status {
+		case kanban.StatusAwaitingUser, kanban.StatusBlocked, kanban.StatusBacklog:
+			config.UnverifiableCriteriaStatus = status
+		}
+	}
+	// failure_routing overrides routes by agent and failure category as JSON, e.g.
+	// {"dev": "BLOCKED", "qa:timeout": "READY"};
+	// an empty status removes the route so the ticket stays in place
+	if v, _ := store.GetConfigValue("failure_routing"); v != "" {
+		var routes map[string]kanban.Status
+		if err := json.Unmarshal([]byte(v), &routes); err == nil {
+			for agent, status := range routes {
+				switch status {
+				case "":
+					delete(config.FailureRouting, agent)
+				case kanban.StatusReady, kanban.StatusInDev, kanban.StatusBlocked, kanban.StatusBacklog,
+					kanban.StatusAwaitingUser, kanban.StatusAbandoned:
+					config.FailureRouting[agent] = status
+				}
+			}
+		}
+	}
+	if v, _ := store.GetConfigValue("config_cache_ttl"); v != "" {
+		// Seconds config reads are cached in memory (0 disables)
+		var seconds int
+		if _, err := fmt.Sscanf(v, "%d", &seconds); err == nil && seconds >= 0 {
+			database.SetConfigCacheTTL(time.Duration(seconds) * time.Second)
+		}
+	}
+	if v, _ := store.GetConfigValue("prd_template"); v != "" {
+		// JSON array of section names, e.g. ["goals", "security_plan"]
+		var sections []string
+		if err := json.Unmarshal([]byte(v), &sections); err == nil {
+			config.PRDTemplate = sections
+		}
+	}
+	if v, _ := store.GetConfigValue("bugfix_ticket_severities"); v != "" {
+		// Comma-separated, e.g. "critical,high"
+		config.BugfixTicketSeverities = nil
+		for _, severity := range strings.Split(v, ",") {
+			if severity = strings.TrimSpace(severity); severity != "" {
+				config.BugfixTicketSeverities = append(config.BugfixTicketSeverities, severity)
+			}
+		}
+	}
+	if v, _ := store.GetConfigValue("max_ticket_failures"); v != "" {
+		var maxFailures int
+		if _, err := fmt.Sscanf(v, "%d", &maxFailures); err == nil {
+			config.MaxTicketFailures = maxFailures
+		}
+	}
+	if v, _ := store.GetConfigValue("agent_max_retries"); v != "" {
+		var retries int
+		if _, err := fmt.Sscanf(v, "%d", &retries); err == nil && retries >= 0 {
+			config.AgentMaxRetries = retries
+		}
+	}
+	if v, _ := store.GetConfigValue("agent_retry_backoff"); v != "" {
+		// Seconds before the first retry of a transient agent failure, doubled for each further retry
+		var seconds int
+		if _, err := fmt.Sscanf(v, "%d", &seconds); err == nil && seconds >= 0 {
+			config.AgentRetryBackoff = time.Duration(seconds) * time.Second
+		}
+	}
+	if v, _ := store.GetConfigValue("command_policies"); v != "" {
+		// JSON object of agent type to policy, e.g. {"dev-infra": {"allow": ["terraform plan"]}}
+		var policies map[string]agents.CommandPolicy
+		if err := json.Unmarshal([]byte(v), &policies); err == nil {
+			config.CommandPolicies = policies
+		}
+	}
+	if v, _ := store.GetConfigValue("context_budgets"); v != "" {
+		// JSON object of model to prompt token budget, e.g. {"gpt-4o": 64000}
+		var budgets map[string]int
+		if err := json.Unmarshal([]byte(v), &budgets); err == nil {
+			config.ContextBudgets = budgets
+		}
+	}
+	if v, _ := store.GetConfigValue("code_context_snippets"); v != "" {
+		// Related code snippets retrieved into dev prompts when RAG is enabled (0 disables)
+		var snippets int
+		if _, err := fmt.Sscanf(v, "%d", &snippets); err == nil && snippets >= 0 {
+			config.CodeContextSnippets = snippets
+		}
+	}
+	if v, _ := store.GetConfigValue("test_commands"); v != "" {
+		// JSON object of domain to test command, e.g. {"backend": "go test ./..."}
+		var commands map[string]string
+		if err := json.Unmarshal([]byte(v), &commands); err == nil {
+			config.TestCommands = commands
+		}
+	}
+	if v, _ := store.GetConfigValue("test_frameworks"); v != "" {
+		// JSON object of domain to expected test frameworks, e.g. {"frontend": ["jest", "vitest"]}
+		var frameworks map[string][]string
+		if err := json.Unmarshal([]byte(v), &frameworks); err == nil {
+			config.TestFrameworks = frameworks
+		}
+	}
+	if v, _ := store.GetConfigValue("max_total_agents"); v != "" {
+		var maxTotal int
+		if _, err := fmt.Sscanf(v, "%d", &maxTotal); err == nil && maxTotal >= 0 {
+			config.MaxTotalAgents = maxTotal
+		}
+	}
+	if v, _ := store.GetConfigValue("spawn_rampup_initial"); v != "" {
+		var initial int
+		if _, err := fmt.Sscanf(v, "%d", &initial); err == nil {
+			config.SpawnRampUpInitial = initial
+		}
+	}
+	if v, _ := store.GetConfigValue("spawn_rampup_cycles"); v != "" {
+		var cycles int
+		if _, err := fmt.Sscanf(v, "%d", &cycles); err == nil {
+			config.SpawnRampUpCycles = cycles
+		}
+	}
 	if v, _ := store.GetConfigValue("max_parallel_agents"); v != "" && *maxAgents == 3 {
 		var dbMax int
 		if _, err := fmt.Sscanf(v, "%d", &dbMax); err == nil {
@@ -85,6 +273,10 @@ func main() {
 
 	// Handle specific commands that need orchestrator but not the dashboard
 	if *initBoard || *status {
+		if *status && *statusFormat != "text" && *statusFormat != "json" {
+			fmt.Fprintf(os.Stderr, "Error: unknown --format %q (want text or json)\n", *statusFormat)
+			os.Exit(2)
+		}
 		orch, err := factory.NewOrchestrator(*repoRoot, config, store)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
@@ -98,8 +290,9 @@ func main() {
 		case *initBoard:
 			runInitBoard(orch)
 		case *status:
-			runStatusCmd(orch)
+			runStatusCmd(orch, *statusFormat)
 		}
+		orch.Close()
 		return
 	}
 
@@ -113,6 +306,39 @@ func main() {
 	runDashboardWithOrchestrator(*repoRoot, config, store, database, *dashboardPort, *autoStart)
 }
 
+// activateProfile applies a config profile before the database config is read.
+// A path to a .json file is saved as a profile first (named after the file unless
+// it sets "name"); anything else is treated as the name of a saved profile.
+func activateProfile(store *db.Store, profile string) (string, error) {
+	name := profile
+	if strings.HasSuffix(profile, ".json") {
+		data, err := os.ReadFile(profile)
+		if err != nil {
+			return "", fmt.Errorf("failed to read profile file: %w", err)
+		}
+		var p kanban.ConfigProfile
+		if err := json.Unmarshal(data, &p); err != nil {
+			return "", fmt.Errorf("failed to parse profile file %s: %w", profile, err)
+		}
+		if p.Name == "" {
+			p.Name = strings.TrimSuffix(filepath.Base(profile), ".json")
+		}
+		delete(p.Values, db.ActiveConfigProfileKey)
+		if existing, err := store.GetConfigProfile(p.Name); err == nil && existing != nil {
+			p.CreatedAt = existing.CreatedAt
+		}
+		if err := store.SaveConfigProfile(&p); err != nil {
+			return "", err
+		}
+		name = p.Name
+	}
+
+	if err := store.ActivateConfigProfile(name); err != nil {
+		return "", err
+	}
+	return name, nil
+}
+
 func banner() string {
 	return `
 ╔═══════════════════════════════════════════════════════════════╗
@@ -155,11 +381,75 @@ func runInitBoard(orch *factory.Orchestrator) {
 	fmt.Println("  3. Run 'factory' to start the development pipeline")
 }
 
-func runStatusCmd(orch *factory.Orchestrator) {
+// statusReport is the JSON printed by `factory --status --format json`. Scripts
+// depend on this shape, so add fields rather than renaming or removing them.
+type statusReport struct {
+	Iteration  *statusIteration      `json:"iteration,omitempty"`
+	Stats      map[kanban.Status]int `json:"stats"`      // Ticket count per status
+	ActiveRuns []statusRun           `json:"activeRuns"` // Agents currently running
+	InProgress []statusTicket        `json:"inProgress"` // Tickets past the backlog and not done
+}
+
+type statusIteration struct {
+	ID     string `json:"id"`
+	Goal   string `json:"goal"`
+	Status string `json:"status"`
+}
+
+type statusRun struct {
+	Agent     string    `json:"agent"`
+	TicketID  string    `json:"ticketId"`
+	StartedAt time.Time `json:"startedAt"`
+}
+
+type statusTicket struct {
+	ID     string        `json:"id"`
+	Title  string        `json:"title"`
+	Status kanban.Status `json:"status"`
+	Domain kanban.Domain `json:"domain"`
+}
+
+// newStatusReport builds the status report from the board, its stats and the active runs.
+func newStatusReport(board kanban.Board, stats map[kanban.Status]int, runs []kanban.AgentRun) statusReport {
+	report := statusReport{
+		Stats:      stats,
+		ActiveRuns: []statusRun{},
+		InProgress: []statusTicket{},
+	}
+	if board.Iteration != nil {
+		report.Iteration = &statusIteration{ID: board.Iteration.ID, Goal: board.Iteration.Goal, Status: board.Iteration.Status}
+	}
+	for _, run := range runs {
+		report.ActiveRuns = append(report.ActiveRuns, statusRun{Agent: run.Agent, TicketID: run.TicketID, StartedAt: run.StartedAt})
+	}
+	for _, ticket := range board.Tickets {
+		if isStatusInProgress(ticket.Status) {
+			report.InProgress = append(report.InProgress, statusTicket{ID: ticket.ID, Title: ticket.Title, Status: ticket.Status, Domain: ticket.Domain})
+		}
+	}
+	return report
+}
+
+// isStatusInProgress reports whether the status command lists a ticket as in progress.
+func isStatusInProgress(status kanban.Status) bool {
+	return status != kanban.StatusDone && status != kanban.StatusBacklog && status != kanban.StatusIcebox
+}
+
+func runStatusCmd(orch *factory.Orchestrator, format string) {
 	state := orch.GetState()
 	board := state.GetBoard()
 	stats := state.GetStats()
 
+	if format == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(newStatusReport(board, stats, state.GetActiveRuns())); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	fmt.Println("=== Factory Status ===")
 	fmt.Println()
 
@@ -171,6 +461,7 @@ func runStatusCmd(orch *factory.Orchestrator) {
 	}
 
 	fmt.Println("Pipeline:")
+	fmt.Printf("  ICEBOX:        %d  (deferred)\n", stats[kanban.StatusIcebox])
 	fmt.Printf("  BACKLOG:       %d\n", stats[kanban.StatusBacklog])
 	fmt.Println("  --- Requirements ---")
 	fmt.Printf("  APPROVED:      %d  (awaiting requirements)\n", stats[kanban.StatusApproved])
@@ -178,14 +469,18 @@ func runStatusCmd(orch *factory.Orchestrator) {
 	fmt.Printf("  NEEDS_EXPERT:  %d  (consulting domain expert)\n", stats[kanban.StatusNeedsExpert])
 	fmt.Printf("  AWAITING_USER: %d  (user review needed)\n", stats[kanban.StatusAwaitingUser])
 	fmt.Println("  --- Development ---")
+	fmt.Printf("  QUEUED:        %d  (waiting on earlier sub-ticket groups)\n", stats[kanban.StatusQueued])
 	fmt.Printf("  READY:         %d  (ready for dev)\n", stats[kanban.StatusReady])
 	fmt.Printf("  IN_DEV:        %d\n", stats[kanban.StatusInDev])
 	fmt.Printf("  IN_QA:         %d\n", stats[kanban.StatusInQA])
 	fmt.Printf("  IN_UX:         %d\n", stats[kanban.StatusInUX])
 	fmt.Printf("  IN_SEC:        %d\n", stats[kanban.StatusInSec])
 	fmt.Printf("  PM_REVIEW:     %d\n", stats[kanban.StatusPMReview])
+	fmt.Printf("  MERGE_APPROVAL: %d  (human approval needed)\n", stats[kanban.StatusAwaitingMergeApproval])
+	fmt.Printf("  PENDING_DONE:  %d  (soaking before done)\n", stats[kanban.StatusPendingDone])
 	fmt.Printf("  DONE:          %d\n", stats[kanban.StatusDone])
 	fmt.Printf("  BLOCKED:       %d\n", stats[kanban.StatusBlocked])
+	fmt.Printf("  ABANDONED:     %d  (human intervention needed)\n", stats[kanban.StatusAbandoned])
 	fmt.Println()
 
 	// Show active runs
@@ -202,7 +497,7 @@ func runStatusCmd(orch *factory.Orchestrator) {
 	// Show tickets in progress
 	fmt.Println("Tickets in Progress:")
 	for _, ticket := range board.Tickets {
-		if ticket.Status != kanban.StatusDone && ticket.Status != kanban.StatusBacklog {
+		if isStatusInProgress(ticket.Status) {
 			fmt.Printf("  [%s] %s - %s (%s)\n",
 				ticket.ID, ticket.Title, ticket.Status, ticket.Domain)
 		}
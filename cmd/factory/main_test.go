@@ -0,0 +1,41 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/madhatter5501/Factory/kanban"
+)
+
+func TestStatusReportJSONShape(t *testing.T) {
+	started := time.Date(2024, 3, 4, 10, 0, 0, 0, time.UTC)
+	board := kanban.Board{
+		Iteration: &kanban.Iteration{ID: "sprint-1", Goal: "Ship it", Status: "active"},
+		Tickets: []kanban.Ticket{
+			{ID: "T-1", Title: "Login", Status: kanban.StatusInDev, Domain: kanban.DomainBackend},
+			{ID: "T-2", Title: "Later", Status: kanban.StatusBacklog},
+			{ID: "T-3", Title: "Shipped", Status: kanban.StatusDone},
+		},
+	}
+	stats := map[kanban.Status]int{kanban.StatusInDev: 1, kanban.StatusBacklog: 1, kanban.StatusDone: 1}
+	runs := []kanban.AgentRun{{ID: "run-1", Agent: "dev-backend", TicketID: "T-1", StartedAt: started, Output: "not in report"}}
+
+	data, err := json.Marshal(newStatusReport(board, stats, runs))
+	if err != nil {
+		t.Fatalf("marshal failed: %v", err)
+	}
+
+	want := `{"iteration":{"id":"sprint-1","goal":"Ship it","status":"active"},` +
+		`"stats":{"BACKLOG":1,"DONE":1,"IN_DEV":1},` +
+		`"activeRuns":[{"agent":"dev-backend","ticketId":"T-1","startedAt":"2024-03-04T10:00:00Z"}],` +
+		`"inProgress":[{"id":"T-1","title":"Login","status":"IN_DEV","domain":"backend"}]}`
+	if string(data) != want {
+		t.Errorf("unexpected status JSON:\n got: %s\nwant: %s", data, want)
+	}
+
+	empty, _ := json.Marshal(newStatusReport(kanban.Board{}, map[kanban.Status]int{}, nil))
+	if string(empty) != `{"stats":{},"activeRuns":[],"inProgress":[]}` {
+		t.Errorf("expected empty lists rather than null, got %s", empty)
+	}
+}
@@ -4,14 +4,26 @@ package git
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
+	"time"
 )
 
+// MaxCommits caps the number of commits returned by Commits.
+const MaxCommits = 100
+
+// ErrBranchNotFound is returned when a branch exists neither locally nor on origin.
+var ErrBranchNotFound = errors.New("branch not found")
+
+// ErrRebaseConflict is returned when rebasing a branch onto main stops on conflicts.
+var ErrRebaseConflict = errors.New("rebase conflict")
+
 // WorktreeManager handles git worktree operations.
 type WorktreeManager struct {
 	repoRoot    string // Main repository root
@@ -138,7 +150,12 @@ func (m *WorktreeManager) RemoveWorktree(worktreePath string, removeBranch bool)
 
 // ListWorktrees returns all worktrees in the worktree directory.
 func (m *WorktreeManager) ListWorktrees() ([]WorktreeInfo, error) {
-	output, err := m.runGitOutput(m.repoRoot, "worktree", "list", "--porcelain")
+	return m.listWorktreesIn(m.repoRoot)
+}
+
+// listWorktreesIn returns all worktrees attached to the given repository.
+func (m *WorktreeManager) listWorktreesIn(repoPath string) ([]WorktreeInfo, error) {
+	output, err := m.runGitOutput(repoPath, "worktree", "list", "--porcelain")
 	if err != nil {
 		return nil, fmt.Errorf("failed to list worktrees: %w", err)
 	}
@@ -227,7 +244,50 @@ func (m *WorktreeManager) UpdateWorktree(worktreePath string) error {
 	return nil
 }
 
-// SquashMerge merges a branch into main using squash merge.
+// RebaseOntoMain rebases a branch onto the local main branch, in the worktree
+// that has it checked out. On conflict the rebase is aborted, leaving the branch
+// as it was, and ErrRebaseConflict is returned.
+func (m *WorktreeManager) RebaseOntoMain(branchName string) error {
+	sourceRepo := m.repoRoot
+	if m.bareRepo != "" {
+		sourceRepo = m.bareRepo
+	}
+
+	worktrees, err := m.listWorktreesIn(sourceRepo)
+	if err != nil {
+		return err
+	}
+	worktreePath := ""
+	for _, wt := range worktrees {
+		if wt.Branch == branchName {
+			worktreePath = wt.Path
+			break
+		}
+	}
+	if worktreePath == "" {
+		return fmt.Errorf("branch %s is not checked out in a worktree", branchName)
+	}
+
+	// Check for uncommitted changes
+	output, err := m.runGitOutput(worktreePath, "status", "--porcelain")
+	if err != nil {
+		return fmt.Errorf("failed to check status: %w", err)
+	}
+	if len(bytes.TrimSpace(output)) > 0 {
+		return fmt.Errorf("worktree has uncommitted changes")
+	}
+
+	if err := m.runGit(worktreePath, "rebase", m.mainBranch); err != nil {
+		// Abort rebase on failure
+		_ = m.runGit(worktreePath, "rebase", "--abort")
+		return fmt.Errorf("%w: %s onto %s", ErrRebaseConflict, branchName, m.mainBranch)
+	}
+
+	return nil
+}
+
+// SquashMerge merges a branch into main using squash merge. A branch main
+// already has is left alone rather than failing on an empty commit.
 func (m *WorktreeManager) SquashMerge(branchName, commitMessage string) error {
 	// Switch to main in the main repo
 	if err := m.runGit(m.repoRoot, "checkout", m.mainBranch); err != nil {
@@ -244,6 +304,12 @@ func (m *WorktreeManager) SquashMerge(branchName, commitMessage string) error {
 		return fmt.Errorf("failed to squash merge: %w", err)
 	}
 
+	// Nothing staged means main already has the branch, e.g. merged at dev
+	// sign-off with no review changes since
+	if err := m.runGit(m.repoRoot, "diff", "--cached", "--quiet"); err == nil {
+		return nil
+	}
+
 	// Commit
 	if err := m.runGit(m.repoRoot, "commit", "-m", commitMessage); err != nil {
 		return fmt.Errorf("failed to commit merge: %w", err)
@@ -325,6 +391,151 @@ func (m *WorktreeManager) GetLatestCommit(worktreePath string) (string, error) {
 	return strings.TrimSpace(string(output)), nil
 }
 
+// Commit describes a single commit on a branch.
+type Commit struct {
+	SHA     string    `json:"sha"`
+	Author  string    `json:"author"`
+	Date    time.Time `json:"date"`
+	Message string    `json:"message"`
+}
+
+// Commits returns the commits on a branch that are not on main, newest first,
+// capped at MaxCommits. It reads the branch ref from the repository, so it works
+// whether or not the branch's worktree still exists.
+func (m *WorktreeManager) Commits(branchName string) ([]Commit, error) {
+	sourceRepo, ref, err := m.resolveBranch(branchName)
+	if err != nil {
+		return nil, err
+	}
+
+	// Fields separated by \x1f, records by \x1e (messages may span lines)
+	output, err := m.runGitOutput(sourceRepo, "log",
+		fmt.Sprintf("--max-count=%d", MaxCommits),
+		"--format=%H%x1f%an%x1f%aI%x1f%B%x1e",
+		m.mainBranch+".."+ref, "--")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read git log: %w", err)
+	}
+
+	var commits []Commit
+	for _, record := range strings.Split(string(output), "\x1e") {
+		fields := strings.SplitN(strings.TrimSpace(record), "\x1f", 4)
+		if len(fields) != 4 {
+			continue
+		}
+		date, _ := time.Parse(time.RFC3339, fields[2])
+		commits = append(commits, Commit{
+			SHA:     fields[0],
+			Author:  fields[1],
+			Date:    date,
+			Message: strings.TrimSpace(fields[3]),
+		})
+	}
+
+	return commits, nil
+}
+
+// ChangedFiles returns the sorted paths a branch changes relative to where it
+// forked from main. Like Commits, it works after the worktree is gone.
+func (m *WorktreeManager) ChangedFiles(branchName string) ([]string, error) {
+	sourceRepo, ref, err := m.resolveBranch(branchName)
+	if err != nil {
+		return nil, err
+	}
+
+	output, err := m.runGitOutput(sourceRepo, "diff", "--name-only", m.mainBranch+"..."+ref, "--")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read git diff: %w", err)
+	}
+	return splitFileList(output), nil
+}
+
+// DiffStat summarizes the code volume of a branch relative to main.
+type DiffStat struct {
+	FilesChanged int `json:"filesChanged"`
+	LinesAdded   int `json:"linesAdded"`
+	LinesRemoved int `json:"linesRemoved"`
+}
+
+// DiffStat returns the files changed and lines added and removed on a branch
+// since it diverged from main. Binary files count as changed without lines.
+func (m *WorktreeManager) DiffStat(branchName string) (*DiffStat, error) {
+	sourceRepo, ref, err := m.resolveBranch(branchName)
+	if err != nil {
+		return nil, err
+	}
+
+	output, err := m.runGitOutput(sourceRepo, "diff", "--numstat", m.mainBranch+"..."+ref, "--")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read git diff: %w", err)
+	}
+
+	stat := &DiffStat{}
+	for _, line := range strings.Split(string(output), "\n") {
+		fields := strings.SplitN(line, "\t", 3)
+		if len(fields) < 3 {
+			continue
+		}
+		stat.FilesChanged++
+		var added, removed int
+		fmt.Sscanf(fields[0], "%d", &added)
+		fmt.Sscanf(fields[1], "%d", &removed)
+		stat.LinesAdded += added
+		stat.LinesRemoved += removed
+	}
+	return stat, nil
+}
+
+// MergedFiles returns the sorted paths changed by commits on main whose message
+// carries a "Ticket: <id>" trailer, as squash merges of ticket branches do. It
+// finds a ticket's changes once its branch has been deleted.
+func (m *WorktreeManager) MergedFiles(ticketID string) ([]string, error) {
+	sourceRepo := m.repoRoot
+	if m.bareRepo != "" {
+		sourceRepo = m.bareRepo
+	}
+
+	output, err := m.runGitOutput(sourceRepo, "log", m.mainBranch,
+		"--extended-regexp", "--grep=^Ticket: "+regexp.QuoteMeta(ticketID)+"$",
+		"--format=", "--name-only", "--")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read git log: %w", err)
+	}
+	return splitFileList(output), nil
+}
+
+// splitFileList returns the unique, sorted, non-empty lines of git file output.
+func splitFileList(output []byte) []string {
+	seen := make(map[string]bool)
+	var files []string
+	for _, line := range strings.Split(string(output), "\n") {
+		if line = strings.TrimSpace(line); line != "" && !seen[line] {
+			seen[line] = true
+			files = append(files, line)
+		}
+	}
+	sort.Strings(files)
+	return files
+}
+
+// resolveBranch returns the repository holding a branch and the ref to read it
+// by, preferring the local branch and falling back to origin's copy.
+func (m *WorktreeManager) resolveBranch(branchName string) (repo, ref string, err error) {
+	sourceRepo := m.repoRoot
+	if m.bareRepo != "" {
+		sourceRepo = m.bareRepo
+	}
+
+	ref = branchName
+	if m.runGit(sourceRepo, "show-ref", "--verify", "--quiet", "refs/heads/"+branchName) != nil {
+		ref = "origin/" + branchName
+		if m.bareRepo != "" || m.runGit(sourceRepo, "show-ref", "--verify", "--quiet", "refs/remotes/"+ref) != nil {
+			return "", "", fmt.Errorf("%w: %s", ErrBranchNotFound, branchName)
+		}
+	}
+	return sourceRepo, ref, nil
+}
+
 // CleanupOrphanedWorktrees removes worktrees that are no longer tracked.
 func (m *WorktreeManager) CleanupOrphanedWorktrees() error {
 	return m.runGit(m.repoRoot, "worktree", "prune")
@@ -0,0 +1,105 @@
+package git
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// newTestRepo creates a repository with one commit on main.
+func newTestRepo(t *testing.T) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	runTestGit(t, dir, "init", "-q", "-b", "main")
+	runTestGit(t, dir, "config", "user.name", "Test Dev")
+	runTestGit(t, dir, "config", "user.email", "dev@example.com")
+	runTestGit(t, dir, "commit", "-q", "--allow-empty", "-m", "Initial commit")
+	return dir
+}
+
+func runTestGit(t *testing.T, dir string, args ...string) {
+	t.Helper()
+
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %v failed: %v\n%s", args, err, output)
+	}
+}
+
+func TestCommitsListsBranchCommitsNotOnMain(t *testing.T) {
+	repo := newTestRepo(t)
+
+	runTestGit(t, repo, "checkout", "-q", "-b", "feat/T-1-login")
+	runTestGit(t, repo, "commit", "-q", "--allow-empty", "-m", "Add login form")
+	runTestGit(t, repo, "commit", "-q", "--allow-empty", "-m", "Validate credentials\n\nRejects empty passwords.")
+	runTestGit(t, repo, "commit", "-q", "--allow-empty", "-m", "Fix redirect after login")
+	runTestGit(t, repo, "checkout", "-q", "main")
+
+	manager := NewWorktreeManager(repo, ".worktrees", "main")
+	commits, err := manager.Commits("feat/T-1-login")
+	if err != nil {
+		t.Fatalf("Commits failed: %v", err)
+	}
+
+	expected := []string{
+		"Fix redirect after login",
+		"Validate credentials\n\nRejects empty passwords.",
+		"Add login form",
+	}
+	if len(commits) != len(expected) {
+		t.Fatalf("Expected %d commits, got %d: %+v", len(expected), len(commits), commits)
+	}
+	for i, want := range expected {
+		c := commits[i]
+		if c.Message != want {
+			t.Errorf("Commit %d: expected message %q, got %q", i, want, c.Message)
+		}
+		if len(c.SHA) != 40 || c.Author != "Test Dev" || c.Date.IsZero() {
+			t.Errorf("Commit %d: incomplete entry %+v", i, c)
+		}
+	}
+
+	if _, err := manager.Commits("feat/missing"); !errors.Is(err, ErrBranchNotFound) {
+		t.Errorf("Expected ErrBranchNotFound for missing branch, got %v", err)
+	}
+}
+
+func TestDiffStatCountsBranchChanges(t *testing.T) {
+	repo := newTestRepo(t)
+	writeTestFile(t, repo, "main.go", "package main\n\nfunc main() {}\n")
+	runTestGit(t, repo, "add", "-A")
+	runTestGit(t, repo, "commit", "-q", "-m", "Add main")
+
+	runTestGit(t, repo, "checkout", "-q", "-b", "feat/T-2-stats")
+	writeTestFile(t, repo, "main.go", "package main\n\nfunc main() {\n\trun()\n}\n")
+	writeTestFile(t, repo, "run.go", "package main\n\nfunc run() {}\n")
+	runTestGit(t, repo, "add", "-A")
+	runTestGit(t, repo, "commit", "-q", "-m", "Add run")
+	runTestGit(t, repo, "checkout", "-q", "main")
+
+	manager := NewWorktreeManager(repo, ".worktrees", "main")
+	stat, err := manager.DiffStat("feat/T-2-stats")
+	if err != nil {
+		t.Fatalf("DiffStat failed: %v", err)
+	}
+	want := DiffStat{FilesChanged: 2, LinesAdded: 6, LinesRemoved: 1}
+	if *stat != want {
+		t.Errorf("Expected %+v, got %+v", want, *stat)
+	}
+
+	if _, err := manager.DiffStat("feat/missing"); !errors.Is(err, ErrBranchNotFound) {
+		t.Errorf("Expected ErrBranchNotFound for missing branch, got %v", err)
+	}
+}
+
+func writeTestFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+		t.Fatalf("write %s: %v", name, err)
+	}
+}
@@ -0,0 +1,73 @@
+package db
+
+import (
+	"sync"
+	"time"
+)
+
+// DefaultConfigCacheTTL is how long config values are served from memory
+// before the config table is read again.
+const DefaultConfigCacheTTL = 5 * time.Second
+
+// configCache holds a snapshot of the config table, shared by every Store on
+// the same DB. Writes through a Store invalidate it so reads in this process
+// see them immediately; the TTL bounds how long writes made by another process
+// (or directly in SQL) can go unseen.
+type configCache struct {
+	mu       sync.RWMutex
+	ttl      time.Duration
+	values   map[string]string
+	loadedAt time.Time
+	gen      uint64 // Bumped on invalidation so an in-flight load can't store stale values
+}
+
+// enabled reports whether config reads are cached.
+func (c *configCache) enabled() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.ttl > 0
+}
+
+// get returns a config value, reloading the snapshot with load once it expires.
+// A missing key returns "".
+func (c *configCache) get(key string, load func() (map[string]string, error)) (string, error) {
+	c.mu.RLock()
+	if c.values != nil && time.Since(c.loadedAt) < c.ttl {
+		value := c.values[key]
+		c.mu.RUnlock()
+		return value, nil
+	}
+	gen := c.gen
+	c.mu.RUnlock()
+
+	values, err := load()
+	if err != nil {
+		return "", err
+	}
+
+	c.mu.Lock()
+	if c.gen == gen && c.ttl > 0 {
+		c.values = values
+		c.loadedAt = time.Now()
+	}
+	c.mu.Unlock()
+	return values[key], nil
+}
+
+// invalidate drops the snapshot so the next read goes to the database.
+func (c *configCache) invalidate() {
+	c.mu.Lock()
+	c.values = nil
+	c.gen++
+	c.mu.Unlock()
+}
+
+// SetConfigCacheTTL changes how long config values are cached; zero or less
+// disables caching so every read queries the database.
+func (d *DB) SetConfigCacheTTL(ttl time.Duration) {
+	d.configCache.mu.Lock()
+	d.configCache.ttl = ttl
+	d.configCache.values = nil
+	d.configCache.gen++
+	d.configCache.mu.Unlock()
+}
@@ -0,0 +1,202 @@
+package db
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/madhatter5501/Factory/internal/notify"
+	"github.com/madhatter5501/Factory/kanban"
+)
+
+// Config value types reported by the schema.
+const (
+	ConfigTypeString = "string"
+	ConfigTypeInt    = "int"
+	ConfigTypeFloat  = "float"
+	ConfigTypeBool   = "bool"
+	ConfigTypeList   = "list" // Comma-separated values
+	ConfigTypeJSON   = "json"
+)
+
+// ConfigKey describes a recognized config key. Default is the value used when
+// the key is unset; Allowed, when set, lists the only values accepted (for
+// lists, for each element).
+type ConfigKey struct {
+	Key         string   `json:"key"`
+	Type        string   `json:"type"`
+	Default     string   `json:"default"`
+	Allowed     []string `json:"allowed,omitempty"`
+	Description string   `json:"description"`
+}
+
+// ConfigSchema is the registry of config keys the factory reads. Keys managed
+// by their own endpoints (the current iteration, the active profile and
+// per-provider default models) are not listed.
+var ConfigSchema = []ConfigKey{
+	// Board and git
+	{Key: "worktree_dir", Type: ConfigTypeString, Default: ".worktrees", Description: "Directory, relative to the repo root, where ticket worktrees are created."},
+	{Key: "main_branch", Type: ConfigTypeString, Default: "main", Description: "Branch tickets are branched from and merged into."},
+	{Key: "branch_prefix", Type: ConfigTypeString, Default: "feat/", Description: "Prefix for ticket branch names."},
+	{Key: "bare_repo", Type: ConfigTypeString, Description: "Path to a bare repository to create worktrees from instead of the repo root."},
+	{Key: "git_provider", Type: ConfigTypeString, Default: "github", Allowed: []string{"github", "gitlab", "bitbucket"}, Description: "Hosting provider of the git remote."},
+	{Key: "git_repo_url", Type: ConfigTypeString, Description: "URL of the git remote."},
+	{Key: "squash_on_merge", Type: ConfigTypeBool, Default: "true", Description: "Squash ticket branches when merging."},
+	{Key: "rebase_before_qa", Type: ConfigTypeBool, Default: "false", Description: "Rebase ticket branches onto the main branch before QA."},
+	{Key: "require_merge_approval", Type: ConfigTypeBool, Default: "false", Description: "Hold signed-off tickets until a human approves the merge."},
+	{Key: "require_all_signoffs", Type: ConfigTypeBool, Default: "true", Description: "Require every review stage to sign off before merging."},
+	{Key: "default_acceptance_criteria", Type: ConfigTypeJSON, Description: `Criteria added to new tickets by type, e.g. {"bug": ["Regression test added"]}.`},
+	{Key: "skip_stages", Type: ConfigTypeList, Description: "Review stages skipped for every ticket, e.g. UX,SECURITY."},
+	{Key: "pipelines", Type: ConfigTypeJSON, Description: `Stages each ticket type passes through, e.g. {"bug": ["IN_DEV", "IN_QA"]}.`},
+	{Key: "wip_limits", Type: ConfigTypeJSON, Description: `Most tickets in a column at once, e.g. {"IN_DEV": 2, "IN_QA": 3}.`},
+	{Key: "hidden_columns", Type: ConfigTypeList, Description: "Board columns hidden from the dashboard, e.g. ICEBOX."},
+	{Key: "default_priority", Type: ConfigTypeInt, Default: "3", Allowed: []string{"1", "2", "3", "4"}, Description: "Priority given to new tickets that don't set one (1 is highest)."},
+	{Key: "duplicate_threshold", Type: ConfigTypeFloat, Description: "Similarity (0-1) above which a new ticket is treated as a duplicate; unset disables the check."},
+	{Key: "duplicate_action", Type: ConfigTypeString, Default: "return", Allowed: []string{"return", "link"}, Description: "Whether a duplicate returns the existing ticket or is created with a duplicates link."},
+	{Key: "auto_infer_dependencies", Type: ConfigTypeBool, Default: "false", Description: "Add suggested dependencies to new tickets automatically."},
+	{Key: "auto_promote_answered", Type: ConfigTypeBool, Default: "false", Description: "Move tickets back to work once their open question is answered."},
+	{Key: "max_ticket_attachment_bytes", Type: ConfigTypeInt, Default: strconv.FormatInt(50<<20, 10), Description: "Attachment storage allowed per ticket, in bytes."},
+	{Key: "max_concurrent_uploads", Type: ConfigTypeInt, Default: "4", Description: "Attachment uploads accepted at once; further uploads get 503 until one finishes."},
+	{Key: "tag_cleanup_keep_types", Type: ConfigTypeList, Description: "Tag types tag cleanup never deletes, even when unused, e.g. epic,initiative."},
+	{Key: "time_stats_include_git", Type: ConfigTypeBool, Default: "false", Description: "Include branch diff stats in ticket time reporting."},
+
+	// Orchestrator
+	{Key: "max_parallel_agents", Type: ConfigTypeInt, Default: "3", Description: "Agents the orchestrator runs at once."},
+	{Key: "max_total_agents", Type: ConfigTypeInt, Default: "0", Description: "Ceiling on agents across the orchestrator and dashboard (0 is unlimited)."},
+	{Key: "spawn_rampup_initial", Type: ConfigTypeInt, Default: "0", Description: "Agents allowed in the first cycle after startup (0 disables ramp-up)."},
+	{Key: "spawn_rampup_cycles", Type: ConfigTypeInt, Default: "0", Description: "Cycles over which spawning ramps up to max_parallel_agents."},
+	{Key: "worktree_retention_seconds", Type: ConfigTypeInt, Default: "0", Description: "Seconds merged worktrees are kept for inspection before cleanup (0 removes them at merge)."},
+	{Key: "done_soak_seconds", Type: ConfigTypeInt, Default: "0", Description: "Seconds signed-off tickets wait in PENDING_DONE before DONE (0 disables)."},
+	{Key: "sequential_parallel_groups", Type: ConfigTypeBool, Default: "false", Description: "Run parallel groups of sub-tickets one group at a time."},
+	{Key: "notify_iteration_digest", Type: ConfigTypeBool, Default: "false", Description: "Send operators the digest recorded when an iteration completes."},
+	{Key: "max_sub_tickets_per_prd", Type: ConfigTypeInt, Default: "0", Description: "Sub-tickets a PRD may be broken into (0 is unlimited)."},
+	{Key: "fast_track_min_criteria", Type: ConfigTypeInt, Default: "0", Description: "Acceptance criteria an approved ticket needs to skip PRD expert review (0 disables fast-track)."},
+	{Key: "fast_track_min_description_len", Type: ConfigTypeInt, Default: "0", Description: "Shortest description, in characters, a fast-tracked ticket may have."},
+	{Key: "fast_track_max_description_len", Type: ConfigTypeInt, Default: "0", Description: "Longest description, in characters, a fast-tracked ticket may have (0 is unlimited)."},
+	{Key: "unverifiable_criteria_status", Type: ConfigTypeString, Default: "AWAITING_USER", Allowed: []string{"AWAITING_USER", "BLOCKED", "BACKLOG"}, Description: "Where tickets with unverifiable acceptance criteria are sent."},
+	{Key: "failure_routing", Type: ConfigTypeJSON, Description: `Status a ticket moves to when an agent fails, by agent, failure category or both, e.g. {"dev": "BLOCKED", "qa:timeout": "READY"}.`},
+	{Key: "config_cache_ttl", Type: ConfigTypeInt, Default: "5", Description: "Seconds config reads are cached in memory (0 disables)."},
+	{Key: "prd_template", Type: ConfigTypeJSON, Description: `Sections PRDs must cover, e.g. ["goals", "security_plan"].`},
+	{Key: "bugfix_ticket_severities", Type: ConfigTypeList, Description: "Bug severities that get a bugfix ticket, e.g. critical,high."},
+	{Key: "max_ticket_failures", Type: ConfigTypeInt, Default: "0", Description: "Agent failures after which a ticket is abandoned (0 disables)."},
+	{Key: "agent_max_retries", Type: ConfigTypeInt, Default: "2", Description: "Retries of an agent run that failed on a timeout, rate limit or API 5xx error."},
+	{Key: "agent_retry_backoff", Type: ConfigTypeInt, Default: "30", Description: "Seconds before the first agent retry, doubled for each further retry."},
+	{Key: "command_policies", Type: ConfigTypeJSON, Description: `Commands each agent type may run, e.g. {"dev-infra": {"allow": ["terraform plan"]}}.`},
+	{Key: "context_budgets", Type: ConfigTypeJSON, Description: `Prompt token budget by model, e.g. {"gpt-4o": 64000}.`},
+	{Key: "code_context_snippets", Type: ConfigTypeInt, Default: "0", Description: "Related code snippets retrieved into dev prompts when RAG is enabled (0 disables)."},
+	{Key: "test_commands", Type: ConfigTypeJSON, Description: `Test command run after dev by domain, e.g. {"backend": "go test ./..."}.`},
+	{Key: "test_frameworks", Type: ConfigTypeJSON, Description: `Test frameworks expected in sign-off reports by domain, e.g. {"frontend": ["jest", "vitest"]}.`},
+	{Key: "enable_audit_logging", Type: ConfigTypeBool, Default: "true", Description: "Record agent prompts, responses and tool calls."},
+
+	// Worktrees and merging
+	{Key: "max_global_worktrees", Type: ConfigTypeInt, Default: "3", Description: "Worktrees allowed across all tickets."},
+	{Key: "max_worktrees_per_domain", Type: ConfigTypeJSON, Description: `Worktrees allowed per domain, e.g. {"frontend": 1}.`},
+	{Key: "merge_after_dev_signoff", Type: ConfigTypeBool, Default: "true", Description: "Queue branches for merge once dev signs off."},
+	{Key: "cleanup_worktree_on_merge", Type: ConfigTypeBool, Default: "false", Description: "Remove a ticket's worktree once it is done and its branch merges; review stages keep using it until then."},
+	{Key: "worktree_check_interval", Type: ConfigTypeInt, Default: "30", Description: "Seconds between worktree manager checks."},
+	{Key: "max_merge_attempts", Type: ConfigTypeInt, Default: "3", Description: "Merge attempts before a queued merge fails."},
+	{Key: "merge_retry_backoff", Type: ConfigTypeInt, Default: "30", Description: "Seconds to wait before retrying a failed merge."},
+
+	// Background agents
+	{Key: "pm_checkin_interval", Type: ConfigTypeInt, Default: "15", Description: "Minutes between PM check-ins on tickets in development."},
+	{Key: "awaiting_user_idle_hours", Type: ConfigTypeInt, Default: "0", Description: "Hours a ticket may wait on the user before being deferred (0 disables)."},
+	{Key: "awaiting_user_nudge_grace_hours", Type: ConfigTypeInt, Default: "24", Description: "Hours between nudging the user and deferring the ticket."},
+	{Key: "awaiting_user_defer_status", Type: ConfigTypeString, Default: "BACKLOG", Allowed: []string{"BACKLOG", "ICEBOX"}, Description: "Where idle tickets awaiting the user are deferred to."},
+	{Key: "blocked_escalation_hours", Type: ConfigTypeInt, Default: "0", Description: "Hours a ticket may stay blocked on an unmanaged reason before operators are notified (0 disables)."},
+	{Key: "blocked_escalation_target", Type: ConfigTypeString, Default: "", Description: "Who blocked-ticket escalations are addressed to, e.g. a team or channel."},
+
+	// Dashboard
+	{Key: "pm_chat_concurrency", Type: ConfigTypeInt, Default: "2", Description: "PM chat replies generated at once."},
+	{Key: "pm_chat_queue_size", Type: ConfigTypeInt, Default: "10", Description: "PM chat messages queued before new ones are rejected."},
+	{Key: "pm_chat_max_retries", Type: ConfigTypeInt, Default: "3", Description: "Retries of a PM chat response after a transient provider error."},
+	{Key: "pm_chat_retry_backoff", Type: ConfigTypeInt, Default: "2", Description: "Seconds before the first PM chat retry, doubled for each further retry."},
+	{Key: "provider_health_ttl", Type: ConfigTypeInt, Default: "60", Description: "Seconds provider health checks are cached."},
+	{Key: "sse_history_size", Type: ConfigTypeInt, Default: "256", Description: "Events kept for clients reconnecting to the event stream."},
+	{Key: "notification_min_interval", Type: ConfigTypeInt, Default: "30", Description: "Minimum seconds between notifications about the same ticket (0 disables coalescing)."},
+	{Key: "webhooks", Type: ConfigTypeJSON, Description: `Endpoint ticket status changes are POSTed to, signed with the optional secret, e.g. {"url": "https://example.com/hook", "secret": "s3cret"}.`},
+	{Key: "health_thrashing_window", Type: ConfigTypeInt, Default: "10", Description: "Recent history entries per ticket examined for thrashing."},
+	{Key: "health_thrashing_repeats", Type: ConfigTypeInt, Default: "3", Description: "Times one status must recur in the window for a ticket to count as thrashing."},
+	{Key: "health_thrashing_min_history", Type: ConfigTypeInt, Default: "6", Description: "History entries a ticket needs before it can count as thrashing."},
+	{Key: "activity_feed_retention", Type: ConfigTypeInt, Default: strconv.Itoa(DefaultActivityRetention), Description: "Activity feed entries kept (0 disables the feed)."},
+	{Key: "run_retention_days", Type: ConfigTypeInt, Default: "0", Description: "Days agent runs are kept in full (0 disables pruning)."},
+	{Key: "run_retention_mode", Type: ConfigTypeString, Default: "output", Allowed: []string{"output", "delete"}, Description: "Whether pruning clears a run's output or deletes the run."},
+}
+
+// LookupConfigKey returns the schema entry for key.
+func LookupConfigKey(key string) (ConfigKey, bool) {
+	for _, k := range ConfigSchema {
+		if k.Key == key {
+			return k, true
+		}
+	}
+	return ConfigKey{}, false
+}
+
+// ValidateConfigValue checks value against the schema entry for key. An empty
+// value is always valid; it unsets the key so the default applies.
+func ValidateConfigValue(key, value string) error {
+	k, ok := LookupConfigKey(key)
+	if !ok {
+		return fmt.Errorf("unknown config key %q", key)
+	}
+	if value == "" {
+		return nil
+	}
+
+	switch k.Type {
+	case ConfigTypeInt:
+		if _, err := strconv.Atoi(value); err != nil {
+			return fmt.Errorf("%s must be an integer", key)
+		}
+	case ConfigTypeFloat:
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			return fmt.Errorf("%s must be a number", key)
+		}
+	case ConfigTypeBool:
+		if value != "true" && value != "false" {
+			return fmt.Errorf("%s must be true or false", key)
+		}
+	case ConfigTypeJSON:
+		if !json.Valid([]byte(value)) {
+			return fmt.Errorf("%s must be valid JSON", key)
+		}
+	}
+	if key == "wip_limits" {
+		var limits map[kanban.Status]int
+		if err := json.Unmarshal([]byte(value), &limits); err != nil {
+			return fmt.Errorf("%s must map columns to limits", key)
+		}
+		if err := kanban.ValidateWIPLimits(limits); err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+	}
+	if key == "webhooks" {
+		if _, err := notify.ParseWebhookConfig(value); err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+	}
+
+	if len(k.Allowed) == 0 {
+		return nil
+	}
+	values := []string{value}
+	if k.Type == ConfigTypeList {
+		values = strings.Split(value, ",")
+	}
+	for _, v := range values {
+		if !configValueAllowed(k, strings.TrimSpace(v)) {
+			return fmt.Errorf("%s must be one of %s", key, strings.Join(k.Allowed, ", "))
+		}
+	}
+	return nil
+}
+
+func configValueAllowed(k ConfigKey, value string) bool {
+	for _, allowed := range k.Allowed {
+		if value == allowed {
+			return true
+		}
+	}
+	return false
+}
@@ -14,6 +14,8 @@ import (
 type DB struct {
 	*sql.DB
 	path string
+
+	configCache configCache
 }
 
 // Open opens or creates a SQLite database at the given path.
@@ -42,6 +44,7 @@ func Open(dbPath string) (*DB, error) {
 	}
 
 	d := &DB{DB: db, path: dbPath}
+	d.configCache.ttl = DefaultConfigCacheTTL
 
 	// Run migrations
 	if err := d.migrate(); err != nil {
@@ -88,6 +91,27 @@ func (d *DB) migrate() error {
 		{9, migration9},
 		{10, migration10},
 		{11, migration11},
+		{12, migration12},
+		{13, migration13},
+		{14, migration14},
+		{15, migration15},
+		{16, migration16},
+		{17, migration17},
+		{18, migration18},
+		{19, migration19},
+		{20, migration20},
+		{21, migration21},
+		{22, migration22},
+		{23, migration23},
+		{24, migration24},
+		{25, migration25},
+		{26, migration26},
+		{27, migration27},
+		{28, migration28},
+		{29, migration29},
+		{30, migration30},
+		{31, migration31},
+		{32, migration32},
 	}
 
 	for _, m := range migrations {
@@ -451,6 +475,196 @@ CREATE INDEX IF NOT EXISTS idx_ticket_tags_ticket ON ticket_tags(ticket_id);
 CREATE INDEX IF NOT EXISTS idx_ticket_tags_tag ON ticket_tags(tag_id);
 `
 
+// Migration 12: System health transitions for alerting.
+const migration12 = `
+-- Health status transitions (one row per change of ComputeSystemHealth status)
+CREATE TABLE IF NOT EXISTS health_events (
+    id TEXT PRIMARY KEY,
+    from_status TEXT NOT NULL,
+    to_status TEXT NOT NULL,
+    message TEXT,
+    acknowledged INTEGER DEFAULT 0,
+    acknowledged_at DATETIME,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_health_events_created ON health_events(created_at);
+`
+
+// Migration 13: Request trace IDs threaded through tickets, runs and audit entries.
+const migration13 = `
+ALTER TABLE tickets ADD COLUMN trace_id TEXT;
+ALTER TABLE agent_runs ADD COLUMN trace_id TEXT;
+ALTER TABLE agent_audit_log ADD COLUMN trace_id TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_agent_runs_trace ON agent_runs(trace_id);
+CREATE INDEX IF NOT EXISTS idx_audit_log_trace ON agent_audit_log(trace_id);
+`
+
+// Migration 14: Heartbeats for running agents, so restarts only orphan stale runs.
+const migration14 = `
+ALTER TABLE agent_runs ADD COLUMN last_heartbeat DATETIME;
+`
+
+// migration15 adds the retry backoff time to merge queue entries.
+const migration15 = `
+ALTER TABLE merge_queue ADD COLUMN next_attempt_at DATETIME;
+`
+
+// migration16 adds named config profiles.
+const migration16 = `
+CREATE TABLE IF NOT EXISTS config_profiles (
+    name TEXT PRIMARY KEY,
+    config_values TEXT NOT NULL,
+    providers TEXT,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+`
+
+// migration17 indexes agent runs by start time for recent-run queries.
+const migration17 = `
+CREATE INDEX IF NOT EXISTS idx_agent_runs_started_at ON agent_runs(started_at);
+`
+
+// migration18 adds per-ticket review stage skips, overriding the board default.
+const migration18 = `
+ALTER TABLE tickets ADD COLUMN skip_stages TEXT;
+`
+
+// migration19 records where review-found follow-up tickets came from, and typed
+// links between tickets.
+const migration19 = `
+ALTER TABLE tickets ADD COLUMN source_ticket_id TEXT;
+ALTER TABLE tickets ADD COLUMN links TEXT;
+`
+
+// migration20 adds per-agent generation parameters.
+const migration20 = `
+ALTER TABLE agent_provider_config ADD COLUMN temperature REAL;
+ALTER TABLE agent_provider_config ADD COLUMN max_tokens INTEGER;
+`
+
+// migration21 stores the computed supervisor context on tickets, so API reads
+// include it without recomputing against the whole board.
+const migration21 = `
+ALTER TABLE tickets ADD COLUMN blocked_reason TEXT;
+ALTER TABLE tickets ADD COLUMN creation_context TEXT;
+`
+
+// migration22 adds the per-ticket pause flag.
+const migration22 = `
+ALTER TABLE tickets ADD COLUMN paused INTEGER NOT NULL DEFAULT 0;
+`
+
+// migration23 adds the persistent activity feed.
+const migration23 = `
+CREATE TABLE IF NOT EXISTS activity_feed (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    kind TEXT NOT NULL,
+    ticket_id TEXT,
+    message TEXT NOT NULL,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_activity_feed_created ON activity_feed(created_at);
+`
+
+// migration24 adds structured run metadata so runs can be reported without
+// joining the audit log.
+const migration24 = `
+ALTER TABLE agent_runs ADD COLUMN model TEXT NOT NULL DEFAULT '';
+ALTER TABLE agent_runs ADD COLUMN token_input INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE agent_runs ADD COLUMN token_output INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE agent_runs ADD COLUMN exit_reason TEXT NOT NULL DEFAULT '';
+`
+
+// migration25 stores the latest test command run on each ticket.
+const migration25 = `
+ALTER TABLE tickets ADD COLUMN test_run TEXT;
+`
+
+// migration26 adds per-ticket provider/model overrides.
+const migration26 = `
+ALTER TABLE tickets ADD COLUMN provider_override TEXT;
+`
+
+// migration27 adds advisory locks, held by the running orchestrator so two
+// processes can't drive the same board.
+const migration27 = `
+CREATE TABLE IF NOT EXISTS locks (
+    name TEXT PRIMARY KEY,
+    owner TEXT NOT NULL,
+    acquired_at DATETIME NOT NULL,
+    heartbeat_at DATETIME NOT NULL
+);
+`
+
+// migration28 adds PM chat messages left unanswered after the provider kept
+// failing, so they can be retried instead of dropped.
+const migration28 = `
+CREATE TABLE IF NOT EXISTS pm_pending_responses (
+    id TEXT PRIMARY KEY,
+    ticket_id TEXT NOT NULL,
+    conversation_id TEXT NOT NULL,
+    message TEXT NOT NULL,
+    attempts INTEGER DEFAULT 0,
+    last_error TEXT,
+    created_at DATETIME NOT NULL,
+    updated_at DATETIME NOT NULL
+);
+`
+
+// migration29 adds the time a merged worktree kept for inspection is due to be
+// removed.
+const migration29 = `
+ALTER TABLE worktree_pool ADD COLUMN cleanup_after DATETIME;
+`
+
+// migration30 adds a full-text index over ticket titles, descriptions and
+// notes for keyword search. Triggers keep it in step with the tickets table,
+// keyed by ticket ID rather than rowid so it survives a VACUUM.
+const migration30 = `
+CREATE VIRTUAL TABLE IF NOT EXISTS tickets_fts USING fts5(
+    ticket_id UNINDEXED,
+    title,
+    description,
+    notes
+);
+
+INSERT INTO tickets_fts (ticket_id, title, description, notes)
+SELECT id, title, COALESCE(description, ''), COALESCE(notes, '') FROM tickets;
+
+CREATE TRIGGER IF NOT EXISTS tickets_fts_insert AFTER INSERT ON tickets BEGIN
+    INSERT INTO tickets_fts (ticket_id, title, description, notes)
+    VALUES (new.id, new.title, COALESCE(new.description, ''), COALESCE(new.notes, ''));
+END;
+
+CREATE TRIGGER IF NOT EXISTS tickets_fts_update AFTER UPDATE OF title, description, notes ON tickets BEGIN
+    DELETE FROM tickets_fts WHERE ticket_id = old.id;
+    INSERT INTO tickets_fts (ticket_id, title, description, notes)
+    VALUES (new.id, new.title, COALESCE(new.description, ''), COALESCE(new.notes, ''));
+END;
+
+CREATE TRIGGER IF NOT EXISTS tickets_fts_delete AFTER DELETE ON tickets BEGIN
+    DELETE FROM tickets_fts WHERE ticket_id = old.id;
+END;
+`
+
+// migration31 adds the ticket version used for optimistic locking.
+const migration31 = `
+ALTER TABLE tickets ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+`
+
+// migration32 adds the digests recorded when iterations complete.
+const migration32 = `
+CREATE TABLE IF NOT EXISTS iteration_digests (
+    iteration_id TEXT PRIMARY KEY,
+    digest TEXT NOT NULL,
+    created_at DATETIME NOT NULL
+);
+`
+
 // Close closes the database connection.
 func (d *DB) Close() error {
 	return d.DB.Close()
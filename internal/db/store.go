@@ -5,7 +5,11 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"html"
+	"sort"
+	"strings"
 	"time"
+	"unicode"
 
 	"github.com/madhatter5501/Factory/agents/provider"
 	"github.com/madhatter5501/Factory/kanban"
@@ -14,6 +18,8 @@ import (
 // Store implements kanban state storage using SQLite.
 type Store struct {
 	db *DB
+
+	statusHooks []func(kanban.StatusChange) // Called after each status change commits
 }
 
 // NewStore creates a new SQLite-backed store.
@@ -33,8 +39,11 @@ func mustMarshal(v interface{}) []byte {
 
 // --- Ticket Operations ---
 
-// CreateTicket creates a new ticket.
+// CreateTicket creates a new ticket. Tickets without acceptance criteria get
+// the configured defaults for their type.
 func (s *Store) CreateTicket(t *kanban.Ticket) error {
+	s.getBoardConfig().ApplyDefaultAcceptanceCriteria(t)
+
 	files := mustMarshal(t.Files)
 	deps := mustMarshal(t.Dependencies)
 	criteria := mustMarshal(t.AcceptanceCriteria)
@@ -42,6 +51,9 @@ func (s *Store) CreateTicket(t *kanban.Ticket) error {
 	signoffs := mustMarshal(t.Signoffs)
 	bugs := mustMarshal(t.Bugs)
 	conversation := mustMarshal(t.Conversation)
+	skipStages := mustMarshal(t.SkipStages)
+	links := mustMarshal(t.Links)
+	providerOverride := mustMarshal(t.ProviderOverride)
 
 	_, err := s.db.Exec(`
 		INSERT INTO tickets (
@@ -49,16 +61,19 @@ func (s *Store) CreateTicket(t *kanban.Ticket) error {
 			assigned_agent, assignee, files, dependencies, acceptance_criteria,
 			requirements, signoffs, bugs, notes,
 			worktree_path, worktree_branch, worktree_active,
-			conversation, parent_id, parallel_group,
+			conversation, parent_id, parallel_group, trace_id, skip_stages,
+			source_ticket_id, links, provider_override,
 			created_at, updated_at
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?,
+			COALESCE(NULLIF(?, ''), (SELECT trace_id FROM tickets WHERE id = ?)), ?, ?, ?, ?, ?, ?)
 	`,
 		t.ID, t.Title, t.Description, t.Domain, t.Priority, t.Type, t.Status,
 		t.AssignedAgent, t.Assignee, files, deps, criteria,
 		requirements, signoffs, bugs, t.Notes,
 		worktreePath(t.Worktree), worktreeBranch(t.Worktree), worktreeActive(t.Worktree),
-		conversation, t.ParentID, t.ParallelGroup,
-		t.CreatedAt, t.UpdatedAt,
+		conversation, parentID(t.ParentID), t.ParallelGroup,
+		t.TraceID, t.ParentID, // Sub-tickets inherit their parent's trace
+		skipStages, t.SourceTicketID, links, providerOverride, t.CreatedAt, t.UpdatedAt,
 	)
 	if err != nil {
 		return fmt.Errorf("failed to create ticket: %w", err)
@@ -75,7 +90,8 @@ func (s *Store) GetTicket(id string) (*kanban.Ticket, bool) {
 			assigned_agent, assignee, files, dependencies, acceptance_criteria,
 			requirements, signoffs, bugs, notes,
 			worktree_path, worktree_branch, worktree_active,
-			conversation, parent_id, parallel_group,
+			conversation, parent_id, parallel_group, trace_id, skip_stages,
+			source_ticket_id, links, blocked_reason, creation_context, paused, test_run, provider_override, version,
 			created_at, updated_at
 		FROM tickets WHERE id = ?
 	`, id)
@@ -101,9 +117,10 @@ func (s *Store) GetAllTickets() ([]kanban.Ticket, error) {
 			assigned_agent, assignee, files, dependencies, acceptance_criteria,
 			requirements, signoffs, bugs, notes,
 			worktree_path, worktree_branch, worktree_active,
-			conversation, parent_id, parallel_group,
+			conversation, parent_id, parallel_group, trace_id, skip_stages,
+			source_ticket_id, links, blocked_reason, creation_context, paused, test_run, provider_override, version,
 			created_at, updated_at
-		FROM tickets ORDER BY priority, created_at
+		FROM tickets ORDER BY priority, created_at, id
 	`)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query tickets: %w", err)
@@ -128,6 +145,137 @@ func (s *Store) GetAllTickets() ([]kanban.Ticket, error) {
 	return tickets, nil
 }
 
+// Ticket search result bounds for SearchTickets.
+const (
+	DefaultTicketSearchLimit = 20
+	MaxTicketSearchLimit     = 100
+)
+
+// SearchTickets returns the tickets whose title, description or notes match
+// the words in query, best match first, each with a highlighted excerpt in
+// SearchSnippet. Words match as prefixes and all must appear. A limit of zero
+// means DefaultTicketSearchLimit; larger limits are capped at
+// MaxTicketSearchLimit.
+func (s *Store) SearchTickets(query string, limit int) ([]kanban.Ticket, error) {
+	if limit <= 0 {
+		limit = DefaultTicketSearchLimit
+	}
+	if limit > MaxTicketSearchLimit {
+		limit = MaxTicketSearchLimit
+	}
+	tickets := []kanban.Ticket{}
+	match := ftsMatchQuery(query)
+	if match == "" {
+		return tickets, nil
+	}
+
+	// Title matches weigh most, notes least; the snippet comes from the best
+	// matching column with the matches between control characters, so the
+	// text can be escaped before they become <mark> tags.
+	rows, err := s.db.Query(`
+		SELECT ticket_id, snippet(tickets_fts, -1, char(2), char(3), '…', 16)
+		FROM tickets_fts WHERE tickets_fts MATCH ?
+		ORDER BY bm25(tickets_fts, 0.0, 10.0, 4.0, 1.0) LIMIT ?
+	`, match, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search tickets: %w", err)
+	}
+	type hit struct{ id, snippet string }
+	var hits []hit
+	for rows.Next() {
+		var h hit
+		if err := rows.Scan(&h.id, &h.snippet); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan search result: %w", err)
+		}
+		hits = append(hits, h)
+	}
+	rows.Close()
+
+	highlighter := strings.NewReplacer("\x02", "<mark>", "\x03", "</mark>")
+	for _, h := range hits {
+		t, found := s.GetTicket(h.id)
+		if !found {
+			continue
+		}
+		t.SearchSnippet = highlighter.Replace(html.EscapeString(h.snippet))
+		tickets = append(tickets, *t)
+	}
+	return tickets, nil
+}
+
+// ftsMatchQuery turns free text into an FTS5 query requiring every word as a
+// prefix, dropping punctuation that FTS5 would parse as syntax.
+func ftsMatchQuery(query string) string {
+	words := strings.FieldsFunc(query, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	terms := make([]string, len(words))
+	for i, word := range words {
+		terms[i] = `"` + word + `"*`
+	}
+	return strings.Join(terms, " ")
+}
+
+// Ticket page size bounds for GetTicketsPaged.
+const (
+	DefaultTicketPageSize = 100
+	MaxTicketPageSize     = 500
+)
+
+// GetTicketsPaged returns one page of tickets, in GetAllTickets' order and
+// with their tags, along with the total number of tickets. A limit of zero
+// means DefaultTicketPageSize and larger limits are capped at
+// MaxTicketPageSize; an offset past the end returns an empty page.
+func (s *Store) GetTicketsPaged(limit, offset int) ([]kanban.Ticket, int, error) {
+	if limit <= 0 {
+		limit = DefaultTicketPageSize
+	}
+	if limit > MaxTicketPageSize {
+		limit = MaxTicketPageSize
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	var total int
+	if err := s.db.QueryRow(`SELECT COUNT(*) FROM tickets`).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count tickets: %w", err)
+	}
+
+	rows, err := s.db.Query(`
+		SELECT id, title, description, domain, priority, type, status,
+			assigned_agent, assignee, files, dependencies, acceptance_criteria,
+			requirements, signoffs, bugs, notes,
+			worktree_path, worktree_branch, worktree_active,
+			conversation, parent_id, parallel_group, trace_id, skip_stages,
+			source_ticket_id, links, blocked_reason, creation_context, paused, test_run, provider_override, version,
+			created_at, updated_at
+		FROM tickets ORDER BY priority, created_at, id LIMIT ? OFFSET ?
+	`, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query tickets: %w", err)
+	}
+	defer rows.Close()
+
+	tickets := []kanban.Ticket{}
+	for rows.Next() {
+		t, err := scanTicketRows(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		tickets = append(tickets, *t)
+	}
+
+	// Load tags for just this page's tickets
+	if err := s.loadTagsForTickets(tickets); err != nil {
+		// Log but don't fail - tags are supplementary
+		_ = err
+	}
+
+	return tickets, total, nil
+}
+
 // loadTagsForTickets efficiently loads tags for multiple tickets in a single query.
 func (s *Store) loadTagsForTickets(tickets []kanban.Ticket) error {
 	if len(tickets) == 0 {
@@ -191,7 +339,8 @@ func (s *Store) GetTicketsByStatus(status kanban.Status) []kanban.Ticket {
 			assigned_agent, assignee, files, dependencies, acceptance_criteria,
 			requirements, signoffs, bugs, notes,
 			worktree_path, worktree_branch, worktree_active,
-			conversation, parent_id, parallel_group,
+			conversation, parent_id, parallel_group, trace_id, skip_stages,
+			source_ticket_id, links, blocked_reason, creation_context, paused, test_run, provider_override, version,
 			created_at, updated_at
 		FROM tickets WHERE status = ? ORDER BY priority, created_at
 	`, status)
@@ -212,8 +361,37 @@ func (s *Store) GetTicketsByStatus(status kanban.Status) []kanban.Ticket {
 	return tickets
 }
 
-// UpdateTicket updates an existing ticket.
+// ErrVersionConflict is returned when a ticket changed since it was loaded.
+var ErrVersionConflict = errors.New("ticket was modified since it was loaded")
+
+// UpdateTicket updates an existing ticket, whatever its stored version.
 func (s *Store) UpdateTicket(t *kanban.Ticket) error {
+	_, err := s.updateTicket(t, 0)
+	return err
+}
+
+// UpdateTicketIfVersion updates an existing ticket only while its stored
+// version is still version, otherwise returning ErrVersionConflict. On success
+// t.Version is advanced to the stored version.
+func (s *Store) UpdateTicketIfVersion(t *kanban.Ticket, version int) error {
+	applied, err := s.updateTicket(t, version)
+	if err != nil {
+		return err
+	}
+	if !applied {
+		var stored int
+		if err := s.db.QueryRow(`SELECT version FROM tickets WHERE id = ?`, t.ID).Scan(&stored); err == nil {
+			return fmt.Errorf("%w: ticket %s is at version %d, not %d", ErrVersionConflict, t.ID, stored, version)
+		}
+		return nil
+	}
+	t.Version = version + 1
+	return nil
+}
+
+// updateTicket writes t, when version is non-zero only if the stored version
+// matches, and reports whether a row was updated.
+func (s *Store) updateTicket(t *kanban.Ticket, version int) (bool, error) {
 	files := mustMarshal(t.Files)
 	deps := mustMarshal(t.Dependencies)
 	criteria := mustMarshal(t.AcceptanceCriteria)
@@ -221,29 +399,33 @@ func (s *Store) UpdateTicket(t *kanban.Ticket) error {
 	signoffs := mustMarshal(t.Signoffs)
 	bugs := mustMarshal(t.Bugs)
 	conversation := mustMarshal(t.Conversation)
+	skipStages := mustMarshal(t.SkipStages)
+	links := mustMarshal(t.Links)
+	providerOverride := mustMarshal(t.ProviderOverride)
 
-	_, err := s.db.Exec(`
+	res, err := s.db.Exec(`
 		UPDATE tickets SET
 			title = ?, description = ?, domain = ?, priority = ?, type = ?, status = ?,
 			assigned_agent = ?, assignee = ?, files = ?, dependencies = ?, acceptance_criteria = ?,
 			requirements = ?, signoffs = ?, bugs = ?, notes = ?,
 			worktree_path = ?, worktree_branch = ?, worktree_active = ?,
-			conversation = ?, parent_id = ?, parallel_group = ?,
-			updated_at = ?
-		WHERE id = ?
+			conversation = ?, parent_id = ?, parallel_group = ?, skip_stages = ?,
+			source_ticket_id = ?, links = ?, provider_override = ?, updated_at = ?,
+			version = version + 1
+		WHERE id = ? AND (? = 0 OR version = ?)
 	`,
 		t.Title, t.Description, t.Domain, t.Priority, t.Type, t.Status,
 		t.AssignedAgent, t.Assignee, files, deps, criteria,
 		requirements, signoffs, bugs, t.Notes,
 		worktreePath(t.Worktree), worktreeBranch(t.Worktree), worktreeActive(t.Worktree),
-		conversation, t.ParentID, t.ParallelGroup,
-		time.Now(), t.ID,
+		conversation, parentID(t.ParentID), t.ParallelGroup, skipStages,
+		t.SourceTicketID, links, providerOverride, time.Now(), t.ID, version, version,
 	)
 	if err != nil {
-		return fmt.Errorf("failed to update ticket: %w", err)
+		return false, fmt.Errorf("failed to update ticket: %w", err)
 	}
-
-	return nil
+	n, _ := res.RowsAffected()
+	return n > 0, nil
 }
 
 // UpdateTicketStatus updates a ticket's status and records history.
@@ -254,9 +436,13 @@ func (s *Store) UpdateTicketStatus(id string, status kanban.Status, by, note str
 	}
 	defer func() { _ = tx.Rollback() }()
 
+	var from kanban.Status
+	_ = tx.QueryRow(`SELECT status FROM tickets WHERE id = ?`, id).Scan(&from)
+
+	now := time.Now()
 	_, err = tx.Exec(`
-		UPDATE tickets SET status = ?, updated_at = ? WHERE id = ?
-	`, status, time.Now(), id)
+		UPDATE tickets SET status = ?, updated_at = ?, version = version + 1 WHERE id = ?
+	`, status, now, id)
 	if err != nil {
 		return fmt.Errorf("failed to update status: %w", err)
 	}
@@ -269,7 +455,73 @@ func (s *Store) UpdateTicketStatus(id string, status kanban.Status, by, note str
 		return fmt.Errorf("failed to add history: %w", err)
 	}
 
-	return tx.Commit()
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	// The feed is best-effort; a failed entry must not fail the transition
+	_ = s.AddActivity(&kanban.Activity{
+		Kind:     kanban.ActivityStatusChange,
+		TicketID: id,
+		Message:  fmt.Sprintf("Moved to %s by %s", status, by),
+	})
+
+	change := kanban.StatusChange{TicketID: id, From: from, To: status, By: by, Note: note, At: now}
+	for _, hook := range s.statusHooks {
+		hook(change)
+	}
+	return nil
+}
+
+// OnStatusChange registers fn to be called after each UpdateTicketStatus
+// commits. Hooks run synchronously, so slow work belongs in a goroutine, and
+// must be registered before the store is shared.
+func (s *Store) OnStatusChange(fn func(kanban.StatusChange)) {
+	s.statusHooks = append(s.statusHooks, fn)
+}
+
+// SetTicketPaused pauses or resumes agent work on a ticket. It is kept out of
+// UpdateTicket so agents saving a ticket they loaded earlier can't undo a pause.
+func (s *Store) SetTicketPaused(id string, paused bool) error {
+	_, err := s.db.Exec(`
+		UPDATE tickets SET paused = ?, updated_at = ? WHERE id = ?
+	`, paused, time.Now(), id)
+	if err != nil {
+		return fmt.Errorf("failed to set ticket paused: %w", err)
+	}
+	return nil
+}
+
+// RecomputeTicketContext computes every ticket's blocked reason and creation
+// context against the whole board and stores them, so reads include them
+// without recomputing. It returns the number of tickets updated. Stored context
+// can go stale as tickets change, until the next recompute.
+func (s *Store) RecomputeTicketContext() (int, error) {
+	tickets, err := s.GetAllTickets()
+	if err != nil {
+		return 0, err
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	for i := range tickets {
+		t := &tickets[i]
+		_, err := tx.Exec(`
+			UPDATE tickets SET blocked_reason = ?, creation_context = ? WHERE id = ?
+		`, mustMarshal(t.ComputeBlockedReason(tickets)), mustMarshal(t.ComputeCreationContext(tickets)), t.ID)
+		if err != nil {
+			return 0, fmt.Errorf("failed to store context for ticket %s: %w", t.ID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit ticket context: %w", err)
+	}
+	return len(tickets), nil
 }
 
 // DeleteTicket deletes a ticket.
@@ -290,12 +542,22 @@ func (s *Store) AddHistoryEntry(id string, status kanban.Status, by, note string
 // --- Agent Runs ---
 
 // AddRun adds an agent run.
+// Runs without an explicit trace ID inherit the trace of their ticket.
 func (s *Store) AddRun(run *kanban.AgentRun) error {
 	_, err := s.db.Exec(`
-		INSERT INTO agent_runs (id, agent, ticket_id, worktree, started_at, status)
-		VALUES (?, ?, ?, ?, ?, ?)
-	`, run.ID, run.Agent, run.TicketID, run.Worktree, run.StartedAt, run.Status)
-	return err
+		INSERT INTO agent_runs (id, agent, ticket_id, worktree, started_at, status, trace_id)
+		VALUES (?, ?, ?, ?, ?, ?, COALESCE(NULLIF(?, ''), (SELECT trace_id FROM tickets WHERE id = ?)))
+	`, run.ID, run.Agent, run.TicketID, run.Worktree, run.StartedAt, run.Status, run.TraceID, run.TicketID)
+	if err != nil {
+		return err
+	}
+
+	_ = s.AddActivity(&kanban.Activity{
+		Kind:     kanban.ActivityAgentSpawned,
+		TicketID: run.TicketID,
+		Message:  fmt.Sprintf("Spawned %s agent", run.Agent),
+	})
+	return nil
 }
 
 // CompleteRun marks a run as complete.
@@ -305,10 +567,18 @@ func (s *Store) CompleteRun(id, status, output string) {
 	`, time.Now(), status, output, id)
 }
 
+// SetRunMetadata records the model, token usage, and exit reason of a run.
+func (s *Store) SetRunMetadata(id string, meta kanban.RunMetadata) {
+	_, _ = s.db.Exec(`
+		UPDATE agent_runs SET model = ?, token_input = ?, token_output = ?, exit_reason = ? WHERE id = ?
+	`, meta.Model, meta.TokenInput, meta.TokenOutput, meta.ExitReason, id)
+}
+
 // GetActiveRuns returns all running agent runs.
 func (s *Store) GetActiveRuns() []kanban.AgentRun {
 	rows, err := s.db.Query(`
-		SELECT id, agent, ticket_id, worktree, started_at, ended_at, status, output
+		SELECT id, agent, ticket_id, worktree, started_at, ended_at, status, output,
+			model, token_input, token_output, exit_reason
 		FROM agent_runs WHERE status = 'running'
 	`)
 	if err != nil {
@@ -322,7 +592,8 @@ func (s *Store) GetActiveRuns() []kanban.AgentRun {
 		var endedAt sql.NullTime
 		var output sql.NullString
 		err := rows.Scan(&run.ID, &run.Agent, &run.TicketID, &run.Worktree,
-			&run.StartedAt, &endedAt, &run.Status, &output)
+			&run.StartedAt, &endedAt, &run.Status, &output,
+			&run.Model, &run.TokenInput, &run.TokenOutput, &run.ExitReason)
 		if err != nil {
 			continue
 		}
@@ -340,55 +611,329 @@ func (s *Store) GetActiveRuns() []kanban.AgentRun {
 
 // --- Config ---
 
-// GetConfigValue retrieves a config value by key.
+// GetConfigValue retrieves a config value by key, served from the in-memory
+// config cache while it is fresh.
 func (s *Store) GetConfigValue(key string) (string, error) {
-	var value string
-	err := s.db.QueryRow("SELECT value FROM config WHERE key = ?", key).Scan(&value)
-	if err == sql.ErrNoRows {
-		return "", nil
+	if !s.db.configCache.enabled() {
+		var value string
+		err := s.db.QueryRow("SELECT value FROM config WHERE key = ?", key).Scan(&value)
+		if err == sql.ErrNoRows {
+			return "", nil
+		}
+		return value, err
 	}
-	return value, err
+	return s.db.configCache.get(key, s.GetAllConfig)
 }
 
 // SetConfig sets a config value.
 func (s *Store) SetConfig(key, value string) error {
+	defer s.db.configCache.invalidate()
 	_, err := s.db.Exec(`
 		INSERT OR REPLACE INTO config (key, value) VALUES (?, ?)
 	`, key, value)
 	return err
 }
 
+// --- Config Profiles ---
+
+// ActiveConfigProfileKey is the config key recording the last activated profile.
+const ActiveConfigProfileKey = "active_config_profile"
+
+// GetAllConfig returns every config value keyed by name.
+func (s *Store) GetAllConfig() (map[string]string, error) {
+	rows, err := s.db.Query("SELECT key, value FROM config")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get config: %w", err)
+	}
+	defer rows.Close()
+
+	values := make(map[string]string)
+	for rows.Next() {
+		var key, value string
+		if err := rows.Scan(&key, &value); err != nil {
+			return nil, fmt.Errorf("failed to scan config: %w", err)
+		}
+		values[key] = value
+	}
+	return values, rows.Err()
+}
+
+// isProfileKey reports whether a config key belongs in a profile: settings in
+// ConfigSchema and per-provider default models. Runtime state, such as the
+// current iteration or which profile is active, is left out.
+func isProfileKey(key string) bool {
+	if _, ok := LookupConfigKey(key); ok {
+		return true
+	}
+	return strings.HasPrefix(key, provider.DefaultModelConfigKey(""))
+}
+
+// CurrentConfigProfile captures the current settings and agent providers as a
+// profile with the given name. The profile is not saved.
+func (s *Store) CurrentConfigProfile(name string) (*kanban.ConfigProfile, error) {
+	values, err := s.GetAllConfig()
+	if err != nil {
+		return nil, err
+	}
+	for key := range values {
+		if !isProfileKey(key) {
+			delete(values, key)
+		}
+	}
+
+	configs, err := s.GetAllAgentProviderConfigs()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get provider configs: %w", err)
+	}
+	providers := make(map[string]kanban.ProfileProvider, len(configs))
+	for _, cfg := range configs {
+		providers[cfg.AgentType] = kanban.ProfileProvider{Provider: cfg.Provider, Model: cfg.Model}
+	}
+
+	return &kanban.ConfigProfile{Name: name, Values: values, Providers: providers}, nil
+}
+
+// SaveConfigProfile creates or replaces a config profile.
+func (s *Store) SaveConfigProfile(profile *kanban.ConfigProfile) error {
+	valuesJSON, err := json.Marshal(profile.Values)
+	if err != nil {
+		return fmt.Errorf("failed to marshal profile values: %w", err)
+	}
+	providersJSON, err := json.Marshal(profile.Providers)
+	if err != nil {
+		return fmt.Errorf("failed to marshal profile providers: %w", err)
+	}
+
+	now := time.Now()
+	if profile.CreatedAt.IsZero() {
+		profile.CreatedAt = now
+	}
+	profile.UpdatedAt = now
+
+	_, err = s.db.Exec(`
+		INSERT INTO config_profiles (name, config_values, providers, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(name) DO UPDATE SET
+			config_values = excluded.config_values,
+			providers = excluded.providers,
+			updated_at = excluded.updated_at
+	`, profile.Name, string(valuesJSON), string(providersJSON), profile.CreatedAt, profile.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save config profile: %w", err)
+	}
+	return nil
+}
+
+// GetConfigProfile retrieves a config profile by name.
+func (s *Store) GetConfigProfile(name string) (*kanban.ConfigProfile, error) {
+	row := s.db.QueryRow(`
+		SELECT name, config_values, providers, created_at, updated_at
+		FROM config_profiles WHERE name = ?
+	`, name)
+
+	profile, err := scanConfigProfile(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get config profile: %w", err)
+	}
+
+	active, _ := s.GetConfigValue(ActiveConfigProfileKey)
+	profile.Active = profile.Name == active
+	return profile, nil
+}
+
+// GetConfigProfiles returns all config profiles ordered by name.
+func (s *Store) GetConfigProfiles() ([]kanban.ConfigProfile, error) {
+	rows, err := s.db.Query(`
+		SELECT name, config_values, providers, created_at, updated_at
+		FROM config_profiles ORDER BY name
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get config profiles: %w", err)
+	}
+	defer rows.Close()
+
+	active, _ := s.GetConfigValue(ActiveConfigProfileKey)
+	var profiles []kanban.ConfigProfile
+	for rows.Next() {
+		profile, err := scanConfigProfile(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan config profile: %w", err)
+		}
+		profile.Active = profile.Name == active
+		profiles = append(profiles, *profile)
+	}
+	return profiles, rows.Err()
+}
+
+// ActivateConfigProfile applies every setting and agent provider in the named
+// profile and records it as the active profile. Config keys the profile does
+// not mention, and runtime state a profile can't hold, are left unchanged.
+func (s *Store) ActivateConfigProfile(name string) error {
+	profile, err := s.GetConfigProfile(name)
+	if err != nil {
+		return err
+	}
+	if profile == nil {
+		return fmt.Errorf("config profile %q not found", name)
+	}
+
+	// Resolve models before the transaction so defaults are read outside it
+	models := make(map[string]string, len(profile.Providers))
+	for agentType, p := range profile.Providers {
+		model := p.Model
+		if model == "" {
+			// Prefer the profile's own default for the provider over the current one
+			model = profile.Values[provider.DefaultModelConfigKey(p.Provider)]
+		}
+		if model == "" {
+			model = s.GetProviderDefaultModel(p.Provider)
+		}
+		models[agentType] = model
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+	defer s.db.configCache.invalidate()
+
+	for key, value := range profile.Values {
+		if !isProfileKey(key) {
+			continue
+		}
+		if _, err := tx.Exec(`
+			INSERT OR REPLACE INTO config (key, value) VALUES (?, ?)
+		`, key, value); err != nil {
+			return fmt.Errorf("failed to apply config %s: %w", key, err)
+		}
+	}
+
+	for agentType, p := range profile.Providers {
+		// Upsert rather than replace so custom system prompts are kept
+		if _, err := tx.Exec(`
+			INSERT INTO agent_provider_config (agent_type, provider, model, updated_at)
+			VALUES (?, ?, ?, CURRENT_TIMESTAMP)
+			ON CONFLICT(agent_type) DO UPDATE SET
+				provider = excluded.provider,
+				model = excluded.model,
+				updated_at = CURRENT_TIMESTAMP
+		`, agentType, p.Provider, models[agentType]); err != nil {
+			return fmt.Errorf("failed to apply provider for %s: %w", agentType, err)
+		}
+	}
+
+	if _, err := tx.Exec(`
+		INSERT OR REPLACE INTO config (key, value) VALUES (?, ?)
+	`, ActiveConfigProfileKey, name); err != nil {
+		return fmt.Errorf("failed to record active profile: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// scanConfigProfile scans a config profile row.
+func scanConfigProfile(row scanner) (*kanban.ConfigProfile, error) {
+	var profile kanban.ConfigProfile
+	var valuesJSON string
+	var providersJSON sql.NullString
+
+	if err := row.Scan(&profile.Name, &valuesJSON, &providersJSON, &profile.CreatedAt, &profile.UpdatedAt); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(valuesJSON), &profile.Values); err != nil {
+		return nil, fmt.Errorf("failed to parse profile values: %w", err)
+	}
+	if providersJSON.Valid && providersJSON.String != "" {
+		if err := json.Unmarshal([]byte(providersJSON.String), &profile.Providers); err != nil {
+			return nil, fmt.Errorf("failed to parse profile providers: %w", err)
+		}
+	}
+	return &profile, nil
+}
+
 // --- Provider Config ---
 
 // GetAgentProviderConfig retrieves the provider config for an agent type.
 func (s *Store) GetAgentProviderConfig(agentType string) (*provider.AgentProviderConfig, error) {
-	var cfg provider.AgentProviderConfig
-	var systemPrompt sql.NullString
-	err := s.db.QueryRow(`
-		SELECT agent_type, provider, model, system_prompt, updated_at
+	cfg, err := scanAgentProviderConfig(s.db.QueryRow(`
+		SELECT agent_type, provider, model, system_prompt, temperature, max_tokens, updated_at
 		FROM agent_provider_config WHERE agent_type = ?
-	`, agentType).Scan(&cfg.AgentType, &cfg.Provider, &cfg.Model, &systemPrompt, &cfg.UpdatedAt)
+	`, agentType))
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
 	if err != nil {
 		return nil, err
 	}
+	return cfg, nil
+}
+
+// scanAgentProviderConfig scans an agent provider config row.
+func scanAgentProviderConfig(row scanner) (*provider.AgentProviderConfig, error) {
+	var cfg provider.AgentProviderConfig
+	var systemPrompt sql.NullString
+	var temperature sql.NullFloat64
+	var maxTokens sql.NullInt64
+	if err := row.Scan(&cfg.AgentType, &cfg.Provider, &cfg.Model, &systemPrompt, &temperature, &maxTokens, &cfg.UpdatedAt); err != nil {
+		return nil, err
+	}
 	if systemPrompt.Valid {
 		cfg.SystemPrompt = systemPrompt.String
 	}
+	if temperature.Valid {
+		cfg.Temperature = &temperature.Float64
+	}
+	if maxTokens.Valid {
+		cfg.MaxTokens = int(maxTokens.Int64)
+	}
 	return &cfg, nil
 }
 
+// GetProviderDefaultModel returns the configured default model for a provider,
+// falling back to the provider package's built-in default.
+func (s *Store) GetProviderDefaultModel(providerName string) string {
+	if model, _ := s.GetConfigValue(provider.DefaultModelConfigKey(providerName)); model != "" {
+		return model
+	}
+	return provider.DefaultModel(providerName)
+}
+
 // SetAgentProviderConfig sets the provider config for an agent type.
+// An empty model is filled in with the provider's default model.
 func (s *Store) SetAgentProviderConfig(agentType, providerName, model string) error {
+	if model == "" {
+		model = s.GetProviderDefaultModel(providerName)
+	}
+	// Upsert rather than replace so system prompts and generation params are kept
 	_, err := s.db.Exec(`
-		INSERT OR REPLACE INTO agent_provider_config (agent_type, provider, model, updated_at)
+		INSERT INTO agent_provider_config (agent_type, provider, model, updated_at)
 		VALUES (?, ?, ?, CURRENT_TIMESTAMP)
+		ON CONFLICT(agent_type) DO UPDATE SET
+			provider = excluded.provider,
+			model = excluded.model,
+			updated_at = CURRENT_TIMESTAMP
 	`, agentType, providerName, model)
 	return err
 }
 
+// SetAgentGenerationParams sets the temperature and max tokens for an agent
+// type. A nil temperature or zero max tokens reverts to the default.
+func (s *Store) SetAgentGenerationParams(agentType string, temperature *float64, maxTokens int) error {
+	var tokens sql.NullInt64
+	if maxTokens > 0 {
+		tokens = sql.NullInt64{Int64: int64(maxTokens), Valid: true}
+	}
+	_, err := s.db.Exec(`
+		UPDATE agent_provider_config SET temperature = ?, max_tokens = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE agent_type = ?
+	`, temperature, tokens, agentType)
+	return err
+}
+
 // SetAgentSystemPrompt updates the system prompt for an agent type.
 func (s *Store) SetAgentSystemPrompt(agentType, systemPrompt string) error {
 	_, err := s.db.Exec(`
@@ -401,7 +946,7 @@ func (s *Store) SetAgentSystemPrompt(agentType, systemPrompt string) error {
 // GetAllAgentProviderConfigs retrieves all agent provider configs.
 func (s *Store) GetAllAgentProviderConfigs() ([]provider.AgentProviderConfig, error) {
 	rows, err := s.db.Query(`
-		SELECT agent_type, provider, model, system_prompt, updated_at
+		SELECT agent_type, provider, model, system_prompt, temperature, max_tokens, updated_at
 		FROM agent_provider_config ORDER BY agent_type
 	`)
 	if err != nil {
@@ -411,15 +956,11 @@ func (s *Store) GetAllAgentProviderConfigs() ([]provider.AgentProviderConfig, er
 
 	var configs []provider.AgentProviderConfig
 	for rows.Next() {
-		var cfg provider.AgentProviderConfig
-		var systemPrompt sql.NullString
-		if err := rows.Scan(&cfg.AgentType, &cfg.Provider, &cfg.Model, &systemPrompt, &cfg.UpdatedAt); err != nil {
+		cfg, err := scanAgentProviderConfig(rows)
+		if err != nil {
 			return nil, err
 		}
-		if systemPrompt.Valid {
-			cfg.SystemPrompt = systemPrompt.String
-		}
-		configs = append(configs, cfg)
+		configs = append(configs, *cfg)
 	}
 	return configs, rows.Err()
 }
@@ -428,9 +969,22 @@ func (s *Store) GetAllAgentProviderConfigs() ([]provider.AgentProviderConfig, er
 
 // GetStats returns ticket counts by status.
 func (s *Store) GetStats() map[kanban.Status]int {
-	rows, err := s.db.Query(`
-		SELECT status, COUNT(*) FROM tickets GROUP BY status
+	return s.queryStats(`SELECT status, COUNT(*) FROM tickets GROUP BY status`)
+}
+
+// GetStatsExcludingParents returns ticket counts by status without tickets that
+// have sub-tickets, whose progress is already counted through their children.
+func (s *Store) GetStatsExcludingParents() map[kanban.Status]int {
+	return s.queryStats(`
+		SELECT status, COUNT(*) FROM tickets t
+		WHERE NOT EXISTS (SELECT 1 FROM tickets c WHERE c.parent_id = t.id)
+		GROUP BY status
 	`)
+}
+
+// queryStats runs a status/count query, returning empty stats on failure.
+func (s *Store) queryStats(query string) map[kanban.Status]int {
+	rows, err := s.db.Query(query)
 	if err != nil {
 		return make(map[kanban.Status]int)
 	}
@@ -481,12 +1035,44 @@ func (s *Store) getHistory(ticketID string) ([]kanban.HistoryEntry, error) {
 	return history, nil
 }
 
-// Scanner interface for both sql.Row and sql.Rows.
-type scanner interface {
-	Scan(dest ...interface{}) error
-}
+// GetTicketsWithHistory retrieves all tickets with their status history loaded.
+func (s *Store) GetTicketsWithHistory() ([]kanban.Ticket, error) {
+	tickets, err := s.GetAllTickets()
+	if err != nil {
+		return nil, err
+	}
 
-func scanTicket(row *sql.Row) (*kanban.Ticket, error) {
+	rows, err := s.db.Query(`
+		SELECT ticket_id, status, changed_by, note, created_at
+		FROM ticket_history ORDER BY created_at, id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query ticket history: %w", err)
+	}
+	defer rows.Close()
+
+	history := make(map[string][]kanban.HistoryEntry)
+	for rows.Next() {
+		var ticketID string
+		var h kanban.HistoryEntry
+		if err := rows.Scan(&ticketID, &h.Status, &h.By, &h.Note, &h.At); err != nil {
+			return nil, fmt.Errorf("failed to scan ticket history: %w", err)
+		}
+		history[ticketID] = append(history[ticketID], h)
+	}
+
+	for i := range tickets {
+		tickets[i].History = history[tickets[i].ID]
+	}
+	return tickets, nil
+}
+
+// Scanner interface for both sql.Row and sql.Rows.
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanTicket(row *sql.Row) (*kanban.Ticket, error) {
 	return scanTicketGeneric(row)
 }
 
@@ -498,8 +1084,9 @@ func scanTicketGeneric(s scanner) (*kanban.Ticket, error) {
 	var t kanban.Ticket
 	var files, deps, criteria, requirements, signoffs, bugs, conversation sql.NullString
 	var wtPath, wtBranch sql.NullString
-	var wtActive int
-	var parentID sql.NullString
+	var wtActive, paused int
+	var parentID, traceID, skipStages, sourceTicketID, links sql.NullString
+	var blockedReason, creationContext, testRun, providerOverride sql.NullString
 	var assignedAgent, assignee, notes, description sql.NullString
 
 	err := s.Scan(
@@ -507,7 +1094,8 @@ func scanTicketGeneric(s scanner) (*kanban.Ticket, error) {
 		&assignedAgent, &assignee, &files, &deps, &criteria,
 		&requirements, &signoffs, &bugs, &notes,
 		&wtPath, &wtBranch, &wtActive,
-		&conversation, &parentID, &t.ParallelGroup,
+		&conversation, &parentID, &t.ParallelGroup, &traceID, &skipStages,
+		&sourceTicketID, &links, &blockedReason, &creationContext, &paused, &testRun, &providerOverride, &t.Version,
 		&t.CreatedAt, &t.UpdatedAt,
 	)
 	if err != nil {
@@ -550,11 +1138,36 @@ func scanTicketGeneric(s scanner) (*kanban.Ticket, error) {
 	if conversation.Valid {
 		_ = json.Unmarshal([]byte(conversation.String), &t.Conversation)
 	}
+	if skipStages.Valid {
+		_ = json.Unmarshal([]byte(skipStages.String), &t.SkipStages)
+	}
+	if links.Valid {
+		_ = json.Unmarshal([]byte(links.String), &t.Links)
+	}
+	if blockedReason.Valid {
+		_ = json.Unmarshal([]byte(blockedReason.String), &t.BlockedReason)
+	}
+	if creationContext.Valid {
+		_ = json.Unmarshal([]byte(creationContext.String), &t.CreationContext)
+	}
+	if testRun.Valid {
+		_ = json.Unmarshal([]byte(testRun.String), &t.TestRun)
+	}
+	if providerOverride.Valid {
+		_ = json.Unmarshal([]byte(providerOverride.String), &t.ProviderOverride)
+	}
+	t.Paused = paused != 0
 
 	// Parent ID
 	if parentID.Valid {
 		t.ParentID = parentID.String
 	}
+	if traceID.Valid {
+		t.TraceID = traceID.String
+	}
+	if sourceTicketID.Valid {
+		t.SourceTicketID = sourceTicketID.String
+	}
 
 	// Worktree
 	if wtPath.Valid && wtPath.String != "" {
@@ -568,6 +1181,14 @@ func scanTicketGeneric(s scanner) (*kanban.Ticket, error) {
 	return &t, nil
 }
 
+// parentID maps an empty parent to NULL so the parent_id foreign key is satisfied.
+func parentID(id string) interface{} {
+	if id == "" {
+		return nil
+	}
+	return id
+}
+
 func worktreePath(w *kanban.Worktree) string {
 	if w == nil {
 		return ""
@@ -641,6 +1262,46 @@ func (s *Store) getBoardConfig() kanban.BoardConfig {
 	if v, _ := s.GetConfigValue("branch_prefix"); v != "" {
 		config.BranchPrefix = v
 	}
+	if v, _ := s.GetConfigValue("rebase_before_qa"); v != "" {
+		config.RebaseBeforeQA = v == "true"
+	}
+	if v, _ := s.GetConfigValue("default_acceptance_criteria"); v != "" {
+		// JSON object of ticket type to criteria; ignored if malformed
+		_ = json.Unmarshal([]byte(v), &config.DefaultAcceptanceCriteria)
+	}
+	if v, _ := s.GetConfigValue("skip_stages"); v != "" {
+		for _, status := range strings.Split(v, ",") {
+			if status = strings.TrimSpace(status); status != "" {
+				config.SkipStages = append(config.SkipStages, kanban.Status(strings.ToUpper(status)))
+			}
+		}
+	}
+	if v, _ := s.GetConfigValue("pipelines"); v != "" {
+		// JSON object of ticket type to stages; malformed or invalid pipelines are ignored
+		var pipelines map[string][]kanban.Status
+		if err := json.Unmarshal([]byte(v), &pipelines); err == nil {
+			for ticketType, stages := range pipelines {
+				if kanban.ValidatePipeline(stages) != nil {
+					delete(pipelines, ticketType)
+				}
+			}
+			config.Pipelines = pipelines
+		}
+	}
+	if v, _ := s.GetConfigValue("wip_limits"); v != "" {
+		// JSON object of column to limit; ignored if malformed or invalid
+		var limits map[kanban.Status]int
+		if err := json.Unmarshal([]byte(v), &limits); err == nil && kanban.ValidateWIPLimits(limits) == nil {
+			config.WIPLimits = limits
+		}
+	}
+	if v, _ := s.GetConfigValue("hidden_columns"); v != "" {
+		for _, status := range strings.Split(v, ",") {
+			if status = strings.TrimSpace(status); status != "" {
+				config.HiddenColumns = append(config.HiddenColumns, kanban.Status(strings.ToUpper(status)))
+			}
+		}
+	}
 
 	return config
 }
@@ -652,7 +1313,8 @@ func (s *Store) GetTicketsByDomain(domain kanban.Domain) []kanban.Ticket {
 			assigned_agent, assignee, files, dependencies, acceptance_criteria,
 			requirements, signoffs, bugs, notes,
 			worktree_path, worktree_branch, worktree_active,
-			conversation, parent_id, parallel_group,
+			conversation, parent_id, parallel_group, trace_id, skip_stages,
+			source_ticket_id, links, blocked_reason, creation_context, paused, test_run, provider_override, version,
 			created_at, updated_at
 		FROM tickets WHERE domain = ? ORDER BY priority, created_at
 	`, domain)
@@ -679,7 +1341,8 @@ func (s *Store) GetTicketsByParent(parentID string) []kanban.Ticket {
 			assigned_agent, assignee, files, dependencies, acceptance_criteria,
 			requirements, signoffs, bugs, notes,
 			worktree_path, worktree_branch, worktree_active,
-			conversation, parent_id, parallel_group,
+			conversation, parent_id, parallel_group, trace_id, skip_stages,
+			source_ticket_id, links, blocked_reason, creation_context, paused, test_run, provider_override, version,
 			created_at, updated_at
 		FROM tickets WHERE parent_id = ? ORDER BY parallel_group, priority, created_at
 	`, parentID)
@@ -706,7 +1369,8 @@ func (s *Store) GetTicketsByRefiningRound() []kanban.Ticket {
 			assigned_agent, assignee, files, dependencies, acceptance_criteria,
 			requirements, signoffs, bugs, notes,
 			worktree_path, worktree_branch, worktree_active,
-			conversation, parent_id, parallel_group,
+			conversation, parent_id, parallel_group, trace_id, skip_stages,
+			source_ticket_id, links, blocked_reason, creation_context, paused, test_run, provider_override, version,
 			created_at, updated_at
 		FROM tickets WHERE status LIKE 'REFINING_ROUND%' ORDER BY priority, created_at
 	`)
@@ -737,7 +1401,7 @@ func (s *Store) GetNextTicketForDomain(domain kanban.Domain) (*kanban.Ticket, bo
 	ready := s.GetTicketsByStatus(kanban.StatusReady)
 
 	for _, t := range ready {
-		if t.Domain != domain {
+		if t.Domain != domain || t.Paused {
 			continue
 		}
 		// Check dependencies are met
@@ -780,7 +1444,8 @@ func (s *Store) GetTicketByTitle(title string) (*kanban.Ticket, bool) {
 			assigned_agent, assignee, files, dependencies, acceptance_criteria,
 			requirements, signoffs, bugs, notes,
 			worktree_path, worktree_branch, worktree_active,
-			conversation, parent_id, parallel_group,
+			conversation, parent_id, parallel_group, trace_id, skip_stages,
+			source_ticket_id, links, blocked_reason, creation_context, paused, test_run, provider_override, version,
 			created_at, updated_at
 		FROM tickets WHERE title = ?
 	`, title)
@@ -797,23 +1462,7 @@ func (s *Store) hasConflict(t *kanban.Ticket) bool {
 	// Check if any in-progress ticket touches the same files
 	inProgress := s.GetTicketsByStatus(kanban.StatusInDev)
 	for _, other := range inProgress {
-		if s.filesOverlap(t.Files, other.Files) {
-			return true
-		}
-	}
-	return false
-}
-
-func (s *Store) filesOverlap(a, b []string) bool {
-	if len(a) == 0 || len(b) == 0 {
-		return false
-	}
-	fileSet := make(map[string]bool)
-	for _, f := range a {
-		fileSet[f] = true
-	}
-	for _, f := range b {
-		if fileSet[f] {
+		if kanban.FilesOverlap(t.Files, other.Files) {
 			return true
 		}
 	}
@@ -905,6 +1554,14 @@ func (s *Store) UpdateNotes(ticketID, notes string) error {
 	return err
 }
 
+// SetTestRun records the latest test command run for a ticket.
+func (s *Store) SetTestRun(ticketID string, result *kanban.TestRunResult) error {
+	_, err := s.db.Exec(`
+		UPDATE tickets SET test_run = ?, updated_at = ? WHERE id = ?
+	`, mustMarshal(result), time.Now(), ticketID)
+	return err
+}
+
 // UpdateActivity updates the current activity for a ticket.
 func (s *Store) UpdateActivity(ticketID, activity, assignee string) error {
 	t, found := s.GetTicket(ticketID)
@@ -956,11 +1613,67 @@ func (s *Store) IsIterationComplete() bool {
 	var count int
 	_ = s.db.QueryRow(`
 		SELECT COUNT(*) FROM tickets
-		WHERE status NOT IN ('DONE', 'BACKLOG')
+		WHERE status NOT IN ('DONE', 'BACKLOG', 'ICEBOX')
 	`).Scan(&count)
 	return count == 0
 }
 
+// BuildIterationDigest summarizes the iteration from the tickets, agent runs
+// and ADRs in its window. The digest is not stored; see SaveIterationDigest.
+func (s *Store) BuildIterationDigest(iteration *kanban.Iteration) (*kanban.IterationDigest, error) {
+	tickets, err := s.GetTicketsWithHistory()
+	if err != nil {
+		return nil, err
+	}
+	start, end := iteration.Window()
+	runs, err := s.GetRunsBetween(start, end)
+	if err != nil {
+		return nil, err
+	}
+	adrs, err := s.GetAllADRs()
+	if err != nil {
+		return nil, err
+	}
+	return kanban.ComputeIterationDigest(iteration, tickets, runs, adrs), nil
+}
+
+// SaveIterationDigest stores an iteration's digest, replacing any earlier one.
+func (s *Store) SaveIterationDigest(digest *kanban.IterationDigest) error {
+	data, err := json.Marshal(digest)
+	if err != nil {
+		return fmt.Errorf("failed to marshal iteration digest: %w", err)
+	}
+	_, err = s.db.Exec(`
+		INSERT OR REPLACE INTO iteration_digests (iteration_id, digest, created_at)
+		VALUES (?, ?, ?)
+	`, digest.IterationID, string(data), digest.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save iteration digest: %w", err)
+	}
+	return nil
+}
+
+// GetIterationDigest returns the digest recorded for an iteration, or nil if
+// none has been.
+func (s *Store) GetIterationDigest(iterationID string) (*kanban.IterationDigest, error) {
+	var data string
+	err := s.db.QueryRow(`
+		SELECT digest FROM iteration_digests WHERE iteration_id = ?
+	`, iterationID).Scan(&data)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get iteration digest: %w", err)
+	}
+
+	var digest kanban.IterationDigest
+	if err := json.Unmarshal([]byte(data), &digest); err != nil {
+		return nil, fmt.Errorf("failed to parse iteration digest: %w", err)
+	}
+	return &digest, nil
+}
+
 // --- Active Runs ---
 
 // AddActiveRun records a new agent run.
@@ -971,7 +1684,8 @@ func (s *Store) AddActiveRun(run kanban.AgentRun) {
 // GetActiveDevRuns returns only dev agent runs.
 func (s *Store) GetActiveDevRuns() []kanban.AgentRun {
 	rows, err := s.db.Query(`
-		SELECT id, agent, ticket_id, worktree, started_at, ended_at, status, output
+		SELECT id, agent, ticket_id, worktree, started_at, ended_at, status, output,
+			model, token_input, token_output, exit_reason
 		FROM agent_runs WHERE status = 'running' AND agent LIKE 'dev-%'
 	`)
 	if err != nil {
@@ -985,7 +1699,8 @@ func (s *Store) GetActiveDevRuns() []kanban.AgentRun {
 		var endedAt sql.NullTime
 		var output sql.NullString
 		err := rows.Scan(&run.ID, &run.Agent, &run.TicketID, &run.Worktree,
-			&run.StartedAt, &endedAt, &run.Status, &output)
+			&run.StartedAt, &endedAt, &run.Status, &output,
+			&run.Model, &run.TokenInput, &run.TokenOutput, &run.ExitReason)
 		if err != nil {
 			continue
 		}
@@ -1052,17 +1767,12 @@ func (s *Store) CleanupStaleRunningAgents(maxRunDuration time.Duration) int {
 	return len(staleIDs)
 }
 
-// CleanupOrphanedRunningAgents marks ALL running agents as failed on startup.
-// This is called during factory initialization to clean up runs from a previous
-// factory session that was killed without proper cleanup.
-func (s *Store) CleanupOrphanedRunningAgents() int {
-	now := time.Now()
-
+// HeartbeatRunningAgents records that all running agents are still alive.
+// The orchestrator calls this periodically while it is up.
+func (s *Store) HeartbeatRunningAgents() int {
 	result, err := s.db.Exec(`
-		UPDATE agent_runs
-		SET status = 'failed', ended_at = ?, output = 'Orphaned run from previous factory session'
-		WHERE status = 'running'
-	`, now)
+		UPDATE agent_runs SET last_heartbeat = ? WHERE status = 'running'
+	`, time.Now())
 	if err != nil {
 		return 0
 	}
@@ -1071,6 +1781,52 @@ func (s *Store) CleanupOrphanedRunningAgents() int {
 	return int(affected)
 }
 
+// CleanupOrphanedRunningAgents marks running agents as failed on startup when their
+// last heartbeat (or start, if they never beat) is older than gracePeriod.
+// Runs that beat recently belonged to an instance that was alive moments ago and
+// are left to be adopted or timed out by CleanupStaleRunningAgents.
+func (s *Store) CleanupOrphanedRunningAgents(gracePeriod time.Duration) int {
+	cutoff := time.Now().Add(-gracePeriod)
+	now := time.Now()
+
+	// Compare times in Go (SQLite string comparison is unreliable)
+	rows, err := s.db.Query(`
+		SELECT id, started_at, last_heartbeat FROM agent_runs WHERE status = 'running'
+	`)
+	if err != nil {
+		return 0
+	}
+	defer rows.Close()
+
+	var orphanedIDs []string
+	for rows.Next() {
+		var id string
+		var startedAt time.Time
+		var lastHeartbeat sql.NullTime
+		if err := rows.Scan(&id, &startedAt, &lastHeartbeat); err != nil {
+			continue
+		}
+		lastSeen := startedAt
+		if lastHeartbeat.Valid {
+			lastSeen = lastHeartbeat.Time
+		}
+		if !lastSeen.After(cutoff) {
+			orphanedIDs = append(orphanedIDs, id)
+		}
+	}
+	rows.Close()
+
+	for _, id := range orphanedIDs {
+		_, _ = s.db.Exec(`
+			UPDATE agent_runs
+			SET status = 'failed', ended_at = ?, output = 'Orphaned run from previous factory session'
+			WHERE id = ?
+		`, now, id)
+	}
+
+	return len(orphanedIDs)
+}
+
 // IsAgentRunning checks if an agent of the given type is already running for a ticket.
 func (s *Store) IsAgentRunning(ticketID, agentType string) bool {
 	var count int
@@ -1111,7 +1867,8 @@ func (s *Store) GetTicketsByParallelGroup(group int) []kanban.Ticket {
 			assigned_agent, assignee, files, dependencies, acceptance_criteria,
 			requirements, signoffs, bugs, notes,
 			worktree_path, worktree_branch, worktree_active,
-			conversation, parent_id, parallel_group,
+			conversation, parent_id, parallel_group, trace_id, skip_stages,
+			source_ticket_id, links, blocked_reason, creation_context, paused, test_run, provider_override, version,
 			created_at, updated_at
 		FROM tickets WHERE parallel_group = ? ORDER BY priority, created_at
 	`, group)
@@ -1134,7 +1891,8 @@ func (s *Store) GetTicketsByParallelGroup(group int) []kanban.Ticket {
 // GetActiveRunsForTicket returns all active runs for a specific ticket.
 func (s *Store) GetActiveRunsForTicket(ticketID string) []kanban.AgentRun {
 	rows, err := s.db.Query(`
-		SELECT id, agent, ticket_id, worktree, started_at, ended_at, status, output
+		SELECT id, agent, ticket_id, worktree, started_at, ended_at, status, output,
+			model, token_input, token_output, exit_reason
 		FROM agent_runs WHERE status = 'running' AND ticket_id = ?
 	`, ticketID)
 	if err != nil {
@@ -1148,7 +1906,8 @@ func (s *Store) GetActiveRunsForTicket(ticketID string) []kanban.AgentRun {
 		var endedAt sql.NullTime
 		var output sql.NullString
 		err := rows.Scan(&run.ID, &run.Agent, &run.TicketID, &run.Worktree,
-			&run.StartedAt, &endedAt, &run.Status, &output)
+			&run.StartedAt, &endedAt, &run.Status, &output,
+			&run.Model, &run.TokenInput, &run.TokenOutput, &run.ExitReason)
 		if err != nil {
 			continue
 		}
@@ -1166,15 +1925,22 @@ func (s *Store) GetActiveRunsForTicket(ticketID string) []kanban.AgentRun {
 // --- Audit Logging ---
 
 // AddAuditEntry records an audit log entry for agent activity.
+// Entries without an explicit trace ID inherit the trace of their run or ticket.
 func (s *Store) AddAuditEntry(entry *kanban.AuditEntry) error {
 	_, err := s.db.Exec(`
 		INSERT INTO agent_audit_log (
 			id, run_id, ticket_id, agent, event_type, event_data,
-			token_input, token_output, duration_ms, created_at
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			token_input, token_output, duration_ms, trace_id, created_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?,
+			COALESCE(NULLIF(?, ''),
+				(SELECT trace_id FROM agent_runs WHERE id = ?),
+				(SELECT trace_id FROM tickets WHERE id = ?)),
+			?)
 	`,
 		entry.ID, entry.RunID, entry.TicketID, entry.Agent, entry.EventType, entry.EventData,
-		entry.TokenInput, entry.TokenOutput, entry.DurationMs, entry.CreatedAt,
+		entry.TokenInput, entry.TokenOutput, entry.DurationMs,
+		entry.TraceID, entry.RunID, entry.TicketID,
+		entry.CreatedAt,
 	)
 	if err != nil {
 		return fmt.Errorf("failed to add audit entry: %w", err)
@@ -1186,7 +1952,7 @@ func (s *Store) AddAuditEntry(entry *kanban.AuditEntry) error {
 func (s *Store) GetAuditEntriesByRun(runID string) ([]kanban.AuditEntry, error) {
 	rows, err := s.db.Query(`
 		SELECT id, run_id, ticket_id, agent, event_type, event_data,
-			token_input, token_output, duration_ms, created_at
+			token_input, token_output, duration_ms, trace_id, created_at
 		FROM agent_audit_log WHERE run_id = ? ORDER BY created_at
 	`, runID)
 	if err != nil {
@@ -1197,11 +1963,35 @@ func (s *Store) GetAuditEntriesByRun(runID string) ([]kanban.AuditEntry, error)
 	return scanAuditEntries(rows)
 }
 
+// GetPromptSentEntry returns the most recent prompt_sent audit entry for a run.
+// Returns nil if the run has no recorded prompt.
+func (s *Store) GetPromptSentEntry(runID string) (*kanban.AuditEntry, error) {
+	rows, err := s.db.Query(`
+		SELECT id, run_id, ticket_id, agent, event_type, event_data,
+			token_input, token_output, duration_ms, trace_id, created_at
+		FROM agent_audit_log WHERE run_id = ? AND event_type = ?
+		ORDER BY created_at DESC LIMIT 1
+	`, runID, kanban.AuditEventPromptSent)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query prompt entry: %w", err)
+	}
+	defer rows.Close()
+
+	entries, err := scanAuditEntries(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, nil
+	}
+	return &entries[0], nil
+}
+
 // GetAuditEntriesByTicket returns all audit entries for a specific ticket.
 func (s *Store) GetAuditEntriesByTicket(ticketID string) ([]kanban.AuditEntry, error) {
 	rows, err := s.db.Query(`
 		SELECT id, run_id, ticket_id, agent, event_type, event_data,
-			token_input, token_output, duration_ms, created_at
+			token_input, token_output, duration_ms, trace_id, created_at
 		FROM agent_audit_log WHERE ticket_id = ? ORDER BY created_at
 	`, ticketID)
 	if err != nil {
@@ -1212,11 +2002,26 @@ func (s *Store) GetAuditEntriesByTicket(ticketID string) ([]kanban.AuditEntry, e
 	return scanAuditEntries(rows)
 }
 
+// GetAuditEntriesByTrace returns all audit entries recorded as a side effect of one API request.
+func (s *Store) GetAuditEntriesByTrace(traceID string) ([]kanban.AuditEntry, error) {
+	rows, err := s.db.Query(`
+		SELECT id, run_id, ticket_id, agent, event_type, event_data,
+			token_input, token_output, duration_ms, trace_id, created_at
+		FROM agent_audit_log WHERE trace_id = ? ORDER BY created_at
+	`, traceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query audit entries: %w", err)
+	}
+	defer rows.Close()
+
+	return scanAuditEntries(rows)
+}
+
 // GetRecentAuditEntries returns the most recent audit entries across all tickets.
 func (s *Store) GetRecentAuditEntries(limit int) ([]kanban.AuditEntry, error) {
 	rows, err := s.db.Query(`
 		SELECT id, run_id, ticket_id, agent, event_type, event_data,
-			token_input, token_output, duration_ms, created_at
+			token_input, token_output, duration_ms, trace_id, created_at
 		FROM agent_audit_log ORDER BY created_at DESC LIMIT ?
 	`, limit)
 	if err != nil {
@@ -1234,16 +2039,244 @@ func (s *Store) GetAuditEntryCount() int {
 	return count
 }
 
+// GetProviderUsage aggregates audit entries and finished runs between start and end
+// per provider. Activity is attributed using each agent's current provider config,
+// with unconfigured agents counted against Anthropic.
+func (s *Store) GetProviderUsage(start, end time.Time) ([]kanban.ProviderUsage, error) {
+	resolve, err := s.agentProviderResolver()
+	if err != nil {
+		return nil, err
+	}
+
+	usage := make(map[string]*kanban.ProviderUsage)
+	agentsByProvider := make(map[string]map[string]bool)
+	latencyTotal := make(map[string]int64)
+	latencyCount := make(map[string]int)
+	get := func(agent string) (*kanban.ProviderUsage, string) {
+		name, model := resolve(agent)
+		u, ok := usage[name]
+		if !ok {
+			u = &kanban.ProviderUsage{Provider: name}
+			usage[name] = u
+			agentsByProvider[name] = make(map[string]bool)
+		}
+		agentsByProvider[name][agent] = true
+		return u, model
+	}
+	inRange := func(t time.Time) bool {
+		return !t.Before(start) && !t.After(end)
+	}
+
+	// Filter times in Go - SQLite string comparison is unreliable
+	rows, err := s.db.Query(`
+		SELECT agent, event_type, token_input, token_output, duration_ms, created_at
+		FROM agent_audit_log WHERE event_type IN (?, ?)
+	`, kanban.AuditEventResponseReceived, kanban.AuditEventError)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query audit entries: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var agent string
+		var eventType kanban.AuditEventType
+		var tokenIn, tokenOut, durationMs sql.NullInt64
+		var createdAt time.Time
+		if err := rows.Scan(&agent, &eventType, &tokenIn, &tokenOut, &durationMs, &createdAt); err != nil {
+			return nil, fmt.Errorf("failed to scan audit entry: %w", err)
+		}
+		if !inRange(createdAt) {
+			continue
+		}
+
+		u, model := get(agent)
+		if eventType == kanban.AuditEventError {
+			u.Errors++
+			continue
+		}
+		u.Requests++
+		u.InputTokens += tokenIn.Int64
+		u.OutputTokens += tokenOut.Int64
+		u.EstimatedCost += provider.EstimateCost(model, tokenIn.Int64, tokenOut.Int64)
+		if durationMs.Int64 > 0 {
+			latencyTotal[u.Provider] += durationMs.Int64
+			latencyCount[u.Provider]++
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read audit entries: %w", err)
+	}
+
+	runRows, err := s.db.Query(`SELECT agent, status, started_at FROM agent_runs WHERE status != 'running'`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query runs: %w", err)
+	}
+	defer runRows.Close()
+
+	for runRows.Next() {
+		var agent, status string
+		var startedAt time.Time
+		if err := runRows.Scan(&agent, &status, &startedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan run: %w", err)
+		}
+		if !inRange(startedAt) {
+			continue
+		}
+
+		u, _ := get(agent)
+		u.Runs++
+		if status == "failed" {
+			u.FailedRuns++
+		}
+	}
+	if err := runRows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read runs: %w", err)
+	}
+
+	result := make([]kanban.ProviderUsage, 0, len(usage))
+	for name, u := range usage {
+		for agent := range agentsByProvider[name] {
+			u.AgentTypes = append(u.AgentTypes, agent)
+		}
+		sort.Strings(u.AgentTypes)
+		if n := latencyCount[name]; n > 0 {
+			u.AvgLatencyMs = float64(latencyTotal[name]) / float64(n)
+		}
+		if u.Runs > 0 {
+			u.ErrorRate = float64(u.FailedRuns) / float64(u.Runs)
+		}
+		result = append(result, *u)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Provider < result[j].Provider })
+
+	return result, nil
+}
+
+// agentProviderResolver returns a lookup of each agent's current provider and
+// model, with unconfigured agents on Anthropic's default model.
+func (s *Store) agentProviderResolver() (func(agent string) (string, string), error) {
+	configs, err := s.GetAllAgentProviderConfigs()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get provider configs: %w", err)
+	}
+	byAgent := make(map[string]provider.AgentProviderConfig, len(configs))
+	for _, cfg := range configs {
+		byAgent[cfg.AgentType] = cfg
+	}
+	return func(agent string) (string, string) {
+		if cfg, ok := byAgent[agent]; ok {
+			return cfg.Provider, cfg.Model
+		}
+		return "anthropic", s.GetProviderDefaultModel("anthropic")
+	}, nil
+}
+
+// GetCostByTicket estimates what the agents working on a ticket have cost.
+func (s *Store) GetCostByTicket(ticketID string) (*kanban.CostSummary, error) {
+	return s.getCost(ticketID, time.Time{}, time.Time{})
+}
+
+// GetCostByIteration estimates what the agents have cost during an
+// iteration: the current one, or a completed one with a recorded digest.
+// Returns nil if the iteration is unknown.
+func (s *Store) GetCostByIteration(iterationID string) (*kanban.CostSummary, error) {
+	var start, end time.Time
+	if current := s.GetIteration(); current != nil && current.ID == iterationID {
+		start, end = current.Window()
+	} else {
+		digest, err := s.GetIterationDigest(iterationID)
+		if err != nil {
+			return nil, err
+		}
+		if digest == nil {
+			return nil, nil
+		}
+		start, end = digest.Start, digest.End
+	}
+	return s.getCost("", start, end)
+}
+
+// getCost prices the tokens of every response in the audit log, optionally
+// only one ticket's and only those between start and end, at the model
+// recorded for the run. Runs without one are priced at the agent's current
+// model.
+func (s *Store) getCost(ticketID string, start, end time.Time) (*kanban.CostSummary, error) {
+	resolve, err := s.agentProviderResolver()
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT a.run_id, a.agent, a.token_input, a.token_output, a.created_at, COALESCE(r.model, '')
+		FROM agent_audit_log a LEFT JOIN agent_runs r ON r.id = a.run_id
+		WHERE a.event_type = ?`
+	args := []interface{}{kanban.AuditEventResponseReceived}
+	if ticketID != "" {
+		query += " AND a.ticket_id = ?"
+		args = append(args, ticketID)
+	}
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query audit entries: %w", err)
+	}
+	defer rows.Close()
+
+	cost := &kanban.CostSummary{Models: make(map[string]kanban.ModelCost)}
+	runs := make(map[string]bool)
+	for rows.Next() {
+		var runID sql.NullString
+		var agent, model string
+		var tokenIn, tokenOut sql.NullInt64
+		var createdAt time.Time
+		if err := rows.Scan(&runID, &agent, &tokenIn, &tokenOut, &createdAt, &model); err != nil {
+			return nil, fmt.Errorf("failed to scan audit entry: %w", err)
+		}
+		// Filter times in Go - SQLite string comparison is unreliable
+		if (!start.IsZero() && createdAt.Before(start)) || (!end.IsZero() && createdAt.After(end)) {
+			continue
+		}
+
+		if model == "" {
+			_, model = resolve(agent)
+		}
+		estimate := provider.EstimateCost(model, tokenIn.Int64, tokenOut.Int64)
+		m := cost.Models[model]
+		m.InputTokens += tokenIn.Int64
+		m.OutputTokens += tokenOut.Int64
+		m.EstimatedCost += estimate
+		cost.Models[model] = m
+
+		cost.InputTokens += tokenIn.Int64
+		cost.OutputTokens += tokenOut.Int64
+		cost.EstimatedCost += estimate
+		if runID.Valid && runID.String != "" {
+			runs[runID.String] = true
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read audit entries: %w", err)
+	}
+
+	cost.Runs = len(runs)
+	for model := range cost.Models {
+		if _, ok := provider.ModelPrices[model]; !ok {
+			cost.UnpricedModels = append(cost.UnpricedModels, model)
+		}
+	}
+	sort.Strings(cost.UnpricedModels)
+	return cost, nil
+}
+
 func scanAuditEntries(rows *sql.Rows) ([]kanban.AuditEntry, error) {
 	var entries []kanban.AuditEntry
 	for rows.Next() {
 		var e kanban.AuditEntry
-		var runID, eventData sql.NullString
+		var runID, eventData, traceID sql.NullString
 		var tokenIn, tokenOut, durationMs sql.NullInt64
 
 		err := rows.Scan(
 			&e.ID, &runID, &e.TicketID, &e.Agent, &e.EventType, &eventData,
-			&tokenIn, &tokenOut, &durationMs, &e.CreatedAt,
+			&tokenIn, &tokenOut, &durationMs, &traceID, &e.CreatedAt,
 		)
 		if err != nil {
 			return nil, err
@@ -1255,6 +2288,9 @@ func scanAuditEntries(rows *sql.Rows) ([]kanban.AuditEntry, error) {
 		if eventData.Valid {
 			e.EventData = eventData.String
 		}
+		if traceID.Valid {
+			e.TraceID = traceID.String
+		}
 		if tokenIn.Valid {
 			e.TokenInput = int(tokenIn.Int64)
 		}
@@ -1284,6 +2320,14 @@ func (s *Store) CreateConversation(conv *kanban.TicketConversation) error {
 	if err != nil {
 		return fmt.Errorf("failed to create conversation: %w", err)
 	}
+
+	if conv.Status == kanban.ThreadStatusEscalated {
+		_ = s.AddActivity(&kanban.Activity{
+			Kind:     kanban.ActivityEscalation,
+			TicketID: conv.TicketID,
+			Message:  "Escalated: " + conv.Title,
+		})
+	}
 	return nil
 }
 
@@ -1532,6 +2576,31 @@ func (s *Store) ResolvePMCheckin(id string) error {
 	return err
 }
 
+// ResolvePMCheckins marks several PM check-ins as resolved in one transaction,
+// returning how many were unresolved before.
+func (s *Store) ResolvePMCheckins(ids []string) (int, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	resolved := 0
+	for _, id := range ids {
+		result, err := tx.Exec("UPDATE pm_checkins SET resolved = 1 WHERE id = ? AND resolved = 0", id)
+		if err != nil {
+			return 0, fmt.Errorf("failed to resolve PM checkin %s: %w", id, err)
+		}
+		n, _ := result.RowsAffected()
+		resolved += int(n)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return resolved, nil
+}
+
 // GetLastPMCheckin returns the most recent PM check-in for a ticket.
 func (s *Store) GetLastPMCheckin(ticketID string) (*kanban.PMCheckin, error) {
 	row := s.db.QueryRow(`
@@ -1598,13 +2667,44 @@ func scanPMCheckins(rows *sql.Rows) ([]kanban.PMCheckin, error) {
 
 // --- Recent Agent Runs ---
 
-// GetRecentRuns returns agent runs from the last 24 hours.
-func (s *Store) GetRecentRuns() ([]kanban.AgentRun, error) {
-	cutoff := time.Now().Add(-24 * time.Hour)
-	rows, err := s.db.Query(`
-		SELECT id, agent, ticket_id, worktree, started_at, ended_at, status, output
-		FROM agent_runs WHERE started_at > ? ORDER BY started_at DESC
-	`, cutoff)
+// Recent run query bounds.
+const (
+	DefaultRecentRunsWindow = 24 * time.Hour
+	MaxRecentRuns           = 500
+)
+
+// RunFilter selects runs for GetRecentRuns. Zero values mean the last
+// DefaultRecentRunsWindow, any status, and at most MaxRecentRuns runs.
+type RunFilter struct {
+	Since  time.Time // Runs started after this time
+	Status string    // running, success, failed, ...
+	Limit  int       // Capped at MaxRecentRuns
+}
+
+// GetRecentRuns returns agent runs matching the filter, newest first.
+func (s *Store) GetRecentRuns(filter RunFilter) ([]kanban.AgentRun, error) {
+	since := filter.Since
+	if since.IsZero() {
+		since = time.Now().Add(-DefaultRecentRunsWindow)
+	}
+	limit := filter.Limit
+	if limit <= 0 || limit > MaxRecentRuns {
+		limit = MaxRecentRuns
+	}
+
+	query := `
+		SELECT id, agent, ticket_id, worktree, started_at, ended_at, status, output,
+			model, token_input, token_output, exit_reason
+		FROM agent_runs WHERE started_at > ?`
+	args := []interface{}{since}
+	if filter.Status != "" {
+		query += ` AND status = ?`
+		args = append(args, filter.Status)
+	}
+	query += ` ORDER BY started_at DESC LIMIT ?`
+	args = append(args, limit)
+
+	rows, err := s.db.Query(query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query recent runs: %w", err)
 	}
@@ -1616,7 +2716,8 @@ func (s *Store) GetRecentRuns() ([]kanban.AgentRun, error) {
 		var endedAt sql.NullTime
 		var output sql.NullString
 		err := rows.Scan(&run.ID, &run.Agent, &run.TicketID, &run.Worktree,
-			&run.StartedAt, &endedAt, &run.Status, &output)
+			&run.StartedAt, &endedAt, &run.Status, &output,
+			&run.Model, &run.TokenInput, &run.TokenOutput, &run.ExitReason)
 		if err != nil {
 			continue
 		}
@@ -1632,19 +2733,73 @@ func (s *Store) GetRecentRuns() ([]kanban.AgentRun, error) {
 	return runs, nil
 }
 
-// GetRun retrieves a single agent run by ID.
-func (s *Store) GetRun(id string) (*kanban.AgentRun, error) {
-	row := s.db.QueryRow(`
-		SELECT id, agent, ticket_id, worktree, started_at, ended_at, status, output
-		FROM agent_runs WHERE id = ?
-	`, id)
+// GetRunsWithoutSignoff returns successful review runs (qa, ux, security, pm)
+// that left no sign-off thread for their stage on the ticket, newest first.
+// These are runs whose output couldn't be parsed into a sign-off report. The
+// limit is capped at MaxRecentRuns; zero means the cap.
+func (s *Store) GetRunsWithoutSignoff(limit int) ([]kanban.AgentRun, error) {
+	if limit <= 0 || limit > MaxRecentRuns {
+		limit = MaxRecentRuns
+	}
+
+	// Sign-off threads are named after the stage, e.g. qa -> qa_signoff
+	rows, err := s.db.Query(`
+		SELECT r.id, r.agent, r.ticket_id, r.worktree, r.started_at, r.ended_at, r.status, r.output,
+			r.model, r.token_input, r.token_output, r.exit_reason
+		FROM agent_runs r
+		WHERE r.status = 'success'
+			AND r.agent IN ('qa', 'ux', 'security', 'pm')
+			AND NOT EXISTS (
+				SELECT 1 FROM ticket_conversations c
+				WHERE c.ticket_id = r.ticket_id
+					AND c.thread_type = r.agent || '_signoff'
+					AND c.created_at >= r.started_at
+			)
+		ORDER BY r.started_at DESC LIMIT ?
+	`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query runs without sign-off: %w", err)
+	}
+	defer rows.Close()
+
+	var runs []kanban.AgentRun
+	for rows.Next() {
+		var run kanban.AgentRun
+		var endedAt sql.NullTime
+		var output sql.NullString
+		err := rows.Scan(&run.ID, &run.Agent, &run.TicketID, &run.Worktree,
+			&run.StartedAt, &endedAt, &run.Status, &output,
+			&run.Model, &run.TokenInput, &run.TokenOutput, &run.ExitReason)
+		if err != nil {
+			continue
+		}
+		if endedAt.Valid {
+			run.EndedAt = endedAt.Time
+		}
+		if output.Valid {
+			run.Output = output.String
+		}
+		runs = append(runs, run)
+	}
+
+	return runs, nil
+}
+
+// GetRun retrieves a single agent run by ID.
+func (s *Store) GetRun(id string) (*kanban.AgentRun, error) {
+	row := s.db.QueryRow(`
+		SELECT id, agent, ticket_id, worktree, started_at, ended_at, status, output,
+			model, token_input, token_output, exit_reason, trace_id
+		FROM agent_runs WHERE id = ?
+	`, id)
 
 	var run kanban.AgentRun
 	var endedAt sql.NullTime
-	var output sql.NullString
+	var output, traceID sql.NullString
 
 	err := row.Scan(&run.ID, &run.Agent, &run.TicketID, &run.Worktree,
-		&run.StartedAt, &endedAt, &run.Status, &output)
+		&run.StartedAt, &endedAt, &run.Status, &output,
+		&run.Model, &run.TokenInput, &run.TokenOutput, &run.ExitReason, &traceID)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
@@ -1658,10 +2813,49 @@ func (s *Store) GetRun(id string) (*kanban.AgentRun, error) {
 	if output.Valid {
 		run.Output = output.String
 	}
+	if traceID.Valid {
+		run.TraceID = traceID.String
+	}
 
 	return &run, nil
 }
 
+// GetRunsByTrace returns all agent runs started as a side effect of one API request.
+func (s *Store) GetRunsByTrace(traceID string) ([]kanban.AgentRun, error) {
+	rows, err := s.db.Query(`
+		SELECT id, agent, ticket_id, worktree, started_at, ended_at, status, output,
+			model, token_input, token_output, exit_reason
+		FROM agent_runs WHERE trace_id = ? ORDER BY started_at
+	`, traceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query runs by trace: %w", err)
+	}
+	defer rows.Close()
+
+	var runs []kanban.AgentRun
+	for rows.Next() {
+		var run kanban.AgentRun
+		var endedAt sql.NullTime
+		var output sql.NullString
+		err := rows.Scan(&run.ID, &run.Agent, &run.TicketID, &run.Worktree,
+			&run.StartedAt, &endedAt, &run.Status, &output,
+			&run.Model, &run.TokenInput, &run.TokenOutput, &run.ExitReason)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan run: %w", err)
+		}
+		if endedAt.Valid {
+			run.EndedAt = endedAt.Time
+		}
+		if output.Valid {
+			run.Output = output.String
+		}
+		run.TraceID = traceID
+		runs = append(runs, run)
+	}
+
+	return runs, nil
+}
+
 // GetCompletedRunsCount returns the count of completed agent runs.
 func (s *Store) GetCompletedRunsCount() int {
 	var count int
@@ -1690,7 +2884,7 @@ func (s *Store) RegisterWorktree(entry kanban.WorktreePoolEntry) error {
 // GetWorktreePool returns all worktrees in the pool.
 func (s *Store) GetWorktreePool() ([]kanban.WorktreePoolEntry, error) {
 	rows, err := s.db.Query(`
-		SELECT id, ticket_id, branch, path, agent, status, created_at, last_activity
+		SELECT id, ticket_id, branch, path, agent, status, created_at, last_activity, cleanup_after
 		FROM worktree_pool ORDER BY created_at
 	`)
 	if err != nil {
@@ -1704,14 +2898,15 @@ func (s *Store) GetWorktreePool() ([]kanban.WorktreePoolEntry, error) {
 // GetWorktreeByTicket returns the worktree entry for a specific ticket.
 func (s *Store) GetWorktreeByTicket(ticketID string) (*kanban.WorktreePoolEntry, error) {
 	row := s.db.QueryRow(`
-		SELECT id, ticket_id, branch, path, agent, status, created_at, last_activity
+		SELECT id, ticket_id, branch, path, agent, status, created_at, last_activity, cleanup_after
 		FROM worktree_pool WHERE ticket_id = ?
 	`, ticketID)
 
 	var entry kanban.WorktreePoolEntry
+	var cleanupAfter sql.NullTime
 	err := row.Scan(
 		&entry.ID, &entry.TicketID, &entry.Branch, &entry.Path, &entry.Agent,
-		&entry.Status, &entry.CreatedAt, &entry.LastActivity,
+		&entry.Status, &entry.CreatedAt, &entry.LastActivity, &cleanupAfter,
 	)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -1719,6 +2914,9 @@ func (s *Store) GetWorktreeByTicket(ticketID string) (*kanban.WorktreePoolEntry,
 		}
 		return nil, fmt.Errorf("failed to get worktree: %w", err)
 	}
+	if cleanupAfter.Valid {
+		entry.CleanupAfter = &cleanupAfter.Time
+	}
 	return &entry, nil
 }
 
@@ -1753,6 +2951,32 @@ func (s *Store) RemoveFromPool(ticketID string) error {
 	return err
 }
 
+// ScheduleWorktreeCleanup marks a merged ticket's worktree cleanup_pending,
+// to be removed once at has passed. A worktree missing from the pool is added.
+func (s *Store) ScheduleWorktreeCleanup(ticketID, branch, path string, at time.Time) error {
+	now := time.Now()
+	result, err := s.db.Exec(`
+		UPDATE worktree_pool SET status = ?, cleanup_after = ?, last_activity = ? WHERE ticket_id = ?
+	`, kanban.WorktreePoolStatusCleanupPending, at, now, ticketID)
+	if err != nil {
+		return fmt.Errorf("failed to schedule worktree cleanup: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n > 0 {
+		return nil
+	}
+
+	_, err = s.db.Exec(`
+		INSERT INTO worktree_pool (
+			id, ticket_id, branch, path, agent, status, created_at, last_activity, cleanup_after
+		) VALUES (?, ?, ?, ?, '', ?, ?, ?, ?)
+	`, fmt.Sprintf("wt-%s-%d", ticketID, now.Unix()), ticketID, branch, path,
+		kanban.WorktreePoolStatusCleanupPending, now, now, at)
+	if err != nil {
+		return fmt.Errorf("failed to schedule worktree cleanup: %w", err)
+	}
+	return nil
+}
+
 // GetWorktreePoolStats returns statistics about the worktree pool.
 func (s *Store) GetWorktreePoolStats() (*kanban.WorktreePoolStats, error) {
 	stats := &kanban.WorktreePoolStats{}
@@ -1789,13 +3013,17 @@ func scanWorktreePoolEntries(rows *sql.Rows) ([]kanban.WorktreePoolEntry, error)
 	var entries []kanban.WorktreePoolEntry
 	for rows.Next() {
 		var e kanban.WorktreePoolEntry
+		var cleanupAfter sql.NullTime
 		err := rows.Scan(
 			&e.ID, &e.TicketID, &e.Branch, &e.Path, &e.Agent,
-			&e.Status, &e.CreatedAt, &e.LastActivity,
+			&e.Status, &e.CreatedAt, &e.LastActivity, &cleanupAfter,
 		)
 		if err != nil {
 			return nil, err
 		}
+		if cleanupAfter.Valid {
+			e.CleanupAfter = &cleanupAfter.Time
+		}
 		entries = append(entries, e)
 	}
 	return entries, nil
@@ -1822,7 +3050,7 @@ func (s *Store) QueueMerge(entry kanban.MergeQueueEntry) error {
 // GetPendingMerges returns all pending merge operations.
 func (s *Store) GetPendingMerges() ([]kanban.MergeQueueEntry, error) {
 	rows, err := s.db.Query(`
-		SELECT id, ticket_id, branch, status, attempts, last_error, created_at, completed_at
+		SELECT id, ticket_id, branch, status, attempts, last_error, next_attempt_at, created_at, completed_at
 		FROM merge_queue WHERE status IN ('pending', 'in_progress') ORDER BY created_at
 	`)
 	if err != nil {
@@ -1833,12 +3061,62 @@ func (s *Store) GetPendingMerges() ([]kanban.MergeQueueEntry, error) {
 	return scanMergeQueueEntries(rows)
 }
 
-// GetMergeQueue returns all merge queue entries.
-func (s *Store) GetMergeQueue() ([]kanban.MergeQueueEntry, error) {
-	rows, err := s.db.Query(`
-		SELECT id, ticket_id, branch, status, attempts, last_error, created_at, completed_at
-		FROM merge_queue ORDER BY created_at DESC LIMIT 50
-	`)
+// Merge queue page size bounds for GetMergeQueue.
+const (
+	DefaultMergeQueueLimit = 50
+	MaxMergeQueueLimit     = 500
+)
+
+// MergeQueueSort orders merge queue listings.
+type MergeQueueSort string
+
+const (
+	MergeQueueSortCreated  MergeQueueSort = "created"  // Newest first
+	MergeQueueSortAttempts MergeQueueSort = "attempts" // Most retried first, then newest
+)
+
+// MergeQueueFilter selects and pages merge queue entries for GetMergeQueue.
+// Zero values mean any status, newest first, and DefaultMergeQueueLimit
+// entries from the start.
+type MergeQueueFilter struct {
+	Status kanban.MergeQueueStatus
+	Sort   MergeQueueSort
+	Limit  int // Capped at MaxMergeQueueLimit
+	Offset int
+}
+
+// GetMergeQueue returns a page of merge queue entries.
+func (s *Store) GetMergeQueue(filter MergeQueueFilter) ([]kanban.MergeQueueEntry, error) {
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = DefaultMergeQueueLimit
+	}
+	if limit > MaxMergeQueueLimit {
+		limit = MaxMergeQueueLimit
+	}
+	offset := filter.Offset
+	if offset < 0 {
+		offset = 0
+	}
+
+	query := `
+		SELECT id, ticket_id, branch, status, attempts, last_error, next_attempt_at, created_at, completed_at
+		FROM merge_queue`
+	var args []interface{}
+	if filter.Status != "" {
+		query += ` WHERE status = ?`
+		args = append(args, filter.Status)
+	}
+	switch filter.Sort {
+	case MergeQueueSortAttempts:
+		query += ` ORDER BY attempts DESC, created_at DESC, id`
+	default:
+		query += ` ORDER BY created_at DESC, id`
+	}
+	query += ` LIMIT ? OFFSET ?`
+	args = append(args, limit, offset)
+
+	rows, err := s.db.Query(query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query merge queue: %w", err)
 	}
@@ -1849,32 +3127,23 @@ func (s *Store) GetMergeQueue() ([]kanban.MergeQueueEntry, error) {
 
 // GetMergeQueueByStatus returns merge queue entries filtered by status.
 func (s *Store) GetMergeQueueByStatus(status kanban.MergeQueueStatus) ([]kanban.MergeQueueEntry, error) {
-	rows, err := s.db.Query(`
-		SELECT id, ticket_id, branch, status, attempts, last_error, created_at, completed_at
-		FROM merge_queue WHERE status = ? ORDER BY created_at DESC LIMIT 50
-	`, status)
-	if err != nil {
-		return nil, fmt.Errorf("failed to query merge queue by status: %w", err)
-	}
-	defer rows.Close()
-
-	return scanMergeQueueEntries(rows)
+	return s.GetMergeQueue(MergeQueueFilter{Status: status})
 }
 
 // GetMergeByTicket returns the merge queue entry for a specific ticket.
 func (s *Store) GetMergeByTicket(ticketID string) (*kanban.MergeQueueEntry, error) {
 	row := s.db.QueryRow(`
-		SELECT id, ticket_id, branch, status, attempts, last_error, created_at, completed_at
+		SELECT id, ticket_id, branch, status, attempts, last_error, next_attempt_at, created_at, completed_at
 		FROM merge_queue WHERE ticket_id = ? ORDER BY created_at DESC LIMIT 1
 	`, ticketID)
 
 	var entry kanban.MergeQueueEntry
 	var lastError sql.NullString
-	var completedAt sql.NullTime
+	var nextAttemptAt, completedAt sql.NullTime
 
 	err := row.Scan(
 		&entry.ID, &entry.TicketID, &entry.Branch, &entry.Status,
-		&entry.Attempts, &lastError, &entry.CreatedAt, &completedAt,
+		&entry.Attempts, &lastError, &nextAttemptAt, &entry.CreatedAt, &completedAt,
 	)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -1886,6 +3155,9 @@ func (s *Store) GetMergeByTicket(ticketID string) (*kanban.MergeQueueEntry, erro
 	if lastError.Valid {
 		entry.LastError = lastError.String
 	}
+	if nextAttemptAt.Valid {
+		entry.NextAttemptAt = &nextAttemptAt.Time
+	}
 	if completedAt.Valid {
 		entry.CompletedAt = &completedAt.Time
 	}
@@ -1901,6 +3173,15 @@ func (s *Store) UpdateMergeStatus(id string, status kanban.MergeQueueStatus, err
 	return err
 }
 
+// ScheduleMergeRetry returns a failed merge to pending and defers it until nextAttemptAt.
+// The attempt itself was already counted when the merge was marked in progress.
+func (s *Store) ScheduleMergeRetry(id string, errMsg string, nextAttemptAt time.Time) error {
+	_, err := s.db.Exec(`
+		UPDATE merge_queue SET status = 'pending', last_error = ?, next_attempt_at = ? WHERE id = ?
+	`, errMsg, nextAttemptAt, id)
+	return err
+}
+
 // CompleteMerge marks a merge as completed.
 func (s *Store) CompleteMerge(id string) error {
 	_, err := s.db.Exec(`
@@ -1922,11 +3203,11 @@ func scanMergeQueueEntries(rows *sql.Rows) ([]kanban.MergeQueueEntry, error) {
 	for rows.Next() {
 		var e kanban.MergeQueueEntry
 		var lastError sql.NullString
-		var completedAt sql.NullTime
+		var nextAttemptAt, completedAt sql.NullTime
 
 		err := rows.Scan(
 			&e.ID, &e.TicketID, &e.Branch, &e.Status,
-			&e.Attempts, &lastError, &e.CreatedAt, &completedAt,
+			&e.Attempts, &lastError, &nextAttemptAt, &e.CreatedAt, &completedAt,
 		)
 		if err != nil {
 			return nil, err
@@ -1935,6 +3216,9 @@ func scanMergeQueueEntries(rows *sql.Rows) ([]kanban.MergeQueueEntry, error) {
 		if lastError.Valid {
 			e.LastError = lastError.String
 		}
+		if nextAttemptAt.Valid {
+			e.NextAttemptAt = &nextAttemptAt.Time
+		}
 		if completedAt.Valid {
 			e.CompletedAt = &completedAt.Time
 		}
@@ -1955,6 +3239,13 @@ func (s *Store) LogWorktreeEvent(event kanban.WorktreeEvent) error {
 	if err != nil {
 		return fmt.Errorf("failed to log worktree event: %w", err)
 	}
+
+	switch event.EventType {
+	case kanban.WorktreeEventMergeCompleted:
+		_ = s.AddActivity(&kanban.Activity{Kind: kanban.ActivityMerge, TicketID: event.TicketID, Message: "Merged to main"})
+	case kanban.WorktreeEventMergeFailed:
+		_ = s.AddActivity(&kanban.Activity{Kind: kanban.ActivityMerge, TicketID: event.TicketID, Message: "Merge failed"})
+	}
 	return nil
 }
 
@@ -2006,6 +3297,80 @@ func scanWorktreeEvents(rows *sql.Rows) ([]kanban.WorktreeEvent, error) {
 	return events, nil
 }
 
+// --- Activity Feed ---
+
+// DefaultActivityRetention is how many activity entries are kept when
+// activity_feed_retention is unset.
+const DefaultActivityRetention = 1000
+
+// activityRetention returns how many activity entries to keep. Zero disables
+// the feed.
+func (s *Store) activityRetention() int {
+	retention := DefaultActivityRetention
+	if v, _ := s.GetConfigValue("activity_feed_retention"); v != "" {
+		if _, err := fmt.Sscanf(v, "%d", &retention); err != nil || retention < 0 {
+			retention = DefaultActivityRetention
+		}
+	}
+	return retention
+}
+
+// AddActivity appends an entry to the activity feed and prunes entries beyond
+// the configured retention. It does nothing when the feed is disabled.
+func (s *Store) AddActivity(a *kanban.Activity) error {
+	retention := s.activityRetention()
+	if retention == 0 {
+		return nil
+	}
+	if a.CreatedAt.IsZero() {
+		a.CreatedAt = time.Now()
+	}
+
+	res, err := s.db.Exec(`
+		INSERT INTO activity_feed (kind, ticket_id, message, created_at)
+		VALUES (?, ?, ?, ?)
+	`, a.Kind, a.TicketID, a.Message, a.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to add activity: %w", err)
+	}
+	a.ID, _ = res.LastInsertId()
+
+	_, err = s.db.Exec(`
+		DELETE FROM activity_feed WHERE id <= (
+			SELECT id FROM activity_feed ORDER BY id DESC LIMIT 1 OFFSET ?
+		)
+	`, retention)
+	if err != nil {
+		return fmt.Errorf("failed to prune activity feed: %w", err)
+	}
+	return nil
+}
+
+// GetActivity returns the most recent activity entries, newest first. Entries
+// are appended as they happen, so insertion order is chronological order.
+func (s *Store) GetActivity(limit int) ([]kanban.Activity, error) {
+	rows, err := s.db.Query(`
+		SELECT id, kind, ticket_id, message, created_at
+		FROM activity_feed ORDER BY id DESC LIMIT ?
+	`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query activity feed: %w", err)
+	}
+	defer rows.Close()
+
+	var feed []kanban.Activity
+	for rows.Next() {
+		var a kanban.Activity
+		var ticketID sql.NullString
+		if err := rows.Scan(&a.ID, &a.Kind, &ticketID, &a.Message, &a.CreatedAt); err != nil {
+			return nil, err
+		}
+		a.TicketID = ticketID.String
+		feed = append(feed, a)
+	}
+	return feed, rows.Err()
+}
+
 // --- Worktree Config ---
 
 // GetWorktreeConfig returns the worktree manager configuration values.
@@ -2051,13 +3416,23 @@ func (s *Store) AddAttachment(att *kanban.Attachment) error {
 
 // GetMessageAttachments returns all attachments for a message.
 func (s *Store) GetMessageAttachments(messageID string) ([]kanban.Attachment, error) {
-	rows, err := s.db.Query(`
+	return s.queryAttachments(`
 		SELECT id, message_id, filename, content_type, size, path, created_at
 		FROM message_attachments WHERE message_id = ? ORDER BY created_at
 	`, messageID)
+}
+
+// queryAttachments runs a query selecting attachment columns.
+func (s *Store) queryAttachments(query string, args ...interface{}) ([]kanban.Attachment, error) {
+	rows, err := s.db.Query(query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query attachments: %w", err)
 	}
+	return scanAttachments(rows)
+}
+
+// scanAttachments reads attachment rows and closes them.
+func scanAttachments(rows *sql.Rows) ([]kanban.Attachment, error) {
 	defer rows.Close()
 
 	var attachments []kanban.Attachment
@@ -2091,27 +3466,336 @@ func (s *Store) GetAttachment(id string) (*kanban.Attachment, error) {
 		if err == sql.ErrNoRows {
 			return nil, nil
 		}
-		return nil, fmt.Errorf("failed to get attachment: %w", err)
+		return nil, fmt.Errorf("failed to get attachment: %w", err)
+	}
+	return &att, nil
+}
+
+// GetMessageTicketID returns the ticket a conversation message belongs to.
+// Returns an empty string if the message does not exist.
+func (s *Store) GetMessageTicketID(messageID string) (string, error) {
+	var ticketID string
+	err := s.db.QueryRow(`
+		SELECT c.ticket_id FROM conversation_messages m
+		INNER JOIN ticket_conversations c ON c.id = m.conversation_id
+		WHERE m.id = ?
+	`, messageID).Scan(&ticketID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to get message ticket: %w", err)
+	}
+	return ticketID, nil
+}
+
+// GetTicketAttachmentBytes returns the total size of all attachments on a ticket's messages.
+func (s *Store) GetTicketAttachmentBytes(ticketID string) (int64, error) {
+	var total int64
+	err := s.db.QueryRow(`
+		SELECT COALESCE(SUM(a.size), 0) FROM message_attachments a
+		INNER JOIN conversation_messages m ON m.id = a.message_id
+		INNER JOIN ticket_conversations c ON c.id = m.conversation_id
+		WHERE c.ticket_id = ?
+	`, ticketID).Scan(&total)
+	if err != nil {
+		return 0, fmt.Errorf("failed to sum ticket attachments: %w", err)
+	}
+	return total, nil
+}
+
+// DeleteAttachment removes an attachment.
+func (s *Store) DeleteAttachment(id string) error {
+	_, err := s.db.Exec("DELETE FROM message_attachments WHERE id = ?", id)
+	return err
+}
+
+// --- Pending PM Responses ---
+
+// SavePendingPMResponse records a chat message the PM couldn't answer, or
+// updates its attempt count and error if it is already pending.
+func (s *Store) SavePendingPMResponse(p *kanban.PendingPMResponse) error {
+	_, err := s.db.Exec(`
+		INSERT INTO pm_pending_responses (
+			id, ticket_id, conversation_id, message, attempts, last_error, created_at, updated_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET
+			attempts = excluded.attempts,
+			last_error = excluded.last_error,
+			updated_at = excluded.updated_at
+	`,
+		p.ID, p.TicketID, p.ConversationID, p.Message, p.Attempts, p.LastError, p.CreatedAt, p.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save pending PM response: %w", err)
+	}
+	return nil
+}
+
+// GetPendingPMResponses returns unanswered chat messages, oldest first.
+func (s *Store) GetPendingPMResponses() ([]kanban.PendingPMResponse, error) {
+	rows, err := s.db.Query(`
+		SELECT id, ticket_id, conversation_id, message, attempts, last_error, created_at, updated_at
+		FROM pm_pending_responses ORDER BY created_at
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query pending PM responses: %w", err)
+	}
+	defer rows.Close()
+
+	var pending []kanban.PendingPMResponse
+	for rows.Next() {
+		var p kanban.PendingPMResponse
+		var lastError sql.NullString
+		err := rows.Scan(
+			&p.ID, &p.TicketID, &p.ConversationID, &p.Message, &p.Attempts,
+			&lastError, &p.CreatedAt, &p.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		p.LastError = lastError.String
+		pending = append(pending, p)
+	}
+	return pending, nil
+}
+
+// DeletePendingPMResponse removes a pending chat message once it is answered.
+func (s *Store) DeletePendingPMResponse(id string) error {
+	_, err := s.db.Exec("DELETE FROM pm_pending_responses WHERE id = ?", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete pending PM response: %w", err)
+	}
+	return nil
+}
+
+// --- Orphaned Conversation Rows ---
+
+// Conversation rows still attached to a live ticket. Anything outside these
+// sets was left behind when its ticket, conversation, or message was deleted.
+const (
+	liveConversations = `SELECT c.id FROM ticket_conversations c
+		INNER JOIN tickets t ON t.id = c.ticket_id`
+	liveMessages = `SELECT m.id FROM conversation_messages m
+		INNER JOIN ticket_conversations c ON c.id = m.conversation_id
+		INNER JOIN tickets t ON t.id = c.ticket_id`
+)
+
+// GetOrphanedConversations returns conversations whose ticket no longer exists.
+func (s *Store) GetOrphanedConversations() ([]kanban.TicketConversation, error) {
+	rows, err := s.db.Query(`
+		SELECT id, ticket_id, thread_type, title, status, created_at, resolved_at
+		FROM ticket_conversations
+		WHERE ticket_id NOT IN (SELECT id FROM tickets)
+		ORDER BY created_at
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query orphaned conversations: %w", err)
+	}
+	defer rows.Close()
+
+	var conversations []kanban.TicketConversation
+	for rows.Next() {
+		var conv kanban.TicketConversation
+		var resolvedAt sql.NullTime
+		var title sql.NullString
+
+		err := rows.Scan(
+			&conv.ID, &conv.TicketID, &conv.ThreadType, &title, &conv.Status,
+			&conv.CreatedAt, &resolvedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		if title.Valid {
+			conv.Title = title.String
+		}
+		if resolvedAt.Valid {
+			conv.ResolvedAt = resolvedAt.Time
+		}
+
+		conversations = append(conversations, conv)
+	}
+	return conversations, nil
+}
+
+// GetOrphanedMessages returns messages whose conversation no longer exists.
+func (s *Store) GetOrphanedMessages() ([]kanban.ConversationMessage, error) {
+	rows, err := s.db.Query(`
+		SELECT id, conversation_id, agent, message_type, content, metadata, created_at
+		FROM conversation_messages
+		WHERE conversation_id NOT IN (SELECT id FROM ticket_conversations)
+		ORDER BY created_at
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query orphaned messages: %w", err)
+	}
+	defer rows.Close()
+
+	var messages []kanban.ConversationMessage
+	for rows.Next() {
+		var msg kanban.ConversationMessage
+		var metadata sql.NullString
+
+		err := rows.Scan(
+			&msg.ID, &msg.ConversationID, &msg.Agent, &msg.MessageType,
+			&msg.Content, &metadata, &msg.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		if metadata.Valid && metadata.String != "" {
+			_ = json.Unmarshal([]byte(metadata.String), &msg.Metadata)
+		}
+
+		messages = append(messages, msg)
+	}
+	return messages, nil
+}
+
+// GetOrphanedAttachments returns attachments whose message no longer exists.
+func (s *Store) GetOrphanedAttachments() ([]kanban.Attachment, error) {
+	return s.queryAttachments(`
+		SELECT id, message_id, filename, content_type, size, path, created_at
+		FROM message_attachments
+		WHERE message_id NOT IN (SELECT id FROM conversation_messages)
+		ORDER BY created_at
+	`)
+}
+
+// OrphanCleanup reports what DeleteOrphans removed.
+type OrphanCleanup struct {
+	Conversations int64               // Conversations whose ticket was gone
+	Messages      int64               // Messages whose conversation was gone or orphaned
+	Attachments   []kanban.Attachment // Attachments whose message was gone or orphaned
+}
+
+// DeleteOrphans removes every conversation row no longer reachable from a
+// ticket, including the messages and attachments of orphaned conversations.
+// The returned attachments' files are left for the caller to delete.
+func (s *Store) DeleteOrphans() (*OrphanCleanup, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	cleanup := &OrphanCleanup{}
+	rows, err := tx.Query(`
+		SELECT id, message_id, filename, content_type, size, path, created_at
+		FROM message_attachments WHERE message_id NOT IN (` + liveMessages + `)
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query orphaned attachments: %w", err)
+	}
+	cleanup.Attachments, err = scanAttachments(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	// Children first, so foreign keys never see a dangling reference
+	if _, err := tx.Exec(`DELETE FROM message_attachments WHERE message_id NOT IN (` + liveMessages + `)`); err != nil {
+		return nil, fmt.Errorf("failed to delete orphaned attachments: %w", err)
+	}
+	result, err := tx.Exec(`DELETE FROM conversation_messages WHERE conversation_id NOT IN (` + liveConversations + `)`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete orphaned messages: %w", err)
+	}
+	cleanup.Messages, _ = result.RowsAffected()
+	result, err = tx.Exec(`DELETE FROM ticket_conversations WHERE ticket_id NOT IN (SELECT id FROM tickets)`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete orphaned conversations: %w", err)
+	}
+	cleanup.Conversations, _ = result.RowsAffected()
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit orphan cleanup: %w", err)
+	}
+	return cleanup, nil
+}
+
+// --- Ticket Time Stats ---
+
+// GetRunsByTicket returns all agent runs for a specific ticket.
+func (s *Store) GetRunsByTicket(ticketID string) ([]kanban.AgentRun, error) {
+	rows, err := s.db.Query(`
+		SELECT id, agent, ticket_id, worktree, started_at, ended_at, status, output,
+			model, token_input, token_output, exit_reason
+		FROM agent_runs WHERE ticket_id = ? ORDER BY started_at
+	`, ticketID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query runs by ticket: %w", err)
+	}
+	defer rows.Close()
+
+	var runs []kanban.AgentRun
+	for rows.Next() {
+		var run kanban.AgentRun
+		var endedAt sql.NullTime
+		var output sql.NullString
+		err := rows.Scan(&run.ID, &run.Agent, &run.TicketID, &run.Worktree,
+			&run.StartedAt, &endedAt, &run.Status, &output,
+			&run.Model, &run.TokenInput, &run.TokenOutput, &run.ExitReason)
+		if err != nil {
+			continue
+		}
+		if endedAt.Valid {
+			run.EndedAt = endedAt.Time
+		}
+		if output.Valid {
+			run.Output = output.String
+		}
+		runs = append(runs, run)
 	}
-	return &att, nil
+	return runs, nil
 }
 
-// DeleteAttachment removes an attachment.
-func (s *Store) DeleteAttachment(id string) error {
-	_, err := s.db.Exec("DELETE FROM message_attachments WHERE id = ?", id)
-	return err
-}
+// GetRunsBetween returns the agent runs started between start and end, oldest first.
+func (s *Store) GetRunsBetween(start, end time.Time) ([]kanban.AgentRun, error) {
+	rows, err := s.db.Query(`
+		SELECT id, agent, ticket_id, worktree, started_at, ended_at, status, output,
+			model, token_input, token_output, exit_reason
+		FROM agent_runs WHERE started_at >= ? AND started_at <= ? ORDER BY started_at
+	`, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query runs: %w", err)
+	}
+	defer rows.Close()
 
-// --- Ticket Time Stats ---
+	var runs []kanban.AgentRun
+	for rows.Next() {
+		var run kanban.AgentRun
+		var endedAt sql.NullTime
+		var output sql.NullString
+		err := rows.Scan(&run.ID, &run.Agent, &run.TicketID, &run.Worktree,
+			&run.StartedAt, &endedAt, &run.Status, &output,
+			&run.Model, &run.TokenInput, &run.TokenOutput, &run.ExitReason)
+		if err != nil {
+			continue
+		}
+		if endedAt.Valid {
+			run.EndedAt = endedAt.Time
+		}
+		if output.Valid {
+			run.Output = output.String
+		}
+		runs = append(runs, run)
+	}
+	return runs, nil
+}
 
-// GetRunsByTicket returns all agent runs for a specific ticket.
-func (s *Store) GetRunsByTicket(ticketID string) ([]kanban.AgentRun, error) {
+// GetRunsByWorktree returns all agent runs that used the worktree at path,
+// across tickets since pooled worktree paths can be reused, oldest first.
+func (s *Store) GetRunsByWorktree(path string) ([]kanban.AgentRun, error) {
 	rows, err := s.db.Query(`
-		SELECT id, agent, ticket_id, worktree, started_at, ended_at, status, output
-		FROM agent_runs WHERE ticket_id = ? ORDER BY started_at
-	`, ticketID)
+		SELECT id, agent, ticket_id, worktree, started_at, ended_at, status, output,
+			model, token_input, token_output, exit_reason
+		FROM agent_runs WHERE worktree = ? ORDER BY started_at
+	`, path)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query runs by ticket: %w", err)
+		return nil, fmt.Errorf("failed to query runs by worktree: %w", err)
 	}
 	defer rows.Close()
 
@@ -2121,7 +3805,8 @@ func (s *Store) GetRunsByTicket(ticketID string) ([]kanban.AgentRun, error) {
 		var endedAt sql.NullTime
 		var output sql.NullString
 		err := rows.Scan(&run.ID, &run.Agent, &run.TicketID, &run.Worktree,
-			&run.StartedAt, &endedAt, &run.Status, &output)
+			&run.StartedAt, &endedAt, &run.Status, &output,
+			&run.Model, &run.TokenInput, &run.TokenOutput, &run.ExitReason)
 		if err != nil {
 			continue
 		}
@@ -2346,6 +4031,48 @@ func (s *Store) GetADRsByTicket(ticketID string) ([]kanban.ADR, error) {
 	return scanADRRows(rows, s)
 }
 
+// GetADRRollout returns the tickets linked to an ADR with their current
+// status, and how many of them are done. Abandoned tickets are listed but
+// left out of the completion percentage.
+func (s *Store) GetADRRollout(adrID string) (*kanban.ADRRollout, error) {
+	rows, err := s.db.Query(`
+		SELECT t.id, t.title, t.status, COALESCE(t.domain, '')
+		FROM tickets t
+		INNER JOIN adr_tickets at ON t.id = at.ticket_id
+		WHERE at.adr_id = ?
+		ORDER BY t.id
+	`, adrID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query tickets by ADR: %w", err)
+	}
+	defer rows.Close()
+
+	rollout := &kanban.ADRRollout{ADRID: adrID, Tickets: []kanban.ADRTicket{}}
+	counted := 0
+	for rows.Next() {
+		var t kanban.ADRTicket
+		if err := rows.Scan(&t.ID, &t.Title, &t.Status, &t.Domain); err != nil {
+			return nil, fmt.Errorf("failed to scan ADR ticket: %w", err)
+		}
+		rollout.Tickets = append(rollout.Tickets, t)
+		if t.Status == kanban.StatusAbandoned {
+			continue
+		}
+		counted++
+		if t.Status == kanban.StatusDone {
+			rollout.Done++
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read ADR tickets: %w", err)
+	}
+
+	if counted > 0 {
+		rollout.CompletionPercent = 100 * float64(rollout.Done) / float64(counted)
+	}
+	return rollout, nil
+}
+
 // UpdateADR updates an existing ADR.
 func (s *Store) UpdateADR(adr *kanban.ADR) error {
 	_, err := s.db.Exec(`
@@ -2363,6 +4090,49 @@ func (s *Store) UpdateADR(adr *kanban.ADR) error {
 	return nil
 }
 
+// SupersedeADR creates newADR and marks the ADR oldID as superseded by it, in
+// one transaction. The new ADR's ticket links are created with it.
+func (s *Store) SupersedeADR(oldID string, newADR *kanban.ADR) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	_, err = tx.Exec(`
+		INSERT INTO adrs (
+			id, title, status, context, decision, consequences,
+			iteration_id, superseded_by, created_by, created_at, updated_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`,
+		newADR.ID, newADR.Title, newADR.Status, newADR.Context, newADR.Decision, newADR.Consequences,
+		newADR.IterationID, newADR.SupersededBy, newADR.CreatedBy, newADR.CreatedAt, newADR.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create ADR: %w", err)
+	}
+
+	for _, ticketID := range newADR.TicketIDs {
+		if _, err := tx.Exec(`
+			INSERT OR IGNORE INTO adr_tickets (adr_id, ticket_id) VALUES (?, ?)
+		`, newADR.ID, ticketID); err != nil {
+			return fmt.Errorf("failed to link ADR to ticket: %w", err)
+		}
+	}
+
+	result, err := tx.Exec(`
+		UPDATE adrs SET status = ?, superseded_by = ?, updated_at = ? WHERE id = ?
+	`, kanban.ADRStatusSuperseded, newADR.ID, time.Now(), oldID)
+	if err != nil {
+		return fmt.Errorf("failed to supersede ADR: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("ADR %s not found", oldID)
+	}
+
+	return tx.Commit()
+}
+
 // DeleteADR deletes an ADR.
 func (s *Store) DeleteADR(id string) error {
 	_, err := s.db.Exec("DELETE FROM adrs WHERE id = ?", id)
@@ -2647,7 +4417,8 @@ func (s *Store) GetTicketsByTag(tagID string) ([]kanban.Ticket, error) {
 			t.assigned_agent, t.assignee, t.files, t.dependencies, t.acceptance_criteria,
 			t.requirements, t.signoffs, t.bugs, t.notes,
 			t.worktree_path, t.worktree_branch, t.worktree_active,
-			t.conversation, t.parent_id, t.parallel_group,
+			t.conversation, t.parent_id, t.parallel_group, t.trace_id, t.skip_stages,
+			t.source_ticket_id, t.links, t.blocked_reason, t.creation_context, t.paused, t.test_run, t.provider_override, t.version,
 			t.created_at, t.updated_at
 		FROM tickets t
 		INNER JOIN ticket_tags tt ON t.id = tt.ticket_id
@@ -2696,6 +4467,80 @@ func (s *Store) GetTagTicketCount(tagID string) (int, error) {
 	return count, err
 }
 
+// GetUnusedTags returns tags attached to no tickets, leaving out tags of the
+// given types.
+func (s *Store) GetUnusedTags(keep []kanban.TagType) ([]kanban.Tag, error) {
+	rows, err := s.db.Query(`
+		SELECT id, name, type, color, description FROM tags t
+		WHERE NOT EXISTS (SELECT 1 FROM ticket_tags tt WHERE tt.tag_id = t.id)
+		ORDER BY type, name
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query unused tags: %w", err)
+	}
+	defer rows.Close()
+
+	tags, err := scanTagRows(rows)
+	if err != nil {
+		return nil, err
+	}
+	unused := tags[:0]
+	for _, tag := range tags {
+		if !containsTagType(keep, tag.Type) {
+			unused = append(unused, tag)
+		}
+	}
+	return unused, nil
+}
+
+// DeleteUnusedTags deletes the tags GetUnusedTags reports and returns those
+// deleted. A tag attached to a ticket in the meantime is kept.
+func (s *Store) DeleteUnusedTags(keep []kanban.TagType) ([]kanban.Tag, error) {
+	tags, err := s.GetUnusedTags(keep)
+	if err != nil {
+		return nil, err
+	}
+
+	var deleted []kanban.Tag
+	for _, tag := range tags {
+		res, err := s.db.Exec(`
+			DELETE FROM tags WHERE id = ?
+			AND NOT EXISTS (SELECT 1 FROM ticket_tags WHERE tag_id = ?)
+		`, tag.ID, tag.ID)
+		if err != nil {
+			return deleted, fmt.Errorf("failed to delete tag %s: %w", tag.ID, err)
+		}
+		if n, _ := res.RowsAffected(); n > 0 {
+			deleted = append(deleted, tag)
+		}
+	}
+	return deleted, nil
+}
+
+func containsTagType(types []kanban.TagType, t kanban.TagType) bool {
+	for _, candidate := range types {
+		if candidate == t {
+			return true
+		}
+	}
+	return false
+}
+
+// GetTagStatusBreakdown returns a tag's ticket counts by status and completion
+// percentage, or nil if the tag doesn't exist.
+func (s *Store) GetTagStatusBreakdown(tagID string) (*kanban.TagStats, error) {
+	tag, err := s.GetTag(tagID)
+	if err != nil || tag == nil {
+		return nil, err
+	}
+	tickets, err := s.GetTicketsByTag(tagID)
+	if err != nil {
+		return nil, err
+	}
+	stats := kanban.NewTagStats(*tag, tickets)
+	return &stats, nil
+}
+
 // GetEpicTags is a convenience method to get all epic-type tags.
 func (s *Store) GetEpicTags() ([]kanban.Tag, error) {
 	return s.GetTagsByType(kanban.TagTypeEpic)
@@ -2723,3 +4568,301 @@ func scanTagRows(rows *sql.Rows) ([]kanban.Tag, error) {
 	}
 	return tags, nil
 }
+
+// --- Health Events ---
+
+// RecordHealthTransition stores a health event if the status differs from the last recorded one.
+// The comparison and insert happen in one statement so concurrent callers record a transition once.
+// Returns nil if the status is unchanged.
+func (s *Store) RecordHealthTransition(health *kanban.SystemHealth) (*kanban.HealthEvent, error) {
+	event := &kanban.HealthEvent{
+		ID:        fmt.Sprintf("health-%d", time.Now().UnixNano()),
+		ToStatus:  health.Status,
+		Message:   health.Message,
+		CreatedAt: time.Now(),
+	}
+
+	result, err := s.db.Exec(`
+		INSERT INTO health_events (id, from_status, to_status, message, created_at)
+		SELECT ?, last.status, ?, ?, ?
+		FROM (SELECT COALESCE(
+			(SELECT to_status FROM health_events ORDER BY created_at DESC, rowid DESC LIMIT 1),
+			'stable') AS status) AS last
+		WHERE last.status != ?
+	`, event.ID, event.ToStatus, event.Message, event.CreatedAt, event.ToStatus)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record health transition: %w", err)
+	}
+
+	if n, _ := result.RowsAffected(); n == 0 {
+		return nil, nil
+	}
+
+	return s.GetHealthEvent(event.ID)
+}
+
+// GetHealthEvent returns a health event by ID, or nil if it doesn't exist.
+func (s *Store) GetHealthEvent(id string) (*kanban.HealthEvent, error) {
+	rows, err := s.db.Query(`
+		SELECT id, from_status, to_status, message, acknowledged, acknowledged_at, created_at
+		FROM health_events WHERE id = ?
+	`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query health event: %w", err)
+	}
+	defer rows.Close()
+
+	events, err := scanHealthEvents(rows)
+	if err != nil {
+		return nil, err
+	}
+	if len(events) == 0 {
+		return nil, nil
+	}
+	return &events[0], nil
+}
+
+// GetHealthEvents returns the most recent health events, newest first.
+func (s *Store) GetHealthEvents(limit int) ([]kanban.HealthEvent, error) {
+	rows, err := s.db.Query(`
+		SELECT id, from_status, to_status, message, acknowledged, acknowledged_at, created_at
+		FROM health_events ORDER BY created_at DESC, rowid DESC LIMIT ?
+	`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query health events: %w", err)
+	}
+	defer rows.Close()
+
+	return scanHealthEvents(rows)
+}
+
+// AcknowledgeHealthEvent marks a health event as acknowledged.
+func (s *Store) AcknowledgeHealthEvent(id string) error {
+	_, err := s.db.Exec(`
+		UPDATE health_events SET acknowledged = 1, acknowledged_at = ? WHERE id = ?
+	`, time.Now(), id)
+	if err != nil {
+		return fmt.Errorf("failed to acknowledge health event: %w", err)
+	}
+	return nil
+}
+
+func scanHealthEvents(rows *sql.Rows) ([]kanban.HealthEvent, error) {
+	var events []kanban.HealthEvent
+	for rows.Next() {
+		var e kanban.HealthEvent
+		var message sql.NullString
+		var ackAt sql.NullTime
+
+		err := rows.Scan(&e.ID, &e.FromStatus, &e.ToStatus, &message, &e.Acknowledged, &ackAt, &e.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		if message.Valid {
+			e.Message = message.String
+		}
+		if ackAt.Valid {
+			e.AcknowledgedAt = ackAt.Time
+		}
+
+		events = append(events, e)
+	}
+	return events, nil
+}
+
+// --- Run Retention ---
+
+// RunPrunePreview reports what pruning finished runs would affect.
+type RunPrunePreview struct {
+	RetentionDays int    `json:"retentionDays"` // Zero means pruning is disabled
+	Mode          string `json:"mode"`          // "output" or "delete"
+	Runs          int    `json:"runs"`          // Runs that would be pruned
+	OutputBytes   int64  `json:"outputBytes"`   // Output that would be freed
+	AuditEntries  int    `json:"auditEntries"`  // Audit entries tied to those runs
+}
+
+// runRetention reads the run retention policy: run_retention_days (unset or 0
+// disables pruning) and run_retention_mode ("output" clears only the output,
+// "delete" removes the run).
+func (s *Store) runRetention() (days int, mode string) {
+	mode = "output"
+	if v, _ := s.GetConfigValue("run_retention_days"); v != "" {
+		if _, err := fmt.Sscanf(v, "%d", &days); err != nil || days < 0 {
+			days = 0
+		}
+	}
+	if v, _ := s.GetConfigValue("run_retention_mode"); v == "delete" {
+		mode = v
+	}
+	return days, mode
+}
+
+// expiredRunIDs returns finished runs that ended before cutoff. In output mode
+// runs whose output was already cleared are skipped. Times are compared in Go
+// since SQLite string comparison is unreliable.
+func (s *Store) expiredRunIDs(cutoff time.Time, mode string) ([]string, error) {
+	query := `SELECT id, ended_at FROM agent_runs WHERE ended_at IS NOT NULL AND status != 'running'`
+	if mode == "output" {
+		query += ` AND output IS NOT NULL AND output != ''`
+	}
+	rows, err := s.db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query finished runs: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		var endedAt time.Time
+		if err := rows.Scan(&id, &endedAt); err != nil {
+			return nil, err
+		}
+		if endedAt.Before(cutoff) {
+			ids = append(ids, id)
+		}
+	}
+	return ids, rows.Err()
+}
+
+// PreviewRunPrune reports what PruneExpiredRuns would prune now without
+// changing anything.
+func (s *Store) PreviewRunPrune() (*RunPrunePreview, error) {
+	days, mode := s.runRetention()
+	preview := &RunPrunePreview{RetentionDays: days, Mode: mode}
+	if days == 0 {
+		return preview, nil
+	}
+
+	ids, err := s.expiredRunIDs(time.Now().AddDate(0, 0, -days), mode)
+	if err != nil {
+		return nil, err
+	}
+	preview.Runs = len(ids)
+	for _, id := range ids {
+		var bytes int64
+		var audits int
+		err := s.db.QueryRow(`
+			SELECT COALESCE(LENGTH(output), 0),
+				(SELECT COUNT(*) FROM agent_audit_log WHERE run_id = agent_runs.id)
+			FROM agent_runs WHERE id = ?
+		`, id).Scan(&bytes, &audits)
+		if err != nil {
+			return nil, fmt.Errorf("failed to measure run %s: %w", id, err)
+		}
+		preview.OutputBytes += bytes
+		preview.AuditEntries += audits
+	}
+	return preview, nil
+}
+
+// PruneExpiredRuns applies the run retention policy to finished runs older
+// than run_retention_days and returns how many were pruned. In output mode the
+// run metadata stays and only its output is cleared. In delete mode the run is
+// removed, and its audit entries are kept with the ticket but no longer point
+// at the run.
+func (s *Store) PruneExpiredRuns() (int, error) {
+	days, mode := s.runRetention()
+	if days == 0 {
+		return 0, nil
+	}
+
+	ids, err := s.expiredRunIDs(time.Now().AddDate(0, 0, -days), mode)
+	if err != nil || len(ids) == 0 {
+		return 0, err
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	for _, id := range ids {
+		if mode == "delete" {
+			if _, err := tx.Exec(`UPDATE agent_audit_log SET run_id = NULL WHERE run_id = ?`, id); err != nil {
+				return 0, fmt.Errorf("failed to detach audit entries: %w", err)
+			}
+			if _, err := tx.Exec(`DELETE FROM agent_runs WHERE id = ?`, id); err != nil {
+				return 0, fmt.Errorf("failed to delete run: %w", err)
+			}
+			continue
+		}
+		if _, err := tx.Exec(`UPDATE agent_runs SET output = NULL WHERE id = ?`, id); err != nil {
+			return 0, fmt.Errorf("failed to clear run output: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit run pruning: %w", err)
+	}
+	return len(ids), nil
+}
+
+// --- Orchestrator Lock ---
+
+// orchestratorLockName is the locks row held by the running orchestrator.
+const orchestratorLockName = "orchestrator"
+
+// ErrLockHeld is returned when another live owner holds a lock.
+var ErrLockHeld = errors.New("lock held by another owner")
+
+// AcquireOrchestratorLock takes the board's orchestrator lock for owner, so
+// two processes sharing the database don't both spawn agents. The lock is
+// reentrant for its owner, and a lock whose heartbeat is older than staleAfter
+// belonged to an instance that died without releasing it and is reclaimed.
+func (s *Store) AcquireOrchestratorLock(owner string, staleAfter time.Duration) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	now := time.Now()
+	var holder string
+	var heartbeatAt time.Time
+	err = tx.QueryRow(`SELECT owner, heartbeat_at FROM locks WHERE name = ?`, orchestratorLockName).Scan(&holder, &heartbeatAt)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+	case err != nil:
+		return fmt.Errorf("failed to read lock: %w", err)
+	case holder != owner && heartbeatAt.After(now.Add(-staleAfter)):
+		// Compare times in Go (SQLite string comparison is unreliable)
+		return fmt.Errorf("%w: %s (last heartbeat %s)", ErrLockHeld, holder, heartbeatAt.Format(time.RFC3339))
+	}
+
+	if _, err := tx.Exec(`
+		INSERT INTO locks (name, owner, acquired_at, heartbeat_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT(name) DO UPDATE SET
+			owner = excluded.owner, acquired_at = excluded.acquired_at, heartbeat_at = excluded.heartbeat_at
+	`, orchestratorLockName, owner, now, now); err != nil {
+		return fmt.Errorf("failed to take lock: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit lock: %w", err)
+	}
+	return nil
+}
+
+// HeartbeatOrchestratorLock records that owner is still alive. It returns
+// ErrLockHeld if owner no longer holds the lock.
+func (s *Store) HeartbeatOrchestratorLock(owner string) error {
+	result, err := s.db.Exec(`
+		UPDATE locks SET heartbeat_at = ? WHERE name = ? AND owner = ?
+	`, time.Now(), orchestratorLockName, owner)
+	if err != nil {
+		return fmt.Errorf("failed to heartbeat lock: %w", err)
+	}
+	if affected, _ := result.RowsAffected(); affected == 0 {
+		return ErrLockHeld
+	}
+	return nil
+}
+
+// ReleaseOrchestratorLock gives up owner's orchestrator lock. Releasing a lock
+// owner doesn't hold is a no-op.
+func (s *Store) ReleaseOrchestratorLock(owner string) error {
+	_, err := s.db.Exec(`DELETE FROM locks WHERE name = ? AND owner = ?`, orchestratorLockName, owner)
+	return err
+}
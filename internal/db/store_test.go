@@ -0,0 +1,1354 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"math"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/madhatter5501/Factory/agents/provider"
+	"github.com/madhatter5501/Factory/kanban"
+)
+
+// newTestStore creates a store backed by a fresh SQLite database.
+func newTestStore(t *testing.T) *Store {
+	t.Helper()
+
+	database, err := Open(filepath.Join(t.TempDir(), "factory.db"))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	t.Cleanup(func() { _ = database.Close() })
+
+	return NewStore(database)
+}
+
+func TestAgentProviderConfigUsesProviderDefaultModel(t *testing.T) {
+	store := newTestStore(t)
+
+	if err := store.SetConfig(provider.DefaultModelConfigKey("openai"), provider.ModelOpenAIGPT4); err != nil {
+		t.Fatalf("failed to set default model: %v", err)
+	}
+
+	tests := []struct {
+		agentType string
+		provider  string
+		want      string
+	}{
+		{"new-agent", "openai", provider.ModelOpenAIGPT4},                // Configured default
+		{"other-agent", "google", provider.DefaultModel("google")},       // Built-in default
+		{"reset-agent", "anthropic", provider.DefaultModel("anthropic")}, // Built-in default
+	}
+
+	for _, tc := range tests {
+		if err := store.SetAgentProviderConfig(tc.agentType, tc.provider, ""); err != nil {
+			t.Fatalf("failed to set provider config: %v", err)
+		}
+
+		cfg, err := store.GetAgentProviderConfig(tc.agentType)
+		if err != nil || cfg == nil {
+			t.Fatalf("failed to get provider config for %s: %v", tc.agentType, err)
+		}
+		if cfg.Model != tc.want {
+			t.Errorf("%s: expected model %q, got %q", tc.agentType, tc.want, cfg.Model)
+		}
+	}
+}
+
+func TestOrphanCleanupSparesRecentlyBeatingRuns(t *testing.T) {
+	store := newTestStore(t)
+
+	if err := store.CreateTicket(&kanban.Ticket{ID: "T-1", Title: "Heartbeat", Status: kanban.StatusInDev, CreatedAt: time.Now(), UpdatedAt: time.Now()}); err != nil {
+		t.Fatalf("failed to create ticket: %v", err)
+	}
+
+	// Both runs started long ago; only the first was beating until the restart
+	startedAt := time.Now().Add(-10 * time.Minute)
+	if err := store.AddRun(&kanban.AgentRun{ID: "run-beating", Agent: "dev-backend", TicketID: "T-1", StartedAt: startedAt, Status: "running"}); err != nil {
+		t.Fatalf("failed to add run: %v", err)
+	}
+	if n := store.HeartbeatRunningAgents(); n != 1 {
+		t.Fatalf("expected 1 heartbeat, got %d", n)
+	}
+	if err := store.AddRun(&kanban.AgentRun{ID: "run-stale", Agent: "qa", TicketID: "T-1", StartedAt: startedAt, Status: "running"}); err != nil {
+		t.Fatalf("failed to add run: %v", err)
+	}
+
+	if n := store.CleanupOrphanedRunningAgents(2 * time.Minute); n != 1 {
+		t.Errorf("expected 1 orphaned run, got %d", n)
+	}
+
+	for id, want := range map[string]string{"run-beating": "running", "run-stale": "failed"} {
+		run, err := store.GetRun(id)
+		if err != nil || run == nil {
+			t.Fatalf("failed to get run %s: %v", id, err)
+		}
+		if run.Status != want {
+			t.Errorf("%s: expected status %q, got %q", id, want, run.Status)
+		}
+	}
+}
+
+func TestCostAggregatesAuditTokensAtEachRunsModel(t *testing.T) {
+	store := newTestStore(t)
+	now := time.Now()
+
+	for _, id := range []string{"T-1", "T-2"} {
+		if err := store.CreateTicket(&kanban.Ticket{ID: id, Title: "Costed", Status: kanban.StatusInDev, CreatedAt: now, UpdatedAt: now}); err != nil {
+			t.Fatalf("failed to create ticket: %v", err)
+		}
+	}
+	runs := []struct{ id, agent, ticketID, model string }{
+		{"run-dev", "dev-backend", "T-1", provider.ModelAnthropicSonnet4}, // $3 in, $15 out per 1M
+		{"run-qa", "qa", "T-1", provider.ModelOpenAIGPT4o},                // $2.50 in, $10 out per 1M
+		{"run-unpriced", "qa", "T-1", "local-llama"},
+		{"run-other", "dev-backend", "T-2", provider.ModelAnthropicHaiku35},
+	}
+	for _, r := range runs {
+		if err := store.AddRun(&kanban.AgentRun{ID: r.id, Agent: r.agent, TicketID: r.ticketID, StartedAt: now, Status: "running"}); err != nil {
+			t.Fatalf("failed to add run: %v", err)
+		}
+		store.SetRunMetadata(r.id, kanban.RunMetadata{Model: r.model})
+	}
+
+	entries := []kanban.AuditEntry{
+		{ID: "a1", RunID: "run-dev", TicketID: "T-1", Agent: "dev-backend", EventType: kanban.AuditEventResponseReceived, TokenInput: 1_000_000, TokenOutput: 100_000, CreatedAt: now},
+		{ID: "a2", RunID: "run-dev", TicketID: "T-1", Agent: "dev-backend", EventType: kanban.AuditEventResponseReceived, TokenInput: 500_000, TokenOutput: 100_000, CreatedAt: now},
+		{ID: "a3", RunID: "run-dev", TicketID: "T-1", Agent: "dev-backend", EventType: kanban.AuditEventPromptSent, TokenInput: 9_999_999, CreatedAt: now},
+		{ID: "a4", RunID: "run-qa", TicketID: "T-1", Agent: "qa", EventType: kanban.AuditEventResponseReceived, TokenInput: 200_000, TokenOutput: 50_000, CreatedAt: now},
+		{ID: "a5", RunID: "run-unpriced", TicketID: "T-1", Agent: "qa", EventType: kanban.AuditEventResponseReceived, TokenInput: 1000, TokenOutput: 1000, CreatedAt: now},
+		{ID: "a6", RunID: "run-other", TicketID: "T-2", Agent: "dev-backend", EventType: kanban.AuditEventResponseReceived, TokenInput: 1_000_000, TokenOutput: 0, CreatedAt: now.Add(-48 * time.Hour)},
+	}
+	for i := range entries {
+		if err := store.AddAuditEntry(&entries[i]); err != nil {
+			t.Fatalf("failed to add audit entry: %v", err)
+		}
+	}
+
+	cost, err := store.GetCostByTicket("T-1")
+	if err != nil {
+		t.Fatalf("GetCostByTicket failed: %v", err)
+	}
+	// Sonnet: 1.5M in ($4.50) + 200k out ($3); GPT-4o: 200k in ($0.50) + 50k out ($0.50)
+	if cost.InputTokens != 1_701_000 || cost.OutputTokens != 251_000 || cost.Runs != 3 {
+		t.Errorf("Expected 1701000 in, 251000 out over 3 runs, got %d in, %d out over %d runs", cost.InputTokens, cost.OutputTokens, cost.Runs)
+	}
+	if math.Abs(cost.EstimatedCost-8.5) > 1e-9 {
+		t.Errorf("Expected $8.50, got $%.4f", cost.EstimatedCost)
+	}
+	if got := cost.Models[provider.ModelAnthropicSonnet4].EstimatedCost; math.Abs(got-7.5) > 1e-9 {
+		t.Errorf("Expected $7.50 on Sonnet, got $%.4f", got)
+	}
+	if len(cost.UnpricedModels) != 1 || cost.UnpricedModels[0] != "local-llama" {
+		t.Errorf("Expected local-llama flagged as unpriced, got %v", cost.UnpricedModels)
+	}
+
+	// The iteration only covers the last day, so T-2's older response is outside it
+	store.SetIteration(&kanban.Iteration{ID: "iter-1", StartedAt: now.Add(-24 * time.Hour)})
+	cost, err = store.GetCostByIteration("iter-1")
+	if err != nil {
+		t.Fatalf("GetCostByIteration failed: %v", err)
+	}
+	if cost.InputTokens != 1_701_000 || math.Abs(cost.EstimatedCost-8.5) > 1e-9 {
+		t.Errorf("Expected the iteration to cost $8.50 for 1701000 input tokens, got $%.4f for %d", cost.EstimatedCost, cost.InputTokens)
+	}
+
+	if cost, err := store.GetCostByIteration("iter-unknown"); err != nil || cost != nil {
+		t.Errorf("Expected no cost for an unknown iteration, got %+v, %v", cost, err)
+	}
+}
+
+func TestProviderUsageAggregatesAuditAndRuns(t *testing.T) {
+	store := newTestStore(t)
+	now := time.Now()
+
+	if err := store.CreateTicket(&kanban.Ticket{ID: "T-1", Title: "Usage", Status: kanban.StatusInDev, CreatedAt: now, UpdatedAt: now}); err != nil {
+		t.Fatalf("failed to create ticket: %v", err)
+	}
+	// dev-backend runs on OpenAI; qa is unconfigured and falls back to Anthropic
+	if err := store.SetAgentProviderConfig("dev-backend", "openai", provider.ModelOpenAIGPT4o); err != nil {
+		t.Fatalf("failed to set provider config: %v", err)
+	}
+
+	runs := []struct {
+		id, agent, status string
+		startedAt         time.Time
+	}{
+		{"run-dev-1", "dev-backend", "success", now},
+		{"run-dev-2", "dev-backend", "failed", now},
+		{"run-qa-1", "qa", "success", now},
+		{"run-qa-old", "qa", "failed", now.AddDate(0, 0, -30)}, // Outside the range
+	}
+	for _, r := range runs {
+		if err := store.AddRun(&kanban.AgentRun{ID: r.id, Agent: r.agent, TicketID: "T-1", StartedAt: r.startedAt, Status: "running"}); err != nil {
+			t.Fatalf("failed to add run: %v", err)
+		}
+		store.CompleteRun(r.id, r.status, "")
+	}
+
+	entries := []kanban.AuditEntry{
+		{ID: "a1", RunID: "run-dev-1", Agent: "dev-backend", EventType: kanban.AuditEventResponseReceived, TokenInput: 1000, TokenOutput: 500, DurationMs: 2000, CreatedAt: now},
+		{ID: "a2", RunID: "run-dev-2", Agent: "dev-backend", EventType: kanban.AuditEventResponseReceived, TokenInput: 3000, TokenOutput: 1000, DurationMs: 4000, CreatedAt: now},
+		{ID: "a3", RunID: "run-dev-2", Agent: "dev-backend", EventType: kanban.AuditEventError, EventData: "boom", CreatedAt: now},
+		{ID: "a4", RunID: "run-qa-1", Agent: "qa", EventType: kanban.AuditEventPromptSent, CreatedAt: now},
+		{ID: "a5", RunID: "run-qa-1", Agent: "qa", EventType: kanban.AuditEventResponseReceived, TokenInput: 1_000_000, TokenOutput: 100_000, DurationMs: 1000, CreatedAt: now},
+		{ID: "a6", RunID: "run-qa-old", Agent: "qa", EventType: kanban.AuditEventResponseReceived, TokenInput: 500, TokenOutput: 500, DurationMs: 9000, CreatedAt: now.AddDate(0, 0, -30)},
+	}
+	for i := range entries {
+		entries[i].TicketID = "T-1"
+		if err := store.AddAuditEntry(&entries[i]); err != nil {
+			t.Fatalf("failed to add audit entry: %v", err)
+		}
+	}
+
+	usage, err := store.GetProviderUsage(now.Add(-time.Hour), now.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("GetProviderUsage failed: %v", err)
+	}
+	if len(usage) != 2 {
+		t.Fatalf("Expected 2 providers, got %d: %+v", len(usage), usage)
+	}
+
+	expected := []kanban.ProviderUsage{
+		{
+			Provider: "anthropic", AgentTypes: []string{"qa"},
+			Requests: 1, InputTokens: 1_000_000, OutputTokens: 100_000,
+			EstimatedCost: 4.5, AvgLatencyMs: 1000, Runs: 1,
+		},
+		{
+			Provider: "openai", AgentTypes: []string{"dev-backend"},
+			Requests: 2, Errors: 1, InputTokens: 4000, OutputTokens: 1500,
+			EstimatedCost: 0.025, AvgLatencyMs: 3000, Runs: 2, FailedRuns: 1, ErrorRate: 0.5,
+		},
+	}
+	for i, want := range expected {
+		got := usage[i]
+		if got.Provider != want.Provider || len(got.AgentTypes) != 1 || got.AgentTypes[0] != want.AgentTypes[0] {
+			t.Errorf("Provider %d: expected %s used by %v, got %s used by %v", i, want.Provider, want.AgentTypes, got.Provider, got.AgentTypes)
+		}
+		if got.Requests != want.Requests || got.Errors != want.Errors ||
+			got.InputTokens != want.InputTokens || got.OutputTokens != want.OutputTokens ||
+			got.Runs != want.Runs || got.FailedRuns != want.FailedRuns {
+			t.Errorf("%s: expected counts %+v, got %+v", want.Provider, want, got)
+		}
+		if math.Abs(got.EstimatedCost-want.EstimatedCost) > 1e-9 ||
+			got.AvgLatencyMs != want.AvgLatencyMs || got.ErrorRate != want.ErrorRate {
+			t.Errorf("%s: expected cost %.4f, latency %.0f, error rate %.2f; got %.4f, %.0f, %.2f",
+				want.Provider, want.EstimatedCost, want.AvgLatencyMs, want.ErrorRate,
+				got.EstimatedCost, got.AvgLatencyMs, got.ErrorRate)
+		}
+	}
+}
+
+func TestCreateTicketAppliesDefaultAcceptanceCriteriaByType(t *testing.T) {
+	store := newTestStore(t)
+	if err := store.SetConfig("default_acceptance_criteria", `{"bugfix": ["Regression test added", "Root cause documented"]}`); err != nil {
+		t.Fatalf("failed to set config: %v", err)
+	}
+
+	now := time.Now()
+	tickets := []*kanban.Ticket{
+		{ID: "BUG-1", Title: "Crash on save", Type: "bugfix"},
+		{ID: "BUG-2", Title: "Typo", Type: "bugfix", AcceptanceCriteria: []string{"Label reads Save"}},
+		{ID: "FEAT-1", Title: "Dark mode", Type: "feature"},
+	}
+	for _, ticket := range tickets {
+		ticket.Status = kanban.StatusBacklog
+		ticket.CreatedAt, ticket.UpdatedAt = now, now
+		if err := store.CreateTicket(ticket); err != nil {
+			t.Fatalf("failed to create ticket %s: %v", ticket.ID, err)
+		}
+	}
+
+	expected := map[string][]string{
+		"BUG-1":  {"Regression test added", "Root cause documented"},
+		"BUG-2":  {"Label reads Save"},
+		"FEAT-1": nil,
+	}
+	for id, want := range expected {
+		got, _ := store.GetTicket(id)
+		if len(got.AcceptanceCriteria) != len(want) {
+			t.Errorf("%s: expected criteria %v, got %v", id, want, got.AcceptanceCriteria)
+			continue
+		}
+		for i := range want {
+			if got.AcceptanceCriteria[i] != want[i] {
+				t.Errorf("%s: expected criteria %v, got %v", id, want, got.AcceptanceCriteria)
+				break
+			}
+		}
+	}
+
+	// Updates replace the defaults rather than having them re-applied
+	bug, _ := store.GetTicket("BUG-1")
+	bug.AcceptanceCriteria = []string{"Save works offline"}
+	if err := store.UpdateTicket(bug); err != nil {
+		t.Fatalf("failed to update ticket: %v", err)
+	}
+	if got, _ := store.GetTicket("BUG-1"); len(got.AcceptanceCriteria) != 1 || got.AcceptanceCriteria[0] != "Save works offline" {
+		t.Errorf("Expected update to override default criteria, got %v", got.AcceptanceCriteria)
+	}
+}
+
+func TestActivateConfigProfileAppliesAllValues(t *testing.T) {
+	store := newTestStore(t)
+
+	if err := store.SetAgentSystemPrompt("dev-backend", "Prefer small commits."); err != nil {
+		t.Fatalf("failed to set system prompt: %v", err)
+	}
+
+	prod := &kanban.ConfigProfile{
+		Name: "prod",
+		Values: map[string]string{
+			"max_parallel_agents":    "8",
+			"max_ticket_failures":    "3",
+			"require_merge_approval": "true",
+		},
+		Providers: map[string]kanban.ProfileProvider{
+			"dev-backend": {Provider: "openai", Model: provider.ModelOpenAIGPT4},
+			"qa":          {Provider: "google"},
+		},
+	}
+	if err := store.SaveConfigProfile(prod); err != nil {
+		t.Fatalf("failed to save profile: %v", err)
+	}
+
+	// Drift the live config away from the profile
+	if err := store.SetConfig("max_parallel_agents", "2"); err != nil {
+		t.Fatalf("failed to set config: %v", err)
+	}
+
+	if err := store.ActivateConfigProfile("prod"); err != nil {
+		t.Fatalf("ActivateConfigProfile failed: %v", err)
+	}
+
+	for key, want := range prod.Values {
+		if got, _ := store.GetConfigValue(key); got != want {
+			t.Errorf("%s: expected %q, got %q", key, want, got)
+		}
+	}
+	if got, _ := store.GetConfigValue("main_branch"); got != "main" {
+		t.Errorf("Expected keys outside the profile to be unchanged, main_branch = %q", got)
+	}
+
+	dev, _ := store.GetAgentProviderConfig("dev-backend")
+	if dev == nil || dev.Provider != "openai" || dev.Model != provider.ModelOpenAIGPT4 {
+		t.Errorf("Expected dev-backend on openai/%s, got %+v", provider.ModelOpenAIGPT4, dev)
+	} else if dev.SystemPrompt != "Prefer small commits." {
+		t.Errorf("Expected custom system prompt to survive activation, got %q", dev.SystemPrompt)
+	}
+	qa, _ := store.GetAgentProviderConfig("qa")
+	if qa == nil || qa.Provider != "google" || qa.Model != provider.DefaultModel("google") {
+		t.Errorf("Expected qa on google default model, got %+v", qa)
+	}
+
+	if active, _ := store.GetConfigValue(ActiveConfigProfileKey); active != "prod" {
+		t.Errorf("Expected prod recorded as active profile, got %q", active)
+	}
+	profiles, err := store.GetConfigProfiles()
+	if err != nil || len(profiles) != 1 || !profiles[0].Active {
+		t.Errorf("Expected one active profile, got %+v (err %v)", profiles, err)
+	}
+
+	if err := store.ActivateConfigProfile("missing"); err == nil {
+		t.Error("Expected error activating an unknown profile")
+	}
+}
+
+func TestConfigProfileLeavesOutRuntimeState(t *testing.T) {
+	store := newTestStore(t)
+	if err := store.SetConfig("max_parallel_agents", "4"); err != nil {
+		t.Fatalf("failed to set config: %v", err)
+	}
+	if err := store.SetConfig("iteration", `{"id": "iter-1"}`); err != nil {
+		t.Fatalf("failed to set iteration: %v", err)
+	}
+
+	profile, err := store.CurrentConfigProfile("snapshot")
+	if err != nil {
+		t.Fatalf("CurrentConfigProfile failed: %v", err)
+	}
+	if profile.Values["max_parallel_agents"] != "4" {
+		t.Errorf("Expected the profile to capture settings, got %v", profile.Values)
+	}
+	if _, ok := profile.Values["iteration"]; ok {
+		t.Errorf("Expected the profile to leave out the current iteration, got %v", profile.Values)
+	}
+
+	// A profile saved before keys were limited still holds runtime state
+	profile.Values["iteration"] = `{"id": "iter-0"}`
+	if err := store.SaveConfigProfile(profile); err != nil {
+		t.Fatalf("failed to save profile: %v", err)
+	}
+	if err := store.SetConfig("iteration", `{"id": "iter-2"}`); err != nil {
+		t.Fatalf("failed to set iteration: %v", err)
+	}
+	if err := store.ActivateConfigProfile("snapshot"); err != nil {
+		t.Fatalf("ActivateConfigProfile failed: %v", err)
+	}
+	if got, _ := store.GetConfigValue("iteration"); got != `{"id": "iter-2"}` {
+		t.Errorf("Expected activation to keep the current iteration, got %s", got)
+	}
+}
+
+func TestGetRecentRunsFiltersByStatusAndLimit(t *testing.T) {
+	store := newTestStore(t)
+	now := time.Now()
+
+	if err := store.CreateTicket(&kanban.Ticket{ID: "T-1", Title: "Runs", Status: kanban.StatusInDev, CreatedAt: now, UpdatedAt: now}); err != nil {
+		t.Fatalf("failed to create ticket: %v", err)
+	}
+	runs := []struct {
+		id, status string
+		startedAt  time.Time
+	}{
+		{"run-1", "failed", now.Add(-3 * time.Hour)},
+		{"run-2", "success", now.Add(-2 * time.Hour)},
+		{"run-3", "failed", now.Add(-1 * time.Hour)},
+		{"run-4", "failed", now.Add(-30 * time.Minute)},
+		{"run-old", "failed", now.Add(-48 * time.Hour)}, // Outside the default window
+	}
+	for _, r := range runs {
+		if err := store.AddRun(&kanban.AgentRun{ID: r.id, Agent: "qa", TicketID: "T-1", StartedAt: r.startedAt, Status: "running"}); err != nil {
+			t.Fatalf("failed to add run: %v", err)
+		}
+		store.CompleteRun(r.id, r.status, "")
+	}
+
+	ids := func(runs []kanban.AgentRun) string {
+		var ids []string
+		for _, r := range runs {
+			ids = append(ids, r.ID)
+		}
+		return strings.Join(ids, ",")
+	}
+
+	all, err := store.GetRecentRuns(RunFilter{})
+	if err != nil {
+		t.Fatalf("GetRecentRuns failed: %v", err)
+	}
+	if got := ids(all); got != "run-4,run-3,run-2,run-1" {
+		t.Errorf("expected the last 24h of runs newest first, got %s", got)
+	}
+
+	failed, err := store.GetRecentRuns(RunFilter{Status: "failed", Limit: 2})
+	if err != nil {
+		t.Fatalf("GetRecentRuns failed: %v", err)
+	}
+	if got := ids(failed); got != "run-4,run-3" {
+		t.Errorf("expected the 2 newest failed runs, got %s", got)
+	}
+
+	older, err := store.GetRecentRuns(RunFilter{Since: now.Add(-72 * time.Hour), Status: "failed"})
+	if err != nil {
+		t.Fatalf("GetRecentRuns failed: %v", err)
+	}
+	if got := ids(older); got != "run-4,run-3,run-1,run-old" {
+		t.Errorf("expected a wider window to include older runs, got %s", got)
+	}
+}
+
+func TestGetTicketsPagedBoundariesAndTags(t *testing.T) {
+	store := newTestStore(t)
+	now := time.Now()
+
+	for _, tag := range []string{"tag-even", "tag-odd"} {
+		if err := store.CreateTag(&kanban.Tag{ID: tag, Name: tag, Type: kanban.TagTypeGeneric}); err != nil {
+			t.Fatalf("failed to create tag: %v", err)
+		}
+	}
+	for i := 0; i < 5; i++ {
+		id := fmt.Sprintf("T-%d", i+1)
+		if err := store.CreateTicket(&kanban.Ticket{ID: id, Title: id, Status: kanban.StatusReady, CreatedAt: now.Add(time.Duration(i) * time.Minute), UpdatedAt: now}); err != nil {
+			t.Fatalf("failed to create ticket: %v", err)
+		}
+		tag := "tag-odd"
+		if (i+1)%2 == 0 {
+			tag = "tag-even"
+		}
+		if err := store.AddTagToTicket(id, tag); err != nil {
+			t.Fatalf("failed to tag ticket: %v", err)
+		}
+	}
+
+	tests := []struct {
+		limit, offset int
+		want          []string
+	}{
+		{2, 0, []string{"T-1", "T-2"}},
+		{2, 2, []string{"T-3", "T-4"}},
+		{2, 4, []string{"T-5"}}, // Partial last page
+		{2, 5, nil},             // Exactly at the end
+		{2, 50, nil},            // Past the end
+		{0, 0, []string{"T-1", "T-2", "T-3", "T-4", "T-5"}},
+	}
+	for _, tt := range tests {
+		page, total, err := store.GetTicketsPaged(tt.limit, tt.offset)
+		if err != nil {
+			t.Fatalf("GetTicketsPaged(%d, %d) failed: %v", tt.limit, tt.offset, err)
+		}
+		if total != 5 {
+			t.Errorf("GetTicketsPaged(%d, %d): expected total 5, got %d", tt.limit, tt.offset, total)
+		}
+		var got []string
+		for _, ticket := range page {
+			got = append(got, ticket.ID)
+
+			// Each ticket on the page carries its own tag
+			wantTag := "tag-odd"
+			if n, _ := strconv.Atoi(strings.TrimPrefix(ticket.ID, "T-")); n%2 == 0 {
+				wantTag = "tag-even"
+			}
+			if len(ticket.Tags) != 1 || ticket.Tags[0].ID != wantTag {
+				t.Errorf("GetTicketsPaged(%d, %d): expected %s tagged %s, got %+v", tt.limit, tt.offset, ticket.ID, wantTag, ticket.Tags)
+			}
+		}
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("GetTicketsPaged(%d, %d) = %v, want %v", tt.limit, tt.offset, got, tt.want)
+		}
+	}
+}
+
+func TestGetMergeQueuePagesAndSortsByAttempts(t *testing.T) {
+	store := newTestStore(t)
+	now := time.Now()
+
+	attempts := []int{0, 3, 1, 5, 3}
+	for i, n := range attempts {
+		id := fmt.Sprintf("T-%d", i+1)
+		if err := store.CreateTicket(&kanban.Ticket{ID: id, Title: id, Status: kanban.StatusDone, CreatedAt: now, UpdatedAt: now}); err != nil {
+			t.Fatalf("failed to create ticket: %v", err)
+		}
+		entry := kanban.MergeQueueEntry{
+			ID: fmt.Sprintf("merge-%d", i+1), TicketID: id, Branch: "feat/" + id,
+			Status: kanban.MergeQueueStatusPending, Attempts: n, CreatedAt: now.Add(time.Duration(i) * time.Minute),
+		}
+		if err := store.QueueMerge(entry); err != nil {
+			t.Fatalf("failed to queue merge: %v", err)
+		}
+	}
+
+	ids := func(entries []kanban.MergeQueueEntry) string {
+		var got []string
+		for _, e := range entries {
+			got = append(got, e.ID)
+		}
+		return strings.Join(got, ",")
+	}
+
+	tests := []struct {
+		filter MergeQueueFilter
+		want   string
+	}{
+		{MergeQueueFilter{}, "merge-5,merge-4,merge-3,merge-2,merge-1"},
+		{MergeQueueFilter{Limit: 2}, "merge-5,merge-4"},
+		{MergeQueueFilter{Limit: 2, Offset: 2}, "merge-3,merge-2"},
+		{MergeQueueFilter{Limit: 2, Offset: 4}, "merge-1"},
+		{MergeQueueFilter{Offset: 10}, ""},
+		// Most retried first; equal attempts newest first
+		{MergeQueueFilter{Sort: MergeQueueSortAttempts}, "merge-4,merge-5,merge-2,merge-3,merge-1"},
+		{MergeQueueFilter{Sort: MergeQueueSortAttempts, Limit: 2, Offset: 1}, "merge-5,merge-2"},
+	}
+	for _, tt := range tests {
+		entries, err := store.GetMergeQueue(tt.filter)
+		if err != nil {
+			t.Fatalf("GetMergeQueue(%+v) failed: %v", tt.filter, err)
+		}
+		if got := ids(entries); got != tt.want {
+			t.Errorf("GetMergeQueue(%+v) = %s, want %s", tt.filter, got, tt.want)
+		}
+	}
+}
+
+func TestSearchTicketsRanksAndTracksEdits(t *testing.T) {
+	store := newTestStore(t)
+	now := time.Now()
+
+	tickets := []kanban.Ticket{
+		{ID: "T-1", Title: "Add OAuth login", Description: "Let users sign in with <GitHub>", Status: kanban.StatusReady},
+		{ID: "T-2", Title: "Fix flaky tests", Description: "Login page test times out", Status: kanban.StatusReady},
+		{ID: "T-3", Title: "Refactor billing", Notes: "Check login redirect after checkout", Status: kanban.StatusReady},
+		{ID: "T-4", Title: "Dark mode", Description: "Theme toggle", Status: kanban.StatusReady},
+	}
+	for i := range tickets {
+		tickets[i].CreatedAt, tickets[i].UpdatedAt = now, now
+		if err := store.CreateTicket(&tickets[i]); err != nil {
+			t.Fatalf("failed to create ticket: %v", err)
+		}
+	}
+
+	ids := func(results []kanban.Ticket) string {
+		var got []string
+		for _, r := range results {
+			got = append(got, r.ID)
+		}
+		return strings.Join(got, ",")
+	}
+	search := func(q string) []kanban.Ticket {
+		t.Helper()
+		results, err := store.SearchTickets(q, 0)
+		if err != nil {
+			t.Fatalf("SearchTickets(%q) failed: %v", q, err)
+		}
+		return results
+	}
+
+	// Title matches rank above description matches, which rank above notes
+	results := search("login")
+	if got := ids(results); got != "T-1,T-2,T-3" {
+		t.Errorf("expected login hits ranked T-1,T-2,T-3, got %s", got)
+	}
+	if len(results) > 0 && !strings.Contains(results[0].SearchSnippet, "<mark>login</mark>") {
+		t.Errorf("expected highlighted snippet, got %q", results[0].SearchSnippet)
+	}
+
+	// All words must match, as prefixes; punctuation isn't query syntax
+	if got := ids(search("login \"github")); got != "T-1" {
+		t.Errorf("expected only T-1 for login github, got %s", got)
+	}
+	if got := ids(search("them")); got != "T-4" {
+		t.Errorf("expected prefix match on theme, got %s", got)
+	}
+	if got := search("github"); len(got) != 1 || strings.Contains(got[0].SearchSnippet, "<GitHub>") {
+		t.Errorf("expected snippet text HTML-escaped, got %+v", got)
+	}
+
+	// Edits and deletes are reflected in the index
+	if err := store.UpdateNotes("T-4", "Needs login state"); err != nil {
+		t.Fatalf("failed to update notes: %v", err)
+	}
+	if err := store.DeleteTicket("T-2"); err != nil {
+		t.Fatalf("failed to delete ticket: %v", err)
+	}
+	if got := ids(search("login")); got != "T-1,T-3,T-4" && got != "T-1,T-4,T-3" {
+		t.Errorf("expected index to follow edits and deletes, got %s", got)
+	}
+}
+
+func TestGetTagStatusBreakdownCountsTicketsByStatus(t *testing.T) {
+	store := newTestStore(t)
+	now := time.Now()
+
+	if err := store.CreateTag(&kanban.Tag{ID: "tag-1", Name: "Checkout", Type: kanban.TagTypeEpic}); err != nil {
+		t.Fatalf("failed to create tag: %v", err)
+	}
+	statuses := []kanban.Status{
+		kanban.StatusDone, kanban.StatusDone, kanban.StatusInDev,
+		kanban.StatusReady, kanban.StatusAbandoned,
+	}
+	for i, status := range statuses {
+		id := fmt.Sprintf("T-%d", i+1)
+		if err := store.CreateTicket(&kanban.Ticket{ID: id, Title: id, Status: status, CreatedAt: now, UpdatedAt: now}); err != nil {
+			t.Fatalf("failed to create ticket: %v", err)
+		}
+		if err := store.AddTagToTicket(id, "tag-1"); err != nil {
+			t.Fatalf("failed to tag ticket: %v", err)
+		}
+	}
+
+	stats, err := store.GetTagStatusBreakdown("tag-1")
+	if err != nil {
+		t.Fatalf("GetTagStatusBreakdown failed: %v", err)
+	}
+	if stats == nil {
+		t.Fatal("expected stats for an existing tag")
+	}
+	if stats.Total != 5 {
+		t.Errorf("expected 5 tickets, got %d", stats.Total)
+	}
+	want := map[kanban.Status]int{kanban.StatusDone: 2, kanban.StatusInDev: 1, kanban.StatusReady: 1, kanban.StatusAbandoned: 1}
+	for status, n := range want {
+		if stats.ByStatus[status] != n {
+			t.Errorf("expected %d %s tickets, got %d", n, status, stats.ByStatus[status])
+		}
+	}
+	// Abandoned tickets don't count against completion: 2 of 4 are done
+	if stats.CompletionPercent != 50 {
+		t.Errorf("expected 50%% complete, got %v", stats.CompletionPercent)
+	}
+
+	missing, err := store.GetTagStatusBreakdown("no-such-tag")
+	if err != nil {
+		t.Fatalf("GetTagStatusBreakdown failed: %v", err)
+	}
+	if missing != nil {
+		t.Error("expected nil stats for a missing tag")
+	}
+}
+
+func TestGetStatsExcludingParentsSkipsTicketsWithChildren(t *testing.T) {
+	store := newTestStore(t)
+	now := time.Now()
+
+	tickets := []kanban.Ticket{
+		{ID: "PRD-1", Title: "Checkout", Status: kanban.StatusInDev},
+		{ID: "SUB-1", Title: "API", Status: kanban.StatusDone, ParentID: "PRD-1"},
+		{ID: "SUB-2", Title: "UI", Status: kanban.StatusInDev, ParentID: "PRD-1"},
+		{ID: "T-1", Title: "Standalone", Status: kanban.StatusReady},
+	}
+	for _, ticket := range tickets {
+		ticket.CreatedAt, ticket.UpdatedAt = now, now
+		if err := store.CreateTicket(&ticket); err != nil {
+			t.Fatalf("failed to create ticket %s: %v", ticket.ID, err)
+		}
+	}
+
+	inclusive := store.GetStats()
+	want := map[kanban.Status]int{kanban.StatusDone: 1, kanban.StatusInDev: 2, kanban.StatusReady: 1}
+	for status, n := range want {
+		if inclusive[status] != n {
+			t.Errorf("inclusive: expected %d %s tickets, got %d", n, status, inclusive[status])
+		}
+	}
+
+	exclusive := store.GetStatsExcludingParents()
+	want = map[kanban.Status]int{kanban.StatusDone: 1, kanban.StatusInDev: 1, kanban.StatusReady: 1}
+	for status, n := range want {
+		if exclusive[status] != n {
+			t.Errorf("exclusive: expected %d %s tickets, got %d", n, status, exclusive[status])
+		}
+	}
+}
+
+func TestGetRunsWithoutSignoffFindsUnreportedReviews(t *testing.T) {
+	store := newTestStore(t)
+	now := time.Now()
+
+	for _, id := range []string{"T-1", "T-2"} {
+		if err := store.CreateTicket(&kanban.Ticket{ID: id, Title: id, Status: kanban.StatusInUX, CreatedAt: now, UpdatedAt: now}); err != nil {
+			t.Fatalf("failed to create ticket: %v", err)
+		}
+	}
+	runs := []struct {
+		id, agent, ticketID, status string
+	}{
+		{"qa-reported", "qa", "T-1", "success"},
+		{"qa-unreported", "qa", "T-2", "success"},
+		{"qa-failed", "qa", "T-2", "failed"},         // Failed runs aren't expected to sign off
+		{"dev-run", "dev-backend", "T-2", "success"}, // Not a review agent
+	}
+	for i, r := range runs {
+		startedAt := now.Add(time.Duration(i-len(runs)) * time.Minute)
+		if err := store.AddRun(&kanban.AgentRun{ID: r.id, Agent: r.agent, TicketID: r.ticketID, StartedAt: startedAt, Status: "running"}); err != nil {
+			t.Fatalf("failed to add run: %v", err)
+		}
+		store.CompleteRun(r.id, r.status, "")
+	}
+
+	// Only T-1's QA run produced a sign-off thread
+	if err := store.CreateConversation(&kanban.TicketConversation{
+		ID: "conv-1", TicketID: "T-1", ThreadType: kanban.ThreadTypeQASignoff, Status: kanban.ThreadStatusResolved, CreatedAt: now,
+	}); err != nil {
+		t.Fatalf("failed to create conversation: %v", err)
+	}
+	// A sign-off from another stage doesn't count for QA
+	if err := store.CreateConversation(&kanban.TicketConversation{
+		ID: "conv-2", TicketID: "T-2", ThreadType: kanban.ThreadTypeUXSignoff, Status: kanban.ThreadStatusResolved, CreatedAt: now,
+	}); err != nil {
+		t.Fatalf("failed to create conversation: %v", err)
+	}
+
+	got, err := store.GetRunsWithoutSignoff(0)
+	if err != nil {
+		t.Fatalf("GetRunsWithoutSignoff failed: %v", err)
+	}
+	if len(got) != 1 || got[0].ID != "qa-unreported" {
+		t.Errorf("expected only qa-unreported, got %+v", got)
+	}
+}
+
+func TestSetConfigInvalidatesConfigCache(t *testing.T) {
+	store := newTestStore(t)
+
+	if err := store.SetConfig("worktree_dir", ".worktrees"); err != nil {
+		t.Fatalf("SetConfig failed: %v", err)
+	}
+	if v, _ := store.GetConfigValue("worktree_dir"); v != ".worktrees" {
+		t.Fatalf("expected .worktrees, got %q", v)
+	}
+
+	// A write that bypasses the store is served stale until the cache expires
+	if _, err := store.db.Exec(`UPDATE config SET value = 'external' WHERE key = 'worktree_dir'`); err != nil {
+		t.Fatalf("direct update failed: %v", err)
+	}
+	if v, _ := store.GetConfigValue("worktree_dir"); v != ".worktrees" {
+		t.Errorf("expected the cached value, got %q", v)
+	}
+
+	if err := store.SetConfig("worktree_dir", "/tmp/wt"); err != nil {
+		t.Fatalf("SetConfig failed: %v", err)
+	}
+	if v, _ := store.GetConfigValue("worktree_dir"); v != "/tmp/wt" {
+		t.Errorf("expected the new value after SetConfig, got %q", v)
+	}
+
+	// Stores sharing a database share the cache
+	if err := NewStore(store.db).SetConfig("worktree_dir", "/srv/wt"); err != nil {
+		t.Fatalf("SetConfig failed: %v", err)
+	}
+	if v, _ := store.GetConfigValue("worktree_dir"); v != "/srv/wt" {
+		t.Errorf("expected a write through another store to be visible, got %q", v)
+	}
+
+	// With caching disabled, reads go straight to the database
+	store.db.SetConfigCacheTTL(0)
+	if _, err := store.db.Exec(`UPDATE config SET value = 'external' WHERE key = 'worktree_dir'`); err != nil {
+		t.Fatalf("direct update failed: %v", err)
+	}
+	if v, _ := store.GetConfigValue("worktree_dir"); v != "external" {
+		t.Errorf("expected an uncached read, got %q", v)
+	}
+}
+
+func TestSupersedeADRLinksBothRecords(t *testing.T) {
+	store := newTestStore(t)
+	now := time.Now()
+
+	old := &kanban.ADR{ID: "ADR-001", Title: "Poll for updates", Status: kanban.ADRStatusAccepted, CreatedAt: now, UpdatedAt: now}
+	if err := store.CreateADR(old); err != nil {
+		t.Fatalf("failed to create ADR: %v", err)
+	}
+
+	replacement := &kanban.ADR{ID: "ADR-002", Title: "Use SSE for updates", Status: kanban.ADRStatusAccepted, CreatedAt: now, UpdatedAt: now}
+	if err := store.SupersedeADR("ADR-001", replacement); err != nil {
+		t.Fatalf("SupersedeADR failed: %v", err)
+	}
+
+	gotOld, err := store.GetADR("ADR-001")
+	if err != nil || gotOld == nil {
+		t.Fatalf("failed to get old ADR: %v", err)
+	}
+	if gotOld.Status != kanban.ADRStatusSuperseded || gotOld.SupersededBy != "ADR-002" {
+		t.Errorf("expected old ADR superseded by ADR-002, got status %s superseded by %q", gotOld.Status, gotOld.SupersededBy)
+	}
+	gotNew, err := store.GetADR("ADR-002")
+	if err != nil || gotNew == nil {
+		t.Fatalf("failed to get new ADR: %v", err)
+	}
+	if gotNew.Status != kanban.ADRStatusAccepted || gotNew.SupersededBy != "" {
+		t.Errorf("expected new ADR accepted and current, got status %s superseded by %q", gotNew.Status, gotNew.SupersededBy)
+	}
+
+	// Superseding a missing ADR leaves no new record behind
+	orphan := &kanban.ADR{ID: "ADR-003", Title: "Orphan", Status: kanban.ADRStatusAccepted, CreatedAt: now, UpdatedAt: now}
+	if err := store.SupersedeADR("ADR-999", orphan); err == nil {
+		t.Error("expected superseding a missing ADR to fail")
+	}
+	if got, _ := store.GetADR("ADR-003"); got != nil {
+		t.Error("expected the new ADR to be rolled back")
+	}
+}
+
+func TestDeleteOrphansRemovesDeletedTicketsConversations(t *testing.T) {
+	store := newTestStore(t)
+	now := time.Now()
+
+	for _, id := range []string{"T-1", "T-2"} {
+		if err := store.CreateTicket(&kanban.Ticket{ID: id, Title: id, Status: kanban.StatusBacklog, CreatedAt: now, UpdatedAt: now}); err != nil {
+			t.Fatalf("failed to create ticket: %v", err)
+		}
+		conv := &kanban.TicketConversation{ID: "conv-" + id, TicketID: id, ThreadType: kanban.ThreadTypeUserQuestion, Status: kanban.ThreadStatusOpen, CreatedAt: now}
+		if err := store.CreateConversation(conv); err != nil {
+			t.Fatalf("failed to create conversation: %v", err)
+		}
+		msg := &kanban.ConversationMessage{ID: "msg-" + id, ConversationID: conv.ID, Agent: "user", MessageType: kanban.MessageTypeQuestion, Content: "hi", CreatedAt: now}
+		if err := store.AddConversationMessage(msg); err != nil {
+			t.Fatalf("failed to add message: %v", err)
+		}
+		att := &kanban.Attachment{ID: "att-" + id, MessageID: msg.ID, Filename: "a.txt", ContentType: "text/plain", Size: 1, Path: "/tmp/att-" + id, CreatedAt: now}
+		if err := store.AddAttachment(att); err != nil {
+			t.Fatalf("failed to add attachment: %v", err)
+		}
+	}
+
+	// PRAGMA foreign_keys only applies to the pooled connection it ran on, so
+	// deletes elsewhere can leave rows behind; reproduce that on one connection
+	store.db.SetMaxOpenConns(1)
+	if _, err := store.db.Exec("PRAGMA foreign_keys=OFF"); err != nil {
+		t.Fatalf("failed to disable foreign keys: %v", err)
+	}
+	if err := store.DeleteTicket("T-1"); err != nil {
+		t.Fatalf("failed to delete ticket: %v", err)
+	}
+	if _, err := store.db.Exec("PRAGMA foreign_keys=ON"); err != nil {
+		t.Fatalf("failed to enable foreign keys: %v", err)
+	}
+
+	orphans, err := store.GetOrphanedConversations()
+	if err != nil {
+		t.Fatalf("failed to get orphaned conversations: %v", err)
+	}
+	if len(orphans) != 1 || orphans[0].ID != "conv-T-1" {
+		t.Fatalf("expected conv-T-1 to be orphaned, got %+v", orphans)
+	}
+
+	cleanup, err := store.DeleteOrphans()
+	if err != nil {
+		t.Fatalf("failed to delete orphans: %v", err)
+	}
+	if cleanup.Conversations != 1 || cleanup.Messages != 1 || len(cleanup.Attachments) != 1 || cleanup.Attachments[0].ID != "att-T-1" {
+		t.Errorf("expected one conversation, message, and attachment removed, got %+v", cleanup)
+	}
+
+	if conv, _ := store.GetConversation("conv-T-1"); conv != nil {
+		t.Error("expected the deleted ticket's conversation to be removed")
+	}
+	if att, _ := store.GetAttachment("att-T-1"); att != nil {
+		t.Error("expected the deleted ticket's attachment to be removed")
+	}
+	if messages, _ := store.GetConversationMessages("conv-T-2"); len(messages) != 1 || len(messages[0].Attachments) != 1 {
+		t.Errorf("expected the live ticket's conversation to be kept, got %+v", messages)
+	}
+	if orphans, _ := store.GetOrphanedConversations(); len(orphans) != 0 {
+		t.Errorf("expected no orphans after cleanup, got %+v", orphans)
+	}
+}
+
+func TestRecomputeTicketContextPersistsBlockedReason(t *testing.T) {
+	store := newTestStore(t)
+	now := time.Now()
+
+	tickets := []*kanban.Ticket{
+		{ID: "T-1", Title: "Parent", Status: kanban.StatusBreakingDown},
+		{ID: "T-2", Title: "Crashing", Status: kanban.StatusBlocked, ParentID: "T-1",
+			Bugs: []kanban.Bug{{ID: "B-1", Severity: "critical", Description: "Crash on save"}}},
+	}
+	for _, ticket := range tickets {
+		ticket.CreatedAt, ticket.UpdatedAt = now, now
+		if err := store.CreateTicket(ticket); err != nil {
+			t.Fatalf("failed to create ticket: %v", err)
+		}
+	}
+
+	if got, _ := store.GetTicket("T-2"); got.BlockedReason != nil {
+		t.Fatalf("expected no stored blocked reason before recompute, got %+v", got.BlockedReason)
+	}
+
+	updated, err := store.RecomputeTicketContext()
+	if err != nil {
+		t.Fatalf("failed to recompute ticket context: %v", err)
+	}
+	if updated != 2 {
+		t.Errorf("expected 2 tickets updated, got %d", updated)
+	}
+
+	got, _ := store.GetTicket("T-2")
+	if got.BlockedReason == nil || got.BlockedReason.Category != "bug" {
+		t.Errorf("expected stored bug blocked reason, got %+v", got.BlockedReason)
+	}
+	if got.CreationContext == nil || got.CreationContext.ParentTitle != "Parent" {
+		t.Errorf("expected stored creation context from the parent, got %+v", got.CreationContext)
+	}
+	if parent, _ := store.GetTicket("T-1"); parent.BlockedReason != nil {
+		t.Errorf("expected no blocked reason for an unblocked ticket, got %+v", parent.BlockedReason)
+	}
+}
+
+func TestPausedTicketIsNotNextForDomain(t *testing.T) {
+	store := newTestStore(t)
+	now := time.Now()
+	ticket := &kanban.Ticket{ID: "T-1", Title: "Backend work", Domain: kanban.DomainBackend, Status: kanban.StatusReady, CreatedAt: now, UpdatedAt: now}
+	if err := store.CreateTicket(ticket); err != nil {
+		t.Fatalf("failed to create ticket: %v", err)
+	}
+
+	if err := store.SetTicketPaused("T-1", true); err != nil {
+		t.Fatalf("failed to pause ticket: %v", err)
+	}
+	if got, _ := store.GetTicket("T-1"); !got.Paused {
+		t.Error("expected the pause to be stored")
+	}
+	if next, ok := store.GetNextTicketForDomain(kanban.DomainBackend); ok {
+		t.Errorf("expected no schedulable ticket while paused, got %s", next.ID)
+	}
+
+	// Saving a copy loaded before the pause doesn't resume it
+	ticket.Title = "Backend work, renamed"
+	if err := store.UpdateTicket(ticket); err != nil {
+		t.Fatalf("failed to update ticket: %v", err)
+	}
+	if got, _ := store.GetTicket("T-1"); !got.Paused {
+		t.Error("expected the pause to survive a ticket update")
+	}
+
+	if err := store.SetTicketPaused("T-1", false); err != nil {
+		t.Fatalf("failed to resume ticket: %v", err)
+	}
+	if next, ok := store.GetNextTicketForDomain(kanban.DomainBackend); !ok || next.ID != "T-1" {
+		t.Errorf("expected T-1 to be schedulable once resumed, got %v", next)
+	}
+}
+
+func TestStatusChangesAreRecordedInActivityFeed(t *testing.T) {
+	store := newTestStore(t)
+
+	now := time.Now()
+	if err := store.CreateTicket(&kanban.Ticket{ID: "T-1", Title: "Feed", Status: kanban.StatusBacklog, CreatedAt: now, UpdatedAt: now}); err != nil {
+		t.Fatalf("CreateTicket failed: %v", err)
+	}
+	for _, status := range []kanban.Status{kanban.StatusReady, kanban.StatusInDev, kanban.StatusInQA} {
+		if err := store.UpdateTicketStatus("T-1", status, "orchestrator", ""); err != nil {
+			t.Fatalf("UpdateTicketStatus failed: %v", err)
+		}
+	}
+
+	feed, err := store.GetActivity(2)
+	if err != nil {
+		t.Fatalf("GetActivity failed: %v", err)
+	}
+	if len(feed) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(feed))
+	}
+	for _, a := range feed {
+		if a.Kind != kanban.ActivityStatusChange || a.TicketID != "T-1" {
+			t.Errorf("unexpected activity entry: %+v", a)
+		}
+	}
+	if !strings.Contains(feed[0].Message, string(kanban.StatusInQA)) || !strings.Contains(feed[1].Message, string(kanban.StatusInDev)) {
+		t.Errorf("expected newest first, got %q then %q", feed[0].Message, feed[1].Message)
+	}
+
+	// Retention prunes the oldest entries
+	if err := store.SetConfig("activity_feed_retention", "1"); err != nil {
+		t.Fatalf("SetConfig failed: %v", err)
+	}
+	if err := store.UpdateTicketStatus("T-1", kanban.StatusDone, "orchestrator", ""); err != nil {
+		t.Fatalf("UpdateTicketStatus failed: %v", err)
+	}
+	if feed, _ := store.GetActivity(10); len(feed) != 1 || !strings.Contains(feed[0].Message, string(kanban.StatusDone)) {
+		t.Errorf("expected only the newest entry to be kept, got %+v", feed)
+	}
+}
+
+func TestUpdateTicketChecksVersionOnlyWhenGiven(t *testing.T) {
+	store := newTestStore(t)
+
+	now := time.Now()
+	if err := store.CreateTicket(&kanban.Ticket{ID: "T-1", Title: "PRD", Status: kanban.StatusRefiningRound, CreatedAt: now, UpdatedAt: now}); err != nil {
+		t.Fatalf("CreateTicket failed: %v", err)
+	}
+
+	// An internal read-modify-write still lands after another write bumped the version
+	ticket, _ := store.GetTicket("T-1")
+	if err := store.UpdateActivity("T-1", "Synthesizing PRD", "pm"); err != nil {
+		t.Fatalf("UpdateActivity failed: %v", err)
+	}
+	ticket.Notes = "Final PRD"
+	if err := store.UpdateTicket(ticket); err != nil {
+		t.Fatalf("UpdateTicket failed: %v", err)
+	}
+	if got, _ := store.GetTicket("T-1"); got.Notes != "Final PRD" {
+		t.Errorf("expected the notes to be saved, got %q", got.Notes)
+	}
+
+	// A caller-supplied version is enforced
+	stale := ticket.Version
+	ticket.Notes = "Stale edit"
+	if err := store.UpdateTicketIfVersion(ticket, stale); !errors.Is(err, ErrVersionConflict) {
+		t.Fatalf("expected a version conflict, got %v", err)
+	}
+	current, _ := store.GetTicket("T-1")
+	if err := store.UpdateTicketIfVersion(ticket, current.Version); err != nil {
+		t.Fatalf("UpdateTicketIfVersion failed: %v", err)
+	}
+	if ticket.Version != current.Version+1 {
+		t.Errorf("expected the version advanced to %d, got %d", current.Version+1, ticket.Version)
+	}
+}
+
+func TestStatusChangeHookReportsTransition(t *testing.T) {
+	store := newTestStore(t)
+	var changes []kanban.StatusChange
+	store.OnStatusChange(func(change kanban.StatusChange) { changes = append(changes, change) })
+
+	now := time.Now()
+	if err := store.CreateTicket(&kanban.Ticket{ID: "T-1", Title: "Hook", Status: kanban.StatusReady, CreatedAt: now, UpdatedAt: now}); err != nil {
+		t.Fatalf("CreateTicket failed: %v", err)
+	}
+	if err := store.UpdateTicketStatus("T-1", kanban.StatusInDev, "orchestrator", "Picked up"); err != nil {
+		t.Fatalf("UpdateTicketStatus failed: %v", err)
+	}
+
+	if len(changes) != 1 {
+		t.Fatalf("expected one change, got %+v", changes)
+	}
+	got := changes[0]
+	if got.TicketID != "T-1" || got.From != kanban.StatusReady || got.To != kanban.StatusInDev || got.By != "orchestrator" || got.Note != "Picked up" || got.At.IsZero() {
+		t.Errorf("unexpected change: %+v", got)
+	}
+}
+
+func TestPruneExpiredRunsClearsOnlyOldOutput(t *testing.T) {
+	store := newTestStore(t)
+
+	now := time.Now()
+	if err := store.CreateTicket(&kanban.Ticket{ID: "T-1", Title: "Retention", Status: kanban.StatusInDev, CreatedAt: now, UpdatedAt: now}); err != nil {
+		t.Fatalf("CreateTicket failed: %v", err)
+	}
+	for _, id := range []string{"old", "recent"} {
+		if err := store.AddRun(&kanban.AgentRun{ID: id, Agent: "dev", TicketID: "T-1", StartedAt: now, Status: "running"}); err != nil {
+			t.Fatalf("AddRun failed: %v", err)
+		}
+		store.CompleteRun(id, "success", "output of "+id)
+	}
+	if _, err := store.db.Exec(`UPDATE agent_runs SET ended_at = ? WHERE id = 'old'`, now.AddDate(0, 0, -30)); err != nil {
+		t.Fatalf("failed to age run: %v", err)
+	}
+	if err := store.AddAuditEntry(&kanban.AuditEntry{ID: "A-1", RunID: "old", TicketID: "T-1", Agent: "dev", EventType: kanban.AuditEventPromptSent}); err != nil {
+		t.Fatalf("AddAuditEntry failed: %v", err)
+	}
+
+	// Pruning is off until a retention is configured
+	if pruned, err := store.PruneExpiredRuns(); err != nil || pruned != 0 {
+		t.Fatalf("expected no pruning without retention, got %d (%v)", pruned, err)
+	}
+	if err := store.SetConfig("run_retention_days", "7"); err != nil {
+		t.Fatalf("SetConfig failed: %v", err)
+	}
+
+	preview, err := store.PreviewRunPrune()
+	if err != nil {
+		t.Fatalf("PreviewRunPrune failed: %v", err)
+	}
+	if preview.Runs != 1 || preview.OutputBytes != int64(len("output of old")) || preview.AuditEntries != 1 {
+		t.Errorf("unexpected preview: %+v", preview)
+	}
+
+	if pruned, err := store.PruneExpiredRuns(); err != nil || pruned != 1 {
+		t.Fatalf("expected 1 pruned run, got %d (%v)", pruned, err)
+	}
+	old, err := store.GetRun("old")
+	if err != nil || old == nil {
+		t.Fatalf("expected old run metadata to remain: %v", err)
+	}
+	if old.Output != "" || old.Status != "success" {
+		t.Errorf("expected old output cleared and status kept, got %+v", old)
+	}
+	if recent, _ := store.GetRun("recent"); recent == nil || recent.Output != "output of recent" {
+		t.Errorf("expected recent output kept, got %+v", recent)
+	}
+
+	// Delete mode removes the run but keeps its audit trail on the ticket
+	if err := store.SetConfig("run_retention_mode", "delete"); err != nil {
+		t.Fatalf("SetConfig failed: %v", err)
+	}
+	if pruned, err := store.PruneExpiredRuns(); err != nil || pruned != 1 {
+		t.Fatalf("expected 1 deleted run, got %d (%v)", pruned, err)
+	}
+	if run, _ := store.GetRun("old"); run != nil {
+		t.Errorf("expected old run deleted, got %+v", run)
+	}
+	entries, err := store.GetAuditEntriesByTicket("T-1")
+	if err != nil || len(entries) != 1 || entries[0].RunID != "" {
+		t.Errorf("expected audit entry kept without its run, got %+v (%v)", entries, err)
+	}
+}
+
+func TestCompletedRunPersistsMetadata(t *testing.T) {
+	store := newTestStore(t)
+
+	now := time.Now()
+	if err := store.CreateTicket(&kanban.Ticket{ID: "T-1", Title: "Metadata", Status: kanban.StatusInDev, CreatedAt: now, UpdatedAt: now}); err != nil {
+		t.Fatalf("CreateTicket failed: %v", err)
+	}
+	if err := store.AddRun(&kanban.AgentRun{ID: "run-1", Agent: "dev-backend", TicketID: "T-1", StartedAt: now, Status: "running"}); err != nil {
+		t.Fatalf("AddRun failed: %v", err)
+	}
+
+	store.CompleteRun("run-1", "success", "done")
+	store.SetRunMetadata("run-1", kanban.RunMetadata{Model: "claude-sonnet-4", TokenInput: 1200, TokenOutput: 340, ExitReason: "end_turn"})
+
+	run, err := store.GetRun("run-1")
+	if err != nil || run == nil {
+		t.Fatalf("GetRun failed: %v", err)
+	}
+	want := kanban.RunMetadata{Model: "claude-sonnet-4", TokenInput: 1200, TokenOutput: 340, ExitReason: "end_turn"}
+	if run.RunMetadata != want {
+		t.Errorf("expected metadata %+v, got %+v", want, run.RunMetadata)
+	}
+
+	runs, err := store.GetRunsByTicket("T-1")
+	if err != nil || len(runs) != 1 || runs[0].RunMetadata != want {
+		t.Errorf("expected metadata in ticket runs, got %+v (%v)", runs, err)
+	}
+}
+
+func TestOrchestratorLockExcludesSecondOwner(t *testing.T) {
+	store := newTestStore(t)
+
+	if err := store.AcquireOrchestratorLock("host-a:1", time.Minute); err != nil {
+		t.Fatalf("first acquire failed: %v", err)
+	}
+	if err := store.AcquireOrchestratorLock("host-a:1", time.Minute); err != nil {
+		t.Errorf("expected the holder to re-acquire its own lock, got %v", err)
+	}
+	if err := store.AcquireOrchestratorLock("host-b:2", time.Minute); !errors.Is(err, ErrLockHeld) {
+		t.Fatalf("expected ErrLockHeld while the lock is held, got %v", err)
+	}
+
+	if err := store.ReleaseOrchestratorLock("host-a:1"); err != nil {
+		t.Fatalf("release failed: %v", err)
+	}
+	if err := store.AcquireOrchestratorLock("host-b:2", time.Minute); err != nil {
+		t.Fatalf("expected acquire to succeed after release, got %v", err)
+	}
+	if err := store.HeartbeatOrchestratorLock("host-a:1"); !errors.Is(err, ErrLockHeld) {
+		t.Errorf("expected a released owner's heartbeat to fail, got %v", err)
+	}
+
+	// A holder that stopped heartbeating is reclaimed
+	if err := store.AcquireOrchestratorLock("host-c:3", 0); err != nil {
+		t.Errorf("expected a stale lock to be reclaimed, got %v", err)
+	}
+}
+
+func TestConfigSchemaCoversBoardAndWorktreeConfig(t *testing.T) {
+	file, err := parser.ParseFile(token.NewFileSet(), "store.go", nil, 0)
+	if err != nil {
+		t.Fatalf("failed to parse store.go: %v", err)
+	}
+
+	var keys []string
+	for _, decl := range file.Decls {
+		fn, ok := decl.(*ast.FuncDecl)
+		if !ok || (fn.Name.Name != "getBoardConfig" && fn.Name.Name != "GetWorktreeConfig") {
+			continue
+		}
+		ast.Inspect(fn.Body, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if !ok || len(call.Args) != 1 {
+				return true
+			}
+			if sel, ok := call.Fun.(*ast.SelectorExpr); !ok || sel.Sel.Name != "GetConfigValue" {
+				return true
+			}
+			if lit, ok := call.Args[0].(*ast.BasicLit); ok {
+				keys = append(keys, strings.Trim(lit.Value, `"`))
+			}
+			return true
+		})
+	}
+	if len(keys) == 0 {
+		t.Fatal("found no config keys read in getBoardConfig or GetWorktreeConfig")
+	}
+
+	for _, key := range keys {
+		if _, ok := LookupConfigKey(key); !ok {
+			t.Errorf("config key %q is read but missing from ConfigSchema", key)
+		}
+	}
+}
+
+func TestValidateConfigValue(t *testing.T) {
+	tests := []struct {
+		key, value string
+		valid      bool
+	}{
+		{"max_global_worktrees", "5", true},
+		{"max_global_worktrees", "five", false},
+		{"rebase_before_qa", "true", true},
+		{"rebase_before_qa", "yes", false},
+		{"duplicate_threshold", "0.8", true},
+		{"pipelines", `{"bug": ["IN_DEV"]}`, true},
+		{"pipelines", `{"bug": [`, false},
+		{"run_retention_mode", "delete", true},
+		{"run_retention_mode", "archive", false},
+		{"wip_limits", `{"IN_DEV": 2, "IN_QA": 3}`, true},
+		{"wip_limits", `{"DONE": 5}`, false},
+		{"wip_limits", `{"IN_DEV": -1}`, false},
+		{"wip_limits", `["IN_DEV"]`, false},
+		{"webhooks", `{"url": "https://example.com/hook", "secret": "s3cret"}`, true},
+		{"webhooks", `{"secret": "s3cret"}`, false},
+		{"max_global_worktrees", "", true},
+		{"no_such_key", "1", false},
+	}
+	for _, tt := range tests {
+		err := ValidateConfigValue(tt.key, tt.value)
+		if (err == nil) != tt.valid {
+			t.Errorf("ValidateConfigValue(%q, %q) = %v, want valid=%v", tt.key, tt.value, err, tt.valid)
+		}
+	}
+}
+
+func TestCompletedIterationDigestCountsTicketsAndADRs(t *testing.T) {
+	store := newTestStore(t)
+	now := time.Now()
+	iteration := &kanban.Iteration{ID: "it-2", Goal: "Ship sync", StartedAt: now.Add(-time.Hour), Status: "active"}
+	store.SetIteration(iteration)
+
+	for _, id := range []string{"T-1", "T-2"} {
+		if err := store.CreateTicket(&kanban.Ticket{ID: id, Title: "Work " + id, Status: kanban.StatusInDev, CreatedAt: now.Add(-30 * time.Minute), UpdatedAt: now}); err != nil {
+			t.Fatalf("failed to create ticket: %v", err)
+		}
+	}
+	if err := store.AddBug("T-2", kanban.Bug{ID: "BUG-1", Title: "Crash", Severity: "high", FoundBy: "qa", FoundAt: now.Add(-10 * time.Minute)}); err != nil {
+		t.Fatalf("failed to add bug: %v", err)
+	}
+	for _, adr := range []kanban.ADR{
+		{ID: "ADR-001", Title: "Earlier decision", Status: kanban.ADRStatusAccepted, IterationID: "it-1", CreatedAt: now.Add(-48 * time.Hour)},
+		{ID: "ADR-002", Title: "Use SQLite", Status: kanban.ADRStatusAccepted, IterationID: "it-2", CreatedAt: now.Add(-20 * time.Minute)},
+	} {
+		if err := store.CreateADR(&adr); err != nil {
+			t.Fatalf("failed to create ADR: %v", err)
+		}
+	}
+	for i, status := range []string{"success", "failed", "success"} {
+		run := &kanban.AgentRun{ID: fmt.Sprintf("run-%d", i), Agent: "qa", TicketID: "T-1", StartedAt: now.Add(-15 * time.Minute), Status: status}
+		if err := store.AddRun(run); err != nil {
+			t.Fatalf("failed to add run: %v", err)
+		}
+	}
+
+	if store.IsIterationComplete() {
+		t.Fatal("expected the iteration to be open while tickets are in development")
+	}
+	for _, id := range []string{"T-1", "T-2"} {
+		if err := store.UpdateTicketStatus(id, kanban.StatusDone, "pm", "Signed off"); err != nil {
+			t.Fatalf("failed to complete ticket: %v", err)
+		}
+	}
+	if !store.IsIterationComplete() {
+		t.Fatal("expected the iteration to be complete")
+	}
+
+	digest, err := store.BuildIterationDigest(iteration)
+	if err != nil {
+		t.Fatalf("failed to build digest: %v", err)
+	}
+	if err := store.SaveIterationDigest(digest); err != nil {
+		t.Fatalf("failed to save digest: %v", err)
+	}
+	got, err := store.GetIterationDigest("it-2")
+	if err != nil || got == nil {
+		t.Fatalf("expected a stored digest, got %v (err %v)", got, err)
+	}
+
+	if strings.Join(got.TicketsCompleted, ",") != "T-1,T-2" {
+		t.Errorf("expected T-1 and T-2 completed, got %v", got.TicketsCompleted)
+	}
+	if strings.Join(got.ADRsCreated, ",") != "ADR-002" {
+		t.Errorf("expected only this iteration's ADR, got %v", got.ADRsCreated)
+	}
+	if got.BugsFound != 1 || got.BugsBySeverity["high"] != 1 {
+		t.Errorf("expected 1 high bug, got %d %v", got.BugsFound, got.BugsBySeverity)
+	}
+	qa := got.AgentRuns["qa"]
+	if qa.Runs != 3 || qa.Succeeded != 2 || qa.Failed != 1 {
+		t.Errorf("expected 3 QA runs with 2 successes, got %+v", qa)
+	}
+	// Each ticket took about 30 minutes from creation to done
+	if got.TotalCycleTime < 55*time.Minute || got.TotalCycleTime > 65*time.Minute {
+		t.Errorf("expected about an hour of cycle time, got %v", got.TotalCycleTime)
+	}
+
+	if missing, err := store.GetIterationDigest("it-1"); err != nil || missing != nil {
+		t.Errorf("expected no digest for an iteration that never completed, got %v (err %v)", missing, err)
+	}
+}
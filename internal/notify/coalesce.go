@@ -0,0 +1,100 @@
+package notify
+
+import (
+	"sync"
+	"time"
+)
+
+// Coalescer rate-limits notifications per ticket. A notification is delivered
+// immediately unless one for the same ticket was sent within the minimum
+// interval; those are held, later ones replace them, and only the latest is
+// delivered when the interval is up, so a burst of changes produces the first
+// notification and one reflecting the final state. Notifications without a
+// ticket are delivered immediately.
+type Coalescer struct {
+	next     Notifier
+	interval time.Duration
+
+	mu       sync.Mutex
+	pending  map[string]Notification
+	timers   map[string]*time.Timer
+	lastSent map[string]time.Time
+}
+
+// NewCoalescer wraps next so each ticket is notified at most once per interval.
+func NewCoalescer(next Notifier, interval time.Duration) *Coalescer {
+	return &Coalescer{
+		next:     next,
+		interval: interval,
+		pending:  make(map[string]Notification),
+		timers:   make(map[string]*time.Timer),
+		lastSent: make(map[string]time.Time),
+	}
+}
+
+// Notify delivers the notification, or queues it as the latest for its ticket
+// if the ticket was notified within the interval.
+func (c *Coalescer) Notify(n Notification) error {
+	if n.TicketID == "" || c.interval <= 0 {
+		return c.next.Notify(n)
+	}
+	ticketID := n.TicketID
+
+	c.mu.Lock()
+	if _, held := c.timers[ticketID]; held {
+		c.pending[ticketID] = n
+		c.mu.Unlock()
+		return nil
+	}
+	if last, ok := c.lastSent[ticketID]; ok {
+		if wait := c.interval - time.Since(last); wait > 0 {
+			c.pending[ticketID] = n
+			c.timers[ticketID] = time.AfterFunc(wait, func() { c.flush(ticketID) })
+			c.mu.Unlock()
+			return nil
+		}
+	}
+	c.lastSent[ticketID] = time.Now()
+	c.mu.Unlock()
+
+	return c.next.Notify(n)
+}
+
+// LastSent returns when a notification for the ticket was last delivered.
+func (c *Coalescer) LastSent(ticketID string) (time.Time, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	at, ok := c.lastSent[ticketID]
+	return at, ok
+}
+
+// Flush delivers every pending notification now, e.g. on shutdown.
+func (c *Coalescer) Flush() {
+	c.mu.Lock()
+	ticketIDs := make([]string, 0, len(c.timers))
+	for ticketID, timer := range c.timers {
+		timer.Stop()
+		ticketIDs = append(ticketIDs, ticketID)
+	}
+	c.mu.Unlock()
+
+	for _, ticketID := range ticketIDs {
+		c.flush(ticketID)
+	}
+}
+
+// flush delivers the ticket's held notification.
+func (c *Coalescer) flush(ticketID string) {
+	c.mu.Lock()
+	n, ok := c.pending[ticketID]
+	delete(c.pending, ticketID)
+	delete(c.timers, ticketID)
+	if ok {
+		c.lastSent[ticketID] = time.Now()
+	}
+	c.mu.Unlock()
+
+	if ok {
+		_ = c.next.Notify(n)
+	}
+}
@@ -0,0 +1,76 @@
+package notify
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordingNotifier records delivered notifications.
+type recordingNotifier struct {
+	mu   sync.Mutex
+	sent []Notification
+}
+
+func (r *recordingNotifier) Notify(n Notification) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sent = append(r.sent, n)
+	return nil
+}
+
+func (r *recordingNotifier) delivered() []Notification {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Notification(nil), r.sent...)
+}
+
+func TestCoalescerCollapsesRapidTicketChanges(t *testing.T) {
+	recorder := &recordingNotifier{}
+	c := NewCoalescer(recorder, 50*time.Millisecond)
+
+	for _, status := range []string{"IN_DEV", "IN_QA", "IN_UX"} {
+		_ = c.Notify(Notification{Event: "ticket:status", TicketID: "T-1", Message: status})
+	}
+	// Notifications without a ticket aren't held back
+	_ = c.Notify(Notification{Event: "health:degraded"})
+
+	deadline := time.Now().Add(time.Second)
+	for len(recorder.delivered()) < 3 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	time.Sleep(100 * time.Millisecond) // Nothing else should follow
+
+	sent := recorder.delivered()
+	if len(sent) != 3 {
+		t.Fatalf("expected 3 notifications, got %d: %+v", len(sent), sent)
+	}
+	if sent[0].TicketID != "T-1" || sent[0].Message != "IN_DEV" {
+		t.Errorf("expected the first change to be delivered immediately, got %+v", sent[0])
+	}
+	if sent[1].Event != "health:degraded" {
+		t.Errorf("expected the ticketless notification not to wait, got %+v", sent[1])
+	}
+	if sent[2].TicketID != "T-1" || sent[2].Message != "IN_UX" {
+		t.Errorf("expected one more notification with the final status, got %+v", sent[2])
+	}
+	if _, ok := c.LastSent("T-1"); !ok {
+		t.Error("expected the ticket's last-sent time to be tracked")
+	}
+}
+
+func TestCoalescerFlushDeliversPending(t *testing.T) {
+	recorder := &recordingNotifier{}
+	c := NewCoalescer(recorder, time.Hour)
+
+	_ = c.Notify(Notification{TicketID: "T-1", Message: "IN_QA"})
+	_ = c.Notify(Notification{TicketID: "T-1", Message: "IN_UX"})
+	if sent := recorder.delivered(); len(sent) != 1 || sent[0].Message != "IN_QA" {
+		t.Fatalf("expected only the first notification delivered, got %+v", sent)
+	}
+	c.Flush()
+
+	if sent := recorder.delivered(); len(sent) != 2 || sent[1].Message != "IN_UX" {
+		t.Errorf("expected the pending notification to be delivered, got %+v", sent)
+	}
+}
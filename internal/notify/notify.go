@@ -0,0 +1,43 @@
+// Package notify delivers operator notifications about factory events.
+package notify
+
+import (
+	"log/slog"
+	"time"
+)
+
+// Notification is a single operator-facing event.
+type Notification struct {
+	Event    string    `json:"event"` // e.g. "health:thrashing"
+	Title    string    `json:"title"`
+	Message  string    `json:"message"`
+	TicketID string    `json:"ticketId,omitempty"`
+	Target   string    `json:"target,omitempty"` // Who should act, e.g. a team or channel; empty for all operators
+	At       time.Time `json:"at"`
+}
+
+// Notifier delivers notifications to operators.
+type Notifier interface {
+	Notify(n Notification) error
+}
+
+// LogNotifier writes notifications to the structured log.
+type LogNotifier struct {
+	logger *slog.Logger
+}
+
+// NewLogNotifier creates a notifier that logs each notification as a warning.
+func NewLogNotifier(logger *slog.Logger) *LogNotifier {
+	return &LogNotifier{logger: logger}
+}
+
+// Notify logs the notification.
+func (n *LogNotifier) Notify(notification Notification) error {
+	n.logger.Warn("Notification",
+		"event", notification.Event,
+		"title", notification.Title,
+		"message", notification.Message,
+		"ticket", notification.TicketID,
+		"target", notification.Target)
+	return nil
+}
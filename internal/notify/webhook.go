@@ -0,0 +1,164 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/madhatter5501/Factory/kanban"
+)
+
+// SignatureHeader carries the HMAC-SHA256 of a webhook body, as
+// "sha256=<hex>", when the webhook has a secret.
+const SignatureHeader = "X-Factory-Signature"
+
+// Webhook delivery retries and the delay before the first retry, doubled for
+// each further retry.
+const (
+	defaultWebhookRetries = 3
+	defaultWebhookBackoff = time.Second
+)
+
+// WebhookConfig is an endpoint ticket status changes are POSTed to, as set in
+// the webhooks config key.
+type WebhookConfig struct {
+	URL    string `json:"url"`
+	Secret string `json:"secret,omitempty"` // Signs payloads when set
+}
+
+// ParseWebhookConfig parses the webhooks config value, e.g.
+// {"url": "https://example.com/hook", "secret": "s3cret"}.
+func ParseWebhookConfig(value string) (WebhookConfig, error) {
+	var config WebhookConfig
+	if err := json.Unmarshal([]byte(value), &config); err != nil {
+		return config, fmt.Errorf("webhook config must be an object with a url: %w", err)
+	}
+	u, err := url.Parse(config.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return config, fmt.Errorf("webhook url %q must be an http or https URL", config.URL)
+	}
+	return config, nil
+}
+
+// ConfigSource reads config values, e.g. a *db.Store.
+type ConfigSource interface {
+	GetConfigValue(key string) (string, error)
+}
+
+// WebhookFromConfig returns the webhook set in the webhooks config key, or nil
+// if none is set or it is invalid.
+func WebhookFromConfig(config ConfigSource, logger *slog.Logger) *Webhook {
+	v, _ := config.GetConfigValue("webhooks")
+	if v == "" {
+		return nil
+	}
+	webhookConfig, err := ParseWebhookConfig(v)
+	if err != nil {
+		logger.Warn("Ignoring invalid webhook config", "error", err)
+		return nil
+	}
+	return NewWebhook(webhookConfig, logger)
+}
+
+// StatusChangeHook returns a store status hook that delivers each change to
+// the configured webhook in the background. The config is read per change so
+// edits apply without a restart.
+func StatusChangeHook(config ConfigSource, logger *slog.Logger) func(kanban.StatusChange) {
+	return func(change kanban.StatusChange) {
+		if webhook := WebhookFromConfig(config, logger); webhook != nil {
+			webhook.Send(change)
+		}
+	}
+}
+
+// Webhook POSTs JSON events to an endpoint, retrying failed deliveries with
+// exponential backoff.
+type Webhook struct {
+	config  WebhookConfig
+	client  *http.Client
+	logger  *slog.Logger
+	retries int
+	backoff time.Duration
+}
+
+// NewWebhook creates a webhook delivering to the configured endpoint.
+func NewWebhook(config WebhookConfig, logger *slog.Logger) *Webhook {
+	return &Webhook{
+		config:  config,
+		client:  &http.Client{Timeout: 10 * time.Second},
+		logger:  logger,
+		retries: defaultWebhookRetries,
+		backoff: defaultWebhookBackoff,
+	}
+}
+
+// Send delivers the event in the background, logging it if every attempt fails.
+func (w *Webhook) Send(event interface{}) {
+	go func() {
+		if err := w.Deliver(context.Background(), event); err != nil {
+			w.logger.Error("Webhook delivery failed", "url", w.config.URL, "error", err)
+		}
+	}()
+}
+
+// Deliver POSTs the event, retrying until it is accepted, the retries run out
+// or ctx is done.
+func (w *Webhook) Deliver(ctx context.Context, event interface{}) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal webhook payload: %w", err)
+	}
+
+	for attempt := 0; ; attempt++ {
+		err = w.post(ctx, body)
+		if err == nil || attempt >= w.retries {
+			return err
+		}
+
+		delay := w.backoff << attempt
+		w.logger.Warn("Webhook delivery failed, retrying", "url", w.config.URL, "attempt", attempt+1, "delay", delay, "error", err)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+// post makes a single delivery attempt.
+func (w *Webhook) post(ctx context.Context, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.config.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if w.config.Secret != "" {
+		req.Header.Set(SignatureHeader, Sign(w.config.Secret, body))
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
+
+// Sign returns the signature header value for body, so receivers can verify
+// a delivery came from the factory.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,87 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/madhatter5501/Factory/kanban"
+)
+
+func TestWebhookRetriesAndSignsDelivery(t *testing.T) {
+	var mu sync.Mutex
+	var bodies [][]byte
+	var signatures []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		defer mu.Unlock()
+		bodies = append(bodies, body)
+		signatures = append(signatures, r.Header.Get(SignatureHeader))
+		// The first delivery hits an outage
+		if len(bodies) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+
+	webhook := NewWebhook(WebhookConfig{URL: srv.URL, Secret: "s3cret"}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	webhook.backoff = time.Millisecond
+
+	change := kanban.StatusChange{
+		TicketID: "T-1",
+		From:     kanban.StatusInDev,
+		To:       kanban.StatusInQA,
+		By:       "dev",
+		Note:     "Implementation complete",
+		At:       time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC),
+	}
+	if err := webhook.Deliver(context.Background(), change); err != nil {
+		t.Fatalf("expected the retry to be delivered, got %v", err)
+	}
+
+	if len(bodies) != 2 {
+		t.Fatalf("expected one retry after the outage, got %d deliveries", len(bodies))
+	}
+	var payload map[string]interface{}
+	if err := json.Unmarshal(bodies[1], &payload); err != nil {
+		t.Fatalf("failed to decode payload: %v", err)
+	}
+	want := map[string]interface{}{
+		"ticketId": "T-1",
+		"from":     "IN_DEV",
+		"to":       "IN_QA",
+		"by":       "dev",
+		"note":     "Implementation complete",
+		"at":       "2026-03-01T12:00:00Z",
+	}
+	if len(payload) != len(want) {
+		t.Errorf("expected payload keys %v, got %v", want, payload)
+	}
+	for key, value := range want {
+		if payload[key] != value {
+			t.Errorf("expected %s = %v, got %v", key, value, payload[key])
+		}
+	}
+	if signatures[1] != Sign("s3cret", bodies[1]) {
+		t.Errorf("expected the body to be signed, got %q", signatures[1])
+	}
+}
+
+func TestParseWebhookConfigRequiresHTTPURL(t *testing.T) {
+	for _, value := range []string{`{}`, `{"url": "ftp://example.com"}`, `"https://example.com"`} {
+		if _, err := ParseWebhookConfig(value); err == nil {
+			t.Errorf("expected %s to be rejected", value)
+		}
+	}
+	config, err := ParseWebhookConfig(`{"url": "https://example.com/hook"}`)
+	if err != nil || config.URL != "https://example.com/hook" || config.Secret != "" {
+		t.Errorf("expected an unsigned webhook, got %+v, %v", config, err)
+	}
+}
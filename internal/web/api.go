@@ -1,17 +1,26 @@
 package web
 
 import (
+	"bytes"
 	"context"
+	"encoding/csv"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
 	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
-	"github.com/madhatter5501/Factory/agents/anthropic"
+	factory "github.com/madhatter5501/Factory"
+	"github.com/madhatter5501/Factory/agents"
 	"github.com/madhatter5501/Factory/agents/provider"
+	"github.com/madhatter5501/Factory/git"
+	"github.com/madhatter5501/Factory/internal/db"
+	"github.com/madhatter5501/Factory/internal/notify"
 	"github.com/madhatter5501/Factory/kanban"
 
 	"github.com/google/uuid"
@@ -41,10 +50,17 @@ func (s *Server) apiGetBoard(w http.ResponseWriter, r *http.Request) {
 	s.jsonResponse(w, response)
 }
 
-// apiGetTickets returns a list of tickets, optionally filtered by status.
+// apiGetTickets returns a list of tickets, optionally filtered by status. With
+// ?limit= or ?offset= it returns one page of the board instead.
 func (s *Server) apiGetTickets(w http.ResponseWriter, r *http.Request) {
 	statusFilter := r.URL.Query().Get("status")
 
+	// ?limit=&offset= pages the full board
+	if statusFilter == "" && (r.URL.Query().Has("limit") || r.URL.Query().Has("offset")) {
+		s.getTicketsPage(w, r)
+		return
+	}
+
 	var tickets []kanban.Ticket
 
 	if statusFilter != "" {
@@ -61,6 +77,91 @@ func (s *Server) apiGetTickets(w http.ResponseWriter, r *http.Request) {
 	s.jsonResponse(w, tickets)
 }
 
+// getTicketsPage responds with one page of the board and the total ticket
+// count. limit defaults to db.DefaultTicketPageSize and is capped at
+// db.MaxTicketPageSize.
+func (s *Server) getTicketsPage(w http.ResponseWriter, r *http.Request) {
+	limit, offset := 0, 0
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			s.jsonError(w, "Invalid 'limit', expected a positive integer", http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+	if v := r.URL.Query().Get("offset"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			s.jsonError(w, "Invalid 'offset', expected a non-negative integer", http.StatusBadRequest)
+			return
+		}
+		offset = n
+	}
+	if limit == 0 {
+		limit = db.DefaultTicketPageSize
+	}
+	if limit > db.MaxTicketPageSize {
+		limit = db.MaxTicketPageSize
+	}
+
+	tickets, total, err := s.store.GetTicketsPaged(limit, offset)
+	if err != nil {
+		s.logger.Error("Failed to get tickets page", "error", err)
+		s.jsonError(w, "Failed to get tickets", http.StatusInternalServerError)
+		return
+	}
+
+	s.jsonResponse(w, map[string]interface{}{
+		"tickets": tickets,
+		"total":   total,
+		"limit":   limit,
+		"offset":  offset,
+	})
+}
+
+// apiSearchTickets returns tickets matching the ?q keywords in their title,
+// description or notes, best match first, each with a highlighted
+// searchSnippet. ?limit caps the results (at most db.MaxTicketSearchLimit).
+func (s *Server) apiSearchTickets(w http.ResponseWriter, r *http.Request) {
+	q := strings.TrimSpace(r.URL.Query().Get("q"))
+	if q == "" {
+		s.jsonError(w, "Missing search query 'q'", http.StatusBadRequest)
+		return
+	}
+	limit := 0
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			s.jsonError(w, "Invalid 'limit', expected a positive integer", http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	tickets, err := s.store.SearchTickets(q, limit)
+	if err != nil {
+		s.logger.Error("Failed to search tickets", "query", q, "error", err)
+		s.jsonError(w, "Failed to search tickets", http.StatusInternalServerError)
+		return
+	}
+
+	s.jsonResponse(w, tickets)
+}
+
+// apiRecomputeTicketContext recomputes and stores every ticket's blocked reason
+// and creation context, so ticket API reads include them.
+func (s *Server) apiRecomputeTicketContext(w http.ResponseWriter, r *http.Request) {
+	updated, err := s.store.RecomputeTicketContext()
+	if err != nil {
+		s.logger.Error("Failed to recompute ticket context", "error", err)
+		s.jsonError(w, "Failed to recompute ticket context", http.StatusInternalServerError)
+		return
+	}
+
+	s.jsonResponse(w, map[string]int{"updated": updated})
+}
+
 // apiGetTicket returns a single ticket by ID.
 func (s *Server) apiGetTicket(w http.ResponseWriter, r *http.Request) {
 	id := r.PathValue("id")
@@ -78,6 +179,157 @@ func (s *Server) apiGetTicket(w http.ResponseWriter, r *http.Request) {
 	s.jsonResponse(w, ticket)
 }
 
+// apiPauseTicket stops agents from picking up a ticket until it is resumed.
+// With ?cancel=true, agents already running on it are cancelled too.
+func (s *Server) apiPauseTicket(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		s.jsonError(w, "Missing ticket ID", http.StatusBadRequest)
+		return
+	}
+	if _, found := s.store.GetTicket(id); !found {
+		s.jsonError(w, "Ticket not found", http.StatusNotFound)
+		return
+	}
+
+	if err := s.store.SetTicketPaused(id, true); err != nil {
+		s.logger.Error("Failed to pause ticket", "id", id, "error", err)
+		s.jsonError(w, "Failed to pause ticket", http.StatusInternalServerError)
+		return
+	}
+
+	cancelled := 0
+	if r.URL.Query().Get("cancel") == "true" {
+		s.orchMu.RLock()
+		if s.orchestrator != nil {
+			cancelled = s.orchestrator.CancelTicketRuns(id)
+		}
+		s.orchMu.RUnlock()
+	}
+	s.logger.Info("Ticket paused", "id", id, "cancelledRuns", cancelled)
+
+	s.Broadcast("board-update")
+
+	s.jsonResponse(w, map[string]interface{}{"status": "paused", "cancelledRuns": cancelled})
+}
+
+// apiResumeTicket lets agents pick up a paused ticket again.
+func (s *Server) apiResumeTicket(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		s.jsonError(w, "Missing ticket ID", http.StatusBadRequest)
+		return
+	}
+	if _, found := s.store.GetTicket(id); !found {
+		s.jsonError(w, "Ticket not found", http.StatusNotFound)
+		return
+	}
+
+	if err := s.store.SetTicketPaused(id, false); err != nil {
+		s.logger.Error("Failed to resume ticket", "id", id, "error", err)
+		s.jsonError(w, "Failed to resume ticket", http.StatusInternalServerError)
+		return
+	}
+
+	s.Broadcast("board-update")
+
+	s.jsonResponse(w, map[string]string{"status": "resumed"})
+}
+
+// TicketDetail is a ticket with everything the ticket detail page shows.
+type TicketDetail struct {
+	Ticket         *kanban.Ticket              `json:"ticket"`
+	Conversations  []kanban.TicketConversation `json:"conversations"`
+	PMCheckins     []kanban.PMCheckin          `json:"pmCheckins"`
+	TimeStats      *kanban.TimeStats           `json:"timeStats"`
+	AgentRuns      []kanban.AgentRun           `json:"agentRuns"`
+	ADRs           []kanban.ADR                `json:"adrs"`
+	Tags           []kanban.Tag                `json:"tags"`
+	WorktreeEvents []kanban.WorktreeEvent      `json:"worktreeEvents"`
+}
+
+// apiGetTicketFull returns a ticket with all its related entities in one call.
+func (s *Server) apiGetTicketFull(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		s.jsonError(w, "Missing ticket ID", http.StatusBadRequest)
+		return
+	}
+
+	ticket, found := s.store.GetTicket(id)
+	if !found {
+		s.jsonError(w, "Ticket not found", http.StatusNotFound)
+		return
+	}
+
+	detail, err := s.loadTicketDetail(ticket)
+	if err != nil {
+		s.logger.Error("Failed to load ticket detail", "ticketID", id, "error", err)
+		s.jsonError(w, "Failed to load ticket detail", http.StatusInternalServerError)
+		return
+	}
+
+	s.jsonResponse(w, detail)
+}
+
+// loadTicketDetail gathers a ticket's related entities, with empty sections
+// as empty lists rather than null.
+func (s *Server) loadTicketDetail(ticket *kanban.Ticket) (*TicketDetail, error) {
+	detail := &TicketDetail{Ticket: ticket}
+	var err error
+
+	if detail.Tags, err = s.store.GetTicketTags(ticket.ID); err != nil {
+		return nil, err
+	}
+	ticket.Tags = detail.Tags
+
+	if detail.Conversations, err = s.store.GetConversationsByTicket(ticket.ID); err != nil {
+		return nil, err
+	}
+	for i := range detail.Conversations {
+		if detail.Conversations[i].Messages, err = s.store.GetConversationMessages(detail.Conversations[i].ID); err != nil {
+			return nil, err
+		}
+	}
+
+	if detail.PMCheckins, err = s.store.GetPMCheckinsByTicket(ticket.ID); err != nil {
+		return nil, err
+	}
+	if detail.TimeStats, err = s.store.GetTicketTimeStats(ticket.ID); err != nil {
+		return nil, err
+	}
+	s.addCodeStats(ticket, detail.TimeStats)
+	if detail.AgentRuns, err = s.store.GetRunsByTicket(ticket.ID); err != nil {
+		return nil, err
+	}
+	if detail.ADRs, err = s.store.GetADRsByTicket(ticket.ID); err != nil {
+		return nil, err
+	}
+	if detail.WorktreeEvents, err = s.store.GetWorktreeEvents(ticket.ID); err != nil {
+		return nil, err
+	}
+
+	if detail.Conversations == nil {
+		detail.Conversations = []kanban.TicketConversation{}
+	}
+	if detail.PMCheckins == nil {
+		detail.PMCheckins = []kanban.PMCheckin{}
+	}
+	if detail.AgentRuns == nil {
+		detail.AgentRuns = []kanban.AgentRun{}
+	}
+	if detail.ADRs == nil {
+		detail.ADRs = []kanban.ADR{}
+	}
+	if detail.Tags == nil {
+		detail.Tags = []kanban.Tag{}
+	}
+	if detail.WorktreeEvents == nil {
+		detail.WorktreeEvents = []kanban.WorktreeEvent{}
+	}
+	return detail, nil
+}
+
 // CreateTicketRequest is the request body for creating a ticket.
 type CreateTicketRequest struct {
 	Title              string   `json:"title"`
@@ -86,6 +338,7 @@ type CreateTicketRequest struct {
 	Priority           int      `json:"priority"`
 	Type               string   `json:"type"`
 	AcceptanceCriteria []string `json:"acceptanceCriteria"`
+	Files              []string `json:"files"`
 }
 
 // apiCreateTicket creates a new ticket.
@@ -109,6 +362,7 @@ func (s *Server) apiCreateTicket(w http.ResponseWriter, r *http.Request) {
 		}
 		// Parse acceptance criteria array
 		req.AcceptanceCriteria = r.Form["criteria[]"]
+		req.Files = r.Form["files[]"]
 	} else {
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 			s.jsonError(w, "Invalid request body", http.StatusBadRequest)
@@ -121,15 +375,27 @@ func (s *Server) apiCreateTicket(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	priority, ok := s.resolvePriority(req.Priority)
+	if !ok {
+		s.jsonError(w, "Priority must be between 1 (critical) and 4 (low)", http.StatusUnprocessableEntity)
+		return
+	}
+	if errs := kanban.ValidateTicketFiles(req.Files); len(errs) > 0 {
+		s.jsonError(w, "Invalid file patterns: "+strings.Join(errs, "; "), http.StatusBadRequest)
+		return
+	}
+
 	ticket := &kanban.Ticket{
 		ID:                 uuid.New().String(),
 		Title:              req.Title,
 		Description:        req.Description,
 		Domain:             kanban.Domain(req.Domain),
-		Priority:           kanban.Priority(req.Priority),
+		Priority:           priority,
 		Type:               req.Type,
 		Status:             kanban.StatusBacklog,
 		AcceptanceCriteria: req.AcceptanceCriteria,
+		Files:              req.Files,
+		TraceID:            traceIDFromContext(r.Context()),
 		Signoffs: kanban.Signoffs{
 			Dev:      false,
 			QA:       false,
@@ -140,6 +406,17 @@ func (s *Server) apiCreateTicket(w http.ResponseWriter, r *http.Request) {
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
 	}
+	s.autoAddInferredDependencies(ticket)
+
+	duplicate, similarity := s.findDuplicateTicket(ticket)
+	if duplicate != nil {
+		s.logger.Info("Near-duplicate ticket on intake", "title", ticket.Title, "duplicateOf", duplicate.ID, "similarity", similarity)
+		if !s.linkDuplicates() {
+			s.jsonResponse(w, duplicateResponse{duplicate, duplicate.ID, similarity})
+			return
+		}
+		ticket.Links = append(ticket.Links, kanban.TicketLink{Type: kanban.LinkTypeDuplicates, TicketID: duplicate.ID})
+	}
 
 	if err := s.store.CreateTicket(ticket); err != nil {
 		s.logger.Error("Failed to create ticket", "error", err)
@@ -151,9 +428,66 @@ func (s *Server) apiCreateTicket(w http.ResponseWriter, r *http.Request) {
 	s.Broadcast("board-update")
 
 	w.WriteHeader(http.StatusCreated)
+	if duplicate != nil {
+		s.jsonResponse(w, duplicateResponse{ticket, duplicate.ID, similarity})
+		return
+	}
 	s.jsonResponse(w, ticket)
 }
 
+// duplicateResponse is a ticket with a hint that it matches an existing one.
+type duplicateResponse struct {
+	*kanban.Ticket
+	DuplicateOf string  `json:"duplicateOf"`
+	Similarity  float64 `json:"similarity"`
+}
+
+// findDuplicateTicket returns the open ticket a new one near-duplicates, when
+// duplicate_threshold (0-1, unset disables) is configured.
+func (s *Server) findDuplicateTicket(ticket *kanban.Ticket) (*kanban.Ticket, float64) {
+	v, _ := s.store.GetConfigValue("duplicate_threshold")
+	if v == "" {
+		return nil, 0
+	}
+	threshold, err := strconv.ParseFloat(v, 64)
+	if err != nil || threshold <= 0 || threshold > 1 {
+		s.logger.Warn("Invalid duplicate_threshold, skipping duplicate check", "value", v)
+		return nil, 0
+	}
+
+	tickets, err := s.store.GetAllTickets()
+	if err != nil {
+		s.logger.Warn("Failed to load tickets for duplicate check", "error", err)
+		return nil, 0
+	}
+	return kanban.FindDuplicate(ticket, tickets, threshold)
+}
+
+// linkDuplicates reports whether near-duplicates are created with a
+// duplicates link (duplicate_action "link") rather than returning the
+// existing ticket.
+func (s *Server) linkDuplicates() bool {
+	v, _ := s.store.GetConfigValue("duplicate_action")
+	return v == "link"
+}
+
+// resolvePriority maps a requested priority to a valid one. Zero (omitted)
+// becomes the configured default_priority, or Medium when unset; anything else
+// outside 1-4 is rejected.
+func (s *Server) resolvePriority(p int) (kanban.Priority, bool) {
+	if p != 0 {
+		return kanban.Priority(p), kanban.IsValidPriority(kanban.Priority(p))
+	}
+
+	if v, _ := s.store.GetConfigValue("default_priority"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && kanban.IsValidPriority(kanban.Priority(n)) {
+			return kanban.Priority(n), true
+		}
+		s.logger.Warn("Invalid default_priority config, using medium", "value", v)
+	}
+	return kanban.PriorityMedium, true
+}
+
 // UpdateTicketRequest is the request body for updating a ticket.
 type UpdateTicketRequest struct {
 	Title              *string              `json:"title,omitempty"`
@@ -165,8 +499,17 @@ type UpdateTicketRequest struct {
 	AssignedAgent      *string              `json:"assignedAgent,omitempty"`
 	Assignee           *string              `json:"assignee,omitempty"`
 	AcceptanceCriteria []string             `json:"acceptanceCriteria,omitempty"`
+	Files              []string             `json:"files,omitempty"`
 	Notes              *string              `json:"notes,omitempty"`
 	Requirements       *kanban.Requirements `json:"requirements,omitempty"`
+	SkipStages         *[]kanban.Status     `json:"skipStages,omitempty"` // Empty list skips nothing, overriding the board default
+
+	// Provider/model for every agent on the ticket; an empty provider clears it
+	ProviderOverride *kanban.ProviderOverride `json:"providerOverride,omitempty"`
+
+	// Version the client last saw; the update is rejected with 409 if the
+	// ticket has changed since
+	Version *int `json:"version,omitempty"`
 }
 
 // apiUpdateTicket updates an existing ticket.
@@ -191,6 +534,15 @@ func (s *Server) apiUpdateTicket(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if req.Priority != nil {
+		priority, ok := s.resolvePriority(*req.Priority)
+		if !ok {
+			s.jsonError(w, "Priority must be between 1 (critical) and 4 (low)", http.StatusUnprocessableEntity)
+			return
+		}
+		ticket.Priority = priority
+	}
+
 	// Track if status is changing for history
 	oldStatus := ticket.Status
 	statusChanged := false
@@ -205,9 +557,6 @@ func (s *Server) apiUpdateTicket(w http.ResponseWriter, r *http.Request) {
 	if req.Domain != nil {
 		ticket.Domain = kanban.Domain(*req.Domain)
 	}
-	if req.Priority != nil {
-		ticket.Priority = kanban.Priority(*req.Priority)
-	}
 	if req.Type != nil {
 		ticket.Type = *req.Type
 	}
@@ -224,22 +573,78 @@ func (s *Server) apiUpdateTicket(w http.ResponseWriter, r *http.Request) {
 	if req.AcceptanceCriteria != nil {
 		ticket.AcceptanceCriteria = req.AcceptanceCriteria
 	}
+	if req.Files != nil {
+		if errs := kanban.ValidateTicketFiles(req.Files); len(errs) > 0 {
+			s.jsonError(w, "Invalid file patterns: "+strings.Join(errs, "; "), http.StatusBadRequest)
+			return
+		}
+		ticket.Files = req.Files
+	}
 	if req.Notes != nil {
 		ticket.Notes = *req.Notes
 	}
 	if req.Requirements != nil {
 		ticket.Requirements = req.Requirements
 	}
+	if req.SkipStages != nil {
+		if err := kanban.ValidateSkipStages(*req.SkipStages); err != nil {
+			s.jsonError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		ticket.SkipStages = append([]kanban.Status{}, *req.SkipStages...)
+	}
+	if req.ProviderOverride != nil {
+		override := *req.ProviderOverride
+		switch {
+		case override.Provider == "":
+			ticket.ProviderOverride = nil
+		case !isValidProvider(override.Provider):
+			s.jsonError(w, fmt.Sprintf("Invalid provider: %s", override.Provider), http.StatusBadRequest)
+			return
+		case override.Model != "" && !isValidModelForProvider(override.Provider, override.Model):
+			s.jsonError(w, fmt.Sprintf("Invalid model %s for provider %s", override.Model, override.Provider), http.StatusBadRequest)
+			return
+		default:
+			ticket.ProviderOverride = &override
+		}
+	}
 
 	ticket.UpdatedAt = time.Now()
 
+	if req.Files != nil {
+		s.autoAddInferredDependencies(ticket)
+	}
+
+	// The status moves through UpdateTicketStatus below, so history and status
+	// hooks see it change from the old status
+	newStatus := ticket.Status
+	ticket.Status = oldStatus
+
+	// With a version, save only if nobody changed the ticket since the client
+	// last saw it
+	var err error
+	if req.Version != nil {
+		err = s.store.UpdateTicketIfVersion(ticket, *req.Version)
+	} else {
+		err = s.store.UpdateTicket(ticket)
+	}
+	if err != nil {
+		if errors.Is(err, db.ErrVersionConflict) {
+			s.jsonError(w, "Ticket was modified by someone else, refetch and retry", http.StatusConflict)
+			return
+		}
+		s.logger.Error("Failed to update ticket", "id", id, "error", err)
+		s.jsonError(w, "Failed to update ticket", http.StatusInternalServerError)
+		return
+	}
+
 	// If status changed, use UpdateTicketStatus to record history
 	if statusChanged {
 		note := "Status updated via API"
 		if req.AssignedAgent != nil && *req.AssignedAgent != "" {
 			note = "Picked up by " + *req.AssignedAgent
 		}
-		if err := s.store.UpdateTicketStatus(id, ticket.Status, "system", note); err != nil {
+		if err := s.store.UpdateTicketStatus(id, newStatus, "system", note); err != nil {
 			s.logger.Error("Failed to update ticket status", "id", id, "error", err)
 			s.jsonError(w, "Failed to update ticket", http.StatusInternalServerError)
 			return
@@ -248,19 +653,71 @@ func (s *Server) apiUpdateTicket(w http.ResponseWriter, r *http.Request) {
 		ticket, _ = s.store.GetTicket(id) // Ignore ok, we know it exists
 	}
 
-	// Update other fields
-	if err := s.store.UpdateTicket(ticket); err != nil {
-		s.logger.Error("Failed to update ticket", "id", id, "error", err)
-		s.jsonError(w, "Failed to update ticket", http.StatusInternalServerError)
-		return
-	}
-
 	// Broadcast update
 	s.Broadcast("board-update")
 
 	s.jsonResponse(w, ticket)
 }
 
+// apiGetSuggestedDependencies returns the unfinished, earlier tickets whose
+// file patterns overlap the ticket's and that it should therefore wait on.
+func (s *Server) apiGetSuggestedDependencies(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		s.jsonError(w, "Missing ticket ID", http.StatusBadRequest)
+		return
+	}
+
+	ticket, found := s.store.GetTicket(id)
+	if !found {
+		s.jsonError(w, "Ticket not found", http.StatusNotFound)
+		return
+	}
+
+	suggestions, err := s.suggestDependencies(ticket)
+	if err != nil {
+		s.logger.Error("Failed to suggest dependencies", "id", id, "error", err)
+		s.jsonError(w, "Failed to suggest dependencies", http.StatusInternalServerError)
+		return
+	}
+	if suggestions == nil {
+		suggestions = []kanban.DependencySuggestion{}
+	}
+
+	s.jsonResponse(w, suggestions)
+}
+
+// suggestDependencies compares a ticket's file patterns against every other ticket.
+func (s *Server) suggestDependencies(ticket *kanban.Ticket) ([]kanban.DependencySuggestion, error) {
+	if len(ticket.Files) == 0 {
+		return nil, nil
+	}
+	tickets, err := s.store.GetAllTickets()
+	if err != nil {
+		return nil, err
+	}
+	return kanban.SuggestDependencies(ticket, tickets), nil
+}
+
+// autoAddInferredDependencies adds the suggested dependencies to a ticket
+// before it is saved, when the auto_infer_dependencies config is enabled.
+// Otherwise overlaps are only reported via the suggested-dependencies endpoint.
+func (s *Server) autoAddInferredDependencies(ticket *kanban.Ticket) {
+	if v, _ := s.store.GetConfigValue("auto_infer_dependencies"); v != "true" {
+		return
+	}
+
+	suggestions, err := s.suggestDependencies(ticket)
+	if err != nil {
+		s.logger.Warn("Failed to infer dependencies", "id", ticket.ID, "error", err)
+		return
+	}
+	for _, sug := range suggestions {
+		ticket.Dependencies = append(ticket.Dependencies, sug.TicketID)
+		s.logger.Info("Inferred dependency from file overlap", "id", ticket.ID, "dependsOn", sug.TicketID, "files", sug.Files)
+	}
+}
+
 // apiApproveTicket approves a ticket's requirements and moves it to READY.
 func (s *Server) apiApproveTicket(w http.ResponseWriter, r *http.Request) {
 	id := r.PathValue("id")
@@ -275,52 +732,599 @@ func (s *Server) apiApproveTicket(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Only allow approval from AWAITING_USER status
-	if ticket.Status != kanban.StatusAwaitingUser {
-		s.jsonError(w, "Ticket is not awaiting user approval", http.StatusBadRequest)
+	// Only allow approval from AWAITING_USER status
+	if ticket.Status != kanban.StatusAwaitingUser {
+		s.jsonError(w, "Ticket is not awaiting user approval", http.StatusBadRequest)
+		return
+	}
+
+	if err := s.store.UpdateTicketStatus(id, kanban.StatusReady, "user", "Requirements approved via dashboard"); err != nil {
+		s.logger.Error("Failed to approve ticket", "id", id, "error", err)
+		s.jsonError(w, "Failed to approve ticket", http.StatusInternalServerError)
+		return
+	}
+
+	// Broadcast update
+	s.Broadcast("board-update")
+
+	s.jsonResponse(w, map[string]string{"status": "approved"})
+}
+
+// apiPromoteTicket moves an iceboxed ticket back into the backlog.
+func (s *Server) apiPromoteTicket(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		s.jsonError(w, "Missing ticket ID", http.StatusBadRequest)
+		return
+	}
+
+	ticket, found := s.store.GetTicket(id)
+	if !found {
+		s.jsonError(w, "Ticket not found", http.StatusNotFound)
+		return
+	}
+
+	// Only iceboxed tickets can be promoted
+	if ticket.Status != kanban.StatusIcebox {
+		s.jsonError(w, "Ticket is not in the icebox", http.StatusBadRequest)
+		return
+	}
+
+	if err := s.store.UpdateTicketStatus(id, kanban.StatusBacklog, "user", "Promoted from icebox via dashboard"); err != nil {
+		s.logger.Error("Failed to promote ticket", "id", id, "error", err)
+		s.jsonError(w, "Failed to promote ticket", http.StatusInternalServerError)
+		return
+	}
+
+	// Broadcast update
+	s.Broadcast("board-update")
+
+	s.jsonResponse(w, map[string]string{"status": "promoted"})
+}
+
+// apiApproveMerge releases a ticket held for human merge approval so it can be merged.
+func (s *Server) apiApproveMerge(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		s.jsonError(w, "Missing ticket ID", http.StatusBadRequest)
+		return
+	}
+
+	var req struct {
+		ApprovedBy string `json:"approvedBy"`
+	}
+	if r.ContentLength > 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			s.jsonError(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+	if req.ApprovedBy == "" {
+		req.ApprovedBy = "user"
+	}
+
+	ticket, found := s.store.GetTicket(id)
+	if !found {
+		s.jsonError(w, "Ticket not found", http.StatusNotFound)
+		return
+	}
+
+	if ticket.Status != kanban.StatusAwaitingMergeApproval {
+		s.jsonError(w, "Ticket is not awaiting merge approval", http.StatusBadRequest)
+		return
+	}
+
+	note := fmt.Sprintf("Merge approved by %s", req.ApprovedBy)
+	if err := s.store.UpdateTicketStatus(id, kanban.StatusDone, req.ApprovedBy, note); err != nil {
+		s.logger.Error("Failed to approve merge", "id", id, "error", err)
+		s.jsonError(w, "Failed to approve merge", http.StatusInternalServerError)
+		return
+	}
+
+	// Broadcast update
+	s.Broadcast("board-update")
+
+	s.jsonResponse(w, map[string]string{"status": "approved"})
+}
+
+// signoffOverrides maps each sign-off a human can override to its thread type,
+// its review stage and the review stage that follows it. An empty next stage
+// means the ticket is fully signed off.
+var signoffOverrides = map[string]struct {
+	threadType kanban.ThreadType
+	stage      kanban.Status
+	next       kanban.Status
+}{
+	"qa":       {kanban.ThreadTypeQASignoff, kanban.StatusInQA, kanban.StatusInUX},
+	"ux":       {kanban.ThreadTypeUXSignoff, kanban.StatusInUX, kanban.StatusInSec},
+	"security": {kanban.ThreadTypeSecuritySignoff, kanban.StatusInSec, kanban.StatusPMReview},
+	"pm":       {kanban.ThreadTypePMSignoff, kanban.StatusPMReview, ""},
+}
+
+// apiOverrideSignoff records a human override of a sign-off that agents
+// failed, so the ticket can move past that stage. The ticket must be BLOCKED
+// or in the overridden stage. The rationale is kept in the ticket history and
+// in a sign-off thread.
+func (s *Server) apiOverrideSignoff(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		s.jsonError(w, "Missing ticket ID", http.StatusBadRequest)
+		return
+	}
+
+	var req struct {
+		Stage        string `json:"stage"`
+		Rationale    string `json:"rationale"`
+		OverriddenBy string `json:"overriddenBy"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.jsonError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	override, ok := signoffOverrides[req.Stage]
+	if !ok {
+		s.jsonError(w, "Invalid 'stage', expected one of qa, ux, security, pm", http.StatusBadRequest)
+		return
+	}
+	req.Rationale = strings.TrimSpace(req.Rationale)
+	if req.Rationale == "" {
+		s.jsonError(w, "A rationale is required to override a sign-off", http.StatusBadRequest)
+		return
+	}
+	if req.OverriddenBy == "" {
+		req.OverriddenBy = "user"
+	}
+
+	ticket, found := s.store.GetTicket(id)
+	if !found {
+		s.jsonError(w, "Ticket not found", http.StatusNotFound)
+		return
+	}
+	if ticket.Status != kanban.StatusBlocked && ticket.Status != override.stage {
+		s.jsonError(w, fmt.Sprintf("Ticket is %s; only a BLOCKED ticket or one in %s can have its %s sign-off overridden", ticket.Status, override.stage, req.Stage), http.StatusConflict)
+		return
+	}
+
+	if err := s.store.AddSignoff(id, req.Stage, req.OverriddenBy); err != nil {
+		s.logger.Error("Failed to record sign-off override", "id", id, "stage", req.Stage, "error", err)
+		s.jsonError(w, "Failed to override sign-off", http.StatusInternalServerError)
+		return
+	}
+
+	now := time.Now()
+	conv := &kanban.TicketConversation{
+		ID:         uuid.New().String(),
+		TicketID:   id,
+		ThreadType: override.threadType,
+		Title:      fmt.Sprintf("%s sign-off overridden", strings.ToUpper(req.Stage)),
+		Status:     kanban.ThreadStatusResolved,
+		CreatedAt:  now,
+		ResolvedAt: now,
+	}
+	if err := s.store.CreateConversation(conv); err != nil {
+		s.logger.Error("Failed to create override conversation", "id", id, "error", err)
+		s.jsonError(w, "Failed to override sign-off", http.StatusInternalServerError)
+		return
+	}
+	msg := &kanban.ConversationMessage{
+		ID:             uuid.New().String(),
+		ConversationID: conv.ID,
+		Agent:          req.OverriddenBy,
+		MessageType:    kanban.MessageTypeDecision,
+		Content:        req.Rationale,
+		CreatedAt:      now,
+	}
+	if err := s.store.AddConversationMessage(msg); err != nil {
+		s.logger.Error("Failed to add override message", "id", id, "error", err)
+		s.jsonError(w, "Failed to override sign-off", http.StatusInternalServerError)
+		return
+	}
+
+	next := factory.SignedOffStatus(s.orchConfig)
+	if override.next != "" {
+		skip := ticket.EffectiveSkipStages(s.store.GetConfig().SkipStages)
+		next = kanban.NextReviewStage(override.next, skip, next)
+	}
+	note := fmt.Sprintf("%s sign-off overridden by %s: %s", req.Stage, req.OverriddenBy, req.Rationale)
+	if err := s.store.UpdateTicketStatus(id, next, req.OverriddenBy, note); err != nil {
+		s.logger.Error("Failed to advance ticket after override", "id", id, "error", err)
+		s.jsonError(w, "Failed to override sign-off", http.StatusInternalServerError)
+		return
+	}
+
+	// Broadcast update
+	s.Broadcast("board-update")
+
+	s.jsonResponse(w, map[string]string{"status": string(next)})
+}
+
+// apiDeleteTicket deletes a ticket.
+func (s *Server) apiDeleteTicket(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		s.jsonError(w, "Missing ticket ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := s.store.DeleteTicket(id); err != nil {
+		s.logger.Error("Failed to delete ticket", "id", id, "error", err)
+		s.jsonError(w, "Failed to delete ticket", http.StatusInternalServerError)
+		return
+	}
+
+	// Broadcast update
+	s.Broadcast("board-update")
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// apiGetStats returns board statistics. Parent tickets are counted alongside
+// their sub-tickets unless ?exclude_parents=true is given.
+func (s *Server) apiGetStats(w http.ResponseWriter, r *http.Request) {
+	excludeParents := false
+	if v := r.URL.Query().Get("exclude_parents"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			s.jsonError(w, "Invalid exclude_parents", http.StatusBadRequest)
+			return
+		}
+		excludeParents = b
+	}
+
+	if excludeParents {
+		s.jsonResponse(w, s.store.GetStatsExcludingParents())
+		return
+	}
+	s.jsonResponse(w, s.store.GetStats())
+}
+
+// apiGetBurndown returns the daily count of not-done tickets for the current iteration.
+func (s *Server) apiGetBurndown(w http.ResponseWriter, r *http.Request) {
+	iteration := s.store.GetIteration()
+	if iteration == nil {
+		s.jsonError(w, "No active iteration", http.StatusNotFound)
+		return
+	}
+	if id := r.URL.Query().Get("iteration"); id != "" && id != iteration.ID {
+		s.jsonError(w, "Iteration not found", http.StatusNotFound)
+		return
+	}
+
+	start, end := iteration.Window()
+
+	tickets, err := s.store.GetTicketsWithHistory()
+	if err != nil {
+		s.logger.Error("Failed to get tickets for burndown", "error", err)
+		s.jsonError(w, "Failed to compute burndown", http.StatusInternalServerError)
+		return
+	}
+
+	s.jsonResponse(w, map[string]interface{}{
+		"iteration": iteration.ID,
+		"start":     start,
+		"end":       end,
+		"series":    kanban.ComputeBurndown(tickets, start, end),
+	})
+}
+
+// apiGetIterationDigest returns the digest recorded when an iteration completed.
+func (s *Server) apiGetIterationDigest(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		s.jsonError(w, "Missing iteration ID", http.StatusBadRequest)
+		return
+	}
+
+	digest, err := s.store.GetIterationDigest(id)
+	if err != nil {
+		s.logger.Error("Failed to get iteration digest", "iteration", id, "error", err)
+		s.jsonError(w, "Failed to get iteration digest", http.StatusInternalServerError)
+		return
+	}
+	if digest == nil {
+		s.jsonError(w, "No digest for iteration", http.StatusNotFound)
+		return
+	}
+
+	s.jsonResponse(w, digest)
+}
+
+// apiGetDependencyGraph returns the ticket dependency graph as nodes and edges,
+// with any cycles flagged. The optional iteration query parameter limits it to
+// tickets in scope during the current iteration, and tag to tickets with that tag.
+func (s *Server) apiGetDependencyGraph(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	var tickets []kanban.Ticket
+	var err error
+	if tagID := query.Get("tag"); tagID != "" {
+		tickets, err = s.store.GetTicketsByTag(tagID)
+	} else {
+		tickets, err = s.store.GetAllTickets()
+	}
+	if err != nil {
+		s.logger.Error("Failed to get tickets for dependency graph", "error", err)
+		s.jsonError(w, "Failed to build dependency graph", http.StatusInternalServerError)
+		return
+	}
+
+	if id := query.Get("iteration"); id != "" {
+		iteration := s.store.GetIteration()
+		if iteration == nil || iteration.ID != id {
+			s.jsonError(w, "Iteration not found", http.StatusNotFound)
+			return
+		}
+		start, end := iteration.Window()
+		inScope := tickets[:0]
+		for _, t := range tickets {
+			// Created before the iteration ended and not finished before it started
+			if !t.CreatedAt.After(end) && (t.Status != kanban.StatusDone || !t.UpdatedAt.Before(start)) {
+				inScope = append(inScope, t)
+			}
+		}
+		tickets = inScope
+	}
+
+	s.jsonResponse(w, kanban.BuildDependencyGraph(tickets))
+}
+
+// ticketChangedFiles lists the files one ticket changed.
+type ticketChangedFiles struct {
+	TicketID string   `json:"ticketId"`
+	Title    string   `json:"title"`
+	Branch   string   `json:"branch"`
+	Files    []string `json:"files"`
+	Error    string   `json:"error,omitempty"` // Why the changes could not be read
+}
+
+// apiGetChangedFiles returns the files changed by every ticket completed in the
+// current iteration, grouped by ticket, plus the deduplicated sorted union. Each
+// ticket's changes come from its branch diff against main, or from its squash
+// merge commit on main once the branch is gone.
+func (s *Server) apiGetChangedFiles(w http.ResponseWriter, r *http.Request) {
+	iteration := s.store.GetIteration()
+	if iteration == nil {
+		s.jsonError(w, "No active iteration", http.StatusNotFound)
+		return
+	}
+	if id := r.URL.Query().Get("iteration"); id != "" && id != iteration.ID {
+		s.jsonError(w, "Iteration not found", http.StatusNotFound)
+		return
+	}
+
+	tickets, err := s.store.GetTicketsWithHistory()
+	if err != nil {
+		s.logger.Error("Failed to get tickets for changed files", "error", err)
+		s.jsonError(w, "Failed to get changed files", http.StatusInternalServerError)
+		return
+	}
+	start, end := iteration.Window()
+	completed := kanban.CompletedBetween(tickets, start, end)
+	sort.Slice(completed, func(i, j int) bool { return completed[i].ID < completed[j].ID })
+
+	manager := s.worktreeManager()
+	branchPrefix := s.store.GetConfig().BranchPrefix
+	seen := make(map[string]bool)
+	allFiles := []string{}
+	byTicket := make([]ticketChangedFiles, 0, len(completed))
+
+	for _, ticket := range completed {
+		entry := ticketChangedFiles{TicketID: ticket.ID, Title: ticket.Title}
+		if ticket.Worktree != nil {
+			entry.Branch = ticket.Worktree.Branch
+		}
+		if entry.Branch == "" {
+			entry.Branch = git.GenerateBranchName(branchPrefix, ticket.ID, ticket.Title)
+		}
+
+		files, err := manager.ChangedFiles(entry.Branch)
+		if errors.Is(err, git.ErrBranchNotFound) || (err == nil && len(files) == 0) {
+			// Merged branches are usually deleted; read the squash commit instead
+			files, err = manager.MergedFiles(ticket.ID)
+		}
+		if err != nil {
+			s.logger.Warn("Failed to read ticket changes", "ticketID", ticket.ID, "branch", entry.Branch, "error", err)
+			entry.Error = err.Error()
+		}
+
+		entry.Files = files
+		if entry.Files == nil {
+			entry.Files = []string{}
+		}
+		for _, f := range entry.Files {
+			if !seen[f] {
+				seen[f] = true
+				allFiles = append(allFiles, f)
+			}
+		}
+		byTicket = append(byTicket, entry)
+	}
+	sort.Strings(allFiles)
+
+	s.jsonResponse(w, map[string]interface{}{
+		"iteration": iteration.ID,
+		"files":     allFiles,
+		"tickets":   byTicket,
+	})
+}
+
+// defaultProviderUsageWindow is the report range when no start time is given.
+const defaultProviderUsageWindow = 7 * 24 * time.Hour
+
+// apiGetProviderUsage returns per-provider request, token, cost, latency and error
+// totals between ?from and ?to (RFC 3339), defaulting to the last seven days.
+func (s *Server) apiGetProviderUsage(w http.ResponseWriter, r *http.Request) {
+	to := time.Now()
+	if v := r.URL.Query().Get("to"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			s.jsonError(w, "Invalid 'to' time, expected RFC 3339", http.StatusBadRequest)
+			return
+		}
+		to = t
+	}
+	from := to.Add(-defaultProviderUsageWindow)
+	if v := r.URL.Query().Get("from"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			s.jsonError(w, "Invalid 'from' time, expected RFC 3339", http.StatusBadRequest)
+			return
+		}
+		from = t
+	}
+	if to.Before(from) {
+		s.jsonError(w, "'to' must not be before 'from'", http.StatusBadRequest)
+		return
+	}
+
+	usage, err := s.store.GetProviderUsage(from, to)
+	if err != nil {
+		s.logger.Error("Failed to get provider usage", "error", err)
+		s.jsonError(w, "Failed to compute provider usage", http.StatusInternalServerError)
+		return
+	}
+
+	s.jsonResponse(w, map[string]interface{}{
+		"from":      from,
+		"to":        to,
+		"providers": usage,
+	})
+}
+
+// apiGetCosts returns the tokens agents used and their estimated USD cost for
+// one ticket (ticket_id) or one iteration (iteration_id).
+func (s *Server) apiGetCosts(w http.ResponseWriter, r *http.Request) {
+	ticketID := r.URL.Query().Get("ticket_id")
+	iterationID := r.URL.Query().Get("iteration_id")
+	if (ticketID == "") == (iterationID == "") {
+		s.jsonError(w, "Pass one of ticket_id or iteration_id", http.StatusBadRequest)
 		return
 	}
 
-	if err := s.store.UpdateTicketStatus(id, kanban.StatusReady, "user", "Requirements approved via dashboard"); err != nil {
-		s.logger.Error("Failed to approve ticket", "id", id, "error", err)
-		s.jsonError(w, "Failed to approve ticket", http.StatusInternalServerError)
+	var cost *kanban.CostSummary
+	var err error
+	if ticketID != "" {
+		cost, err = s.store.GetCostByTicket(ticketID)
+	} else {
+		cost, err = s.store.GetCostByIteration(iterationID)
+	}
+	if err != nil {
+		s.logger.Error("Failed to compute costs", "ticket", ticketID, "iteration", iterationID, "error", err)
+		s.jsonError(w, "Failed to compute costs", http.StatusInternalServerError)
+		return
+	}
+	if cost == nil {
+		s.jsonError(w, "Iteration not found", http.StatusNotFound)
 		return
 	}
 
-	// Broadcast update
-	s.Broadcast("board-update")
+	s.jsonResponse(w, cost)
+}
 
-	s.jsonResponse(w, map[string]string{"status": "approved"})
+// timeStatsIncludeGit reports whether time stats carry the code volume of each
+// ticket's branch. Diffing runs git per ticket, so it is opt-in via the
+// time_stats_include_git config.
+func (s *Server) timeStatsIncludeGit() bool {
+	v, _ := s.store.GetConfigValue("time_stats_include_git")
+	return v == "true"
 }
 
-// apiDeleteTicket deletes a ticket.
-func (s *Server) apiDeleteTicket(w http.ResponseWriter, r *http.Request) {
-	id := r.PathValue("id")
-	if id == "" {
-		s.jsonError(w, "Missing ticket ID", http.StatusBadRequest)
+// addCodeStats attaches the diff of the ticket's branch to stats when enabled.
+// A ticket without a branch keeps no code stats.
+func (s *Server) addCodeStats(ticket *kanban.Ticket, stats *kanban.TimeStats) {
+	if stats == nil || !s.timeStatsIncludeGit() {
 		return
 	}
 
-	if err := s.store.DeleteTicket(id); err != nil {
-		s.logger.Error("Failed to delete ticket", "id", id, "error", err)
-		s.jsonError(w, "Failed to delete ticket", http.StatusInternalServerError)
+	branch := ""
+	if ticket.Worktree != nil {
+		branch = ticket.Worktree.Branch
+	}
+	if branch == "" {
+		branch = git.GenerateBranchName(s.store.GetConfig().BranchPrefix, ticket.ID, ticket.Title)
+	}
+
+	diff, err := s.worktreeManager().DiffStat(branch)
+	if err != nil {
+		if !errors.Is(err, git.ErrBranchNotFound) {
+			s.logger.Warn("Failed to read branch diff stats", "ticketID", ticket.ID, "branch", branch, "error", err)
+		}
 		return
 	}
+	stats.CodeStats = &kanban.CodeStats{
+		FilesChanged: diff.FilesChanged,
+		LinesAdded:   diff.LinesAdded,
+		LinesRemoved: diff.LinesRemoved,
+	}
+}
 
-	// Broadcast update
-	s.Broadcast("board-update")
+// apiExportTimeStats returns every ticket's time stats as CSV. The code volume
+// columns are filled only when time_stats_include_git is enabled and the
+// ticket's branch exists.
+func (s *Server) apiExportTimeStats(w http.ResponseWriter, r *http.Request) {
+	tickets, err := s.store.GetAllTickets()
+	if err != nil {
+		s.logger.Error("Failed to get tickets for time stats export", "error", err)
+		s.jsonError(w, "Failed to export time stats", http.StatusInternalServerError)
+		return
+	}
+	sort.Slice(tickets, func(i, j int) bool { return tickets[i].ID < tickets[j].ID })
 
-	w.WriteHeader(http.StatusNoContent)
-}
+	var buf bytes.Buffer
+	out := csv.NewWriter(&buf)
+	_ = out.Write([]string{
+		"ticket_id", "title", "status", "work_seconds", "idle_seconds", "cycle_seconds",
+		"agent_runs", "files_changed", "lines_added", "lines_removed",
+	})
+	for i := range tickets {
+		ticket := &tickets[i]
+		stats, err := s.store.GetTicketTimeStats(ticket.ID)
+		if err != nil {
+			s.logger.Error("Failed to get time stats", "ticketID", ticket.ID, "error", err)
+			s.jsonError(w, "Failed to export time stats", http.StatusInternalServerError)
+			return
+		}
+		s.addCodeStats(ticket, stats)
 
-// apiGetStats returns board statistics.
-func (s *Server) apiGetStats(w http.ResponseWriter, r *http.Request) {
-	stats := s.store.GetStats()
-	s.jsonResponse(w, stats)
+		files, added, removed := "", "", ""
+		if stats.CodeStats != nil {
+			files = strconv.Itoa(stats.CodeStats.FilesChanged)
+			added = strconv.Itoa(stats.CodeStats.LinesAdded)
+			removed = strconv.Itoa(stats.CodeStats.LinesRemoved)
+		}
+		_ = out.Write([]string{
+			ticket.ID, ticket.Title, string(ticket.Status),
+			strconv.Itoa(int(stats.TotalWorkTime.Seconds())),
+			strconv.Itoa(int(stats.TotalIdleTime.Seconds())),
+			strconv.Itoa(int(stats.TotalCycleTime.Seconds())),
+			strconv.Itoa(stats.AgentRunCount),
+			files, added, removed,
+		})
+	}
+	out.Flush()
+
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="time-stats.csv"`)
+	_, _ = w.Write(buf.Bytes())
 }
 
-// apiGetRuns returns active agent runs.
+// apiGetRuns returns active agent runs, or with ?worktree=<path> every run
+// that used that worktree, across tickets.
 func (s *Server) apiGetRuns(w http.ResponseWriter, r *http.Request) {
+	if path := r.URL.Query().Get("worktree"); path != "" {
+		runs, err := s.store.GetRunsByWorktree(path)
+		if err != nil {
+			s.logger.Error("Failed to get runs by worktree", "worktree", path, "error", err)
+			s.jsonError(w, "Failed to get runs", http.StatusInternalServerError)
+			return
+		}
+		if runs == nil {
+			runs = []kanban.AgentRun{}
+		}
+		s.jsonResponse(w, runs)
+		return
+	}
+
 	runs := s.store.GetActiveRuns()
 	s.jsonResponse(w, runs)
 }
@@ -395,10 +1399,30 @@ func (s *Server) apiAnswerQuestion(w http.ResponseWriter, r *http.Request) {
 	// Add history entry
 	_ = s.store.AddHistoryEntry(id, ticket.Status, "user", "Answered question: "+req.Answer[:min(50, len(req.Answer))]+"...")
 
+	allAnswered := ticket.Requirements.AllQuestionsAnswered()
+	if allAnswered && ticket.Status == kanban.StatusAwaitingUser && s.autoPromoteAnswered() {
+		if err := s.store.UpdateTicketStatus(id, kanban.StatusReady, "system", "All questions answered, promoted automatically"); err != nil {
+			s.logger.Error("Failed to auto-promote ticket", "id", id, "error", err)
+		} else {
+			ticket.Status = kanban.StatusReady
+		}
+	}
+
 	// Broadcast update
 	s.Broadcast("board-update")
 
-	s.jsonResponse(w, ticket)
+	s.jsonResponse(w, struct {
+		*kanban.Ticket
+		AllQuestionsAnswered bool `json:"allQuestionsAnswered"`
+	}{ticket, allAnswered})
+}
+
+// autoPromoteAnswered reports whether an AWAITING_USER ticket moves to READY on
+// its own once every question is answered. Promotion is opt-in via the
+// auto_promote_answered config; otherwise the user still approves manually.
+func (s *Server) autoPromoteAnswered() bool {
+	v, _ := s.store.GetConfigValue("auto_promote_answered")
+	return v == "true"
 }
 
 // --- Conversation API ---
@@ -421,6 +1445,43 @@ func (s *Server) apiGetConversations(w http.ResponseWriter, r *http.Request) {
 	s.jsonResponse(w, conversations)
 }
 
+// apiGetTicketTranscript exports all of a ticket's conversation threads as a
+// chronological markdown transcript.
+func (s *Server) apiGetTicketTranscript(w http.ResponseWriter, r *http.Request) {
+	ticketID := r.PathValue("id")
+	if ticketID == "" {
+		s.jsonError(w, "Missing ticket ID", http.StatusBadRequest)
+		return
+	}
+
+	ticket, found := s.store.GetTicket(ticketID)
+	if !found {
+		s.jsonError(w, "Ticket not found", http.StatusNotFound)
+		return
+	}
+
+	conversations, err := s.store.GetConversationsByTicket(ticketID)
+	if err != nil {
+		s.logger.Error("Failed to get conversations", "ticketID", ticketID, "error", err)
+		s.jsonError(w, "Failed to get conversations", http.StatusInternalServerError)
+		return
+	}
+
+	for i := range conversations {
+		messages, err := s.store.GetConversationMessages(conversations[i].ID)
+		if err != nil {
+			s.logger.Error("Failed to get conversation messages", "conversationID", conversations[i].ID, "error", err)
+			s.jsonError(w, "Failed to get conversation messages", http.StatusInternalServerError)
+			return
+		}
+		conversations[i].Messages = messages
+	}
+
+	w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("inline; filename=%q", ticketID+"-transcript.md"))
+	_, _ = w.Write([]byte(kanban.FormatTranscript(ticket, conversations)))
+}
+
 // apiGetConversation returns a single conversation with its messages.
 func (s *Server) apiGetConversation(w http.ResponseWriter, r *http.Request) {
 	convID := r.PathValue("id")
@@ -577,6 +1638,85 @@ func (s *Server) apiResolveConversation(w http.ResponseWriter, r *http.Request)
 	s.jsonResponse(w, map[string]string{"status": "resolved"})
 }
 
+// apiGetOrphans reports conversation rows whose parent row no longer exists:
+// conversations without a ticket, messages without a conversation, and
+// attachments without a message.
+func (s *Server) apiGetOrphans(w http.ResponseWriter, r *http.Request) {
+	conversations, err := s.store.GetOrphanedConversations()
+	if err != nil {
+		s.logger.Error("Failed to get orphaned conversations", "error", err)
+		s.jsonError(w, "Failed to get orphans", http.StatusInternalServerError)
+		return
+	}
+	messages, err := s.store.GetOrphanedMessages()
+	if err != nil {
+		s.logger.Error("Failed to get orphaned messages", "error", err)
+		s.jsonError(w, "Failed to get orphans", http.StatusInternalServerError)
+		return
+	}
+	attachments, err := s.store.GetOrphanedAttachments()
+	if err != nil {
+		s.logger.Error("Failed to get orphaned attachments", "error", err)
+		s.jsonError(w, "Failed to get orphans", http.StatusInternalServerError)
+		return
+	}
+
+	if conversations == nil {
+		conversations = []kanban.TicketConversation{}
+	}
+	if messages == nil {
+		messages = []kanban.ConversationMessage{}
+	}
+	if attachments == nil {
+		attachments = []kanban.Attachment{}
+	}
+
+	s.jsonResponse(w, map[string]interface{}{
+		"conversations": conversations,
+		"messages":      messages,
+		"attachments":   attachments,
+	})
+}
+
+// apiCleanupOrphans deletes orphaned conversation rows, including everything
+// under an orphaned conversation, and removes the deleted attachments' files.
+func (s *Server) apiCleanupOrphans(w http.ResponseWriter, r *http.Request) {
+	cleanup, err := s.store.DeleteOrphans()
+	if err != nil {
+		s.logger.Error("Failed to delete orphans", "error", err)
+		s.jsonError(w, "Failed to clean up orphans", http.StatusInternalServerError)
+		return
+	}
+
+	for _, att := range cleanup.Attachments {
+		if err := os.Remove(att.Path); err != nil && !os.IsNotExist(err) {
+			s.logger.Warn("Failed to remove orphaned attachment file", "id", att.ID, "path", att.Path, "error", err)
+		}
+	}
+
+	s.logger.Info("Cleaned up orphaned conversation rows",
+		"conversations", cleanup.Conversations, "messages", cleanup.Messages, "attachments", len(cleanup.Attachments))
+
+	s.jsonResponse(w, map[string]interface{}{
+		"conversations": cleanup.Conversations,
+		"messages":      cleanup.Messages,
+		"attachments":   len(cleanup.Attachments),
+	})
+}
+
+// apiGetPrunePreview reports how many finished runs the retention job would
+// prune under the current policy, without pruning anything.
+func (s *Server) apiGetPrunePreview(w http.ResponseWriter, r *http.Request) {
+	preview, err := s.store.PreviewRunPrune()
+	if err != nil {
+		s.logger.Error("Failed to preview run pruning", "error", err)
+		s.jsonError(w, "Failed to preview run pruning", http.StatusInternalServerError)
+		return
+	}
+
+	s.jsonResponse(w, preview)
+}
+
 // --- Chat API (simplified user chat with PM response) ---
 
 // apiPostChat handles user chat messages and triggers PM response.
@@ -644,24 +1784,28 @@ func (s *Server) apiPostChat(w http.ResponseWriter, r *http.Request) {
 	// Broadcast update for SSE
 	s.Broadcast(fmt.Sprintf("conversation-update-%s", ticketID))
 
-	// Trigger PM response asynchronously
-	go s.generatePMResponse(ticketID, conv.ID, content)
+	// Queue PM response asynchronously
+	s.enqueuePMResponse(ticketID, conv.ID, content)
 
 	w.WriteHeader(http.StatusCreated)
 	s.jsonResponse(w, userMsg)
 }
 
-// generatePMResponse calls the Anthropic API to generate a PM response.
-func (s *Server) generatePMResponse(ticketID, convID, userMessage string) {
+// generatePMResponse calls the Anthropic API to generate a PM response. When
+// the provider keeps failing, the message is saved to be retried later.
+func (s *Server) generatePMResponse(job pmChatJob) {
 	// Get ticket context
-	ticket, found := s.store.GetTicket(ticketID)
+	ticket, found := s.store.GetTicket(job.ticketID)
 	if !found {
-		s.logger.Error("Ticket not found for PM response", "ticketID", ticketID)
+		s.logger.Error("Ticket not found for PM response", "ticketID", job.ticketID)
+		if job.pending != nil {
+			_ = s.store.DeletePendingPMResponse(job.pending.ID)
+		}
 		return
 	}
 
 	// Get conversation history
-	messages, _ := s.store.GetConversationMessages(convID)
+	messages, _ := s.store.GetConversationMessages(job.convID)
 
 	// Build context for PM
 	var history string
@@ -670,38 +1814,47 @@ func (s *Server) generatePMResponse(ticketID, convID, userMessage string) {
 	}
 
 	// Broadcast typing indicator
-	s.Broadcast(fmt.Sprintf("pm-typing-%s", ticketID))
-
-	// Generate PM response using Anthropic API
-	pmResponse := s.callAnthropicForPMResponse(ticket, history, userMessage)
+	s.Broadcast(fmt.Sprintf("pm-typing-%s", job.ticketID))
 
-	// Add PM response as message
-	pmMsg := &kanban.ConversationMessage{
-		ID:             uuid.New().String(),
-		ConversationID: convID,
-		Agent:          "PM",
-		MessageType:    kanban.MessageTypeResponse,
-		Content:        pmResponse,
-		CreatedAt:      time.Now(),
-	}
-	if err := s.store.AddConversationMessage(pmMsg); err != nil {
-		s.logger.Error("Failed to add PM response", "error", err)
+	pmResponse, attempts, err := s.respondWithRetry(ticket, history, job.message)
+	if err != nil {
+		s.logger.Error("Failed to get PM response, saving for retry", "ticketID", job.ticketID, "attempts", attempts, "error", err)
+		s.savePendingPMResponse(job, attempts, err)
 		return
 	}
+	if job.pending != nil {
+		if err := s.store.DeletePendingPMResponse(job.pending.ID); err != nil {
+			s.logger.Warn("Failed to clear pending PM response", "id", job.pending.ID, "error", err)
+		}
+	}
 
-	// Broadcast update
-	s.Broadcast(fmt.Sprintf("conversation-update-%s", ticketID))
+	// Add PM response as message
+	s.addPMMessage(job.ticketID, job.convID, kanban.MessageTypeResponse, pmResponse)
 }
 
-// callAnthropicForPMResponse makes the actual API call to generate PM response.
-func (s *Server) callAnthropicForPMResponse(ticket *kanban.Ticket, history, userMessage string) string {
-	// Create Anthropic client
-	client, err := anthropic.NewClientFromEnv()
+// callProviderForPMResponse generates a PM chat response with the provider and
+// model configured for the pm-chat agent type, which is separate from the PM
+// pipeline agent so chat can use a cheaper or faster model. Unconfigured, it
+// uses Anthropic Haiku.
+func (s *Server) callProviderForPMResponse(ticket *kanban.Ticket, history, userMessage string) (string, error) {
+	providerName, model := "anthropic", provider.ModelAnthropicHaiku35
+	cfg, err := s.store.GetAgentProviderConfig(pmChatAgentType)
 	if err != nil {
-		s.logger.Warn("Anthropic API key not set, using placeholder response", "error", err)
+		s.logger.Warn("Failed to get PM chat provider config, using default", "error", err)
+	}
+	if cfg != nil {
+		providerName, model = cfg.Provider, cfg.Model
+		if model == "" {
+			model = s.store.GetProviderDefaultModel(providerName)
+		}
+	}
+
+	prov, err := s.providers.GetProvider(providerName)
+	if err != nil || !prov.Available() {
+		s.logger.Warn("PM chat provider not available, using placeholder response", "provider", providerName, "error", err)
 		return fmt.Sprintf("Thanks for your message! I'm the PM for ticket \"%s\". "+
 			"I've noted your comment and will coordinate with the team. "+
-			"Is there anything specific about the implementation you'd like me to clarify?", ticket.Title)
+			"Is there anything specific about the implementation you'd like me to clarify?", ticket.Title), nil
 	}
 
 	// Build system prompt
@@ -724,20 +1877,14 @@ You are responding to a user comment about a specific ticket.
 ## Conversation History
 %s`, ticket.ID, ticket.Title, ticket.Status, ticket.Description, history)
 
-	// Create request using Haiku for fast responses
-	req := &anthropic.CreateMessageRequest{
-		Model:     anthropic.ModelHaiku35, // Fast model for chat
-		MaxTokens: 500,
-		System: []anthropic.SystemBlock{
-			{Type: "text", Text: systemPrompt},
-		},
-		Messages: []anthropic.Message{
-			{
-				Role: "user",
-				Content: []anthropic.ContentBlock{
-					{Type: "text", Text: userMessage},
-				},
-			},
+	temperature, maxTokens := cfg.GenerationParams(pmChatMaxTokens)
+	req := &provider.MessageRequest{
+		Model:       model,
+		MaxTokens:   maxTokens,
+		Temperature: temperature,
+		System:      systemPrompt,
+		Messages: []provider.Message{
+			{Role: "user", Content: userMessage},
 		},
 	}
 
@@ -745,13 +1892,39 @@ You are responding to a user comment about a specific ticket.
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
-	resp, err := client.CreateMessage(ctx, req)
+	resp, err := prov.CreateMessage(ctx, req)
+	if err != nil {
+		return "", fmt.Errorf("%s/%s: %w", providerName, model, err)
+	}
+
+	return resp.Content, nil
+}
+
+// apiGetPendingPMResponses lists chat messages waiting on a PM response after
+// the provider kept failing.
+func (s *Server) apiGetPendingPMResponses(w http.ResponseWriter, r *http.Request) {
+	pending, err := s.store.GetPendingPMResponses()
 	if err != nil {
-		s.logger.Error("Failed to get PM response from Anthropic", "error", err)
-		return "I apologize, but I'm having trouble processing your message right now. Please try again in a moment."
+		s.logger.Error("Failed to get pending PM responses", "error", err)
+		s.jsonError(w, "Failed to get pending PM responses", http.StatusInternalServerError)
+		return
 	}
+	if pending == nil {
+		pending = []kanban.PendingPMResponse{}
+	}
+	s.jsonResponse(w, pending)
+}
 
-	return resp.GetText()
+// apiRetryPendingPMResponses queues every pending chat message for another
+// PM response attempt.
+func (s *Server) apiRetryPendingPMResponses(w http.ResponseWriter, r *http.Request) {
+	queued, err := s.retryPendingPMResponses()
+	if err != nil {
+		s.logger.Error("Failed to retry pending PM responses", "error", err)
+		s.jsonError(w, "Failed to retry pending PM responses", http.StatusInternalServerError)
+		return
+	}
+	s.jsonResponse(w, map[string]int{"queued": queued})
 }
 
 // apiGetTicketMessages returns all chat messages for a ticket as HTML bubbles.
@@ -870,6 +2043,11 @@ func (s *Server) apiGetAuditLog(w http.ResponseWriter, r *http.Request) {
 	runID := r.URL.Query().Get("run_id")
 	ticketID := r.URL.Query().Get("ticket_id")
 
+	if traceID := r.URL.Query().Get("trace"); traceID != "" {
+		s.apiGetTrace(w, traceID)
+		return
+	}
+
 	var entries []kanban.AuditEntry
 	var err error
 
@@ -891,6 +2069,29 @@ func (s *Server) apiGetAuditLog(w http.ResponseWriter, r *http.Request) {
 	s.jsonResponse(w, entries)
 }
 
+// apiGetTrace returns every agent run and audit entry recorded for one API request trace.
+func (s *Server) apiGetTrace(w http.ResponseWriter, traceID string) {
+	runs, err := s.store.GetRunsByTrace(traceID)
+	if err != nil {
+		s.logger.Error("Failed to get runs for trace", "trace", traceID, "error", err)
+		s.jsonError(w, "Failed to get trace", http.StatusInternalServerError)
+		return
+	}
+
+	entries, err := s.store.GetAuditEntriesByTrace(traceID)
+	if err != nil {
+		s.logger.Error("Failed to get audit entries for trace", "trace", traceID, "error", err)
+		s.jsonError(w, "Failed to get trace", http.StatusInternalServerError)
+		return
+	}
+
+	s.jsonResponse(w, map[string]interface{}{
+		"traceId": traceID,
+		"runs":    runs,
+		"entries": entries,
+	})
+}
+
 // apiGetRunAudit returns audit entries for a specific agent run.
 func (s *Server) apiGetRunAudit(w http.ResponseWriter, r *http.Request) {
 	runID := r.PathValue("id")
@@ -909,6 +2110,38 @@ func (s *Server) apiGetRunAudit(w http.ResponseWriter, r *http.Request) {
 	s.jsonResponse(w, entries)
 }
 
+// apiGetRunPrompt returns the system and user prompt that were sent for a run.
+func (s *Server) apiGetRunPrompt(w http.ResponseWriter, r *http.Request) {
+	runID := r.PathValue("id")
+	if runID == "" {
+		s.jsonError(w, "Missing run ID", http.StatusBadRequest)
+		return
+	}
+
+	entry, err := s.store.GetPromptSentEntry(runID)
+	if err != nil {
+		s.logger.Error("Failed to get prompt entry", "runID", runID, "error", err)
+		s.jsonError(w, "Failed to get prompt", http.StatusInternalServerError)
+		return
+	}
+	if entry == nil {
+		s.jsonError(w, "No prompt recorded for run", http.StatusNotFound)
+		return
+	}
+
+	sent := agents.ParseSentPrompt(entry.EventData)
+	s.jsonResponse(w, map[string]interface{}{
+		"runId":            entry.RunID,
+		"ticketId":         entry.TicketID,
+		"agent":            entry.Agent,
+		"sentAt":           entry.CreatedAt,
+		"systemPrompt":     sent.SystemPrompt,
+		"userPrompt":       sent.UserPrompt,
+		"systemPromptHash": sent.SystemPromptHash,
+		"truncated":        sent.Truncated,
+	})
+}
+
 // --- PM Check-in API ---
 
 // apiGetPMCheckins returns PM check-ins for a ticket.
@@ -955,14 +2188,176 @@ func (s *Server) apiResolvePMCheckin(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	s.jsonResponse(w, map[string]string{"status": "resolved"})
+	s.jsonResponse(w, map[string]string{"status": "resolved"})
+}
+
+// apiResolvePMCheckins resolves several PM check-ins at once, either by ID or
+// all unresolved check-ins matching a ticket and/or check-in type.
+func (s *Server) apiResolvePMCheckins(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		IDs         []string           `json:"ids"`
+		TicketID    string             `json:"ticketId"`
+		CheckinType kanban.CheckinType `json:"checkinType"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.jsonError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	ids := req.IDs
+	if len(ids) == 0 {
+		if req.TicketID == "" && req.CheckinType == "" {
+			s.jsonError(w, "Provide ids, ticketId or checkinType", http.StatusBadRequest)
+			return
+		}
+		checkins, err := s.store.GetUnresolvedPMCheckins()
+		if err != nil {
+			s.logger.Error("Failed to get unresolved PM check-ins", "error", err)
+			s.jsonError(w, "Failed to get unresolved PM check-ins", http.StatusInternalServerError)
+			return
+		}
+		for _, c := range checkins {
+			if (req.TicketID == "" || c.TicketID == req.TicketID) && (req.CheckinType == "" || c.CheckinType == req.CheckinType) {
+				ids = append(ids, c.ID)
+			}
+		}
+	}
+
+	resolved, err := s.store.ResolvePMCheckins(ids)
+	if err != nil {
+		s.logger.Error("Failed to resolve PM check-ins", "error", err)
+		s.jsonError(w, "Failed to resolve PM check-ins", http.StatusInternalServerError)
+		return
+	}
+
+	s.jsonResponse(w, map[string]int{"resolved": resolved})
+}
+
+// --- Health Events API ---
+
+// apiGetHealthEvents returns recent system health transitions, as recorded by
+// the background health check.
+func (s *Server) apiGetHealthEvents(w http.ResponseWriter, r *http.Request) {
+	events, err := s.store.GetHealthEvents(100)
+	if err != nil {
+		s.logger.Error("Failed to get health events", "error", err)
+		s.jsonError(w, "Failed to get health events", http.StatusInternalServerError)
+		return
+	}
+
+	s.jsonResponse(w, events)
+}
+
+// apiAckHealthEvent acknowledges a health event.
+func (s *Server) apiAckHealthEvent(w http.ResponseWriter, r *http.Request) {
+	eventID := r.PathValue("id")
+	if eventID == "" {
+		s.jsonError(w, "Missing health event ID", http.StatusBadRequest)
+		return
+	}
+
+	event, err := s.store.GetHealthEvent(eventID)
+	if err != nil {
+		s.logger.Error("Failed to get health event", "error", err)
+		s.jsonError(w, "Failed to get health event", http.StatusInternalServerError)
+		return
+	}
+	if event == nil {
+		s.jsonError(w, "Health event not found", http.StatusNotFound)
+		return
+	}
+
+	if err := s.store.AcknowledgeHealthEvent(eventID); err != nil {
+		s.logger.Error("Failed to acknowledge health event", "error", err)
+		s.jsonError(w, "Failed to acknowledge health event", http.StatusInternalServerError)
+		return
+	}
+
+	s.jsonResponse(w, map[string]string{"status": "acknowledged"})
+}
+
+// SimulateHealthRequest lists hypothetical ticket statuses, keyed by ticket ID.
+type SimulateHealthRequest struct {
+	Overrides map[string]kanban.Status `json:"overrides"`
+}
+
+// apiSimulateHealth returns the system health the board would have if the
+// given tickets had the given statuses. Nothing is changed or recorded.
+func (s *Server) apiSimulateHealth(w http.ResponseWriter, r *http.Request) {
+	var req SimulateHealthRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.jsonError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	valid := make(map[kanban.Status]bool, len(boardStatusOrder))
+	for _, status := range boardStatusOrder {
+		valid[status] = true
+	}
+	for id, status := range req.Overrides {
+		if !valid[status] {
+			s.jsonError(w, fmt.Sprintf("Invalid status %q for ticket %s", status, id), http.StatusBadRequest)
+			return
+		}
+	}
+
+	tickets, err := s.store.GetAllTickets()
+	if err != nil {
+		s.logger.Error("Failed to get tickets for health simulation", "error", err)
+		s.jsonError(w, "Failed to simulate health", http.StatusInternalServerError)
+		return
+	}
+
+	simulated := make([]kanban.Ticket, len(tickets))
+	copy(simulated, tickets)
+	applied := 0
+	for i := range simulated {
+		if status, ok := req.Overrides[simulated[i].ID]; ok {
+			simulated[i].Status = status
+			applied++
+		}
+	}
+	if applied != len(req.Overrides) {
+		s.jsonError(w, "Overrides reference unknown tickets", http.StatusBadRequest)
+		return
+	}
+
+	cfg := s.healthConfig()
+	s.jsonResponse(w, map[string]interface{}{
+		"current":   kanban.ComputeSystemHealth(tickets, cfg),
+		"simulated": kanban.ComputeSystemHealth(simulated, cfg),
+	})
 }
 
 // --- Recent Agent Runs API ---
 
-// apiGetRecentRuns returns recent agent runs (last 24h).
+// apiGetRecentRuns returns recent agent runs, newest first. ?since takes an RFC
+// 3339 time or a duration back from now such as "6h" (default 24h), ?status
+// filters by run status, and ?limit caps the results (at most db.MaxRecentRuns).
 func (s *Server) apiGetRecentRuns(w http.ResponseWriter, r *http.Request) {
-	runs, err := s.store.GetRecentRuns()
+	var filter db.RunFilter
+	q := r.URL.Query()
+	if v := q.Get("since"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			filter.Since = time.Now().Add(-d)
+		} else if t, err := time.Parse(time.RFC3339, v); err == nil {
+			filter.Since = t
+		} else {
+			s.jsonError(w, "Invalid 'since', expected RFC 3339 time or duration", http.StatusBadRequest)
+			return
+		}
+	}
+	filter.Status = q.Get("status")
+	if v := q.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit <= 0 {
+			s.jsonError(w, "Invalid 'limit', expected a positive integer", http.StatusBadRequest)
+			return
+		}
+		filter.Limit = limit
+	}
+
+	runs, err := s.store.GetRecentRuns(filter)
 	if err != nil {
 		s.logger.Error("Failed to get recent runs", "error", err)
 		s.jsonError(w, "Failed to get recent runs", http.StatusInternalServerError)
@@ -972,6 +2367,33 @@ func (s *Server) apiGetRecentRuns(w http.ResponseWriter, r *http.Request) {
 	s.jsonResponse(w, runs)
 }
 
+// apiGetRunsWithoutSignoff returns successful review runs that never produced a
+// sign-off report, newest first, for investigating prompt or format problems.
+// ?limit caps the results (at most db.MaxRecentRuns).
+func (s *Server) apiGetRunsWithoutSignoff(w http.ResponseWriter, r *http.Request) {
+	limit := 0
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			s.jsonError(w, "Invalid 'limit', expected a positive integer", http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	runs, err := s.store.GetRunsWithoutSignoff(limit)
+	if err != nil {
+		s.logger.Error("Failed to get runs without sign-off", "error", err)
+		s.jsonError(w, "Failed to get runs without sign-off", http.StatusInternalServerError)
+		return
+	}
+	if runs == nil {
+		runs = []kanban.AgentRun{}
+	}
+
+	s.jsonResponse(w, runs)
+}
+
 // apiGetRunDetail returns details for a specific agent run.
 func (s *Server) apiGetRunDetail(w http.ResponseWriter, r *http.Request) {
 	runID := r.PathValue("id")
@@ -1040,19 +2462,105 @@ func (s *Server) apiGetWorktreePoolStats(w http.ResponseWriter, r *http.Request)
 	s.jsonResponse(w, stats)
 }
 
-// apiGetMergeQueue returns the merge queue entries.
-func (s *Server) apiGetMergeQueue(w http.ResponseWriter, r *http.Request) {
-	statusFilter := r.URL.Query().Get("status")
+// WorktreeSlot is a worktree pool slot and the ticket holding it.
+type WorktreeSlot struct {
+	TicketID     string                    `json:"ticketId"`
+	Title        string                    `json:"title"`
+	Status       kanban.Status             `json:"status"`
+	PoolStatus   kanban.WorktreePoolStatus `json:"poolStatus"`
+	Agent        string                    `json:"agent"`
+	Branch       string                    `json:"branch"`
+	CreatedAt    time.Time                 `json:"createdAt"`
+	LastActivity time.Time                 `json:"lastActivity"`
+	CleanupAfter *time.Time                `json:"cleanupAfter,omitempty"`
+}
 
-	var entries []kanban.MergeQueueEntry
-	var err error
+// apiGetWorktreeSlots returns the tickets holding active or merging worktree
+// slots, least recently active first, along with the pool stats and the
+// merged worktrees kept until their cleanup deadline.
+func (s *Server) apiGetWorktreeSlots(w http.ResponseWriter, r *http.Request) {
+	pool, err := s.store.GetWorktreePool()
+	if err != nil {
+		s.logger.Error("Failed to get worktree pool", "error", err)
+		s.jsonError(w, "Failed to get worktree pool", http.StatusInternalServerError)
+		return
+	}
+	stats, err := s.store.GetWorktreePoolStats()
+	if err != nil {
+		s.logger.Error("Failed to get worktree pool stats", "error", err)
+		s.jsonError(w, "Failed to get worktree pool stats", http.StatusInternalServerError)
+		return
+	}
 
-	if statusFilter != "" {
-		entries, err = s.store.GetMergeQueueByStatus(kanban.MergeQueueStatus(statusFilter))
-	} else {
-		entries, err = s.store.GetMergeQueue()
+	slots := []WorktreeSlot{}
+	pendingCleanup := []WorktreeSlot{}
+	for _, entry := range pool {
+		if entry.Status != kanban.WorktreePoolStatusActive && entry.Status != kanban.WorktreePoolStatusMerging &&
+			entry.Status != kanban.WorktreePoolStatusCleanupPending {
+			continue
+		}
+		slot := WorktreeSlot{
+			TicketID:     entry.TicketID,
+			PoolStatus:   entry.Status,
+			Agent:        entry.Agent,
+			Branch:       entry.Branch,
+			CreatedAt:    entry.CreatedAt,
+			LastActivity: entry.LastActivity,
+			CleanupAfter: entry.CleanupAfter,
+		}
+		if ticket, ok := s.store.GetTicket(entry.TicketID); ok {
+			slot.Title = ticket.Title
+			slot.Status = ticket.Status
+		}
+		if entry.Status == kanban.WorktreePoolStatusCleanupPending {
+			pendingCleanup = append(pendingCleanup, slot)
+			continue
+		}
+		slots = append(slots, slot)
+	}
+	sort.SliceStable(slots, func(i, j int) bool { return slots[i].LastActivity.Before(slots[j].LastActivity) })
+
+	s.jsonResponse(w, map[string]interface{}{
+		"slots":          slots,
+		"pendingCleanup": pendingCleanup,
+		"availableSlots": stats.AvailableSlots,
+		"limit":          stats.Limit,
+		"pendingCount":   stats.PendingCount,
+	})
+}
+
+// apiGetMergeQueue returns a page of merge queue entries. ?status filters,
+// ?sort=created (newest first, the default) or attempts (most retried first)
+// orders, and ?limit (default db.DefaultMergeQueueLimit) and ?offset page.
+func (s *Server) apiGetMergeQueue(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := db.MergeQueueFilter{Status: kanban.MergeQueueStatus(query.Get("status"))}
+
+	switch order := db.MergeQueueSort(query.Get("sort")); order {
+	case "", db.MergeQueueSortCreated, db.MergeQueueSortAttempts:
+		filter.Sort = order
+	default:
+		s.jsonError(w, "Invalid 'sort', expected created or attempts", http.StatusBadRequest)
+		return
+	}
+	if v := query.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			s.jsonError(w, "Invalid 'limit', expected a positive integer", http.StatusBadRequest)
+			return
+		}
+		filter.Limit = n
+	}
+	if v := query.Get("offset"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			s.jsonError(w, "Invalid 'offset', expected a non-negative integer", http.StatusBadRequest)
+			return
+		}
+		filter.Offset = n
 	}
 
+	entries, err := s.store.GetMergeQueue(filter)
 	if err != nil {
 		s.logger.Error("Failed to get merge queue", "error", err)
 		s.jsonError(w, "Failed to get merge queue", http.StatusInternalServerError)
@@ -1080,6 +2588,73 @@ func (s *Server) apiGetWorktreeEvents(w http.ResponseWriter, r *http.Request) {
 	s.jsonResponse(w, events)
 }
 
+// worktreeManager returns a git worktree manager for the orchestrator's repository.
+func (s *Server) worktreeManager() *git.WorktreeManager {
+	boardConfig := s.store.GetConfig()
+
+	repoRoot := s.orchRepoRoot
+	if repoRoot == "" {
+		repoRoot = "."
+	}
+	mainBranch := s.orchConfig.MainBranch
+	if mainBranch == "" {
+		mainBranch = boardConfig.MainBranch
+	}
+	worktreeDir := s.orchConfig.WorktreeDir
+	if worktreeDir == "" {
+		worktreeDir = boardConfig.WorktreeDir
+	}
+
+	manager := git.NewWorktreeManager(repoRoot, worktreeDir, mainBranch)
+	if s.orchConfig.BareRepo != "" {
+		manager.SetBareRepo(s.orchConfig.BareRepo)
+	}
+	return manager
+}
+
+// apiGetTicketCommits returns the commits on a ticket's branch that are not yet on main.
+func (s *Server) apiGetTicketCommits(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		s.jsonError(w, "Missing ticket ID", http.StatusBadRequest)
+		return
+	}
+
+	ticket, found := s.store.GetTicket(id)
+	if !found {
+		s.jsonError(w, "Ticket not found", http.StatusNotFound)
+		return
+	}
+
+	// Without a recorded worktree, fall back to the branch name dev would have used
+	branch := ""
+	if ticket.Worktree != nil {
+		branch = ticket.Worktree.Branch
+	}
+	if branch == "" {
+		branch = git.GenerateBranchName(s.store.GetConfig().BranchPrefix, ticket.ID, ticket.Title)
+	}
+
+	commits, err := s.worktreeManager().Commits(branch)
+	if err != nil {
+		if errors.Is(err, git.ErrBranchNotFound) {
+			s.jsonError(w, "Ticket branch not found", http.StatusNotFound)
+			return
+		}
+		s.logger.Error("Failed to get ticket commits", "ticketID", id, "branch", branch, "error", err)
+		s.jsonError(w, "Failed to get commits", http.StatusInternalServerError)
+		return
+	}
+	if commits == nil {
+		commits = []git.Commit{}
+	}
+
+	s.jsonResponse(w, map[string]interface{}{
+		"branch":  branch,
+		"commits": commits,
+	})
+}
+
 // apiGetRecentWorktreeEvents returns recent worktree events across all tickets.
 func (s *Server) apiGetRecentWorktreeEvents(w http.ResponseWriter, r *http.Request) {
 	limit := 50 // Default limit
@@ -1099,6 +2674,64 @@ func (s *Server) apiGetRecentWorktreeEvents(w http.ResponseWriter, r *http.Reque
 	s.jsonResponse(w, events)
 }
 
+// --- Activity Feed API ---
+
+// apiGetActivity returns the most recent activity feed entries, newest first.
+func (s *Server) apiGetActivity(w http.ResponseWriter, r *http.Request) {
+	limit := 50
+	if l := r.URL.Query().Get("limit"); l != "" {
+		if parsed, err := fmt.Sscanf(l, "%d", &limit); err != nil || parsed != 1 || limit < 1 {
+			limit = 50
+		}
+	}
+	if limit > 500 {
+		limit = 500
+	}
+
+	feed, err := s.store.GetActivity(limit)
+	if err != nil {
+		s.logger.Error("Failed to get activity feed", "error", err)
+		s.jsonError(w, "Failed to get activity feed", http.StatusInternalServerError)
+		return
+	}
+	if feed == nil {
+		feed = []kanban.Activity{}
+	}
+
+	s.jsonResponse(w, feed)
+}
+
+// --- Webhook API ---
+
+// apiTestWebhook delivers a sample status change to the configured webhook
+// and reports whether it was accepted.
+func (s *Server) apiTestWebhook(w http.ResponseWriter, r *http.Request) {
+	webhook := notify.WebhookFromConfig(s.store, s.logger)
+	if webhook == nil {
+		s.jsonError(w, "No valid webhook configured", http.StatusBadRequest)
+		return
+	}
+
+	event := kanban.StatusChange{
+		TicketID: "TEST-1",
+		From:     kanban.StatusBacklog,
+		To:       kanban.StatusReady,
+		By:       "webhook-test",
+		Note:     "Test event from the factory dashboard",
+		At:       time.Now(),
+	}
+	if err := webhook.Deliver(r.Context(), event); err != nil {
+		s.logger.Warn("Test webhook delivery failed", "error", err)
+		s.jsonError(w, "Webhook delivery failed: "+err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	s.jsonResponse(w, map[string]interface{}{
+		"delivered": true,
+		"event":     event,
+	})
+}
+
 // --- Provider Settings API ---
 
 // apiGetProviderConfigs returns all provider configurations and availability.
@@ -1132,6 +2765,7 @@ func (s *Server) apiGetProviderConfigs(w http.ResponseWriter, r *http.Request) {
 		"ux":           "UX Agent",
 		"security":     "Security Agent",
 		"ideas":        "Ideas/Triage",
+		"pm-chat":      "PM Chat",
 	}
 
 	// Enrich configs with labels
@@ -1147,21 +2781,42 @@ func (s *Server) apiGetProviderConfigs(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	// Default model per provider, used for agents configured without a model
+	defaultModels := make(map[string]string, len(providers))
+	for _, p := range providers {
+		defaultModels[p.Name] = s.store.GetProviderDefaultModel(p.Name)
+	}
+
 	s.jsonResponse(w, map[string]interface{}{
-		"configs":   enrichedConfigs,
-		"providers": providers,
-		"apiKeys":   apiKeys,
+		"configs":       enrichedConfigs,
+		"providers":     providers,
+		"apiKeys":       apiKeys,
+		"defaultModels": defaultModels,
 	})
 }
 
+// defaultProviderHealthTTL is how long, in seconds, provider key checks are
+// reused; overridable via the provider_health_ttl config key.
+const defaultProviderHealthTTL = 60
+
+// apiGetProviderHealth validates each provider's API key with a minimal request
+// and reports whether it is reachable, unauthorized or rate limited.
+func (s *Server) apiGetProviderHealth(w http.ResponseWriter, r *http.Request) {
+	ttl := time.Duration(s.configInt("provider_health_ttl", defaultProviderHealthTTL, 0)) * time.Second
+	s.jsonResponse(w, s.providers.ProviderHealth(r.Context(), ttl))
+}
+
 // apiUpdateProviderConfigs updates provider/model for one or more agents.
 func (s *Server) apiUpdateProviderConfigs(w http.ResponseWriter, r *http.Request) {
 	var req struct {
 		Configs []struct {
-			AgentType string `json:"agent_type"`
-			Provider  string `json:"provider"`
-			Model     string `json:"model"`
+			AgentType   string   `json:"agent_type"`
+			Provider    string   `json:"provider"`
+			Model       string   `json:"model"`
+			Temperature *float64 `json:"temperature"` // Omitted or null uses the provider default
+			MaxTokens   int      `json:"max_tokens"`  // 0 uses the default
 		} `json:"configs"`
+		DefaultModels map[string]string `json:"default_models"` // Provider -> default model
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -1169,27 +2824,49 @@ func (s *Server) apiUpdateProviderConfigs(w http.ResponseWriter, r *http.Request
 		return
 	}
 
+	// Update provider defaults first so configs without a model pick them up
+	for providerName, model := range req.DefaultModels {
+		if !isValidModelForProvider(providerName, model) {
+			s.jsonError(w, fmt.Sprintf("Invalid default model %s for provider %s", model, providerName), http.StatusBadRequest)
+			return
+		}
+		if err := s.store.SetConfig(provider.DefaultModelConfigKey(providerName), model); err != nil {
+			s.logger.Error("Failed to update provider default model", "provider", providerName, "error", err)
+			s.jsonError(w, "Failed to update config", http.StatusInternalServerError)
+			return
+		}
+	}
+
 	// Validate and update each config
 	for _, config := range req.Configs {
 		agentType := config.AgentType
 		// Validate provider
-		validProviders := map[string]bool{"anthropic": true, "openai": true, "google": true}
-		if !validProviders[config.Provider] {
+		if !isValidProvider(config.Provider) {
 			s.jsonError(w, fmt.Sprintf("Invalid provider: %s", config.Provider), http.StatusBadRequest)
 			return
 		}
 
-		// Validate model belongs to provider
-		if !isValidModelForProvider(config.Provider, config.Model) {
+		// Validate model belongs to provider (empty uses the provider default)
+		if config.Model != "" && !isValidModelForProvider(config.Provider, config.Model) {
 			s.jsonError(w, fmt.Sprintf("Invalid model %s for provider %s", config.Model, config.Provider), http.StatusBadRequest)
 			return
 		}
 
+		if err := provider.ValidateGenerationParams(config.Provider, config.Temperature, config.MaxTokens); err != nil {
+			s.jsonError(w, fmt.Sprintf("Invalid settings for %s: %v", agentType, err), http.StatusBadRequest)
+			return
+		}
+
 		if err := s.store.SetAgentProviderConfig(agentType, config.Provider, config.Model); err != nil {
 			s.logger.Error("Failed to update provider config", "agentType", agentType, "error", err)
 			s.jsonError(w, "Failed to update config", http.StatusInternalServerError)
 			return
 		}
+		if err := s.store.SetAgentGenerationParams(agentType, config.Temperature, config.MaxTokens); err != nil {
+			s.logger.Error("Failed to update generation params", "agentType", agentType, "error", err)
+			s.jsonError(w, "Failed to update config", http.StatusInternalServerError)
+			return
+		}
 	}
 
 	// Broadcast settings update
@@ -1198,6 +2875,15 @@ func (s *Server) apiUpdateProviderConfigs(w http.ResponseWriter, r *http.Request
 	s.jsonResponse(w, map[string]string{"status": "updated"})
 }
 
+// isValidProvider checks if a provider is one agents can run on.
+func isValidProvider(providerName string) bool {
+	switch providerName {
+	case "anthropic", "openai", "google":
+		return true
+	}
+	return false
+}
+
 // isValidModelForProvider checks if a model is valid for a provider.
 func isValidModelForProvider(providerName, model string) bool {
 	validModels := map[string][]string{
@@ -1356,6 +3042,229 @@ func (s *Server) apiDeleteAgentSystemPrompt(w http.ResponseWriter, r *http.Reque
 	s.jsonResponse(w, map[string]string{"status": "reverted"})
 }
 
+// ReassignAgentRequest moves work from one agent type to another.
+type ReassignAgentRequest struct {
+	From   string `json:"from"`
+	To     string `json:"to"`
+	Cancel bool   `json:"cancel"` // Cancel in-flight runs so they restart under To
+}
+
+// apiReassignAgent rehomes every open ticket assigned to, or being worked by,
+// one agent type onto another. Runs in flight finish as they are unless cancel
+// is set; a cancelled run is retried by the orchestrator under the new agent.
+func (s *Server) apiReassignAgent(w http.ResponseWriter, r *http.Request) {
+	var req ReassignAgentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.jsonError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.From == "" || req.To == "" || req.From == req.To {
+		s.jsonError(w, "from and to must be two different agent types", http.StatusBadRequest)
+		return
+	}
+	for _, agentType := range []string{req.From, req.To} {
+		config, err := s.store.GetAgentProviderConfig(agentType)
+		if err != nil {
+			s.logger.Error("Failed to get agent config", "error", err)
+			s.jsonError(w, "Failed to get agent config", http.StatusInternalServerError)
+			return
+		}
+		if config == nil {
+			s.jsonError(w, "Unknown agent type: "+agentType, http.StatusBadRequest)
+			return
+		}
+	}
+
+	tickets, err := s.store.GetAllTickets()
+	if err != nil {
+		s.logger.Error("Failed to get tickets", "error", err)
+		s.jsonError(w, "Failed to get tickets", http.StatusInternalServerError)
+		return
+	}
+	affected := make(map[string]bool)
+	for _, t := range tickets {
+		if t.AssignedAgent == req.From && t.Status != kanban.StatusDone {
+			affected[t.ID] = true
+		}
+	}
+	running := make(map[string]bool)
+	for _, run := range s.store.GetActiveRuns() {
+		if run.Agent == req.From {
+			affected[run.TicketID] = true
+			running[run.TicketID] = true
+		}
+	}
+
+	reassigned := make([]string, 0, len(affected))
+	for id := range affected {
+		if err := s.store.AssignAgent(id, req.To); err != nil {
+			s.logger.Error("Failed to reassign ticket", "id", id, "error", err)
+			s.jsonError(w, "Failed to reassign ticket", http.StatusInternalServerError)
+			return
+		}
+		reassigned = append(reassigned, id)
+	}
+	sort.Strings(reassigned)
+
+	cancelled := 0
+	if req.Cancel {
+		s.orchMu.RLock()
+		if s.orchestrator != nil {
+			for id := range running {
+				cancelled += s.orchestrator.CancelTicketRuns(id)
+			}
+		}
+		s.orchMu.RUnlock()
+	}
+	s.logger.Info("Reassigned agent work", "from", req.From, "to", req.To,
+		"tickets", len(reassigned), "cancelledRuns", cancelled)
+
+	s.Broadcast("board-update")
+
+	s.jsonResponse(w, map[string]interface{}{
+		"tickets":       reassigned,
+		"cancelledRuns": cancelled,
+	})
+}
+
+// apiGetConfigSchema lists every recognized config key with its type, default,
+// allowed values and description.
+func (s *Server) apiGetConfigSchema(w http.ResponseWriter, r *http.Request) {
+	s.jsonResponse(w, db.ConfigSchema)
+}
+
+// apiUpdateConfig writes config values given as a JSON object of key to value.
+// Every value is validated against the schema before any is written, so a bad
+// key or value leaves the config unchanged. An empty value unsets the key.
+func (s *Server) apiUpdateConfig(w http.ResponseWriter, r *http.Request) {
+	var values map[string]string
+	if err := json.NewDecoder(r.Body).Decode(&values); err != nil {
+		s.jsonError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if len(values) == 0 {
+		s.jsonError(w, "No config values given", http.StatusBadRequest)
+		return
+	}
+
+	for key, value := range values {
+		if err := db.ValidateConfigValue(key, value); err != nil {
+			s.jsonError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	for key, value := range values {
+		if err := s.store.SetConfig(key, value); err != nil {
+			s.logger.Error("Failed to set config", "key", key, "error", err)
+			s.jsonError(w, "Failed to set config", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	s.Broadcast("board-update")
+	s.jsonResponse(w, values)
+}
+
+// apiGetConfigProfiles lists saved config profiles.
+func (s *Server) apiGetConfigProfiles(w http.ResponseWriter, r *http.Request) {
+	profiles, err := s.store.GetConfigProfiles()
+	if err != nil {
+		s.logger.Error("Failed to get config profiles", "error", err)
+		s.jsonError(w, "Failed to get config profiles", http.StatusInternalServerError)
+		return
+	}
+	if profiles == nil {
+		profiles = []kanban.ConfigProfile{}
+	}
+	s.jsonResponse(w, profiles)
+}
+
+// apiSaveConfigProfile saves a named config profile. Without explicit values or
+// providers in the body, the current config is captured as the profile.
+func (s *Server) apiSaveConfigProfile(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Name      string                            `json:"name"`
+		Values    map[string]string                 `json:"values"`
+		Providers map[string]kanban.ProfileProvider `json:"providers"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.jsonError(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		s.jsonError(w, "Profile name is required", http.StatusBadRequest)
+		return
+	}
+
+	var profile *kanban.ConfigProfile
+	if req.Values == nil && req.Providers == nil {
+		current, err := s.store.CurrentConfigProfile(req.Name)
+		if err != nil {
+			s.logger.Error("Failed to capture current config", "error", err)
+			s.jsonError(w, "Failed to capture current config", http.StatusInternalServerError)
+			return
+		}
+		profile = current
+	} else {
+		delete(req.Values, db.ActiveConfigProfileKey)
+		for agentType, p := range req.Providers {
+			if p.Model != "" && !isValidModelForProvider(p.Provider, p.Model) {
+				s.jsonError(w, fmt.Sprintf("Invalid model %s for provider %s (agent %s)", p.Model, p.Provider, agentType), http.StatusBadRequest)
+				return
+			}
+		}
+		profile = &kanban.ConfigProfile{Name: req.Name, Values: req.Values, Providers: req.Providers}
+	}
+
+	// Keep the original creation time when overwriting a profile
+	if existing, err := s.store.GetConfigProfile(req.Name); err == nil && existing != nil {
+		profile.CreatedAt = existing.CreatedAt
+		profile.Active = existing.Active
+	}
+
+	if err := s.store.SaveConfigProfile(profile); err != nil {
+		s.logger.Error("Failed to save config profile", "name", req.Name, "error", err)
+		s.jsonError(w, "Failed to save config profile", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	s.jsonResponse(w, profile)
+}
+
+// apiActivateConfigProfile applies a saved config profile and marks it active.
+func (s *Server) apiActivateConfigProfile(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	if name == "" {
+		s.jsonError(w, "Missing profile name", http.StatusBadRequest)
+		return
+	}
+
+	profile, err := s.store.GetConfigProfile(name)
+	if err != nil {
+		s.logger.Error("Failed to get config profile", "name", name, "error", err)
+		s.jsonError(w, "Failed to get config profile", http.StatusInternalServerError)
+		return
+	}
+	if profile == nil {
+		s.jsonError(w, "Config profile not found", http.StatusNotFound)
+		return
+	}
+
+	if err := s.store.ActivateConfigProfile(name); err != nil {
+		s.logger.Error("Failed to activate config profile", "name", name, "error", err)
+		s.jsonError(w, "Failed to activate config profile", http.StatusInternalServerError)
+		return
+	}
+	profile.Active = true
+
+	s.Broadcast("settings-update")
+
+	s.jsonResponse(w, profile)
+}
+
 // apiGetOrchestratorStatus returns the current orchestrator status.
 func (s *Server) apiGetOrchestratorStatus(w http.ResponseWriter, r *http.Request) {
 	status := s.GetOrchestratorStatus()
@@ -1376,6 +3285,22 @@ func (s *Server) apiStartOrchestrator(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// apiStepOrchestrator runs one orchestration cycle and returns what it did.
+func (s *Server) apiStepOrchestrator(w http.ResponseWriter, r *http.Request) {
+	summary, err := s.StepOrchestrator()
+	if errors.Is(err, factory.ErrCycleInProgress) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		s.logger.Error("Failed to step orchestrator", "error", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	s.jsonResponse(w, summary)
+}
+
 // apiStopOrchestrator stops the orchestrator.
 func (s *Server) apiStopOrchestrator(w http.ResponseWriter, r *http.Request) {
 	s.StopOrchestrator()
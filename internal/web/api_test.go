@@ -0,0 +1,2170 @@
+package web
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"math"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/madhatter5501/Factory/agents"
+	"github.com/madhatter5501/Factory/agents/provider"
+	"github.com/madhatter5501/Factory/git"
+	"github.com/madhatter5501/Factory/internal/db"
+	"github.com/madhatter5501/Factory/internal/notify"
+	"github.com/madhatter5501/Factory/kanban"
+)
+
+// --- Test Helpers ---
+
+// newTestServer creates a server backed by a fresh SQLite database.
+func newTestServer(t *testing.T) *Server {
+	t.Helper()
+
+	database, err := db.Open(filepath.Join(t.TempDir(), "factory.db"))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	t.Cleanup(func() { _ = database.Close() })
+
+	srv, err := NewServer(database, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatalf("failed to create server: %v", err)
+	}
+	return srv
+}
+
+// promptSpawner reports a fixed prompt the way real spawners do.
+type promptSpawner struct {
+	system string
+	user   string
+}
+
+func (p *promptSpawner) SpawnAgent(ctx context.Context, agentType agents.AgentType, data agents.PromptData, workDir string) (*agents.AgentResult, error) {
+	if data.OnPrompt != nil {
+		data.OnPrompt(p.system, p.user)
+	}
+	return &agents.AgentResult{Success: true, AgentType: agentType, Output: "done"}, nil
+}
+
+func (p *promptSpawner) ValidateAgentEnvironment() []string { return nil }
+
+// --- Tests ---
+
+func TestRunPromptIsRetrievable(t *testing.T) {
+	srv := newTestServer(t)
+
+	ticket := &kanban.Ticket{ID: "T-1", Title: "Prompt capture", Status: kanban.StatusInQA, CreatedAt: time.Now(), UpdatedAt: time.Now()}
+	if err := srv.store.CreateTicket(ticket); err != nil {
+		t.Fatalf("failed to create ticket: %v", err)
+	}
+	if err := srv.store.AddRun(&kanban.AgentRun{ID: "run-1", Agent: "qa", TicketID: "T-1", StartedAt: time.Now(), Status: "running"}); err != nil {
+		t.Fatalf("failed to add run: %v", err)
+	}
+
+	inner := &promptSpawner{system: "You are the QA agent.", user: "Execute the task."}
+	spawner := agents.NewAuditingSpawner(inner, agents.NewStoreAuditLogger(srv.store))
+	_, err := spawner.SpawnAgent(context.Background(), agents.AgentTypeQA, agents.PromptData{RunID: "run-1", Ticket: ticket}, t.TempDir())
+	if err != nil {
+		t.Fatalf("spawn failed: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/runs/run-1/prompt", nil)
+	req.SetPathValue("id", "run-1")
+	rec := httptest.NewRecorder()
+	srv.apiGetRunPrompt(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var resp struct {
+		SystemPrompt     string `json:"systemPrompt"`
+		UserPrompt       string `json:"userPrompt"`
+		SystemPromptHash string `json:"systemPromptHash"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.SystemPrompt != inner.system {
+		t.Errorf("expected system prompt %q, got %q", inner.system, resp.SystemPrompt)
+	}
+	if resp.UserPrompt != inner.user {
+		t.Errorf("expected user prompt %q, got %q", inner.user, resp.UserPrompt)
+	}
+	if resp.SystemPromptHash == "" {
+		t.Error("expected system prompt hash to be recorded")
+	}
+
+	// Unknown runs have no prompt
+	req = httptest.NewRequest(http.MethodGet, "/api/runs/missing/prompt", nil)
+	req.SetPathValue("id", "missing")
+	rec = httptest.NewRecorder()
+	srv.apiGetRunPrompt(rec, req)
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for unknown run, got %d", rec.Code)
+	}
+}
+
+// recordingNotifier captures notifications for assertions.
+type recordingNotifier struct {
+	sent []notify.Notification
+}
+
+func (n *recordingNotifier) Notify(notification notify.Notification) error {
+	n.sent = append(n.sent, notification)
+	return nil
+}
+
+func TestHealthTransitionToThrashingCreatesOneEvent(t *testing.T) {
+	srv := newTestServer(t)
+	notifier := &recordingNotifier{}
+	srv.notifier = notifier
+
+	// Stable board records nothing: the implicit starting status is stable
+	srv.recordSystemHealth(nil)
+
+	// Three tickets bouncing between DEV and QA
+	var tickets []kanban.Ticket
+	for _, id := range []string{"T-1", "T-2", "T-3"} {
+		ticket := kanban.Ticket{ID: id, Status: kanban.StatusInDev}
+		for i := 0; i < 3; i++ {
+			ticket.History = append(ticket.History,
+				kanban.HistoryEntry{Status: kanban.StatusInDev},
+				kanban.HistoryEntry{Status: kanban.StatusInQA})
+		}
+		tickets = append(tickets, ticket)
+	}
+
+	// Repeated evaluation of the same state must not re-report
+	for i := 0; i < 3; i++ {
+		if health := srv.recordSystemHealth(tickets); health.Status != kanban.SystemHealthThrashing {
+			t.Fatalf("expected thrashing, got %s", health.Status)
+		}
+	}
+
+	events, err := srv.store.GetHealthEvents(10)
+	if err != nil {
+		t.Fatalf("failed to get health events: %v", err)
+	}
+	if len(events) != 1 {
+		t.Fatalf("expected exactly 1 health event, got %d", len(events))
+	}
+	if events[0].FromStatus != kanban.SystemHealthStable || events[0].ToStatus != kanban.SystemHealthThrashing {
+		t.Errorf("expected stable -> thrashing, got %s -> %s", events[0].FromStatus, events[0].ToStatus)
+	}
+	if len(notifier.sent) != 1 {
+		t.Errorf("expected 1 notification, got %d", len(notifier.sent))
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/api/health/events/"+events[0].ID+"/ack", nil)
+	req.SetPathValue("id", events[0].ID)
+	rec := httptest.NewRecorder()
+	srv.apiAckHealthEvent(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 on ack, got %d", rec.Code)
+	}
+	if event, _ := srv.store.GetHealthEvent(events[0].ID); event == nil || !event.Acknowledged {
+		t.Error("expected health event to be acknowledged")
+	}
+}
+
+func TestGetHealthEventsDoesNotRecordTransitions(t *testing.T) {
+	srv := newTestServer(t)
+	notifier := &recordingNotifier{}
+	srv.notifier = notifier
+
+	// A thrashing board: three tickets bouncing between DEV and QA
+	for _, id := range []string{"T-1", "T-2", "T-3"} {
+		ticket := &kanban.Ticket{ID: id, Title: id, Domain: kanban.DomainBackend, Status: kanban.StatusInDev, CreatedAt: time.Now(), UpdatedAt: time.Now()}
+		if err := srv.store.CreateTicket(ticket); err != nil {
+			t.Fatalf("failed to create ticket: %v", err)
+		}
+		for i := 0; i < 3; i++ {
+			_ = srv.store.AddHistoryEntry(id, kanban.StatusInDev, "dev", "")
+			_ = srv.store.AddHistoryEntry(id, kanban.StatusInQA, "qa", "")
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	srv.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/health/events", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	if events, _ := srv.store.GetHealthEvents(10); len(events) != 0 {
+		t.Fatalf("expected GET to record no health events, got %d", len(events))
+	}
+	if len(notifier.sent) != 0 {
+		t.Errorf("expected GET to send no notifications, got %d", len(notifier.sent))
+	}
+
+	// The background check records the transition
+	srv.checkSystemHealth()
+	if events, _ := srv.store.GetHealthEvents(10); len(events) != 1 {
+		t.Errorf("expected the health check to record 1 event, got %d", len(events))
+	}
+}
+
+func TestIceboxTicketsAreExcludedUntilPromoted(t *testing.T) {
+	srv := newTestServer(t)
+
+	// An iceboxed ticket that looks ready and has cycled enough to count as thrashing
+	ticket := &kanban.Ticket{ID: "T-1", Title: "Someday maybe", Domain: kanban.DomainBackend, Status: kanban.StatusIcebox, CreatedAt: time.Now(), UpdatedAt: time.Now()}
+	for i := 0; i < 3; i++ {
+		ticket.History = append(ticket.History,
+			kanban.HistoryEntry{Status: kanban.StatusInDev},
+			kanban.HistoryEntry{Status: kanban.StatusInQA})
+	}
+	if err := srv.store.CreateTicket(ticket); err != nil {
+		t.Fatalf("failed to create ticket: %v", err)
+	}
+	active := kanban.Ticket{ID: "T-2", Status: kanban.StatusInDev}
+
+	health := srv.computeSystemHealth([]kanban.Ticket{*ticket, active})
+	if health.ActiveCount != 1 || len(health.ThrashingTickets) != 0 || health.ReworkRate != 0 {
+		t.Errorf("expected icebox ticket to be ignored by health, got active=%d thrashing=%v rework=%.2f",
+			health.ActiveCount, health.ThrashingTickets, health.ReworkRate)
+	}
+	if next, ok := srv.store.GetNextTicketForDomain(kanban.DomainBackend); ok {
+		t.Errorf("expected no schedulable ticket, got %s", next.ID)
+	}
+	if !srv.store.IsIterationComplete() {
+		t.Error("expected icebox ticket not to hold the iteration open")
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/api/tickets/T-1/promote", nil)
+	req.SetPathValue("id", "T-1")
+	rec := httptest.NewRecorder()
+	srv.apiPromoteTicket(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 on promote, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if promoted, _ := srv.store.GetTicket("T-1"); promoted == nil || promoted.Status != kanban.StatusBacklog {
+		t.Fatal("expected ticket to be promoted to backlog")
+	}
+
+	// Promoting again is rejected: the ticket is no longer iceboxed
+	rec = httptest.NewRecorder()
+	srv.apiPromoteTicket(rec, req)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 when promoting a backlog ticket, got %d", rec.Code)
+	}
+}
+
+func TestCreatedThenScheduledTicketSharesTraceID(t *testing.T) {
+	srv := newTestServer(t)
+
+	// Create the ticket through the tracing middleware, as the dashboard would
+	req := httptest.NewRequest(http.MethodPost, "/api/tickets", strings.NewReader(`{"title":"Traced ticket","domain":"backend"}`))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	withTracing(http.HandlerFunc(srv.apiCreateTicket)).ServeHTTP(rec, req)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	traceID := rec.Header().Get(TraceIDHeader)
+	if traceID == "" {
+		t.Fatal("expected X-Trace-Id response header")
+	}
+	var created kanban.Ticket
+	if err := json.NewDecoder(rec.Body).Decode(&created); err != nil {
+		t.Fatalf("failed to decode ticket: %v", err)
+	}
+
+	// The orchestrator later schedules it without knowing about the request
+	srv.store.AddActiveRun(kanban.AgentRun{ID: "run-1", Agent: "dev-backend", TicketID: created.ID, StartedAt: time.Now(), Status: "running"})
+	spawner := agents.NewAuditingSpawner(&promptSpawner{system: "You are a developer."}, agents.NewStoreAuditLogger(srv.store))
+	if _, err := spawner.SpawnAgent(context.Background(), agents.AgentTypeDevBackend, agents.PromptData{RunID: "run-1", Ticket: &created}, t.TempDir()); err != nil {
+		t.Fatalf("spawn failed: %v", err)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/audit?trace="+traceID, nil)
+	rec = httptest.NewRecorder()
+	srv.apiGetAuditLog(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var trace struct {
+		Runs    []kanban.AgentRun   `json:"runs"`
+		Entries []kanban.AuditEntry `json:"entries"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&trace); err != nil {
+		t.Fatalf("failed to decode trace: %v", err)
+	}
+	if len(trace.Runs) != 1 || trace.Runs[0].ID != "run-1" {
+		t.Fatalf("expected the scheduled run in the trace, got %+v", trace.Runs)
+	}
+	if len(trace.Entries) == 0 {
+		t.Fatal("expected audit entries in the trace")
+	}
+	for _, entry := range trace.Entries {
+		if entry.TraceID != traceID {
+			t.Errorf("audit entry %s has trace %q, expected %q", entry.ID, entry.TraceID, traceID)
+		}
+	}
+}
+
+func TestRunsQueryableByWorktreeAcrossTickets(t *testing.T) {
+	srv := newTestServer(t)
+
+	now := time.Now()
+	for _, id := range []string{"T-1", "T-2"} {
+		if err := srv.store.CreateTicket(&kanban.Ticket{ID: id, Title: id, Status: kanban.StatusInDev, CreatedAt: now, UpdatedAt: now}); err != nil {
+			t.Fatalf("failed to create ticket: %v", err)
+		}
+	}
+	runs := []kanban.AgentRun{
+		{ID: "run-1", Agent: "dev-backend", TicketID: "T-1", Worktree: ".worktrees/slot-1", StartedAt: now.Add(-2 * time.Hour), Status: "success"},
+		{ID: "run-2", Agent: "dev-backend", TicketID: "T-2", Worktree: ".worktrees/slot-1", StartedAt: now.Add(-time.Hour), Status: "running"},
+		{ID: "run-3", Agent: "dev-backend", TicketID: "T-2", Worktree: ".worktrees/slot-2", StartedAt: now, Status: "running"},
+	}
+	for i := range runs {
+		if err := srv.store.AddRun(&runs[i]); err != nil {
+			t.Fatalf("failed to add run: %v", err)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	srv.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/runs?worktree=.worktrees/slot-1", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var got []kanban.AgentRun
+	if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("expected 2 runs on the worktree, got %+v", got)
+	}
+	if got[0].ID != "run-1" || got[0].TicketID != "T-1" || got[0].Status != "success" {
+		t.Errorf("expected T-1's finished run first, got %+v", got[0])
+	}
+	if got[1].ID != "run-2" || got[1].TicketID != "T-2" || got[1].Status != "running" {
+		t.Errorf("expected T-2's running run second, got %+v", got[1])
+	}
+}
+
+func TestTicketsAPIPagesWhenAsked(t *testing.T) {
+	srv := newTestServer(t)
+
+	now := time.Now()
+	for i := 0; i < 5; i++ {
+		id := fmt.Sprintf("T-%d", i+1)
+		if err := srv.store.CreateTicket(&kanban.Ticket{ID: id, Title: id, Status: kanban.StatusReady, CreatedAt: now.Add(time.Duration(i) * time.Minute), UpdatedAt: now}); err != nil {
+			t.Fatalf("failed to create ticket: %v", err)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	srv.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/tickets?limit=2&offset=3", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var page struct {
+		Tickets []kanban.Ticket `json:"tickets"`
+		Total   int             `json:"total"`
+		Limit   int             `json:"limit"`
+		Offset  int             `json:"offset"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&page); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if page.Total != 5 || page.Limit != 2 || page.Offset != 3 {
+		t.Errorf("expected total 5, limit 2, offset 3, got %d, %d, %d", page.Total, page.Limit, page.Offset)
+	}
+	if len(page.Tickets) != 2 || page.Tickets[0].ID != "T-4" || page.Tickets[1].ID != "T-5" {
+		t.Errorf("expected T-4 and T-5, got %+v", page.Tickets)
+	}
+
+	// Without paging params the whole board comes back as a list
+	rec = httptest.NewRecorder()
+	srv.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/tickets", nil))
+	var all []kanban.Ticket
+	if err := json.NewDecoder(rec.Body).Decode(&all); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(all) != 5 {
+		t.Errorf("expected all 5 tickets, got %d", len(all))
+	}
+
+	rec = httptest.NewRecorder()
+	srv.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/tickets?offset=-1", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for a negative offset, got %d", rec.Code)
+	}
+}
+
+func TestTicketSearchEndpoint(t *testing.T) {
+	srv := newTestServer(t)
+
+	now := time.Now()
+	for _, ticket := range []kanban.Ticket{
+		{ID: "T-1", Title: "Rate limit the API", Status: kanban.StatusReady},
+		{ID: "T-2", Title: "Docs", Description: "Describe the rate limit headers", Status: kanban.StatusReady},
+		{ID: "T-3", Title: "Unrelated", Status: kanban.StatusReady},
+	} {
+		ticket.CreatedAt, ticket.UpdatedAt = now, now
+		if err := srv.store.CreateTicket(&ticket); err != nil {
+			t.Fatalf("failed to create ticket: %v", err)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	srv.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/tickets/search?q=rate+limit", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var results []kanban.Ticket
+	if err := json.NewDecoder(rec.Body).Decode(&results); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(results) != 2 || results[0].ID != "T-1" || results[1].ID != "T-2" {
+		t.Fatalf("expected T-1 then T-2, got %+v", results)
+	}
+	if !strings.Contains(results[1].SearchSnippet, "<mark>rate</mark>") {
+		t.Errorf("expected highlighted snippet, got %q", results[1].SearchSnippet)
+	}
+
+	rec = httptest.NewRecorder()
+	srv.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/tickets/search?q=+", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an empty query, got %d", rec.Code)
+	}
+}
+
+// uploadAttachment posts a multipart file of the given size to a message.
+func uploadAttachment(t *testing.T, srv *Server, messageID string, size int) int {
+	t.Helper()
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	part, err := mw.CreateFormFile("file", "report.txt")
+	if err != nil {
+		t.Fatalf("failed to create form file: %v", err)
+	}
+	_, _ = part.Write(bytes.Repeat([]byte("x"), size))
+	_ = mw.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/api/messages/"+messageID+"/attachments", &body)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	req.SetPathValue("messageID", messageID)
+	rec := httptest.NewRecorder()
+	srv.apiUploadAttachment(rec, req)
+	return rec.Code
+}
+
+func TestAttachmentUploadsAreLimitedPerTicket(t *testing.T) {
+	srv := newTestServer(t)
+	t.Chdir(t.TempDir()) // Uploads are written relative to the working directory
+
+	if err := srv.store.SetConfig("max_ticket_attachment_bytes", "100"); err != nil {
+		t.Fatalf("failed to set quota: %v", err)
+	}
+	if err := srv.store.CreateTicket(&kanban.Ticket{ID: "T-1", Title: "Attachments", Status: kanban.StatusInDev, CreatedAt: time.Now(), UpdatedAt: time.Now()}); err != nil {
+		t.Fatalf("failed to create ticket: %v", err)
+	}
+	if err := srv.store.CreateConversation(&kanban.TicketConversation{ID: "conv-1", TicketID: "T-1", ThreadType: kanban.ThreadTypeDevDiscussion, Status: kanban.ThreadStatusOpen, CreatedAt: time.Now()}); err != nil {
+		t.Fatalf("failed to create conversation: %v", err)
+	}
+	for _, id := range []string{"msg-1", "msg-2"} {
+		if err := srv.store.AddConversationMessage(&kanban.ConversationMessage{ID: id, ConversationID: "conv-1", Agent: "user", MessageType: kanban.MessageTypeQuestion, Content: "See attached", CreatedAt: time.Now()}); err != nil {
+			t.Fatalf("failed to add message: %v", err)
+		}
+	}
+
+	// Uploads across the ticket's messages count toward one quota
+	if code := uploadAttachment(t, srv, "msg-1", 60); code != http.StatusCreated {
+		t.Fatalf("expected first upload to succeed, got %d", code)
+	}
+	if code := uploadAttachment(t, srv, "msg-2", 40); code != http.StatusCreated {
+		t.Fatalf("expected upload reaching the quota to succeed, got %d", code)
+	}
+	if code := uploadAttachment(t, srv, "msg-2", 1); code != http.StatusRequestEntityTooLarge {
+		t.Errorf("expected 413 for upload crossing the quota, got %d", code)
+	}
+
+	used, err := srv.store.GetTicketAttachmentBytes("T-1")
+	if err != nil {
+		t.Fatalf("failed to get attachment bytes: %v", err)
+	}
+	if used != 100 {
+		t.Errorf("expected 100 bytes stored, got %d", used)
+	}
+}
+
+func TestFailedAttachmentInsertLeavesNoOrphanedFile(t *testing.T) {
+	srv := newTestServer(t)
+	t.Chdir(t.TempDir()) // Uploads are written relative to the working directory
+
+	if err := srv.store.CreateTicket(&kanban.Ticket{ID: "T-1", Title: "Attachments", Status: kanban.StatusInDev, CreatedAt: time.Now(), UpdatedAt: time.Now()}); err != nil {
+		t.Fatalf("failed to create ticket: %v", err)
+	}
+	if err := srv.store.CreateConversation(&kanban.TicketConversation{ID: "conv-1", TicketID: "T-1", ThreadType: kanban.ThreadTypeDevDiscussion, Status: kanban.ThreadStatusOpen, CreatedAt: time.Now()}); err != nil {
+		t.Fatalf("failed to create conversation: %v", err)
+	}
+	if err := srv.store.AddConversationMessage(&kanban.ConversationMessage{ID: "msg-1", ConversationID: "conv-1", Agent: "user", MessageType: kanban.MessageTypeQuestion, Content: "See attached", CreatedAt: time.Now()}); err != nil {
+		t.Fatalf("failed to add message: %v", err)
+	}
+
+	// Simulate the DB insert failing after the file has been written
+	if _, err := srv.db.Exec(`CREATE TRIGGER fail_attachment BEFORE INSERT ON message_attachments
+		BEGIN SELECT RAISE(ABORT, 'simulated insert failure'); END`); err != nil {
+		t.Fatalf("failed to create trigger: %v", err)
+	}
+	if code := uploadAttachment(t, srv, "msg-1", 10); code != http.StatusInternalServerError {
+		t.Fatalf("expected 500 when the insert fails, got %d", code)
+	}
+
+	// A file staged by an upload that crashed before its insert is swept
+	if err := os.WriteFile(filepath.Join(pendingUploadsDir, "crashed.txt"), []byte("x"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	srv.sweepPendingUploads()
+
+	var files []string
+	_ = filepath.WalkDir("uploads", func(path string, d fs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if len(files) != 0 {
+		t.Errorf("expected no orphaned upload files, got %v", files)
+	}
+}
+
+func TestPMChatResponsesAreBoundedByConcurrency(t *testing.T) {
+	srv := newTestServer(t)
+
+	const limit, posts = 2, 8
+	if err := srv.store.SetConfig("pm_chat_concurrency", "2"); err != nil {
+		t.Fatalf("failed to set concurrency: %v", err)
+	}
+	if err := srv.store.SetConfig("pm_chat_queue_size", "1"); err != nil {
+		t.Fatalf("failed to set queue size: %v", err)
+	}
+
+	var mu sync.Mutex
+	inFlight, maxInFlight := 0, 0
+	release := make(chan struct{})
+	responded := make(chan struct{}, posts)
+	srv.pmResponder = func(ticket *kanban.Ticket, history, userMessage string) (string, error) {
+		mu.Lock()
+		inFlight++
+		maxInFlight = max(maxInFlight, inFlight)
+		mu.Unlock()
+
+		<-release
+
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+		responded <- struct{}{}
+		return "On it.", nil
+	}
+
+	for i := 0; i < posts; i++ {
+		id := fmt.Sprintf("T-%d", i)
+		if err := srv.store.CreateTicket(&kanban.Ticket{ID: id, Title: "Chat", Status: kanban.StatusInDev, CreatedAt: time.Now(), UpdatedAt: time.Now()}); err != nil {
+			t.Fatalf("failed to create ticket: %v", err)
+		}
+	}
+
+	// Posts return immediately, so every response is pending at once
+	for i := 0; i < posts; i++ {
+		id := fmt.Sprintf("T-%d", i)
+		req := httptest.NewRequest(http.MethodPost, "/api/tickets/"+id+"/chat", strings.NewReader("content=Any+update%3F"))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.SetPathValue("id", id)
+		rec := httptest.NewRecorder()
+		srv.apiPostChat(rec, req)
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("%s: expected 201, got %d", id, rec.Code)
+		}
+	}
+	// Requests beyond the workers and the queue are saved rather than held in memory
+	if pending, _ := srv.store.GetPendingPMResponses(); len(pending) < posts-limit-1 {
+		t.Errorf("expected at least %d deferred messages saved, got %d", posts-limit-1, len(pending))
+	}
+	close(release)
+
+	for i := 0; i < posts; i++ {
+		select {
+		case <-responded:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("only %d of %d PM responses arrived", i, posts)
+		}
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if maxInFlight > limit {
+		t.Errorf("expected at most %d PM calls in flight, got %d", limit, maxInFlight)
+	}
+
+	// Requests that overflowed the queue were told the PM is busy
+	busy := 0
+	for i := 0; i < posts; i++ {
+		convs, _ := srv.store.GetConversationsByTicket(fmt.Sprintf("T-%d", i))
+		for _, c := range convs {
+			msgs, _ := srv.store.GetConversationMessages(c.ID)
+			for _, m := range msgs {
+				if m.Content == pmBusyMessage {
+					busy++
+				}
+			}
+		}
+	}
+	if busy < posts-limit-1 {
+		t.Errorf("expected at least %d busy placeholders, got %d", posts-limit-1, busy)
+	}
+}
+
+func TestPMChatRetriesTransientProviderErrors(t *testing.T) {
+	srv := newTestServer(t)
+	if err := srv.store.SetConfig("pm_chat_retry_backoff", "0"); err != nil {
+		t.Fatal(err)
+	}
+	if err := srv.store.CreateTicket(&kanban.Ticket{ID: "T-1", Title: "Chat", Status: kanban.StatusInDev, CreatedAt: time.Now(), UpdatedAt: time.Now()}); err != nil {
+		t.Fatalf("failed to create ticket: %v", err)
+	}
+
+	var mu sync.Mutex
+	calls, failUntil := 0, 2
+	srv.pmResponder = func(ticket *kanban.Ticket, history, userMessage string) (string, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		calls++
+		if calls <= failUntil {
+			return "", errors.New("API error (status 529): overloaded")
+		}
+		return "Dev is halfway through.", nil
+	}
+
+	postChat := func() {
+		req := httptest.NewRequest(http.MethodPost, "/api/tickets/T-1/chat", strings.NewReader("content=Any+update%3F"))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rec := httptest.NewRecorder()
+		srv.routes().ServeHTTP(rec, req)
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("expected 201, got %d: %s", rec.Code, rec.Body.String())
+		}
+	}
+	pmMessages := func() []string {
+		var contents []string
+		convs, _ := srv.store.GetConversationsByTicket("T-1")
+		for _, c := range convs {
+			msgs, _ := srv.store.GetConversationMessages(c.ID)
+			for _, m := range msgs {
+				if m.Agent == "PM" {
+					contents = append(contents, m.Content)
+				}
+			}
+		}
+		return contents
+	}
+	waitForPMMessages := func(n int) []string {
+		for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+			if got := pmMessages(); len(got) >= n {
+				return got
+			}
+		}
+		t.Fatalf("expected %d PM messages, got %v", n, pmMessages())
+		return nil
+	}
+
+	postChat()
+	if got := waitForPMMessages(1); len(got) != 1 || got[0] != "Dev is halfway through." {
+		t.Fatalf("expected the real PM response after retries, got %v", got)
+	}
+
+	// Retries exhausted: the question is saved and answered on a later retry
+	mu.Lock()
+	calls, failUntil = 0, 100
+	mu.Unlock()
+	if err := srv.store.SetConfig("pm_chat_max_retries", "1"); err != nil {
+		t.Fatal(err)
+	}
+	postChat()
+	var pending []kanban.PendingPMResponse
+	for deadline := time.Now().Add(5 * time.Second); len(pending) == 0; {
+		if time.Now().After(deadline) {
+			t.Fatal("expected the unanswered question to be saved")
+		}
+		time.Sleep(10 * time.Millisecond)
+		pending, _ = srv.store.GetPendingPMResponses()
+	}
+	if pending[0].Message != "Any update?" || pending[0].Attempts != 2 {
+		t.Errorf("expected question saved after 2 attempts, got %+v", pending[0])
+	}
+
+	mu.Lock()
+	failUntil = 0
+	mu.Unlock()
+	rec := httptest.NewRecorder()
+	srv.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/pm/pending/retry", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	got := waitForPMMessages(3)
+	for deadline := time.Now().Add(5 * time.Second); len(pending) != 0; {
+		if time.Now().After(deadline) {
+			t.Fatal("expected the pending question to be cleared once answered")
+		}
+		time.Sleep(10 * time.Millisecond)
+		pending, _ = srv.store.GetPendingPMResponses()
+	}
+	if len(got) != 3 || got[1] != pmUnavailableMessage || got[2] != "Dev is halfway through." {
+		t.Errorf("expected a saved notice then the answer, got %v", got)
+	}
+}
+
+func TestRetryPendingPMResponsesSkipsQueuedMessages(t *testing.T) {
+	srv := newTestServer(t)
+	if err := srv.store.CreateTicket(&kanban.Ticket{ID: "T-1", Title: "Chat", Status: kanban.StatusInDev, CreatedAt: time.Now(), UpdatedAt: time.Now()}); err != nil {
+		t.Fatalf("failed to create ticket: %v", err)
+	}
+	conv := &kanban.TicketConversation{ID: "C-1", TicketID: "T-1", ThreadType: kanban.ThreadTypeUserQuestion, Title: "Chat", Status: kanban.ThreadStatusOpen, CreatedAt: time.Now()}
+	if err := srv.store.CreateConversation(conv); err != nil {
+		t.Fatalf("failed to create conversation: %v", err)
+	}
+	if err := srv.store.SavePendingPMResponse(&kanban.PendingPMResponse{
+		ID: "P-1", TicketID: "T-1", ConversationID: "C-1", Message: "Any update?", Attempts: 2, CreatedAt: time.Now(), UpdatedAt: time.Now(),
+	}); err != nil {
+		t.Fatalf("failed to save pending response: %v", err)
+	}
+
+	var mu sync.Mutex
+	calls := 0
+	release := make(chan struct{})
+	srv.pmResponder = func(ticket *kanban.Ticket, history, userMessage string) (string, error) {
+		mu.Lock()
+		calls++
+		mu.Unlock()
+		<-release
+		return "Dev is halfway through.", nil
+	}
+
+	retry := func() int {
+		rec := httptest.NewRecorder()
+		srv.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/pm/pending/retry", nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+		}
+		var body map[string]int
+		_ = json.Unmarshal(rec.Body.Bytes(), &body)
+		return body["queued"]
+	}
+	if queued := retry(); queued != 1 {
+		t.Fatalf("expected the message queued, got %d", queued)
+	}
+	if queued := retry(); queued != 0 {
+		t.Errorf("expected a message already queued to be skipped, got %d", queued)
+	}
+	close(release)
+
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(10 * time.Millisecond) {
+		if pending, _ := srv.store.GetPendingPMResponses(); len(pending) == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("expected the pending message to be answered")
+		}
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if calls != 1 {
+		t.Errorf("expected one PM response, got %d", calls)
+	}
+}
+
+// gitIn runs git in dir, failing the test on error.
+func gitIn(t *testing.T, dir string, args ...string) {
+	t.Helper()
+
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %v failed: %v\n%s", args, err, output)
+	}
+}
+
+func TestChangedFilesAggregatesIterationTickets(t *testing.T) {
+	srv := newTestServer(t)
+	repo := t.TempDir()
+	srv.orchRepoRoot = repo
+
+	gitIn(t, repo, "init", "-q", "-b", "main")
+	gitIn(t, repo, "config", "user.name", "Test Dev")
+	gitIn(t, repo, "config", "user.email", "dev@example.com")
+	gitIn(t, repo, "commit", "-q", "--allow-empty", "-m", "Initial commit")
+
+	// Each ticket's branch touches its own file plus a shared one
+	branchWith := func(id, title string, files ...string) string {
+		branch := git.GenerateBranchName(srv.store.GetConfig().BranchPrefix, id, title)
+		gitIn(t, repo, "checkout", "-q", "-b", branch, "main")
+		for _, f := range files {
+			if err := os.MkdirAll(filepath.Join(repo, filepath.Dir(f)), 0o755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(filepath.Join(repo, f), []byte(id+"\n"), 0o600); err != nil {
+				t.Fatal(err)
+			}
+		}
+		gitIn(t, repo, "add", "-A")
+		gitIn(t, repo, "commit", "-q", "-m", "Work on "+id)
+		gitIn(t, repo, "checkout", "-q", "main")
+		return branch
+	}
+	tickets := []struct {
+		id, title string
+		status    kanban.Status
+		files     []string
+	}{
+		{"T-1", "Login form", kanban.StatusDone, []string{"web/login.go", "web/routes.go"}},
+		{"T-2", "Session store", kanban.StatusDone, []string{"db/session.go", "web/routes.go"}},
+		{"T-3", "Still in progress", kanban.StatusInDev, []string{"web/wip.go"}},
+	}
+	srv.store.SetIteration(&kanban.Iteration{ID: "sprint-1", StartedAt: time.Now().Add(-time.Hour)})
+	branches := make(map[string]string)
+	for _, tc := range tickets {
+		branches[tc.id] = branchWith(tc.id, tc.title, tc.files...)
+		if err := srv.store.CreateTicket(&kanban.Ticket{ID: tc.id, Title: tc.title, Status: kanban.StatusReady, CreatedAt: time.Now(), UpdatedAt: time.Now()}); err != nil {
+			t.Fatalf("failed to create ticket: %v", err)
+		}
+		if err := srv.store.UpdateTicketStatus(tc.id, tc.status, "test", ""); err != nil {
+			t.Fatalf("failed to update status: %v", err)
+		}
+	}
+
+	// T-2 was squash-merged and its branch deleted
+	gitIn(t, repo, "merge", "-q", "--squash", branches["T-2"])
+	gitIn(t, repo, "commit", "-q", "-m", "feat(backend): Session store\n\nTicket: T-2")
+	gitIn(t, repo, "branch", "-q", "-D", branches["T-2"])
+
+	req := httptest.NewRequest(http.MethodGet, "/api/reports/changed-files?iteration=sprint-1", nil)
+	rec := httptest.NewRecorder()
+	srv.apiGetChangedFiles(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var report struct {
+		Files   []string             `json:"files"`
+		Tickets []ticketChangedFiles `json:"tickets"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &report); err != nil {
+		t.Fatalf("failed to decode report: %v", err)
+	}
+
+	wantFiles := []string{"db/session.go", "web/login.go", "web/routes.go"}
+	if strings.Join(report.Files, ",") != strings.Join(wantFiles, ",") {
+		t.Errorf("expected files %v, got %v", wantFiles, report.Files)
+	}
+
+	wantByTicket := map[string]string{
+		"T-1": "web/login.go,web/routes.go",
+		"T-2": "db/session.go,web/routes.go",
+	}
+	if len(report.Tickets) != len(wantByTicket) {
+		t.Fatalf("expected %d tickets, got %+v", len(wantByTicket), report.Tickets)
+	}
+	for _, entry := range report.Tickets {
+		if got := strings.Join(entry.Files, ","); got != wantByTicket[entry.TicketID] || entry.Error != "" {
+			t.Errorf("%s: expected files %s, got %s (error %q)", entry.TicketID, wantByTicket[entry.TicketID], got, entry.Error)
+		}
+	}
+}
+
+func TestPublicStatusPageRendersWithoutToken(t *testing.T) {
+	srv := newTestServer(t)
+
+	srv.store.SetIteration(&kanban.Iteration{ID: "sprint-1", Goal: "Ship self-serve signup"})
+	for _, ticket := range []kanban.Ticket{
+		{ID: "T-1", Title: "Secret internal migration", Status: kanban.StatusInDev},
+		{ID: "T-2", Title: "Another private ticket", Status: kanban.StatusInDev},
+		{ID: "T-3", Title: "Done work", Status: kanban.StatusDone},
+	} {
+		ticket.CreatedAt, ticket.UpdatedAt = time.Now(), time.Now()
+		if err := srv.store.CreateTicket(&ticket); err != nil {
+			t.Fatalf("failed to create ticket: %v", err)
+		}
+	}
+
+	// No Authorization header or cookie
+	req := httptest.NewRequest(http.MethodGet, "/status", nil)
+	rec := httptest.NewRecorder()
+	srv.routes().ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	tickets, _ := srv.store.GetAllTickets()
+	body := rec.Body.String()
+	for _, want := range []string{"In Dev: 2", "Done: 1", "Ship self-serve signup", kanban.ComputeSystemHealth(tickets, kanban.DefaultHealthConfig()).StatusLabel} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected status page to contain %q", want)
+		}
+	}
+	for _, leak := range []string{"T-1", "Secret internal migration", "hx-post"} {
+		if strings.Contains(body, leak) {
+			t.Errorf("status page must not expose %q", leak)
+		}
+	}
+}
+
+func TestTicketTranscriptListsMessagesInOrder(t *testing.T) {
+	srv := newTestServer(t)
+
+	base := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	if err := srv.store.CreateTicket(&kanban.Ticket{ID: "T-1", Title: "Login form", Status: kanban.StatusDone, CreatedAt: base, UpdatedAt: base}); err != nil {
+		t.Fatalf("failed to create ticket: %v", err)
+	}
+	convs := []kanban.TicketConversation{
+		{ID: "conv-review", TicketID: "T-1", ThreadType: kanban.ThreadTypeQASignoff, Title: "QA review", Status: kanban.ThreadStatusResolved, CreatedAt: base.Add(time.Hour)},
+		{ID: "conv-dev", TicketID: "T-1", ThreadType: kanban.ThreadTypeDevDiscussion, Title: "Implementation", Status: kanban.ThreadStatusOpen, CreatedAt: base},
+	}
+	for i := range convs {
+		if err := srv.store.CreateConversation(&convs[i]); err != nil {
+			t.Fatalf("failed to create conversation: %v", err)
+		}
+	}
+
+	report, _ := json.MarshalIndent(kanban.SignoffReport{
+		Status:           "APPROVED",
+		Agent:            "qa",
+		Summary:          "Login works end to end",
+		CriteriaVerified: []string{"Rejects empty passwords"},
+	}, "", "  ")
+	messages := []kanban.ConversationMessage{
+		{ID: "m-3", ConversationID: "conv-review", Agent: "qa", MessageType: kanban.MessageTypeSignoffReport, Content: string(report), CreatedAt: base.Add(2 * time.Hour)},
+		{ID: "m-2", ConversationID: "conv-dev", Agent: "pm", MessageType: kanban.MessageTypeResponse, Content: "Yes, show an inline error.", CreatedAt: base.Add(10 * time.Minute)},
+		{ID: "m-1", ConversationID: "conv-dev", Agent: "dev-frontend", MessageType: kanban.MessageTypeQuestion, Content: "Should empty passwords be rejected client-side?", CreatedAt: base.Add(5 * time.Minute)},
+	}
+	for i := range messages {
+		if err := srv.store.AddConversationMessage(&messages[i]); err != nil {
+			t.Fatalf("failed to add message: %v", err)
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/tickets/T-1/transcript", nil)
+	rec := httptest.NewRecorder()
+	srv.routes().ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	body := rec.Body.String()
+
+	// Threads and messages appear chronologically with their agents
+	ordered := []string{
+		"## Implementation",
+		"[2026-03-01 09:05:00 UTC] dev-frontend",
+		"Should empty passwords be rejected client-side?",
+		"[2026-03-01 09:10:00 UTC] pm",
+		"Yes, show an inline error.",
+		"## QA review",
+		"[2026-03-01 11:00:00 UTC] qa",
+		"Sign-off: **APPROVED**",
+		"- Rejects empty passwords",
+	}
+	last := -1
+	for _, want := range ordered {
+		idx := strings.Index(body, want)
+		if idx < 0 {
+			t.Fatalf("transcript missing %q:\n%s", want, body)
+		}
+		if idx < last {
+			t.Errorf("expected %q to appear later in the transcript:\n%s", want, body)
+		}
+		last = idx
+	}
+	if strings.Contains(body, `"criteria_verified"`) {
+		t.Errorf("sign-off report should not be rendered as raw JSON:\n%s", body)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/tickets/T-404/transcript", nil)
+	rec = httptest.NewRecorder()
+	srv.routes().ServeHTTP(rec, req)
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for missing ticket, got %d", rec.Code)
+	}
+}
+
+func TestCreateTicketValidatesPriority(t *testing.T) {
+	srv := newTestServer(t)
+
+	create := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/tickets", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		srv.apiCreateTicket(rec, req)
+		return rec
+	}
+
+	// Omitted priority gets the default
+	rec := create(`{"title": "No priority"}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var ticket kanban.Ticket
+	if err := json.Unmarshal(rec.Body.Bytes(), &ticket); err != nil {
+		t.Fatalf("failed to decode ticket: %v", err)
+	}
+	if ticket.Priority != kanban.PriorityMedium {
+		t.Errorf("expected default priority %d, got %d", kanban.PriorityMedium, ticket.Priority)
+	}
+
+	// The default is configurable
+	if err := srv.store.SetConfig("default_priority", "2"); err != nil {
+		t.Fatalf("failed to set default priority: %v", err)
+	}
+	rec = create(`{"title": "Zero priority", "priority": 0}`)
+	if err := json.Unmarshal(rec.Body.Bytes(), &ticket); err != nil {
+		t.Fatalf("failed to decode ticket: %v", err)
+	}
+	if ticket.Priority != kanban.PriorityHigh {
+		t.Errorf("expected configured default priority %d, got %d", kanban.PriorityHigh, ticket.Priority)
+	}
+
+	if rec := create(`{"title": "Out of range", "priority": 5}`); rec.Code != http.StatusUnprocessableEntity {
+		t.Errorf("expected 422 for priority 5, got %d", rec.Code)
+	}
+}
+
+// readSSEEvents connects to the event stream with the given Last-Event-ID and
+// returns the first n events after the connection message, as "id:name".
+func readSSEEvents(t *testing.T, url, lastEventID string, n int) []string {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, url+"/api/events", nil)
+	req.Header.Set("Last-Event-ID", lastEventID)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var events []string
+	id := ""
+	scanner := bufio.NewScanner(resp.Body)
+	for len(events) < n && scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "id: "):
+			id = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "event: "):
+			if name := strings.TrimPrefix(line, "event: "); name != "connected" {
+				events = append(events, id+":"+name)
+			}
+			id = ""
+		}
+	}
+	return events
+}
+
+func TestSSEHistoryEvictsOldEventsAndResyncsStaleClients(t *testing.T) {
+	srv := newTestServer(t)
+	if err := srv.store.SetConfig("sse_history_size", "3"); err != nil {
+		t.Fatalf("failed to set history size: %v", err)
+	}
+
+	for i := 1; i <= 5; i++ {
+		srv.Broadcast(fmt.Sprintf("update-%d", i))
+	}
+
+	// Only the newest three events are held
+	events, ok := srv.eventHistory().since(0)
+	if ok {
+		t.Errorf("expected replay from the start to be impossible after eviction, got %+v", events)
+	}
+	events, ok = srv.eventHistory().since(2)
+	if !ok || len(events) != 3 || events[0].ID != 3 || events[2].ID != 5 {
+		t.Fatalf("expected events 3-5 to be held, got %+v (ok=%v)", events, ok)
+	}
+
+	ts := httptest.NewServer(srv.routes())
+	defer ts.Close()
+
+	// A client that missed events still in the buffer is caught up
+	if got := readSSEEvents(t, ts.URL, "3", 2); strings.Join(got, ",") != "4:update-4,5:update-5" {
+		t.Errorf("expected replay of events 4 and 5, got %v", got)
+	}
+
+	// A client that missed evicted events is told to refetch
+	if got := readSSEEvents(t, ts.URL, "1", 1); len(got) != 1 || got[0] != ":resync" {
+		t.Errorf("expected a resync event, got %v", got)
+	}
+}
+
+// recordingProvider is a provider that records requests and replies with fixed content.
+type recordingProvider struct {
+	provider.BaseProvider
+	name     string
+	requests []*provider.MessageRequest
+}
+
+func (p *recordingProvider) Name() string    { return p.name }
+func (p *recordingProvider) Available() bool { return true }
+
+func (p *recordingProvider) CreateMessage(ctx context.Context, req *provider.MessageRequest) (*provider.MessageResponse, error) {
+	p.requests = append(p.requests, req)
+	return &provider.MessageResponse{Content: "Reply from " + p.name, Model: req.Model}, nil
+}
+
+func TestPMChatUsesConfiguredProvider(t *testing.T) {
+	srv := newTestServer(t)
+	openai := &recordingProvider{name: "openai"}
+	srv.providers.Register(openai)
+
+	// Chat is configured separately from the PM pipeline agent
+	if err := srv.store.SetAgentProviderConfig("pm-chat", "openai", provider.ModelOpenAIGPT35Turbo); err != nil {
+		t.Fatalf("failed to configure pm-chat: %v", err)
+	}
+
+	ticket := &kanban.Ticket{ID: "T-1", Title: "Chat ticket", Status: kanban.StatusInDev}
+	reply, err := srv.callProviderForPMResponse(ticket, "user: hi\n", "What's the status?")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if reply != "Reply from openai" {
+		t.Errorf("expected the configured provider's reply, got %q", reply)
+	}
+	if len(openai.requests) != 1 {
+		t.Fatalf("expected 1 request to the configured provider, got %d", len(openai.requests))
+	}
+	req := openai.requests[0]
+	if req.Model != provider.ModelOpenAIGPT35Turbo {
+		t.Errorf("expected model %s, got %s", provider.ModelOpenAIGPT35Turbo, req.Model)
+	}
+	if len(req.Messages) != 1 || req.Messages[0].Content != "What's the status?" {
+		t.Errorf("expected the user's message to be sent, got %+v", req.Messages)
+	}
+}
+
+// healthProvider is a provider whose requests succeed or fail with a fixed error.
+type healthProvider struct {
+	provider.BaseProvider
+	name      string
+	available bool
+	err       error
+	calls     int
+}
+
+func (p *healthProvider) Name() string    { return p.name }
+func (p *healthProvider) Available() bool { return p.available }
+
+func (p *healthProvider) CreateMessage(ctx context.Context, req *provider.MessageRequest) (*provider.MessageResponse, error) {
+	p.calls++
+	if p.err != nil {
+		return nil, p.err
+	}
+	return &provider.MessageResponse{Content: "p", Model: req.Model}, nil
+}
+
+func TestProviderHealthReportsKeyStatusAndCaches(t *testing.T) {
+	srv := newTestServer(t)
+	anthropic := &healthProvider{name: "anthropic", available: true}
+	openai := &healthProvider{name: "openai", available: true,
+		err: errors.New(`OpenAI API error (status 401): {"error":"invalid key"}`)}
+	google := &healthProvider{name: "google"}
+	for _, p := range []*healthProvider{anthropic, openai, google} {
+		srv.providers.Register(p)
+	}
+
+	getHealth := func() map[string]provider.Health {
+		rec := httptest.NewRecorder()
+		srv.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/providers/health", nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+		}
+		var results []provider.Health
+		if err := json.NewDecoder(rec.Body).Decode(&results); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		byName := make(map[string]provider.Health, len(results))
+		for _, h := range results {
+			byName[h.Provider] = h
+		}
+		return byName
+	}
+
+	health := getHealth()
+	want := map[string]provider.HealthStatus{
+		"anthropic": provider.HealthReachable,
+		"openai":    provider.HealthUnauthorized,
+		"google":    provider.HealthNotConfigured,
+	}
+	for name, status := range want {
+		if health[name].Status != status {
+			t.Errorf("expected %s to be %s, got %s", name, status, health[name].Status)
+		}
+		if health[name].CheckedAt.IsZero() {
+			t.Errorf("expected %s to have a check time", name)
+		}
+	}
+	if health["openai"].HTTPStatus != http.StatusUnauthorized {
+		t.Errorf("expected openai HTTP status 401, got %d", health["openai"].HTTPStatus)
+	}
+
+	// A second request within the TTL reuses the cached results
+	getHealth()
+	if anthropic.calls != 1 || openai.calls != 1 {
+		t.Errorf("expected one validation call per provider, got anthropic=%d openai=%d", anthropic.calls, openai.calls)
+	}
+}
+
+func TestPMChatRequestUsesConfiguredGenerationParams(t *testing.T) {
+	srv := newTestServer(t)
+	openai := &recordingProvider{name: "openai"}
+	srv.providers.Register(openai)
+
+	body := `{"configs": [{"agent_type": "pm-chat", "provider": "openai", "model": "` + provider.ModelOpenAIGPT4o + `", "temperature": 0.2, "max_tokens": 1200}]}`
+	rec := httptest.NewRecorder()
+	srv.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodPatch, "/api/settings/providers", strings.NewReader(body)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	ticket := &kanban.Ticket{ID: "T-1", Title: "Chat ticket", Status: kanban.StatusInDev}
+	srv.callProviderForPMResponse(ticket, "", "Any update?")
+
+	if len(openai.requests) != 1 {
+		t.Fatalf("expected 1 request, got %d", len(openai.requests))
+	}
+	req := openai.requests[0]
+	if req.Temperature == nil || *req.Temperature != 0.2 {
+		t.Errorf("expected temperature 0.2, got %v", req.Temperature)
+	}
+	if req.MaxTokens != 1200 {
+		t.Errorf("expected max tokens 1200, got %d", req.MaxTokens)
+	}
+
+	// Out-of-range values are rejected
+	body = `{"configs": [{"agent_type": "security", "provider": "anthropic", "temperature": 1.5}]}`
+	rec = httptest.NewRecorder()
+	srv.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodPatch, "/api/settings/providers", strings.NewReader(body)))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an Anthropic temperature above 1, got %d", rec.Code)
+	}
+}
+
+func TestAnsweringLastQuestionAutoPromotesWhenEnabled(t *testing.T) {
+	for _, enabled := range []bool{true, false} {
+		t.Run(fmt.Sprintf("enabled=%v", enabled), func(t *testing.T) {
+			srv := newTestServer(t)
+			if enabled {
+				if err := srv.store.SetConfig("auto_promote_answered", "true"); err != nil {
+					t.Fatalf("failed to enable auto-promotion: %v", err)
+				}
+			}
+
+			ticket := &kanban.Ticket{
+				ID:     "T-1",
+				Title:  "Needs answers",
+				Status: kanban.StatusAwaitingUser,
+				Requirements: &kanban.Requirements{Questions: []kanban.Question{
+					{Question: "Which database?", Answer: "SQLite"},
+					{Question: "Who are the users?"},
+				}},
+				CreatedAt: time.Now(),
+				UpdatedAt: time.Now(),
+			}
+			if err := srv.store.CreateTicket(ticket); err != nil {
+				t.Fatalf("failed to create ticket: %v", err)
+			}
+
+			rec := httptest.NewRecorder()
+			body := strings.NewReader(`{"questionIndex": 1, "answer": "Internal developers"}`)
+			srv.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/tickets/T-1/answer", body))
+			if rec.Code != http.StatusOK {
+				t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+			}
+			var resp struct {
+				Status               kanban.Status `json:"status"`
+				AllQuestionsAnswered bool          `json:"allQuestionsAnswered"`
+			}
+			if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if !resp.AllQuestionsAnswered {
+				t.Error("expected the response to flag all questions as answered")
+			}
+
+			want := kanban.StatusAwaitingUser
+			if enabled {
+				want = kanban.StatusReady
+			}
+			got, _ := srv.store.GetTicket("T-1")
+			if got.Status != want || resp.Status != want {
+				t.Errorf("expected status %s, got %s (response %s)", want, got.Status, resp.Status)
+			}
+		})
+	}
+}
+
+func TestWorktreeSlotsListPoolHoldersByActivity(t *testing.T) {
+	srv := newTestServer(t)
+	if err := srv.store.SetConfig("max_global_worktrees", "4"); err != nil {
+		t.Fatalf("failed to set limit: %v", err)
+	}
+	now := time.Now()
+
+	entries := []struct {
+		ticket kanban.Ticket
+		status kanban.WorktreePoolStatus
+		idle   time.Duration
+	}{
+		{kanban.Ticket{ID: "T-1", Title: "Recent work", Status: kanban.StatusInDev}, kanban.WorktreePoolStatusActive, time.Minute},
+		{kanban.Ticket{ID: "T-2", Title: "Stalled work", Status: kanban.StatusInQA}, kanban.WorktreePoolStatusActive, 3 * time.Hour},
+		{kanban.Ticket{ID: "T-3", Title: "Merging", Status: kanban.StatusDone}, kanban.WorktreePoolStatusMerging, time.Hour},
+		{kanban.Ticket{ID: "T-4", Title: "Cleaned up", Status: kanban.StatusDone}, kanban.WorktreePoolStatusCleanupPending, 5 * time.Hour},
+	}
+	for _, e := range entries {
+		ticket := e.ticket
+		ticket.CreatedAt, ticket.UpdatedAt = now, now
+		if err := srv.store.CreateTicket(&ticket); err != nil {
+			t.Fatalf("failed to create ticket: %v", err)
+		}
+		if err := srv.store.RegisterWorktree(kanban.WorktreePoolEntry{
+			ID: "wt-" + ticket.ID, TicketID: ticket.ID, Branch: "feat/" + ticket.ID, Path: "/tmp/" + ticket.ID,
+			Agent: "dev-backend", Status: e.status, CreatedAt: now.Add(-e.idle), LastActivity: now.Add(-e.idle),
+		}); err != nil {
+			t.Fatalf("failed to register worktree: %v", err)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	srv.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/worktrees/slots", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var resp struct {
+		Slots          []WorktreeSlot `json:"slots"`
+		AvailableSlots int            `json:"availableSlots"`
+		Limit          int            `json:"limit"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+
+	// Active and merging slots only, most stalled first
+	var got []string
+	for _, slot := range resp.Slots {
+		got = append(got, slot.TicketID)
+	}
+	if strings.Join(got, ",") != "T-2,T-3,T-1" {
+		t.Errorf("expected slots T-2,T-3,T-1, got %v", got)
+	}
+	if len(resp.Slots) > 0 {
+		stalled := resp.Slots[0]
+		if stalled.Title != "Stalled work" || stalled.Status != kanban.StatusInQA || stalled.Agent != "dev-backend" {
+			t.Errorf("expected slot details from the ticket and pool, got %+v", stalled)
+		}
+	}
+	if resp.Limit != 4 || resp.AvailableSlots != 1 {
+		t.Errorf("expected 1 of 4 slots available, got %d of %d", resp.AvailableSlots, resp.Limit)
+	}
+}
+
+func TestTicketFullIncludesAllRelatedEntities(t *testing.T) {
+	srv := newTestServer(t)
+	store := srv.store
+	now := time.Now()
+
+	if err := store.CreateTicket(&kanban.Ticket{ID: "T-1", Title: "Login form", Status: kanban.StatusInDev, CreatedAt: now, UpdatedAt: now}); err != nil {
+		t.Fatalf("failed to create ticket: %v", err)
+	}
+	if err := store.CreateConversation(&kanban.TicketConversation{ID: "conv-1", TicketID: "T-1", ThreadType: kanban.ThreadTypeDevDiscussion, Title: "Implementation", Status: kanban.ThreadStatusOpen, CreatedAt: now}); err != nil {
+		t.Fatalf("failed to create conversation: %v", err)
+	}
+	if err := store.AddConversationMessage(&kanban.ConversationMessage{ID: "m-1", ConversationID: "conv-1", Agent: "dev-frontend", MessageType: kanban.MessageTypeQuestion, Content: "Client-side validation?", CreatedAt: now}); err != nil {
+		t.Fatalf("failed to add message: %v", err)
+	}
+	if err := store.AddPMCheckin(&kanban.PMCheckin{ID: "c-1", TicketID: "T-1", ConversationID: "conv-1", CheckinType: kanban.CheckinTypeProgress, Summary: "On track", CreatedAt: now}); err != nil {
+		t.Fatalf("failed to add check-in: %v", err)
+	}
+	if err := store.AddRun(&kanban.AgentRun{ID: "run-1", Agent: "dev-frontend", TicketID: "T-1", StartedAt: now.Add(-time.Hour), Status: "running"}); err != nil {
+		t.Fatalf("failed to add run: %v", err)
+	}
+	if err := store.CreateADR(&kanban.ADR{ID: "ADR-001", Title: "Use server-side sessions", Status: kanban.ADRStatusAccepted, CreatedAt: now, UpdatedAt: now}); err != nil {
+		t.Fatalf("failed to create ADR: %v", err)
+	}
+	if err := store.LinkADRToTicket("ADR-001", "T-1"); err != nil {
+		t.Fatalf("failed to link ADR: %v", err)
+	}
+	if err := store.CreateTag(&kanban.Tag{ID: "tag-1", Name: "Auth Refactor", Type: kanban.TagTypeEpic}); err != nil {
+		t.Fatalf("failed to create tag: %v", err)
+	}
+	if err := store.AddTagToTicket("T-1", "tag-1"); err != nil {
+		t.Fatalf("failed to tag ticket: %v", err)
+	}
+	if err := store.LogWorktreeEvent(kanban.WorktreeEvent{ID: "we-1", TicketID: "T-1", EventType: kanban.WorktreeEventCreated, CreatedAt: now}); err != nil {
+		t.Fatalf("failed to log worktree event: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	srv.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/tickets/T-1/full", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var detail TicketDetail
+	if err := json.NewDecoder(rec.Body).Decode(&detail); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+
+	if detail.Ticket == nil || detail.Ticket.ID != "T-1" {
+		t.Fatalf("expected ticket T-1, got %+v", detail.Ticket)
+	}
+	if len(detail.Conversations) != 1 || len(detail.Conversations[0].Messages) != 1 {
+		t.Errorf("expected one conversation with its message, got %+v", detail.Conversations)
+	}
+	if len(detail.PMCheckins) != 1 {
+		t.Errorf("expected one check-in, got %d", len(detail.PMCheckins))
+	}
+	if detail.TimeStats == nil || detail.TimeStats.AgentRunCount != 1 {
+		t.Errorf("expected time stats covering one run, got %+v", detail.TimeStats)
+	}
+	if len(detail.AgentRuns) != 1 {
+		t.Errorf("expected one agent run, got %d", len(detail.AgentRuns))
+	}
+	if len(detail.ADRs) != 1 || detail.ADRs[0].ID != "ADR-001" {
+		t.Errorf("expected linked ADR-001, got %+v", detail.ADRs)
+	}
+	if len(detail.Tags) != 1 || len(detail.Ticket.Tags) != 1 {
+		t.Errorf("expected one tag on the detail and ticket, got %+v / %+v", detail.Tags, detail.Ticket.Tags)
+	}
+	if len(detail.WorktreeEvents) != 1 {
+		t.Errorf("expected one worktree event, got %d", len(detail.WorktreeEvents))
+	}
+
+	rec = httptest.NewRecorder()
+	srv.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/tickets/T-404/full", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for missing ticket, got %d", rec.Code)
+	}
+}
+
+func TestCreateTicketDetectsNearDuplicates(t *testing.T) {
+	srv := newTestServer(t)
+	if err := srv.store.SetConfig("duplicate_threshold", "0.5"); err != nil {
+		t.Fatalf("failed to set threshold: %v", err)
+	}
+	now := time.Now()
+	if err := srv.store.CreateTicket(&kanban.Ticket{ID: "T-1", Title: "Login page crashes on empty password", Status: kanban.StatusReady, CreatedAt: now, UpdatedAt: now}); err != nil {
+		t.Fatalf("failed to create ticket: %v", err)
+	}
+
+	create := func(title string) (int, duplicateResponse) {
+		rec := httptest.NewRecorder()
+		body := strings.NewReader(fmt.Sprintf(`{"title": %q}`, title))
+		srv.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/tickets", body))
+		var resp duplicateResponse
+		if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		return rec.Code, resp
+	}
+
+	// By default the existing ticket comes back instead of a new one
+	code, resp := create("Login page crash on empty password")
+	if code != http.StatusOK || resp.Ticket == nil || resp.ID != "T-1" || resp.DuplicateOf != "T-1" {
+		t.Fatalf("expected existing T-1 with a duplicate hint, got %d %+v", code, resp)
+	}
+	if all, _ := srv.store.GetAllTickets(); len(all) != 1 {
+		t.Errorf("expected no new ticket, got %d tickets", len(all))
+	}
+
+	// Distinct tickets are created as usual
+	if code, resp := create("Export board as CSV"); code != http.StatusCreated || resp.DuplicateOf != "" {
+		t.Errorf("expected a new ticket without a hint, got %d %+v", code, resp)
+	}
+
+	// In link mode the duplicate is created and linked to the original
+	if err := srv.store.SetConfig("duplicate_action", "link"); err != nil {
+		t.Fatalf("failed to set action: %v", err)
+	}
+	code, resp = create("Login page crash on empty password")
+	if code != http.StatusCreated || resp.Ticket == nil || resp.ID == "T-1" || resp.DuplicateOf != "T-1" {
+		t.Fatalf("expected a new linked ticket, got %d %+v", code, resp)
+	}
+	created, _ := srv.store.GetTicket(resp.ID)
+	if created == nil || len(created.Links) != 1 || created.Links[0].Type != kanban.LinkTypeDuplicates || created.Links[0].TicketID != "T-1" {
+		t.Errorf("expected a duplicates link to T-1, got %+v", created)
+	}
+}
+
+func TestReassignAgentMovesTicketsToNewAgent(t *testing.T) {
+	srv := newTestServer(t)
+
+	now := time.Now()
+	for _, ticket := range []*kanban.Ticket{
+		{ID: "T-1", Title: "VPC", Status: kanban.StatusReady, AssignedAgent: "dev-infra"},
+		{ID: "T-2", Title: "DNS", Status: kanban.StatusDone, AssignedAgent: "dev-infra"},
+		{ID: "T-3", Title: "Load balancer", Status: kanban.StatusInDev},
+		{ID: "T-4", Title: "API", Status: kanban.StatusInDev, AssignedAgent: "dev-backend"},
+	} {
+		ticket.CreatedAt, ticket.UpdatedAt = now, now
+		if err := srv.store.CreateTicket(ticket); err != nil {
+			t.Fatalf("failed to create ticket: %v", err)
+		}
+	}
+	if err := srv.store.AddRun(&kanban.AgentRun{ID: "run-1", Agent: "dev-infra", TicketID: "T-3", StartedAt: now, Status: "running"}); err != nil {
+		t.Fatalf("failed to add run: %v", err)
+	}
+
+	reassign := func(body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		srv.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/agents/reassign", strings.NewReader(body)))
+		return rec
+	}
+
+	if rec := reassign(`{"from":"dev-infra","to":"dev-plumbing"}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an unknown agent type, got %d", rec.Code)
+	}
+
+	rec := reassign(`{"from":"dev-infra","to":"dev-backend"}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var resp struct {
+		Tickets []string `json:"tickets"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if strings.Join(resp.Tickets, ",") != "T-1,T-3" {
+		t.Errorf("expected T-1 and T-3 reassigned, got %v", resp.Tickets)
+	}
+
+	want := map[string]string{"T-1": "dev-backend", "T-2": "dev-infra", "T-3": "dev-backend", "T-4": "dev-backend"}
+	for id, agent := range want {
+		ticket, _ := srv.store.GetTicket(id)
+		if ticket.AssignedAgent != agent {
+			t.Errorf("%s: expected assigned agent %s, got %q", id, agent, ticket.AssignedAgent)
+		}
+	}
+}
+
+func TestSimulateHealthAppliesOverridesWithoutMutating(t *testing.T) {
+	srv := newTestServer(t)
+
+	now := time.Now()
+	for _, id := range []string{"T-1", "T-2", "T-3", "T-4"} {
+		if err := srv.store.CreateTicket(&kanban.Ticket{ID: id, Title: id, Status: kanban.StatusInDev, CreatedAt: now, UpdatedAt: now}); err != nil {
+			t.Fatalf("failed to create ticket: %v", err)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	body := `{"overrides": {"T-1": "BLOCKED", "T-2": "BLOCKED", "T-3": "BLOCKED"}}`
+	srv.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/health/simulate", strings.NewReader(body)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	var resp struct {
+		Current   kanban.SystemHealth `json:"current"`
+		Simulated kanban.SystemHealth `json:"simulated"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.Current.Status != kanban.SystemHealthStable {
+		t.Errorf("expected current health stable, got %s", resp.Current.Status)
+	}
+	if resp.Simulated.Status != kanban.SystemHealthAccumulating || resp.Simulated.BlockedCount != 3 {
+		t.Errorf("expected simulated health accumulating with 3 blocked, got %s (%d blocked)", resp.Simulated.Status, resp.Simulated.BlockedCount)
+	}
+
+	if ticket, _ := srv.store.GetTicket("T-1"); ticket.Status != kanban.StatusInDev {
+		t.Errorf("expected simulation to leave tickets unchanged, got %s", ticket.Status)
+	}
+
+	rec = httptest.NewRecorder()
+	srv.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/health/simulate", strings.NewReader(`{"overrides": {"T-9": "BLOCKED"}}`)))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an unknown ticket, got %d", rec.Code)
+	}
+}
+
+func TestTimeStatsExportIncludesBranchDiffStats(t *testing.T) {
+	srv := newTestServer(t)
+	repo := t.TempDir()
+	srv.orchRepoRoot = repo
+	if err := srv.store.SetConfig("time_stats_include_git", "true"); err != nil {
+		t.Fatal(err)
+	}
+
+	gitIn(t, repo, "init", "-q", "-b", "main")
+	gitIn(t, repo, "config", "user.name", "Test Dev")
+	gitIn(t, repo, "config", "user.email", "dev@example.com")
+	gitIn(t, repo, "commit", "-q", "--allow-empty", "-m", "Initial commit")
+
+	branch := git.GenerateBranchName(srv.store.GetConfig().BranchPrefix, "T-1", "Login form")
+	gitIn(t, repo, "checkout", "-q", "-b", branch)
+	if err := os.WriteFile(filepath.Join(repo, "login.go"), []byte("package web\n\nfunc Login() {}\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	gitIn(t, repo, "add", "-A")
+	gitIn(t, repo, "commit", "-q", "-m", "Add login")
+	gitIn(t, repo, "checkout", "-q", "main")
+
+	for _, ticket := range []*kanban.Ticket{
+		{ID: "T-1", Title: "Login form", Status: kanban.StatusInDev, CreatedAt: time.Now(), UpdatedAt: time.Now()},
+		{ID: "T-2", Title: "No branch yet", Status: kanban.StatusReady, CreatedAt: time.Now(), UpdatedAt: time.Now()},
+	} {
+		if err := srv.store.CreateTicket(ticket); err != nil {
+			t.Fatalf("failed to create ticket: %v", err)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	srv.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/reports/time-stats.csv", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	rows, err := csv.NewReader(rec.Body).ReadAll()
+	if err != nil {
+		t.Fatalf("invalid CSV: %v", err)
+	}
+	if len(rows) != 3 {
+		t.Fatalf("expected header and 2 rows, got %v", rows)
+	}
+	if got := rows[1][7:]; got[0] != "1" || got[1] != "3" || got[2] != "0" {
+		t.Errorf("expected T-1 to report 1 file, +3/-0, got %v", got)
+	}
+	if got := rows[2][7:]; got[0] != "" || got[1] != "" || got[2] != "" {
+		t.Errorf("expected empty code stats for T-2 without a branch, got %v", got)
+	}
+}
+
+func TestUpdateConfigValidatesAgainstSchema(t *testing.T) {
+	srv := newTestServer(t)
+
+	rec := httptest.NewRecorder()
+	srv.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/config/schema", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var schema []db.ConfigKey
+	if err := json.NewDecoder(rec.Body).Decode(&schema); err != nil {
+		t.Fatalf("invalid schema response: %v", err)
+	}
+	if len(schema) != len(db.ConfigSchema) {
+		t.Errorf("expected %d schema keys, got %d", len(db.ConfigSchema), len(schema))
+	}
+
+	body := `{"max_global_worktrees": "6", "rebase_before_qa": "maybe"}`
+	rec = httptest.NewRecorder()
+	srv.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/api/config", strings.NewReader(body)))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for invalid value, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if v, _ := srv.store.GetConfigValue("max_global_worktrees"); v == "6" {
+		t.Error("expected no values written when any value is invalid")
+	}
+
+	body = `{"max_global_worktrees": "6", "rebase_before_qa": "true"}`
+	rec = httptest.NewRecorder()
+	srv.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/api/config", strings.NewReader(body)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if v, _ := srv.store.GetConfigValue("max_global_worktrees"); v != "6" {
+		t.Errorf("expected max_global_worktrees 6, got %q", v)
+	}
+	if !srv.store.GetConfig().RebaseBeforeQA {
+		t.Error("expected rebase_before_qa to be enabled")
+	}
+}
+
+func TestBulkResolvePMCheckins(t *testing.T) {
+	srv := newTestServer(t)
+	now := time.Now()
+	for _, id := range []string{"T-1", "T-2"} {
+		if err := srv.store.CreateTicket(&kanban.Ticket{ID: id, Title: "Checked in", Status: kanban.StatusInDev, CreatedAt: now, UpdatedAt: now}); err != nil {
+			t.Fatalf("failed to create ticket: %v", err)
+		}
+		conv := &kanban.TicketConversation{ID: "conv-" + id, TicketID: id, ThreadType: kanban.ThreadTypeDevDiscussion, Title: "Check-ins", Status: kanban.ThreadStatusOpen, CreatedAt: now}
+		if err := srv.store.CreateConversation(conv); err != nil {
+			t.Fatalf("failed to create conversation: %v", err)
+		}
+	}
+	for _, c := range []kanban.PMCheckin{
+		{ID: "c-1", TicketID: "T-1", CheckinType: kanban.CheckinTypeProgress},
+		{ID: "c-2", TicketID: "T-1", CheckinType: kanban.CheckinTypeBlocker},
+		{ID: "c-3", TicketID: "T-2", CheckinType: kanban.CheckinTypeProgress},
+		{ID: "c-4", TicketID: "T-2", CheckinType: kanban.CheckinTypeGuidance},
+	} {
+		c.ConversationID, c.Summary, c.CreatedAt = "conv-"+c.TicketID, "Check-in", now
+		if err := srv.store.AddPMCheckin(&c); err != nil {
+			t.Fatalf("failed to add check-in: %v", err)
+		}
+	}
+
+	resolve := func(body string) int {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		srv.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/checkins/resolve", strings.NewReader(body)))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+		}
+		var resp struct {
+			Resolved int `json:"resolved"`
+		}
+		if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+			t.Fatalf("invalid response: %v", err)
+		}
+		return resp.Resolved
+	}
+
+	if n := resolve(`{"ids": ["c-1", "c-3"]}`); n != 2 {
+		t.Errorf("expected 2 resolved by ID, got %d", n)
+	}
+	// c-1 is already resolved, so only c-2 counts
+	if n := resolve(`{"ticketId": "T-1"}`); n != 1 {
+		t.Errorf("expected 1 resolved for T-1, got %d", n)
+	}
+
+	unresolved, err := srv.store.GetUnresolvedPMCheckins()
+	if err != nil {
+		t.Fatalf("failed to get unresolved check-ins: %v", err)
+	}
+	if len(unresolved) != 1 || unresolved[0].ID != "c-4" {
+		t.Errorf("expected only c-4 unresolved, got %+v", unresolved)
+	}
+
+	rec := httptest.NewRecorder()
+	srv.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/checkins/resolve", strings.NewReader(`{}`)))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 without ids or a filter, got %d", rec.Code)
+	}
+}
+
+func TestOverrideFailedQASignoffAdvancesTicket(t *testing.T) {
+	srv := newTestServer(t)
+	now := time.Now()
+	ticket := &kanban.Ticket{ID: "T-1", Title: "Flaky QA", Status: kanban.StatusBlocked, CreatedAt: now, UpdatedAt: now,
+		History: []kanban.HistoryEntry{
+			{Status: kanban.StatusInQA, By: "dev-backend", At: now.Add(-time.Hour)},
+			{Status: kanban.StatusBlocked, By: "qa", Note: "qa run failed: browser crashed", At: now},
+		}}
+	if err := srv.store.CreateTicket(ticket); err != nil {
+		t.Fatalf("failed to create ticket: %v", err)
+	}
+
+	override := func(body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		srv.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/tickets/T-1/override-signoff", strings.NewReader(body)))
+		return rec
+	}
+
+	if rec := override(`{"stage": "qa", "rationale": "   "}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 without a rationale, got %d", rec.Code)
+	}
+	if rec := override(`{"stage": "dev", "rationale": "Looks fine"}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for a non-review stage, got %d", rec.Code)
+	}
+	if err := srv.store.UpdateTicketStatus("T-1", kanban.StatusInDev, "dev-backend", "Reworking"); err != nil {
+		t.Fatalf("failed to move ticket: %v", err)
+	}
+	if rec := override(`{"stage": "qa", "rationale": "Looks fine"}`); rec.Code != http.StatusConflict {
+		t.Errorf("expected 409 for a ticket still in dev, got %d", rec.Code)
+	}
+	if err := srv.store.UpdateTicketStatus("T-1", kanban.StatusBlocked, "qa", "qa run failed: browser crashed"); err != nil {
+		t.Fatalf("failed to move ticket: %v", err)
+	}
+
+	rationale := "Verified manually; the failure is a CI browser crash"
+	if rec := override(`{"stage": "qa", "rationale": "` + rationale + `", "overriddenBy": "alice"}`); rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	updated, _ := srv.store.GetTicket("T-1")
+	if updated.Status != kanban.StatusInUX {
+		t.Errorf("expected ticket to advance to IN_UX, got %s", updated.Status)
+	}
+	if !updated.Signoffs.QA {
+		t.Error("expected QA sign-off to be marked satisfied")
+	}
+	last := updated.History[len(updated.History)-1]
+	if last.By != "alice" || !strings.Contains(last.Note, rationale) {
+		t.Errorf("expected history to record the rationale, got %+v", last)
+	}
+
+	convs, err := srv.store.GetConversationsByTicket("T-1")
+	if err != nil {
+		t.Fatalf("failed to get conversations: %v", err)
+	}
+	if len(convs) != 1 || convs[0].ThreadType != kanban.ThreadTypeQASignoff {
+		t.Fatalf("expected one QA sign-off thread, got %+v", convs)
+	}
+	msgs, err := srv.store.GetConversationMessages(convs[0].ID)
+	if err != nil {
+		t.Fatalf("failed to get messages: %v", err)
+	}
+	if len(msgs) != 1 || msgs[0].Content != rationale {
+		t.Errorf("expected the rationale as the thread message, got %+v", msgs)
+	}
+}
+
+func TestConcurrentTicketUpdateIsRejectedAsConflict(t *testing.T) {
+	srv := newTestServer(t)
+	now := time.Now()
+	if err := srv.store.CreateTicket(&kanban.Ticket{ID: "T-1", Title: "Original", Status: kanban.StatusBacklog, CreatedAt: now, UpdatedAt: now}); err != nil {
+		t.Fatalf("failed to create ticket: %v", err)
+	}
+	loaded, _ := srv.store.GetTicket("T-1")
+
+	// Both clients loaded the same version; the first save wins
+	update := func(body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		srv.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodPatch, "/api/tickets/T-1", strings.NewReader(body)))
+		return rec
+	}
+	first := update(fmt.Sprintf(`{"title": "First edit", "version": %d}`, loaded.Version))
+	if first.Code != http.StatusOK {
+		t.Fatalf("expected 200 for the first update, got %d: %s", first.Code, first.Body.String())
+	}
+	var saved kanban.Ticket
+	if err := json.NewDecoder(first.Body).Decode(&saved); err != nil {
+		t.Fatalf("invalid response: %v", err)
+	}
+	if saved.Version <= loaded.Version {
+		t.Errorf("expected the version to advance past %d, got %d", loaded.Version, saved.Version)
+	}
+
+	second := update(fmt.Sprintf(`{"notes": "Second edit", "version": %d}`, loaded.Version))
+	if second.Code != http.StatusConflict {
+		t.Fatalf("expected 409 for the stale update, got %d: %s", second.Code, second.Body.String())
+	}
+	got, _ := srv.store.GetTicket("T-1")
+	if got.Title != "First edit" || got.Notes != "" {
+		t.Errorf("expected only the first update to be stored, got title %q notes %q", got.Title, got.Notes)
+	}
+
+	// Refetching picks up the current version and the retry goes through
+	if retry := update(fmt.Sprintf(`{"notes": "Second edit", "version": %d}`, got.Version)); retry.Code != http.StatusOK {
+		t.Errorf("expected 200 after refetching, got %d: %s", retry.Code, retry.Body.String())
+	}
+}
+
+func TestCostsEndpointTotalsTicketTokens(t *testing.T) {
+	srv := newTestServer(t)
+	now := time.Now()
+	if err := srv.store.CreateTicket(&kanban.Ticket{ID: "T-1", Title: "Costed", Status: kanban.StatusInDev, CreatedAt: now, UpdatedAt: now}); err != nil {
+		t.Fatalf("failed to create ticket: %v", err)
+	}
+	if err := srv.store.AddRun(&kanban.AgentRun{ID: "run-1", Agent: "qa", TicketID: "T-1", StartedAt: now, Status: "running"}); err != nil {
+		t.Fatalf("failed to add run: %v", err)
+	}
+	srv.store.SetRunMetadata("run-1", kanban.RunMetadata{Model: provider.ModelOpenAIGPT4o})
+	if err := srv.store.AddAuditEntry(&kanban.AuditEntry{ID: "a1", RunID: "run-1", TicketID: "T-1", Agent: "qa",
+		EventType: kanban.AuditEventResponseReceived, TokenInput: 400_000, TokenOutput: 100_000, CreatedAt: now}); err != nil {
+		t.Fatalf("failed to add audit entry: %v", err)
+	}
+
+	get := func(query string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		srv.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/costs"+query, nil))
+		return rec
+	}
+
+	if rec := get(""); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 without a filter, got %d", rec.Code)
+	}
+	if rec := get("?iteration_id=iter-unknown"); rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for an unknown iteration, got %d", rec.Code)
+	}
+
+	rec := get("?ticket_id=T-1")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var cost kanban.CostSummary
+	if err := json.Unmarshal(rec.Body.Bytes(), &cost); err != nil {
+		t.Fatalf("failed to decode costs: %v", err)
+	}
+	// GPT-4o: 400k in at $2.50 and 100k out at $10 per 1M tokens
+	if cost.InputTokens != 400_000 || cost.OutputTokens != 100_000 || math.Abs(cost.EstimatedCost-2.0) > 1e-9 {
+		t.Errorf("expected 400000 in, 100000 out for $2.00, got %+v", cost)
+	}
+}
+
+func TestADRTicketsRollUpLinkedTicketProgress(t *testing.T) {
+	srv := newTestServer(t)
+	store := srv.store
+	now := time.Now()
+
+	tickets := []kanban.Ticket{
+		{ID: "T-1", Title: "Session store", Status: kanban.StatusDone, Domain: kanban.DomainBackend},
+		{ID: "T-2", Title: "Login form", Status: kanban.StatusInQA, Domain: kanban.DomainFrontend},
+		{ID: "T-3", Title: "Logout endpoint", Status: kanban.StatusDone, Domain: kanban.DomainBackend},
+		{ID: "T-4", Title: "Session migration", Status: kanban.StatusReady, Domain: kanban.DomainBackend},
+		{ID: "T-5", Title: "Cookie banner", Status: kanban.StatusAbandoned, Domain: kanban.DomainFrontend},
+		{ID: "T-6", Title: "Unrelated", Status: kanban.StatusDone},
+	}
+	for i := range tickets {
+		tickets[i].CreatedAt, tickets[i].UpdatedAt = now, now
+		if err := store.CreateTicket(&tickets[i]); err != nil {
+			t.Fatalf("failed to create ticket: %v", err)
+		}
+	}
+	if err := store.CreateADR(&kanban.ADR{ID: "ADR-001", Title: "Use server-side sessions", Status: kanban.ADRStatusAccepted, CreatedAt: now, UpdatedAt: now}); err != nil {
+		t.Fatalf("failed to create ADR: %v", err)
+	}
+	for _, id := range []string{"T-1", "T-2", "T-3", "T-4", "T-5"} {
+		if err := store.LinkADRToTicket("ADR-001", id); err != nil {
+			t.Fatalf("failed to link ADR: %v", err)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	srv.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/adrs/ADR-001/tickets", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var rollout kanban.ADRRollout
+	if err := json.NewDecoder(rec.Body).Decode(&rollout); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+
+	if len(rollout.Tickets) != 5 {
+		t.Fatalf("expected the 5 linked tickets, got %+v", rollout.Tickets)
+	}
+	if got := rollout.Tickets[1]; got.ID != "T-2" || got.Title != "Login form" || got.Status != kanban.StatusInQA || got.Domain != kanban.DomainFrontend {
+		t.Errorf("unexpected linked ticket %+v", got)
+	}
+	// 2 of the 4 tickets still in play are done; the abandoned one doesn't count
+	if rollout.Done != 2 || rollout.CompletionPercent != 50 {
+		t.Errorf("expected 2 done for 50%% complete, got %d for %.1f%%", rollout.Done, rollout.CompletionPercent)
+	}
+
+	rec = httptest.NewRecorder()
+	srv.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/adrs/ADR-404/tickets", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("expected 404 for an unknown ADR, got %d", rec.Code)
+	}
+}
+
+func TestSupersedeADRWithExistingIDConflicts(t *testing.T) {
+	srv := newTestServer(t)
+	now := time.Now()
+	for _, id := range []string{"ADR-001", "ADR-002"} {
+		if err := srv.store.CreateADR(&kanban.ADR{ID: id, Title: "Decision " + id, Status: kanban.ADRStatusAccepted, CreatedAt: now, UpdatedAt: now}); err != nil {
+			t.Fatalf("failed to create ADR: %v", err)
+		}
+	}
+
+	supersede := func(body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		srv.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/adrs/ADR-001/supersede", strings.NewReader(body)))
+		return rec
+	}
+	if rec := supersede(`{"id": "ADR-002", "title": "Replacement"}`); rec.Code != http.StatusConflict {
+		t.Errorf("expected 409 for an ID already in use, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if old, _ := srv.store.GetADR("ADR-001"); old.Status != kanban.ADRStatusAccepted {
+		t.Errorf("expected the old ADR untouched after a conflict, got %s", old.Status)
+	}
+
+	if rec := supersede(`{"id": "ADR-003", "title": "Replacement"}`); rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if old, _ := srv.store.GetADR("ADR-001"); old.SupersededBy != "ADR-003" {
+		t.Errorf("expected ADR-001 superseded by ADR-003, got %q", old.SupersededBy)
+	}
+}
+
+func TestBoardColumnsFlagWIPExceeded(t *testing.T) {
+	tickets := []kanban.Ticket{
+		{ID: "T-1", Status: kanban.StatusInDev},
+		{ID: "T-2", Status: kanban.StatusInDev},
+		{ID: "T-3", Status: kanban.StatusInDev},
+		{ID: "T-4", Status: kanban.StatusInQA},
+	}
+	config := kanban.BoardConfig{WIPLimits: map[kanban.Status]int{kanban.StatusInDev: 2, kanban.StatusInQA: 1}}
+
+	for _, column := range groupTicketsByStatus(tickets, config) {
+		switch column.Status {
+		case kanban.StatusInDev:
+			if column.WIPLimit != 2 || !column.WIPExceeded {
+				t.Errorf("expected IN_DEV over its limit of 2, got limit %d exceeded=%v", column.WIPLimit, column.WIPExceeded)
+			}
+		case kanban.StatusInQA:
+			if column.WIPLimit != 1 || column.WIPExceeded {
+				t.Errorf("expected IN_QA at but not over its limit of 1, got limit %d exceeded=%v", column.WIPLimit, column.WIPExceeded)
+			}
+		default:
+			if column.WIPLimit != 0 || column.WIPExceeded {
+				t.Errorf("expected no limit on %s, got %+v", column.Status, column)
+			}
+		}
+	}
+}
+
+func TestTagCleanupDeletesOnlyUnusedTags(t *testing.T) {
+	srv := newTestServer(t)
+	store := srv.store
+	now := time.Now()
+
+	if err := store.CreateTicket(&kanban.Ticket{ID: "T-1", Title: "Login", Status: kanban.StatusBacklog, CreatedAt: now, UpdatedAt: now}); err != nil {
+		t.Fatalf("failed to create ticket: %v", err)
+	}
+	tags := []kanban.Tag{
+		{ID: "tag-used", Name: "auth", Type: kanban.TagTypeGeneric},
+		{ID: "tag-stale", Name: "old-spike", Type: kanban.TagTypeGeneric},
+		{ID: "tag-component", Name: "billing", Type: kanban.TagTypeComponent},
+		{ID: "tag-epic", Name: "Q3 Launch", Type: kanban.TagTypeEpic},
+	}
+	for i := range tags {
+		if err := store.CreateTag(&tags[i]); err != nil {
+			t.Fatalf("failed to create tag: %v", err)
+		}
+	}
+	if err := store.AddTagToTicket("T-1", "tag-used"); err != nil {
+		t.Fatalf("failed to tag ticket: %v", err)
+	}
+	if err := store.SetConfig("tag_cleanup_keep_types", "epic,initiative"); err != nil {
+		t.Fatalf("failed to set config: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	srv.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/tags/unused", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var unused []kanban.Tag
+	if err := json.Unmarshal(rec.Body.Bytes(), &unused); err != nil {
+		t.Fatalf("failed to decode unused tags: %v", err)
+	}
+	if len(unused) != 2 || unused[0].ID != "tag-component" || unused[1].ID != "tag-stale" {
+		t.Fatalf("expected the unused component and tag to be reported, got %+v", unused)
+	}
+
+	rec = httptest.NewRecorder()
+	srv.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/tags/cleanup", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var result struct {
+		Deleted []kanban.Tag `json:"deleted"`
+		Count   int          `json:"count"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &result); err != nil {
+		t.Fatalf("failed to decode cleanup result: %v", err)
+	}
+	if result.Count != 2 {
+		t.Errorf("expected 2 tags deleted, got %+v", result)
+	}
+
+	remaining, err := store.GetAllTags()
+	if err != nil {
+		t.Fatalf("failed to get tags: %v", err)
+	}
+	var ids []string
+	for _, tag := range remaining {
+		ids = append(ids, tag.ID)
+	}
+	if len(ids) != 2 || ids[0] != "tag-epic" || ids[1] != "tag-used" {
+		t.Errorf("expected the used tag and the kept epic to remain, got %v", ids)
+	}
+}
+
+func TestWebhookTestEndpointSendsSignedSampleEvent(t *testing.T) {
+	srv := newTestServer(t)
+
+	received := make(chan *http.Request, 1)
+	var body []byte
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+		received <- r
+	}))
+	defer hook.Close()
+
+	rec := httptest.NewRecorder()
+	srv.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/webhooks/test", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 without a webhook configured, got %d", rec.Code)
+	}
+
+	if err := srv.store.SetConfig("webhooks", `{"url": "`+hook.URL+`", "secret": "s3cret"}`); err != nil {
+		t.Fatalf("failed to set config: %v", err)
+	}
+	rec = httptest.NewRecorder()
+	srv.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/webhooks/test", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	r := <-received
+	if got := r.Header.Get(notify.SignatureHeader); got != notify.Sign("s3cret", body) {
+		t.Errorf("expected a valid signature, got %q", got)
+	}
+	var event kanban.StatusChange
+	if err := json.Unmarshal(body, &event); err != nil {
+		t.Fatalf("failed to decode payload: %v", err)
+	}
+	if event.TicketID == "" || event.From == "" || event.To == "" || event.At.IsZero() {
+		t.Errorf("expected a complete sample event, got %+v", event)
+	}
+}
+
+func TestStatusChangeViaAPIPostsWebhookWithPreviousStatus(t *testing.T) {
+	srv := newTestServer(t)
+
+	received := make(chan kanban.StatusChange, 1)
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var event kanban.StatusChange
+		_ = json.NewDecoder(r.Body).Decode(&event)
+		received <- event
+	}))
+	defer hook.Close()
+	if err := srv.store.SetConfig("webhooks", `{"url": "`+hook.URL+`"}`); err != nil {
+		t.Fatalf("failed to set config: %v", err)
+	}
+
+	now := time.Now()
+	if err := srv.store.CreateTicket(&kanban.Ticket{ID: "T-1", Title: "Login", Status: kanban.StatusBacklog, CreatedAt: now, UpdatedAt: now}); err != nil {
+		t.Fatalf("failed to create ticket: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	srv.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodPatch, "/api/tickets/T-1", strings.NewReader(`{"status": "READY", "title": "Login page"}`)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	select {
+	case event := <-received:
+		if event.TicketID != "T-1" || event.From != kanban.StatusBacklog || event.To != kanban.StatusReady {
+			t.Errorf("expected T-1 moving BACKLOG -> READY, got %+v", event)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected a webhook delivery")
+	}
+	if ticket, _ := srv.store.GetTicket("T-1"); ticket.Title != "Login page" || ticket.Status != kanban.StatusReady {
+		t.Errorf("expected both the field and status updates saved, got %q in %s", ticket.Title, ticket.Status)
+	}
+}
@@ -7,9 +7,14 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/madhatter5501/Factory/agents/provider"
+	"github.com/madhatter5501/Factory/internal/db"
+	"github.com/madhatter5501/Factory/internal/notify"
 	"github.com/madhatter5501/Factory/kanban"
 
 	"github.com/google/uuid"
@@ -18,6 +23,21 @@ import (
 // safeExtensionRe matches valid file extensions (alphanumeric only).
 var safeExtensionRe = regexp.MustCompile(`^\.[a-zA-Z0-9]+$`)
 
+// defaultMaxTicketAttachmentBytes caps attachment storage per ticket unless
+// overridden by the max_ticket_attachment_bytes config value.
+const defaultMaxTicketAttachmentBytes int64 = 50 << 20
+
+// defaultMaxConcurrentUploads caps attachment uploads in flight at once unless
+// overridden by the max_concurrent_uploads config value.
+const defaultMaxConcurrentUploads = 4
+
+// attachmentsDir holds uploaded attachments, one directory per message.
+// Uploads are staged in its pendingUploadsDir until their record is committed.
+var (
+	attachmentsDir    = filepath.Join("uploads", "attachments")
+	pendingUploadsDir = filepath.Join(attachmentsDir, ".pending")
+)
+
 // getGlobalStatusData returns the system health and stats for the global status bar.
 // This should be included in all page data to render the persistent header.
 func (s *Server) getGlobalStatusData() (systemHealth *kanban.SystemHealth, stats map[kanban.Status]int) {
@@ -25,11 +45,125 @@ func (s *Server) getGlobalStatusData() (systemHealth *kanban.SystemHealth, stats
 	if err != nil {
 		return nil, nil
 	}
-	systemHealth = kanban.ComputeSystemHealth(tickets)
+	systemHealth = s.computeSystemHealth(tickets)
 	stats = s.store.GetStats()
 	return systemHealth, stats
 }
 
+// healthCheckInterval is how often runHealthChecks records health transitions.
+const healthCheckInterval = 30 * time.Second
+
+// computeSystemHealth computes system health without recording anything, so
+// page views and API reads have no side effects.
+func (s *Server) computeSystemHealth(tickets []kanban.Ticket) *kanban.SystemHealth {
+	return kanban.ComputeSystemHealth(tickets, s.healthConfig())
+}
+
+// runHealthChecks records health transitions every healthCheckInterval until
+// the server shuts down.
+func (s *Server) runHealthChecks() {
+	ticker := time.NewTicker(healthCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		s.checkSystemHealth()
+		select {
+		case <-s.healthStop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// checkSystemHealth evaluates the board's health and records it. Tickets are
+// loaded with their history so thrashing is detected.
+func (s *Server) checkSystemHealth() {
+	tickets, err := s.store.GetTicketsWithHistory()
+	if err != nil {
+		s.logger.Warn("Failed to load tickets for health check", "error", err)
+		return
+	}
+	s.recordSystemHealth(tickets)
+}
+
+// recordSystemHealth computes system health and records any status transition.
+// Transitions into a non-stable status notify operators.
+func (s *Server) recordSystemHealth(tickets []kanban.Ticket) *kanban.SystemHealth {
+	health := s.computeSystemHealth(tickets)
+
+	event, err := s.store.RecordHealthTransition(health)
+	if err != nil {
+		s.logger.Warn("Failed to record health transition", "error", err)
+		return health
+	}
+	if event != nil && event.ToStatus != kanban.SystemHealthStable {
+		_ = s.notifier.Notify(notify.Notification{
+			Event:   "health:" + string(event.ToStatus),
+			Title:   "System health: " + health.StatusLabel,
+			Message: event.Message,
+			At:      event.CreatedAt,
+		})
+	}
+
+	return health
+}
+
+// healthConfig reads the thrashing thresholds from the health_thrashing_window,
+// health_thrashing_repeats and health_thrashing_min_history config keys.
+func (s *Server) healthConfig() kanban.HealthConfig {
+	def := kanban.DefaultHealthConfig()
+	return kanban.HealthConfig{
+		ThrashingWindow:     s.configInt("health_thrashing_window", def.ThrashingWindow, 1),
+		ThrashingRepeats:    s.configInt("health_thrashing_repeats", def.ThrashingRepeats, 1),
+		ThrashingMinHistory: s.configInt("health_thrashing_min_history", def.ThrashingMinHistory, 1),
+	}
+}
+
+// StatusCount is the number of tickets in one status, for the public status page.
+type StatusCount struct {
+	Status kanban.Status
+	Name   string
+	Count  int
+}
+
+// handleStatus renders the public status page: ticket counts per status, the
+// system health label and the iteration goal. It is read-only and shows no
+// ticket details, so it is safe to share outside the dashboard.
+func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
+	tickets, err := s.store.GetAllTickets()
+	if err != nil {
+		s.logger.Error("Failed to get tickets", "error", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	// Computed without recording a transition, so page views have no side effects
+	health := kanban.ComputeSystemHealth(tickets, s.healthConfig())
+
+	stats := s.store.GetStats()
+	counts := make([]StatusCount, 0, len(stats))
+	total := 0
+	for _, status := range boardStatusOrder {
+		if stats[status] > 0 {
+			counts = append(counts, StatusCount{Status: status, Name: statusName(status), Count: stats[status]})
+			total += stats[status]
+		}
+	}
+
+	goal := ""
+	if iteration := s.store.GetIteration(); iteration != nil {
+		goal = iteration.Goal
+	}
+
+	s.render(w, "status.html", map[string]interface{}{
+		"Title":         "Status",
+		"Counts":        counts,
+		"Total":         total,
+		"SystemHealth":  health,
+		"IterationGoal": goal,
+		"UpdatedAt":     time.Now(),
+	})
+}
+
 // handleBoard renders the main kanban board view.
 func (s *Server) handleBoard(w http.ResponseWriter, r *http.Request) {
 	if r.URL.Path != "/" {
@@ -44,14 +178,15 @@ func (s *Server) handleBoard(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Compute human supervisor context for each ticket
+	// Compute human supervisor context for each ticket; the board always renders
+	// fresh context rather than what was last stored
 	for i := range tickets {
 		tickets[i].BlockedReason = tickets[i].ComputeBlockedReason(tickets)
 		tickets[i].CreationContext = tickets[i].ComputeCreationContext(tickets)
 	}
 
 	// Compute system health
-	systemHealth := kanban.ComputeSystemHealth(tickets)
+	systemHealth := s.computeSystemHealth(tickets)
 
 	// Extract unique domains and agents for facet rail
 	domainSet := make(map[string]bool)
@@ -75,7 +210,7 @@ func (s *Server) handleBoard(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Group tickets by status
-	columns := groupTicketsByStatus(tickets)
+	columns := groupTicketsByStatus(tickets, s.store.GetConfig())
 
 	stats := s.store.GetStats()
 	runs := s.store.GetActiveRuns()
@@ -131,6 +266,7 @@ func (s *Server) handleTicketDetail(w http.ResponseWriter, r *http.Request) {
 
 	// Fetch time statistics for this ticket
 	timeStats, _ := s.store.GetTicketTimeStats(id)
+	s.addCodeStats(ticket, timeStats)
 
 	// Fetch agent runs for this ticket
 	agentRuns, _ := s.store.GetRunsByTicket(id)
@@ -158,7 +294,7 @@ func (s *Server) handleTicketDetail(w http.ResponseWriter, r *http.Request) {
 // handleAgents renders the agents status view.
 func (s *Server) handleAgents(w http.ResponseWriter, r *http.Request) {
 	runs := s.store.GetActiveRuns()
-	recentRuns, _ := s.store.GetRecentRuns()
+	recentRuns, _ := s.store.GetRecentRuns(db.RunFilter{})
 
 	// Get provider configurations for each agent type
 	providerConfigs, _ := s.store.GetAllAgentProviderConfigs()
@@ -327,29 +463,39 @@ func (s *Server) handleNewTicket(w http.ResponseWriter, r *http.Request) {
 
 // Column represents a kanban column with its tickets.
 type Column struct {
-	Status  kanban.Status
-	Name    string
-	Tickets []kanban.Ticket
-}
-
-// groupTicketsByStatus groups tickets into columns by their status.
-func groupTicketsByStatus(tickets []kanban.Ticket) []Column {
-	// Define column order
-	statuses := []kanban.Status{
-		kanban.StatusBacklog,
-		kanban.StatusApproved,
-		kanban.StatusRefining,
-		kanban.StatusNeedsExpert,
-		kanban.StatusAwaitingUser,
-		kanban.StatusReady,
-		kanban.StatusInDev,
-		kanban.StatusInQA,
-		kanban.StatusInUX,
-		kanban.StatusInSec,
-		kanban.StatusPMReview,
-		kanban.StatusDone,
-		kanban.StatusBlocked,
-	}
+	Status      kanban.Status
+	Name        string
+	Tickets     []kanban.Ticket
+	WIPLimit    int  // 0 when the column has no limit
+	WIPExceeded bool // More tickets than the limit
+}
+
+// boardStatusOrder is the order statuses appear in on the board.
+var boardStatusOrder = []kanban.Status{
+	kanban.StatusIcebox,
+	kanban.StatusBacklog,
+	kanban.StatusApproved,
+	kanban.StatusRefining,
+	kanban.StatusNeedsExpert,
+	kanban.StatusAwaitingUser,
+	kanban.StatusQueued,
+	kanban.StatusReady,
+	kanban.StatusInDev,
+	kanban.StatusInQA,
+	kanban.StatusInUX,
+	kanban.StatusInSec,
+	kanban.StatusPMReview,
+	kanban.StatusAwaitingMergeApproval,
+	kanban.StatusPendingDone,
+	kanban.StatusDone,
+	kanban.StatusBlocked,
+	kanban.StatusAbandoned,
+}
+
+// groupTicketsByStatus groups tickets into columns by their status, with
+// each column's WIP limit. Columns the config hides are left out of the layout.
+func groupTicketsByStatus(tickets []kanban.Ticket, config kanban.BoardConfig) []Column {
+	statuses := boardStatusOrder
 
 	// Group tickets by status
 	byStatus := make(map[kanban.Status][]kanban.Ticket)
@@ -357,13 +503,23 @@ func groupTicketsByStatus(tickets []kanban.Ticket) []Column {
 		byStatus[t.Status] = append(byStatus[t.Status], t)
 	}
 
+	hiddenSet := make(map[kanban.Status]bool, len(config.HiddenColumns))
+	for _, status := range config.HiddenColumns {
+		hiddenSet[status] = true
+	}
+
 	// Build columns
 	columns := make([]Column, 0, len(statuses))
 	for _, status := range statuses {
+		if hiddenSet[status] {
+			continue
+		}
 		columns = append(columns, Column{
-			Status:  status,
-			Name:    statusName(status),
-			Tickets: byStatus[status],
+			Status:      status,
+			Name:        statusName(status),
+			Tickets:     byStatus[status],
+			WIPLimit:    config.WIPLimits[status],
+			WIPExceeded: config.WIPExceeded(status, len(byStatus[status])),
 		})
 	}
 
@@ -373,19 +529,24 @@ func groupTicketsByStatus(tickets []kanban.Ticket) []Column {
 // statusName returns a human-readable name for a status.
 func statusName(status kanban.Status) string {
 	names := map[kanban.Status]string{
-		kanban.StatusBacklog:      "Backlog",
-		kanban.StatusApproved:     "Approved",
-		kanban.StatusRefining:     "Refining",
-		kanban.StatusNeedsExpert:  "Needs Expert",
-		kanban.StatusAwaitingUser: "Awaiting User",
-		kanban.StatusReady:        "Ready",
-		kanban.StatusInDev:        "In Dev",
-		kanban.StatusInQA:         "In QA",
-		kanban.StatusInUX:         "In UX",
-		kanban.StatusInSec:        "In Security",
-		kanban.StatusPMReview:     "PM Review",
-		kanban.StatusDone:         "Done",
-		kanban.StatusBlocked:      "Blocked",
+		kanban.StatusIcebox:                "Icebox",
+		kanban.StatusBacklog:               "Backlog",
+		kanban.StatusApproved:              "Approved",
+		kanban.StatusRefining:              "Refining",
+		kanban.StatusNeedsExpert:           "Needs Expert",
+		kanban.StatusAwaitingUser:          "Awaiting User",
+		kanban.StatusQueued:                "Queued",
+		kanban.StatusReady:                 "Ready",
+		kanban.StatusInDev:                 "In Dev",
+		kanban.StatusInQA:                  "In QA",
+		kanban.StatusInUX:                  "In UX",
+		kanban.StatusInSec:                 "In Security",
+		kanban.StatusPMReview:              "PM Review",
+		kanban.StatusAwaitingMergeApproval: "Merge Approval",
+		kanban.StatusPendingDone:           "Pending Done",
+		kanban.StatusDone:                  "Done",
+		kanban.StatusBlocked:               "Blocked",
+		kanban.StatusAbandoned:             "Abandoned",
 	}
 	if name, ok := names[status]; ok {
 		return name
@@ -403,6 +564,16 @@ func (s *Server) apiUploadAttachment(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Cap uploads in flight so parallel large files don't thrash the disk
+	select {
+	case s.uploadSlots() <- struct{}{}:
+		defer func() { <-s.uploadSlots() }()
+	default:
+		w.Header().Set("Retry-After", "1")
+		http.Error(w, "Too many uploads in progress", http.StatusServiceUnavailable)
+		return
+	}
+
 	// Parse multipart form (max 10MB)
 	err := r.ParseMultipartForm(10 << 20)
 	if err != nil {
@@ -417,18 +588,42 @@ func (s *Server) apiUploadAttachment(w http.ResponseWriter, r *http.Request) {
 	}
 	defer file.Close()
 
+	// Enforce the per-ticket attachment quota
+	ticketID, err := s.store.GetMessageTicketID(messageID)
+	if err != nil {
+		s.logger.Error("Failed to get message ticket", "messageID", messageID, "error", err)
+		http.Error(w, "Failed to check attachment quota", http.StatusInternalServerError)
+		return
+	}
+	if ticketID == "" {
+		http.Error(w, "Message not found", http.StatusNotFound)
+		return
+	}
+	used, err := s.store.GetTicketAttachmentBytes(ticketID)
+	if err != nil {
+		s.logger.Error("Failed to get ticket attachment usage", "ticketID", ticketID, "error", err)
+		http.Error(w, "Failed to check attachment quota", http.StatusInternalServerError)
+		return
+	}
+	if used+header.Size > s.maxTicketAttachmentBytes() {
+		http.Error(w, "Ticket attachment quota exceeded", http.StatusRequestEntityTooLarge)
+		return
+	}
+
 	// Validate content type
 	contentType := header.Header.Get("Content-Type")
 	if contentType == "" {
 		contentType = "application/octet-stream"
 	}
 
-	// Create uploads directory
-	uploadsDir := filepath.Join("uploads", "attachments", messageID)
-	if err := os.MkdirAll(uploadsDir, 0750); err != nil {
-		s.logger.Error("Failed to create uploads directory", "error", err)
-		http.Error(w, "Failed to create uploads directory", http.StatusInternalServerError)
-		return
+	// Create uploads directories
+	uploadsDir := filepath.Join(attachmentsDir, messageID)
+	for _, dir := range []string{uploadsDir, pendingUploadsDir} {
+		if err := os.MkdirAll(dir, 0750); err != nil {
+			s.logger.Error("Failed to create uploads directory", "error", err)
+			http.Error(w, "Failed to create uploads directory", http.StatusInternalServerError)
+			return
+		}
 	}
 
 	// Generate unique filename
@@ -442,21 +637,15 @@ func (s *Server) apiUploadAttachment(w http.ResponseWriter, r *http.Request) {
 	}
 
 	storedFilename := attID + ext
-	filePath := filepath.Join(uploadsDir, storedFilename) // #nosec G304 -- path constructed from validated UUID and extension
+	filePath := filepath.Join(uploadsDir, storedFilename)           // #nosec G304 -- path constructed from validated UUID and extension
+	pendingPath := filepath.Join(pendingUploadsDir, storedFilename) // #nosec G304 -- path constructed from validated UUID and extension
 
-	// Create the file
-	dst, err := os.Create(filePath) // #nosec G304 -- path constructed from validated components
+	// Stage the upload; it only moves into place once its record is committed,
+	// and sweepPendingUploads clears anything a crash leaves behind.
+	size, err := writeUpload(pendingPath, file)
 	if err != nil {
-		s.logger.Error("Failed to create file", "error", err)
-		http.Error(w, "Failed to save file", http.StatusInternalServerError)
-		return
-	}
-	defer dst.Close()
-
-	// Copy the uploaded file
-	size, err := io.Copy(dst, file)
-	if err != nil {
-		s.logger.Error("Failed to copy file", "error", err)
+		s.logger.Error("Failed to save file", "error", err)
+		_ = os.Remove(pendingPath)
 		http.Error(w, "Failed to save file", http.StatusInternalServerError)
 		return
 	}
@@ -474,18 +663,104 @@ func (s *Server) apiUploadAttachment(w http.ResponseWriter, r *http.Request) {
 
 	if err := s.store.AddAttachment(att); err != nil {
 		s.logger.Error("Failed to save attachment", "error", err)
-		// Clean up the file
-		if rmErr := os.Remove(filePath); rmErr != nil {
-			s.logger.Error("Failed to clean up file after attachment error", "error", rmErr, "path", filePath)
+		// Clean up the staged file
+		if rmErr := os.Remove(pendingPath); rmErr != nil {
+			s.logger.Error("Failed to clean up file after attachment error", "error", rmErr, "path", pendingPath)
 		}
 		http.Error(w, "Failed to save attachment", http.StatusInternalServerError)
 		return
 	}
 
+	if err := os.Rename(pendingPath, filePath); err != nil {
+		s.logger.Error("Failed to move attachment into place", "error", err, "path", pendingPath)
+		_ = s.store.DeleteAttachment(attID)
+		_ = os.Remove(pendingPath)
+		http.Error(w, "Failed to save attachment", http.StatusInternalServerError)
+		return
+	}
+
 	w.WriteHeader(http.StatusCreated)
 	_, _ = w.Write([]byte(`{"id":"` + attID + `"}`))
 }
 
+// maxTicketAttachmentBytes returns the configured per-ticket attachment quota.
+func (s *Server) maxTicketAttachmentBytes() int64 {
+	if v, _ := s.store.GetConfigValue("max_ticket_attachment_bytes"); v != "" {
+		if n, err := strconv.ParseInt(v, 10, 64); err == nil && n > 0 {
+			return n
+		}
+	}
+	return defaultMaxTicketAttachmentBytes
+}
+
+// uploadSlots returns the semaphore bounding concurrent uploads, sized from
+// the max_concurrent_uploads config value on first use.
+func (s *Server) uploadSlots() chan struct{} {
+	s.uploadOnce.Do(func() {
+		limit := defaultMaxConcurrentUploads
+		if v, _ := s.store.GetConfigValue("max_concurrent_uploads"); v != "" {
+			if n, err := strconv.Atoi(v); err == nil && n > 0 {
+				limit = n
+			}
+		}
+		s.uploadSem = make(chan struct{}, limit)
+	})
+	return s.uploadSem
+}
+
+// writeUpload copies an uploaded file to path and syncs it to disk.
+func writeUpload(path string, src io.Reader) (int64, error) {
+	dst, err := os.Create(path) // #nosec G304 -- path constructed from validated components
+	if err != nil {
+		return 0, err
+	}
+	size, err := io.Copy(dst, src)
+	if err == nil {
+		err = dst.Sync()
+	}
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	return size, err
+}
+
+// sweepPendingUploads resolves uploads interrupted by a crash: staged files
+// whose record was committed are moved into place, the rest are removed.
+func (s *Server) sweepPendingUploads() {
+	entries, err := os.ReadDir(pendingUploadsDir)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			s.logger.Warn("Failed to read pending uploads", "error", err)
+		}
+		return
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		pendingPath := filepath.Join(pendingUploadsDir, entry.Name())
+		attID := strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name()))
+
+		att, err := s.store.GetAttachment(attID)
+		if err != nil {
+			s.logger.Warn("Failed to look up pending upload", "path", pendingPath, "error", err)
+			continue
+		}
+		if att != nil {
+			if err := os.Rename(pendingPath, att.Path); err == nil {
+				s.logger.Info("Recovered pending upload", "attachment", attID)
+				continue
+			}
+		}
+		if err := os.Remove(pendingPath); err != nil {
+			s.logger.Warn("Failed to remove orphaned upload", "path", pendingPath, "error", err)
+			continue
+		}
+		s.logger.Info("Removed orphaned upload", "path", pendingPath)
+	}
+}
+
 // apiGetAttachment serves an attachment file.
 func (s *Server) apiGetAttachment(w http.ResponseWriter, r *http.Request) {
 	id := r.PathValue("id")
@@ -612,6 +887,174 @@ func (s *Server) apiCreateADR(w http.ResponseWriter, r *http.Request) {
 	s.jsonResponse(w, adr)
 }
 
+// apiSupersedeADR creates a new ADR from the request body and marks the ADR in
+// the path as superseded by it. The new ADR is accepted unless a status is given.
+func (s *Server) apiSupersedeADR(w http.ResponseWriter, r *http.Request) {
+	oldID := r.PathValue("id")
+
+	old, err := s.store.GetADR(oldID)
+	if err != nil {
+		s.logger.Error("Failed to get ADR", "error", err, "id", oldID)
+		http.Error(w, "Failed to get ADR", http.StatusInternalServerError)
+		return
+	}
+	if old == nil {
+		http.NotFound(w, r)
+		return
+	}
+	if old.Status == kanban.ADRStatusSuperseded {
+		http.Error(w, "ADR is already superseded by "+old.SupersededBy, http.StatusConflict)
+		return
+	}
+
+	var adr kanban.ADR
+	if err := json.NewDecoder(r.Body).Decode(&adr); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if adr.ID == "" {
+		nextNum, _ := s.store.GetNextADRNumber()
+		adr.ID = kanban.FormatADRID(nextNum)
+	} else if existing, err := s.store.GetADR(adr.ID); err != nil {
+		s.logger.Error("Failed to get ADR", "error", err, "id", adr.ID)
+		http.Error(w, "Failed to get ADR", http.StatusInternalServerError)
+		return
+	} else if existing != nil {
+		http.Error(w, "ADR "+adr.ID+" already exists", http.StatusConflict)
+		return
+	}
+	now := time.Now()
+	adr.CreatedAt = now
+	adr.UpdatedAt = now
+	adr.SupersededBy = ""
+	if adr.Status == "" {
+		adr.Status = kanban.ADRStatusAccepted
+	}
+
+	if err := s.store.SupersedeADR(oldID, &adr); err != nil {
+		s.logger.Error("Failed to supersede ADR", "error", err, "id", oldID)
+		http.Error(w, "Failed to supersede ADR", http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	s.jsonResponse(w, adr)
+}
+
+// adrImportFile is one markdown file submitted for ADR import.
+type adrImportFile struct {
+	Name    string
+	Content string
+}
+
+// adrImportSkip reports a file that could not be imported.
+type adrImportSkip struct {
+	File  string `json:"file"`
+	Error string `json:"error"`
+}
+
+// apiImportADRs creates ADRs from markdown files, either uploaded as multipart
+// "files" or read from the *.md files in a JSON {"path": ...} directory relative
+// to the repository root. Files that don't parse are skipped and reported.
+func (s *Server) apiImportADRs(w http.ResponseWriter, r *http.Request) {
+	var files []adrImportFile
+
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		if err := r.ParseMultipartForm(10 << 20); err != nil {
+			http.Error(w, "Failed to parse form", http.StatusBadRequest)
+			return
+		}
+		for _, header := range r.MultipartForm.File["files"] {
+			f, err := header.Open()
+			if err != nil {
+				http.Error(w, "Failed to read uploaded file", http.StatusBadRequest)
+				return
+			}
+			content, err := io.ReadAll(f)
+			f.Close()
+			if err != nil {
+				http.Error(w, "Failed to read uploaded file", http.StatusBadRequest)
+				return
+			}
+			files = append(files, adrImportFile{Name: header.Filename, Content: string(content)})
+		}
+	} else {
+		var req struct {
+			Path string `json:"path"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		if req.Path == "" || !filepath.IsLocal(req.Path) {
+			http.Error(w, "Path must be a directory inside the repository", http.StatusBadRequest)
+			return
+		}
+
+		repoRoot := s.orchRepoRoot
+		if repoRoot == "" {
+			repoRoot = "."
+		}
+		dir := filepath.Join(repoRoot, req.Path)
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			http.Error(w, "Directory not found", http.StatusNotFound)
+			return
+		}
+		matches, err := filepath.Glob(filepath.Join(dir, "*.md"))
+		if err != nil {
+			http.Error(w, "Invalid path", http.StatusBadRequest)
+			return
+		}
+		for _, match := range matches {
+			content, err := os.ReadFile(match) // #nosec G304 -- path confined to the repository root
+			if err != nil {
+				s.logger.Error("Failed to read ADR file", "file", match, "error", err)
+				http.Error(w, "Failed to read ADR files", http.StatusInternalServerError)
+				return
+			}
+			files = append(files, adrImportFile{Name: filepath.Base(match), Content: string(content)})
+		}
+	}
+
+	if len(files) == 0 {
+		http.Error(w, "No markdown files to import", http.StatusBadRequest)
+		return
+	}
+
+	// Import in file name order so numbered ADRs keep their sequence
+	sort.Slice(files, func(i, j int) bool { return files[i].Name < files[j].Name })
+
+	imported := []kanban.ADR{}
+	skipped := []adrImportSkip{}
+	for _, file := range files {
+		adr, err := kanban.ParseADRMarkdown(file.Content)
+		if err != nil {
+			skipped = append(skipped, adrImportSkip{File: file.Name, Error: err.Error()})
+			continue
+		}
+
+		nextNum, _ := s.store.GetNextADRNumber()
+		now := time.Now()
+		adr.ID = kanban.FormatADRID(nextNum)
+		adr.CreatedBy = "import"
+		adr.CreatedAt = now
+		adr.UpdatedAt = now
+
+		if err := s.store.CreateADR(adr); err != nil {
+			s.logger.Error("Failed to create imported ADR", "file", file.Name, "error", err)
+			skipped = append(skipped, adrImportSkip{File: file.Name, Error: "failed to save ADR"})
+			continue
+		}
+		imported = append(imported, *adr)
+	}
+
+	s.jsonResponse(w, map[string]interface{}{
+		"imported": imported,
+		"skipped":  skipped,
+	})
+}
+
 // apiUpdateADR updates an existing ADR.
 func (s *Server) apiUpdateADR(w http.ResponseWriter, r *http.Request) {
 	id := r.PathValue("id")
@@ -701,6 +1144,36 @@ func (s *Server) apiGetTicketADRs(w http.ResponseWriter, r *http.Request) {
 	s.jsonResponse(w, adrs)
 }
 
+// apiGetADRTickets returns the tickets an ADR affects with their statuses and
+// how far the decision has rolled out.
+func (s *Server) apiGetADRTickets(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		http.Error(w, "Missing ADR ID", http.StatusBadRequest)
+		return
+	}
+
+	adr, err := s.store.GetADR(id)
+	if err != nil {
+		s.logger.Error("Failed to get ADR", "error", err)
+		http.Error(w, "Failed to get ADR", http.StatusInternalServerError)
+		return
+	}
+	if adr == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	rollout, err := s.store.GetADRRollout(id)
+	if err != nil {
+		s.logger.Error("Failed to get ADR tickets", "error", err)
+		http.Error(w, "Failed to get ADR tickets", http.StatusInternalServerError)
+		return
+	}
+
+	s.jsonResponse(w, rollout)
+}
+
 // apiLinkADRToTicket creates a link between an ADR and a ticket.
 func (s *Server) apiLinkADRToTicket(w http.ResponseWriter, r *http.Request) {
 	adrID := r.PathValue("id")
@@ -885,6 +1358,56 @@ func (s *Server) apiDeleteTag(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// tagCleanupKeepTypes returns the tag types cleanup leaves alone, from the
+// tag_cleanup_keep_types config.
+func (s *Server) tagCleanupKeepTypes() []kanban.TagType {
+	var keep []kanban.TagType
+	v, _ := s.store.GetConfigValue("tag_cleanup_keep_types")
+	for _, tagType := range strings.Split(v, ",") {
+		if tagType = strings.TrimSpace(tagType); tagType != "" {
+			keep = append(keep, kanban.TagType(strings.ToLower(tagType)))
+		}
+	}
+	return keep
+}
+
+// apiGetUnusedTags returns tags attached to no tickets that cleanup would delete.
+func (s *Server) apiGetUnusedTags(w http.ResponseWriter, r *http.Request) {
+	tags, err := s.store.GetUnusedTags(s.tagCleanupKeepTypes())
+	if err != nil {
+		s.logger.Error("Failed to get unused tags", "error", err)
+		http.Error(w, "Failed to get unused tags", http.StatusInternalServerError)
+		return
+	}
+
+	if tags == nil {
+		tags = []kanban.Tag{}
+	}
+	s.jsonResponse(w, tags)
+}
+
+// apiCleanupTags deletes every unused tag and returns the tags deleted.
+func (s *Server) apiCleanupTags(w http.ResponseWriter, r *http.Request) {
+	deleted, err := s.store.DeleteUnusedTags(s.tagCleanupKeepTypes())
+	if err != nil {
+		s.logger.Error("Failed to clean up tags", "error", err)
+		http.Error(w, "Failed to clean up tags", http.StatusInternalServerError)
+		return
+	}
+
+	if deleted == nil {
+		deleted = []kanban.Tag{}
+	}
+	if len(deleted) > 0 {
+		s.logger.Info("Deleted unused tags", "count", len(deleted))
+		s.Broadcast("board-update")
+	}
+	s.jsonResponse(w, map[string]interface{}{
+		"deleted": deleted,
+		"count":   len(deleted),
+	})
+}
+
 // apiGetTicketsByTag returns all tickets with a specific tag.
 func (s *Server) apiGetTicketsByTag(w http.ResponseWriter, r *http.Request) {
 	tagID := r.PathValue("id")
@@ -903,6 +1426,51 @@ func (s *Server) apiGetTicketsByTag(w http.ResponseWriter, r *http.Request) {
 	s.jsonResponse(w, tickets)
 }
 
+// apiGetTagStats returns a tag's ticket counts by status and completion percentage.
+func (s *Server) apiGetTagStats(w http.ResponseWriter, r *http.Request) {
+	tagID := r.PathValue("id")
+	if tagID == "" {
+		http.Error(w, "Missing tag ID", http.StatusBadRequest)
+		return
+	}
+
+	stats, err := s.store.GetTagStatusBreakdown(tagID)
+	if err != nil {
+		s.logger.Error("Failed to get tag stats", "error", err)
+		http.Error(w, "Failed to get tag stats", http.StatusInternalServerError)
+		return
+	}
+	if stats == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	s.jsonResponse(w, stats)
+}
+
+// apiGetEpicStats returns the status breakdown of every epic tag, for an epic rollup.
+func (s *Server) apiGetEpicStats(w http.ResponseWriter, r *http.Request) {
+	epics, err := s.store.GetEpicTags()
+	if err != nil {
+		s.logger.Error("Failed to get epic tags", "error", err)
+		http.Error(w, "Failed to get epic tags", http.StatusInternalServerError)
+		return
+	}
+
+	rollup := make([]kanban.TagStats, 0, len(epics))
+	for _, epic := range epics {
+		tickets, err := s.store.GetTicketsByTag(epic.ID)
+		if err != nil {
+			s.logger.Error("Failed to get tickets by tag", "tag", epic.ID, "error", err)
+			http.Error(w, "Failed to get tag stats", http.StatusInternalServerError)
+			return
+		}
+		rollup = append(rollup, kanban.NewTagStats(epic, tickets))
+	}
+
+	s.jsonResponse(w, rollup)
+}
+
 // apiGetTicketTags returns all tags for a specific ticket.
 func (s *Server) apiGetTicketTags(w http.ResponseWriter, r *http.Request) {
 	ticketID := r.PathValue("id")
@@ -15,7 +15,7 @@ func (s *Server) partialBoard(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	columns := groupTicketsByStatus(tickets)
+	columns := groupTicketsByStatus(tickets, s.store.GetConfig())
 	stats := s.store.GetStats()
 	runs := s.store.GetActiveRuns()
 
@@ -0,0 +1,251 @@
+package web
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/madhatter5501/Factory/agents/provider"
+	"github.com/madhatter5501/Factory/kanban"
+)
+
+// PM chat pool defaults, overridable via the pm_chat_concurrency and
+// pm_chat_queue_size config keys.
+const (
+	defaultPMChatConcurrency = 2
+	defaultPMChatQueueSize   = 10
+)
+
+// PM chat retry defaults, overridable via the pm_chat_max_retries and
+// pm_chat_retry_backoff (seconds, doubled per retry) config keys.
+const (
+	defaultPMChatMaxRetries   = 3
+	defaultPMChatRetryBackoff = 2
+)
+
+// pmChatAgentType is the agent_provider_config entry that selects the PM chat's
+// provider and model, independently of the PM pipeline agent.
+const pmChatAgentType = "pm-chat"
+
+// pmChatMaxTokens is the chat response token limit unless pm-chat configures one.
+const pmChatMaxTokens = 500
+
+// pmBusyMessage is posted when the PM chat queue is full.
+const pmBusyMessage = "PM is busy, will respond shortly."
+
+// errPMChatQueueFull is recorded on messages saved because the queue was full.
+var errPMChatQueueFull = errors.New("PM chat queue full")
+
+// pmUnavailableMessage is posted when retries are exhausted and the message is
+// saved for a later retry.
+const pmUnavailableMessage = "I'm having trouble reaching the model right now. " +
+	"Your question is saved and I'll answer it once I can."
+
+// pmChatJob is a chat message waiting for a PM response.
+type pmChatJob struct {
+	ticketID string
+	convID   string
+	message  string
+	pending  *kanban.PendingPMResponse // Set when retrying a saved message
+}
+
+// enqueuePMResponse queues a PM response to a chat message. At most the
+// configured number of responses are generated at once; when the queue is full
+// the user gets a busy placeholder and the message is saved as pending, to be
+// answered once a worker is free, rather than being dropped.
+func (s *Server) enqueuePMResponse(ticketID, convID, message string) {
+	s.pmChatOnce.Do(s.startPMChatWorkers)
+
+	job := pmChatJob{ticketID: ticketID, convID: convID, message: message}
+	select {
+	case s.pmChatQueue <- job:
+		return
+	default:
+	}
+
+	s.logger.Warn("PM chat queue full, deferring response", "ticketID", ticketID)
+	s.addPMMessage(ticketID, convID, kanban.MessageTypeStatusUpdate, pmBusyMessage)
+	pending := newPendingPMResponse(job)
+	pending.LastError = errPMChatQueueFull.Error()
+	if err := s.store.SavePendingPMResponse(pending); err != nil {
+		s.logger.Error("Failed to save deferred PM response", "ticketID", ticketID, "error", err)
+	}
+}
+
+// startPMChatWorkers creates the PM chat queue and its fixed set of workers.
+func (s *Server) startPMChatWorkers() {
+	concurrency := s.configInt("pm_chat_concurrency", defaultPMChatConcurrency, 1)
+	// A zero-size queue makes every request beyond the workers wait with a placeholder
+	queueSize := s.configInt("pm_chat_queue_size", defaultPMChatQueueSize, 0)
+
+	s.pmChatQueue = make(chan pmChatJob, queueSize)
+	s.pmChatQueued = make(map[string]bool)
+	for i := 0; i < concurrency; i++ {
+		go func() {
+			for job := range s.pmChatQueue {
+				s.runPMChatJob(job)
+				s.runDeferredPMResponses()
+			}
+		}()
+	}
+}
+
+// runPMChatJob generates one PM response, releasing a saved message's claim
+// once it is done.
+func (s *Server) runPMChatJob(job pmChatJob) {
+	// Chat responses count toward the global agent ceiling
+	_ = s.agentLimiter.Acquire(context.Background())
+	s.generatePMResponse(job)
+	s.agentLimiter.Release()
+
+	if job.pending != nil {
+		s.pmChatMu.Lock()
+		delete(s.pmChatQueued, job.pending.ID)
+		s.pmChatMu.Unlock()
+	}
+}
+
+// runDeferredPMResponses answers messages saved because the queue was full,
+// until none are left that this pass hasn't tried. Messages saved after a
+// provider failure wait for an explicit retry.
+func (s *Server) runDeferredPMResponses() {
+	tried := make(map[string]bool)
+	for {
+		pending, err := s.store.GetPendingPMResponses()
+		if err != nil {
+			s.logger.Error("Failed to get deferred PM responses", "error", err)
+			return
+		}
+
+		ran := false
+		for i := range pending {
+			p := &pending[i]
+			if p.Attempts > 0 || tried[p.ID] || !s.claimPendingPMResponse(p.ID) {
+				continue
+			}
+			tried[p.ID] = true
+			ran = true
+			s.runPMChatJob(pmChatJob{ticketID: p.TicketID, convID: p.ConversationID, message: p.Message, pending: p})
+		}
+		if !ran {
+			return
+		}
+	}
+}
+
+// claimPendingPMResponse marks a saved message as queued, reporting false if
+// it already is, so it is never answered twice at once.
+func (s *Server) claimPendingPMResponse(id string) bool {
+	s.pmChatMu.Lock()
+	defer s.pmChatMu.Unlock()
+	if s.pmChatQueued[id] {
+		return false
+	}
+	s.pmChatQueued[id] = true
+	return true
+}
+
+// respondWithRetry generates a PM response, retrying transient provider
+// errors with exponential backoff. It returns the number of attempts made.
+func (s *Server) respondWithRetry(ticket *kanban.Ticket, history, userMessage string) (string, int, error) {
+	respond := s.pmResponder
+	if respond == nil {
+		respond = s.callProviderForPMResponse
+	}
+	retries := s.configInt("pm_chat_max_retries", defaultPMChatMaxRetries, 0)
+	backoff := time.Duration(s.configInt("pm_chat_retry_backoff", defaultPMChatRetryBackoff, 0)) * time.Second
+
+	for attempt := 1; ; attempt++ {
+		response, err := respond(ticket, history, userMessage)
+		if err == nil {
+			return response, attempt, nil
+		}
+		if attempt > retries || !provider.IsTransientError(err) {
+			return "", attempt, err
+		}
+		s.logger.Warn("PM chat provider error, retrying", "ticketID", ticket.ID, "attempt", attempt, "error", err)
+		time.Sleep(backoff << (attempt - 1))
+	}
+}
+
+// savePendingPMResponse keeps a chat message whose response failed so it can
+// be retried, posting a notice the first time it is saved.
+func (s *Server) savePendingPMResponse(job pmChatJob, attempts int, cause error) {
+	pending := job.pending
+	if pending == nil {
+		pending = newPendingPMResponse(job)
+	}
+	pending.Attempts += attempts
+	pending.LastError = cause.Error()
+	pending.UpdatedAt = time.Now()
+	if err := s.store.SavePendingPMResponse(pending); err != nil {
+		s.logger.Error("Failed to save pending PM response", "ticketID", job.ticketID, "error", err)
+	}
+
+	if job.pending == nil {
+		s.addPMMessage(job.ticketID, job.convID, kanban.MessageTypeStatusUpdate, pmUnavailableMessage)
+	}
+}
+
+// newPendingPMResponse returns a saved message for a job that has none yet.
+func newPendingPMResponse(job pmChatJob) *kanban.PendingPMResponse {
+	now := time.Now()
+	return &kanban.PendingPMResponse{
+		ID:             uuid.New().String(),
+		TicketID:       job.ticketID,
+		ConversationID: job.convID,
+		Message:        job.message,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+}
+
+// retryPendingPMResponses queues saved chat messages for another response
+// attempt, returning how many were queued. Messages already queued are
+// skipped, and those that don't fit in the queue stay saved for a later retry.
+func (s *Server) retryPendingPMResponses() (int, error) {
+	pending, err := s.store.GetPendingPMResponses()
+	if err != nil {
+		return 0, err
+	}
+
+	s.pmChatOnce.Do(s.startPMChatWorkers)
+	queued := 0
+	for i := range pending {
+		p := &pending[i]
+		if !s.claimPendingPMResponse(p.ID) {
+			continue
+		}
+		select {
+		case s.pmChatQueue <- pmChatJob{ticketID: p.TicketID, convID: p.ConversationID, message: p.Message, pending: p}:
+			queued++
+		default:
+			s.pmChatMu.Lock()
+			delete(s.pmChatQueued, p.ID)
+			s.pmChatMu.Unlock()
+			return queued, nil
+		}
+	}
+	return queued, nil
+}
+
+// addPMMessage posts a message from the PM to a conversation and notifies the ticket's chat.
+func (s *Server) addPMMessage(ticketID, convID string, msgType kanban.MessageType, content string) {
+	pmMsg := &kanban.ConversationMessage{
+		ID:             uuid.New().String(),
+		ConversationID: convID,
+		Agent:          "PM",
+		MessageType:    msgType,
+		Content:        content,
+		CreatedAt:      time.Now(),
+	}
+	if err := s.store.AddConversationMessage(pmMsg); err != nil {
+		s.logger.Error("Failed to add PM message", "error", err)
+		return
+	}
+
+	s.Broadcast(fmt.Sprintf("conversation-update-%s", ticketID))
+}
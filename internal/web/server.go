@@ -12,14 +12,19 @@ import (
 	"log/slog"
 	"net/http"
 	"regexp"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	factory "github.com/madhatter5501/Factory"
+	"github.com/madhatter5501/Factory/agents"
+	"github.com/madhatter5501/Factory/agents/provider"
 	"github.com/madhatter5501/Factory/internal/db"
+	"github.com/madhatter5501/Factory/internal/notify"
 	"github.com/madhatter5501/Factory/kanban"
 
+	"github.com/google/uuid"
 	"github.com/yuin/goldmark"
 )
 
@@ -36,12 +41,17 @@ type Server struct {
 	templates *template.Template
 	logger    *slog.Logger
 	server    *http.Server
+	notifier  notify.Notifier
 
-	// SSE clients
-	sseClients   map[chan string]bool
+	// SSE clients and the recent events replayed to reconnecting clients
+	sseClients   map[chan sseEvent]bool
+	sseHistory   *sseHistory
 	sseMu        sync.RWMutex
 	shutdownOnce sync.Once
 
+	// Closed on shutdown to stop the background health check
+	healthStop chan struct{}
+
 	// Orchestrator management
 	orchestrator  *factory.Orchestrator
 	orchConfig    factory.Config
@@ -51,11 +61,30 @@ type Server struct {
 	orchRunning   bool
 	orchMu        sync.RWMutex
 	orchStartedAt time.Time
+
+	// PM chat responses, generated by a bounded worker pool
+	pmChatQueue chan pmChatJob
+	pmChatOnce  sync.Once
+	pmResponder func(ticket *kanban.Ticket, history, userMessage string) (string, error) // nil uses the pm-chat provider
+
+	// Saved PM chat messages queued or being answered, by ID
+	pmChatMu     sync.Mutex
+	pmChatQueued map[string]bool
+
+	// Attachment uploads in flight, bounded by max_concurrent_uploads
+	uploadSem  chan struct{}
+	uploadOnce sync.Once
+
+	// Global in-flight agent ceiling, shared with the orchestrator it starts
+	agentLimiter *agents.AgentLimiter
+
+	providers *provider.Factory
 }
 
 // NewServer creates a new dashboard server (without orchestrator management).
 func NewServer(database *db.DB, logger *slog.Logger) (*Server, error) {
 	store := db.NewStore(database)
+	store.OnStatusChange(notify.StatusChangeHook(store, logger))
 
 	// Parse templates
 	tmpl, err := template.New("").Funcs(templateFuncs()).ParseFS(templatesFS, "templates/*.html", "templates/partials/*.html")
@@ -64,17 +93,22 @@ func NewServer(database *db.DB, logger *slog.Logger) (*Server, error) {
 	}
 
 	return &Server{
-		store:      store,
-		db:         database,
-		templates:  tmpl,
-		logger:     logger,
-		sseClients: make(map[chan string]bool),
+		store:        store,
+		db:           database,
+		templates:    tmpl,
+		logger:       logger,
+		notifier:     newNotifier(store, logger),
+		sseClients:   make(map[chan sseEvent]bool),
+		healthStop:   make(chan struct{}),
+		providers:    provider.NewFactory(),
+		agentLimiter: newAgentLimiter(store),
 	}, nil
 }
 
 // NewServerWithOrchestrator creates a dashboard server that can control an orchestrator.
 func NewServerWithOrchestrator(database *db.DB, logger *slog.Logger, repoRoot string, config factory.Config) (*Server, error) {
 	store := db.NewStore(database)
+	store.OnStatusChange(notify.StatusChangeHook(store, logger))
 
 	// Parse templates
 	tmpl, err := template.New("").Funcs(templateFuncs()).ParseFS(templatesFS, "templates/*.html", "templates/partials/*.html")
@@ -82,17 +116,60 @@ func NewServerWithOrchestrator(database *db.DB, logger *slog.Logger, repoRoot st
 		return nil, fmt.Errorf("failed to parse templates: %w", err)
 	}
 
+	// PM chat and the orchestrator's agents share one ceiling
+	if config.AgentLimiter == nil {
+		config.AgentLimiter = agents.NewAgentLimiter(config.MaxTotalAgents)
+	}
+
+	// The orchestrator's escalations go through the dashboard's notifier
+	notifier := newNotifier(store, logger)
+	if config.Notifier == nil {
+		config.Notifier = notifier
+	}
+
 	return &Server{
 		store:        store,
 		db:           database,
 		templates:    tmpl,
 		logger:       logger,
-		sseClients:   make(map[chan string]bool),
+		notifier:     notifier,
+		sseClients:   make(map[chan sseEvent]bool),
+		healthStop:   make(chan struct{}),
+		providers:    provider.NewFactory(),
 		orchConfig:   config,
 		orchRepoRoot: repoRoot,
+		agentLimiter: config.AgentLimiter,
 	}, nil
 }
 
+// newAgentLimiter creates the global agent ceiling from the max_total_agents
+// config key for a server without an orchestrator (0 or unset is unlimited).
+func newAgentLimiter(store *db.Store) *agents.AgentLimiter {
+	v, _ := store.GetConfigValue("max_total_agents")
+	n, _ := strconv.Atoi(v)
+	return agents.NewAgentLimiter(n)
+}
+
+// defaultNotificationInterval is the minimum time, in seconds, between
+// notifications about the same ticket; overridable via the
+// notification_min_interval config key (0 disables coalescing).
+const defaultNotificationInterval = 30
+
+// newNotifier creates the operator notifier, coalescing rapid notifications
+// about the same ticket into one.
+func newNotifier(store *db.Store, logger *slog.Logger) notify.Notifier {
+	interval := defaultNotificationInterval
+	if v, _ := store.GetConfigValue("notification_min_interval"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			interval = n
+		}
+	}
+	if interval == 0 {
+		return notify.NewLogNotifier(logger)
+	}
+	return notify.NewCoalescer(notify.NewLogNotifier(logger), time.Duration(interval)*time.Second)
+}
+
 // StartOrchestrator creates and starts the orchestrator.
 func (s *Server) StartOrchestrator() error {
 	s.orchMu.Lock()
@@ -160,12 +237,47 @@ func (s *Server) StopOrchestrator() {
 	s.orchRunning = false
 }
 
+// StepOrchestrator runs a single orchestration cycle now, even while the
+// orchestrator is stopped, creating one if none has been started yet.
+func (s *Server) StepOrchestrator() (*factory.CycleSummary, error) {
+	s.orchMu.Lock()
+	orch, ctx := s.orchestrator, s.orchCtx
+	if !s.orchRunning {
+		// Agents spawned by the step outlive the request
+		ctx = context.Background()
+	}
+	if orch == nil {
+		var err error
+		orch, err = factory.NewOrchestrator(s.orchRepoRoot, s.orchConfig, s.store)
+		if err != nil {
+			s.orchMu.Unlock()
+			return nil, fmt.Errorf("failed to create orchestrator: %w", err)
+		}
+		if err := orch.Initialize(); err != nil {
+			s.orchMu.Unlock()
+			return nil, fmt.Errorf("failed to initialize orchestrator: %w", err)
+		}
+		s.orchestrator = orch
+	}
+	s.orchMu.Unlock()
+
+	summary, err := orch.Step(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	s.Broadcast("board-update")
+	return summary, nil
+}
+
 // OrchestratorStatus represents the orchestrator's current status.
 type OrchestratorStatus struct {
 	Running   bool             `json:"running"`
 	StartedAt time.Time        `json:"startedAt,omitempty"`
 	Uptime    string           `json:"uptime,omitempty"`
 	Metrics   *factory.Metrics `json:"metrics,omitempty"`
+
+	Backpressure *factory.Backpressure `json:"backpressure,omitempty"`
 }
 
 // GetOrchestratorStatus returns the current orchestrator status.
@@ -182,6 +294,8 @@ func (s *Server) GetOrchestratorStatus() OrchestratorStatus {
 		status.Uptime = time.Since(s.orchStartedAt).Round(time.Second).String()
 		metrics := s.orchestrator.GetMetrics()
 		status.Metrics = &metrics
+		backpressure := s.orchestrator.GetBackpressure()
+		status.Backpressure = &backpressure
 	}
 
 	return status
@@ -211,6 +325,7 @@ func templateFuncs() template.FuncMap {
 				"NEEDS_EXPERT":  "purple",
 				"AWAITING_USER": "yellow",
 				"READY":         "green",
+				"QUEUED":        "gray",
 				"IN_DEV":        "cyan",
 				"IN_QA":         "orange",
 				"IN_UX":         "pink",
@@ -490,6 +605,7 @@ func templateFuncs() template.FuncMap {
 				"dependency": "link",
 				"bug":        "bug",
 				"policy":     "shield",
+				"conflict":   "git-branch",
 				"confidence": "brain",
 				"ambiguous":  "help-circle",
 				"issue":      "alert-triangle",
@@ -544,15 +660,20 @@ func templateFuncs() template.FuncMap {
 		// Human-readable status name for PM_REVIEW clarification
 		"statusDisplayName": func(status kanban.Status) string {
 			names := map[kanban.Status]string{
-				kanban.StatusPMReview:     "Awaiting Decision",
-				kanban.StatusAwaitingUser: "Requires Confirmation",
-				kanban.StatusBlocked:      "Blocked",
-				kanban.StatusInDev:        "In Development",
-				kanban.StatusInQA:         "In QA",
-				kanban.StatusInUX:         "In UX Review",
-				kanban.StatusInSec:        "In Security Review",
-				kanban.StatusDone:         "Complete",
-				kanban.StatusReady:        "Ready",
+				kanban.StatusIcebox:                "Icebox",
+				kanban.StatusPMReview:              "Awaiting Decision",
+				kanban.StatusAwaitingMergeApproval: "Awaiting Merge Approval",
+				kanban.StatusPendingDone:           "Soaking Before Done",
+				kanban.StatusAwaitingUser:          "Requires Confirmation",
+				kanban.StatusBlocked:               "Blocked",
+				kanban.StatusAbandoned:             "Abandoned",
+				kanban.StatusInDev:                 "In Development",
+				kanban.StatusInQA:                  "In QA",
+				kanban.StatusInUX:                  "In UX Review",
+				kanban.StatusInSec:                 "In Security Review",
+				kanban.StatusDone:                  "Complete",
+				kanban.StatusReady:                 "Ready",
+				kanban.StatusQueued:                "Queued",
 			}
 			if name, ok := names[status]; ok {
 				return name
@@ -564,6 +685,23 @@ func templateFuncs() template.FuncMap {
 
 // Start starts the HTTP server.
 func (s *Server) Start(addr string) error {
+	s.server = &http.Server{
+		Addr:         addr,
+		Handler:      s.routes(),
+		ReadTimeout:  15 * time.Second,
+		WriteTimeout: 15 * time.Second,
+		IdleTimeout:  60 * time.Second,
+	}
+
+	s.sweepPendingUploads()
+	go s.runHealthChecks()
+
+	s.logger.Info("Starting dashboard server", "addr", addr)
+	return s.server.ListenAndServe()
+}
+
+// routes builds the server's HTTP handler.
+func (s *Server) routes() http.Handler {
 	mux := http.NewServeMux()
 
 	// Static files
@@ -579,60 +717,112 @@ func (s *Server) Start(addr string) error {
 	mux.HandleFunc("GET /new", s.handleNewTicket)
 	mux.HandleFunc("GET /wizard", s.handleWizard)
 
+	// Public read-only status page, shareable outside the dashboard
+	mux.HandleFunc("GET /status", s.handleStatus)
+
 	// API routes
 	mux.HandleFunc("GET /api/board", s.apiGetBoard)
 	mux.HandleFunc("GET /api/tickets", s.apiGetTickets)
+	mux.HandleFunc("GET /api/tickets/search", s.apiSearchTickets)
 	mux.HandleFunc("GET /api/tickets/{id}", s.apiGetTicket)
+	mux.HandleFunc("GET /api/tickets/{id}/full", s.apiGetTicketFull)
 	mux.HandleFunc("POST /api/tickets", s.apiCreateTicket)
+	mux.HandleFunc("POST /api/tickets/recompute-context", s.apiRecomputeTicketContext)
 	mux.HandleFunc("PATCH /api/tickets/{id}", s.apiUpdateTicket)
 	mux.HandleFunc("POST /api/tickets/{id}/ready", s.apiApproveTicket)
+	mux.HandleFunc("POST /api/tickets/{id}/promote", s.apiPromoteTicket)
+	mux.HandleFunc("POST /api/tickets/{id}/pause", s.apiPauseTicket)
+	mux.HandleFunc("POST /api/tickets/{id}/resume", s.apiResumeTicket)
+	mux.HandleFunc("POST /api/tickets/{id}/approve-merge", s.apiApproveMerge)
+	mux.HandleFunc("POST /api/tickets/{id}/override-signoff", s.apiOverrideSignoff)
 	mux.HandleFunc("POST /api/tickets/{id}/answer", s.apiAnswerQuestion)
 	mux.HandleFunc("DELETE /api/tickets/{id}", s.apiDeleteTicket)
 	mux.HandleFunc("GET /api/stats", s.apiGetStats)
+	mux.HandleFunc("GET /api/reports/burndown", s.apiGetBurndown)
+	mux.HandleFunc("GET /api/iterations/{id}/digest", s.apiGetIterationDigest)
+	mux.HandleFunc("GET /api/reports/providers", s.apiGetProviderUsage)
+	mux.HandleFunc("GET /api/costs", s.apiGetCosts)
+	mux.HandleFunc("GET /api/reports/changed-files", s.apiGetChangedFiles)
+	mux.HandleFunc("GET /api/reports/time-stats.csv", s.apiExportTimeStats)
+	mux.HandleFunc("GET /api/reports/dependency-graph", s.apiGetDependencyGraph)
 	mux.HandleFunc("GET /api/runs", s.apiGetRuns)
 	mux.HandleFunc("POST /api/wizard", s.apiWizard)
 
 	// Conversation API routes
 	mux.HandleFunc("GET /api/tickets/{id}/conversations", s.apiGetConversations)
 	mux.HandleFunc("POST /api/tickets/{id}/conversations", s.apiCreateConversation)
+	mux.HandleFunc("GET /api/tickets/{id}/transcript", s.apiGetTicketTranscript)
+	mux.HandleFunc("GET /api/tickets/{id}/suggested-dependencies", s.apiGetSuggestedDependencies)
 	mux.HandleFunc("GET /api/conversations/{id}", s.apiGetConversation)
 	mux.HandleFunc("POST /api/conversations/{id}/messages", s.apiAddMessage)
 	mux.HandleFunc("POST /api/conversations/{id}/resolve", s.apiResolveConversation)
 
+	// Admin routes
+	mux.HandleFunc("GET /api/admin/orphans", s.apiGetOrphans)
+	mux.HandleFunc("POST /api/admin/orphans/cleanup", s.apiCleanupOrphans)
+	mux.HandleFunc("GET /api/admin/prune-preview", s.apiGetPrunePreview)
+
 	// Chat API routes (simplified user chat)
 	mux.HandleFunc("POST /api/tickets/{id}/chat", s.apiPostChat)
 	mux.HandleFunc("GET /api/tickets/{id}/messages", s.apiGetTicketMessages)
+	mux.HandleFunc("GET /api/pm/pending", s.apiGetPendingPMResponses)
+	mux.HandleFunc("POST /api/pm/pending/retry", s.apiRetryPendingPMResponses)
 
 	// Audit API routes
 	mux.HandleFunc("GET /api/audit", s.apiGetAuditLog)
 	mux.HandleFunc("GET /api/runs/{id}/audit", s.apiGetRunAudit)
+	mux.HandleFunc("GET /api/runs/{id}/prompt", s.apiGetRunPrompt)
 
 	// PM Check-in API routes
 	mux.HandleFunc("GET /api/tickets/{id}/checkins", s.apiGetPMCheckins)
 	mux.HandleFunc("GET /api/checkins/unresolved", s.apiGetUnresolvedCheckins)
+	mux.HandleFunc("POST /api/checkins/resolve", s.apiResolvePMCheckins)
 	mux.HandleFunc("POST /api/checkins/{id}/resolve", s.apiResolvePMCheckin)
 
+	// Health event API routes
+	mux.HandleFunc("GET /api/health/events", s.apiGetHealthEvents)
+	mux.HandleFunc("POST /api/health/events/{id}/ack", s.apiAckHealthEvent)
+	mux.HandleFunc("POST /api/health/simulate", s.apiSimulateHealth)
+
 	// Attachment API routes
 	mux.HandleFunc("POST /api/messages/{messageID}/attachments", s.apiUploadAttachment)
 	mux.HandleFunc("GET /api/attachments/{id}", s.apiGetAttachment)
 
 	// Recent runs API routes
 	mux.HandleFunc("GET /api/runs/recent", s.apiGetRecentRuns)
+	mux.HandleFunc("GET /api/runs/no-signoff", s.apiGetRunsWithoutSignoff)
 	mux.HandleFunc("GET /api/runs/{id}", s.apiGetRunDetail)
 
 	// Worktree management API routes
 	mux.HandleFunc("GET /api/worktrees", s.apiGetWorktreePool)
 	mux.HandleFunc("GET /api/worktrees/pool", s.apiGetWorktreePoolStats)
+	mux.HandleFunc("GET /api/worktrees/slots", s.apiGetWorktreeSlots)
 	mux.HandleFunc("GET /api/merge-queue", s.apiGetMergeQueue)
 	mux.HandleFunc("GET /api/worktrees/{ticketID}/events", s.apiGetWorktreeEvents)
+	mux.HandleFunc("GET /api/tickets/{id}/commits", s.apiGetTicketCommits)
 	mux.HandleFunc("GET /api/worktrees/events/recent", s.apiGetRecentWorktreeEvents)
 
+	// Activity feed API route
+	mux.HandleFunc("GET /api/activity", s.apiGetActivity)
+
+	// Webhook API route
+	mux.HandleFunc("POST /api/webhooks/test", s.apiTestWebhook)
+
 	// Provider settings API routes
 	mux.HandleFunc("GET /api/settings/providers", s.apiGetProviderConfigs)
 	mux.HandleFunc("PATCH /api/settings/providers", s.apiUpdateProviderConfigs)
+	mux.HandleFunc("GET /api/providers/health", s.apiGetProviderHealth)
 	mux.HandleFunc("GET /api/settings/agents/{agentType}/prompt", s.apiGetAgentSystemPrompt)
 	mux.HandleFunc("PATCH /api/settings/agents/{agentType}/prompt", s.apiUpdateAgentSystemPrompt)
 	mux.HandleFunc("DELETE /api/settings/agents/{agentType}/prompt", s.apiDeleteAgentSystemPrompt)
+	mux.HandleFunc("POST /api/agents/reassign", s.apiReassignAgent)
+
+	// Config profiles
+	mux.HandleFunc("GET /api/config/schema", s.apiGetConfigSchema)
+	mux.HandleFunc("PUT /api/config", s.apiUpdateConfig)
+	mux.HandleFunc("GET /api/config/profiles", s.apiGetConfigProfiles)
+	mux.HandleFunc("POST /api/config/profiles", s.apiSaveConfigProfile)
+	mux.HandleFunc("POST /api/config/profiles/{name}/activate", s.apiActivateConfigProfile)
 
 	// SSE for real-time updates
 	mux.HandleFunc("GET /api/events", s.handleSSE)
@@ -641,14 +831,18 @@ func (s *Server) Start(addr string) error {
 	mux.HandleFunc("GET /api/orchestrator/status", s.apiGetOrchestratorStatus)
 	mux.HandleFunc("POST /api/orchestrator/start", s.apiStartOrchestrator)
 	mux.HandleFunc("POST /api/orchestrator/stop", s.apiStopOrchestrator)
+	mux.HandleFunc("POST /api/orchestrator/step", s.apiStepOrchestrator)
 
 	// ADRs (Architecture Decision Records)
 	mux.HandleFunc("GET /api/adrs", s.apiGetADRs)
 	mux.HandleFunc("GET /api/adrs/{id}", s.apiGetADR)
 	mux.HandleFunc("POST /api/adrs", s.apiCreateADR)
+	mux.HandleFunc("POST /api/adrs/import", s.apiImportADRs)
+	mux.HandleFunc("POST /api/adrs/{id}/supersede", s.apiSupersedeADR)
 	mux.HandleFunc("PATCH /api/adrs/{id}", s.apiUpdateADR)
 	mux.HandleFunc("DELETE /api/adrs/{id}", s.apiDeleteADR)
 	mux.HandleFunc("GET /api/tickets/{id}/adrs", s.apiGetTicketADRs)
+	mux.HandleFunc("GET /api/adrs/{id}/tickets", s.apiGetADRTickets)
 	mux.HandleFunc("POST /api/adrs/{id}/tickets/{ticketID}", s.apiLinkADRToTicket)
 	mux.HandleFunc("DELETE /api/adrs/{id}/tickets/{ticketID}", s.apiUnlinkADRFromTicket)
 
@@ -659,6 +853,10 @@ func (s *Server) Start(addr string) error {
 	mux.HandleFunc("PATCH /api/tags/{id}", s.apiUpdateTag)
 	mux.HandleFunc("DELETE /api/tags/{id}", s.apiDeleteTag)
 	mux.HandleFunc("GET /api/tags/{id}/tickets", s.apiGetTicketsByTag)
+	mux.HandleFunc("GET /api/tags/{id}/stats", s.apiGetTagStats)
+	mux.HandleFunc("GET /api/tags/stats", s.apiGetEpicStats)
+	mux.HandleFunc("GET /api/tags/unused", s.apiGetUnusedTags)
+	mux.HandleFunc("POST /api/tags/cleanup", s.apiCleanupTags)
 	mux.HandleFunc("GET /api/tickets/{id}/tags", s.apiGetTicketTags)
 	mux.HandleFunc("POST /api/tickets/{id}/tags/{tagID}", s.apiAddTagToTicket)
 	mux.HandleFunc("DELETE /api/tickets/{id}/tags/{tagID}", s.apiRemoveTagFromTicket)
@@ -668,16 +866,7 @@ func (s *Server) Start(addr string) error {
 	mux.HandleFunc("GET /partials/ticket/{id}", s.partialTicket)
 	mux.HandleFunc("POST /partials/tickets/{id}/ready", s.partialApproveTicket)
 
-	s.server = &http.Server{
-		Addr:         addr,
-		Handler:      s.withLogging(mux),
-		ReadTimeout:  15 * time.Second,
-		WriteTimeout: 15 * time.Second,
-		IdleTimeout:  60 * time.Second,
-	}
-
-	s.logger.Info("Starting dashboard server", "addr", addr)
-	return s.server.ListenAndServe()
+	return s.withLogging(withTracing(mux))
 }
 
 // Shutdown gracefully shuts down the server.
@@ -690,6 +879,13 @@ func (s *Server) Shutdown(ctx context.Context) error {
 			delete(s.sseClients, ch)
 		}
 		s.sseMu.Unlock()
+
+		close(s.healthStop)
+
+		// Deliver notifications still waiting out their interval
+		if c, ok := s.notifier.(*notify.Coalescer); ok {
+			c.Flush()
+		}
 	})
 
 	if s.server != nil {
@@ -698,20 +894,53 @@ func (s *Server) Shutdown(ctx context.Context) error {
 	return nil
 }
 
-// Broadcast sends an SSE event to all clients.
+// feedEvents maps the broadcast events worth keeping in the activity feed to
+// their feed message. Refresh signals like board-update are left out; the
+// store records the changes behind them.
+var feedEvents = map[string]string{
+	"orchestrator:started":  "Orchestrator started",
+	"orchestrator:stopped":  "Orchestrator stopped",
+	"conversation-created":  "Conversation started",
+	"conversation-resolved": "Conversation resolved",
+}
+
+// Broadcast sends an SSE event to all clients and records it for replay.
 func (s *Server) Broadcast(event string) {
-	s.sseMu.RLock()
-	defer s.sseMu.RUnlock()
+	if msg, ok := feedEvents[event]; ok {
+		if err := s.store.AddActivity(&kanban.Activity{Kind: kanban.ActivityEvent, Message: msg}); err != nil {
+			s.logger.Warn("Failed to record activity", "event", event, "error", err)
+		}
+	}
 
+	history := s.eventHistory()
+
+	s.sseMu.Lock()
+	defer s.sseMu.Unlock()
+
+	ev := history.add(event)
 	for ch := range s.sseClients {
 		select {
-		case ch <- event:
+		case ch <- ev:
 		default:
 			// Client too slow, skip
 		}
 	}
 }
 
+// configInt reads an integer config value of at least minimum, falling back to def.
+func (s *Server) configInt(key string, def, minimum int) int {
+	v, _ := s.store.GetConfigValue(key)
+	if v == "" {
+		return def
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < minimum {
+		s.logger.Warn("Invalid config value, using default", "key", key, "value", v, "default", def)
+		return def
+	}
+	return n
+}
+
 // withLogging wraps a handler with request logging.
 func (s *Server) withLogging(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -724,6 +953,31 @@ func (s *Server) withLogging(next http.Handler) http.Handler {
 	})
 }
 
+// traceIDKey is the context key for the request trace ID.
+type traceIDKey struct{}
+
+// TraceIDHeader carries the trace ID on requests and responses.
+const TraceIDHeader = "X-Trace-Id"
+
+// withTracing assigns each request a trace ID, reusing one supplied by the caller.
+// The ID is stored in the request context and echoed in the X-Trace-Id response header.
+func withTracing(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceID := r.Header.Get(TraceIDHeader)
+		if traceID == "" || len(traceID) > 64 {
+			traceID = uuid.New().String()
+		}
+		w.Header().Set(TraceIDHeader, traceID)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), traceIDKey{}, traceID)))
+	})
+}
+
+// traceIDFromContext returns the trace ID of the current request, if any.
+func traceIDFromContext(ctx context.Context) string {
+	traceID, _ := ctx.Value(traceIDKey{}).(string)
+	return traceID
+}
+
 // render executes a template.
 func (s *Server) render(w http.ResponseWriter, name string, data interface{}) {
 	w.Header().Set("Content-Type", "text/html; charset=utf-8")
@@ -3,9 +3,90 @@ package web
 import (
 	"fmt"
 	"net/http"
+	"strconv"
 )
 
-// handleSSE handles Server-Sent Events for real-time updates.
+// defaultSSEHistorySize is how many recent events are kept for replay,
+// overridable via the sse_history_size config key.
+const defaultSSEHistorySize = 256
+
+// sseEvent is a broadcast event with its position in the event stream.
+type sseEvent struct {
+	ID   uint64
+	Name string
+}
+
+// sseHistory is a fixed-size ring of the most recent events. Once full, each new
+// event evicts the oldest, so a reconnecting client can be replayed everything it
+// missed only if fewer than size events were broadcast while it was away.
+type sseHistory struct {
+	events []sseEvent
+	next   int    // Ring index the next event is written to
+	count  int    // Number of events held, up to len(events)
+	lastID uint64 // ID of the most recent event; IDs start at 1
+}
+
+func newSSEHistory(size int) *sseHistory {
+	return &sseHistory{events: make([]sseEvent, size)}
+}
+
+// add assigns the next ID to an event and records it, evicting the oldest event
+// when the ring is full.
+func (h *sseHistory) add(name string) sseEvent {
+	h.lastID++
+	ev := sseEvent{ID: h.lastID, Name: name}
+	h.events[h.next] = ev
+	h.next = (h.next + 1) % len(h.events)
+	if h.count < len(h.events) {
+		h.count++
+	}
+	return ev
+}
+
+// since returns the events after lastID, oldest first. ok is false when events
+// after lastID have been evicted, or lastID is from another server instance, so
+// the client cannot be caught up by replay.
+func (h *sseHistory) since(lastID uint64) (events []sseEvent, ok bool) {
+	if lastID > h.lastID {
+		return nil, false
+	}
+	oldest := h.lastID - uint64(h.count) + 1
+	if lastID+1 < oldest {
+		return nil, false
+	}
+
+	start := (h.next - h.count + len(h.events)) % len(h.events)
+	for i := 0; i < h.count; i++ {
+		ev := h.events[(start+i)%len(h.events)]
+		if ev.ID > lastID {
+			events = append(events, ev)
+		}
+	}
+	return events, true
+}
+
+// eventHistory returns the event history, creating it with the configured size on first use.
+func (s *Server) eventHistory() *sseHistory {
+	s.sseMu.RLock()
+	history := s.sseHistory
+	s.sseMu.RUnlock()
+	if history != nil {
+		return history
+	}
+
+	size := s.configInt("sse_history_size", defaultSSEHistorySize, 1)
+	s.sseMu.Lock()
+	defer s.sseMu.Unlock()
+	if s.sseHistory == nil {
+		s.sseHistory = newSSEHistory(size)
+	}
+	return s.sseHistory
+}
+
+// handleSSE handles Server-Sent Events for real-time updates. Each event carries
+// an id; a client reconnecting with Last-Event-ID is replayed the events it
+// missed, or sent a resync event telling it to refetch the board when they are
+// no longer held.
 func (s *Server) handleSSE(w http.ResponseWriter, r *http.Request) {
 	// Set headers for SSE
 	w.Header().Set("Content-Type", "text/event-stream")
@@ -13,12 +94,25 @@ func (s *Server) handleSSE(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Connection", "keep-alive")
 	w.Header().Set("Access-Control-Allow-Origin", "*")
 
+	history := s.eventHistory()
+
 	// Create channel for this client
-	messageChan := make(chan string, 10)
+	messageChan := make(chan sseEvent, 10)
 
-	// Register client
+	// Register client, collecting missed events under the same lock so none are
+	// lost or repeated between replay and live delivery
+	var replay []sseEvent
+	resync := false
 	s.sseMu.Lock()
 	s.sseClients[messageChan] = true
+	if v := r.Header.Get("Last-Event-ID"); v != "" {
+		lastID, err := strconv.ParseUint(v, 10, 64)
+		var ok bool
+		if err == nil {
+			replay, ok = history.since(lastID)
+		}
+		resync = !ok
+	}
 	s.sseMu.Unlock()
 
 	// Cleanup on disconnect
@@ -38,9 +132,16 @@ func (s *Server) handleSSE(w http.ResponseWriter, r *http.Request) {
 
 	// Send initial connection message
 	fmt.Fprintf(w, "event: connected\ndata: {\"status\":\"connected\"}\n\n")
+	if resync {
+		fmt.Fprintf(w, "event: resync\ndata: {\"type\":\"resync\"}\n\n")
+		s.logger.Debug("SSE client too far behind, requesting resync")
+	}
+	for _, ev := range replay {
+		writeSSEEvent(w, ev)
+	}
 	flusher.Flush()
 
-	s.logger.Debug("SSE client connected")
+	s.logger.Debug("SSE client connected", "replayed", len(replay))
 
 	// Stream events to client
 	for {
@@ -48,13 +149,17 @@ func (s *Server) handleSSE(w http.ResponseWriter, r *http.Request) {
 		case <-r.Context().Done():
 			s.logger.Debug("SSE client disconnected")
 			return
-		case msg, ok := <-messageChan:
+		case ev, ok := <-messageChan:
 			if !ok {
 				return
 			}
-			// Send as htmx-compatible event
-			fmt.Fprintf(w, "event: %s\ndata: {\"type\":\"%s\"}\n\n", msg, msg)
+			writeSSEEvent(w, ev)
 			flusher.Flush()
 		}
 	}
 }
+
+// writeSSEEvent writes an event in the htmx-compatible format.
+func writeSSEEvent(w http.ResponseWriter, ev sseEvent) {
+	fmt.Fprintf(w, "id: %d\nevent: %s\ndata: {\"type\":\"%s\"}\n\n", ev.ID, ev.Name, ev.Name)
+}
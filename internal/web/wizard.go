@@ -134,6 +134,7 @@ func (s *Server) apiWizard(w http.ResponseWriter, r *http.Request) {
 	case "create":
 		// Create the ticket
 		ticket := wizardDataToTicket(&session.Data)
+		ticket.TraceID = traceIDFromContext(r.Context())
 		if err := s.store.CreateTicket(ticket); err != nil {
 			s.logger.Error("Failed to create ticket from wizard", "error", err)
 			s.jsonError(w, "Failed to create ticket", http.StatusInternalServerError)
@@ -0,0 +1,89 @@
+package kanban
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// adrTitlePrefixRe matches numbering in front of an ADR title, e.g. "1. ", "0007 - " or "ADR-003: ".
+var adrTitlePrefixRe = regexp.MustCompile(`^(?:(?i:adr)[-\s]*)?\d+\s*[.:)-]\s*`)
+
+// ParseADRMarkdown parses an ADR written in the common markdown layout: a "# Title"
+// heading (or a "## Title" section) followed by "## Status", "## Context",
+// "## Decision" and "## Consequences" sections. Title, Context and Decision are
+// required; a missing status defaults to proposed. The returned ADR has no ID.
+func ParseADRMarkdown(content string) (*ADR, error) {
+	var title string
+	sections := make(map[string]*strings.Builder)
+	var current *strings.Builder
+
+	for _, line := range strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(trimmed, "# "):
+			if title == "" {
+				title = strings.TrimSpace(strings.TrimPrefix(trimmed, "# "))
+			}
+			current = nil
+		case strings.HasPrefix(trimmed, "## "):
+			name := strings.ToLower(strings.TrimSpace(strings.TrimPrefix(trimmed, "## ")))
+			current = &strings.Builder{}
+			sections[name] = current
+		case current != nil:
+			current.WriteString(line)
+			current.WriteString("\n")
+		}
+	}
+
+	section := func(name string) string {
+		if b, ok := sections[name]; ok {
+			return strings.TrimSpace(b.String())
+		}
+		return ""
+	}
+
+	if title == "" {
+		title = section("title")
+	}
+	title = strings.TrimSpace(adrTitlePrefixRe.ReplaceAllString(title, ""))
+	if title == "" {
+		return nil, fmt.Errorf("missing title")
+	}
+
+	adr := &ADR{
+		Title:        title,
+		Status:       ADRStatusProposed,
+		Context:      section("context"),
+		Decision:     section("decision"),
+		Consequences: section("consequences"),
+	}
+	if adr.Context == "" {
+		return nil, fmt.Errorf("missing Context section")
+	}
+	if adr.Decision == "" {
+		return nil, fmt.Errorf("missing Decision section")
+	}
+
+	if raw := section("status"); raw != "" {
+		status, err := parseADRStatus(raw)
+		if err != nil {
+			return nil, err
+		}
+		adr.Status = status
+	}
+
+	return adr, nil
+}
+
+// parseADRStatus reads the status from the first word of a Status section,
+// e.g. "Accepted" or "Superseded by ADR-0005".
+func parseADRStatus(raw string) (ADRStatus, error) {
+	word := strings.ToLower(strings.Trim(strings.Fields(raw)[0], "*_.,:"))
+	switch ADRStatus(word) {
+	case ADRStatusProposed, ADRStatusAccepted, ADRStatusDeprecated, ADRStatusSuperseded:
+		return ADRStatus(word), nil
+	default:
+		return "", fmt.Errorf("unknown status %q", word)
+	}
+}
@@ -0,0 +1,73 @@
+package kanban
+
+import "testing"
+
+func TestParseADRMarkdown(t *testing.T) {
+	const nygard = `# 3. Use SQLite for board state
+
+Date: 2024-02-01
+
+## Status
+
+Accepted
+
+## Context
+
+The JSON state file is rewritten on every change
+and loses updates under concurrent agents.
+
+## Decision
+
+Store the board in SQLite.
+
+## Consequences
+
+Migrations are needed for schema changes.
+`
+
+	adr, err := ParseADRMarkdown(nygard)
+	if err != nil {
+		t.Fatalf("Expected well-formed ADR to parse, got %v", err)
+	}
+	if adr.Title != "Use SQLite for board state" {
+		t.Errorf("Expected numbering stripped from title, got %q", adr.Title)
+	}
+	if adr.Status != ADRStatusAccepted {
+		t.Errorf("Expected status accepted, got %q", adr.Status)
+	}
+	if adr.Context != "The JSON state file is rewritten on every change\nand loses updates under concurrent agents." {
+		t.Errorf("Unexpected context %q", adr.Context)
+	}
+	if adr.Decision != "Store the board in SQLite." || adr.Consequences != "Migrations are needed for schema changes." {
+		t.Errorf("Unexpected decision/consequences %q / %q", adr.Decision, adr.Consequences)
+	}
+
+	// Title as a section, superseded status with a link, no consequences
+	adr, err = ParseADRMarkdown("## Title\nADR-007: Drop the CLI spawner\n\n## Status\nSuperseded by [ADR-009](0009.md)\n\n## Context\nAPI mode is cheaper.\n\n## Decision\nRemove it.\n")
+	if err != nil {
+		t.Fatalf("Expected section-titled ADR to parse, got %v", err)
+	}
+	if adr.Title != "Drop the CLI spawner" || adr.Status != ADRStatusSuperseded || adr.Consequences != "" {
+		t.Errorf("Unexpected ADR %+v", adr)
+	}
+
+	// Missing status defaults to proposed
+	adr, err = ParseADRMarkdown("# Cache prompts\n## Context\nTokens are expensive.\n## Decision\nCache them.\n")
+	if err != nil || adr.Status != ADRStatusProposed {
+		t.Errorf("Expected proposed status by default, got %+v, %v", adr, err)
+	}
+
+	malformed := map[string]string{
+		"empty":          "",
+		"no title":       "## Context\nSomething.\n## Decision\nSomething else.\n",
+		"no context":     "# Title\n## Decision\nDo it.\n",
+		"no decision":    "# Title\n## Context\nWhy.\n",
+		"unknown status": "# Title\n## Status\nRejected\n## Context\nWhy.\n## Decision\nDo it.\n",
+		"plain notes":    "Meeting notes\n\n- talked about caching\n",
+	}
+	for name, content := range malformed {
+		if adr, err := ParseADRMarkdown(content); err == nil {
+			t.Errorf("%s: expected parse error, got %+v", name, adr)
+		}
+	}
+}
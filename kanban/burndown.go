@@ -0,0 +1,89 @@
+package kanban
+
+import "time"
+
+// BurndownPoint is the remaining work at the end of one day of an iteration.
+type BurndownPoint struct {
+	Date      time.Time `json:"date"`      // Start of the day
+	Remaining int       `json:"remaining"` // Tickets not yet done at end of day
+	Total     int       `json:"total"`     // Tickets in scope at end of day (grows as tickets are added)
+}
+
+// ComputeBurndown replays ticket history to count not-done tickets at the end of
+// each day from start to end. Tickets created mid-iteration only count from the
+// day they were created; iceboxed tickets are out of scope.
+func ComputeBurndown(tickets []Ticket, start, end time.Time) []BurndownPoint {
+	if end.Before(start) {
+		return nil
+	}
+
+	var series []BurndownPoint
+	day := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, start.Location())
+	for !day.After(end) {
+		cutoff := day.AddDate(0, 0, 1)
+		point := BurndownPoint{Date: day}
+
+		for i := range tickets {
+			status, exists := statusAt(&tickets[i], cutoff)
+			if !exists || status == StatusIcebox {
+				continue
+			}
+			point.Total++
+			if status != StatusDone {
+				point.Remaining++
+			}
+		}
+
+		series = append(series, point)
+		day = cutoff
+	}
+
+	return series
+}
+
+// statusAt returns the status a ticket had just before the given time, and
+// whether the ticket existed by then.
+func statusAt(t *Ticket, at time.Time) (Status, bool) {
+	if !t.CreatedAt.IsZero() && !t.CreatedAt.Before(at) {
+		return "", false
+	}
+
+	var status Status
+	found := false
+	for _, entry := range t.History {
+		if !entry.At.Before(at) {
+			break
+		}
+		status = entry.Status
+		found = true
+	}
+
+	if !found {
+		if t.CreatedAt.IsZero() {
+			// No creation time and no history yet: not created by then
+			return "", false
+		}
+		// Created before the cutoff but history starts later; assume its first status
+		if len(t.History) > 0 {
+			return t.History[0].Status, true
+		}
+		return t.Status, true
+	}
+	return status, true
+}
+
+// CompletedBetween returns the tickets that were done at end but not at start,
+// i.e. the work an iteration spanning that range delivered.
+func CompletedBetween(tickets []Ticket, start, end time.Time) []Ticket {
+	var completed []Ticket
+	for i := range tickets {
+		if status, exists := statusAt(&tickets[i], end); !exists || status != StatusDone {
+			continue
+		}
+		if status, exists := statusAt(&tickets[i], start); exists && status == StatusDone {
+			continue
+		}
+		completed = append(completed, tickets[i])
+	}
+	return completed
+}
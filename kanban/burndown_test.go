@@ -0,0 +1,60 @@
+package kanban
+
+import (
+	"testing"
+	"time"
+)
+
+func TestComputeBurndownReplaysHistory(t *testing.T) {
+	day0 := time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+	at := func(day, hour int) time.Time { return day0.AddDate(0, 0, day).Add(time.Duration(hour) * time.Hour) }
+
+	tickets := []Ticket{
+		{
+			ID: "A", Status: StatusDone, CreatedAt: at(0, 9),
+			History: []HistoryEntry{
+				{Status: StatusReady, At: at(0, 9)},
+				{Status: StatusInDev, At: at(1, 10)},
+				{Status: StatusDone, At: at(1, 15)},
+			},
+		},
+		{
+			ID: "B", Status: StatusDone, CreatedAt: at(0, 10),
+			History: []HistoryEntry{
+				{Status: StatusReady, At: at(0, 10)},
+				{Status: StatusDone, At: at(3, 12)},
+			},
+		},
+		{
+			// Added mid-iteration, still open
+			ID: "C", Status: StatusInDev, CreatedAt: at(2, 11),
+			History: []HistoryEntry{
+				{Status: StatusBacklog, At: at(2, 11)},
+				{Status: StatusInDev, At: at(2, 14)},
+			},
+		},
+		{
+			// Parked ideas are out of scope
+			ID: "D", Status: StatusIcebox, CreatedAt: at(0, 9),
+			History: []HistoryEntry{{Status: StatusIcebox, At: at(0, 9)}},
+		},
+	}
+
+	series := ComputeBurndown(tickets, at(0, 8), at(3, 20))
+
+	expected := []BurndownPoint{
+		{Date: at(0, 0), Remaining: 2, Total: 2},
+		{Date: at(1, 0), Remaining: 1, Total: 2},
+		{Date: at(2, 0), Remaining: 2, Total: 3},
+		{Date: at(3, 0), Remaining: 1, Total: 3},
+	}
+	if len(series) != len(expected) {
+		t.Fatalf("Expected %d days, got %d: %+v", len(expected), len(series), series)
+	}
+	for i, want := range expected {
+		got := series[i]
+		if !got.Date.Equal(want.Date) || got.Remaining != want.Remaining || got.Total != want.Total {
+			t.Errorf("Day %d: expected %+v, got %+v", i, want, got)
+		}
+	}
+}
@@ -37,7 +37,7 @@ func (s *State) hasConflictUnsafe(ticket *Ticket) bool {
 		}
 
 		// Check for file overlap
-		if filesOverlap(ticket.Files, other.Files) {
+		if FilesOverlap(ticket.Files, other.Files) {
 			return true
 		}
 	}
@@ -69,7 +69,7 @@ func (s *State) GetConflictingTickets(ticket *Ticket) []Ticket {
 			continue
 		}
 
-		if filesOverlap(ticket.Files, other.Files) {
+		if FilesOverlap(ticket.Files, other.Files) {
 			conflicts = append(conflicts, other)
 		}
 	}
@@ -77,8 +77,9 @@ func (s *State) GetConflictingTickets(ticket *Ticket) []Ticket {
 	return conflicts
 }
 
-// filesOverlap checks if any patterns from a overlap with patterns from b.
-func filesOverlap(a, b []string) bool {
+// FilesOverlap checks if any patterns from a could match the same file as a
+// pattern from b.
+func FilesOverlap(a, b []string) bool {
 	for _, patternA := range a {
 		for _, patternB := range b {
 			if patternsOverlap(patternA, patternB) {
@@ -90,11 +91,14 @@ func filesOverlap(a, b []string) bool {
 }
 
 // patternsOverlap checks if two glob patterns could match the same files.
-// This is a conservative check - it may return true even if patterns don't actually overlap.
+// Patterns are compared segment by segment, with ** matching any number of
+// directories, and a pattern naming a directory covers everything beneath it.
+// Where two wildcard segments meet the check is conservative: it may return
+// true even if the patterns don't actually overlap.
 func patternsOverlap(a, b string) bool {
 	// Normalize paths
-	a = filepath.Clean(a)
-	b = filepath.Clean(b)
+	a = filepath.ToSlash(filepath.Clean(a))
+	b = filepath.ToSlash(filepath.Clean(b))
 
 	// Direct match
 	if a == b {
@@ -106,42 +110,71 @@ func patternsOverlap(a, b string) bool {
 		return true
 	}
 
-	// Check if patterns share a common prefix
-	aParts := strings.Split(a, string(filepath.Separator))
-	bParts := strings.Split(b, string(filepath.Separator))
+	return segmentsOverlap(strings.Split(a, "/"), strings.Split(b, "/"))
+}
 
-	// Find common prefix length
-	minLen := len(aParts)
-	if len(bParts) < minLen {
-		minLen = len(bParts)
+// segmentsOverlap checks if two patterns split into path segments could match
+// the same path.
+func segmentsOverlap(a, b []string) bool {
+	switch {
+	case len(a) == 0:
+		return onlyRecursive(b)
+	case len(b) == 0:
+		return onlyRecursive(a)
+	case a[0] == "**":
+		// ** matches no segments, or consumes one of b's and keeps going
+		return segmentsOverlap(a[1:], b) || segmentsOverlap(a, b[1:])
+	case b[0] == "**":
+		return segmentsOverlap(a, b[1:]) || segmentsOverlap(a[1:], b)
+	default:
+		return segmentOverlaps(a[0], b[0]) && segmentsOverlap(a[1:], b[1:])
 	}
+}
 
-	commonPrefixLen := 0
-	for i := 0; i < minLen; i++ {
-		if aParts[i] == bParts[i] || aParts[i] == "*" || bParts[i] == "*" ||
-			aParts[i] == "**" || bParts[i] == "**" {
-			commonPrefixLen++
-		} else {
-			break
+// onlyRecursive reports whether every segment is **, which can match nothing.
+func onlyRecursive(segments []string) bool {
+	for _, s := range segments {
+		if s != "**" {
+			return false
 		}
 	}
+	return true
+}
 
-	// If we matched all parts of the shorter pattern, they might overlap
-	if commonPrefixLen == minLen {
-		return true
+// segmentOverlaps checks if two path segments could match the same name. A
+// literal is matched against the other pattern; two wildcards overlap unless
+// their literal prefixes or suffixes rule it out.
+func segmentOverlaps(a, b string) bool {
+	if !hasGlobMeta(a) {
+		return matchSegment(b, a)
+	}
+	if !hasGlobMeta(b) {
+		return matchSegment(a, b)
 	}
 
-	// Check for ** which matches any depth
-	if strings.Contains(a, "**") || strings.Contains(b, "**") {
-		// Conservative: if either has **, check if they share any directory
-		aDir := getFirstConcreteDir(a)
-		bDir := getFirstConcreteDir(b)
-		if aDir != "" && bDir != "" && (aDir == bDir || strings.HasPrefix(aDir, bDir) || strings.HasPrefix(bDir, aDir)) {
-			return true
-		}
+	aPrefix, bPrefix := a[:strings.IndexAny(a, globMeta)], b[:strings.IndexAny(b, globMeta)]
+	if !strings.HasPrefix(aPrefix, bPrefix) && !strings.HasPrefix(bPrefix, aPrefix) {
+		return false
 	}
+	aSuffix, bSuffix := a[strings.LastIndexAny(a, globMeta)+1:], b[strings.LastIndexAny(b, globMeta)+1:]
+	return strings.HasSuffix(aSuffix, bSuffix) || strings.HasSuffix(bSuffix, aSuffix)
+}
 
-	return false
+// globMeta holds the characters filepath.Match treats specially.
+const globMeta = "*?["
+
+func hasGlobMeta(s string) bool {
+	return strings.ContainsAny(s, globMeta)
+}
+
+// matchSegment matches name against pattern, treating a malformed pattern as
+// a literal.
+func matchSegment(pattern, name string) bool {
+	matched, err := filepath.Match(pattern, name)
+	if err != nil {
+		return pattern == name
+	}
+	return matched
 }
 
 // isParentPath checks if parent is a parent directory of child.
@@ -153,18 +186,10 @@ func isParentPath(parent, child string) bool {
 	child = strings.TrimSuffix(child, "/*")
 	child = strings.TrimSuffix(child, "/**")
 
-	return strings.HasPrefix(child, parent+string(filepath.Separator))
-}
-
-// getFirstConcreteDir returns the first non-glob directory component.
-func getFirstConcreteDir(pattern string) string {
-	parts := strings.Split(pattern, string(filepath.Separator))
-	for _, part := range parts {
-		if part != "*" && part != "**" && !strings.Contains(part, "*") {
-			return part
-		}
+	if hasGlobMeta(parent) {
+		return false
 	}
-	return ""
+	return strings.HasPrefix(child, parent+"/")
 }
 
 // ConflictMatrix returns a matrix showing which tickets conflict with each other.
@@ -180,7 +205,7 @@ func (s *State) ConflictMatrix() map[string][]string {
 			if i >= j {
 				continue // Only check each pair once
 			}
-			if filesOverlap(a.Files, b.Files) {
+			if FilesOverlap(a.Files, b.Files) {
 				matrix[a.ID] = append(matrix[a.ID], b.ID)
 				matrix[b.ID] = append(matrix[b.ID], a.ID)
 			}
@@ -218,7 +243,7 @@ func (s *State) SuggestParallelGroups(tickets []Ticket) [][]Ticket {
 			// Check if candidate conflicts with any ticket in the group
 			conflicts := false
 			for _, member := range group {
-				if filesOverlap(candidate.Files, member.Files) {
+				if FilesOverlap(candidate.Files, member.Files) {
 					conflicts = true
 					break
 				}
@@ -236,6 +261,64 @@ func (s *State) SuggestParallelGroups(tickets []Ticket) [][]Ticket {
 	return groups
 }
 
+// DependencySuggestion is an existing ticket that another ticket should wait on
+// because their file patterns overlap.
+type DependencySuggestion struct {
+	TicketID string   `json:"ticketId"`
+	Title    string   `json:"title"`
+	Status   Status   `json:"status"`
+	Files    []string `json:"files"` // The existing ticket's patterns that overlap
+}
+
+// SuggestDependencies returns the not-done tickets among others whose file
+// patterns overlap ticket's, so ticket can wait on them instead of conflicting
+// at runtime. Only tickets created earlier are suggested (ties broken by ID),
+// which keeps the later ticket waiting and inferred dependencies acyclic.
+// Existing dependencies and tickets that already depend on ticket are skipped.
+func SuggestDependencies(ticket *Ticket, others []Ticket) []DependencySuggestion {
+	if len(ticket.Files) == 0 {
+		return nil
+	}
+
+	var suggestions []DependencySuggestion
+	for _, other := range others {
+		if other.ID == ticket.ID || other.Status == StatusDone || other.Status == StatusAbandoned {
+			continue
+		}
+		if other.CreatedAt.After(ticket.CreatedAt) || (other.CreatedAt.Equal(ticket.CreatedAt) && other.ID > ticket.ID) {
+			continue
+		}
+		if containsString(ticket.Dependencies, other.ID) || containsString(other.Dependencies, ticket.ID) {
+			continue
+		}
+
+		var overlapping []string
+		for _, pattern := range other.Files {
+			if FilesOverlap(ticket.Files, []string{pattern}) {
+				overlapping = append(overlapping, pattern)
+			}
+		}
+		if len(overlapping) > 0 {
+			suggestions = append(suggestions, DependencySuggestion{
+				TicketID: other.ID,
+				Title:    other.Title,
+				Status:   other.Status,
+				Files:    overlapping,
+			})
+		}
+	}
+	return suggestions
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
 // ValidateTicketFiles checks that a ticket's file patterns are valid.
 func ValidateTicketFiles(files []string) []string {
 	var errors []string
@@ -0,0 +1,91 @@
+package kanban
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSuggestDependenciesFromFileOverlap(t *testing.T) {
+	now := time.Now()
+	ticket := &Ticket{ID: "NEW", Files: []string{"src/api/*", "src/models/user.go"}, CreatedAt: now}
+
+	others := []Ticket{
+		{ID: "API", Title: "API refactor", Status: StatusInDev, Files: []string{"src/api/handlers.go", "docs/api.md"}, CreatedAt: now.Add(-time.Hour)},
+		{ID: "USER", Status: StatusReady, Files: []string{"src/models/user.go"}, CreatedAt: now.Add(-time.Hour)},
+		{ID: "UI", Status: StatusReady, Files: []string{"web/components/*"}, CreatedAt: now.Add(-time.Hour)},
+		{ID: "DONE", Status: StatusDone, Files: []string{"src/api/*"}, CreatedAt: now.Add(-time.Hour)},
+		{ID: "LATER", Status: StatusBacklog, Files: []string{"src/api/*"}, CreatedAt: now.Add(time.Hour)},
+	}
+
+	suggestions := SuggestDependencies(ticket, others)
+	if len(suggestions) != 2 {
+		t.Fatalf("expected 2 suggestions, got %+v", suggestions)
+	}
+	if suggestions[0].TicketID != "API" || suggestions[1].TicketID != "USER" {
+		t.Errorf("expected API and USER, got %s and %s", suggestions[0].TicketID, suggestions[1].TicketID)
+	}
+	if len(suggestions[0].Files) != 1 || suggestions[0].Files[0] != "src/api/handlers.go" {
+		t.Errorf("expected only the overlapping pattern, got %v", suggestions[0].Files)
+	}
+
+	// The later ticket would be told to wait on the new one, never the reverse
+	if later := SuggestDependencies(&others[4], append(others, *ticket)); len(later) != 2 {
+		t.Errorf("expected the later ticket to wait on API and NEW, got %+v", later)
+	}
+
+	// Existing dependencies aren't suggested again
+	ticket.Dependencies = []string{"API", "USER"}
+	if again := SuggestDependencies(ticket, others); len(again) != 0 {
+		t.Errorf("expected no suggestions for existing dependencies, got %+v", again)
+	}
+}
+
+func TestSuggestDependenciesIgnoresDisjointFiles(t *testing.T) {
+	now := time.Now()
+	ticket := &Ticket{ID: "NEW", Files: []string{"src/api/*"}, CreatedAt: now}
+	others := []Ticket{
+		{ID: "UI", Status: StatusInDev, Files: []string{"web/components/*"}, CreatedAt: now.Add(-time.Hour)},
+		{ID: "DB", Status: StatusReady, Files: []string{"migrations/001.sql"}, CreatedAt: now.Add(-time.Hour)},
+		{ID: "NOFILES", Status: StatusReady, CreatedAt: now.Add(-time.Hour)},
+	}
+
+	if suggestions := SuggestDependencies(ticket, others); len(suggestions) != 0 {
+		t.Errorf("expected no suggestions for disjoint files, got %+v", suggestions)
+	}
+}
+
+func TestFilesOverlapExpandsGlobs(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b []string
+		want bool
+	}{
+		{"same literal path", []string{"src/api/users.go"}, []string{"src/api/users.go"}, true},
+		{"different literal paths", []string{"src/api/users.go"}, []string{"src/api/orders.go"}, false},
+		{"literal under directory", []string{"src/api"}, []string{"src/api/users.go"}, true},
+		{"wildcard matches literal", []string{"src/api/*"}, []string{"src/api/users.go"}, true},
+		{"extension wildcard matches literal", []string{"src/models/user.*"}, []string{"src/models/user.go"}, true},
+		{"wildcard in directory", []string{"src/*/users.go"}, []string{"src/api/users.go"}, true},
+		{"overlapping wildcards", []string{"src/api/*.go"}, []string{"src/api/user*"}, true},
+		{"recursive matches nested literal", []string{"**/users.go"}, []string{"src/api/users.go"}, true},
+		{"recursive in the middle", []string{"src/**/*.go"}, []string{"src/api/v2/users.go"}, true},
+		{"recursive matches zero directories", []string{"src/**/users.go"}, []string{"src/users.go"}, true},
+		{"disjoint extensions", []string{"src/api/*.go"}, []string{"src/api/*.ts"}, false},
+		{"disjoint directories", []string{"src/api/*"}, []string{"web/components/*"}, false},
+		{"disjoint recursive", []string{"src/**/*.go"}, []string{"web/**/*.go"}, false},
+		{"recursive with disjoint name", []string{"**/*_test.go"}, []string{"src/api/users.go"}, false},
+		{"any pattern in list", []string{"docs/*", "src/api/*"}, []string{"src/api/users.go"}, true},
+		{"no patterns", nil, []string{"src/api/users.go"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FilesOverlap(tt.a, tt.b); got != tt.want {
+				t.Errorf("FilesOverlap(%v, %v) = %v, want %v", tt.a, tt.b, got, tt.want)
+			}
+			if got := FilesOverlap(tt.b, tt.a); got != tt.want {
+				t.Errorf("FilesOverlap(%v, %v) = %v, want %v", tt.b, tt.a, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,146 @@
+package kanban
+
+import "sort"
+
+// DependencyGraph is the ticket dependency graph in a shape suited to
+// client-side graph libraries.
+type DependencyGraph struct {
+	Nodes  []GraphNode `json:"nodes"`
+	Edges  []GraphEdge `json:"edges"`
+	Cycles [][]string  `json:"cycles"` // Ticket IDs of each dependency cycle, sorted
+}
+
+// GraphNode is a ticket in the dependency graph.
+type GraphNode struct {
+	ID         string   `json:"id"`
+	Title      string   `json:"title"`
+	Status     Status   `json:"status"`
+	Domain     Domain   `json:"domain"`
+	InCycle    bool     `json:"inCycle,omitempty"`
+	Unresolved []string `json:"unresolved,omitempty"` // Dependencies matching no ticket in the graph
+}
+
+// GraphEdge points from a dependency to the ticket waiting on it.
+type GraphEdge struct {
+	From    string `json:"from"`
+	To      string `json:"to"`
+	InCycle bool   `json:"inCycle,omitempty"`
+}
+
+// BuildDependencyGraph builds the dependency graph of tickets. Dependencies may
+// name a ticket by ID or by title, as the store accepts both; ones that match no
+// ticket are listed on the node rather than dropped. Cycles are flagged on their
+// nodes and edges instead of being treated as an error.
+func BuildDependencyGraph(tickets []Ticket) DependencyGraph {
+	graph := DependencyGraph{
+		Nodes:  make([]GraphNode, 0, len(tickets)),
+		Edges:  []GraphEdge{},
+		Cycles: [][]string{},
+	}
+
+	byID := make(map[string]bool, len(tickets))
+	byTitle := make(map[string]string, len(tickets))
+	for _, t := range tickets {
+		byID[t.ID] = true
+		if _, seen := byTitle[t.Title]; !seen {
+			byTitle[t.Title] = t.ID
+		}
+	}
+
+	// dependsOn maps each ticket to the IDs of its resolved dependencies
+	dependsOn := make(map[string][]string, len(tickets))
+	for _, t := range tickets {
+		node := GraphNode{ID: t.ID, Title: t.Title, Status: t.Status, Domain: t.Domain}
+		seen := make(map[string]bool)
+		for _, dep := range t.Dependencies {
+			depID := dep
+			if !byID[dep] {
+				id, ok := byTitle[dep]
+				if !ok {
+					node.Unresolved = append(node.Unresolved, dep)
+					continue
+				}
+				depID = id
+			}
+			if seen[depID] {
+				continue
+			}
+			seen[depID] = true
+			dependsOn[t.ID] = append(dependsOn[t.ID], depID)
+			graph.Edges = append(graph.Edges, GraphEdge{From: depID, To: t.ID})
+		}
+		graph.Nodes = append(graph.Nodes, node)
+	}
+
+	// component maps each ticket in a cycle to the index of its cycle
+	component := make(map[string]int)
+	for _, scc := range stronglyConnected(tickets, dependsOn) {
+		if len(scc) == 1 && !containsString(dependsOn[scc[0]], scc[0]) {
+			continue
+		}
+		sort.Strings(scc)
+		for _, id := range scc {
+			component[id] = len(graph.Cycles)
+		}
+		graph.Cycles = append(graph.Cycles, scc)
+	}
+
+	for i := range graph.Nodes {
+		_, graph.Nodes[i].InCycle = component[graph.Nodes[i].ID]
+	}
+	for i, e := range graph.Edges {
+		from, fromOK := component[e.From]
+		to, toOK := component[e.To]
+		graph.Edges[i].InCycle = fromOK && toOK && from == to
+	}
+
+	return graph
+}
+
+// stronglyConnected returns the strongly connected components of the
+// dependency graph using Tarjan's algorithm, visiting tickets in order.
+func stronglyConnected(tickets []Ticket, dependsOn map[string][]string) [][]string {
+	index := make(map[string]int)
+	lowlink := make(map[string]int)
+	onStack := make(map[string]bool)
+	var stack []string
+	var components [][]string
+
+	var visit func(id string)
+	visit = func(id string) {
+		index[id] = len(index)
+		lowlink[id] = index[id]
+		stack = append(stack, id)
+		onStack[id] = true
+
+		for _, dep := range dependsOn[id] {
+			if _, visited := index[dep]; !visited {
+				visit(dep)
+				lowlink[id] = min(lowlink[id], lowlink[dep])
+			} else if onStack[dep] {
+				lowlink[id] = min(lowlink[id], index[dep])
+			}
+		}
+
+		if lowlink[id] == index[id] {
+			var scc []string
+			for {
+				top := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				onStack[top] = false
+				scc = append(scc, top)
+				if top == id {
+					break
+				}
+			}
+			components = append(components, scc)
+		}
+	}
+
+	for _, t := range tickets {
+		if _, visited := index[t.ID]; !visited {
+			visit(t.ID)
+		}
+	}
+	return components
+}
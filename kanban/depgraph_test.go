@@ -0,0 +1,59 @@
+package kanban
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBuildDependencyGraphResolvesTitles(t *testing.T) {
+	tickets := []Ticket{
+		{ID: "T-1", Title: "Schema", Status: StatusDone, Domain: DomainBackend},
+		{ID: "T-2", Title: "API", Status: StatusInDev, Domain: DomainBackend, Dependencies: []string{"Schema", "T-1"}},
+		{ID: "T-3", Title: "UI", Status: StatusReady, Domain: DomainFrontend, Dependencies: []string{"T-2", "Missing ticket"}},
+	}
+
+	graph := BuildDependencyGraph(tickets)
+
+	if len(graph.Nodes) != 3 {
+		t.Fatalf("expected 3 nodes, got %d", len(graph.Nodes))
+	}
+	// The title and the ID name the same dependency, so only one edge is drawn
+	want := []GraphEdge{{From: "T-1", To: "T-2"}, {From: "T-2", To: "T-3"}}
+	if !reflect.DeepEqual(graph.Edges, want) {
+		t.Errorf("expected edges %+v, got %+v", want, graph.Edges)
+	}
+	if got := graph.Nodes[2].Unresolved; len(got) != 1 || got[0] != "Missing ticket" {
+		t.Errorf("expected the unknown dependency to be reported, got %v", got)
+	}
+	if len(graph.Cycles) != 0 {
+		t.Errorf("expected no cycles, got %v", graph.Cycles)
+	}
+}
+
+func TestBuildDependencyGraphFlagsCycles(t *testing.T) {
+	tickets := []Ticket{
+		{ID: "A", Title: "Alpha", Dependencies: []string{"Gamma"}},
+		{ID: "B", Title: "Beta", Dependencies: []string{"A"}},
+		{ID: "C", Title: "Gamma", Dependencies: []string{"B"}},
+		{ID: "D", Title: "Delta", Dependencies: []string{"C"}},
+		{ID: "E", Title: "Self", Dependencies: []string{"E"}},
+	}
+
+	graph := BuildDependencyGraph(tickets)
+
+	wantCycles := [][]string{{"A", "B", "C"}, {"E"}}
+	if !reflect.DeepEqual(graph.Cycles, wantCycles) {
+		t.Fatalf("expected cycles %v, got %v", wantCycles, graph.Cycles)
+	}
+	for _, node := range graph.Nodes {
+		if wantInCycle := node.ID != "D"; node.InCycle != wantInCycle {
+			t.Errorf("node %s: expected inCycle=%v", node.ID, wantInCycle)
+		}
+	}
+	for _, edge := range graph.Edges {
+		// D depends on the cycle without being part of it
+		if wantInCycle := edge.To != "D"; edge.InCycle != wantInCycle {
+			t.Errorf("edge %s->%s: expected inCycle=%v", edge.From, edge.To, wantInCycle)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package kanban
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// IterationDigest summarizes what an iteration delivered. One is recorded when
+// the iteration completes.
+type IterationDigest struct {
+	IterationID      string                   `json:"iterationId"`
+	Goal             string                   `json:"goal,omitempty"`
+	Start            time.Time                `json:"start"`
+	End              time.Time                `json:"end"`
+	TicketsCompleted []string                 `json:"ticketsCompleted"`
+	TotalCycleTime   time.Duration            `json:"totalCycleTime"` // Creation to done, summed over completed tickets
+	AgentRuns        map[string]AgentRunTally `json:"agentRuns"`      // Keyed by agent type
+	ADRsCreated      []string                 `json:"adrsCreated"`
+	BugsFound        int                      `json:"bugsFound"`
+	BugsBySeverity   map[string]int           `json:"bugsBySeverity"`
+	CreatedAt        time.Time                `json:"createdAt"`
+}
+
+// AgentRunTally counts an agent type's runs during an iteration.
+type AgentRunTally struct {
+	Runs        int     `json:"runs"`
+	Succeeded   int     `json:"succeeded"`
+	Failed      int     `json:"failed"`
+	SuccessRate float64 `json:"successRate"` // Of finished runs, 0-1
+}
+
+// Window returns the time range the iteration covers; an iteration that has
+// not ended runs until now.
+func (it *Iteration) Window() (start, end time.Time) {
+	start = it.StartedAt
+	if start.IsZero() {
+		start = it.CreatedAt
+	}
+	end = it.EndedAt
+	if end.IsZero() {
+		end = time.Now()
+	}
+	return start, end
+}
+
+// ComputeIterationDigest summarizes the iteration from tickets (with history),
+// the agent runs and the ADRs: tickets completed within its window and their
+// cycle time, runs started and bugs found within it, and ADRs created for it.
+func ComputeIterationDigest(iteration *Iteration, tickets []Ticket, runs []AgentRun, adrs []ADR) *IterationDigest {
+	start, end := iteration.Window()
+	digest := &IterationDigest{
+		IterationID:      iteration.ID,
+		Goal:             iteration.Goal,
+		Start:            start,
+		End:              end,
+		TicketsCompleted: []string{},
+		AgentRuns:        make(map[string]AgentRunTally),
+		ADRsCreated:      []string{},
+		BugsBySeverity:   make(map[string]int),
+		CreatedAt:        time.Now(),
+	}
+
+	for _, t := range CompletedBetween(tickets, start, end) {
+		digest.TicketsCompleted = append(digest.TicketsCompleted, t.ID)
+		for _, entry := range t.History {
+			if entry.Status == StatusDone {
+				digest.TotalCycleTime += entry.At.Sub(t.CreatedAt)
+				break
+			}
+		}
+	}
+	sort.Strings(digest.TicketsCompleted)
+
+	for _, t := range tickets {
+		for _, bug := range t.Bugs {
+			if within(bug.FoundAt, start, end) {
+				digest.BugsFound++
+				digest.BugsBySeverity[bug.Severity]++
+			}
+		}
+	}
+
+	for _, run := range runs {
+		if !within(run.StartedAt, start, end) {
+			continue
+		}
+		tally := digest.AgentRuns[run.Agent]
+		tally.Runs++
+		switch run.Status {
+		case AgentRunStatusSuccess:
+			tally.Succeeded++
+		case AgentRunStatusFailed:
+			tally.Failed++
+		}
+		if finished := tally.Succeeded + tally.Failed; finished > 0 {
+			tally.SuccessRate = float64(tally.Succeeded) / float64(finished)
+		}
+		digest.AgentRuns[run.Agent] = tally
+	}
+
+	for _, adr := range adrs {
+		if adr.IterationID == iteration.ID || (adr.IterationID == "" && within(adr.CreatedAt, start, end)) {
+			digest.ADRsCreated = append(digest.ADRsCreated, adr.ID)
+		}
+	}
+	sort.Strings(digest.ADRsCreated)
+
+	return digest
+}
+
+// Summary describes the digest in one line, for notifications.
+func (d *IterationDigest) Summary() string {
+	var runs, succeeded, finished int
+	for _, tally := range d.AgentRuns {
+		runs += tally.Runs
+		succeeded += tally.Succeeded
+		finished += tally.Succeeded + tally.Failed
+	}
+	summary := fmt.Sprintf("%d tickets completed, %d ADRs created, %d bugs found, %d agent runs",
+		len(d.TicketsCompleted), len(d.ADRsCreated), d.BugsFound, runs)
+	if finished > 0 {
+		summary += fmt.Sprintf(" (%.0f%% succeeded)", 100*float64(succeeded)/float64(finished))
+	}
+	if len(d.TicketsCompleted) > 0 {
+		avg := d.TotalCycleTime / time.Duration(len(d.TicketsCompleted))
+		summary += fmt.Sprintf("; average cycle time %s", avg.Round(time.Minute))
+	}
+	return summary
+}
+
+func within(t, start, end time.Time) bool {
+	return !t.Before(start) && !t.After(end)
+}
@@ -0,0 +1,111 @@
+package kanban
+
+import "fmt"
+
+// ReviewStages are the review statuses a ticket passes through after
+// development, in order. Any of them may be skipped.
+var ReviewStages = []Status{StatusInQA, StatusInUX, StatusInSec, StatusPMReview}
+
+// EffectiveSkipStages returns the review stages the ticket skips: its own list
+// when set (even if empty), otherwise the board default.
+func (t *Ticket) EffectiveSkipStages(boardDefault []Status) []Status {
+	if t.SkipStages != nil {
+		return t.SkipStages
+	}
+	return boardDefault
+}
+
+// NextReviewStage returns status, or when status is a skipped review stage, the
+// first later review stage that isn't skipped. final is returned when every
+// remaining review stage is skipped. Other statuses are returned unchanged.
+func NextReviewStage(status Status, skip []Status, final Status) Status {
+	for i, stage := range ReviewStages {
+		if stage != status {
+			continue
+		}
+		for _, next := range ReviewStages[i:] {
+			if !containsStatus(skip, next) {
+				return next
+			}
+		}
+		return final
+	}
+	return status
+}
+
+// SkippedReviewStages returns the review stages from status up to, but not
+// including, next: the stages NextReviewStage passed over to reach next.
+func SkippedReviewStages(status, next Status) []Status {
+	start := indexOfStatus(ReviewStages, status)
+	if start < 0 {
+		return nil
+	}
+	end := indexOfStatus(ReviewStages, next)
+	if end < 0 {
+		end = len(ReviewStages)
+	}
+	if end <= start {
+		return nil
+	}
+	return append([]Status{}, ReviewStages[start:end]...)
+}
+
+// ValidateSkipStages checks that every stage to skip is a review stage.
+func ValidateSkipStages(stages []Status) error {
+	for _, stage := range stages {
+		if !containsStatus(ReviewStages, stage) {
+			return fmt.Errorf("%s is not a skippable review stage", stage)
+		}
+	}
+	return nil
+}
+
+// ValidatePipeline checks a ticket type's pipeline: the review stages its
+// tickets pass through after development, in the standard order, ending at DONE.
+func ValidatePipeline(stages []Status) error {
+	if len(stages) == 0 || stages[len(stages)-1] != StatusDone {
+		return fmt.Errorf("pipeline must end at %s", StatusDone)
+	}
+
+	next := 0
+	for _, stage := range stages[:len(stages)-1] {
+		i := indexOfStatus(ReviewStages, stage)
+		if i < 0 {
+			return fmt.Errorf("%s is not a review stage", stage)
+		}
+		if i < next {
+			return fmt.Errorf("%s is out of order or repeated", stage)
+		}
+		next = i + 1
+	}
+	return nil
+}
+
+// PipelineSkipStages returns the review stages a pipeline leaves out.
+func PipelineSkipStages(stages []Status) []Status {
+	skip := []Status{}
+	for _, stage := range ReviewStages {
+		if !containsStatus(stages, stage) {
+			skip = append(skip, stage)
+		}
+	}
+	return skip
+}
+
+func containsStatus(statuses []Status, status Status) bool {
+	for _, s := range statuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+func indexOfStatus(statuses []Status, status Status) int {
+	for i, s := range statuses {
+		if s == status {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,84 @@
+package kanban
+
+import "testing"
+
+func TestNextReviewStageSkipsStages(t *testing.T) {
+	tests := []struct {
+		name   string
+		status Status
+		skip   []Status
+		want   Status
+	}{
+		{"not skipped", StatusInUX, nil, StatusInUX},
+		{"skip UX", StatusInUX, []Status{StatusInUX}, StatusInSec},
+		{"skip UX and security", StatusInUX, []Status{StatusInUX, StatusInSec}, StatusPMReview},
+		{"skip all remaining", StatusInSec, []Status{StatusInSec, StatusPMReview}, StatusDone},
+		{"non-review status", StatusBlocked, []Status{StatusInUX}, StatusBlocked},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NextReviewStage(tt.status, tt.skip, StatusDone); got != tt.want {
+				t.Errorf("NextReviewStage(%s, %v) = %s, want %s", tt.status, tt.skip, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSkippedReviewStagesListsPassedOverStages(t *testing.T) {
+	tests := []struct {
+		name         string
+		status, next Status
+		want         []Status
+	}{
+		{"nothing skipped", StatusInUX, StatusInUX, nil},
+		{"UX skipped", StatusInUX, StatusInSec, []Status{StatusInUX}},
+		{"rest skipped", StatusInSec, StatusDone, []Status{StatusInSec, StatusPMReview}},
+		{"non-review status", StatusBlocked, StatusInQA, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := SkippedReviewStages(tt.status, tt.next)
+			if len(got) != len(tt.want) {
+				t.Fatalf("SkippedReviewStages(%s, %s) = %v, want %v", tt.status, tt.next, got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("SkippedReviewStages(%s, %s) = %v, want %v", tt.status, tt.next, got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateSkipStagesRejectsNonReviewStages(t *testing.T) {
+	if err := ValidateSkipStages([]Status{StatusInUX, StatusPMReview}); err != nil {
+		t.Errorf("expected review stages to be valid, got %v", err)
+	}
+	if err := ValidateSkipStages([]Status{StatusInDev}); err == nil {
+		t.Error("expected IN_DEV to be rejected")
+	}
+}
+
+func TestValidatePipelineRequiresOrderedStagesEndingAtDone(t *testing.T) {
+	tests := []struct {
+		name    string
+		stages  []Status
+		wantErr bool
+	}{
+		{"full", []Status{StatusInQA, StatusInUX, StatusInSec, StatusPMReview, StatusDone}, false},
+		{"tech debt", []Status{StatusInQA, StatusInSec, StatusDone}, false},
+		{"straight to done", []Status{StatusDone}, false},
+		{"empty", nil, true},
+		{"not ending at done", []Status{StatusInQA, StatusInSec}, true},
+		{"non-review stage", []Status{StatusInDev, StatusInQA, StatusDone}, true},
+		{"out of order", []Status{StatusInSec, StatusInQA, StatusDone}, true},
+		{"repeated", []Status{StatusInQA, StatusInQA, StatusDone}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidatePipeline(tt.stages); (err != nil) != tt.wantErr {
+				t.Errorf("ValidatePipeline(%v) error = %v, wantErr %v", tt.stages, err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package kanban
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// MissingPRDSections returns the required sections a final PRD lacks. A JSON PRD
+// has a section when it has a non-empty top-level field of that name; any other
+// PRD when it has a markdown heading with that text. Names match
+// case-insensitively, with spaces, hyphens and underscores interchangeable.
+func MissingPRDSections(prd string, sections []string) []string {
+	if len(sections) == 0 {
+		return nil
+	}
+
+	present := make(map[string]bool)
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(prd), &fields); err == nil {
+		for name, value := range fields {
+			switch strings.TrimSpace(string(value)) {
+			case "null", `""`, "[]", "{}":
+				continue
+			}
+			present[normalizeSectionName(name)] = true
+		}
+	} else {
+		for _, line := range strings.Split(prd, "\n") {
+			line = strings.TrimSpace(line)
+			if strings.HasPrefix(line, "#") {
+				present[normalizeSectionName(strings.TrimLeft(line, "# "))] = true
+			}
+		}
+	}
+
+	var missing []string
+	for _, section := range sections {
+		if !present[normalizeSectionName(section)] {
+			missing = append(missing, section)
+		}
+	}
+	return missing
+}
+
+func normalizeSectionName(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	return strings.NewReplacer(" ", "_", "-", "_").Replace(name)
+}
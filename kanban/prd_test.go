@@ -0,0 +1,24 @@
+package kanban
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMissingPRDSections(t *testing.T) {
+	sections := []string{"goals", "Security Plan", "out-of-scope"}
+
+	jsonPRD := `{"goals": ["Ship login"], "security_plan": {"threat_model": "Credential stuffing"}, "out_of_scope": []}`
+	if got := MissingPRDSections(jsonPRD, sections); !reflect.DeepEqual(got, []string{"out-of-scope"}) {
+		t.Errorf("expected the empty section to count as missing, got %v", got)
+	}
+
+	markdownPRD := "# Login\n\n## Goals\n- Ship login\n\n## Out of Scope\n- SSO\n"
+	if got := MissingPRDSections(markdownPRD, sections); !reflect.DeepEqual(got, []string{"Security Plan"}) {
+		t.Errorf("expected the missing heading to be reported, got %v", got)
+	}
+
+	if got := MissingPRDSections("", nil); got != nil {
+		t.Errorf("expected no template to require nothing, got %v", got)
+	}
+}
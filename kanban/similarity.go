@@ -0,0 +1,65 @@
+package kanban
+
+import (
+	"strings"
+	"unicode"
+)
+
+// similarityStopWords are dropped before comparing tickets so filler words
+// don't make unrelated tickets look alike.
+var similarityStopWords = map[string]bool{
+	"the": true, "and": true, "for": true, "with": true, "from": true,
+	"into": true, "that": true, "this": true, "when": true, "should": true,
+	"are": true, "was": true, "not": true, "can": true, "all": true,
+	"add": true, "use": true, "make": true, "new": true, "ticket": true,
+}
+
+// TicketTokens returns the distinct lowercase words of a ticket's title and
+// description, ignoring punctuation, short words, and stop words.
+func TicketTokens(t *Ticket) map[string]bool {
+	tokens := make(map[string]bool)
+	words := strings.FieldsFunc(strings.ToLower(t.Title+" "+t.Description), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for _, word := range words {
+		if len(word) < 3 || similarityStopWords[word] {
+			continue
+		}
+		tokens[word] = true
+	}
+	return tokens
+}
+
+// TicketSimilarity returns the Jaccard similarity of two tickets' tokens,
+// from 0 (nothing shared) to 1 (same words).
+func TicketSimilarity(a, b *Ticket) float64 {
+	ta, tb := TicketTokens(a), TicketTokens(b)
+	if len(ta) == 0 || len(tb) == 0 {
+		return 0
+	}
+	shared := 0
+	for word := range ta {
+		if tb[word] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(ta)+len(tb)-shared)
+}
+
+// FindDuplicate returns the open ticket most similar to t when its similarity
+// reaches threshold, along with the score. Done tickets and t itself are
+// never considered duplicates.
+func FindDuplicate(t *Ticket, candidates []Ticket, threshold float64) (*Ticket, float64) {
+	var best *Ticket
+	bestScore := 0.0
+	for i := range candidates {
+		c := &candidates[i]
+		if c.ID == t.ID || c.Status == StatusDone {
+			continue
+		}
+		if score := TicketSimilarity(t, c); score >= threshold && score > bestScore {
+			best, bestScore = c, score
+		}
+	}
+	return best, bestScore
+}
@@ -0,0 +1,38 @@
+package kanban
+
+import "testing"
+
+func TestFindDuplicateMatchesRewordedTicket(t *testing.T) {
+	existing := []Ticket{
+		{ID: "LOGIN", Title: "Login page crashes on empty password", Description: "Submitting the login form with an empty password crashes the page.", Status: StatusInDev},
+		{ID: "EXPORT", Title: "Export board as CSV", Description: "Users want a CSV export of all tickets.", Status: StatusReady},
+		{ID: "OLD", Title: "Login page crashes on empty password", Description: "Submitting the login form with an empty password crashes the page.", Status: StatusDone},
+	}
+
+	dup := &Ticket{ID: "NEW", Title: "Login page crash with empty password", Description: "The login form crashes the page when the password is empty."}
+	match, score := FindDuplicate(dup, existing, 0.5)
+	if match == nil || match.ID != "LOGIN" {
+		t.Fatalf("expected LOGIN as duplicate, got %v (score %.2f)", match, score)
+	}
+
+	distinct := &Ticket{ID: "NEW", Title: "Dark mode for settings", Description: "Add a dark theme toggle to the settings screen."}
+	if match, score := FindDuplicate(distinct, existing, 0.5); match != nil {
+		t.Errorf("expected no duplicate for a distinct ticket, got %s (score %.2f)", match.ID, score)
+	}
+
+	// Done tickets are never returned, even as exact matches
+	if match, _ := FindDuplicate(&existing[2], existing[1:], 0.5); match != nil {
+		t.Errorf("expected done tickets to be skipped, got %s", match.ID)
+	}
+}
+
+func TestTicketSimilarityIgnoresCaseAndPunctuation(t *testing.T) {
+	a := &Ticket{Title: "Fix: Login TIMEOUT!"}
+	b := &Ticket{Title: "fix login timeout"}
+	if got := TicketSimilarity(a, b); got != 1 {
+		t.Errorf("expected identical token sets, got %.2f", got)
+	}
+	if got := TicketSimilarity(a, &Ticket{}); got != 0 {
+		t.Errorf("expected 0 against an empty ticket, got %.2f", got)
+	}
+}
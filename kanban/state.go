@@ -159,7 +159,7 @@ func (s *State) GetNextTicketForDomain(domain Domain) (*Ticket, bool) {
 	ready := s.GetTicketsByStatus(StatusReady)
 
 	for _, t := range ready {
-		if t.Domain != domain {
+		if t.Domain != domain || t.Paused {
 			continue
 		}
 
@@ -241,6 +241,7 @@ func (s *State) AddTicket(t Ticket) error {
 		t.CreatedAt = time.Now()
 	}
 	t.UpdatedAt = time.Now()
+	s.board.Config.ApplyDefaultAcceptanceCriteria(&t)
 
 	// Initialize history
 	t.History = append(t.History, HistoryEntry{
@@ -379,6 +380,22 @@ func (s *State) UpdateNotes(ticketID, notes string) error {
 	return fmt.Errorf("ticket %s not found", ticketID)
 }
 
+// SetTestRun records the latest test command run for a ticket.
+func (s *State) SetTestRun(ticketID string, result *TestRunResult) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.board.Tickets {
+		if s.board.Tickets[i].ID == ticketID {
+			s.board.Tickets[i].TestRun = result
+			s.board.Tickets[i].UpdatedAt = time.Now()
+			s.dirty = true
+			return nil
+		}
+	}
+	return fmt.Errorf("ticket %s not found", ticketID)
+}
+
 // UpdateActivity updates the current activity for a ticket (shown in dashboard).
 func (s *State) UpdateActivity(ticketID, activity, assignee string) error {
 	s.mu.Lock()
@@ -457,7 +474,7 @@ func (s *State) IsIterationComplete() bool {
 
 	// Check if all tickets are done (iteration or not)
 	for _, t := range s.board.Tickets {
-		if t.Status != StatusDone && t.Status != StatusBacklog {
+		if t.Status != StatusDone && t.Status != StatusBacklog && t.Status != StatusIcebox {
 			return false
 		}
 	}
@@ -490,6 +507,20 @@ func (s *State) CompleteRun(runID string, status string, output string) {
 	}
 }
 
+// SetRunMetadata records the model, token usage, and exit reason of a run.
+func (s *State) SetRunMetadata(runID string, meta RunMetadata) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.board.ActiveRuns {
+		if s.board.ActiveRuns[i].ID == runID {
+			s.board.ActiveRuns[i].RunMetadata = meta
+			s.dirty = true
+			return
+		}
+	}
+}
+
 // GetActiveRuns returns all currently running agents.
 func (s *State) GetActiveRuns() []AgentRun {
 	s.mu.RLock()
@@ -622,17 +653,47 @@ func (s *State) CleanupStaleRunningAgents(maxRunDuration time.Duration) int {
 	return count
 }
 
-// CleanupOrphanedRunningAgents marks ALL running agents as failed on startup.
+// HeartbeatRunningAgents records that all running agents are still alive.
+func (s *State) HeartbeatRunningAgents() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	count := 0
+	for i := range s.board.ActiveRuns {
+		run := &s.board.ActiveRuns[i]
+		if run.Status == AgentRunStatusRunning {
+			run.LastHeartbeat = now
+			count++
+		}
+	}
+
+	if count > 0 {
+		s.dirty = true
+	}
+	return count
+}
+
+// CleanupOrphanedRunningAgents marks running agents as failed on startup when their
+// last heartbeat (or start, if they never beat) is older than gracePeriod.
 // This is called during factory initialization to clean up runs from a previous
 // factory session that was killed without proper cleanup.
-func (s *State) CleanupOrphanedRunningAgents() int {
+func (s *State) CleanupOrphanedRunningAgents(gracePeriod time.Duration) int {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	cutoff := time.Now().Add(-gracePeriod)
 	count := 0
 	for i := range s.board.ActiveRuns {
 		run := &s.board.ActiveRuns[i]
-		if run.Status == AgentRunStatusRunning {
+		if run.Status != AgentRunStatusRunning {
+			continue
+		}
+		lastSeen := run.StartedAt
+		if !run.LastHeartbeat.IsZero() {
+			lastSeen = run.LastHeartbeat
+		}
+		if !lastSeen.After(cutoff) {
 			run.Status = "failed"
 			run.EndedAt = time.Now()
 			run.Output = "Orphaned run from previous factory session"
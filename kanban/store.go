@@ -30,6 +30,7 @@ type StateStore interface {
 	UpdateTicketStatus(id string, newStatus Status, by string, note string) error
 	AssignAgent(ticketID, agentID string) error
 	SetWorktree(ticketID string, wt *Worktree) error
+	SetTestRun(ticketID string, result *TestRunResult) error
 	AddSignoff(ticketID string, stage string, agentID string) error
 	AddBug(ticketID string, bug Bug) error
 	UpdateNotes(ticketID, notes string) error
@@ -46,12 +47,14 @@ type StateStore interface {
 	AddRun(run *AgentRun) error
 	AddActiveRun(run AgentRun)
 	CompleteRun(runID string, status string, output string)
+	SetRunMetadata(runID string, meta RunMetadata)
 	GetActiveRuns() []AgentRun
 	GetActiveDevRuns() []AgentRun
 	GetActiveRunsForTicket(ticketID string) []AgentRun
 	CleanupStaleRuns(maxAge time.Duration)
 	CleanupStaleRunningAgents(maxRunDuration time.Duration) int
-	CleanupOrphanedRunningAgents() int // Mark ALL running agents as failed on startup
+	CleanupOrphanedRunningAgents(gracePeriod time.Duration) int // Mark runs without a recent heartbeat as failed on startup
+	HeartbeatRunningAgents() int                                // Record that running agents are still alive
 	IsAgentRunning(ticketID, agentType string) bool
 
 	// Conversations
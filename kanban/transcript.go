@@ -0,0 +1,131 @@
+package kanban
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// transcriptTimeFormat is how transcript timestamps are written (always UTC).
+const transcriptTimeFormat = "2006-01-02 15:04:05 UTC"
+
+// FormatTranscript renders a ticket's conversation threads as a markdown
+// transcript for support and handoff. Threads appear in the order they were
+// started and messages in the order they were sent, each with its timestamp and
+// agent. Sign-off reports are rendered as readable sections rather than raw JSON.
+// Conversations must have their Messages populated.
+func FormatTranscript(ticket *Ticket, conversations []TicketConversation) string {
+	threads := append([]TicketConversation(nil), conversations...)
+	sort.SliceStable(threads, func(i, j int) bool { return threads[i].CreatedAt.Before(threads[j].CreatedAt) })
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Transcript: %s - %s\n\n", ticket.ID, ticket.Title)
+	fmt.Fprintf(&b, "Status: %s\n", ticket.Status)
+	if len(threads) == 0 {
+		b.WriteString("\n_No conversations._\n")
+		return b.String()
+	}
+
+	for _, thread := range threads {
+		title := thread.Title
+		if title == "" {
+			title = string(thread.ThreadType)
+		}
+		fmt.Fprintf(&b, "\n## %s\n\n", title)
+		fmt.Fprintf(&b, "_%s thread, %s, started %s_\n", thread.ThreadType, thread.Status, formatTranscriptTime(thread.CreatedAt))
+
+		messages := append([]ConversationMessage(nil), thread.Messages...)
+		sort.SliceStable(messages, func(i, j int) bool { return messages[i].CreatedAt.Before(messages[j].CreatedAt) })
+		if len(messages) == 0 {
+			b.WriteString("\n_No messages._\n")
+		}
+
+		for _, msg := range messages {
+			fmt.Fprintf(&b, "\n**[%s] %s** (%s):\n\n", formatTranscriptTime(msg.CreatedAt), msg.Agent, msg.MessageType)
+			if msg.MessageType == MessageTypeSignoffReport {
+				var report SignoffReport
+				if err := json.Unmarshal([]byte(msg.Content), &report); err == nil {
+					b.WriteString(formatSignoffReport(&report))
+					continue
+				}
+			}
+			b.WriteString(strings.TrimSpace(msg.Content))
+			b.WriteString("\n")
+			for _, a := range msg.Attachments {
+				fmt.Fprintf(&b, "\n- Attachment: %s (%s, %d bytes)\n", a.Filename, a.ContentType, a.Size)
+			}
+		}
+	}
+
+	return b.String()
+}
+
+// formatSignoffReport renders a sign-off report as markdown.
+func formatSignoffReport(r *SignoffReport) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Sign-off: **%s**\n", r.Status)
+	if r.Summary != "" {
+		fmt.Fprintf(&b, "\n%s\n", r.Summary)
+	}
+
+	list := func(heading string, items []string) {
+		if len(items) == 0 {
+			return
+		}
+		fmt.Fprintf(&b, "\n%s:\n", heading)
+		for _, item := range items {
+			fmt.Fprintf(&b, "- %s\n", item)
+		}
+	}
+	list("Checks performed", r.ChecksPerformed)
+	list("Criteria verified", r.CriteriaVerified)
+	list("Unmet criteria", r.UnmetCriteria)
+	list("Unverifiable criteria", r.UnverifiableCriteria)
+
+	if r.TestsRun != nil {
+		fmt.Fprintf(&b, "\nTests (%s): %d passed, %d failed, %d skipped\n",
+			r.TestsRun.Framework, r.TestsRun.Passed, r.TestsRun.Failed, r.TestsRun.Skipped)
+	}
+
+	if len(r.Findings) > 0 {
+		b.WriteString("\nFindings:\n")
+		for _, f := range r.Findings {
+			line := f.Description
+			if f.Title != "" {
+				line = f.Title + ": " + line
+			}
+			if f.File != "" {
+				line += " (" + f.File + ")"
+			}
+			fmt.Fprintf(&b, "- [%s] %s\n", f.Severity, line)
+			if f.Recommendation != "" {
+				fmt.Fprintf(&b, "  Recommendation: %s\n", f.Recommendation)
+			}
+		}
+	}
+
+	if len(r.Bugs) > 0 {
+		b.WriteString("\nBugs:\n")
+		for _, bug := range r.Bugs {
+			desc := bug.Description
+			if bug.Title != "" {
+				desc = bug.Title + ": " + desc
+			}
+			fmt.Fprintf(&b, "- [%s] %s\n", bug.Severity, desc)
+		}
+	}
+
+	if r.Notes != "" {
+		fmt.Fprintf(&b, "\nNotes: %s\n", r.Notes)
+	}
+	if r.Reason != "" {
+		fmt.Fprintf(&b, "\nReason: %s\n", r.Reason)
+	}
+	return b.String()
+}
+
+func formatTranscriptTime(t time.Time) string {
+	return t.UTC().Format(transcriptTimeFormat)
+}
@@ -4,6 +4,7 @@ package kanban
 
 import (
 	"fmt"
+	"strings"
 	"time"
 )
 
@@ -11,19 +12,24 @@ import (
 type Status string
 
 const (
-	StatusBacklog      Status = "BACKLOG"       // Ideas, not yet planned
-	StatusApproved     Status = "APPROVED"      // Approved in Notion, awaiting requirements
-	StatusRefining     Status = "REFINING"      // PM analyzing, gathering requirements (legacy)
-	StatusNeedsExpert  Status = "NEEDS_EXPERT"  // PM needs domain expert input (legacy)
-	StatusAwaitingUser Status = "AWAITING_USER" // Requirements ready for user review/edit
-	StatusReady        Status = "READY"         // Requirements complete, ready for dev
-	StatusInDev        Status = "IN_DEV"        // Developer agent is working on it
-	StatusInQA         Status = "IN_QA"         // QA agent is testing
-	StatusInUX         Status = "IN_UX"         // UX agent is reviewing
-	StatusInSec        Status = "IN_SEC"        // Security agent is reviewing
-	StatusPMReview     Status = "PM_REVIEW"     // PM agent verifies expected behavior
-	StatusDone         Status = "DONE"          // Complete, merged to main
-	StatusBlocked      Status = "BLOCKED"       // Blocked by bugs or dependencies
+	StatusIcebox                Status = "ICEBOX"                  // Deferred ideas, never scheduled until promoted
+	StatusBacklog               Status = "BACKLOG"                 // Ideas, not yet planned
+	StatusApproved              Status = "APPROVED"                // Approved in Notion, awaiting requirements
+	StatusRefining              Status = "REFINING"                // PM analyzing, gathering requirements (legacy)
+	StatusNeedsExpert           Status = "NEEDS_EXPERT"            // PM needs domain expert input (legacy)
+	StatusAwaitingUser          Status = "AWAITING_USER"           // Requirements ready for user review/edit
+	StatusReady                 Status = "READY"                   // Requirements complete, ready for dev
+	StatusQueued                Status = "QUEUED"                  // Sub-ticket held until earlier parallel groups of its parent are done
+	StatusInDev                 Status = "IN_DEV"                  // Developer agent is working on it
+	StatusInQA                  Status = "IN_QA"                   // QA agent is testing
+	StatusInUX                  Status = "IN_UX"                   // UX agent is reviewing
+	StatusInSec                 Status = "IN_SEC"                  // Security agent is reviewing
+	StatusPMReview              Status = "PM_REVIEW"               // PM agent verifies expected behavior
+	StatusAwaitingMergeApproval Status = "AWAITING_MERGE_APPROVAL" // Signed off, waiting for a human to approve the merge
+	StatusPendingDone           Status = "PENDING_DONE"            // PM review passed, soaking for late findings before DONE
+	StatusDone                  Status = "DONE"                    // Complete, merged to main
+	StatusBlocked               Status = "BLOCKED"                 // Blocked by bugs or dependencies
+	StatusAbandoned             Status = "ABANDONED"               // Failed too often; needs human intervention, never scheduled
 
 	// Collaborative PRD refinement statuses.
 	StatusRefiningRound Status = "REFINING_ROUND" // PM facilitating multi-round discussion (append round number)
@@ -52,6 +58,11 @@ const (
 	PriorityLow      Priority = 4
 )
 
+// IsValidPriority reports whether p is one of the defined priorities (1-4).
+func IsValidPriority(p Priority) bool {
+	return p >= PriorityCritical && p <= PriorityLow
+}
+
 // Signoffs tracks which agents have approved the ticket.
 type Signoffs struct {
 	Dev      bool   `json:"dev"`
@@ -152,6 +163,27 @@ type Requirements struct {
 	CompletedAt time.Time `json:"completedAt,omitempty"`
 }
 
+// AllQuestionsAnswered reports whether the PM asked questions and every one of
+// them has a non-empty answer.
+func (r *Requirements) AllQuestionsAnswered() bool {
+	if r == nil || len(r.Questions) == 0 {
+		return false
+	}
+	for _, q := range r.Questions {
+		if strings.TrimSpace(q.Answer) == "" {
+			return false
+		}
+	}
+	return true
+}
+
+// ProviderOverride pins a ticket's agents to a provider. An empty model uses
+// the provider's default.
+type ProviderOverride struct {
+	Provider string `json:"provider"`
+	Model    string `json:"model,omitempty"`
+}
+
 // HistoryEntry tracks state transitions.
 type HistoryEntry struct {
 	Status Status    `json:"status"`
@@ -160,17 +192,35 @@ type HistoryEntry struct {
 	Note   string    `json:"note,omitempty"`
 }
 
+// StatusChange is a ticket moving from one status to another.
+type StatusChange struct {
+	TicketID string    `json:"ticketId"`
+	From     Status    `json:"from"`
+	To       Status    `json:"to"`
+	By       string    `json:"by"`
+	Note     string    `json:"note"`
+	At       time.Time `json:"at"`
+}
+
 // TimeStats holds computed timing statistics for a ticket.
 type TimeStats struct {
-	TotalWorkTime   time.Duration            `json:"totalWorkTime"`   // Total time agents actively worked
-	TotalIdleTime   time.Duration            `json:"totalIdleTime"`   // Total time ticket sat waiting
-	TotalCycleTime  time.Duration            `json:"totalCycleTime"`  // Total time from creation to completion
-	StatusDurations map[Status]time.Duration `json:"statusDurations"` // Time spent in each status
-	AgentWorkTimes  map[string]time.Duration `json:"agentWorkTimes"`  // Work time per agent type
-	LastActivityAt  time.Time                `json:"lastActivityAt"`  // When last agent activity occurred
-	IdleSince       time.Time                `json:"idleSince"`       // When ticket became idle (if currently idle)
-	CurrentIdleTime time.Duration            `json:"currentIdleTime"` // How long it's been idle
-	AgentRunCount   int                      `json:"agentRunCount"`   // Number of agent runs
+	TotalWorkTime   time.Duration            `json:"totalWorkTime"`       // Total time agents actively worked
+	TotalIdleTime   time.Duration            `json:"totalIdleTime"`       // Total time ticket sat waiting
+	TotalCycleTime  time.Duration            `json:"totalCycleTime"`      // Total time from creation to completion
+	StatusDurations map[Status]time.Duration `json:"statusDurations"`     // Time spent in each status
+	AgentWorkTimes  map[string]time.Duration `json:"agentWorkTimes"`      // Work time per agent type
+	LastActivityAt  time.Time                `json:"lastActivityAt"`      // When last agent activity occurred
+	IdleSince       time.Time                `json:"idleSince"`           // When ticket became idle (if currently idle)
+	CurrentIdleTime time.Duration            `json:"currentIdleTime"`     // How long it's been idle
+	AgentRunCount   int                      `json:"agentRunCount"`       // Number of agent runs
+	CodeStats       *CodeStats               `json:"codeStats,omitempty"` // Diff of the ticket's branch, when enabled
+}
+
+// CodeStats holds the code volume of a ticket's branch relative to main.
+type CodeStats struct {
+	FilesChanged int `json:"filesChanged"`
+	LinesAdded   int `json:"linesAdded"`
+	LinesRemoved int `json:"linesRemoved"`
 }
 
 // Worktree tracks the git worktree for this ticket.
@@ -206,9 +256,26 @@ type BlockedReason struct {
 
 // CreationContext explains why a ticket was created (for human supervisors).
 type CreationContext struct {
-	Reason      string `json:"reason"`                // prd_breakdown, detected_issue, dependency, user_request
-	ParentTitle string `json:"parentTitle,omitempty"` // Title of parent ticket if from breakdown
-	Details     string `json:"details,omitempty"`     // Additional context
+	Reason         string `json:"reason"`                   // prd_breakdown, detected_issue, dependency, user_request
+	ParentTitle    string `json:"parentTitle,omitempty"`    // Title of parent ticket if from breakdown
+	SourceTicketID string `json:"sourceTicketId,omitempty"` // Ticket whose review found the issue
+	SourceTitle    string `json:"sourceTitle,omitempty"`    // Title of that ticket
+	Details        string `json:"details,omitempty"`        // Additional context
+}
+
+// LinkType describes how a linked ticket relates to the ticket holding the link.
+type LinkType string
+
+const (
+	LinkTypeRelates    LinkType = "relates"    // Related work, e.g. a bugfix and the ticket it was found in
+	LinkTypeBlocks     LinkType = "blocks"     // This ticket blocks the linked one
+	LinkTypeDuplicates LinkType = "duplicates" // This ticket reports the same issue as the linked one
+)
+
+// TicketLink is a typed reference from one ticket to another.
+type TicketLink struct {
+	Type     LinkType `json:"type"`
+	TicketID string   `json:"ticketId"`
 }
 
 // SystemHealthStatus represents the overall health of the factory.
@@ -235,6 +302,17 @@ type SystemHealth struct {
 	ThrashingTickets []string           `json:"thrashingTickets"` // Ticket IDs that keep cycling
 }
 
+// HealthEvent records a transition of the system health status.
+type HealthEvent struct {
+	ID             string             `json:"id"`
+	FromStatus     SystemHealthStatus `json:"fromStatus"`
+	ToStatus       SystemHealthStatus `json:"toStatus"`
+	Message        string             `json:"message"`
+	Acknowledged   bool               `json:"acknowledged"`
+	AcknowledgedAt time.Time          `json:"acknowledgedAt,omitempty"`
+	CreatedAt      time.Time          `json:"createdAt"`
+}
+
 // Ticket represents a single unit of work in the pipeline.
 type Ticket struct {
 	// Identity
@@ -268,6 +346,10 @@ type Ticket struct {
 	ParentID      string `json:"parentId,omitempty"`      // Parent PRD ticket ID (for sub-tickets)
 	ParallelGroup int    `json:"parallelGroup,omitempty"` // Group number for parallel execution scheduling
 
+	// Where the ticket came from when a review of another ticket found it
+	SourceTicketID string       `json:"sourceTicketId,omitempty"` // Ticket whose review found the issue
+	Links          []TicketLink `json:"links,omitempty"`
+
 	// Pipeline state
 	Status          Status   `json:"status"`
 	AssignedAgent   string   `json:"assignedAgent,omitempty"`   // dev-frontend, dev-backend, etc.
@@ -275,14 +357,25 @@ type Ticket struct {
 	CurrentActivity string   `json:"currentActivity,omitempty"` // What the agent is currently doing
 	Signoffs        Signoffs `json:"signoffs"`
 	Bugs            []Bug    `json:"bugs,omitempty"`
+	SkipStages      []Status `json:"skipStages"`       // Review stages to skip; nil uses the board default
+	Paused          bool     `json:"paused,omitempty"` // Agents leave the ticket alone until resumed
+
+	// Provider and model every agent on this ticket runs with, taking
+	// precedence over the agent's provider config
+	ProviderOverride *ProviderOverride `json:"providerOverride,omitempty"`
 
 	// Git integration
 	Worktree *Worktree `json:"worktree,omitempty"`
 
+	// Latest run of the domain's test command after development
+	TestRun *TestRunResult `json:"testRun,omitempty"`
+
 	// Tracking
 	History   []HistoryEntry `json:"history"`
+	TraceID   string         `json:"traceId,omitempty"` // API request that created the ticket
 	CreatedAt time.Time      `json:"createdAt"`
 	UpdatedAt time.Time      `json:"updatedAt"`
+	Version   int            `json:"version"` // Bumped on every save; 0 skips the optimistic lock check
 
 	// Agent notes (learnings, context for future agents)
 	Notes string `json:"notes,omitempty"`
@@ -290,6 +383,9 @@ type Ticket struct {
 	// Tags (populated on fetch from junction table)
 	Tags []Tag `json:"tags,omitempty"`
 
+	// Keyword search excerpt, HTML-escaped with matches in <mark> (set only by search)
+	SearchSnippet string `json:"searchSnippet,omitempty"`
+
 	// Human supervisor context (computed/populated for UI display)
 	BlockedReason   *BlockedReason   `json:"blockedReason,omitempty"`   // Why this is blocked
 	CreationContext *CreationContext `json:"creationContext,omitempty"` // Why this was created
@@ -324,6 +420,20 @@ type AgentRun struct {
 	EndedAt   time.Time `json:"endedAt,omitempty"`
 	Status    string    `json:"status"` // running, success, failed
 	Output    string    `json:"output,omitempty"`
+	TraceID   string    `json:"traceId,omitempty"` // API request that led to this run
+
+	LastHeartbeat time.Time `json:"lastHeartbeat,omitempty"` // Last time the owning orchestrator reported it alive
+
+	RunMetadata
+}
+
+// RunMetadata is what the spawner reports about a finished run. Fields the
+// spawner can't know (tokens for CLI agents) stay zero.
+type RunMetadata struct {
+	Model       string `json:"model,omitempty"`
+	TokenInput  int    `json:"tokenInput,omitempty"`
+	TokenOutput int    `json:"tokenOutput,omitempty"`
+	ExitReason  string `json:"exitReason,omitempty"` // e.g. end_turn, max_tokens, timeout, exit_code_1
 }
 
 // Duration returns the duration of the agent run.
@@ -356,9 +466,43 @@ type AuditEntry struct {
 	TokenInput  int            `json:"tokenInput,omitempty"`
 	TokenOutput int            `json:"tokenOutput,omitempty"`
 	DurationMs  int            `json:"durationMs,omitempty"`
+	TraceID     string         `json:"traceId,omitempty"` // API request that led to this entry
 	CreatedAt   time.Time      `json:"createdAt"`
 }
 
+// ProviderUsage aggregates agent activity attributed to one AI provider over a time range.
+type ProviderUsage struct {
+	Provider      string   `json:"provider"`
+	AgentTypes    []string `json:"agentTypes"` // Agents that used this provider in the range
+	Requests      int      `json:"requests"`   // Responses received
+	Errors        int      `json:"errors"`     // Error events logged
+	InputTokens   int64    `json:"inputTokens"`
+	OutputTokens  int64    `json:"outputTokens"`
+	EstimatedCost float64  `json:"estimatedCostUsd"`
+	AvgLatencyMs  float64  `json:"avgLatencyMs"` // Mean response duration
+	Runs          int      `json:"runs"`         // Finished agent runs
+	FailedRuns    int      `json:"failedRuns"`
+	ErrorRate     float64  `json:"errorRate"` // FailedRuns / Runs
+}
+
+// CostSummary estimates what agent activity cost, from the tokens in the audit
+// log priced at each run's model.
+type CostSummary struct {
+	InputTokens    int64                `json:"inputTokens"`
+	OutputTokens   int64                `json:"outputTokens"`
+	EstimatedCost  float64              `json:"estimatedCostUsd"`
+	Runs           int                  `json:"runs"`
+	Models         map[string]ModelCost `json:"models"`                   // Keyed by model ID
+	UnpricedModels []string             `json:"unpricedModels,omitempty"` // Models without a known price, counted as free
+}
+
+// ModelCost is the share of a CostSummary spent on one model.
+type ModelCost struct {
+	InputTokens   int64   `json:"inputTokens"`
+	OutputTokens  int64   `json:"outputTokens"`
+	EstimatedCost float64 `json:"estimatedCostUsd"`
+}
+
 // ThreadType represents the type of conversation thread.
 type ThreadType string
 
@@ -433,6 +577,19 @@ type Attachment struct {
 	CreatedAt   time.Time `json:"createdAt"`
 }
 
+// PendingPMResponse is a user chat message the PM couldn't answer because the
+// provider kept failing, kept so it can be retried later.
+type PendingPMResponse struct {
+	ID             string    `json:"id"`
+	TicketID       string    `json:"ticketId"`
+	ConversationID string    `json:"conversationId"`
+	Message        string    `json:"message"`
+	Attempts       int       `json:"attempts"`
+	LastError      string    `json:"lastError,omitempty"`
+	CreatedAt      time.Time `json:"createdAt"`
+	UpdatedAt      time.Time `json:"updatedAt"`
+}
+
 // SignoffReport represents parsed agent review output for sign-off documentation.
 type SignoffReport struct {
 	Status           string           `json:"status"` // passed, failed
@@ -447,14 +604,61 @@ type SignoffReport struct {
 	UnmetCriteria    []string         `json:"unmet_criteria,omitempty"`
 	Notes            string           `json:"notes,omitempty"`
 	Reason           string           `json:"reason,omitempty"` // For failures
+
+	// Criteria the reviewer could not verify because they are untestable as
+	// written, as opposed to UnmetCriteria which the implementation fails.
+	UnverifiableCriteria []string `json:"unverifiable_criteria,omitempty"`
+}
+
+// NeedsClarification reports whether the review failed only because acceptance
+// criteria are unverifiable as written, rather than because the implementation is
+// wrong. Such tickets need the requirements clarified, not another dev pass.
+func (r *SignoffReport) NeedsClarification() bool {
+	return len(r.UnverifiableCriteria) > 0 && len(r.Bugs) == 0 && len(r.UnmetCriteria) == 0
 }
 
-// TestRunResult holds test execution statistics.
+// TestRunResult holds test execution statistics, as reported by a review agent
+// or captured from running the domain's test command in the ticket's worktree.
 type TestRunResult struct {
 	Framework string `json:"framework"`
 	Passed    int    `json:"passed"`
 	Failed    int    `json:"failed"`
 	Skipped   int    `json:"skipped,omitempty"`
+
+	// Set when Factory ran the test command itself. Counts are parsed from the
+	// output and stay zero when the runner's format isn't recognized.
+	Command  string        `json:"command,omitempty"`
+	ExitCode int           `json:"exitCode,omitempty"`
+	Output   string        `json:"output,omitempty"` // Tail of the combined output
+	Duration time.Duration `json:"duration,omitempty"`
+	RanAt    time.Time     `json:"ranAt,omitempty"`
+
+	// Set when the reported framework isn't one expected for the ticket's domain.
+	FrameworkMismatch  bool     `json:"frameworkMismatch,omitempty"`
+	ExpectedFrameworks []string `json:"expectedFrameworks,omitempty"`
+}
+
+// CheckFramework validates the reported framework against the frameworks
+// expected for the ticket's domain. A report naming an expected framework
+// (case-insensitively, e.g. "Jest 29" for "jest") is normalized to that name;
+// any other framework is flagged as a mismatch. Reports without a framework,
+// and domains without expectations, are left as they are.
+func (r *TestRunResult) CheckFramework(expected []string) {
+	reported := strings.ToLower(strings.TrimSpace(r.Framework))
+	if reported == "" || len(expected) == 0 {
+		return
+	}
+
+	for _, name := range expected {
+		if want := strings.ToLower(strings.TrimSpace(name)); want != "" && strings.Contains(reported, want) {
+			r.Framework = name
+			r.FrameworkMismatch = false
+			r.ExpectedFrameworks = nil
+			return
+		}
+	}
+	r.FrameworkMismatch = true
+	r.ExpectedFrameworks = expected
 }
 
 // SignoffFinding represents an issue found during review.
@@ -522,6 +726,7 @@ type WorktreePoolEntry struct {
 	Status       WorktreePoolStatus `json:"status"`
 	CreatedAt    time.Time          `json:"createdAt"`
 	LastActivity time.Time          `json:"lastActivity"`
+	CleanupAfter *time.Time         `json:"cleanupAfter,omitempty"` // Merged worktree kept until then
 }
 
 // MergeQueueStatus represents the status of a merge operation.
@@ -536,14 +741,15 @@ const (
 
 // MergeQueueEntry represents a pending or completed merge operation.
 type MergeQueueEntry struct {
-	ID          string           `json:"id"`
-	TicketID    string           `json:"ticketId"`
-	Branch      string           `json:"branch"`
-	Status      MergeQueueStatus `json:"status"`
-	Attempts    int              `json:"attempts"`
-	LastError   string           `json:"lastError,omitempty"`
-	CreatedAt   time.Time        `json:"createdAt"`
-	CompletedAt *time.Time       `json:"completedAt,omitempty"`
+	ID            string           `json:"id"`
+	TicketID      string           `json:"ticketId"`
+	Branch        string           `json:"branch"`
+	Status        MergeQueueStatus `json:"status"`
+	Attempts      int              `json:"attempts"`
+	LastError     string           `json:"lastError,omitempty"`
+	NextAttemptAt *time.Time       `json:"nextAttemptAt,omitempty"` // Retry backoff: skip until this time
+	CreatedAt     time.Time        `json:"createdAt"`
+	CompletedAt   *time.Time       `json:"completedAt,omitempty"`
 }
 
 // WorktreeEventType represents the type of worktree lifecycle event.
@@ -576,6 +782,26 @@ type WorktreePoolStats struct {
 	AvailableSlots int `json:"availableSlots"`
 }
 
+// ActivityKind categorizes an activity feed entry.
+type ActivityKind string
+
+const (
+	ActivityStatusChange ActivityKind = "status_change"
+	ActivityAgentSpawned ActivityKind = "agent_spawned"
+	ActivityMerge        ActivityKind = "merge"
+	ActivityEscalation   ActivityKind = "escalation"
+	ActivityEvent        ActivityKind = "event" // Broadcast events such as orchestrator start/stop
+)
+
+// Activity is a persisted entry in the recent-activity feed.
+type Activity struct {
+	ID        int64        `json:"id"`
+	Kind      ActivityKind `json:"kind"`
+	TicketID  string       `json:"ticketId,omitempty"`
+	Message   string       `json:"message"`
+	CreatedAt time.Time    `json:"createdAt"`
+}
+
 // Board is the top-level kanban state.
 type Board struct {
 	// Metadata
@@ -612,7 +838,41 @@ type BoardConfig struct {
 
 	// Pipeline settings
 	RequireAllSignoffs bool     `json:"requireAllSignoffs"` // All agents must sign off
+	RebaseBeforeQA     bool     `json:"rebaseBeforeQA"`     // Rebase finished dev branches onto main before QA
 	SkipStages         []Status `json:"skipStages"`         // Stages to skip (e.g., UX for backend-only)
+
+	// Review stages by ticket type, ending at DONE (e.g., "tech-debt": [IN_QA,
+	// IN_SEC, DONE]). Types without a pipeline get the standard one.
+	Pipelines map[string][]Status `json:"pipelines,omitempty"`
+
+	// Default acceptance criteria by ticket type, applied on creation to tickets
+	// without criteria of their own (e.g., "bugfix": ["Regression test added"])
+	DefaultAcceptanceCriteria map[string][]string `json:"defaultAcceptanceCriteria,omitempty"`
+
+	// Most tickets in a column at once, for IN_DEV and the review stages (e.g.,
+	// IN_DEV: 2). The orchestrator doesn't start work that would move a ticket
+	// into a full column; columns without a limit are unbounded.
+	WIPLimits map[Status]int `json:"wipLimits,omitempty"`
+
+	// Dashboard settings
+	HiddenColumns []Status `json:"hiddenColumns"` // Board columns to hide (e.g., ICEBOX)
+}
+
+// ConfigProfile is a named group of config values applied together, such as
+// separate limits and providers for dev and prod.
+type ConfigProfile struct {
+	Name      string                     `json:"name"`
+	Values    map[string]string          `json:"values"`              // Config key -> value
+	Providers map[string]ProfileProvider `json:"providers,omitempty"` // Agent type -> provider
+	Active    bool                       `json:"active"`
+	CreatedAt time.Time                  `json:"createdAt"`
+	UpdatedAt time.Time                  `json:"updatedAt"`
+}
+
+// ProfileProvider is the provider and model a config profile assigns to an agent type.
+type ProfileProvider struct {
+	Provider string `json:"provider"`
+	Model    string `json:"model,omitempty"` // Empty uses the provider default
 }
 
 // ADRStatus represents the status of an Architecture Decision Record.
@@ -641,6 +901,22 @@ type ADR struct {
 	UpdatedAt    time.Time `json:"updatedAt"`
 }
 
+// ADRRollout shows how far the tickets an ADR affects have progressed.
+type ADRRollout struct {
+	ADRID             string      `json:"adrId"`
+	Tickets           []ADRTicket `json:"tickets"`
+	Done              int         `json:"done"`
+	CompletionPercent float64     `json:"completionPercent"` // Done of the linked tickets not abandoned, 0-100
+}
+
+// ADRTicket is a ticket linked to an ADR, as listed in its rollout.
+type ADRTicket struct {
+	ID     string `json:"id"`
+	Title  string `json:"title"`
+	Status Status `json:"status"`
+	Domain Domain `json:"domain"`
+}
+
 // TagType represents the category of a tag.
 type TagType string
 
@@ -662,6 +938,37 @@ type Tag struct {
 	Description string  `json:"description"` // Optional description
 }
 
+// TagStats breaks a tag's tickets down by status for portfolio and epic rollups.
+type TagStats struct {
+	Tag               Tag            `json:"tag"`
+	Total             int            `json:"total"`
+	ByStatus          map[Status]int `json:"byStatus"`
+	CompletionPercent float64        `json:"completionPercent"` // DONE share of tickets, ignoring ABANDONED
+}
+
+// NewTagStats counts a tag's tickets by status.
+func NewTagStats(tag Tag, tickets []Ticket) TagStats {
+	stats := TagStats{Tag: tag, Total: len(tickets), ByStatus: make(map[Status]int)}
+	for _, t := range tickets {
+		stats.ByStatus[t.Status]++
+	}
+	if active := stats.Total - stats.ByStatus[StatusAbandoned]; active > 0 {
+		stats.CompletionPercent = float64(stats.ByStatus[StatusDone]) / float64(active) * 100
+	}
+	return stats
+}
+
+// ApplyDefaultAcceptanceCriteria gives a ticket without acceptance criteria the
+// configured defaults for its type, if any.
+func (c BoardConfig) ApplyDefaultAcceptanceCriteria(t *Ticket) {
+	if len(t.AcceptanceCriteria) > 0 {
+		return
+	}
+	if defaults := c.DefaultAcceptanceCriteria[t.Type]; len(defaults) > 0 {
+		t.AcceptanceCriteria = append([]string(nil), defaults...)
+	}
+}
+
 // FormatADRID formats an ADR number into a standard ADR ID string (e.g., "ADR-001").
 func FormatADRID(num int) string {
 	return fmt.Sprintf("ADR-%03d", num)
@@ -746,6 +1053,9 @@ func (t *Ticket) ComputeBlockedReason(allTickets []Ticket) *BlockedReason {
 			switch {
 			case contains(note, "security"):
 				category = "policy"
+			case contains(note, "merge conflict"):
+				category = "conflict"
+				isManaged = false // Needs a human to resolve
 			case contains(note, "confidence") || contains(note, "unclear"):
 				category = "confidence"
 				isManaged = false // Needs human guidance
@@ -788,6 +1098,27 @@ func (t *Ticket) ComputeCreationContext(allTickets []Ticket) *CreationContext {
 		}
 	}
 
+	// Follow-up work filed by a review of another ticket
+	if t.SourceTicketID != "" {
+		var sourceTitle string
+		for _, other := range allTickets {
+			if other.ID == t.SourceTicketID {
+				sourceTitle = other.Title
+				break
+			}
+		}
+		details := "Found while reviewing " + t.SourceTicketID
+		if sourceTitle != "" {
+			details += ": " + sourceTitle
+		}
+		return &CreationContext{
+			Reason:         "detected_issue",
+			SourceTicketID: t.SourceTicketID,
+			SourceTitle:    sourceTitle,
+			Details:        details,
+		}
+	}
+
 	// Based on type
 	switch t.Type {
 	case "bugfix":
@@ -814,14 +1145,55 @@ func (t *Ticket) ComputeCreationContext(allTickets []Ticket) *CreationContext {
 	}
 }
 
+// HealthConfig tunes how ComputeSystemHealth detects thrashing tickets.
+// Zero fields use the DefaultHealthConfig values.
+type HealthConfig struct {
+	ThrashingWindow     int // Most recent history entries examined per ticket
+	ThrashingRepeats    int // Times one status must appear in the window to count as thrashing
+	ThrashingMinHistory int // Tickets with shorter histories are never thrashing
+}
+
+// DefaultHealthConfig returns the thrashing thresholds: a status appearing 3+
+// times in the last 10 history entries, once a ticket has at least 6.
+func DefaultHealthConfig() HealthConfig {
+	return HealthConfig{
+		ThrashingWindow:     10,
+		ThrashingRepeats:    3,
+		ThrashingMinHistory: 6,
+	}
+}
+
+// withDefaults fills unset fields from DefaultHealthConfig.
+func (c HealthConfig) withDefaults() HealthConfig {
+	def := DefaultHealthConfig()
+	if c.ThrashingWindow <= 0 {
+		c.ThrashingWindow = def.ThrashingWindow
+	}
+	if c.ThrashingRepeats <= 0 {
+		c.ThrashingRepeats = def.ThrashingRepeats
+	}
+	if c.ThrashingMinHistory <= 0 {
+		c.ThrashingMinHistory = def.ThrashingMinHistory
+	}
+	return c
+}
+
 // ComputeSystemHealth analyzes the board state and returns health indicators.
-func ComputeSystemHealth(tickets []Ticket) *SystemHealth {
-	var blocked, active, done, reworked, thrashing int
+func ComputeSystemHealth(tickets []Ticket, cfg HealthConfig) *SystemHealth {
+	cfg = cfg.withDefaults()
+	var blocked, active, done, reworked, thrashing, counted int
 	var totalIdleTime time.Duration
 	var idleCount int
 	thrashingTickets := []string{}
+	now := time.Now()
 
 	for _, t := range tickets {
+		// Iceboxed tickets are parked outside the pipeline entirely.
+		if t.Status == StatusIcebox {
+			continue
+		}
+		counted++
+
 		switch t.Status {
 		case StatusBlocked:
 			blocked++
@@ -836,11 +1208,22 @@ func ComputeSystemHealth(tickets []Ticket) *SystemHealth {
 		// Count rework (tickets that went backwards in the pipeline)
 		reworked += countRework(t.History)
 
-		// Detect thrashing (same status appearing 3+ times)
-		if isThrashing(t.History) {
+		// Detect thrashing (same status recurring within the window)
+		if isThrashing(t.History, cfg) {
 			thrashing++
 			thrashingTickets = append(thrashingTickets, t.ID)
 		}
+
+		// Accumulate time spent waiting rather than being worked
+		if idle := idleTime(t.History, now); idle > 0 {
+			totalIdleTime += idle
+			idleCount++
+		}
+	}
+
+	avgIdle := time.Duration(0)
+	if idleCount > 0 {
+		avgIdle = totalIdleTime / time.Duration(idleCount)
 	}
 
 	total := blocked + active
@@ -851,18 +1234,14 @@ func ComputeSystemHealth(tickets []Ticket) *SystemHealth {
 			Message:      "No active work in progress",
 			BlockedCount: 0,
 			ActiveCount:  0,
+			AvgIdleTime:  avgIdle,
 		}
 	}
 
 	blockedRatio := float64(blocked) / float64(total)
 	reworkRate := 0.0
-	if len(tickets) > 0 {
-		reworkRate = float64(reworked) / float64(len(tickets))
-	}
-
-	avgIdle := time.Duration(0)
-	if idleCount > 0 {
-		avgIdle = totalIdleTime / time.Duration(idleCount)
+	if counted > 0 {
+		reworkRate = float64(reworked) / float64(counted)
 	}
 
 	health := &SystemHealth{
@@ -945,17 +1324,19 @@ func truncateString(s string, maxLen int) string {
 func countRework(history []HistoryEntry) int {
 	rework := 0
 	statusOrder := map[Status]int{
-		StatusBacklog:      0,
-		StatusApproved:     1,
-		StatusRefining:     2,
-		StatusAwaitingUser: 3,
-		StatusReady:        4,
-		StatusInDev:        5,
-		StatusInQA:         6,
-		StatusInUX:         7,
-		StatusInSec:        8,
-		StatusPMReview:     9,
-		StatusDone:         10,
+		StatusBacklog:               0,
+		StatusApproved:              1,
+		StatusRefining:              2,
+		StatusAwaitingUser:          3,
+		StatusReady:                 4,
+		StatusInDev:                 5,
+		StatusInQA:                  6,
+		StatusInUX:                  7,
+		StatusInSec:                 8,
+		StatusPMReview:              9,
+		StatusAwaitingMergeApproval: 10,
+		StatusPendingDone:           11,
+		StatusDone:                  12,
 	}
 
 	var prevOrder int
@@ -972,25 +1353,54 @@ func countRework(history []HistoryEntry) int {
 	return rework
 }
 
-func isThrashing(history []HistoryEntry) bool {
-	if len(history) < 6 {
+// idleStatuses are the statuses in which a ticket waits rather than being
+// worked on.
+var idleStatuses = map[Status]bool{
+	StatusBacklog:      true,
+	StatusApproved:     true,
+	StatusAwaitingUser: true,
+	StatusReady:        true,
+	StatusBlocked:      true,
+}
+
+// idleTime returns how long a ticket's history spent in idle statuses, with
+// the latest status lasting until now. Entries without a timestamp are skipped.
+func idleTime(history []HistoryEntry, now time.Time) time.Duration {
+	var idle time.Duration
+	for i, entry := range history {
+		if !idleStatuses[entry.Status] || entry.At.IsZero() {
+			continue
+		}
+		end := now
+		if i < len(history)-1 {
+			end = history[i+1].At
+		}
+		if end.After(entry.At) {
+			idle += end.Sub(entry.At)
+		}
+	}
+	return idle
+}
+
+func isThrashing(history []HistoryEntry, cfg HealthConfig) bool {
+	if len(history) < cfg.ThrashingMinHistory {
 		return false
 	}
 
 	// Count occurrences of each status in recent history
 	statusCounts := make(map[Status]int)
 	recentHistory := history
-	if len(history) > 10 {
-		recentHistory = history[len(history)-10:]
+	if len(history) > cfg.ThrashingWindow {
+		recentHistory = history[len(history)-cfg.ThrashingWindow:]
 	}
 
 	for _, entry := range recentHistory {
 		statusCounts[entry.Status]++
 	}
 
-	// If any status appears 3+ times in recent history, it's thrashing
+	// If any status repeats often enough in recent history, it's thrashing
 	for _, count := range statusCounts {
-		if count >= 3 {
+		if count >= cfg.ThrashingRepeats {
 			return true
 		}
 	}
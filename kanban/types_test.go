@@ -0,0 +1,94 @@
+package kanban
+
+import (
+	"testing"
+	"time"
+)
+
+func TestThrashingThresholdsAreConfigurable(t *testing.T) {
+	// Three tickets that have each been in dev and QA three times
+	var tickets []Ticket
+	for _, id := range []string{"T-1", "T-2", "T-3"} {
+		ticket := Ticket{ID: id, Status: StatusInDev}
+		for i := 0; i < 3; i++ {
+			ticket.History = append(ticket.History, HistoryEntry{Status: StatusInDev}, HistoryEntry{Status: StatusInQA})
+		}
+		tickets = append(tickets, ticket)
+	}
+
+	tests := []struct {
+		name      string
+		cfg       HealthConfig
+		thrashing bool
+	}{
+		{"defaults", HealthConfig{}, true},
+		{"stricter repeats", HealthConfig{ThrashingRepeats: 2}, true},
+		{"looser repeats", HealthConfig{ThrashingRepeats: 4}, false},
+		{"narrower window", HealthConfig{ThrashingWindow: 4}, false},
+		{"longer minimum history", HealthConfig{ThrashingMinHistory: 8}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			health := ComputeSystemHealth(tickets, tt.cfg)
+			if got := health.Status == SystemHealthThrashing; got != tt.thrashing {
+				t.Errorf("expected thrashing=%v, got status %s (thrashing tickets %v)", tt.thrashing, health.Status, health.ThrashingTickets)
+			}
+		})
+	}
+}
+
+func TestSystemHealthAveragesIdleTimeFromHistory(t *testing.T) {
+	start := time.Now().Add(-24 * time.Hour)
+	at := func(hours int) time.Time { return start.Add(time.Duration(hours) * time.Hour) }
+
+	tickets := []Ticket{
+		{
+			// 2h in backlog, 1h ready, then worked to done
+			ID:     "T-1",
+			Status: StatusDone,
+			History: []HistoryEntry{
+				{Status: StatusBacklog, At: at(0)},
+				{Status: StatusReady, At: at(2)},
+				{Status: StatusInDev, At: at(3)},
+				{Status: StatusDone, At: at(5)},
+			},
+		},
+		{
+			// 1h approved, 4h blocked mid-development
+			ID:     "T-2",
+			Status: StatusDone,
+			History: []HistoryEntry{
+				{Status: StatusApproved, At: at(0)},
+				{Status: StatusInDev, At: at(1)},
+				{Status: StatusBlocked, At: at(2)},
+				{Status: StatusInDev, At: at(6)},
+				{Status: StatusDone, At: at(7)},
+			},
+		},
+		{
+			// Never idle: doesn't count toward the average
+			ID:     "T-3",
+			Status: StatusInQA,
+			History: []HistoryEntry{
+				{Status: StatusInDev, At: at(0)},
+				{Status: StatusInQA, At: at(1)},
+			},
+		},
+	}
+
+	health := ComputeSystemHealth(tickets, HealthConfig{})
+	if want := 4 * time.Hour; health.AvgIdleTime != want {
+		t.Errorf("expected average idle time %s, got %s", want, health.AvgIdleTime)
+	}
+
+	// A ticket still waiting accrues idle time up to now
+	tickets = append(tickets, Ticket{
+		ID:      "T-4",
+		Status:  StatusAwaitingUser,
+		History: []HistoryEntry{{Status: StatusAwaitingUser, At: time.Now().Add(-7 * time.Hour)}},
+	})
+	health = ComputeSystemHealth(tickets, HealthConfig{})
+	if got := health.AvgIdleTime; got < 5*time.Hour-time.Minute || got > 5*time.Hour+time.Minute {
+		t.Errorf("expected average idle time near 5h, got %s", got)
+	}
+}
@@ -0,0 +1,35 @@
+package kanban
+
+import "fmt"
+
+// WIPLimitedStatuses are the columns a work-in-progress limit may be set on:
+// development and the review stages.
+var WIPLimitedStatuses = append([]Status{StatusInDev}, ReviewStages...)
+
+// ValidateWIPLimits checks that every limit is on a column that takes one and
+// isn't negative. A limit of 0 means none.
+func ValidateWIPLimits(limits map[Status]int) error {
+	for status, limit := range limits {
+		if !containsStatus(WIPLimitedStatuses, status) {
+			return fmt.Errorf("%s does not take a WIP limit", status)
+		}
+		if limit < 0 {
+			return fmt.Errorf("WIP limit for %s must not be negative", status)
+		}
+	}
+	return nil
+}
+
+// WIPLimitReached reports whether a column holding count tickets has no room
+// for another under its WIP limit.
+func (c BoardConfig) WIPLimitReached(status Status, count int) bool {
+	limit := c.WIPLimits[status]
+	return limit > 0 && count >= limit
+}
+
+// WIPExceeded reports whether a column holding count tickets is over its WIP
+// limit, as it can be when tickets are moved in by hand.
+func (c BoardConfig) WIPExceeded(status Status, count int) bool {
+	limit := c.WIPLimits[status]
+	return limit > 0 && count > limit
+}
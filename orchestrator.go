@@ -4,17 +4,22 @@ package factory
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log/slog"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/madhatter5501/Factory/agents"
 	"github.com/madhatter5501/Factory/git"
+	"github.com/madhatter5501/Factory/internal/notify"
 	"github.com/madhatter5501/Factory/kanban"
 
 	"github.com/google/uuid"
@@ -33,12 +38,20 @@ type Orchestrator struct {
 	spawner        agents.AgentSpawner
 	spawnerFactory *agents.SpawnerFactory
 	backgroundMgr  *BackgroundAgentManager
+	limiter        *agents.AgentLimiter        // Global in-flight agent ceiling; nil is unlimited
+	ticketRuns     *ticketRunSpawner           // Cancels a ticket's in-flight agents; nil when not wrapped
+	codeRetriever  agents.CodeContextRetriever // Code snippets for dev prompts; nil when disabled
+	notifier       notify.Notifier             // Operator notifications; nil logs them
 
 	// Runtime
 	logger     *slog.Logger
 	cancelFunc context.CancelFunc
 	wg         sync.WaitGroup
 	mu         sync.Mutex
+	lockHeld   bool // Run holds the board's orchestrator lock; guarded by mu
+
+	// What the current cycle has done; agent starts are checked against the ramp-up budget
+	cycleSummary *CycleSummary
 
 	// Metrics
 	metrics Metrics
@@ -56,11 +69,86 @@ type Config struct {
 	AgentTimeout      time.Duration `json:"agentTimeout"`
 	CycleInterval     time.Duration `json:"cycleInterval"`
 
+	// Run liveness: running agents beat every HeartbeatInterval (0 disables), and on
+	// startup only runs silent for longer than OrphanGracePeriod are marked orphaned.
+	HeartbeatInterval time.Duration `json:"heartbeatInterval"`
+	OrphanGracePeriod time.Duration `json:"orphanGracePeriod"`
+
+	// Tickets whose agent runs have failed more than MaxTicketFailures times in
+	// total are abandoned rather than re-attempted (0 disables).
+	MaxTicketFailures int `json:"maxTicketFailures"`
+
+	// Agent runs failing transiently (timeouts, API rate limits and 5xx errors)
+	// are retried up to AgentMaxRetries times, waiting AgentRetryBackoff before
+	// the first retry and twice as long before each further one, up to
+	// maxAgentRetryBackoff (0 disables).
+	AgentMaxRetries   int           `json:"agentMaxRetries"`
+	AgentRetryBackoff time.Duration `json:"agentRetryBackoff"`
+
+	// Startup ramp-up: the first cycle starts at most SpawnRampUpInitial agents and
+	// each later cycle that many more, until SpawnRampUpCycles cycles have run, so a
+	// board full of ready work doesn't spawn everything at once (0 disables).
+	SpawnRampUpInitial int `json:"spawnRampUpInitial"`
+	SpawnRampUpCycles  int `json:"spawnRampUpCycles"`
+
+	// Most agents in flight at once across every type, including background
+	// agents and the dashboard's PM chat (0 disables). Callers sharing the
+	// ceiling pass the same AgentLimiter; nil creates one from MaxTotalAgents.
+	MaxTotalAgents int                  `json:"maxTotalAgents"`
+	AgentLimiter   *agents.AgentLimiter `json:"-"`
+
+	// Operator notifications, such as escalated blocked tickets. The dashboard
+	// passes its own notifier so both share coalescing; nil logs them.
+	Notifier notify.Notifier `json:"-"`
+
 	// Behavior
-	AutoMerge   bool `json:"autoMerge"`   // Auto-merge completed tickets
-	AutoCleanup bool `json:"autoCleanup"` // Auto-cleanup merged worktrees
-	Verbose     bool `json:"verbose"`     // Verbose logging
-	DryRun      bool `json:"dryRun"`      // Don't actually run agents
+	AutoMerge            bool `json:"autoMerge"`            // Auto-merge completed tickets
+	RequireMergeApproval bool `json:"requireMergeApproval"` // Hold signed-off tickets for human approval before merge
+	AutoCleanup          bool `json:"autoCleanup"`          // Auto-cleanup merged worktrees
+	Verbose              bool `json:"verbose"`              // Verbose logging
+	DryRun               bool `json:"dryRun"`               // Don't actually run agents
+
+	// How long tickets that passed PM review wait in PENDING_DONE before DONE,
+	// so a late sign-off with blocking bugs can still stop them (0 disables).
+	DoneSoakPeriod time.Duration `json:"doneSoakPeriod"`
+
+	// How long AutoCleanup keeps a merged ticket's worktree for inspection
+	// before the worktree manager removes it (0 removes it at merge).
+	WorktreeRetention time.Duration `json:"worktreeRetention"`
+
+	// Run a PRD's sub-tickets one parallel group at a time: later groups wait in
+	// QUEUED until every sub-ticket in the earlier groups is DONE.
+	SequentialParallelGroups bool `json:"sequentialParallelGroups"`
+
+	// Most sub-tickets created from one PRD breakdown (0 disables). Proposals
+	// beyond the cap are noted on the parent ticket rather than created.
+	MaxSubTicketsPerPRD int `json:"maxSubTicketsPerPrd"`
+
+	// Send the digest recorded when an iteration completes to operators
+	// through the notifier, not just store it.
+	NotifyIterationDigest bool `json:"notifyIterationDigest"`
+
+	// Where a ticket goes when an agent run fails, keyed by agent type ("dev"
+	// covers every dev agent), failure category, or both as "qa:timeout".
+	// Failures without a route leave the ticket in place to be retried.
+	FailureRouting map[string]kanban.Status `json:"failureRouting"`
+
+	// Sections every final PRD must contain (empty disables). A finalized PRD
+	// missing any of them goes back for another discussion round.
+	PRDTemplate []string `json:"prdTemplate"`
+
+	// Where tickets go when review finds their acceptance criteria unverifiable
+	// as written (default AWAITING_USER), instead of back to dev.
+	UnverifiableCriteriaStatus kanban.Status `json:"unverifiableCriteriaStatus"`
+
+	// Severities of bugs reported by review agents that are filed as bugfix
+	// tickets linked back to the reviewed ticket (empty disables).
+	BugfixTicketSeverities []string `json:"bugfixTicketSeverities"`
+
+	// PRD fast-track: well-specified tickets skip the expert discussion rounds
+	FastTrackMinCriteria       int `json:"fastTrackMinCriteria"`       // Minimum acceptance criteria (0 disables fast-track)
+	FastTrackMinDescriptionLen int `json:"fastTrackMinDescriptionLen"` // Minimum description length
+	FastTrackMaxDescriptionLen int `json:"fastTrackMaxDescriptionLen"` // Maximum description length (longer implies complexity)
 
 	// API Mode Configuration (for token efficiency)
 	SpawnerMode    agents.SpawnerMode `json:"spawnerMode"`    // "cli", "api", or "auto"
@@ -68,6 +156,28 @@ type Config struct {
 	VectorDBPath   string             `json:"vectorDbPath"`   // Path to RAG vector database
 	Model          string             `json:"model"`          // Model override (default: claude-sonnet-4)
 	IndexOnStartup bool               `json:"indexOnStartup"` // Index prompts on startup
+
+	// Snippets of related code retrieved from the RAG index into dev prompts
+	// (0 disables; needs RAGEnabled).
+	CodeContextSnippets int `json:"codeContextSnippets"`
+
+	// Shell commands CLI agents may run, keyed by agent type ("dev" covers every
+	// dev agent). Agents without a policy run with permission checks skipped.
+	CommandPolicies map[string]agents.CommandPolicy `json:"commandPolicies"`
+
+	// API mode prompt token budgets by model; models without one use their
+	// context window minus the response limit.
+	ContextBudgets map[string]int `json:"contextBudgets"`
+
+	// Shell commands run in the ticket's worktree after dev, keyed by domain,
+	// e.g. {"backend": "go test ./..."}. A failing command blocks the ticket
+	// before QA; domains without one go straight to QA (empty disables).
+	TestCommands map[string]string `json:"testCommands"`
+
+	// Test frameworks expected in sign-off reports, keyed by domain, e.g.
+	// {"frontend": ["jest", "vitest"]}. Reported frameworks are normalized to
+	// these names and any other is flagged on the report.
+	TestFrameworks map[string][]string `json:"testFrameworks"`
 }
 
 // DefaultConfig returns sensible defaults.
@@ -78,10 +188,17 @@ func DefaultConfig() Config {
 		MaxParallelAgents: 3,
 		AgentTimeout:      30 * time.Minute,
 		CycleInterval:     10 * time.Second,
+		HeartbeatInterval: 30 * time.Second,
+		OrphanGracePeriod: 2 * time.Minute,
+		AgentMaxRetries:   2,
+		AgentRetryBackoff: 30 * time.Second,
 		AutoMerge:         false, // Require manual merge for safety
 		AutoCleanup:       true,
 		Verbose:           true,
 		DryRun:            false,
+		FailureRouting:    DefaultFailureRouting(),
+		// Unverifiable acceptance criteria go back to the user for clarification
+		UnverifiableCriteriaStatus: kanban.StatusAwaitingUser,
 		// API mode defaults - auto-detect based on ANTHROPIC_API_KEY
 		SpawnerMode:    agents.SpawnerModeAuto,
 		RAGEnabled:     true,
@@ -90,6 +207,19 @@ func DefaultConfig() Config {
 	}
 }
 
+// DefaultFailureRouting returns where tickets go when an agent run fails: task
+// failures go back to dev and runs that couldn't start block the ticket.
+// Provider errors and timeouts that outlast their retries leave the ticket in
+// place for the stuck-ticket healer, except a review that recorded bugs, which
+// blocks it. Routes by agent are opt-in.
+func DefaultFailureRouting() map[string]kanban.Status {
+	return map[string]kanban.Status{
+		string(agents.FailureTaskFailure): kanban.StatusReady,
+		// A run that couldn't start will fail the same way until someone fixes the setup
+		string(agents.FailureSetupError): kanban.StatusBlocked,
+	}
+}
+
 // Metrics tracks orchestrator statistics.
 type Metrics struct {
 	CyclesRun        int           `json:"cyclesRun"`
@@ -100,6 +230,19 @@ type Metrics struct {
 	TotalRuntime     time.Duration `json:"totalRuntime"`
 }
 
+// Backpressure reports dev capacity against its limits and why READY tickets are waiting.
+type Backpressure struct {
+	ActiveDevRuns         int  `json:"activeDevRuns"`
+	DevLimit              int  `json:"devLimit"`
+	WorktreesUsed         int  `json:"worktreesUsed"`
+	WorktreeLimit         int  `json:"worktreeLimit"` // 0 when the store has no worktree pool
+	ReadyTickets          int  `json:"readyTickets"`
+	WaitingOnLimits       int  `json:"waitingOnLimits"`       // Startable but no dev, worktree or IN_DEV WIP slot free
+	WaitingOnDependencies int  `json:"waitingOnDependencies"` // Blocked by unfinished dependencies
+	WaitingOnConflicts    int  `json:"waitingOnConflicts"`    // Blocked by file overlap with in-dev tickets
+	Throttled             bool `json:"throttled"`
+}
+
 // NewOrchestrator creates a new orchestrator with the provided state store.
 func NewOrchestrator(repoRoot string, config Config, state kanban.StateStore) (*Orchestrator, error) {
 	// Look for prompts in ./prompts/ first (when running from factory dir),
@@ -122,14 +265,16 @@ func NewOrchestrator(repoRoot string, config Config, state kanban.StateStore) (*
 
 	// Create spawner using factory (supports CLI and API modes)
 	spawnerConfig := agents.SpawnerConfig{
-		Mode:           config.SpawnerMode,
-		PromptsDir:     promptsDir,
-		Timeout:        config.AgentTimeout,
-		Verbose:        config.Verbose,
-		Model:          config.Model,
-		RAGEnabled:     config.RAGEnabled,
-		VectorDBPath:   config.VectorDBPath,
-		IndexOnStartup: config.IndexOnStartup,
+		Mode:            config.SpawnerMode,
+		PromptsDir:      promptsDir,
+		Timeout:         config.AgentTimeout,
+		Verbose:         config.Verbose,
+		Model:           config.Model,
+		RAGEnabled:      config.RAGEnabled,
+		VectorDBPath:    config.VectorDBPath,
+		IndexOnStartup:  config.IndexOnStartup,
+		CommandPolicies: config.CommandPolicies,
+		ContextBudgets:  config.ContextBudgets,
 	}
 	spawnerFactory := agents.NewSpawnerFactory(spawnerConfig)
 	spawner, err := spawnerFactory.CreateSpawner()
@@ -145,11 +290,37 @@ func NewOrchestrator(repoRoot string, config Config, state kanban.StateStore) (*
 		logger.Info("Audit logging enabled for agent spawning")
 	}
 
+	// Every spawn waits for a slot under the global agent ceiling
+	limiter := config.AgentLimiter
+	if limiter == nil {
+		limiter = agents.NewAgentLimiter(config.MaxTotalAgents)
+	}
+	if limiter != nil {
+		finalSpawner = agents.NewLimitedSpawner(finalSpawner, limiter)
+	}
+
+	// Outermost, so a paused ticket's agents can be cancelled even while
+	// waiting for a slot
+	ticketRuns := newTicketRunSpawner(finalSpawner)
+	finalSpawner = ticketRuns
+
 	logger.Info("Spawner initialized",
 		"mode", spawnerFactory.GetMode(),
 		"rag_enabled", config.RAGEnabled,
+		"max_total_agents", limiter.Max(),
 	)
 
+	// Without RAG, dev prompts go out without related code rather than failing
+	var codeRetriever agents.CodeContextRetriever
+	if config.RAGEnabled && config.CodeContextSnippets > 0 {
+		retriever, err := agents.NewRAGRetriever(config.VectorDBPath)
+		if err != nil {
+			logger.Warn("Code context disabled, RAG unavailable", "error", err)
+		} else {
+			codeRetriever = retriever
+		}
+	}
+
 	return &Orchestrator{
 		repoRoot:       repoRoot,
 		promptsDir:     promptsDir,
@@ -158,11 +329,43 @@ func NewOrchestrator(repoRoot string, config Config, state kanban.StateStore) (*
 		worktree:       worktree,
 		spawner:        finalSpawner,
 		spawnerFactory: spawnerFactory,
+		limiter:        limiter,
+		ticketRuns:     ticketRuns,
+		codeRetriever:  codeRetriever,
+		notifier:       config.Notifier,
 		logger:         logger,
 	}, nil
 }
 
-// Initialize sets up the orchestrator.
+// OrchestratorLockStore is implemented by stores that can keep two
+// orchestrator processes from driving the same board.
+type OrchestratorLockStore interface {
+	AcquireOrchestratorLock(owner string, staleAfter time.Duration) error
+	HeartbeatOrchestratorLock(owner string) error
+	ReleaseOrchestratorLock(owner string) error
+}
+
+// IterationDigestStore is implemented by stores that can summarize an
+// iteration and keep the digest.
+type IterationDigestStore interface {
+	BuildIterationDigest(iteration *kanban.Iteration) (*kanban.IterationDigest, error)
+	SaveIterationDigest(digest *kanban.IterationDigest) error
+	GetIterationDigest(iterationID string) (*kanban.IterationDigest, error)
+}
+
+// orchestratorLockTimeout is how long a lock holder may go without a heartbeat
+// before another instance reclaims the lock.
+const orchestratorLockTimeout = 2 * time.Minute
+
+// orchestratorLockOwner identifies this process as a lock holder. Orchestrators
+// in one process share it, so the dashboard can restart its orchestrator.
+func orchestratorLockOwner() string {
+	host, _ := os.Hostname()
+	return fmt.Sprintf("%s:%d", host, os.Getpid())
+}
+
+// Initialize sets up the orchestrator. It doesn't take the board's
+// orchestrator lock, so read-only commands work while another process runs.
 func (o *Orchestrator) Initialize() error {
 	o.logger.Info("Initializing factory orchestrator")
 
@@ -183,9 +386,9 @@ func (o *Orchestrator) Initialize() error {
 		o.logger.Warn("Failed to cleanup worktrees", "error", err)
 	}
 
-	// On startup, mark ALL running agents as orphaned since we just started
-	// (if the factory was killed, any "running" agents are no longer running)
-	orphanedCount := o.state.CleanupOrphanedRunningAgents()
+	// On startup, mark running agents as orphaned unless a previous instance
+	// reported them alive within the grace period (e.g. a quick restart)
+	orphanedCount := o.state.CleanupOrphanedRunningAgents(o.config.OrphanGracePeriod)
 	if orphanedCount > 0 {
 		o.logger.Info("Cleaned up orphaned agent runs from previous session", "count", orphanedCount)
 	}
@@ -200,8 +403,17 @@ func (o *Orchestrator) Initialize() error {
 	return nil
 }
 
-// Run starts the orchestrator main loop.
+// Run starts the orchestrator main loop. It fails if another live process
+// holds the board's orchestrator lock.
 func (o *Orchestrator) Run(ctx context.Context) error {
+	o.mu.Lock()
+	err := o.acquireLock()
+	o.lockHeld = err == nil
+	o.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
 	ctx, o.cancelFunc = context.WithCancel(ctx)
 	startTime := time.Now()
 
@@ -213,6 +425,32 @@ func (o *Orchestrator) Run(ctx context.Context) error {
 		defer o.backgroundMgr.Stop()
 	}
 
+	// Keep running agents marked alive so a quick restart doesn't orphan them
+	if o.config.HeartbeatInterval > 0 {
+		o.wg.Add(1)
+		go func() {
+			defer o.wg.Done()
+			o.runHeartbeat(ctx)
+		}()
+	}
+
+	// Drain the merge queue independently of the main cycle
+	if store, ok := o.state.(WorktreeStore); ok && o.backgroundMgr != nil {
+		o.wg.Add(1)
+		go func() {
+			defer o.wg.Done()
+			o.backgroundMgr.runMergeQueueWorker(ctx, store)
+		}()
+	}
+
+	if lockStore, ok := o.state.(OrchestratorLockStore); ok {
+		o.wg.Add(1)
+		go func() {
+			defer o.wg.Done()
+			o.runLockHeartbeat(ctx, lockStore)
+		}()
+	}
+
 	ticker := time.NewTicker(o.config.CycleInterval)
 	defer ticker.Stop()
 
@@ -221,17 +459,88 @@ func (o *Orchestrator) Run(ctx context.Context) error {
 		case <-ctx.Done():
 			o.logger.Info("Orchestrator shutting down")
 			o.wg.Wait()
+			o.Close()
 			o.metrics.TotalRuntime = time.Since(startTime)
 			return nil
 
 		case <-ticker.C:
-			if err := o.runCycle(ctx); err != nil {
+			if _, err := o.runCycle(ctx); err != nil {
 				o.logger.Error("Cycle failed", "error", err)
 			}
 		}
 	}
 }
 
+// runHeartbeat periodically records that running agents are still alive.
+func (o *Orchestrator) runHeartbeat(ctx context.Context) {
+	ticker := time.NewTicker(o.config.HeartbeatInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			o.state.HeartbeatRunningAgents()
+		}
+	}
+}
+
+// runLockHeartbeat keeps the orchestrator lock fresh while the orchestrator
+// runs, so other instances don't reclaim it as stale.
+func (o *Orchestrator) runLockHeartbeat(ctx context.Context, lockStore OrchestratorLockStore) {
+	ticker := time.NewTicker(orchestratorLockTimeout / 4)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := lockStore.HeartbeatOrchestratorLock(orchestratorLockOwner()); err != nil {
+				o.logger.Error("Failed to refresh orchestrator lock", "error", err)
+			}
+		}
+	}
+}
+
+// Close releases the orchestrator lock Run took and the code context index.
+// Run calls it on shutdown; callers that Initialize without running call it
+// when done.
+func (o *Orchestrator) Close() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.lockHeld {
+		o.releaseLock()
+		o.lockHeld = false
+	}
+	if closer, ok := o.codeRetriever.(io.Closer); ok {
+		if err := closer.Close(); err != nil {
+			o.logger.Warn("Failed to close code context index", "error", err)
+		}
+		o.codeRetriever = nil
+	}
+}
+
+// acquireLock takes the board's orchestrator lock, if the store keeps one.
+func (o *Orchestrator) acquireLock() error {
+	if lockStore, ok := o.state.(OrchestratorLockStore); ok {
+		if err := lockStore.AcquireOrchestratorLock(orchestratorLockOwner(), orchestratorLockTimeout); err != nil {
+			return fmt.Errorf("another orchestrator is running against this board: %w", err)
+		}
+	}
+	return nil
+}
+
+// releaseLock gives up the board's orchestrator lock.
+func (o *Orchestrator) releaseLock() {
+	if lockStore, ok := o.state.(OrchestratorLockStore); ok {
+		if err := lockStore.ReleaseOrchestratorLock(orchestratorLockOwner()); err != nil {
+			o.logger.Warn("Failed to release orchestrator lock", "error", err)
+		}
+	}
+}
+
 // Stop gracefully stops the orchestrator.
 func (o *Orchestrator) Stop() {
 	if o.cancelFunc != nil {
@@ -252,17 +561,71 @@ func (o *Orchestrator) GetTokenUsage() *agents.TokenUsage {
 	return nil
 }
 
-// runCycle executes one orchestration cycle.
-func (o *Orchestrator) runCycle(ctx context.Context) error {
+// ErrCycleInProgress is returned by Step when a cycle is already running.
+var ErrCycleInProgress = errors.New("orchestration cycle already in progress")
+
+// CycleSummary describes what one orchestration cycle did.
+type CycleSummary struct {
+	Cycle         int            `json:"cycle"`
+	AgentsSpawned []SpawnedAgent `json:"agentsSpawned"`
+	// Status changes made while the cycle ran; agents it spawned keep running
+	// and may move tickets after it returns.
+	Transitions []CycleTransition `json:"transitions"`
+}
+
+// SpawnedAgent is an agent a cycle started for a ticket.
+type SpawnedAgent struct {
+	Agent    string `json:"agent"`
+	TicketID string `json:"ticketId"`
+}
+
+// CycleTransition is a ticket status change made during a cycle.
+type CycleTransition struct {
+	TicketID string        `json:"ticketId"`
+	From     kanban.Status `json:"from"`
+	To       kanban.Status `json:"to"`
+}
+
+// runCycle executes one orchestration cycle, waiting for any cycle in progress.
+func (o *Orchestrator) runCycle(ctx context.Context) (*CycleSummary, error) {
 	o.mu.Lock()
 	defer o.mu.Unlock()
+	return o.runCycleLocked(ctx)
+}
 
+// Step runs one orchestration cycle immediately, whether or not Run is active,
+// for stepping through behavior while debugging. It returns ErrCycleInProgress
+// rather than waiting if a cycle is already running. Without Run, the step
+// holds the board's orchestrator lock only while its cycle runs.
+func (o *Orchestrator) Step(ctx context.Context) (*CycleSummary, error) {
+	if !o.mu.TryLock() {
+		return nil, ErrCycleInProgress
+	}
+	defer o.mu.Unlock()
+	if !o.lockHeld {
+		if err := o.acquireLock(); err != nil {
+			return nil, err
+		}
+		defer o.releaseLock()
+	}
+	return o.runCycleLocked(ctx)
+}
+
+// runCycleLocked executes one orchestration cycle. The caller holds o.mu.
+func (o *Orchestrator) runCycleLocked(ctx context.Context) (*CycleSummary, error) {
 	o.metrics.CyclesRun++
+	summary := &CycleSummary{
+		Cycle:         o.metrics.CyclesRun,
+		AgentsSpawned: []SpawnedAgent{},
+		Transitions:   []CycleTransition{},
+	}
+	o.cycleSummary = summary
+	defer func() { o.cycleSummary = nil }()
 	o.logger.Debug("Running cycle", "cycle", o.metrics.CyclesRun)
 
 	// Reload state (in case of external changes)
 	if err := o.state.Load(); err != nil {
-		return fmt.Errorf("failed to reload state: %w", err)
+		return nil, fmt.Errorf("failed to reload state: %w", err)
 	}
 
 	// Cleanup stale running agents (those that have been running longer than timeout)
@@ -275,9 +638,14 @@ func (o *Orchestrator) runCycle(ctx context.Context) error {
 	// Check if iteration is complete
 	if o.state.IsIterationComplete() {
 		o.logger.Info("Iteration complete!")
-		return nil
+		o.recordIterationDigest()
+		return summary, nil
 	}
 
+	before := make(map[string]kanban.Status)
+	for _, t := range o.state.GetBoard().Tickets {
+		before[t.ID] = t.Status
+	}
 	// Get board stats
 	stats := o.state.GetStats()
 	o.logger.Info("Board status",
@@ -305,6 +673,10 @@ func (o *Orchestrator) runCycle(ctx context.Context) error {
 	o.processPRDCompleteStage(ctx)
 	// 4. Check if parent tickets should be marked complete
 	o.checkParentCompletion(ctx)
+	// 5. Release the next parallel group of sub-tickets once the previous one is done
+	if o.config.SequentialParallelGroups {
+		o.promoteParallelGroups(ctx)
+	}
 
 	// Process development pipeline
 	o.processDevStage(ctx)
@@ -312,25 +684,58 @@ func (o *Orchestrator) runCycle(ctx context.Context) error {
 	o.processUXStage(ctx)
 	o.processSecurityStage(ctx)
 	o.processPMReviewStage(ctx)
+	o.processPendingDoneStage(ctx)
 
 	// Handle completed tickets
 	if o.config.AutoMerge {
 		o.processCompletedTickets(ctx)
 	}
 
+	for _, t := range o.state.GetBoard().Tickets {
+		if from, ok := before[t.ID]; ok && from != t.Status {
+			summary.Transitions = append(summary.Transitions, CycleTransition{TicketID: t.ID, From: from, To: t.Status})
+		}
+	}
+	sort.Slice(summary.Transitions, func(i, j int) bool { return summary.Transitions[i].TicketID < summary.Transitions[j].TicketID })
+
 	// Save state
 	if err := o.state.Save(); err != nil {
 		o.logger.Error("Failed to save state", "error", err)
 	}
 
-	return nil
+	return summary, nil
+}
+
+// spawnBudget returns how many agents the current cycle may start during the
+// startup ramp-up, or 0 once the ramp-up is over (or disabled).
+func (o *Orchestrator) spawnBudget() int {
+	cycle := o.metrics.CyclesRun
+	if o.config.SpawnRampUpInitial <= 0 || cycle < 1 || cycle > o.config.SpawnRampUpCycles {
+		return 0
+	}
+	return o.config.SpawnRampUpInitial * cycle
+}
+
+// takeSpawnSlot claims one of the current cycle's agent starts for the cycle
+// summary, reporting false when the ramp-up budget is spent. Stages call it
+// with the cycle lock held.
+func (o *Orchestrator) takeSpawnSlot(agent, ticketID string) bool {
+	if o.cycleSummary == nil {
+		return true
+	}
+	if budget := o.spawnBudget(); budget > 0 && len(o.cycleSummary.AgentsSpawned) >= budget {
+		o.logger.Debug("Spawn ramp-up budget reached for this cycle", "cycle", o.metrics.CyclesRun, "budget", budget)
+		return false
+	}
+	o.cycleSummary.AgentsSpawned = append(o.cycleSummary.AgentsSpawned, SpawnedAgent{Agent: agent, TicketID: ticketID})
+	return true
 }
 
 // processApprovedToRefining moves newly approved tickets into requirements refinement.
 //
 //nolint:unused // Reserved for future refining workflow implementation.
 func (o *Orchestrator) processApprovedToRefining(ctx context.Context) {
-	approvedTickets := o.state.GetTicketsByStatus(kanban.StatusApproved)
+	approvedTickets := unpaused(o.state.GetTicketsByStatus(kanban.StatusApproved))
 	if len(approvedTickets) == 0 {
 		return
 	}
@@ -357,7 +762,7 @@ func (o *Orchestrator) processApprovedToRefining(ctx context.Context) {
 //
 //nolint:unused // Reserved for future refining workflow implementation.
 func (o *Orchestrator) processRefiningStage(ctx context.Context) {
-	refiningTickets := o.state.GetTicketsByStatus(kanban.StatusRefining)
+	refiningTickets := unpaused(o.state.GetTicketsByStatus(kanban.StatusRefining))
 	if len(refiningTickets) == 0 {
 		return
 	}
@@ -419,7 +824,7 @@ func (o *Orchestrator) processRefiningStage(ctx context.Context) {
 //
 //nolint:unused // Reserved for future expert consultation workflow.
 func (o *Orchestrator) processExpertConsultationStage(ctx context.Context) {
-	expertTickets := o.state.GetTicketsByStatus(kanban.StatusNeedsExpert)
+	expertTickets := unpaused(o.state.GetTicketsByStatus(kanban.StatusNeedsExpert))
 	if len(expertTickets) == 0 {
 		return
 	}
@@ -538,12 +943,6 @@ func (o *Orchestrator) processDevStage(ctx context.Context) {
 		return
 	}
 
-	// Check global worktree limit via background manager
-	if o.backgroundMgr != nil && !o.backgroundMgr.CanStartDevWork() {
-		o.logger.Debug("Global worktree limit reached, waiting for slot")
-		return
-	}
-
 	// Get ready tickets by domain
 	domains := []kanban.Domain{
 		kanban.DomainFrontend,
@@ -551,16 +950,32 @@ func (o *Orchestrator) processDevStage(ctx context.Context) {
 		kanban.DomainInfra,
 	}
 
+	// Tickets this pass moves into a WIP-limited column, before their agents
+	// have updated the board
+	entering := make(map[kanban.Status]int)
+
 	for _, domain := range domains {
 		// Check dev-specific parallel limit
 		if len(o.state.GetActiveDevRuns()) >= o.config.MaxParallelAgents {
 			break
 		}
 
+		// Check worktree limits (global and per-domain) via background manager
+		if o.backgroundMgr != nil && !o.backgroundMgr.CanStartDevWork(domain) {
+			o.logger.Debug("Worktree limit reached, waiting for slot", "domain", domain)
+			continue
+		}
+
 		ticket, ok := o.state.GetNextTicketForDomain(domain)
 		if !ok {
 			continue
 		}
+		if o.heldByDevWIP(ticket, entering) {
+			break
+		}
+		if !o.takeSpawnSlot(string(devAgentType(ticket, domain)), ticket.ID) {
+			return
+		}
 
 		// Start dev agent for this ticket
 		o.wg.Add(1)
@@ -572,7 +987,7 @@ func (o *Orchestrator) processDevStage(ctx context.Context) {
 
 	// Also process tickets without a domain (e.g., from Notion without domain set)
 	// Default these to backend agent
-	readyTickets := o.state.GetTicketsByStatus(kanban.StatusReady)
+	readyTickets := unpaused(o.state.GetTicketsByStatus(kanban.StatusReady))
 	for _, ticket := range readyTickets {
 		if len(o.state.GetActiveDevRuns()) >= o.config.MaxParallelAgents {
 			break
@@ -599,6 +1014,16 @@ func (o *Orchestrator) processDevStage(ctx context.Context) {
 
 		// Default to backend for unspecified domains
 		defaultDomain := kanban.DomainBackend
+		if o.backgroundMgr != nil && !o.backgroundMgr.CanStartDevWork(defaultDomain) {
+			o.logger.Debug("Worktree limit reached, waiting for slot", "domain", defaultDomain)
+			break
+		}
+		if o.heldByDevWIP(&ticket, entering) {
+			break
+		}
+		if !o.takeSpawnSlot(string(devAgentType(&ticket, defaultDomain)), ticket.ID) {
+			break
+		}
 		o.wg.Add(1)
 		go func(t kanban.Ticket, d kanban.Domain) {
 			defer o.wg.Done()
@@ -638,47 +1063,59 @@ func (o *Orchestrator) checkDependenciesMet(ticket *kanban.Ticket) bool {
 	return true
 }
 
-// hasFileConflict checks if a ticket's files overlap with any in-progress ticket.
+// hasFileConflict checks if a ticket's file patterns overlap with any in-progress ticket's.
 func (o *Orchestrator) hasFileConflict(ticket *kanban.Ticket) bool {
 	if len(ticket.Files) == 0 {
 		return false
 	}
 
-	inProgress := o.state.GetTicketsByStatus(kanban.StatusInDev)
-	fileSet := make(map[string]bool)
-	for _, f := range ticket.Files {
-		fileSet[f] = true
-	}
-
-	for _, other := range inProgress {
-		for _, f := range other.Files {
-			if fileSet[f] {
-				return true
-			}
+	for _, other := range o.state.GetTicketsByStatus(kanban.StatusInDev) {
+		if kanban.FilesOverlap(ticket.Files, other.Files) {
+			return true
 		}
 	}
 	return false
 }
 
+// devAgentType returns the dev agent a ticket was assigned to, or the domain's
+// dev agent when it has none. Assignments let work be moved between dev agents
+// without changing the ticket's domain.
+func devAgentType(ticket *kanban.Ticket, domain kanban.Domain) agents.AgentType {
+	switch assigned := agents.AgentType(ticket.AssignedAgent); assigned {
+	case agents.AgentTypeDevFrontend, agents.AgentTypeDevBackend, agents.AgentTypeDevInfra:
+		return assigned
+	}
+	return agents.GetAgentTypeForDomain(domain)
+}
+
 // runDevAgent runs a development agent for a ticket.
 func (o *Orchestrator) runDevAgent(ctx context.Context, ticket *kanban.Ticket, domain kanban.Domain) {
-	agentType := agents.GetAgentTypeForDomain(domain)
+	agentType := devAgentType(ticket, domain)
 	o.logger.Info("Starting dev agent",
 		"ticket", ticket.ID,
 		"domain", domain,
 		"agent", agentType)
 
-	// Create worktree
-	branchName := git.GenerateBranchName(
-		o.state.GetConfig().BranchPrefix,
-		ticket.ID,
-		ticket.Title,
-	)
-
-	worktreePath, err := o.worktree.CreateWorktree(ticket.ID, branchName)
-	if err != nil {
-		o.logger.Error("Failed to create worktree", "ticket", ticket.ID, "error", err)
-		return
+	// Resume in the ticket's existing worktree if an earlier run left work there,
+	// otherwise create one
+	var branchName, worktreePath string
+	resumeContext := o.devResumeContext(ticket)
+	if resumeContext != "" {
+		branchName, worktreePath = ticket.Worktree.Branch, ticket.Worktree.Path
+		o.logger.Info("Resuming dev work in existing worktree", "ticket", ticket.ID, "worktree", worktreePath)
+	} else {
+		branchName = git.GenerateBranchName(
+			o.state.GetConfig().BranchPrefix,
+			ticket.ID,
+			ticket.Title,
+		)
+
+		var err error
+		worktreePath, err = o.worktree.CreateWorktree(ticket.ID, branchName)
+		if err != nil {
+			o.logger.Error("Failed to create worktree", "ticket", ticket.ID, "error", err)
+			return
+		}
 	}
 
 	// Register worktree with global pool via background manager
@@ -688,6 +1125,8 @@ func (o *Orchestrator) runDevAgent(ctx context.Context, ticket *kanban.Ticket, d
 		}
 	}
 
+	o.selectPipeline(ticket)
+
 	// Update ticket state and activity
 	activityDescription := getActivityDescription(agentType)
 	_ = o.state.UpdateTicketStatus(ticket.ID, kanban.StatusInDev, string(agentType), "Starting development")
@@ -714,32 +1153,52 @@ func (o *Orchestrator) runDevAgent(ctx context.Context, ticket *kanban.Ticket, d
 	// Spawn agent
 	var agentOutput string
 	if !o.config.DryRun {
-		result, err := o.spawner.SpawnAgent(ctx, agentType, agents.PromptData{
-			Ticket:       ticket,
-			WorktreePath: worktreePath,
-			Domain:       string(domain),
-			BoardStats:   o.state.GetStats(),
-			Iteration:    o.state.GetIteration(),
+		result, err := o.spawnWithRetry(ctx, agentType, agents.PromptData{
+			RunID:         runID,
+			Ticket:        ticket,
+			WorktreePath:  worktreePath,
+			Domain:        string(domain),
+			BoardStats:    o.state.GetStats(),
+			Iteration:     o.state.GetIteration(),
+			ResumeContext: resumeContext,
+			CodeContext:   o.devCodeContext(ctx, ticket, domain),
 		}, worktreePath)
 
 		o.metrics.AgentsSpawned++
 
+		if errors.Is(err, context.Canceled) {
+			o.logger.Info("Dev agent cancelled", "ticket", ticket.ID)
+			o.completeRun(runID, "cancelled", "Run cancelled", result)
+			return
+		}
 		if err != nil || !result.Success {
 			o.logger.Error("Dev agent failed",
 				"ticket", ticket.ID,
 				"error", err,
 				"output", result.Error)
 			o.metrics.AgentsFailed++
-			o.state.CompleteRun(runID, "failed", result.Error)
+			o.completeRun(runID, "failed", result.Error, result)
+			if !o.abandonIfFailing(ticket.ID) {
+				o.routeFailure(ticket.ID, agentType, err, result)
+			}
 
 			return
 		}
 
 		o.metrics.AgentsSucceeded++
-		o.state.CompleteRun(runID, "success", result.Output)
+		o.completeRun(runID, "success", result.Output, result)
 		agentOutput = result.Output
 	}
 
+	// Bring the branch up to date so QA tests it against current main
+	if o.state.GetConfig().RebaseBeforeQA && !o.rebaseBeforeQA(ticket.ID, branchName) {
+		return
+	}
+
+	if !o.config.DryRun && !o.runTestCommand(ctx, ticket.ID, domain, worktreePath) {
+		return
+	}
+
 	// Clear activity and transition to QA
 	_ = o.state.ClearActivity(ticket.ID)
 	_ = o.state.AddSignoff(ticket.ID, "dev", string(agentType))
@@ -747,21 +1206,223 @@ func (o *Orchestrator) runDevAgent(ctx context.Context, ticket *kanban.Ticket, d
 	// Create sign-off report with dev findings
 	o.createSignoffReport(ticket.ID, agentType, agentOutput)
 
-	_ = o.state.UpdateTicketStatus(ticket.ID, kanban.StatusInQA, string(agentType), "Development complete, ready for QA")
+	nextStatus := o.nextReviewStage(ticket, kanban.StatusInQA)
+	note := withSkipNote("Development complete, ready for QA", ticket, kanban.StatusInQA, nextStatus)
+	_ = o.state.UpdateTicketStatus(ticket.ID, nextStatus, string(agentType), note)
 	_ = o.state.Save()
 
 	o.logger.Info("Dev agent completed", "ticket", ticket.ID)
 }
 
+// devCodeContext returns indexed code relevant to the ticket for its dev
+// prompt, or "" when code context is disabled or retrieval fails.
+func (o *Orchestrator) devCodeContext(ctx context.Context, ticket *kanban.Ticket, domain kanban.Domain) string {
+	if o.codeRetriever == nil || o.config.CodeContextSnippets <= 0 {
+		return ""
+	}
+	codeContext, err := o.codeRetriever.RetrieveCodeContext(ctx, ticket, string(domain), o.config.CodeContextSnippets)
+	if err != nil {
+		o.logger.Warn("Failed to retrieve code context", "ticket", ticket.ID, "error", err)
+		return ""
+	}
+	return codeContext
+}
+
+// maxResumeOutput caps how much of the previous run's output is carried into a resumed prompt.
+const maxResumeOutput = 2000
+
+// devResumeContext describes the partial progress an earlier dev run left in the
+// ticket's worktree: commits on its branch, uncommitted changes, and the tail of
+// the last finished run's output. It returns "" when there is no existing
+// worktree or it holds no work, in which case dev starts clean.
+func (o *Orchestrator) devResumeContext(ticket *kanban.Ticket) string {
+	wt := ticket.Worktree
+	if wt == nil || !wt.Active || wt.Path == "" || wt.Branch == "" {
+		return ""
+	}
+	if _, err := os.Stat(wt.Path); err != nil {
+		return ""
+	}
+
+	dirty, err := o.worktree.HasUncommittedChanges(wt.Path)
+	if err != nil {
+		o.logger.Warn("Failed to check worktree for changes", "ticket", ticket.ID, "error", err)
+	}
+	commits, err := o.worktree.Commits(wt.Branch)
+	if err != nil {
+		o.logger.Warn("Failed to list branch commits", "ticket", ticket.ID, "error", err)
+	}
+	if !dirty && len(commits) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	if len(commits) > 0 {
+		fmt.Fprintf(&b, "Commits already on branch %s (newest first):\n", wt.Branch)
+		for _, c := range commits {
+			subject, _, _ := strings.Cut(c.Message, "\n")
+			fmt.Fprintf(&b, "- %s\n", subject)
+		}
+	}
+	if dirty {
+		b.WriteString("The worktree also has uncommitted changes; check `git status` and `git diff`.\n")
+	}
+
+	if store, ok := o.state.(ticketRunStore); ok {
+		runs, err := store.GetRunsByTicket(ticket.ID)
+		if err != nil {
+			o.logger.Warn("Failed to load previous runs", "ticket", ticket.ID, "error", err)
+		}
+		for i := len(runs) - 1; i >= 0; i-- {
+			run := runs[i]
+			if !strings.HasPrefix(run.Agent, "dev") || run.Status == "running" || run.Output == "" {
+				continue
+			}
+			output := run.Output
+			if len(output) > maxResumeOutput {
+				output = "..." + output[len(output)-maxResumeOutput:]
+			}
+			fmt.Fprintf(&b, "\nThe previous run (%s) ended with:\n\n%s\n", run.Status, output)
+			break
+		}
+	}
+
+	return b.String()
+}
+
+// rebaseBeforeQA rebases a finished dev branch onto main. It returns false if the
+// rebase conflicted and the ticket was blocked; other failures are logged and the
+// ticket proceeds to QA on its existing base.
+func (o *Orchestrator) rebaseBeforeQA(ticketID, branch string) bool {
+	err := o.worktree.RebaseOntoMain(branch)
+	switch {
+	case err == nil:
+		o.logger.Info("Rebased branch onto main before QA", "ticket", ticketID, "branch", branch)
+		return true
+	case errors.Is(err, git.ErrRebaseConflict):
+		o.logger.Warn("Rebase before QA conflicted, blocking ticket", "ticket", ticketID, "branch", branch)
+		_ = o.state.ClearActivity(ticketID)
+		_ = o.state.UpdateTicketStatus(ticketID, kanban.StatusBlocked, "system",
+			fmt.Sprintf("Merge conflict rebasing %s onto main; resolve the conflicts on the branch", branch))
+		_ = o.state.Save()
+		return false
+	default:
+		o.logger.Warn("Failed to rebase before QA", "ticket", ticketID, "branch", branch, "error", err)
+		return true
+	}
+}
+
+// maxTestOutput caps how much of a test command's output is kept on the ticket.
+const maxTestOutput = 4000
+
+var (
+	goTestPassPattern    = regexp.MustCompile(`(?m)^\s*--- PASS:`)
+	goTestFailPattern    = regexp.MustCompile(`(?m)^\s*--- FAIL:`)
+	passedSummaryPattern = regexp.MustCompile(`(\d+) passed`)
+	failedSummaryPattern = regexp.MustCompile(`(\d+) failed`)
+)
+
+// runTestCommand runs the domain's configured test command in the ticket's
+// worktree and records the result on the ticket. It returns false if the
+// command failed and the ticket was blocked; domains without a command pass.
+func (o *Orchestrator) runTestCommand(ctx context.Context, ticketID string, domain kanban.Domain, worktreePath string) bool {
+	command := o.config.TestCommands[string(domain)]
+	if command == "" {
+		return true
+	}
+
+	if o.config.AgentTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, o.config.AgentTimeout)
+		defer cancel()
+	}
+
+	o.logger.Info("Running test command", "ticket", ticketID, "command", command)
+	started := time.Now()
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Dir = worktreePath
+	output, err := cmd.CombinedOutput()
+
+	result := &kanban.TestRunResult{
+		Command:  command,
+		Duration: time.Since(started),
+		RanAt:    started,
+	}
+	var exitErr *exec.ExitError
+	switch {
+	case errors.As(err, &exitErr):
+		result.ExitCode = exitErr.ExitCode()
+	case err != nil:
+		result.ExitCode = -1
+		output = append(output, []byte("\n"+err.Error())...)
+	}
+	result.Framework, result.Passed, result.Failed = parseTestOutput(string(output))
+	result.Output = string(output)
+	if len(result.Output) > maxTestOutput {
+		result.Output = "..." + result.Output[len(result.Output)-maxTestOutput:]
+	}
+
+	if err := o.state.SetTestRun(ticketID, result); err != nil {
+		o.logger.Warn("Failed to record test run", "ticket", ticketID, "error", err)
+	}
+	if err == nil {
+		o.logger.Info("Test command passed", "ticket", ticketID, "passed", result.Passed)
+		return true
+	}
+
+	o.logger.Warn("Test command failed, blocking ticket", "ticket", ticketID, "exitCode", result.ExitCode, "failed", result.Failed)
+	_ = o.state.ClearActivity(ticketID)
+	_ = o.state.UpdateTicketStatus(ticketID, kanban.StatusBlocked, "system",
+		fmt.Sprintf("Tests failed before QA (%s exited %d, %d failed)", command, result.ExitCode, result.Failed))
+	_ = o.state.Save()
+	return false
+}
+
+// parseTestOutput reads pass and fail counts from test output: go test -v
+// result lines, or a "N passed, M failed" summary as pytest and jest print.
+func parseTestOutput(output string) (framework string, passed, failed int) {
+	passed = len(goTestPassPattern.FindAllString(output, -1))
+	failed = len(goTestFailPattern.FindAllString(output, -1))
+	if passed > 0 || failed > 0 {
+		return "go", passed, failed
+	}
+	if m := passedSummaryPattern.FindAllStringSubmatch(output, -1); m != nil {
+		fmt.Sscanf(m[len(m)-1][1], "%d", &passed)
+	}
+	if m := failedSummaryPattern.FindAllStringSubmatch(output, -1); m != nil {
+		fmt.Sscanf(m[len(m)-1][1], "%d", &failed)
+	}
+	return "", passed, failed
+}
+
+// unpaused drops paused tickets, which every stage leaves alone until resumed.
+func unpaused(tickets []kanban.Ticket) []kanban.Ticket {
+	var kept []kanban.Ticket
+	for _, t := range tickets {
+		if !t.Paused {
+			kept = append(kept, t)
+		}
+	}
+	return kept
+}
+
 // processQAStage handles tickets in QA.
 func (o *Orchestrator) processQAStage(ctx context.Context) {
-	tickets := o.state.GetTicketsByStatus(kanban.StatusInQA)
+	tickets := unpaused(o.state.GetTicketsByStatus(kanban.StatusInQA))
+	entering := make(map[kanban.Status]int)
 
 	for _, ticket := range tickets {
 		// Check if QA agent is already running for this ticket
 		if o.state.IsAgentRunning(ticket.ID, string(agents.AgentTypeQA)) {
 			continue
 		}
+		// Wait while the stage it would pass to is full
+		if o.heldByWIP(&ticket, o.nextReviewStage(&ticket, kanban.StatusInUX), entering) {
+			continue
+		}
+		if !o.takeSpawnSlot(string(agents.AgentTypeQA), ticket.ID) {
+			return
+		}
 
 		o.wg.Add(1)
 		go func(t kanban.Ticket) {
@@ -773,13 +1434,21 @@ func (o *Orchestrator) processQAStage(ctx context.Context) {
 
 // processUXStage handles tickets in UX review.
 func (o *Orchestrator) processUXStage(ctx context.Context) {
-	tickets := o.state.GetTicketsByStatus(kanban.StatusInUX)
+	tickets := unpaused(o.state.GetTicketsByStatus(kanban.StatusInUX))
+	entering := make(map[kanban.Status]int)
 
 	for _, ticket := range tickets {
 		// Check if UX agent is already running for this ticket
 		if o.state.IsAgentRunning(ticket.ID, string(agents.AgentTypeUX)) {
 			continue
 		}
+		// Wait while the stage it would pass to is full
+		if o.heldByWIP(&ticket, o.nextReviewStage(&ticket, kanban.StatusInSec), entering) {
+			continue
+		}
+		if !o.takeSpawnSlot(string(agents.AgentTypeUX), ticket.ID) {
+			return
+		}
 
 		o.wg.Add(1)
 		go func(t kanban.Ticket) {
@@ -791,13 +1460,21 @@ func (o *Orchestrator) processUXStage(ctx context.Context) {
 
 // processSecurityStage handles tickets in security review.
 func (o *Orchestrator) processSecurityStage(ctx context.Context) {
-	tickets := o.state.GetTicketsByStatus(kanban.StatusInSec)
+	tickets := unpaused(o.state.GetTicketsByStatus(kanban.StatusInSec))
+	entering := make(map[kanban.Status]int)
 
 	for _, ticket := range tickets {
 		// Check if Security agent is already running for this ticket
 		if o.state.IsAgentRunning(ticket.ID, string(agents.AgentTypeSecurity)) {
 			continue
 		}
+		// Wait while the stage it would pass to is full
+		if o.heldByWIP(&ticket, o.nextReviewStage(&ticket, kanban.StatusPMReview), entering) {
+			continue
+		}
+		if !o.takeSpawnSlot(string(agents.AgentTypeSecurity), ticket.ID) {
+			return
+		}
 
 		o.wg.Add(1)
 		go func(t kanban.Ticket) {
@@ -808,19 +1485,24 @@ func (o *Orchestrator) processSecurityStage(ctx context.Context) {
 }
 
 // processPMReviewStage handles tickets awaiting PM review.
+// With RequireMergeApproval, signed-off tickets wait for a human before reaching DONE.
 func (o *Orchestrator) processPMReviewStage(ctx context.Context) {
-	tickets := o.state.GetTicketsByStatus(kanban.StatusPMReview)
+	tickets := unpaused(o.state.GetTicketsByStatus(kanban.StatusPMReview))
+	nextStatus := o.signedOffStatus()
 
 	for _, ticket := range tickets {
 		// Check if PM agent is already running for this ticket
 		if o.state.IsAgentRunning(ticket.ID, string(agents.AgentTypePM)) {
 			continue
 		}
+		if !o.takeSpawnSlot(string(agents.AgentTypePM), ticket.ID) {
+			return
+		}
 
 		o.wg.Add(1)
 		go func(t kanban.Ticket) {
 			defer o.wg.Done()
-			o.runReviewAgent(ctx, &t, agents.AgentTypePM, kanban.StatusDone, "pm")
+			o.runReviewAgent(ctx, &t, agents.AgentTypePM, nextStatus, "pm")
 		}(ticket)
 	}
 }
@@ -868,32 +1550,47 @@ func (o *Orchestrator) runReviewAgent(ctx context.Context, ticket *kanban.Ticket
 
 	var agentOutput string
 	if !o.config.DryRun {
-		result, err := o.spawner.SpawnAgent(ctx, agentType, agents.PromptData{
+		result, err := o.spawnWithRetry(ctx, agentType, agents.PromptData{
+			RunID:        runID,
 			Ticket:       ticket,
 			WorktreePath: worktreePath,
 			BoardStats:   o.state.GetStats(),
 			Iteration:    o.state.GetIteration(),
+			BugsToVerify: fixedBugsFoundBy(ticket, signoffStage),
 		}, worktreePath)
 
 		o.metrics.AgentsSpawned++
 
+		if errors.Is(err, context.Canceled) {
+			o.logger.Info("Review agent cancelled", "ticket", ticket.ID, "agent", agentType)
+			o.completeRun(runID, "cancelled", "Run cancelled", result)
+			return
+		}
 		if err != nil || !result.Success {
 			o.logger.Error("Review agent failed",
 				"ticket", ticket.ID,
 				"agent", agentType,
 				"error", err)
 			o.metrics.AgentsFailed++
-			o.state.CompleteRun(runID, "failed", result.Error)
-
-			// Check if bugs were found
-			if len(ticket.Bugs) > 0 {
-				_ = o.state.UpdateTicketStatus(ticket.ID, kanban.StatusBlocked, string(agentType), "Bugs found during review")
+			o.completeRun(runID, "failed", result.Error, result)
+			if err == nil {
+				o.fileBugfixTickets(ticket, agentType, result.Output)
+			}
+			if err == nil && o.escalateUnverifiable(ticket.ID, agentType, result.Output) {
+				return
 			}
+			if o.abandonIfFailing(ticket.ID) {
+				return
+			}
+			o.routeFailure(ticket.ID, agentType, err, result)
 			return
 		}
 
 		o.metrics.AgentsSucceeded++
-		o.state.CompleteRun(runID, "success", result.Output)
+		o.completeRun(runID, "success", result.Output, result)
+		if o.escalateUnverifiable(ticket.ID, agentType, result.Output) {
+			return
+		}
 		agentOutput = result.Output
 	} else {
 		o.state.CompleteRun(runID, "skipped", "Dry run mode")
@@ -906,9 +1603,13 @@ func (o *Orchestrator) runReviewAgent(ctx context.Context, ticket *kanban.Ticket
 	// Create sign-off report with review findings
 	if agentOutput != "" {
 		o.createSignoffReport(ticket.ID, agentType, agentOutput)
+		o.fileBugfixTickets(ticket, agentType, agentOutput)
 	}
 
-	_ = o.state.UpdateTicketStatus(ticket.ID, nextStatus, string(agentType), fmt.Sprintf("%s review complete", agentType))
+	stage := nextStatus
+	nextStatus = o.nextReviewStage(ticket, stage)
+	note := withSkipNote(fmt.Sprintf("%s review complete", agentType), ticket, stage, nextStatus)
+	_ = o.state.UpdateTicketStatus(ticket.ID, nextStatus, string(agentType), note)
 	_ = o.state.Save()
 
 	if nextStatus == kanban.StatusDone {
@@ -918,6 +1619,296 @@ func (o *Orchestrator) runReviewAgent(ctx context.Context, ticket *kanban.Ticket
 	o.logger.Info("Review agent completed", "ticket", ticket.ID, "agent", agentType)
 }
 
+// nextReviewStage returns the review stage a ticket moves to when status is
+// next, passing over stages the ticket skips. A ticket skipping every remaining
+// stage goes straight to where PM review would have sent it.
+func (o *Orchestrator) nextReviewStage(ticket *kanban.Ticket, status kanban.Status) kanban.Status {
+	skip := ticket.EffectiveSkipStages(o.state.GetConfig().SkipStages)
+	return kanban.NextReviewStage(status, skip, o.signedOffStatus())
+}
+
+// heldByWIP reports whether work that would move ticket into status must wait
+// because the column is at its WIP limit, counting the tickets this pass has
+// already sent there. A held ticket's activity says why; otherwise the ticket
+// is counted as entering the column.
+func (o *Orchestrator) heldByWIP(ticket *kanban.Ticket, status kanban.Status, entering map[kanban.Status]int) bool {
+	config := o.state.GetConfig()
+	count := len(o.state.GetTicketsByStatus(status)) + entering[status]
+	if !config.WIPLimitReached(status, count) {
+		entering[status]++
+		return false
+	}
+
+	reason := fmt.Sprintf("Waiting for room in %s (WIP limit %d)", getStatusName(status), config.WIPLimits[status])
+	if ticket.CurrentActivity != reason {
+		o.logger.Info("Ticket held by WIP limit", "ticket", ticket.ID, "column", status, "limit", config.WIPLimits[status])
+		_ = o.state.UpdateActivity(ticket.ID, reason, ticket.Assignee)
+		_ = o.state.Save()
+	}
+	return true
+}
+
+// heldByDevWIP reports whether a ticket must wait to start dev because IN_DEV,
+// or the review stage dev hands it to, is at its WIP limit.
+func (o *Orchestrator) heldByDevWIP(ticket *kanban.Ticket, entering map[kanban.Status]int) bool {
+	return o.heldByWIP(ticket, kanban.StatusInDev, entering) ||
+		o.heldByWIP(ticket, o.nextReviewStage(ticket, kanban.StatusInQA), entering)
+}
+
+// withSkipNote appends the review stages a ticket passed over on its way from
+// status to next to a history note, e.g. "qa review complete; UX skipped
+// (ticket pipeline)", saying whether the ticket's own stages or the board
+// default skipped them.
+func withSkipNote(note string, ticket *kanban.Ticket, status, next kanban.Status) string {
+	skipped := kanban.SkippedReviewStages(status, next)
+	if len(skipped) == 0 {
+		return note
+	}
+
+	names := make([]string, len(skipped))
+	for i, stage := range skipped {
+		names[i] = reviewStageNames[stage]
+	}
+	source := "board default"
+	if ticket.SkipStages != nil {
+		source = "ticket pipeline"
+	}
+	return fmt.Sprintf("%s; %s skipped (%s)", note, strings.Join(names, ", "), source)
+}
+
+// reviewStageNames are the short names of the review stages used in history notes.
+var reviewStageNames = map[kanban.Status]string{
+	kanban.StatusInQA:     "QA",
+	kanban.StatusInUX:     "UX",
+	kanban.StatusInSec:    "Security",
+	kanban.StatusPMReview: "PM review",
+}
+
+// signedOffStatus returns where a ticket goes once it passes PM review.
+func (o *Orchestrator) signedOffStatus() kanban.Status {
+	return SignedOffStatus(o.config)
+}
+
+// SignedOffStatus returns where config sends a ticket once it passes PM
+// review: merge approval when required, otherwise the soak period when one is
+// set, else DONE.
+func SignedOffStatus(config Config) kanban.Status {
+	switch {
+	case config.RequireMergeApproval:
+		return kanban.StatusAwaitingMergeApproval
+	case config.DoneSoakPeriod > 0:
+		return kanban.StatusPendingDone
+	}
+	return kanban.StatusDone
+}
+
+// processPendingDoneStage completes tickets that have soaked in PENDING_DONE
+// for DoneSoakPeriod. A ticket that picked up an unfixed critical or high bug
+// while soaking is blocked instead, so it goes back through dev.
+func (o *Orchestrator) processPendingDoneStage(ctx context.Context) {
+	for _, pending := range unpaused(o.state.GetTicketsByStatus(kanban.StatusPendingDone)) {
+		ticket, ok := o.state.GetTicket(pending.ID)
+		if !ok {
+			continue
+		}
+		since := soakStart(ticket)
+		if time.Since(since) < o.config.DoneSoakPeriod {
+			continue
+		}
+
+		if bug := blockingBugSince(ticket, since); bug != nil {
+			o.logger.Warn("Late finding during soak, blocking ticket", "ticket", ticket.ID, "bug", bug.Title)
+			_ = o.state.UpdateTicketStatus(ticket.ID, kanban.StatusBlocked, "system",
+				fmt.Sprintf("%s bug reported during soak: %s", bug.Severity, bug.Title))
+			continue
+		}
+
+		_ = o.state.UpdateTicketStatus(ticket.ID, kanban.StatusDone, "system", "Soak period passed with no new findings")
+		o.metrics.TicketsCompleted++
+	}
+	_ = o.state.Save()
+}
+
+// soakStart returns when a ticket last entered PENDING_DONE.
+func soakStart(ticket *kanban.Ticket) time.Time {
+	for i := len(ticket.History) - 1; i >= 0; i-- {
+		if ticket.History[i].Status == kanban.StatusPendingDone {
+			return ticket.History[i].At
+		}
+	}
+	return ticket.UpdatedAt
+}
+
+// blockingBugSince returns an unfixed critical or high bug found at or after since.
+func blockingBugSince(ticket *kanban.Ticket, since time.Time) *kanban.Bug {
+	for i := range ticket.Bugs {
+		bug := &ticket.Bugs[i]
+		if bug.Fixed || bug.FoundAt.Before(since) {
+			continue
+		}
+		if bug.Severity == "critical" || bug.Severity == "high" {
+			return bug
+		}
+	}
+	return nil
+}
+
+// selectPipeline fixes the review stages of a ticket entering development from
+// its type's pipeline, so later config changes don't reroute it mid-flight.
+// Tickets with their own skip stages, or whose type has no pipeline, keep them.
+func (o *Orchestrator) selectPipeline(ticket *kanban.Ticket) {
+	if ticket.SkipStages != nil {
+		return
+	}
+	stages, ok := o.state.GetConfig().Pipelines[ticket.Type]
+	if !ok {
+		return
+	}
+
+	ticket.SkipStages = kanban.PipelineSkipStages(stages)
+	if err := o.state.UpdateTicket(ticket); err != nil {
+		o.logger.Warn("Failed to save ticket pipeline", "ticket", ticket.ID, "error", err)
+		return
+	}
+	o.logger.Info("Selected ticket pipeline", "ticket", ticket.ID, "type", ticket.Type, "stages", stages)
+}
+
+// routeFailure moves a ticket whose agent run failed to the status FailureRouting
+// gives for the agent and failure category, or leaves it in place when there
+// is no route.
+func (o *Orchestrator) routeFailure(ticketID string, agentType agents.AgentType, err error, result *agents.AgentResult) {
+	category := agents.ClassifyFailure(err, result)
+	status, ok := o.failureRoute(agentType, category)
+	if !ok {
+		// Without a route a review that recorded bugs blocks the ticket
+		if ticket, found := o.state.GetTicket(ticketID); found && len(ticket.Bugs) > 0 && !strings.HasPrefix(string(agentType), "dev-") {
+			_ = o.state.UpdateTicketStatus(ticketID, kanban.StatusBlocked, string(agentType), "Bugs found during review")
+		}
+		return
+	}
+	if status == "" {
+		return
+	}
+
+	note := fmt.Sprintf("%s run failed (%s)", agentType, category)
+	switch {
+	case err != nil:
+		note += ": " + err.Error()
+	case result != nil && result.Error != "":
+		note += ": " + result.Error
+	}
+
+	o.logger.Info("Routing ticket after agent failure", "ticket", ticketID, "agent", agentType, "category", category, "status", status)
+	_ = o.state.ClearActivity(ticketID)
+	_ = o.state.UpdateTicketStatus(ticketID, status, string(agentType), note)
+	_ = o.state.Save()
+}
+
+// failureRoute looks up where a failed run sends its ticket, from the most
+// specific FailureRouting key to the least: "<agent>:<category>", the category
+// alone, then the agent ("dev" standing in for every dev agent at each step).
+func (o *Orchestrator) failureRoute(agentType agents.AgentType, category agents.FailureCategory) (kanban.Status, bool) {
+	agentKeys := []string{string(agentType)}
+	if strings.HasPrefix(string(agentType), "dev-") {
+		agentKeys = append(agentKeys, "dev")
+	}
+
+	var keys []string
+	for _, agent := range agentKeys {
+		keys = append(keys, agent+":"+string(category))
+	}
+	keys = append(keys, string(category))
+	keys = append(keys, agentKeys...)
+
+	for _, key := range keys {
+		if status, ok := o.config.FailureRouting[key]; ok {
+			return status, true
+		}
+	}
+	return "", false
+}
+
+// escalateUnverifiable sends a ticket to UnverifiableCriteriaStatus when the
+// reviewer's report says its acceptance criteria can't be verified as written,
+// flagging the specific criteria, so it doesn't bounce between dev and review.
+// Returns true if the ticket was escalated.
+func (o *Orchestrator) escalateUnverifiable(ticketID string, agentType agents.AgentType, output string) bool {
+	report := parseSignoffReport(output)
+	if report == nil || !report.NeedsClarification() {
+		return false
+	}
+
+	status := o.config.UnverifiableCriteriaStatus
+	if status == "" {
+		status = kanban.StatusAwaitingUser
+	}
+
+	o.logger.Warn("Acceptance criteria unverifiable, escalating for clarification",
+		"ticket", ticketID,
+		"agent", agentType,
+		"criteria", len(report.UnverifiableCriteria))
+	o.createSignoffReport(ticketID, agentType, output)
+	_ = o.state.ClearActivity(ticketID)
+	_ = o.state.UpdateTicketStatus(ticketID, status, string(agentType),
+		"Acceptance criteria can't be verified as written, please clarify: "+strings.Join(report.UnverifiableCriteria, "; "))
+	_ = o.state.Save()
+	return true
+}
+
+// ticketRunStore is implemented by stores that keep every run for a ticket.
+type ticketRunStore interface {
+	GetRunsByTicket(ticketID string) ([]kanban.AgentRun, error)
+}
+
+// abandonIfFailing moves a ticket to ABANDONED once its failed agent runs exceed
+// MaxTicketFailures, so it is no longer scheduled. Returns true if abandoned.
+func (o *Orchestrator) abandonIfFailing(ticketID string) bool {
+	if o.config.MaxTicketFailures <= 0 {
+		return false
+	}
+	store, ok := o.state.(ticketRunStore)
+	if !ok {
+		return false
+	}
+
+	runs, err := store.GetRunsByTicket(ticketID)
+	if err != nil {
+		o.logger.Warn("Failed to count failed runs", "ticket", ticketID, "error", err)
+		return false
+	}
+	failed := 0
+	for _, run := range runs {
+		if run.Status == "failed" {
+			failed++
+		}
+	}
+	if failed <= o.config.MaxTicketFailures {
+		return false
+	}
+
+	o.logger.Warn("Abandoning ticket after repeated agent failures",
+		"ticket", ticketID,
+		"failedRuns", failed,
+		"limit", o.config.MaxTicketFailures)
+	_ = o.state.ClearActivity(ticketID)
+	_ = o.state.UpdateTicketStatus(ticketID, kanban.StatusAbandoned, "system",
+		fmt.Sprintf("Abandoned after %d failed agent runs; needs human intervention", failed))
+	_ = o.state.Save()
+	return true
+}
+
+// fixedBugsFoundBy returns the bugs a reviewer found earlier that are now marked fixed,
+// so a returning ticket's re-review can verify those fixes specifically.
+func fixedBugsFoundBy(ticket *kanban.Ticket, reviewer string) []kanban.Bug {
+	var bugs []kanban.Bug
+	for _, bug := range ticket.Bugs {
+		if bug.Fixed && bug.FoundBy == reviewer {
+			bugs = append(bugs, bug)
+		}
+	}
+	return bugs
+}
+
 // getReviewTypeName returns a human-readable name for the review type.
 func getReviewTypeName(agentType agents.AgentType) string {
 	switch agentType {
@@ -953,14 +1944,14 @@ func getReviewPassedReason(agentType agents.AgentType) string {
 }
 
 // getStatusName returns a human-readable name for a status.
-//
-//nolint:unused // Reserved for status display helpers.
 func getStatusName(status kanban.Status) string {
 	switch status {
 	case kanban.StatusBacklog:
 		return "Backlog"
 	case kanban.StatusApproved:
 		return "Approved"
+	case kanban.StatusQueued:
+		return "Queued"
 	case kanban.StatusReady:
 		return "Ready"
 	case kanban.StatusInDev:
@@ -973,10 +1964,16 @@ func getStatusName(status kanban.Status) string {
 		return "In Security Review"
 	case kanban.StatusPMReview:
 		return "PM Review"
+	case kanban.StatusAwaitingMergeApproval:
+		return "Awaiting Merge Approval"
+	case kanban.StatusPendingDone:
+		return "Pending Done"
 	case kanban.StatusDone:
 		return "Done"
 	case kanban.StatusBlocked:
 		return "Blocked"
+	case kanban.StatusAbandoned:
+		return "Abandoned"
 	default:
 		return string(status)
 	}
@@ -1006,12 +2003,16 @@ func getActivityDescription(agentType agents.AgentType) string {
 
 // processCompletedTickets handles merging completed tickets.
 func (o *Orchestrator) processCompletedTickets(ctx context.Context) {
-	tickets := o.state.GetTicketsByStatus(kanban.StatusDone)
+	tickets := unpaused(o.state.GetTicketsByStatus(kanban.StatusDone))
 
 	for _, ticket := range tickets {
 		if ticket.Worktree == nil || !ticket.Worktree.Active {
 			continue
 		}
+		if o.mergeInFlight(ticket.ID) {
+			o.logger.Debug("Waiting for queued merge before merging completed ticket", "ticket", ticket.ID)
+			continue
+		}
 
 		o.logger.Info("Merging completed ticket", "ticket", ticket.ID)
 
@@ -1030,11 +2031,12 @@ func (o *Orchestrator) processCompletedTickets(ctx context.Context) {
 			continue
 		}
 
-		// Cleanup worktree
+		// Cleanup worktree, or leave it to the worktree manager's
+		// cleanup_worktree_on_merge; either way it no longer holds a slot
 		if o.config.AutoCleanup {
-			if err := o.worktree.RemoveWorktree(ticket.Worktree.Path, true); err != nil {
-				o.logger.Warn("Failed to cleanup worktree", "error", err)
-			}
+			o.cleanupMergedWorktree(ticket.ID, ticket.Worktree)
+		} else if store, ok := o.state.(WorktreeStore); ok {
+			_ = store.UpdateWorktreeStatus(ticket.ID, kanban.WorktreePoolStatusCleanupPending)
 		}
 
 		// Update ticket
@@ -1048,6 +2050,266 @@ func (o *Orchestrator) processCompletedTickets(ctx context.Context) {
 	}
 }
 
+// mergeInFlight reports whether the merge queue still holds an unfinished
+// merge for the ticket, so merging the completed ticket waits for it rather
+// than racing it on main.
+func (o *Orchestrator) mergeInFlight(ticketID string) bool {
+	store, ok := o.state.(WorktreeStore)
+	if !ok {
+		return false
+	}
+	entry, err := store.GetMergeByTicket(ticketID)
+	if err != nil || entry == nil {
+		return false
+	}
+	return entry.Status == kanban.MergeQueueStatusPending || entry.Status == kanban.MergeQueueStatusInProgress
+}
+
+// cleanupMergedWorktree removes a merged ticket's worktree and releases its
+// pool slot, or with a WorktreeRetention schedules its removal so it can be
+// inspected meanwhile; the worktree manager's cleanup removes it once the
+// retention has elapsed.
+func (o *Orchestrator) cleanupMergedWorktree(ticketID string, wt *kanban.Worktree) {
+	store, hasPool := o.state.(WorktreeStore)
+	if hasPool && o.config.WorktreeRetention > 0 {
+		cleanupAfter := time.Now().Add(o.config.WorktreeRetention)
+		err := store.ScheduleWorktreeCleanup(ticketID, wt.Branch, wt.Path, cleanupAfter)
+		if err == nil {
+			o.logger.Info("Keeping merged worktree", "ticket", ticketID, "until", cleanupAfter)
+			return
+		}
+		o.logger.Warn("Failed to schedule worktree cleanup, removing now", "ticket", ticketID, "error", err)
+	}
+
+	if err := o.worktree.RemoveWorktree(wt.Path, true); err != nil {
+		o.logger.Warn("Failed to cleanup worktree", "error", err)
+		if hasPool {
+			// Leave it to the worktree manager's cleanup to retry
+			_ = store.UpdateWorktreeStatus(ticketID, kanban.WorktreePoolStatusCleanupPending)
+		}
+		return
+	}
+	if hasPool {
+		_ = store.RemoveFromPool(ticketID)
+	}
+}
+
+// recordIterationDigest stores a digest of the current iteration once it
+// completes, and with NotifyIterationDigest sends it to operators. Iterations
+// that completed nothing, or already have a digest, are left alone.
+func (o *Orchestrator) recordIterationDigest() {
+	store, ok := o.state.(IterationDigestStore)
+	if !ok {
+		return
+	}
+	iteration := o.state.GetIteration()
+	if iteration == nil {
+		return
+	}
+	if existing, err := store.GetIterationDigest(iteration.ID); err != nil || existing != nil {
+		return
+	}
+
+	digest, err := store.BuildIterationDigest(iteration)
+	if err != nil {
+		o.logger.Error("Failed to build iteration digest", "iteration", iteration.ID, "error", err)
+		return
+	}
+	if len(digest.TicketsCompleted) == 0 {
+		return
+	}
+	if err := store.SaveIterationDigest(digest); err != nil {
+		o.logger.Error("Failed to save iteration digest", "iteration", iteration.ID, "error", err)
+		return
+	}
+	o.logger.Info("Recorded iteration digest", "iteration", iteration.ID, "summary", digest.Summary())
+
+	if o.config.NotifyIterationDigest {
+		o.notify(notify.Notification{
+			Event:   "iteration:digest",
+			Title:   fmt.Sprintf("Iteration %s complete", iteration.ID),
+			Message: digest.Summary(),
+			At:      time.Now(),
+		})
+	}
+}
+
+// notify sends an operator notification, logging it when no notifier is set.
+func (o *Orchestrator) notify(n notify.Notification) {
+	notifier := o.notifier
+	if notifier == nil {
+		notifier = notify.NewLogNotifier(o.logger)
+	}
+	if err := notifier.Notify(n); err != nil {
+		o.logger.Warn("Failed to send notification", "event", n.Event, "error", err)
+	}
+}
+
+// completeRun marks a run finished and records what the spawner reported
+// about it.
+func (o *Orchestrator) completeRun(runID, status, output string, result *agents.AgentResult) {
+	o.state.CompleteRun(runID, status, output)
+	if result != nil {
+		o.state.SetRunMetadata(runID, result.RunMetadata)
+	}
+}
+
+// spawnWithRetry spawns an agent, retrying runs whose failure category is
+// retryable (provider errors and timeouts) up to AgentMaxRetries times with
+// exponential backoff. Every failed attempt is
+// recorded in the audit log; the last attempt's result is returned.
+func (o *Orchestrator) spawnWithRetry(ctx context.Context, agentType agents.AgentType, data agents.PromptData, workDir string) (*agents.AgentResult, error) {
+	maxAttempts := o.config.AgentMaxRetries + 1
+	for attempt := 1; ; attempt++ {
+		result, err := o.spawner.SpawnAgent(ctx, agentType, data, workDir)
+		if err == nil && result.Success {
+			return result, nil
+		}
+		if errors.Is(err, context.Canceled) {
+			return result, err
+		}
+
+		category := agents.ClassifyFailure(err, result)
+		retry := attempt < maxAttempts && ctx.Err() == nil && category.Retryable()
+		o.auditFailedAttempt(data, agentType, attempt, maxAttempts, retry, category, err, result)
+		if !retry {
+			return result, err
+		}
+
+		backoff := agentRetryBackoff(o.config.AgentRetryBackoff, attempt)
+		o.logger.Warn("Agent failed transiently, retrying",
+			"run", data.RunID,
+			"agent", agentType,
+			"attempt", attempt,
+			"category", category,
+			"backoff", backoff)
+		select {
+		case <-ctx.Done():
+			return result, err
+		case <-time.After(backoff):
+		}
+	}
+}
+
+// maxAgentRetryBackoff caps the exponential agent retry delay.
+const maxAgentRetryBackoff = 10 * time.Minute
+
+// agentRetryBackoff returns the delay after the given failed attempt: base,
+// 2*base, 4*base, ... capped at maxAgentRetryBackoff.
+func agentRetryBackoff(base time.Duration, attempt int) time.Duration {
+	backoff := base
+	for i := 1; i < attempt && backoff < maxAgentRetryBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > maxAgentRetryBackoff {
+		backoff = maxAgentRetryBackoff
+	}
+	return backoff
+}
+
+// auditFailedAttempt records a failed agent attempt in the audit log, if the
+// store keeps one.
+func (o *Orchestrator) auditFailedAttempt(data agents.PromptData, agentType agents.AgentType, attempt, maxAttempts int, retry bool, category agents.FailureCategory, err error, result *agents.AgentResult) {
+	store, ok := o.state.(agents.AuditStore)
+	if !ok {
+		return
+	}
+	reason := "agent failed"
+	if err != nil {
+		reason = err.Error()
+	} else if result != nil && result.Error != "" {
+		reason = result.Error
+	}
+	outcome := "giving up"
+	if retry {
+		outcome = "retrying"
+	}
+	ticketID := ""
+	if data.Ticket != nil {
+		ticketID = data.Ticket.ID
+	}
+	message := fmt.Sprintf("attempt %d of %d failed, %s (%s): %s", attempt, maxAttempts, outcome, category, reason)
+	if logErr := agents.NewStoreAuditLogger(store).LogError(data.RunID, ticketID, string(agentType), message); logErr != nil {
+		o.logger.Warn("Failed to audit agent attempt", "run", data.RunID, "error", logErr)
+	}
+}
+
+// CancelTicketRuns cancels every agent currently running for a ticket and
+// returns how many were cancelled.
+func (o *Orchestrator) CancelTicketRuns(ticketID string) int {
+	if o.ticketRuns == nil {
+		return 0
+	}
+	return o.ticketRuns.cancel(ticketID)
+}
+
+// ticketRunSpawner gives each spawn for a ticket its own context, so the
+// ticket's agents can be cancelled without stopping the orchestrator.
+type ticketRunSpawner struct {
+	agents.AgentSpawner
+
+	mu      sync.Mutex
+	nextID  int
+	cancels map[string]map[int]context.CancelFunc // Ticket ID -> in-flight spawns
+}
+
+func newTicketRunSpawner(inner agents.AgentSpawner) *ticketRunSpawner {
+	return &ticketRunSpawner{
+		AgentSpawner: inner,
+		cancels:      make(map[string]map[int]context.CancelFunc),
+	}
+}
+
+// SpawnAgent runs the agent under a context cancel can reach. A run stopped
+// by cancel returns an error wrapping context.Canceled, whatever the inner
+// spawner reported.
+func (s *ticketRunSpawner) SpawnAgent(ctx context.Context, agentType agents.AgentType, data agents.PromptData, workDir string) (*agents.AgentResult, error) {
+	if data.Ticket == nil {
+		return s.AgentSpawner.SpawnAgent(ctx, agentType, data, workDir)
+	}
+	ticketID := data.Ticket.ID
+
+	runCtx, cancel := context.WithCancel(ctx)
+	s.mu.Lock()
+	id := s.nextID
+	s.nextID++
+	if s.cancels[ticketID] == nil {
+		s.cancels[ticketID] = make(map[int]context.CancelFunc)
+	}
+	s.cancels[ticketID][id] = cancel
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		delete(s.cancels[ticketID], id)
+		if len(s.cancels[ticketID]) == 0 {
+			delete(s.cancels, ticketID)
+		}
+		s.mu.Unlock()
+		cancel()
+	}()
+
+	result, err := s.AgentSpawner.SpawnAgent(runCtx, agentType, data, workDir)
+	failed := err != nil || result == nil || !result.Success
+	if failed && runCtx.Err() != nil && ctx.Err() == nil {
+		if err == nil {
+			err = context.Canceled
+		} else if !errors.Is(err, context.Canceled) {
+			err = fmt.Errorf("%w: %v", context.Canceled, err)
+		}
+	}
+	return result, err
+}
+
+func (s *ticketRunSpawner) cancel(ticketID string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, cancel := range s.cancels[ticketID] {
+		cancel()
+	}
+	return len(s.cancels[ticketID])
+}
+
 // GetMetrics returns current metrics.
 func (o *Orchestrator) GetMetrics() Metrics {
 	o.mu.Lock()
@@ -1055,6 +2317,58 @@ func (o *Orchestrator) GetMetrics() Metrics {
 	return o.metrics
 }
 
+// GetBackpressure classifies READY tickets by what is holding them back.
+// Tickets with unmet dependencies or file conflicts are counted as such; the
+// remainder beyond the free dev and worktree slots are waiting on limits.
+func (o *Orchestrator) GetBackpressure() Backpressure {
+	bp := Backpressure{
+		ActiveDevRuns: len(o.state.GetActiveDevRuns()),
+		DevLimit:      o.config.MaxParallelAgents,
+	}
+
+	slots := bp.DevLimit - bp.ActiveDevRuns
+	if poolStore, ok := o.state.(interface {
+		GetWorktreePoolStats() (*kanban.WorktreePoolStats, error)
+	}); ok {
+		if pool, err := poolStore.GetWorktreePoolStats(); err == nil && pool != nil {
+			bp.WorktreesUsed = pool.ActiveCount + pool.MergingCount
+			bp.WorktreeLimit = pool.Limit
+			if pool.AvailableSlots < slots {
+				slots = pool.AvailableSlots
+			}
+		}
+	}
+	config := o.state.GetConfig()
+	if limit := config.WIPLimits[kanban.StatusInDev]; limit > 0 {
+		if room := limit - len(o.state.GetTicketsByStatus(kanban.StatusInDev)); room < slots {
+			slots = room
+		}
+	}
+	if slots < 0 {
+		slots = 0
+	}
+
+	startable := 0
+	for _, ticket := range o.state.GetReadyTickets() {
+		bp.ReadyTickets++
+		switch {
+		case !o.checkDependenciesMet(&ticket):
+			bp.WaitingOnDependencies++
+		case o.hasFileConflict(&ticket):
+			bp.WaitingOnConflicts++
+		default:
+			startable++
+		}
+	}
+
+	if startable > slots {
+		bp.WaitingOnLimits = startable - slots
+	}
+	bp.Throttled = bp.WaitingOnLimits > 0
+
+	return bp
+}
+
 // GetState returns the kanban state store.
 func (o *Orchestrator) GetState() kanban.StateStore {
 	return o.state
@@ -1085,6 +2399,16 @@ func (o *Orchestrator) createSignoffReport(ticketID string, agentType agents.Age
 		return
 	}
 
+	if report.TestsRun != nil {
+		if ticket, ok := o.state.GetTicket(ticketID); ok {
+			report.TestsRun.CheckFramework(o.config.TestFrameworks[string(ticket.Domain)])
+			if report.TestsRun.FrameworkMismatch {
+				o.logger.Warn("Sign-off reports an unexpected test framework",
+					"ticket", ticketID, "agent", agentType, "framework", report.TestsRun.Framework, "domain", ticket.Domain)
+			}
+		}
+	}
+
 	// Create a conversation thread for this sign-off
 	conv := &kanban.TicketConversation{
 		ID:         uuid.New().String(),
@@ -1122,6 +2446,105 @@ func (o *Orchestrator) createSignoffReport(ticketID string, agentType agents.Age
 	o.logger.Info("Created sign-off report", "ticket", ticketID, "agent", agentType, "status", report.Status)
 }
 
+// fileBugfixTickets files a bugfix ticket for each bug in a review agent's report
+// whose severity is in BugfixTicketSeverities. Each is linked to the reviewed
+// ticket, and a bug already filed from it (by title) is not filed again.
+func (o *Orchestrator) fileBugfixTickets(source *kanban.Ticket, agentType agents.AgentType, output string) {
+	if len(o.config.BugfixTicketSeverities) == 0 {
+		return
+	}
+	report := parseSignoffReport(output)
+	if report == nil {
+		return
+	}
+
+	n := 0
+	for _, bug := range report.Bugs {
+		if !o.filesBugfixFor(bug.Severity) {
+			continue
+		}
+		title := bug.Title
+		if title == "" {
+			title = bugTitleFromDescription(bug.Description)
+		}
+
+		// Find the next free ID, skipping bugs already filed from this ticket
+		var id string
+		duplicate := false
+		for {
+			n++
+			id = fmt.Sprintf("%s-BUG-%d", source.ID, n)
+			existing, ok := o.state.GetTicket(id)
+			if !ok {
+				break
+			}
+			if existing.Title == title {
+				duplicate = true
+				break
+			}
+		}
+		if duplicate {
+			continue
+		}
+
+		bugfix := kanban.Ticket{
+			ID:             id,
+			Title:          title,
+			Description:    bug.Description,
+			Domain:         source.Domain,
+			Priority:       severityPriority(bug.Severity),
+			Type:           "bugfix",
+			Files:          source.Files,
+			Status:         kanban.StatusBacklog,
+			SourceTicketID: source.ID,
+			Links:          []kanban.TicketLink{{Type: kanban.LinkTypeRelates, TicketID: source.ID}},
+			CreatedAt:      time.Now(),
+			UpdatedAt:      time.Now(),
+			Notes:          fmt.Sprintf("Found by %s reviewing %s", agentType, source.ID),
+		}
+		if err := o.state.CreateTicket(&bugfix); err != nil {
+			o.logger.Error("Failed to file bugfix ticket", "source", source.ID, "error", err)
+			continue
+		}
+		o.logger.Info("Filed bugfix ticket", "id", id, "source", source.ID, "severity", bug.Severity)
+	}
+}
+
+// filesBugfixFor reports whether bugs of the severity get a bugfix ticket.
+func (o *Orchestrator) filesBugfixFor(severity string) bool {
+	for _, s := range o.config.BugfixTicketSeverities {
+		if strings.EqualFold(s, severity) {
+			return true
+		}
+	}
+	return false
+}
+
+// maxBugTitle caps titles derived from bug descriptions, in runes.
+const maxBugTitle = 80
+
+// bugTitleFromDescription titles an untitled bug after its description's first line.
+func bugTitleFromDescription(description string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(description), "\n")
+	if runes := []rune(line); len(runes) > maxBugTitle {
+		return string(runes[:maxBugTitle-3]) + "..."
+	}
+	return line
+}
+
+// severityPriority maps a bug severity to the priority of its bugfix ticket.
+func severityPriority(severity string) kanban.Priority {
+	switch strings.ToLower(severity) {
+	case "critical":
+		return kanban.PriorityCritical
+	case "high":
+		return kanban.PriorityHigh
+	case "low":
+		return kanban.PriorityLow
+	}
+	return kanban.PriorityMedium
+}
+
 // parseSignoffReport extracts JSON from agent output.
 func parseSignoffReport(output string) *kanban.SignoffReport {
 	// Agent output often contains JSON wrapped in markdown code blocks
@@ -24,7 +24,7 @@ var ExpertAgents = []string{"dev", "qa", "ux", "security"}
 // processApprovedToPRDRound moves newly approved tickets into collaborative PRD refinement.
 // This replaces the legacy processApprovedToRefining for the new collaborative model.
 func (o *Orchestrator) processApprovedToPRDRound(ctx context.Context) {
-	approvedTickets := o.state.GetTicketsByStatus(kanban.StatusApproved)
+	approvedTickets := unpaused(o.state.GetTicketsByStatus(kanban.StatusApproved))
 	o.logger.Info("PRD Round check", "approvedCount", len(approvedTickets))
 	if len(approvedTickets) == 0 {
 		return
@@ -33,6 +33,13 @@ func (o *Orchestrator) processApprovedToPRDRound(ctx context.Context) {
 	o.logger.Info("Starting collaborative PRD discussion for approved tickets", "count", len(approvedTickets))
 
 	for _, ticket := range approvedTickets {
+		// Well-specified tickets don't need a multi-round discussion
+		if o.requirementsReady(&ticket) {
+			_ = o.state.UpdateTicketStatus(ticket.ID, kanban.StatusReady, "PM", "Requirements complete - skipping PRD discussion")
+			o.logger.Info("Ticket fast-tracked to READY", "ticket", ticket.ID, "criteria", len(ticket.AcceptanceCriteria))
+			continue
+		}
+
 		// Initialize conversation tracking
 		conversation := &kanban.PRDConversation{
 			TicketID:     ticket.ID,
@@ -61,7 +68,7 @@ func (o *Orchestrator) processPRDRoundStage(ctx context.Context) {
 	allTickets, _ := o.state.GetAllTickets()
 	var roundTickets []kanban.Ticket
 	for _, t := range allTickets {
-		if strings.HasPrefix(string(t.Status), string(kanban.StatusRefiningRound)) {
+		if strings.HasPrefix(string(t.Status), string(kanban.StatusRefiningRound)) && !t.Paused {
 			roundTickets = append(roundTickets, t)
 		}
 	}
@@ -79,6 +86,9 @@ func (o *Orchestrator) processPRDRoundStage(ctx context.Context) {
 			o.logger.Info("Agents already running for ticket", "ticket", ticket.ID, "count", len(activeRuns))
 			continue
 		}
+		if !o.takeSpawnSlot(string(agents.AgentTypePMFacilitator), ticket.ID) {
+			return
+		}
 
 		// Parse current round from status (REFINING_ROUND_N -> N)
 		roundNum := o.parseRoundFromStatus(ticket.Status)
@@ -193,6 +203,7 @@ func (o *Orchestrator) spawnAllExperts(ctx context.Context, ticket *kanban.Ticke
 				Status:    "running",
 			}
 			_ = o.state.AddRun(&run)
+			promptData.RunID = run.ID
 
 			// Spawn expert agent
 			result, err := o.spawner.SpawnAgent(ctx, agents.AgentTypePRDExpert, promptData, o.repoRoot)
@@ -203,7 +214,7 @@ func (o *Orchestrator) spawnAllExperts(ctx context.Context, ticket *kanban.Ticke
 				status = "failed"
 				o.logger.Error("Expert agent failed", "agent", agentName, "ticket", ticket.ID, "error", err)
 			}
-			o.state.CompleteRun(run.ID, status, result.Output)
+			o.completeRun(run.ID, status, result.Output, result)
 
 			mu.Lock()
 			expertResults[agentName] = result
@@ -281,6 +292,7 @@ func (o *Orchestrator) spawnSingleExpert(ctx context.Context, ticket *kanban.Tic
 		Status:    "running",
 	}
 	_ = o.state.AddRun(&run)
+	promptData.RunID = run.ID
 
 	result, err := o.spawner.SpawnAgent(ctx, agents.AgentTypePRDExpert, promptData, o.repoRoot)
 
@@ -288,10 +300,10 @@ func (o *Orchestrator) spawnSingleExpert(ctx context.Context, ticket *kanban.Tic
 	if err != nil {
 		status = "failed"
 		o.logger.Error("Expert agent failed", "agent", agentName, "ticket", ticket.ID, "error", err)
-		o.state.CompleteRun(run.ID, status, "")
+		o.completeRun(run.ID, status, "", result)
 		return
 	}
-	o.state.CompleteRun(run.ID, status, result.Output)
+	o.completeRun(run.ID, status, result.Output, result)
 
 	if result.Success {
 		input := o.parseExpertResponse(result.Output)
@@ -318,6 +330,7 @@ func (o *Orchestrator) runPMSynthesis(ctx context.Context, ticket *kanban.Ticket
 		Ticket:       ticket,
 		Conversation: ticket.Conversation,
 		CurrentRound: nextRound,
+		PRDTemplate:  o.config.PRDTemplate,
 		BoardStats:   o.state.GetStats(),
 	}
 	synthesisTicketBytes, _ := json.MarshalIndent(ticket, "", "  ")
@@ -336,6 +349,17 @@ func (o *Orchestrator) runPMSynthesis(ctx context.Context, ticket *kanban.Ticket
 
 	switch action {
 	case "FINALIZE_PRD":
+		// A PRD missing required template sections goes back for another round
+		if missing := kanban.MissingPRDSections(prd, o.config.PRDTemplate); len(missing) > 0 && nextRound <= MaxPRDRounds {
+			note := fmt.Sprintf("PRD is missing required sections: %s", strings.Join(missing, ", "))
+			round.PMSynthesis = strings.TrimSpace(round.PMSynthesis + "\n\n" + note)
+			_ = o.state.UpdateTicket(ticket)
+			roundStatus := kanban.Status(fmt.Sprintf("%s_%d", kanban.StatusRefiningRound, nextRound))
+			_ = o.state.UpdateTicketStatus(ticket.ID, roundStatus, "PM", fmt.Sprintf("%s - starting round %d", note, nextRound))
+			o.logger.Info("PRD incomplete, starting another round", "ticket", ticket.ID, "missing", missing)
+			break
+		}
+
 		// All experts approved - move to PRD_COMPLETE
 		ticket.Conversation.Status = "consensus"
 		ticket.Conversation.FinalPRD = prd
@@ -373,7 +397,7 @@ func (o *Orchestrator) runPMSynthesis(ctx context.Context, ticket *kanban.Ticket
 
 // processPRDCompleteStage handles tickets with finalized PRDs.
 func (o *Orchestrator) processPRDCompleteStage(ctx context.Context) {
-	prdCompleteTickets := o.state.GetTicketsByStatus(kanban.StatusPRDComplete)
+	prdCompleteTickets := unpaused(o.state.GetTicketsByStatus(kanban.StatusPRDComplete))
 	if len(prdCompleteTickets) == 0 {
 		return
 	}
@@ -429,12 +453,30 @@ func (o *Orchestrator) createSubTickets(ctx context.Context, parent *kanban.Tick
 		return
 	}
 
+	if limit := o.config.MaxSubTicketsPerPRD; limit > 0 && len(subTickets) > limit {
+		o.logger.Warn("PRD breakdown exceeds sub-ticket cap, deferring the rest",
+			"parent", parent.ID, "proposed", len(subTickets), "cap", limit)
+		o.noteDeferredSubTickets(parent, subTickets[limit:], limit)
+		subTickets = subTickets[:limit]
+	}
+
 	o.logger.Info("Creating sub-tickets from PRD", "parent", parent.ID, "count", len(subTickets))
 
+	// With sequential groups, only the first group starts out READY
+	firstGroup := subTickets[0].ParallelGroup
+	for _, spec := range subTickets {
+		firstGroup = min(firstGroup, spec.ParallelGroup)
+	}
+
 	var createdIDs []string
 	for i, spec := range subTickets {
 		subID := fmt.Sprintf("%s-SUB-%d", parent.ID, i+1)
 
+		status := kanban.StatusReady
+		if o.config.SequentialParallelGroups && spec.ParallelGroup > firstGroup {
+			status = kanban.StatusQueued
+		}
+
 		subTicket := kanban.Ticket{
 			ID:                 subID,
 			Title:              spec.Title,
@@ -447,7 +489,7 @@ func (o *Orchestrator) createSubTickets(ctx context.Context, parent *kanban.Tick
 			AcceptanceCriteria: spec.AcceptanceCriteria,
 			ParentID:           parent.ID,
 			ParallelGroup:      spec.ParallelGroup,
-			Status:             kanban.StatusReady,
+			Status:             status,
 			CreatedAt:          time.Now(),
 			UpdatedAt:          time.Now(),
 			Notes:              spec.TechnicalNotes,
@@ -471,6 +513,21 @@ func (o *Orchestrator) createSubTickets(ctx context.Context, parent *kanban.Tick
 	o.logger.Info("Created sub-tickets from PRD", "parent", parent.ID, "count", len(createdIDs))
 }
 
+// noteDeferredSubTickets records the sub-tickets cut by the per-PRD cap in the
+// parent's notes, so they can be consolidated or filed once the first batch lands.
+func (o *Orchestrator) noteDeferredSubTickets(parent *kanban.Ticket, deferred []SubTicketSpec, limit int) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "PRD breakdown capped at %d sub-tickets; %d deferred:", limit, len(deferred))
+	for _, spec := range deferred {
+		b.WriteString("\n- " + spec.Title)
+	}
+
+	if parent.Notes != "" {
+		parent.Notes += "\n\n"
+	}
+	parent.Notes += b.String()
+}
+
 // SubTicketSpec represents a sub-ticket parsed from PM breakdown output.
 type SubTicketSpec struct {
 	Title              string   `json:"title"`
@@ -493,6 +550,34 @@ func (o *Orchestrator) getCurrentRound(ticket *kanban.Ticket) *kanban.Conversati
 	return &ticket.Conversation.Rounds[len(ticket.Conversation.Rounds)-1]
 }
 
+// requirementsReady reports whether a ticket is specified well enough to skip PRD rounds:
+// enough acceptance criteria and a description within the configured length bounds.
+func (o *Orchestrator) requirementsReady(ticket *kanban.Ticket) bool {
+	if o.config.FastTrackMinCriteria <= 0 {
+		return false
+	}
+
+	criteria := 0
+	for _, c := range ticket.AcceptanceCriteria {
+		if strings.TrimSpace(c) != "" {
+			criteria++
+		}
+	}
+	if criteria < o.config.FastTrackMinCriteria {
+		return false
+	}
+
+	descLen := len(strings.TrimSpace(ticket.Description))
+	if descLen < o.config.FastTrackMinDescriptionLen {
+		return false
+	}
+	if o.config.FastTrackMaxDescriptionLen > 0 && descLen > o.config.FastTrackMaxDescriptionLen {
+		return false
+	}
+
+	return true
+}
+
 // countActualResponses counts expert inputs that have actual non-empty responses.
 func (o *Orchestrator) countActualResponses(round *kanban.ConversationRound) int {
 	count := 0
@@ -706,3 +791,57 @@ func (o *Orchestrator) checkParentCompletion(ctx context.Context) {
 		}
 	}
 }
+
+// promoteParallelGroups stages each breaking-down parent's sub-tickets by parallel
+// group. The lowest group with unfinished sub-tickets is the active one: its
+// QUEUED sub-tickets are made READY, and READY sub-tickets in higher groups are
+// held in QUEUED. Sub-tickets already in progress are left alone. Each ticket is
+// re-read before it moves so changes made elsewhere since the scan are respected.
+func (o *Orchestrator) promoteParallelGroups(ctx context.Context) {
+	for _, parent := range o.state.GetTicketsByStatus(kanban.StatusBreakingDown) {
+		if parent.Conversation == nil || len(parent.Conversation.SubTicketIDs) == 0 {
+			continue
+		}
+
+		var subs []kanban.Ticket
+		activeGroup, found := 0, false
+		for _, subID := range parent.Conversation.SubTicketIDs {
+			sub, ok := o.state.GetTicket(subID)
+			if !ok {
+				continue
+			}
+			subs = append(subs, *sub)
+			if sub.Status != kanban.StatusDone && (!found || sub.ParallelGroup < activeGroup) {
+				activeGroup, found = sub.ParallelGroup, true
+			}
+		}
+		if !found {
+			continue // All done; checkParentCompletion closes the parent
+		}
+
+		for _, sub := range subs {
+			switch {
+			case sub.ParallelGroup == activeGroup:
+				o.moveSubTicket(sub.ID, kanban.StatusQueued, kanban.StatusReady,
+					fmt.Sprintf("Parallel group %d released", activeGroup))
+			case sub.ParallelGroup > activeGroup:
+				o.moveSubTicket(sub.ID, kanban.StatusReady, kanban.StatusQueued,
+					fmt.Sprintf("Waiting for parallel group %d to finish", activeGroup))
+			}
+		}
+	}
+}
+
+// moveSubTicket moves a sub-ticket from one status to another if it is still in
+// the expected status.
+func (o *Orchestrator) moveSubTicket(id string, from, to kanban.Status, note string) {
+	current, ok := o.state.GetTicket(id)
+	if !ok || current.Status != from {
+		return
+	}
+	if err := o.state.UpdateTicketStatus(id, to, "system", note); err != nil {
+		o.logger.Error("Failed to stage sub-ticket", "ticket", id, "status", to, "error", err)
+		return
+	}
+	o.logger.Info("Staged sub-ticket", "ticket", id, "from", from, "to", to)
+}
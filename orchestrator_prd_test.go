@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"log/slog"
+	"sort"
 	"strings"
 	"sync"
 	"testing"
@@ -21,12 +22,17 @@ type mockSpawner struct {
 	mu          sync.Mutex
 	spawnedRuns []spawnRecord
 	responses   map[agents.AgentType]string
+	fail        bool // Report every spawn as a failed run
 }
 
 type spawnRecord struct {
-	AgentType agents.AgentType
-	TicketID  string
-	Agent     string // For expert agents, this is the domain (dev, qa, ux, security)
+	AgentType     agents.AgentType
+	TicketID      string
+	Agent         string // For expert agents, this is the domain (dev, qa, ux, security)
+	BugsToVerify  []kanban.Bug
+	WorktreePath  string
+	ResumeContext string
+	CodeContext   string
 }
 
 func newMockSpawner() *mockSpawner {
@@ -45,11 +51,19 @@ func (m *mockSpawner) SpawnAgent(ctx context.Context, agentType agents.AgentType
 	}
 
 	m.spawnedRuns = append(m.spawnedRuns, spawnRecord{
-		AgentType: agentType,
-		TicketID:  ticketID,
-		Agent:     data.Agent,
+		AgentType:     agentType,
+		TicketID:      ticketID,
+		Agent:         data.Agent,
+		BugsToVerify:  data.BugsToVerify,
+		WorktreePath:  data.WorktreePath,
+		ResumeContext: data.ResumeContext,
+		CodeContext:   data.CodeContext,
 	})
 
+	if m.fail {
+		return &agents.AgentResult{AgentType: agentType, TicketID: ticketID, Error: "agent failed"}, nil
+	}
+
 	response := m.responses[agentType]
 	if response == "" {
 		response = "{}"
@@ -63,6 +77,8 @@ func (m *mockSpawner) SpawnAgent(ctx context.Context, agentType agents.AgentType
 	}, nil
 }
 
+func (m *mockSpawner) ValidateAgentEnvironment() []string { return nil }
+
 func (m *mockSpawner) SetResponse(agentType agents.AgentType, response string) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -111,6 +127,8 @@ type mockState struct {
 	runs      []kanban.AgentRun
 	stats     map[kanban.Status]int
 	iteration *kanban.Iteration
+	config    kanban.BoardConfig
+	messages  []kanban.ConversationMessage
 }
 
 func newMockState() *mockState {
@@ -122,8 +140,7 @@ func newMockState() *mockState {
 
 func (m *mockState) Load() error                         { return nil }
 func (m *mockState) Save() error                         { return nil }
-func (m *mockState) GetBoard() kanban.Board              { return kanban.Board{} }
-func (m *mockState) GetConfig() kanban.BoardConfig       { return kanban.BoardConfig{} }
+func (m *mockState) GetConfig() kanban.BoardConfig       { return m.config }
 func (m *mockState) GetStats() map[kanban.Status]int     { return m.stats }
 func (m *mockState) SetIteration(iter *kanban.Iteration) { m.iteration = iter }
 func (m *mockState) GetIteration() *kanban.Iteration     { return m.iteration }
@@ -134,7 +151,6 @@ func (m *mockState) GetReadyTickets() []kanban.Ticket {
 func (m *mockState) GetNextTicketForDomain(d kanban.Domain) (*kanban.Ticket, bool) { return nil, false }
 func (m *mockState) GetInProgressCount() int                                       { return 0 }
 func (m *mockState) AssignAgent(ticketID, agentID string) error                    { return nil }
-func (m *mockState) SetWorktree(ticketID string, wt *kanban.Worktree) error        { return nil }
 func (m *mockState) AddSignoff(ticketID, stage, agentID string) error              { return nil }
 func (m *mockState) AddBug(ticketID string, bug kanban.Bug) error                  { return nil }
 func (m *mockState) UpdateNotes(ticketID, notes string) error                      { return nil }
@@ -142,10 +158,35 @@ func (m *mockState) UpdateActivity(ticketID, activity, assignee string) error
 func (m *mockState) ClearActivity(ticketID string) error                           { return nil }
 func (m *mockState) CleanupStaleRuns(maxAge time.Duration)                         {}
 func (m *mockState) CleanupStaleRunningAgents(maxRunDuration time.Duration) int    { return 0 }
-func (m *mockState) CleanupOrphanedRunningAgents() int                             { return 0 }
+func (m *mockState) CleanupOrphanedRunningAgents(grace time.Duration) int          { return 0 }
+func (m *mockState) HeartbeatRunningAgents() int                                   { return 0 }
 func (m *mockState) IsAgentRunning(ticketID, agentType string) bool                { return false }
 func (m *mockState) CreateConversation(conv *kanban.TicketConversation) error      { return nil }
-func (m *mockState) AddConversationMessage(msg *kanban.ConversationMessage) error  { return nil }
+
+func (m *mockState) AddConversationMessage(msg *kanban.ConversationMessage) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.messages = append(m.messages, *msg)
+	return nil
+}
+
+func (m *mockState) SetWorktree(ticketID string, wt *kanban.Worktree) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if t, ok := m.tickets[ticketID]; ok {
+		t.Worktree = wt
+	}
+	return nil
+}
+
+func (m *mockState) SetTestRun(ticketID string, result *kanban.TestRunResult) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if t, ok := m.tickets[ticketID]; ok {
+		t.TestRun = result
+	}
+	return nil
+}
 
 func (m *mockState) GetTicket(id string) (*kanban.Ticket, bool) {
 	m.mu.Lock()
@@ -200,6 +241,17 @@ func (m *mockState) GetTicketsByParent(parentID string) []kanban.Ticket {
 	return result
 }
 
+func (m *mockState) GetBoard() kanban.Board {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	board := kanban.Board{Iteration: m.iteration}
+	for _, t := range m.tickets {
+		board.Tickets = append(board.Tickets, *t)
+	}
+	sort.Slice(board.Tickets, func(i, j int) bool { return board.Tickets[i].ID < board.Tickets[j].ID })
+	return board
+}
+
 func (m *mockState) AddTicket(t kanban.Ticket) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -254,6 +306,16 @@ func (m *mockState) CompleteRun(runID string, status string, output string) {
 	}
 }
 
+func (m *mockState) SetRunMetadata(runID string, meta kanban.RunMetadata) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := range m.runs {
+		if m.runs[i].ID == runID {
+			m.runs[i].RunMetadata = meta
+		}
+	}
+}
+
 func (m *mockState) GetActiveRuns() []kanban.AgentRun {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -278,6 +340,18 @@ func (m *mockState) GetActiveDevRuns() []kanban.AgentRun {
 	return active
 }
 
+func (m *mockState) GetRunsByTicket(ticketID string) ([]kanban.AgentRun, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var runs []kanban.AgentRun
+	for _, r := range m.runs {
+		if r.TicketID == ticketID {
+			runs = append(runs, r)
+		}
+	}
+	return runs, nil
+}
+
 func (m *mockState) GetActiveRunsForTicket(ticketID string) []kanban.AgentRun {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -679,6 +753,42 @@ func TestAC6_PRDBreakdown(t *testing.T) {
 	}
 }
 
+func TestPRDBreakdownIsCappedAtMaxSubTickets(t *testing.T) {
+	state := newMockState()
+	ticket := createCompletedPRDTicket("TEST-CAP")
+	state.AddTicket(*ticket)
+
+	var subTickets []SubTicketSpec
+	for i := 1; i <= 5; i++ {
+		subTickets = append(subTickets, SubTicketSpec{
+			Title:         fmt.Sprintf("Sub-task %d", i),
+			Domain:        "backend",
+			Files:         []string{fmt.Sprintf("pkg/task%d.go", i)},
+			ParallelGroup: 1,
+		})
+	}
+
+	orch := &Orchestrator{
+		state:    state,
+		repoRoot: "/tmp/test",
+		config:   Config{MaxSubTicketsPerPRD: 3},
+		logger:   slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	orch.createSubTickets(context.Background(), ticket, subTickets)
+
+	if children := state.GetTicketsByParent(ticket.ID); len(children) != 3 {
+		t.Errorf("Expected 3 sub-tickets, got %d", len(children))
+	}
+	if len(ticket.Conversation.SubTicketIDs) != 3 {
+		t.Errorf("Expected parent to list 3 sub-tickets, got %v", ticket.Conversation.SubTicketIDs)
+	}
+	for _, title := range []string{"Sub-task 4", "Sub-task 5"} {
+		if !strings.Contains(ticket.Notes, title) {
+			t.Errorf("Expected parent notes to record deferred %q, got %q", title, ticket.Notes)
+		}
+	}
+}
+
 // AC-7: Parallel Execution Respects File Conflicts.
 func TestAC7_ParallelExecutionRespectsConflicts(t *testing.T) {
 	// Test file pattern overlap detection
@@ -703,6 +813,31 @@ func TestAC7_ParallelExecutionRespectsConflicts(t *testing.T) {
 	}
 }
 
+func TestFileConflictMatchesGlobsAgainstInDevTickets(t *testing.T) {
+	state := newMockState()
+	inDev := createReadySubTicket("SUB-1", "PARENT-001", "Users endpoint", []string{"src/api/users.go"})
+	inDev.Status = kanban.StatusInDev
+	state.AddTicket(*inDev)
+
+	orch := &Orchestrator{
+		state:  state,
+		logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+
+	glob := createReadySubTicket("SUB-2", "PARENT-001", "API refactor", []string{"src/api/*"})
+	if !orch.hasFileConflict(glob) {
+		t.Error("Expected src/api/* to conflict with src/api/users.go")
+	}
+	recursive := createReadySubTicket("SUB-3", "PARENT-001", "Go cleanup", []string{"src/**/*.go"})
+	if !orch.hasFileConflict(recursive) {
+		t.Error("Expected src/**/*.go to conflict with src/api/users.go")
+	}
+	disjoint := createReadySubTicket("SUB-4", "PARENT-001", "Web UI", []string{"web/**", "src/api/*.ts"})
+	if orch.hasFileConflict(disjoint) {
+		t.Error("Expected disjoint patterns not to conflict")
+	}
+}
+
 // AC-8: Max 3 Parallel DEV Agents.
 func TestAC8_MaxParallelDevAgents(t *testing.T) {
 	state := newMockState()
@@ -779,6 +914,73 @@ func TestAC9_ParentCompletionOnAllSubsDone(t *testing.T) {
 	}
 }
 
+// Sequential parallel groups are released one at a time.
+func TestSequentialParallelGroupsPromoteInStages(t *testing.T) {
+	state := newMockState()
+	parent := createCompletedPRDTicket("TEST-GROUPS")
+	parent.Status = kanban.StatusBreakingDown
+	state.AddTicket(*parent)
+
+	orch := &Orchestrator{
+		state:    state,
+		repoRoot: "/tmp/test",
+		config:   Config{SequentialParallelGroups: true},
+		logger:   slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+
+	ctx := context.Background()
+	orch.createSubTickets(ctx, parent, []SubTicketSpec{
+		{Title: "Schema", Domain: "backend", Files: []string{"db/schema.sql"}, ParallelGroup: 1},
+		{Title: "Models", Domain: "backend", Files: []string{"db/models.go"}, ParallelGroup: 1},
+		{Title: "API", Domain: "backend", Files: []string{"api/handlers.go"}, ParallelGroup: 2},
+		{Title: "UI", Domain: "frontend", Files: []string{"web/page.tsx"}, ParallelGroup: 3},
+	})
+
+	statuses := func() []kanban.Status {
+		var got []kanban.Status
+		for i := 1; i <= 4; i++ {
+			sub, _ := state.GetTicket(fmt.Sprintf("TEST-GROUPS-SUB-%d", i))
+			got = append(got, sub.Status)
+		}
+		return got
+	}
+	expect := func(stage string, want ...kanban.Status) {
+		t.Helper()
+		got := statuses()
+		for i := range want {
+			if got[i] != want[i] {
+				t.Fatalf("%s: expected %v, got %v", stage, want, got)
+			}
+		}
+	}
+	ready, queued, done := kanban.StatusReady, kanban.StatusQueued, kanban.StatusDone
+
+	expect("after breakdown", ready, ready, queued, queued)
+
+	// A partially finished group keeps the next one held
+	_ = state.UpdateTicketStatus("TEST-GROUPS-SUB-1", done, "test", "")
+	orch.promoteParallelGroups(ctx)
+	expect("group 1 partly done", done, ready, queued, queued)
+
+	_ = state.UpdateTicketStatus("TEST-GROUPS-SUB-2", done, "test", "")
+	orch.promoteParallelGroups(ctx)
+	expect("group 1 done", done, done, ready, queued)
+
+	// A later-group ticket made READY out of turn is held again
+	_ = state.UpdateTicketStatus("TEST-GROUPS-SUB-4", ready, "user", "")
+	orch.promoteParallelGroups(ctx)
+	expect("out-of-turn promotion", done, done, ready, queued)
+
+	// In-progress work in the active group is not touched
+	_ = state.UpdateTicketStatus("TEST-GROUPS-SUB-3", kanban.StatusInDev, "test", "")
+	orch.promoteParallelGroups(ctx)
+	expect("group 2 in dev", done, done, kanban.StatusInDev, queued)
+
+	_ = state.UpdateTicketStatus("TEST-GROUPS-SUB-3", done, "test", "")
+	orch.promoteParallelGroups(ctx)
+	expect("group 2 done", done, done, done, ready)
+}
+
 // AC-10: User Can Participate in Discussion.
 func TestAC10_UserParticipationInDiscussion(t *testing.T) {
 	ticket := &kanban.Ticket{
@@ -829,6 +1031,43 @@ func TestAC10_UserParticipationInDiscussion(t *testing.T) {
 	}
 }
 
+// Well-specified tickets skip the PRD discussion rounds.
+func TestRequirementsReadyBypassesPRDRounds(t *testing.T) {
+	state := newMockState()
+
+	specified := createApprovedTicket("TEST-011", "Add health endpoint")
+	specified.Description = "Expose GET /healthz returning 200 with build version for load balancer probes."
+	specified.AcceptanceCriteria = []string{
+		"GET /healthz returns 200",
+		"Response includes build version",
+		"Endpoint requires no authentication",
+	}
+	state.AddTicket(*specified)
+
+	underspecified := createApprovedTicket("TEST-012", "Improve performance")
+	state.AddTicket(*underspecified)
+
+	orch := &Orchestrator{
+		state:    state,
+		repoRoot: "/tmp/test",
+		config: Config{
+			FastTrackMinCriteria:       3,
+			FastTrackMinDescriptionLen: 40,
+			FastTrackMaxDescriptionLen: 600,
+		},
+		logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+
+	orch.processApprovedToPRDRound(context.Background())
+
+	if got, _ := state.GetTicket("TEST-011"); got.Status != kanban.StatusReady {
+		t.Errorf("Expected well-specified ticket to be READY, got %s", got.Status)
+	}
+	if got, _ := state.GetTicket("TEST-012"); !strings.HasPrefix(string(got.Status), string(kanban.StatusRefiningRound)) {
+		t.Errorf("Expected underspecified ticket to enter PRD rounds, got %s", got.Status)
+	}
+}
+
 // --- Helper Functions for Tests ---
 
 // patternsOverlap checks if two file patterns might conflict.
@@ -851,3 +1090,37 @@ func patternsOverlap(pattern1, pattern2 string) bool {
 
 	return false
 }
+
+func TestPRDMissingTemplateSectionStartsAnotherRound(t *testing.T) {
+	state := newMockState()
+	spawner := newMockSpawner()
+
+	ticket := createTicketWithExpertResponses("TEST-TPL")
+	state.AddTicket(*ticket)
+
+	spawner.SetResponse(agents.AgentTypePMFacilitator, `{
+		"action": "FINALIZE_PRD",
+		"synthesis": "All experts approve",
+		"prd": {"title": "Login", "goals": ["Ship login"]}
+	}`)
+
+	orch := &Orchestrator{
+		state:   state,
+		spawner: spawner,
+		config:  Config{PRDTemplate: []string{"goals", "security_plan"}},
+		logger:  slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	orch.runPMSynthesis(context.Background(), ticket, &ticket.Conversation.Rounds[0])
+
+	got, _ := state.GetTicket("TEST-TPL")
+	wantStatus := kanban.Status(fmt.Sprintf("%s_2", kanban.StatusRefiningRound))
+	if got.Status != wantStatus {
+		t.Fatalf("Expected %s, got %s", wantStatus, got.Status)
+	}
+	if got.Conversation.FinalPRD != "" {
+		t.Error("Expected the incomplete PRD not to be finalized")
+	}
+	if !strings.Contains(got.Conversation.Rounds[0].PMSynthesis, "security_plan") {
+		t.Errorf("Expected the synthesis to name the missing section, got %q", got.Conversation.Rounds[0].PMSynthesis)
+	}
+}
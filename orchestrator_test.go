@@ -0,0 +1,2202 @@
+package factory
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/madhatter5501/Factory/agents"
+	"github.com/madhatter5501/Factory/agents/anthropic"
+	"github.com/madhatter5501/Factory/git"
+	"github.com/madhatter5501/Factory/internal/notify"
+	"github.com/madhatter5501/Factory/kanban"
+)
+
+func TestBackpressureCountsTicketsWaitingOnLimits(t *testing.T) {
+	state := newMockState()
+
+	// 5 non-conflicting ready tickets, plus one blocked on an unfinished dependency
+	for i := 0; i < 5; i++ {
+		ticket := createReadySubTicket(
+			fmt.Sprintf("SUB-%d", i+1),
+			"PARENT-001",
+			fmt.Sprintf("Ticket %d", i+1),
+			[]string{fmt.Sprintf("file%d.go", i)},
+		)
+		state.AddTicket(*ticket)
+	}
+	blocked := createReadySubTicket("SUB-6", "PARENT-001", "Blocked ticket", []string{"other.go"})
+	blocked.Dependencies = []string{"MISSING-1"}
+	state.AddTicket(*blocked)
+
+	// One dev agent already running against a limit of 2 leaves a single slot
+	state.AddActiveRun(kanban.AgentRun{ID: "run-1", Agent: "dev-backend", TicketID: "OTHER", StartedAt: time.Now(), Status: "running"})
+
+	orch := &Orchestrator{
+		state:  state,
+		config: Config{MaxParallelAgents: 2},
+		logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+
+	bp := orch.GetBackpressure()
+
+	if bp.ActiveDevRuns != 1 || bp.DevLimit != 2 {
+		t.Errorf("Expected 1/2 dev runs, got %d/%d", bp.ActiveDevRuns, bp.DevLimit)
+	}
+	if bp.ReadyTickets != 6 {
+		t.Errorf("Expected 6 ready tickets, got %d", bp.ReadyTickets)
+	}
+	if bp.WaitingOnDependencies != 1 {
+		t.Errorf("Expected 1 ticket waiting on dependencies, got %d", bp.WaitingOnDependencies)
+	}
+	if bp.WaitingOnLimits != 4 {
+		t.Errorf("Expected 4 tickets waiting on limits, got %d", bp.WaitingOnLimits)
+	}
+	if !bp.Throttled {
+		t.Error("Expected backpressure to report throttled")
+	}
+}
+
+func TestReturningTicketPassesFixedBugsToQA(t *testing.T) {
+	state := newMockState()
+	spawner := newMockSpawner()
+
+	ticket := createReadySubTicket("SUB-1", "PARENT-001", "Returning ticket", []string{"api.go"})
+	ticket.Status = kanban.StatusInQA
+	ticket.Bugs = []kanban.Bug{
+		{ID: "BUG-1", Title: "Nil pointer on empty body", FoundBy: "qa", Fixed: true},
+		{ID: "BUG-2", Title: "Missing validation", FoundBy: "qa", Fixed: false},
+		{ID: "BUG-3", Title: "Token logged in plaintext", FoundBy: "security", Fixed: true},
+	}
+	state.AddTicket(*ticket)
+
+	orch := &Orchestrator{
+		state:   state,
+		spawner: spawner,
+		config:  Config{},
+		logger:  slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+
+	orch.runReviewAgent(context.Background(), ticket, agents.AgentTypeQA, kanban.StatusInUX, "qa")
+
+	if len(spawner.spawnedRuns) != 1 {
+		t.Fatalf("Expected 1 QA spawn, got %d", len(spawner.spawnedRuns))
+	}
+	bugs := spawner.spawnedRuns[0].BugsToVerify
+	if len(bugs) != 1 || bugs[0].ID != "BUG-1" {
+		t.Errorf("Expected only fixed QA bug BUG-1 to verify, got %+v", bugs)
+	}
+}
+
+func TestUnverifiableCriteriaEscalateToUser(t *testing.T) {
+	tests := []struct {
+		name       string
+		report     string
+		wantStatus kanban.Status
+	}{
+		{
+			name:       "untestable criteria",
+			report:     `{"status": "failed", "agent": "qa", "unverifiable_criteria": ["Login should feel snappy"]}`,
+			wantStatus: kanban.StatusAwaitingUser,
+		},
+		{
+			// Real failures are not escalated and follow the normal review path
+			name:       "failed implementation",
+			report:     `{"status": "failed", "agent": "qa", "unmet_criteria": ["Rejects empty passwords"], "unverifiable_criteria": ["Login should feel snappy"]}`,
+			wantStatus: kanban.StatusInUX,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state := &checkinState{mockState: newMockState(), notes: map[string]string{}}
+			spawner := newMockSpawner()
+			spawner.SetResponse(agents.AgentTypeQA, "QA complete.\n\n```json\n"+tt.report+"\n```")
+
+			ticket := createReadySubTicket("SUB-1", "PARENT-001", "Login form", []string{"login.go"})
+			ticket.Status = kanban.StatusInQA
+			state.AddTicket(*ticket)
+
+			orch := &Orchestrator{
+				state:   state,
+				spawner: spawner,
+				config:  Config{},
+				logger:  slog.New(slog.NewTextHandler(io.Discard, nil)),
+			}
+			orch.runReviewAgent(context.Background(), ticket, agents.AgentTypeQA, kanban.StatusInUX, "qa")
+
+			got, _ := state.GetTicket("SUB-1")
+			if got.Status != tt.wantStatus {
+				t.Fatalf("Expected %s, got %s", tt.wantStatus, got.Status)
+			}
+			if tt.wantStatus == kanban.StatusAwaitingUser && !strings.Contains(state.notes["SUB-1"], "Login should feel snappy") {
+				t.Errorf("Expected the unverifiable criterion to be flagged, got note %q", state.notes["SUB-1"])
+			}
+		})
+	}
+}
+
+// mergeQueueStore is a WorktreeStore holding a single merge queue entry.
+// Unimplemented methods panic via the nil embedded interface.
+type mergeQueueStore struct {
+	WorktreeStore
+	entry kanban.MergeQueueEntry
+}
+
+func (s *mergeQueueStore) GetPendingMerges() ([]kanban.MergeQueueEntry, error) {
+	if s.entry.Status == kanban.MergeQueueStatusPending || s.entry.Status == kanban.MergeQueueStatusInProgress {
+		return []kanban.MergeQueueEntry{s.entry}, nil
+	}
+	return nil, nil
+}
+
+func (s *mergeQueueStore) UpdateMergeStatus(id string, status kanban.MergeQueueStatus, lastError string) error {
+	s.entry.Status = status
+	s.entry.LastError = lastError
+	s.entry.Attempts++
+	return nil
+}
+
+func (s *mergeQueueStore) ScheduleMergeRetry(id string, lastError string, nextAttemptAt time.Time) error {
+	s.entry.Status = kanban.MergeQueueStatusPending
+	s.entry.LastError = lastError
+	s.entry.NextAttemptAt = &nextAttemptAt
+	return nil
+}
+
+func (s *mergeQueueStore) FailMerge(id string, lastError string) error {
+	s.entry.Status = kanban.MergeQueueStatusFailed
+	s.entry.LastError = lastError
+	return nil
+}
+
+func (s *mergeQueueStore) LogWorktreeEvent(event kanban.WorktreeEvent) error { return nil }
+
+func (s *mergeQueueStore) GetTicket(id string) (*kanban.Ticket, bool) { return nil, false }
+
+func (s *mergeQueueStore) UpdateTicketStatus(id string, newStatus kanban.Status, by string, note string) error {
+	return nil
+}
+
+func (s *mergeQueueStore) CreateConversation(conv *kanban.TicketConversation) error { return nil }
+
+func (s *mergeQueueStore) AddConversationMessage(msg *kanban.ConversationMessage) error { return nil }
+
+func TestFailingMergeBacksOffThenFailsAtCap(t *testing.T) {
+	// The ticket is missing from the store, so every merge attempt fails
+	store := &mergeQueueStore{entry: kanban.MergeQueueEntry{
+		ID: "merge-1", TicketID: "T-1", Branch: "feat/T-1", Status: kanban.MergeQueueStatusPending,
+	}}
+	m := &BackgroundAgentManager{orchestrator: &Orchestrator{
+		logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}}
+	config := DefaultWorktreeManagerConfig()
+	config.MaxMergeAttempts = 3
+	config.MergeRetryBackoff = time.Minute
+
+	process := func() {
+		t.Helper()
+		if err := m.processMergeQueue(context.Background(), store, config); err != nil {
+			t.Fatalf("processMergeQueue failed: %v", err)
+		}
+	}
+
+	for attempt := 1; attempt < config.MaxMergeAttempts; attempt++ {
+		before := time.Now()
+		process()
+
+		if store.entry.Attempts != attempt || store.entry.Status != kanban.MergeQueueStatusPending {
+			t.Fatalf("Attempt %d: expected pending with %d attempts, got %s with %d",
+				attempt, attempt, store.entry.Status, store.entry.Attempts)
+		}
+		wantBackoff := time.Minute << (attempt - 1)
+		if store.entry.NextAttemptAt == nil || store.entry.NextAttemptAt.Before(before.Add(wantBackoff)) {
+			t.Fatalf("Attempt %d: expected next attempt at least %v out, got %v", attempt, wantBackoff, store.entry.NextAttemptAt)
+		}
+
+		// Reprocessing during the backoff window must not retry
+		process()
+		if store.entry.Attempts != attempt {
+			t.Fatalf("Attempt %d: merge retried during backoff (%d attempts)", attempt, store.entry.Attempts)
+		}
+
+		// Let the backoff elapse
+		elapsed := time.Now().Add(-time.Second)
+		store.entry.NextAttemptAt = &elapsed
+	}
+
+	process()
+	if store.entry.Status != kanban.MergeQueueStatusFailed {
+		t.Fatalf("Expected merge to fail after %d attempts, got %s", config.MaxMergeAttempts, store.entry.Status)
+	}
+	if store.entry.Attempts != config.MaxMergeAttempts {
+		t.Errorf("Expected %d attempts, got %d", config.MaxMergeAttempts, store.entry.Attempts)
+	}
+
+	if got := mergeRetryBackoff(time.Minute, 20); got != maxMergeRetryBackoff {
+		t.Errorf("Expected backoff capped at %v, got %v", maxMergeRetryBackoff, got)
+	}
+}
+
+// fakeMerger is a branchMerger that records merged branches and fails a
+// branch's first failures[branch] merges.
+type fakeMerger struct {
+	merged   []string
+	failures map[string]int
+}
+
+func (f *fakeMerger) SquashMerge(branch, message string) error {
+	if f.failures[branch] > 0 {
+		f.failures[branch]--
+		return fmt.Errorf("conflict merging %s", branch)
+	}
+	f.merged = append(f.merged, branch)
+	return nil
+}
+
+func (f *fakeMerger) PushMain() error { return nil }
+
+// mergeQueueFakeStore is a WorktreeStore holding several merge queue entries,
+// their tickets, and the worktree events logged against them.
+type mergeQueueFakeStore struct {
+	mergeQueueStore
+	entries  []*kanban.MergeQueueEntry
+	tickets  map[string]*kanban.Ticket
+	events   []kanban.WorktreeEvent
+	statuses map[string]kanban.Status
+}
+
+func (s *mergeQueueFakeStore) entry(id string) *kanban.MergeQueueEntry {
+	for _, e := range s.entries {
+		if e.ID == id {
+			return e
+		}
+	}
+	return nil
+}
+
+func (s *mergeQueueFakeStore) GetPendingMerges() ([]kanban.MergeQueueEntry, error) {
+	var pending []kanban.MergeQueueEntry
+	for _, e := range s.entries {
+		if e.Status == kanban.MergeQueueStatusPending || e.Status == kanban.MergeQueueStatusInProgress {
+			pending = append(pending, *e)
+		}
+	}
+	return pending, nil
+}
+
+func (s *mergeQueueFakeStore) UpdateMergeStatus(id string, status kanban.MergeQueueStatus, lastError string) error {
+	e := s.entry(id)
+	e.Status, e.LastError = status, lastError
+	e.Attempts++
+	return nil
+}
+
+func (s *mergeQueueFakeStore) ScheduleMergeRetry(id string, lastError string, nextAttemptAt time.Time) error {
+	e := s.entry(id)
+	e.Status, e.LastError, e.NextAttemptAt = kanban.MergeQueueStatusPending, lastError, &nextAttemptAt
+	return nil
+}
+
+func (s *mergeQueueFakeStore) CompleteMerge(id string) error {
+	s.entry(id).Status = kanban.MergeQueueStatusCompleted
+	return nil
+}
+
+func (s *mergeQueueFakeStore) FailMerge(id string, lastError string) error {
+	e := s.entry(id)
+	e.Status, e.LastError = kanban.MergeQueueStatusFailed, lastError
+	return nil
+}
+
+func (s *mergeQueueFakeStore) LogWorktreeEvent(event kanban.WorktreeEvent) error {
+	s.events = append(s.events, event)
+	return nil
+}
+
+func (s *mergeQueueFakeStore) GetTicket(id string) (*kanban.Ticket, bool) {
+	t, ok := s.tickets[id]
+	return t, ok
+}
+
+func (s *mergeQueueFakeStore) UpdateTicketStatus(id string, newStatus kanban.Status, by string, note string) error {
+	s.statuses[id] = newStatus
+	return nil
+}
+
+func (s *mergeQueueFakeStore) UpdateWorktreeStatus(ticketID string, status kanban.WorktreePoolStatus) error {
+	return nil
+}
+
+// eventTypes returns the types of the worktree events logged for a ticket.
+func (s *mergeQueueFakeStore) eventTypes(ticketID string) []kanban.WorktreeEventType {
+	var types []kanban.WorktreeEventType
+	for _, e := range s.events {
+		if e.TicketID == ticketID {
+			types = append(types, e.EventType)
+		}
+	}
+	return types
+}
+
+func newMergeQueueFakeStore(entries ...*kanban.MergeQueueEntry) *mergeQueueFakeStore {
+	store := &mergeQueueFakeStore{entries: entries, tickets: map[string]*kanban.Ticket{}, statuses: map[string]kanban.Status{}}
+	for _, e := range entries {
+		store.tickets[e.TicketID] = &kanban.Ticket{ID: e.TicketID, Title: "Merge " + e.TicketID, Domain: kanban.DomainBackend}
+	}
+	return store
+}
+
+func TestMergeQueueMergesOldestFirst(t *testing.T) {
+	base := time.Now().Add(-time.Hour)
+	store := newMergeQueueFakeStore(
+		&kanban.MergeQueueEntry{ID: "merge-2", TicketID: "T-2", Branch: "feat/T-2", Status: kanban.MergeQueueStatusPending, CreatedAt: base.Add(2 * time.Minute)},
+		&kanban.MergeQueueEntry{ID: "merge-3", TicketID: "T-3", Branch: "feat/T-3", Status: kanban.MergeQueueStatusPending, CreatedAt: base.Add(3 * time.Minute)},
+		&kanban.MergeQueueEntry{ID: "merge-1", TicketID: "T-1", Branch: "feat/T-1", Status: kanban.MergeQueueStatusPending, CreatedAt: base.Add(time.Minute)},
+	)
+	merger := &fakeMerger{}
+	m := &BackgroundAgentManager{merger: merger, orchestrator: &Orchestrator{
+		logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}}
+
+	if err := m.processMergeQueue(context.Background(), store, DefaultWorktreeManagerConfig()); err != nil {
+		t.Fatalf("processMergeQueue failed: %v", err)
+	}
+
+	if want := []string{"feat/T-1", "feat/T-2", "feat/T-3"}; !reflect.DeepEqual(merger.merged, want) {
+		t.Errorf("Expected merges oldest first %v, got %v", want, merger.merged)
+	}
+	for _, e := range store.entries {
+		if e.Status != kanban.MergeQueueStatusCompleted || e.Attempts != 1 {
+			t.Errorf("%s: expected completed after 1 attempt, got %s after %d", e.ID, e.Status, e.Attempts)
+		}
+		want := []kanban.WorktreeEventType{kanban.WorktreeEventMergeStarted, kanban.WorktreeEventMergeCompleted}
+		if got := store.eventTypes(e.TicketID); !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: expected events %v, got %v", e.TicketID, want, got)
+		}
+	}
+}
+
+func TestMergeQueueRetriesThenCompletesOrFails(t *testing.T) {
+	store := newMergeQueueFakeStore(
+		&kanban.MergeQueueEntry{ID: "merge-1", TicketID: "T-1", Branch: "feat/T-1", Status: kanban.MergeQueueStatusPending, CreatedAt: time.Now().Add(-2 * time.Minute)},
+		&kanban.MergeQueueEntry{ID: "merge-2", TicketID: "T-2", Branch: "feat/T-2", Status: kanban.MergeQueueStatusPending, CreatedAt: time.Now().Add(-time.Minute)},
+	)
+	// T-1 recovers on its second attempt; T-2 never merges
+	merger := &fakeMerger{failures: map[string]int{"feat/T-1": 1, "feat/T-2": 100}}
+	m := &BackgroundAgentManager{merger: merger, orchestrator: &Orchestrator{
+		logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}}
+	config := DefaultWorktreeManagerConfig()
+	config.MaxMergeAttempts = 2
+	config.MergeRetryBackoff = time.Minute
+
+	process := func() {
+		t.Helper()
+		if err := m.processMergeQueue(context.Background(), store, config); err != nil {
+			t.Fatalf("processMergeQueue failed: %v", err)
+		}
+	}
+
+	process()
+	for _, e := range store.entries {
+		if e.Status != kanban.MergeQueueStatusPending || e.NextAttemptAt == nil || e.LastError == "" {
+			t.Fatalf("%s: expected a scheduled retry after the first failure, got %+v", e.ID, e)
+		}
+		// Let the backoff elapse
+		elapsed := time.Now().Add(-time.Second)
+		e.NextAttemptAt = &elapsed
+	}
+
+	process()
+	if e := store.entry("merge-1"); e.Status != kanban.MergeQueueStatusCompleted || e.Attempts != 2 {
+		t.Errorf("merge-1: expected completed on attempt 2, got %s after %d", e.Status, e.Attempts)
+	}
+	if e := store.entry("merge-2"); e.Status != kanban.MergeQueueStatusFailed || e.Attempts != 2 {
+		t.Errorf("merge-2: expected failed after 2 attempts, got %s after %d", e.Status, e.Attempts)
+	}
+	if store.statuses["T-2"] != kanban.StatusBlocked {
+		t.Errorf("Expected T-2 blocked after its merge failed, got %q", store.statuses["T-2"])
+	}
+	if _, changed := store.statuses["T-1"]; changed {
+		t.Errorf("Expected T-1's status untouched, got %s", store.statuses["T-1"])
+	}
+
+	started, completed, failed := kanban.WorktreeEventMergeStarted, kanban.WorktreeEventMergeCompleted, kanban.WorktreeEventMergeFailed
+	if got, want := store.eventTypes("T-1"), []kanban.WorktreeEventType{started, started, completed}; !reflect.DeepEqual(got, want) {
+		t.Errorf("T-1: expected events %v, got %v", want, got)
+	}
+	if got, want := store.eventTypes("T-2"), []kanban.WorktreeEventType{started, started, failed}; !reflect.DeepEqual(got, want) {
+		t.Errorf("T-2: expected events %v, got %v", want, got)
+	}
+
+	// Terminal entries are not picked up again
+	process()
+	if len(merger.merged) != 1 || store.entry("merge-2").Attempts != 2 {
+		t.Errorf("Expected no further merge attempts, merged %v", merger.merged)
+	}
+}
+
+// gitOutput runs git in dir and returns its trimmed output.
+func gitOutput(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %v failed: %v\n%s", args, err, output)
+	}
+	return strings.TrimSpace(string(output))
+}
+
+// newOriginRepo creates a repository on main with one commit, pushed to a bare origin.
+func newOriginRepo(t *testing.T) (repo, origin string) {
+	t.Helper()
+
+	origin = filepath.Join(t.TempDir(), "origin.git")
+	repo = t.TempDir()
+	gitOutput(t, repo, "init", "-q", "--bare", "-b", "main", origin)
+	gitOutput(t, repo, "init", "-q", "-b", "main")
+	gitOutput(t, repo, "config", "user.name", "Test Dev")
+	gitOutput(t, repo, "config", "user.email", "dev@example.com")
+	commitFile(t, repo, "README.md", "base\n", "Initial commit")
+	gitOutput(t, repo, "remote", "add", "origin", origin)
+	gitOutput(t, repo, "push", "-q", "origin", "main")
+	return repo, origin
+}
+
+// commitFile writes a file and commits it on the current branch.
+func commitFile(t *testing.T, dir, name, content, message string) {
+	t.Helper()
+
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	gitOutput(t, dir, "add", name)
+	gitOutput(t, dir, "commit", "-q", "-m", message)
+}
+
+func TestMergeApprovalGateHoldsSignedOffTicket(t *testing.T) {
+	// An origin with main, and a clone holding a finished feature branch
+	repo, origin := newOriginRepo(t)
+	gitOutput(t, repo, "checkout", "-q", "-b", "feat/SUB-1")
+	commitFile(t, repo, "api.go", "package api\n", "Implement feature")
+	gitOutput(t, repo, "checkout", "-q", "main")
+	initialHead := gitOutput(t, origin, "rev-parse", "main")
+
+	state := newMockState()
+	ticket := createReadySubTicket("SUB-1", "PARENT-001", "Gated ticket", []string{"api.go"})
+	ticket.Status = kanban.StatusPMReview
+	ticket.Worktree = &kanban.Worktree{Path: repo, Branch: "feat/SUB-1", Active: true}
+	state.AddTicket(*ticket)
+
+	orch := &Orchestrator{
+		state:    state,
+		worktree: git.NewWorktreeManager(repo, ".worktrees", "main"),
+		config:   Config{DryRun: true, AutoMerge: true, RequireMergeApproval: true},
+		logger:   slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	ctx := context.Background()
+
+	orch.processPMReviewStage(ctx)
+	orch.wg.Wait()
+
+	got, _ := state.GetTicket("SUB-1")
+	if got.Status != kanban.StatusAwaitingMergeApproval {
+		t.Fatalf("Expected PM sign-off to await merge approval, got %s", got.Status)
+	}
+
+	orch.processCompletedTickets(ctx)
+	if head := gitOutput(t, origin, "rev-parse", "main"); head != initialHead {
+		t.Fatal("Expected ticket not to be merged before approval")
+	}
+
+	// A human approves, as POST /api/tickets/{id}/approve-merge does
+	_ = state.UpdateTicketStatus("SUB-1", kanban.StatusDone, "alice", "Merge approved by alice")
+
+	orch.processCompletedTickets(ctx)
+	if head := gitOutput(t, origin, "rev-parse", "main"); head == initialHead {
+		t.Fatal("Expected ticket to be merged after approval")
+	}
+}
+
+func TestRebaseBeforeQA(t *testing.T) {
+	tests := []struct {
+		name       string
+		branchFile string // File the dev branch changes
+		wantStatus kanban.Status
+		wantRebase bool
+	}{
+		{"main advanced", "feature.txt", kanban.StatusInQA, true},
+		{"conflict", "README.md", kanban.StatusBlocked, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, _ := newOriginRepo(t)
+			branch := git.GenerateBranchName("feat/", "SUB-1", "Rebased ticket")
+
+			// The dev branch forks from the pushed main, then main moves on locally
+			gitOutput(t, repo, "checkout", "-q", "-b", branch)
+			commitFile(t, repo, tt.branchFile, "from branch\n", "Branch change")
+			gitOutput(t, repo, "checkout", "-q", "main")
+			commitFile(t, repo, "README.md", "from main\n", "Main change")
+			branchHead := gitOutput(t, repo, "rev-parse", branch)
+
+			state := newMockState()
+			state.config = kanban.BoardConfig{BranchPrefix: "feat/", RebaseBeforeQA: true}
+			ticket := createReadySubTicket("SUB-1", "PARENT-001", "Rebased ticket", []string{tt.branchFile})
+			state.AddTicket(*ticket)
+
+			orch := &Orchestrator{
+				state:    state,
+				worktree: git.NewWorktreeManager(repo, ".worktrees", "main"),
+				config:   Config{DryRun: true},
+				logger:   slog.New(slog.NewTextHandler(io.Discard, nil)),
+			}
+
+			orch.runDevAgent(context.Background(), ticket, kanban.DomainBackend)
+
+			got, _ := state.GetTicket("SUB-1")
+			if got.Status != tt.wantStatus {
+				t.Errorf("Expected status %s, got %s", tt.wantStatus, got.Status)
+			}
+
+			isAncestor := exec.Command("git", "merge-base", "--is-ancestor", "main", branch)
+			isAncestor.Dir = repo
+			rebased := isAncestor.Run() == nil
+			if rebased != tt.wantRebase {
+				t.Errorf("Expected branch rebased onto main = %v, got %v", tt.wantRebase, rebased)
+			}
+			if !tt.wantRebase && gitOutput(t, repo, "rev-parse", branch) != branchHead {
+				t.Error("Expected conflicted rebase to leave the branch untouched")
+			}
+		})
+	}
+}
+
+func TestTestCommandGatesQA(t *testing.T) {
+	tests := []struct {
+		name       string
+		command    string
+		wantStatus kanban.Status
+		wantFailed int
+	}{
+		{"passing", "echo '--- PASS: TestLogin'", kanban.StatusInQA, 0},
+		{"failing", "echo '--- PASS: TestLogin'; echo '--- FAIL: TestLogout'; exit 1", kanban.StatusBlocked, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, _ := newOriginRepo(t)
+			state := newMockState()
+			ticket := createReadySubTicket("SUB-1", "PARENT-001", "Tested ticket", []string{"api.go"})
+			state.AddTicket(*ticket)
+
+			orch := &Orchestrator{
+				state:    state,
+				spawner:  newMockSpawner(),
+				worktree: git.NewWorktreeManager(repo, ".worktrees", "main"),
+				config:   Config{TestCommands: map[string]string{string(kanban.DomainBackend): tt.command}},
+				logger:   slog.New(slog.NewTextHandler(io.Discard, nil)),
+			}
+
+			orch.runDevAgent(context.Background(), ticket, kanban.DomainBackend)
+
+			got, _ := state.GetTicket("SUB-1")
+			if got.Status != tt.wantStatus {
+				t.Errorf("Expected status %s, got %s", tt.wantStatus, got.Status)
+			}
+			if got.TestRun == nil {
+				t.Fatal("Expected the test run to be recorded on the ticket")
+			}
+			if got.TestRun.Command != tt.command || got.TestRun.Passed != 1 || got.TestRun.Failed != tt.wantFailed {
+				t.Errorf("Expected 1 passed and %d failed from %q, got %+v", tt.wantFailed, tt.command, got.TestRun)
+			}
+		})
+	}
+}
+
+func TestTicketIsAbandonedAfterFailureCap(t *testing.T) {
+	state := newMockState()
+	spawner := newMockSpawner()
+	spawner.fail = true
+
+	ticket := createReadySubTicket("SUB-1", "PARENT-001", "Flaky ticket", []string{"api.go"})
+	ticket.Status = kanban.StatusInQA
+	state.AddTicket(*ticket)
+	state.AddActiveRun(kanban.AgentRun{ID: "run-old", Agent: "dev-backend", TicketID: "SUB-1", StartedAt: time.Now(), Status: "failed"})
+
+	orch := &Orchestrator{
+		state:   state,
+		spawner: spawner,
+		config:  Config{MaxTicketFailures: 2},
+		logger:  slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	ctx := context.Background()
+	runQA := func() {
+		orch.processQAStage(ctx)
+		orch.wg.Wait()
+	}
+
+	// Second failure reaches the cap but does not exceed it
+	runQA()
+	if got, _ := state.GetTicket("SUB-1"); got.Status != kanban.StatusInQA {
+		t.Fatalf("Expected ticket to stay in QA at the cap, got %s", got.Status)
+	}
+
+	// Third failure exceeds it
+	runQA()
+	if got, _ := state.GetTicket("SUB-1"); got.Status != kanban.StatusAbandoned {
+		t.Fatalf("Expected ticket to be abandoned, got %s", got.Status)
+	}
+
+	// Abandoned tickets are no longer scheduled
+	runQA()
+	if spawned := len(spawner.GetSpawnedAgents()); spawned != 2 {
+		t.Errorf("Expected 2 spawns before abandoning, got %d", spawned)
+	}
+}
+
+func TestTicketSkipStagesOverrideBoardDefault(t *testing.T) {
+	state := newMockState()
+
+	skipsUX := createReadySubTicket("SUB-1", "PARENT-001", "DB migration", []string{"migrations/001.sql"})
+	skipsUX.Status = kanban.StatusInQA
+	skipsUX.SkipStages = []kanban.Status{kanban.StatusInUX}
+	state.AddTicket(*skipsUX)
+
+	fullPipeline := createReadySubTicket("SUB-2", "PARENT-001", "Settings page", []string{"web/settings.tsx"})
+	fullPipeline.Status = kanban.StatusInQA
+	state.AddTicket(*fullPipeline)
+
+	orch := &Orchestrator{
+		state:   state,
+		spawner: newMockSpawner(),
+		logger:  slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	orch.processQAStage(context.Background())
+	orch.wg.Wait()
+
+	if got, _ := state.GetTicket("SUB-1"); got.Status != kanban.StatusInSec {
+		t.Errorf("Expected ticket skipping UX to go to %s, got %s", kanban.StatusInSec, got.Status)
+	}
+	if got, _ := state.GetTicket("SUB-2"); got.Status != kanban.StatusInUX {
+		t.Errorf("Expected other ticket to go to %s, got %s", kanban.StatusInUX, got.Status)
+	}
+
+	// An empty per-ticket list overrides a board default that skips UX
+	state.config.SkipStages = []kanban.Status{kanban.StatusInUX}
+	optsIn := createReadySubTicket("SUB-3", "PARENT-001", "Login form", []string{"web/login.tsx"})
+	optsIn.Status = kanban.StatusInQA
+	optsIn.SkipStages = []kanban.Status{}
+	state.AddTicket(*optsIn)
+	orch.processQAStage(context.Background())
+	orch.wg.Wait()
+
+	if got, _ := state.GetTicket("SUB-3"); got.Status != kanban.StatusInUX {
+		t.Errorf("Expected ticket overriding the board default to go to %s, got %s", kanban.StatusInUX, got.Status)
+	}
+}
+
+func TestSkippedReviewStageIsNotedInHistory(t *testing.T) {
+	state := &checkinState{mockState: newMockState(), notes: map[string]string{}}
+
+	ticket := createReadySubTicket("SUB-1", "PARENT-001", "DB migration", []string{"migrations/001.sql"})
+	ticket.Status = kanban.StatusInQA
+	ticket.SkipStages = []kanban.Status{kanban.StatusInUX}
+	state.AddTicket(*ticket)
+
+	orch := &Orchestrator{
+		state:   state,
+		spawner: newMockSpawner(),
+		logger:  slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	orch.processQAStage(context.Background())
+	orch.wg.Wait()
+
+	if got, _ := state.GetTicket("SUB-1"); got.Status != kanban.StatusInSec {
+		t.Fatalf("Expected ticket skipping UX to go to %s after QA, got %s", kanban.StatusInSec, got.Status)
+	}
+	if note := state.notes["SUB-1"]; !strings.Contains(note, "UX skipped (ticket pipeline)") {
+		t.Errorf("Expected the skipped UX stage in the history note, got %q", note)
+	}
+
+	// Skips from the board default say so
+	state.mockState.config.SkipStages = []kanban.Status{kanban.StatusInSec, kanban.StatusPMReview}
+	defaulted := createReadySubTicket("SUB-2", "PARENT-001", "Copy tweak", []string{"web/copy.tsx"})
+	defaulted.Status = kanban.StatusInUX
+	state.AddTicket(*defaulted)
+	orch.processUXStage(context.Background())
+	orch.wg.Wait()
+
+	if got, _ := state.GetTicket("SUB-2"); got.Status != kanban.StatusDone {
+		t.Fatalf("Expected ticket skipping the rest of review to be %s, got %s", kanban.StatusDone, got.Status)
+	}
+	if note := state.notes["SUB-2"]; !strings.Contains(note, "Security, PM review skipped (board default)") {
+		t.Errorf("Expected the skipped stages in the history note, got %q", note)
+	}
+}
+
+// flakySpawner fails its first failures spawns with a provider error, then
+// defers to the mock spawner.
+type flakySpawner struct {
+	*mockSpawner
+	failures int
+	err      string
+	calls    int
+}
+
+func (s *flakySpawner) SpawnAgent(ctx context.Context, agentType agents.AgentType, data agents.PromptData, workDir string) (*agents.AgentResult, error) {
+	s.calls++
+	if s.calls <= s.failures {
+		return &agents.AgentResult{AgentType: agentType, Error: "API call failed: " + s.err}, errors.New(s.err)
+	}
+	return s.mockSpawner.SpawnAgent(ctx, agentType, data, workDir)
+}
+
+// auditState records audit entries.
+type auditState struct {
+	*mockState
+	entries []kanban.AuditEntry
+}
+
+func (s *auditState) AddAuditEntry(entry *kanban.AuditEntry) error {
+	s.entries = append(s.entries, *entry)
+	return nil
+}
+
+func (s *auditState) GetConfigValue(key string) (string, error) { return "", nil }
+
+func TestTransientAgentFailureIsRetriedUntilItSucceeds(t *testing.T) {
+	state := &auditState{mockState: newMockState()}
+	spawner := &flakySpawner{mockSpawner: newMockSpawner(), failures: 2, err: "API error (status 503): overloaded"}
+
+	ticket := createReadySubTicket("SUB-1", "PARENT-001", "Login form", []string{"login.go"})
+	ticket.Status = kanban.StatusInQA
+	state.AddTicket(*ticket)
+
+	orch := &Orchestrator{
+		state:   state,
+		spawner: spawner,
+		config:  Config{AgentMaxRetries: 2, AgentRetryBackoff: time.Millisecond},
+		logger:  slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	orch.runReviewAgent(context.Background(), ticket, agents.AgentTypeQA, kanban.StatusInUX, "qa")
+
+	if spawner.calls != 3 {
+		t.Fatalf("Expected 3 attempts, got %d", spawner.calls)
+	}
+	if got, _ := state.GetTicket("SUB-1"); got.Status != kanban.StatusInUX {
+		t.Fatalf("Expected ticket to advance to %s after the retries, got %s", kanban.StatusInUX, got.Status)
+	}
+	if len(state.entries) != 2 {
+		t.Fatalf("Expected an audit entry per failed attempt, got %d", len(state.entries))
+	}
+	if entry := state.entries[1]; entry.EventType != kanban.AuditEventError || !strings.Contains(entry.EventData, "attempt 2 of 3 failed, retrying") {
+		t.Errorf("Unexpected audit entry for the second attempt: %+v", entry)
+	}
+
+	// Failures that aren't transient are not retried
+	rejected := &flakySpawner{mockSpawner: newMockSpawner(), failures: 3, err: "API error (status 400): invalid request"}
+	orch.spawner = rejected
+	invalid := createReadySubTicket("SUB-2", "PARENT-001", "Signup form", []string{"signup.go"})
+	invalid.Status = kanban.StatusInQA
+	state.AddTicket(*invalid)
+	orch.runReviewAgent(context.Background(), invalid, agents.AgentTypeQA, kanban.StatusInUX, "qa")
+
+	if rejected.calls != 1 {
+		t.Errorf("Expected a rejected request to be attempted once, got %d", rejected.calls)
+	}
+	if got, _ := state.GetTicket("SUB-2"); got.Status == kanban.StatusInUX {
+		t.Errorf("Expected the failed ticket not to advance")
+	}
+}
+
+func TestAgentRetryBackoffIsCapped(t *testing.T) {
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{3, 2 * time.Minute},
+		{6, maxAgentRetryBackoff},
+		{100, maxAgentRetryBackoff},
+	}
+	for _, tt := range tests {
+		if got := agentRetryBackoff(30*time.Second, tt.attempt); got != tt.want {
+			t.Errorf("agentRetryBackoff(30s, %d) = %v, want %v", tt.attempt, got, tt.want)
+		}
+	}
+}
+
+func TestTicketTypePipelineSelectsReviewStages(t *testing.T) {
+	repo, _ := newOriginRepo(t)
+	state := newMockState()
+	state.config.Pipelines = map[string][]kanban.Status{
+		"tech-debt": {kanban.StatusInQA, kanban.StatusInSec, kanban.StatusDone},
+	}
+
+	orch := &Orchestrator{
+		state:    state,
+		spawner:  newMockSpawner(),
+		worktree: git.NewWorktreeManager(repo, ".worktrees", "main"),
+		logger:   slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	ctx := context.Background()
+
+	// runPipeline develops the ticket and then runs review until it is DONE,
+	// returning each status it moved through
+	runPipeline := func(id, ticketType string) []kanban.Status {
+		ticket := createReadySubTicket(id, "PARENT-001", ticketType+" work", []string{id + ".go"})
+		ticket.Type = ticketType
+		state.AddTicket(*ticket)
+		orch.runDevAgent(ctx, ticket, kanban.DomainBackend)
+
+		var path []kanban.Status
+		for i := 0; i < len(kanban.ReviewStages)+1; i++ {
+			current, _ := state.GetTicket(id)
+			path = append(path, current.Status)
+			if current.Status == kanban.StatusDone {
+				break
+			}
+			switch current.Status {
+			case kanban.StatusInQA:
+				orch.processQAStage(ctx)
+			case kanban.StatusInUX:
+				orch.processUXStage(ctx)
+			case kanban.StatusInSec:
+				orch.processSecurityStage(ctx)
+			case kanban.StatusPMReview:
+				orch.processPMReviewStage(ctx)
+			}
+			orch.wg.Wait()
+		}
+		return path
+	}
+
+	techDebt := runPipeline("SUB-1", "tech-debt")
+	want := []kanban.Status{kanban.StatusInQA, kanban.StatusInSec, kanban.StatusDone}
+	if !reflect.DeepEqual(techDebt, want) {
+		t.Errorf("Expected tech-debt to skip UX and PM review (%v), got %v", want, techDebt)
+	}
+
+	feature := runPipeline("SUB-2", "feature")
+	want = []kanban.Status{kanban.StatusInQA, kanban.StatusInUX, kanban.StatusInSec, kanban.StatusPMReview, kanban.StatusDone}
+	if !reflect.DeepEqual(feature, want) {
+		t.Errorf("Expected feature to run the full pipeline (%v), got %v", want, feature)
+	}
+}
+
+func TestReviewBugFilesLinkedBugfixTicket(t *testing.T) {
+	state := newMockState()
+	spawner := newMockSpawner()
+	spawner.SetResponse(agents.AgentTypeQA, "```json\n"+`{
+		"agent": "qa",
+		"status": "approved",
+		"bugs": [
+			{"title": "Checkout crashes on empty cart", "description": "Null total", "severity": "critical"},
+			{"title": "Button misaligned", "description": "Off by 2px", "severity": "low"}
+		]
+	}`+"\n```")
+
+	source := createReadySubTicket("SUB-1", "PARENT-001", "Checkout flow", []string{"checkout.go"})
+	source.Status = kanban.StatusInQA
+	state.AddTicket(*source)
+
+	orch := &Orchestrator{
+		state:   state,
+		spawner: spawner,
+		config:  Config{BugfixTicketSeverities: []string{"critical"}},
+		logger:  slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	runQA := func() {
+		orch.processQAStage(context.Background())
+		orch.wg.Wait()
+	}
+	runQA()
+
+	bugfix, ok := state.GetTicket("SUB-1-BUG-1")
+	if !ok {
+		t.Fatal("Expected a bugfix ticket for the critical bug")
+	}
+	if bugfix.Type != "bugfix" || bugfix.Title != "Checkout crashes on empty cart" || bugfix.Priority != kanban.PriorityCritical {
+		t.Errorf("Unexpected bugfix ticket: type %q, title %q, priority %d", bugfix.Type, bugfix.Title, bugfix.Priority)
+	}
+	wantLinks := []kanban.TicketLink{{Type: kanban.LinkTypeRelates, TicketID: "SUB-1"}}
+	if !reflect.DeepEqual(bugfix.Links, wantLinks) {
+		t.Errorf("Expected links %+v, got %+v", wantLinks, bugfix.Links)
+	}
+	ctx := bugfix.ComputeCreationContext(state.GetBoard().Tickets)
+	if ctx.Reason != "detected_issue" || ctx.SourceTicketID != "SUB-1" || ctx.SourceTitle != "Checkout flow" {
+		t.Errorf("Expected creation context pointing at SUB-1, got %+v", ctx)
+	}
+	if _, ok := state.GetTicket("SUB-1-BUG-2"); ok {
+		t.Error("Expected no ticket for a low severity bug")
+	}
+
+	// Reviewing again doesn't file the same bug twice
+	_ = state.UpdateTicketStatus("SUB-1", kanban.StatusInQA, "test", "")
+	runQA()
+	if _, ok := state.GetTicket("SUB-1-BUG-2"); ok {
+		t.Error("Expected the already filed bug not to be filed again")
+	}
+}
+
+// stubCodeRetriever returns fixed snippets, as a RAG index would.
+type stubCodeRetriever struct {
+	snippets string
+	limit    int // Limit of the last request
+	closed   bool
+}
+
+func (s *stubCodeRetriever) Close() error {
+	s.closed = true
+	return nil
+}
+
+func (s *stubCodeRetriever) RetrieveCodeContext(ctx context.Context, ticket *kanban.Ticket, domain string, limit int) (string, error) {
+	s.limit = limit
+	return s.snippets, nil
+}
+
+func TestDevPromptIncludesRetrievedCodeContext(t *testing.T) {
+	repo, _ := newOriginRepo(t)
+	state := newMockState()
+	spawner := newMockSpawner()
+	retriever := &stubCodeRetriever{snippets: "### From internal/auth/session.go (relevance: 0.91)\n\nfunc NewSession(user string) *Session {\n"}
+
+	ticket := createReadySubTicket("SUB-1", "PARENT-001", "Session expiry", []string{"internal/auth/*.go"})
+	state.AddTicket(*ticket)
+
+	orch := &Orchestrator{
+		state:         state,
+		spawner:       spawner,
+		worktree:      git.NewWorktreeManager(repo, ".worktrees", "main"),
+		codeRetriever: retriever,
+		config:        Config{CodeContextSnippets: 3},
+		logger:        slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	orch.runDevAgent(context.Background(), ticket, kanban.DomainBackend)
+
+	if len(spawner.spawnedRuns) != 1 {
+		t.Fatalf("Expected one dev run, got %d", len(spawner.spawnedRuns))
+	}
+	run := spawner.spawnedRuns[0]
+	if retriever.limit != 3 {
+		t.Errorf("Expected 3 snippets requested, got %d", retriever.limit)
+	}
+
+	pb, err := anthropic.NewPromptBuilder("prompts")
+	if err != nil {
+		t.Fatalf("Failed to create prompt builder: %v", err)
+	}
+	parts, err := pb.BuildCachedPrompt(string(run.AgentType), anthropic.AgentPromptData{
+		Ticket:       ticket,
+		WorktreePath: run.WorktreePath,
+		CodeContext:  run.CodeContext,
+	})
+	if err != nil {
+		t.Fatalf("BuildCachedPrompt failed: %v", err)
+	}
+	var prompt strings.Builder
+	for _, block := range pb.BuildSystemBlocks(parts) {
+		prompt.WriteString(block.Text)
+	}
+	if !strings.Contains(prompt.String(), "func NewSession(user string) *Session {") {
+		t.Error("Expected the retrieved snippets in the dev prompt")
+	}
+
+	// Closing the orchestrator closes the index
+	orch.Close()
+	if !retriever.closed {
+		t.Error("Expected Close to close the code context index")
+	}
+
+	// Without RAG there is no code context, and dev still runs
+	if got := orch.devCodeContext(context.Background(), ticket, kanban.DomainBackend); got != "" {
+		t.Errorf("Expected no code context without a retriever, got %q", got)
+	}
+}
+
+func TestDevRerunResumesInExistingWorktree(t *testing.T) {
+	repo, _ := newOriginRepo(t)
+	state := newMockState()
+	spawner := newMockSpawner()
+	spawner.fail = true // The first attempt times out
+
+	ticket := createReadySubTicket("SUB-1", "PARENT-001", "Resumable ticket", []string{"api.go"})
+	state.AddTicket(*ticket)
+
+	orch := &Orchestrator{
+		state:    state,
+		spawner:  spawner,
+		worktree: git.NewWorktreeManager(repo, ".worktrees", "main"),
+		logger:   slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	ctx := context.Background()
+
+	orch.runDevAgent(ctx, ticket, kanban.DomainBackend)
+	first := spawner.GetSpawnedAgents()[0]
+	if first.ResumeContext != "" {
+		t.Fatalf("Expected a clean first attempt, got resume context %q", first.ResumeContext)
+	}
+
+	// The failed attempt left partial work behind
+	commitFile(t, first.WorktreePath, "api.go", "package api\n", "Add API handler skeleton")
+
+	spawner.fail = false
+	current, _ := state.GetTicket("SUB-1")
+	orch.runDevAgent(ctx, current, kanban.DomainBackend)
+
+	second := spawner.GetSpawnedAgents()[1]
+	if second.WorktreePath != first.WorktreePath {
+		t.Errorf("Expected re-run to reuse worktree %s, got %s", first.WorktreePath, second.WorktreePath)
+	}
+	for _, want := range []string{"Add API handler skeleton", "agent failed"} {
+		if !strings.Contains(second.ResumeContext, want) {
+			t.Errorf("Expected resume context to mention %q, got %q", want, second.ResumeContext)
+		}
+	}
+	if got, _ := state.GetTicket("SUB-1"); got.Status != kanban.StatusInQA {
+		t.Errorf("Expected resumed run to move the ticket to QA, got %s", got.Status)
+	}
+}
+
+func TestFailedReviewFollowsFailureRouting(t *testing.T) {
+	routing := map[string]kanban.Status{"qa": kanban.StatusReady, "security": kanban.StatusBlocked}
+	tests := []struct {
+		name       string
+		agentType  agents.AgentType
+		status     kanban.Status
+		nextStatus kanban.Status
+		stage      string
+		bugs       bool
+		routing    map[string]kanban.Status
+		wantStatus kanban.Status
+	}{
+		{"qa failure returns to dev", agents.AgentTypeQA, kanban.StatusInQA, kanban.StatusInUX, "qa", false, routing, kanban.StatusReady},
+		{"security failure blocks", agents.AgentTypeSecurity, kanban.StatusInSec, kanban.StatusPMReview, "security", false, routing, kanban.StatusBlocked},
+		{"default returns failed qa to dev", agents.AgentTypeQA, kanban.StatusInQA, kanban.StatusInUX, "qa", false, DefaultFailureRouting(), kanban.StatusReady},
+		// Without a route a review that recorded bugs blocks the ticket
+		{"unrouted failure leaves qa in place", agents.AgentTypeQA, kanban.StatusInQA, kanban.StatusInUX, "qa", false, map[string]kanban.Status{}, kanban.StatusInQA},
+		{"unrouted review with bugs blocks", agents.AgentTypeQA, kanban.StatusInQA, kanban.StatusInUX, "qa", true, map[string]kanban.Status{}, kanban.StatusBlocked},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state := newMockState()
+			spawner := newMockSpawner()
+			spawner.fail = true
+
+			ticket := createReadySubTicket("SUB-1", "PARENT-001", "Reviewed ticket", []string{"api.go"})
+			ticket.Status = tt.status
+			if tt.bugs {
+				ticket.Bugs = []kanban.Bug{{ID: "BUG-1", Description: "Login fails"}}
+			}
+			state.AddTicket(*ticket)
+
+			orch := &Orchestrator{
+				state:   state,
+				spawner: spawner,
+				config:  Config{FailureRouting: tt.routing},
+				logger:  slog.New(slog.NewTextHandler(io.Discard, nil)),
+			}
+			orch.runReviewAgent(context.Background(), ticket, tt.agentType, tt.nextStatus, tt.stage)
+
+			if got, _ := state.GetTicket("SUB-1"); got.Status != tt.wantStatus {
+				t.Errorf("Expected %s, got %s", tt.wantStatus, got.Status)
+			}
+		})
+	}
+}
+
+func TestFailureCategoryPicksRoute(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        string
+		routing    map[string]kanban.Status
+		wantCalls  int
+		wantStatus kanban.Status
+	}{
+		// A rejected key fails every attempt the same way
+		{"setup error blocks without retrying", "API error (status 401): invalid key", DefaultFailureRouting(), 1, kanban.StatusBlocked},
+		{"task failure returns to dev", "exit status 1", DefaultFailureRouting(), 1, kanban.StatusReady},
+		{
+			"exhausted retries follow the category route",
+			"API error (status 503): overloaded",
+			map[string]kanban.Status{"qa": kanban.StatusReady, "qa:provider_transient": kanban.StatusAwaitingUser},
+			3,
+			kanban.StatusAwaitingUser,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state := newMockState()
+			spawner := &flakySpawner{mockSpawner: newMockSpawner(), failures: 3, err: tt.err}
+
+			ticket := createReadySubTicket("SUB-1", "PARENT-001", "Reviewed ticket", []string{"api.go"})
+			ticket.Status = kanban.StatusInQA
+			state.AddTicket(*ticket)
+
+			orch := &Orchestrator{
+				state:   state,
+				spawner: spawner,
+				config:  Config{FailureRouting: tt.routing, AgentMaxRetries: 2, AgentRetryBackoff: time.Millisecond},
+				logger:  slog.New(slog.NewTextHandler(io.Discard, nil)),
+			}
+			orch.runReviewAgent(context.Background(), ticket, agents.AgentTypeQA, kanban.StatusInUX, "qa")
+
+			if spawner.calls != tt.wantCalls {
+				t.Errorf("Expected %d attempts, got %d", tt.wantCalls, spawner.calls)
+			}
+			if got, _ := state.GetTicket("SUB-1"); got.Status != tt.wantStatus {
+				t.Errorf("Expected %s, got %s", tt.wantStatus, got.Status)
+			}
+		})
+	}
+}
+
+// checkinState is a mock state that also persists PM check-ins and status notes.
+type checkinState struct {
+	*mockState
+	config   map[string]string
+	checkins []kanban.PMCheckin
+	notes    map[string]string
+}
+
+func (s *checkinState) AddPMCheckin(checkin *kanban.PMCheckin) error {
+	s.checkins = append(s.checkins, *checkin)
+	return nil
+}
+
+func (s *checkinState) GetLastPMCheckin(ticketID string) (*kanban.PMCheckin, error) {
+	for i := len(s.checkins) - 1; i >= 0; i-- {
+		if s.checkins[i].TicketID == ticketID {
+			c := s.checkins[i]
+			return &c, nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *checkinState) GetConfigValue(key string) (string, error) { return s.config[key], nil }
+
+func (s *checkinState) CreateConversation(conv *kanban.TicketConversation) error { return nil }
+
+func (s *checkinState) AddConversationMessage(msg *kanban.ConversationMessage) error { return nil }
+
+func (s *checkinState) UpdateTicketStatus(id string, newStatus kanban.Status, by string, note string) error {
+	s.notes[id] = note
+	return s.mockState.UpdateTicketStatus(id, newStatus, by, note)
+}
+
+func TestIdleAwaitingUserTicketIsDeferredAfterWindow(t *testing.T) {
+	state := &checkinState{
+		mockState: newMockState(),
+		config: map[string]string{
+			"awaiting_user_idle_hours":        "72",
+			"awaiting_user_nudge_grace_hours": "24",
+			"awaiting_user_defer_status":      "icebox",
+		},
+		notes: make(map[string]string),
+	}
+	m := &BackgroundAgentManager{orchestrator: &Orchestrator{
+		logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}}
+
+	now := time.Now()
+	_ = state.AddTicket(kanban.Ticket{ID: "T-recent", Status: kanban.StatusAwaitingUser, UpdatedAt: now.Add(-71 * time.Hour)})
+	_ = state.AddTicket(kanban.Ticket{ID: "T-idle", Status: kanban.StatusAwaitingUser, UpdatedAt: now.Add(-73 * time.Hour)})
+
+	// Disabled unless configured
+	m.deferIdleAwaitingUser(state, state, AwaitingUserPolicy{})
+	if len(state.checkins) != 0 {
+		t.Fatalf("Expected no nudges with the policy disabled, got %d", len(state.checkins))
+	}
+
+	policy := getAwaitingUserPolicy(state)
+	if policy.IdleWindow != 72*time.Hour || policy.NudgeGrace != 24*time.Hour || policy.Target != kanban.StatusIcebox {
+		t.Fatalf("Unexpected policy from config: %+v", policy)
+	}
+
+	// Past the window: nudged first, not deferred
+	m.deferIdleAwaitingUser(state, state, policy)
+	if len(state.checkins) != 1 || state.checkins[0].TicketID != "T-idle" || state.checkins[0].CheckinType != kanban.CheckinTypeGuidance {
+		t.Fatalf("Expected one guidance nudge for T-idle, got %+v", state.checkins)
+	}
+	if state.tickets["T-idle"].Status != kanban.StatusAwaitingUser {
+		t.Fatalf("Expected T-idle to wait out the grace period, got %s", state.tickets["T-idle"].Status)
+	}
+
+	// Within the grace period nothing more happens
+	m.deferIdleAwaitingUser(state, state, policy)
+	if len(state.checkins) != 1 || state.tickets["T-idle"].Status != kanban.StatusAwaitingUser {
+		t.Fatalf("Expected no change during grace period, got %d check-ins and status %s",
+			len(state.checkins), state.tickets["T-idle"].Status)
+	}
+
+	// Grace period elapsed without the user responding
+	state.checkins[0].CreatedAt = now.Add(-25 * time.Hour)
+	m.deferIdleAwaitingUser(state, state, policy)
+
+	if got := state.tickets["T-idle"].Status; got != kanban.StatusIcebox {
+		t.Errorf("Expected T-idle deferred to ICEBOX, got %s", got)
+	}
+	if !strings.Contains(state.notes["T-idle"], "Auto-deferred to ICEBOX") {
+		t.Errorf("Expected deferral history note, got %q", state.notes["T-idle"])
+	}
+	if got := state.tickets["T-recent"].Status; got != kanban.StatusAwaitingUser {
+		t.Errorf("Expected T-recent left awaiting the user, got %s", got)
+	}
+}
+
+// recordingNotifier collects the notifications it is sent.
+type recordingNotifier struct {
+	sent []notify.Notification
+}
+
+func (n *recordingNotifier) Notify(notification notify.Notification) error {
+	n.sent = append(n.sent, notification)
+	return nil
+}
+
+func TestLongBlockedUnmanagedTicketIsEscalatedOnce(t *testing.T) {
+	state := &checkinState{
+		mockState: newMockState(),
+		config: map[string]string{
+			"blocked_escalation_hours":  "4",
+			"blocked_escalation_target": "#factory-oncall",
+		},
+		notes: make(map[string]string),
+	}
+	notifier := &recordingNotifier{}
+	m := &BackgroundAgentManager{orchestrator: &Orchestrator{
+		notifier: notifier,
+		logger:   slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}}
+
+	now := time.Now()
+	blockedAt := func(hoursAgo int, note string) []kanban.HistoryEntry {
+		return []kanban.HistoryEntry{
+			{Status: kanban.StatusInDev, At: now.Add(-48 * time.Hour)},
+			{Status: kanban.StatusBlocked, At: now.Add(-time.Duration(hoursAgo) * time.Hour), Note: note},
+		}
+	}
+	// Unmanaged and past the threshold
+	_ = state.AddTicket(kanban.Ticket{ID: "T-conflict", Title: "Conflicted", Status: kanban.StatusBlocked, History: blockedAt(5, "Rebase hit a merge conflict")})
+	// Unmanaged but recently blocked
+	_ = state.AddTicket(kanban.Ticket{ID: "T-recent", Status: kanban.StatusBlocked, History: blockedAt(2, "Rebase hit a merge conflict")})
+	// Past the threshold but the system is routing it back to dev
+	_ = state.AddTicket(kanban.Ticket{ID: "T-bug", Status: kanban.StatusBlocked, History: blockedAt(5, "QA failed"),
+		Bugs: []kanban.Bug{{Description: "Crash on save", Severity: "critical"}}})
+
+	policy := getBlockedEscalationPolicy(state)
+	if policy.Threshold != 4*time.Hour || policy.Target != "#factory-oncall" {
+		t.Fatalf("Unexpected policy from config: %+v", policy)
+	}
+
+	for i := 0; i < 3; i++ {
+		m.escalateLongBlocked(state, state, policy)
+	}
+
+	if len(notifier.sent) != 1 {
+		t.Fatalf("Expected exactly one escalation, got %+v", notifier.sent)
+	}
+	if n := notifier.sent[0]; n.TicketID != "T-conflict" || n.Target != "#factory-oncall" {
+		t.Errorf("Expected escalation of T-conflict to the configured target, got %+v", n)
+	}
+	if len(state.checkins) != 1 || state.checkins[0].TicketID != "T-conflict" || state.checkins[0].CheckinType != kanban.CheckinTypeBlocker {
+		t.Errorf("Expected one blocker check-in for T-conflict, got %+v", state.checkins)
+	}
+
+	// Disabled unless configured
+	m.escalateLongBlocked(state, state, BlockedEscalationPolicy{})
+	if len(notifier.sent) != 1 {
+		t.Errorf("Expected no escalations with the policy disabled, got %d", len(notifier.sent))
+	}
+}
+
+// worktreePoolState is a mock state that also exposes a worktree pool and config.
+// Unimplemented WorktreeStore methods panic via the nil embedded interface.
+type worktreePoolState struct {
+	*mockState
+	WorktreeStore
+	config map[string]string
+	pool   []kanban.WorktreePoolEntry
+	merges []*kanban.MergeQueueEntry
+}
+
+func (s *worktreePoolState) GetWorktreePool() ([]kanban.WorktreePoolEntry, error) { return s.pool, nil }
+
+func (s *worktreePoolState) GetActiveWorktreeCount() (int, error) {
+	count := 0
+	for _, entry := range s.pool {
+		if entry.Status == kanban.WorktreePoolStatusActive {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (s *worktreePoolState) GetConfigValue(key string) (string, error) { return s.config[key], nil }
+
+func (s *worktreePoolState) GetTicket(id string) (*kanban.Ticket, bool) {
+	return s.mockState.GetTicket(id)
+}
+
+func (s *worktreePoolState) GetTicketsByStatus(status kanban.Status) []kanban.Ticket {
+	return s.mockState.GetTicketsByStatus(status)
+}
+
+func (s *worktreePoolState) UpdateTicketStatus(id string, newStatus kanban.Status, by string, note string) error {
+	return s.mockState.UpdateTicketStatus(id, newStatus, by, note)
+}
+
+func (s *worktreePoolState) GetActiveRunsForTicket(ticketID string) []kanban.AgentRun {
+	return s.mockState.GetActiveRunsForTicket(ticketID)
+}
+
+func (s *worktreePoolState) CreateConversation(conv *kanban.TicketConversation) error { return nil }
+
+func (s *worktreePoolState) AddConversationMessage(msg *kanban.ConversationMessage) error { return nil }
+
+func (s *worktreePoolState) GetMergeByTicket(ticketID string) (*kanban.MergeQueueEntry, error) {
+	return nil, nil
+}
+
+func (s *worktreePoolState) ScheduleWorktreeCleanup(ticketID, branch, path string, at time.Time) error {
+	for i := range s.pool {
+		if s.pool[i].TicketID == ticketID {
+			s.pool[i].Status = kanban.WorktreePoolStatusCleanupPending
+			s.pool[i].CleanupAfter = &at
+			return nil
+		}
+	}
+	s.pool = append(s.pool, kanban.WorktreePoolEntry{
+		TicketID: ticketID, Branch: branch, Path: path,
+		Status: kanban.WorktreePoolStatusCleanupPending, CleanupAfter: &at,
+	})
+	return nil
+}
+
+func (s *worktreePoolState) RemoveFromPool(ticketID string) error {
+	for i := range s.pool {
+		if s.pool[i].TicketID == ticketID {
+			s.pool = append(s.pool[:i], s.pool[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+func (s *worktreePoolState) LogWorktreeEvent(event kanban.WorktreeEvent) error { return nil }
+
+func (s *worktreePoolState) UpdateWorktreeStatus(ticketID string, status kanban.WorktreePoolStatus) error {
+	for i := range s.pool {
+		if s.pool[i].TicketID == ticketID {
+			s.pool[i].Status = status
+		}
+	}
+	return nil
+}
+
+func (s *worktreePoolState) GetPendingMerges() ([]kanban.MergeQueueEntry, error) {
+	var pending []kanban.MergeQueueEntry
+	for _, e := range s.merges {
+		if e.Status == kanban.MergeQueueStatusPending {
+			pending = append(pending, *e)
+		}
+	}
+	return pending, nil
+}
+
+func (s *worktreePoolState) UpdateMergeStatus(id string, status kanban.MergeQueueStatus, lastError string) error {
+	for _, e := range s.merges {
+		if e.ID == id {
+			e.Status, e.LastError = status, lastError
+			e.Attempts++
+		}
+	}
+	return nil
+}
+
+func (s *worktreePoolState) CompleteMerge(id string) error {
+	return s.UpdateMergeStatus(id, kanban.MergeQueueStatusCompleted, "")
+}
+
+func TestMergedWorktreeKeptUntilRetentionElapses(t *testing.T) {
+	// A finished ticket's branch checked out in its own worktree
+	repo, _ := newOriginRepo(t)
+	wtPath := filepath.Join(t.TempDir(), "SUB-1")
+	gitOutput(t, repo, "worktree", "add", "-q", "-b", "feat/SUB-1", wtPath)
+	commitFile(t, wtPath, "api.go", "package api\n", "Implement feature")
+
+	state := &worktreePoolState{
+		mockState: newMockState(),
+		pool: []kanban.WorktreePoolEntry{
+			{TicketID: "SUB-1", Branch: "feat/SUB-1", Path: wtPath, Status: kanban.WorktreePoolStatusActive},
+		},
+	}
+	ticket := createReadySubTicket("SUB-1", "PARENT-001", "Merged ticket", []string{"api.go"})
+	ticket.Status = kanban.StatusDone
+	ticket.Worktree = &kanban.Worktree{Path: wtPath, Branch: "feat/SUB-1", Active: true}
+	state.AddTicket(*ticket)
+
+	orch := &Orchestrator{
+		state:    state,
+		worktree: git.NewWorktreeManager(repo, ".worktrees", "main"),
+		config:   Config{AutoCleanup: true, WorktreeRetention: time.Hour},
+		logger:   slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	m := &BackgroundAgentManager{orchestrator: orch}
+	ctx := context.Background()
+
+	orch.processCompletedTickets(ctx)
+	if _, err := os.Stat(wtPath); err != nil {
+		t.Fatalf("Expected merged worktree to be kept, got %v", err)
+	}
+	if got := state.pool[0]; got.Status != kanban.WorktreePoolStatusCleanupPending || got.CleanupAfter == nil {
+		t.Fatalf("Expected worktree scheduled for cleanup, got %+v", got)
+	}
+
+	// Within the retention the cleanup routine leaves it alone
+	if err := m.cleanupCompletedWorktrees(ctx, state, WorktreeManagerConfig{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(wtPath); err != nil {
+		t.Fatalf("Expected worktree kept within retention, got %v", err)
+	}
+
+	// Once the retention has passed it is removed
+	past := time.Now().Add(-time.Minute)
+	state.pool[0].CleanupAfter = &past
+	if err := m.cleanupCompletedWorktrees(ctx, state, WorktreeManagerConfig{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(wtPath); !os.IsNotExist(err) {
+		t.Errorf("Expected worktree removed after retention, got %v", err)
+	}
+	if len(state.pool) != 0 {
+		t.Errorf("Expected pool entry removed, got %+v", state.pool)
+	}
+}
+
+func TestReviewTicketKeepsWorktreeUntilDone(t *testing.T) {
+	// A ticket that has signed off dev and is in QA, its branch queued to merge
+	repo, _ := newOriginRepo(t)
+	wtPath := filepath.Join(t.TempDir(), "SUB-1")
+	gitOutput(t, repo, "worktree", "add", "-q", "-b", "feat/SUB-1", wtPath)
+	commitFile(t, wtPath, "api.go", "package api\n", "Implement feature")
+
+	state := &worktreePoolState{
+		mockState: newMockState(),
+		config:    map[string]string{"max_global_worktrees": "1", "cleanup_worktree_on_merge": "true"},
+		pool: []kanban.WorktreePoolEntry{
+			{TicketID: "SUB-1", Branch: "feat/SUB-1", Path: wtPath, Agent: "dev-backend", Status: kanban.WorktreePoolStatusActive},
+		},
+		merges: []*kanban.MergeQueueEntry{
+			{ID: "merge-1", TicketID: "SUB-1", Branch: "feat/SUB-1", Status: kanban.MergeQueueStatusPending, CreatedAt: time.Now()},
+		},
+	}
+	ticket := createReadySubTicket("SUB-1", "PARENT-001", "Reviewed ticket", []string{"api.go"})
+	ticket.Status = kanban.StatusInQA
+	ticket.Signoffs.Dev = true
+	ticket.Worktree = &kanban.Worktree{Path: wtPath, Branch: "feat/SUB-1", Active: true}
+	state.AddTicket(*ticket)
+
+	worktrees := git.NewWorktreeManager(repo, ".worktrees", "main")
+	orch := &Orchestrator{
+		state:    state,
+		worktree: worktrees,
+		config:   Config{AutoCleanup: true},
+		logger:   slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	m := &BackgroundAgentManager{orchestrator: orch, merger: worktrees}
+	orch.backgroundMgr = m
+	ctx := context.Background()
+	config := m.getWorktreeConfig(state)
+
+	// The merge at dev sign-off leaves the worktree to the review stages
+	if err := m.processMergeQueue(ctx, state, config); err != nil {
+		t.Fatalf("processMergeQueue failed: %v", err)
+	}
+	if err := m.cleanupCompletedWorktrees(ctx, state, config); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(wtPath); err != nil {
+		t.Fatalf("Expected the QA ticket's worktree to be kept, got %v", err)
+	}
+	if len(state.pool) != 1 || state.pool[0].Status != kanban.WorktreePoolStatusActive {
+		t.Fatalf("Expected the worktree to stay active for review, got %+v", state.pool)
+	}
+	if m.CanStartDevWork(kanban.DomainBackend) {
+		t.Error("Expected the reviewed ticket to hold the only worktree slot")
+	}
+
+	// Once the ticket is done and merged its slot is released for new dev work
+	_ = state.UpdateTicketStatus("SUB-1", kanban.StatusDone, "pm", "")
+	orch.processCompletedTickets(ctx)
+	if _, err := os.Stat(wtPath); !os.IsNotExist(err) {
+		t.Errorf("Expected worktree removed once done, got %v", err)
+	}
+	if len(state.pool) != 0 {
+		t.Errorf("Expected the pool entry released, got %+v", state.pool)
+	}
+	if !m.CanStartDevWork(kanban.DomainBackend) {
+		t.Error("Expected dev work to start once the finished ticket released its slot")
+	}
+}
+
+func TestDomainWorktreeCapHoldsInfraWhileBackendProceeds(t *testing.T) {
+	state := &worktreePoolState{
+		mockState: newMockState(),
+		config: map[string]string{
+			"max_global_worktrees":     "5",
+			"max_worktrees_per_domain": `{"infra": 1}`,
+		},
+		pool: []kanban.WorktreePoolEntry{
+			{TicketID: "INFRA-1", Agent: "dev-infra", Status: kanban.WorktreePoolStatusActive},
+			{TicketID: "BACKEND-1", Agent: "dev-backend", Status: kanban.WorktreePoolStatusActive},
+		},
+	}
+	m := &BackgroundAgentManager{orchestrator: &Orchestrator{
+		state:  state,
+		logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}}
+
+	if m.CanStartDevWork(kanban.DomainInfra) {
+		t.Error("Expected a second infra ticket to wait for the infra worktree")
+	}
+	if !m.CanStartDevWork(kanban.DomainBackend) {
+		t.Error("Expected a backend ticket to proceed under the global limit")
+	}
+
+	// Once the infra worktree is merging, the next infra ticket can start
+	state.pool[0].Status = kanban.WorktreePoolStatusMerging
+	if !m.CanStartDevWork(kanban.DomainInfra) {
+		t.Error("Expected infra work to start once its worktree is no longer active")
+	}
+
+	// Uncapped domains still respect the global limit
+	state.config["max_global_worktrees"] = "1"
+	if m.CanStartDevWork(kanban.DomainBackend) {
+		t.Error("Expected the global limit to apply to uncapped domains")
+	}
+}
+
+func TestSpawnRampUpLimitsEarlyCycles(t *testing.T) {
+	state := newMockState()
+	spawner := newMockSpawner()
+	spawner.fail = true // Failed reviews leave the tickets in QA to be picked up again
+
+	for i := 0; i < 6; i++ {
+		ticket := createReadySubTicket(fmt.Sprintf("SUB-%d", i+1), "PARENT-001", "QA ticket", []string{fmt.Sprintf("file%d.go", i)})
+		ticket.Status = kanban.StatusInQA
+		state.AddTicket(*ticket)
+	}
+
+	orch := &Orchestrator{
+		state:   state,
+		spawner: spawner,
+		config:  Config{MaxParallelAgents: 3, SpawnRampUpInitial: 2, SpawnRampUpCycles: 2},
+		logger:  slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+
+	// Budgets grow by the initial amount each cycle, then lift once the ramp-up ends
+	for cycle, want := range []int{2, 4, 6} {
+		before := len(spawner.spawnedRuns)
+		if _, err := orch.runCycle(context.Background()); err != nil {
+			t.Fatalf("Cycle %d failed: %v", cycle+1, err)
+		}
+		orch.wg.Wait()
+
+		if got := len(spawner.spawnedRuns) - before; got != want {
+			t.Errorf("Cycle %d: expected %d spawns, got %d", cycle+1, want, got)
+		}
+	}
+}
+
+// blockingSpawner holds every spawned agent until release is closed.
+type blockingSpawner struct {
+	*mockSpawner
+	release chan struct{}
+}
+
+func (b *blockingSpawner) SpawnAgent(ctx context.Context, agentType agents.AgentType, data agents.PromptData, workDir string) (*agents.AgentResult, error) {
+	<-b.release
+	return b.mockSpawner.SpawnAgent(ctx, agentType, data, workDir)
+}
+
+func TestStepRunsExactlyOneCycle(t *testing.T) {
+	state := newMockState()
+	spawner := &blockingSpawner{mockSpawner: newMockSpawner(), release: make(chan struct{})}
+
+	approved := createReadySubTicket("SUB-1", "PARENT-001", "Well specified", []string{"a.go"})
+	approved.Status = kanban.StatusApproved
+	approved.AcceptanceCriteria = []string{"Works"}
+	state.AddTicket(*approved)
+	for _, id := range []string{"SUB-2", "SUB-3"} {
+		ticket := createReadySubTicket(id, "PARENT-001", "In QA", []string{id + ".go"})
+		ticket.Status = kanban.StatusInQA
+		state.AddTicket(*ticket)
+	}
+
+	orch := &Orchestrator{
+		state:   state,
+		spawner: spawner,
+		// No dev slots, so the fast-tracked ticket stays READY
+		config: Config{MaxParallelAgents: 0, FastTrackMinCriteria: 1},
+		logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+
+	summary, err := orch.Step(context.Background())
+	if err != nil {
+		t.Fatalf("Step failed: %v", err)
+	}
+	if summary.Cycle != 1 || orch.GetMetrics().CyclesRun != 1 {
+		t.Errorf("Expected exactly one cycle, got summary cycle %d and %d cycles run", summary.Cycle, orch.GetMetrics().CyclesRun)
+	}
+	wantSpawns := []SpawnedAgent{{Agent: "qa", TicketID: "SUB-2"}, {Agent: "qa", TicketID: "SUB-3"}}
+	sort.Slice(summary.AgentsSpawned, func(i, j int) bool { return summary.AgentsSpawned[i].TicketID < summary.AgentsSpawned[j].TicketID })
+	if !reflect.DeepEqual(summary.AgentsSpawned, wantSpawns) {
+		t.Errorf("Expected spawns %+v, got %+v", wantSpawns, summary.AgentsSpawned)
+	}
+	wantTransitions := []CycleTransition{{TicketID: "SUB-1", From: kanban.StatusApproved, To: kanban.StatusReady}}
+	if !reflect.DeepEqual(summary.Transitions, wantTransitions) {
+		t.Errorf("Expected transitions %+v, got %+v", wantTransitions, summary.Transitions)
+	}
+
+	// A step while a cycle holds the lock is refused rather than queued
+	orch.mu.Lock()
+	if _, err := orch.Step(context.Background()); !errors.Is(err, ErrCycleInProgress) {
+		t.Errorf("Expected ErrCycleInProgress, got %v", err)
+	}
+	orch.mu.Unlock()
+
+	close(spawner.release)
+	orch.wg.Wait()
+}
+
+func TestStepHoldsLockOnlyWhileItRuns(t *testing.T) {
+	state := &lockState{mockState: newMockState(), holder: "other-host:1"}
+	orch := &Orchestrator{
+		state:   state,
+		spawner: newMockSpawner(),
+		logger:  slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+
+	if _, err := orch.Step(context.Background()); !errors.Is(err, errLockHeld) {
+		t.Fatalf("Expected a step to be refused while another process holds the lock, got %v", err)
+	}
+
+	state.holder = ""
+	if _, err := orch.Step(context.Background()); err != nil {
+		t.Fatalf("Step failed: %v", err)
+	}
+	if state.acquired != 1 || state.holder != "" {
+		t.Errorf("Expected the step to take and release the lock, got %d acquisitions and holder %q", state.acquired, state.holder)
+	}
+}
+
+var errLockHeld = errors.New("lock held")
+
+// lockState is a mock store with an orchestrator lock.
+type lockState struct {
+	*mockState
+	holder   string
+	acquired int
+}
+
+func (l *lockState) AcquireOrchestratorLock(owner string, staleAfter time.Duration) error {
+	if l.holder != "" && l.holder != owner {
+		return errLockHeld
+	}
+	l.holder = owner
+	l.acquired++
+	return nil
+}
+
+func (l *lockState) HeartbeatOrchestratorLock(owner string) error { return nil }
+
+func (l *lockState) ReleaseOrchestratorLock(owner string) error {
+	if l.holder == owner {
+		l.holder = ""
+	}
+	return nil
+}
+
+// concurrencyTracker records the most agents it has seen running at once.
+type concurrencyTracker struct {
+	mu      sync.Mutex
+	current int
+	peak    int
+	total   int
+}
+
+// run holds a slot in the tracker for a moment, long enough for callers to overlap.
+func (c *concurrencyTracker) run() {
+	c.mu.Lock()
+	c.current++
+	c.total++
+	c.peak = max(c.peak, c.current)
+	c.mu.Unlock()
+
+	time.Sleep(20 * time.Millisecond)
+
+	c.mu.Lock()
+	c.current--
+	c.mu.Unlock()
+}
+
+type trackingSpawner struct {
+	*mockSpawner
+	tracker *concurrencyTracker
+}
+
+func (s *trackingSpawner) SpawnAgent(ctx context.Context, agentType agents.AgentType, data agents.PromptData, workDir string) (*agents.AgentResult, error) {
+	s.tracker.run()
+	return s.mockSpawner.SpawnAgent(ctx, agentType, data, workDir)
+}
+
+func TestMaxTotalAgentsHoldsAcrossAgentTypes(t *testing.T) {
+	state := newMockState()
+	for i, status := range []kanban.Status{kanban.StatusInQA, kanban.StatusInUX, kanban.StatusInSec} {
+		for j := 0; j < 2; j++ {
+			ticket := createReadySubTicket(fmt.Sprintf("SUB-%d-%d", i, j), "PARENT-001", "Review me", []string{fmt.Sprintf("file%d%d.go", i, j)})
+			ticket.Status = status
+			state.AddTicket(*ticket)
+		}
+	}
+
+	tracker := &concurrencyTracker{}
+	limiter := agents.NewAgentLimiter(2)
+	orch := &Orchestrator{
+		state:   state,
+		spawner: agents.NewLimitedSpawner(&trackingSpawner{mockSpawner: newMockSpawner(), tracker: tracker}, limiter),
+		limiter: limiter,
+		logger:  slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	bg := NewBackgroundAgentManager(orch)
+	bg.registerAgent("Test", time.Hour, func(context.Context) error {
+		tracker.run()
+		return nil
+	})
+	ctx := context.Background()
+
+	// Review agents of three types, background cycles, and chat responses all at once
+	orch.processQAStage(ctx)
+	orch.processUXStage(ctx)
+	orch.processSecurityStage(ctx)
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			bg.executeAgentCycle(ctx, bg.agents["Test"])
+		}()
+		go func() {
+			defer wg.Done()
+			if err := limiter.Acquire(ctx); err != nil {
+				t.Errorf("Failed to acquire chat slot: %v", err)
+				return
+			}
+			defer limiter.Release()
+			tracker.run()
+		}()
+	}
+	orch.wg.Wait()
+	wg.Wait()
+
+	if tracker.total < 12 {
+		t.Fatalf("Expected at least 12 agent runs, got %d", tracker.total)
+	}
+	if tracker.peak != 2 {
+		t.Errorf("Expected at most 2 agents in flight at the ceiling, peak was %d", tracker.peak)
+	}
+	if limiter.InFlight() != 0 {
+		t.Errorf("Expected every slot to be released, %d still held", limiter.InFlight())
+	}
+}
+
+func TestPausedTicketIsSkippedUntilResumed(t *testing.T) {
+	repo, _ := newOriginRepo(t)
+	state := newMockState()
+	spawner := newMockSpawner()
+
+	ticket := createReadySubTicket("SUB-1", "PARENT-001", "Paused ticket", []string{"api.go"})
+	ticket.Domain = "" // Picked up by the unspecified-domain scan
+	ticket.Paused = true
+	state.AddTicket(*ticket)
+
+	orch := &Orchestrator{
+		state:    state,
+		spawner:  spawner,
+		worktree: git.NewWorktreeManager(repo, ".worktrees", "main"),
+		config:   Config{MaxParallelAgents: 1},
+		logger:   slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	ctx := context.Background()
+
+	orch.processDevStage(ctx)
+	orch.wg.Wait()
+	if spawned := spawner.GetSpawnedAgents(); len(spawned) != 0 {
+		t.Fatalf("Expected no agents for a paused ticket, got %d", len(spawned))
+	}
+	if got, _ := state.GetTicket("SUB-1"); got.Status != kanban.StatusReady {
+		t.Fatalf("Expected paused ticket to stay READY, got %s", got.Status)
+	}
+
+	// Resuming makes it schedulable again
+	current, _ := state.GetTicket("SUB-1")
+	current.Paused = false
+
+	orch.processDevStage(ctx)
+	orch.wg.Wait()
+	if spawned := spawner.GetSpawnedAgents(); len(spawned) != 1 {
+		t.Fatalf("Expected the resumed ticket to be picked up, got %d agents", len(spawned))
+	}
+	if got, _ := state.GetTicket("SUB-1"); got.Status != kanban.StatusInQA {
+		t.Errorf("Expected resumed ticket to move on to QA, got %s", got.Status)
+	}
+}
+
+// activityState records the current activity set on each ticket.
+type activityState struct {
+	*mockState
+	activity map[string]string
+}
+
+func (s *activityState) UpdateActivity(ticketID, activity, assignee string) error {
+	s.activity[ticketID] = activity
+	return nil
+}
+
+func TestWIPLimitKeepsThirdTicketOutOfDev(t *testing.T) {
+	repo, _ := newOriginRepo(t)
+	state := &activityState{mockState: newMockState(), activity: map[string]string{}}
+	state.config.WIPLimits = map[kanban.Status]int{kanban.StatusInDev: 2}
+	spawner := newMockSpawner()
+
+	for i, id := range []string{"SUB-1", "SUB-2"} {
+		inDev := createReadySubTicket(id, "PARENT-001", "In progress", []string{fmt.Sprintf("busy%d.go", i)})
+		inDev.Status = kanban.StatusInDev
+		state.AddTicket(*inDev)
+	}
+	ticket := createReadySubTicket("SUB-3", "PARENT-001", "Third ticket", []string{"api.go"})
+	ticket.Domain = "" // Picked up by the unspecified-domain scan
+	state.AddTicket(*ticket)
+
+	orch := &Orchestrator{
+		state:    state,
+		spawner:  spawner,
+		worktree: git.NewWorktreeManager(repo, ".worktrees", "main"),
+		config:   Config{MaxParallelAgents: 5},
+		logger:   slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	ctx := context.Background()
+
+	orch.processDevStage(ctx)
+	orch.wg.Wait()
+	if spawned := spawner.GetSpawnedAgents(); len(spawned) != 0 {
+		t.Fatalf("Expected no dev agent with IN_DEV at its limit, got %d", len(spawned))
+	}
+	if got, _ := state.GetTicket("SUB-3"); got.Status != kanban.StatusReady {
+		t.Fatalf("Expected the third ticket to stay READY, got %s", got.Status)
+	}
+	if reason := state.activity["SUB-3"]; !strings.Contains(reason, "WIP limit 2") {
+		t.Errorf("Expected the ticket to say why it is waiting, got %q", reason)
+	}
+	if bp := orch.GetBackpressure(); bp.WaitingOnLimits != 1 {
+		t.Errorf("Expected the ticket counted as waiting on limits, got %d", bp.WaitingOnLimits)
+	}
+
+	// Raising the limit lets it start
+	state.config.WIPLimits[kanban.StatusInDev] = 3
+	orch.processDevStage(ctx)
+	orch.wg.Wait()
+	if spawned := spawner.GetSpawnedAgents(); len(spawned) != 1 || spawned[0].TicketID != "SUB-3" {
+		t.Fatalf("Expected SUB-3 to start once there was room, got %+v", spawned)
+	}
+}
+
+func TestWIPLimitKeepsTicketOutOfDevWhileQAIsFull(t *testing.T) {
+	state := &activityState{mockState: newMockState(), activity: map[string]string{}}
+	state.config.WIPLimits = map[kanban.Status]int{kanban.StatusInQA: 1}
+	spawner := newMockSpawner()
+
+	inQA := createReadySubTicket("SUB-1", "PARENT-001", "In QA", []string{"qa.go"})
+	inQA.Status = kanban.StatusInQA
+	state.AddTicket(*inQA)
+	ticket := createReadySubTicket("SUB-2", "PARENT-001", "Ready for dev", []string{"api.go"})
+	ticket.Domain = "" // Picked up by the unspecified-domain scan
+	state.AddTicket(*ticket)
+
+	orch := &Orchestrator{
+		state:   state,
+		spawner: spawner,
+		config:  Config{MaxParallelAgents: 5},
+		logger:  slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	orch.processDevStage(context.Background())
+	orch.wg.Wait()
+
+	if spawned := spawner.GetSpawnedAgents(); len(spawned) != 0 {
+		t.Fatalf("Expected dev to wait while QA is full, got %d agents", len(spawned))
+	}
+	if reason := state.activity["SUB-2"]; !strings.Contains(reason, "In QA (WIP limit 1)") {
+		t.Errorf("Expected the ticket to say why it is waiting, got %q", reason)
+	}
+}
+
+func TestWIPLimitHoldsReviewWhileNextStageIsFull(t *testing.T) {
+	state := &activityState{mockState: newMockState(), activity: map[string]string{}}
+	state.config.WIPLimits = map[kanban.Status]int{kanban.StatusInUX: 1}
+	spawner := newMockSpawner()
+
+	inUX := createReadySubTicket("SUB-1", "PARENT-001", "In UX review", []string{"ux.go"})
+	inUX.Status = kanban.StatusInUX
+	state.AddTicket(*inUX)
+	ticket := createReadySubTicket("SUB-2", "PARENT-001", "Ready for QA", []string{"api.go"})
+	ticket.Status = kanban.StatusInQA
+	state.AddTicket(*ticket)
+
+	orch := &Orchestrator{
+		state:   state,
+		spawner: spawner,
+		logger:  slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	orch.processQAStage(context.Background())
+	orch.wg.Wait()
+
+	if spawned := spawner.GetSpawnedAgents(); len(spawned) != 0 {
+		t.Fatalf("Expected QA to wait while UX is full, got %d agents", len(spawned))
+	}
+	if reason := state.activity["SUB-2"]; !strings.Contains(reason, "In UX Review (WIP limit 1)") {
+		t.Errorf("Expected the ticket to say why it is waiting, got %q", reason)
+	}
+}
+
+func TestCancelTicketRunsStopsOnlyThatTicket(t *testing.T) {
+	inner := &contextSpawner{started: make(chan string, 2)}
+	orch := &Orchestrator{ticketRuns: newTicketRunSpawner(inner)}
+
+	errs := make(map[string]chan error)
+	for _, id := range []string{"T-1", "T-2"} {
+		errs[id] = make(chan error, 1)
+		go func(id string) {
+			_, err := orch.ticketRuns.SpawnAgent(context.Background(), agents.AgentTypeQA, agents.PromptData{Ticket: &kanban.Ticket{ID: id}}, "")
+			errs[id] <- err
+		}(id)
+	}
+	<-inner.started
+	<-inner.started
+
+	if n := orch.CancelTicketRuns("T-1"); n != 1 {
+		t.Fatalf("Expected one run cancelled, got %d", n)
+	}
+	if err := <-errs["T-1"]; !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected T-1's agent to be cancelled, got %v", err)
+	}
+	select {
+	case err := <-errs["T-2"]:
+		t.Errorf("Expected T-2's agent to keep running, got %v", err)
+	default:
+	}
+
+	orch.CancelTicketRuns("T-2")
+	<-errs["T-2"]
+	if n := orch.CancelTicketRuns("T-1"); n != 0 {
+		t.Errorf("Expected finished runs to be forgotten, got %d", n)
+	}
+}
+
+func TestCancelledReviewIsNotAFailure(t *testing.T) {
+	state := newMockState()
+	ticket := createReadySubTicket("SUB-1", "PARENT-001", "Reviewed ticket", []string{"api.go"})
+	ticket.Status = kanban.StatusInQA
+	state.AddTicket(*ticket)
+
+	inner := &contextSpawner{started: make(chan string, 1)}
+	ticketRuns := newTicketRunSpawner(inner)
+	orch := &Orchestrator{
+		state:      state,
+		spawner:    ticketRuns,
+		ticketRuns: ticketRuns,
+		config:     Config{FailureRouting: map[string]kanban.Status{"qa": kanban.StatusBlocked}},
+		logger:     slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	go func() {
+		<-inner.started
+		orch.CancelTicketRuns("SUB-1")
+	}()
+	orch.runReviewAgent(context.Background(), ticket, agents.AgentTypeQA, kanban.StatusInUX, "qa")
+
+	if got, _ := state.GetTicket("SUB-1"); got.Status != kanban.StatusInQA {
+		t.Errorf("Expected a cancelled review to leave the ticket in IN_QA, got %s", got.Status)
+	}
+	if len(state.runs) != 1 || state.runs[0].Status != "cancelled" {
+		t.Errorf("Expected the run to be recorded as cancelled, got %+v", state.runs)
+	}
+	if failed := orch.GetMetrics().AgentsFailed; failed != 0 {
+		t.Errorf("Expected no failed agents, got %d", failed)
+	}
+}
+
+// contextSpawner runs each agent until its context is done.
+type contextSpawner struct {
+	*mockSpawner
+	started chan string
+}
+
+func (c *contextSpawner) SpawnAgent(ctx context.Context, agentType agents.AgentType, data agents.PromptData, workDir string) (*agents.AgentResult, error) {
+	c.started <- data.Ticket.ID
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestPendingDoneTicketCompletesAfterSoak(t *testing.T) {
+	state := newMockState()
+	now := time.Now()
+	pending := func(id string, since time.Time) *kanban.Ticket {
+		ticket := createReadySubTicket(id, "PARENT-001", id, nil)
+		ticket.Status = kanban.StatusPendingDone
+		ticket.History = []kanban.HistoryEntry{{Status: kanban.StatusPendingDone, At: since, By: "pm"}}
+		return ticket
+	}
+	state.AddTicket(*pending("SOAKED", now.Add(-2*time.Hour)))
+	state.AddTicket(*pending("SOAKING", now.Add(-10*time.Minute)))
+	late := pending("LATE", now.Add(-2*time.Hour))
+	late.Bugs = []kanban.Bug{
+		{Title: "Old nit", Severity: "critical", FoundAt: now.Add(-3 * time.Hour), Fixed: true},
+		{Title: "Crash on save", Severity: "critical", FoundAt: now.Add(-30 * time.Minute)},
+	}
+	state.AddTicket(*late)
+	review := createReadySubTicket("REVIEW", "PARENT-001", "In PM review", nil)
+	review.Status = kanban.StatusPMReview
+	state.AddTicket(*review)
+
+	orch := &Orchestrator{
+		state:  state,
+		config: Config{DryRun: true, DoneSoakPeriod: time.Hour},
+		logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	orch.processPMReviewStage(context.Background())
+	orch.wg.Wait()
+	orch.processPendingDoneStage(context.Background())
+
+	want := map[string]kanban.Status{
+		"SOAKED":  kanban.StatusDone,
+		"SOAKING": kanban.StatusPendingDone,
+		"LATE":    kanban.StatusBlocked,
+		"REVIEW":  kanban.StatusPendingDone,
+	}
+	for id, status := range want {
+		if got, _ := state.GetTicket(id); got.Status != status {
+			t.Errorf("%s: expected %s, got %s", id, status, got.Status)
+		}
+	}
+}
+
+func TestSignoffReportFlagsUnexpectedTestFramework(t *testing.T) {
+	tests := []struct {
+		framework string
+		want      string
+		mismatch  bool
+	}{
+		{framework: "Jest 29", want: "jest"},
+		{framework: "go test", want: "go test", mismatch: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.framework, func(t *testing.T) {
+			state := newMockState()
+			ticket := createReadySubTicket("SUB-1", "PARENT-001", "Login form", []string{"login.tsx"})
+			ticket.Domain = kanban.DomainFrontend
+			state.AddTicket(*ticket)
+
+			orch := &Orchestrator{
+				state:  state,
+				config: Config{TestFrameworks: map[string][]string{"frontend": {"jest", "vitest"}}},
+				logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+			}
+
+			output := fmt.Sprintf("```json\n{\"status\": \"passed\", \"agent\": \"qa\", \"tests_run\": {\"framework\": %q, \"passed\": 12, \"failed\": 0}}\n```", tt.framework)
+			orch.createSignoffReport("SUB-1", agents.AgentTypeQA, output)
+
+			if len(state.messages) != 1 {
+				t.Fatalf("expected 1 sign-off message, got %d", len(state.messages))
+			}
+			var report kanban.SignoffReport
+			if err := json.Unmarshal([]byte(state.messages[0].Content), &report); err != nil {
+				t.Fatalf("invalid sign-off report: %v", err)
+			}
+			if report.TestsRun.Framework != tt.want {
+				t.Errorf("expected framework %q, got %q", tt.want, report.TestsRun.Framework)
+			}
+			if report.TestsRun.FrameworkMismatch != tt.mismatch {
+				t.Errorf("expected mismatch %v, got %v", tt.mismatch, report.TestsRun.FrameworkMismatch)
+			}
+		})
+	}
+}
+
+// digestState is a mock state that records iteration digests.
+type digestState struct {
+	*mockState
+	digests map[string]*kanban.IterationDigest
+}
+
+func (s *digestState) BuildIterationDigest(iteration *kanban.Iteration) (*kanban.IterationDigest, error) {
+	return &kanban.IterationDigest{IterationID: iteration.ID, TicketsCompleted: []string{"T-1", "T-2"}, ADRsCreated: []string{"ADR-001"}}, nil
+}
+
+func (s *digestState) SaveIterationDigest(digest *kanban.IterationDigest) error {
+	s.digests[digest.IterationID] = digest
+	return nil
+}
+
+func (s *digestState) GetIterationDigest(iterationID string) (*kanban.IterationDigest, error) {
+	return s.digests[iterationID], nil
+}
+
+func TestCompletedIterationRecordsDigestOnce(t *testing.T) {
+	state := &digestState{mockState: newMockState(), digests: map[string]*kanban.IterationDigest{}}
+	state.SetIteration(&kanban.Iteration{ID: "it-1", StartedAt: time.Now().Add(-time.Hour)})
+	notifier := &recordingNotifier{}
+	orch := &Orchestrator{
+		state:    state,
+		notifier: notifier,
+		config:   Config{NotifyIterationDigest: true},
+		logger:   slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+
+	orch.recordIterationDigest()
+	orch.recordIterationDigest()
+
+	digest := state.digests["it-1"]
+	if digest == nil || len(digest.TicketsCompleted) != 2 || len(digest.ADRsCreated) != 1 {
+		t.Fatalf("Expected a digest with 2 tickets and 1 ADR, got %+v", digest)
+	}
+	if len(notifier.sent) != 1 {
+		t.Fatalf("Expected the digest to be sent once, got %d notifications", len(notifier.sent))
+	}
+	if sent := notifier.sent[0]; sent.Event != "iteration:digest" || !strings.Contains(sent.Message, "2 tickets completed, 1 ADRs created") {
+		t.Errorf("Unexpected digest notification %+v", sent)
+	}
+}
@@ -5,9 +5,11 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"sort"
 	"strconv"
 	"time"
 
+	"github.com/madhatter5501/Factory/agents"
 	"github.com/madhatter5501/Factory/kanban"
 )
 
@@ -21,6 +23,7 @@ type WorktreeStore interface {
 	RegisterWorktree(entry kanban.WorktreePoolEntry) error
 	UpdateWorktreeStatus(ticketID string, status kanban.WorktreePoolStatus) error
 	RemoveFromPool(ticketID string) error
+	ScheduleWorktreeCleanup(ticketID, branch, path string, at time.Time) error
 	GetWorktreePoolStats() (*kanban.WorktreePoolStats, error)
 
 	// Merge queue
@@ -28,6 +31,7 @@ type WorktreeStore interface {
 	GetPendingMerges() ([]kanban.MergeQueueEntry, error)
 	GetMergeByTicket(ticketID string) (*kanban.MergeQueueEntry, error)
 	UpdateMergeStatus(id string, status kanban.MergeQueueStatus, lastError string) error
+	ScheduleMergeRetry(id string, lastError string, nextAttemptAt time.Time) error
 	CompleteMerge(id string) error
 	FailMerge(id string, err string) error
 
@@ -54,11 +58,19 @@ type WorktreeStore interface {
 type WorktreeManagerConfig struct {
 	MaxGlobalWorktrees     int           // Maximum concurrent worktrees (default: 3)
 	MergeAfterDevSignoff   bool          // Merge to main after dev completes (default: true)
-	CleanupWorktreeOnMerge bool          // Remove worktree after merge (default: false)
+	CleanupWorktreeOnMerge bool          // Remove worktree once the ticket is done and merged (default: false)
 	CheckInterval          time.Duration // How often to check (default: 30s)
 	MaxMergeAttempts       int           // Max retry attempts for merge (default: 3)
+	MergeRetryBackoff      time.Duration // Delay before the first retry, doubled per attempt (default: 30s)
+
+	// MaxWorktreesPerDomain caps active dev worktrees per domain on top of the
+	// global limit, e.g. {"infra": 1}. Domains without a cap use only the global limit.
+	MaxWorktreesPerDomain map[kanban.Domain]int
 }
 
+// maxMergeRetryBackoff caps the exponential merge retry delay.
+const maxMergeRetryBackoff = 30 * time.Minute
+
 // DefaultWorktreeManagerConfig returns sensible defaults.
 func DefaultWorktreeManagerConfig() WorktreeManagerConfig {
 	return WorktreeManagerConfig{
@@ -67,6 +79,7 @@ func DefaultWorktreeManagerConfig() WorktreeManagerConfig {
 		CleanupWorktreeOnMerge: false,
 		CheckInterval:          30 * time.Second,
 		MaxMergeAttempts:       3,
+		MergeRetryBackoff:      30 * time.Second,
 	}
 }
 
@@ -86,25 +99,21 @@ func (m *BackgroundAgentManager) runWorktreeBackground(ctx context.Context) erro
 	// Get configuration
 	config := m.getWorktreeConfig(worktreeStore)
 
-	// 1. Process the merge queue - handle pending merges
-	m.updateAgentStatus(m.agents[BackgroundWorktree], "Running", "Processing merge queue")
-	if err := m.processMergeQueue(ctx, worktreeStore, config); err != nil {
-		m.orchestrator.logger.Error("Error processing merge queue", "error", err)
-	}
+	// The merge queue itself is drained by runMergeQueueWorker.
 
-	// 2. Detect dev signoffs - find tickets transitioning to IN_QA
+	// 1. Detect dev signoffs - find tickets transitioning to IN_QA
 	m.updateAgentStatus(m.agents[BackgroundWorktree], "Running", "Detecting dev signoffs")
 	if err := m.detectDevSignoffs(ctx, worktreeStore, config); err != nil {
 		m.orchestrator.logger.Error("Error detecting dev signoffs", "error", err)
 	}
 
-	// 3. Enforce worktree limits - check if new dev work can start
+	// 2. Enforce worktree limits - check if new dev work can start
 	m.updateAgentStatus(m.agents[BackgroundWorktree], "Running", "Checking worktree limits")
 	if err := m.enforceWorktreeLimits(ctx, worktreeStore, config); err != nil {
 		m.orchestrator.logger.Error("Error enforcing worktree limits", "error", err)
 	}
 
-	// 4. Cleanup completed worktrees - remove merged/done worktrees
+	// 3. Cleanup completed worktrees - remove merged/done worktrees
 	m.updateAgentStatus(m.agents[BackgroundWorktree], "Running", "Cleaning up completed worktrees")
 	if err := m.cleanupCompletedWorktrees(ctx, worktreeStore, config); err != nil {
 		m.orchestrator.logger.Error("Error cleaning up worktrees", "error", err)
@@ -148,12 +157,61 @@ func (m *BackgroundAgentManager) getWorktreeConfig(store WorktreeStore) Worktree
 		}
 	}
 
+	if val, err := store.GetConfigValue("max_merge_attempts"); err == nil && val != "" {
+		if n, err := strconv.Atoi(val); err == nil && n > 0 {
+			config.MaxMergeAttempts = n
+		}
+	}
+
+	if val, err := store.GetConfigValue("merge_retry_backoff"); err == nil && val != "" {
+		if seconds, err := strconv.Atoi(val); err == nil && seconds >= 0 {
+			config.MergeRetryBackoff = time.Duration(seconds) * time.Second
+		}
+	}
+
+	if val, err := store.GetConfigValue("max_worktrees_per_domain"); err == nil && val != "" {
+		var caps map[kanban.Domain]int
+		if err := json.Unmarshal([]byte(val), &caps); err == nil {
+			config.MaxWorktreesPerDomain = caps
+		}
+	}
+
 	return config
 }
 
+// branchMerger squash-merges finished branches into main. It is satisfied by
+// *git.WorktreeManager.
+type branchMerger interface {
+	SquashMerge(branch, message string) error
+	PushMain() error
+}
+
+// runMergeQueueWorker drains the merge queue on its own goroutine every
+// CheckInterval until ctx is cancelled, so merges are handled one at a time
+// regardless of what the worktree agent's cycle is doing.
+func (m *BackgroundAgentManager) runMergeQueueWorker(ctx context.Context, store WorktreeStore) {
+	interval := m.getWorktreeConfig(store).CheckInterval
+	if interval <= 0 {
+		interval = DefaultWorktreeManagerConfig().CheckInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := m.processMergeQueue(ctx, store, m.getWorktreeConfig(store)); err != nil {
+				m.orchestrator.logger.Error("Error processing merge queue", "error", err)
+			}
+		}
+	}
+}
+
 // processMergeQueue handles pending merge operations with retry logic.
 // This is the core merge processing function that:
-// 1. Gets pending merges from the queue
+// 1. Gets pending merges from the queue, oldest first
 // 2. Attempts squash merge to main
 // 3. Pushes to remote
 // 4. Notifies QA on success
@@ -163,9 +221,18 @@ func (m *BackgroundAgentManager) processMergeQueue(ctx context.Context, store Wo
 	if err != nil {
 		return fmt.Errorf("failed to get pending merges: %w", err)
 	}
+	sort.SliceStable(pendingMerges, func(i, j int) bool {
+		return pendingMerges[i].CreatedAt.Before(pendingMerges[j].CreatedAt)
+	})
 
+	now := time.Now()
 	for _, merge := range pendingMerges {
-		// Update status to in_progress
+		// Still backing off from a previous failure
+		if merge.NextAttemptAt != nil && now.Before(*merge.NextAttemptAt) {
+			continue
+		}
+
+		// Update status to in_progress (counts the attempt)
 		if err := store.UpdateMergeStatus(merge.ID, kanban.MergeQueueStatusInProgress, ""); err != nil {
 			m.orchestrator.logger.Error("Failed to update merge status", "id", merge.ID, "error", err)
 			continue
@@ -209,7 +276,7 @@ func (m *BackgroundAgentManager) processMergeQueue(ctx context.Context, store Wo
 				})
 
 				// Create escalation conversation
-				m.createMergeFailureConversation(store, merge.TicketID, mergeErr.Error())
+				m.createMergeFailureConversation(store, merge.TicketID, mergeErr.Error(), newAttempts)
 
 				// Update ticket status to BLOCKED
 				_ = store.UpdateTicketStatus(merge.TicketID, kanban.StatusBlocked, "WorktreeManager", "Merge to main failed after multiple attempts")
@@ -221,13 +288,15 @@ func (m *BackgroundAgentManager) processMergeQueue(ctx context.Context, store Wo
 					}
 				}
 			} else {
-				// Retry later
+				// Retry later, backing off exponentially
+				backoff := mergeRetryBackoff(config.MergeRetryBackoff, newAttempts)
 				m.orchestrator.logger.Warn("Merge attempt failed, will retry",
 					"ticket", merge.TicketID,
 					"attempt", newAttempts,
+					"backoff", backoff,
 					"error", mergeErr)
 
-				if err := store.UpdateMergeStatus(merge.ID, kanban.MergeQueueStatusPending, mergeErr.Error()); err != nil {
+				if err := store.ScheduleMergeRetry(merge.ID, mergeErr.Error(), time.Now().Add(backoff)); err != nil {
 					m.orchestrator.logger.Error("Failed to update merge status for retry", "id", merge.ID, "error", err)
 				}
 			}
@@ -241,8 +310,13 @@ func (m *BackgroundAgentManager) processMergeQueue(ctx context.Context, store Wo
 				m.orchestrator.logger.Error("Failed to mark merge as complete", "id", merge.ID, "error", err)
 			}
 
-			// Update worktree pool status
-			_ = store.UpdateWorktreeStatus(merge.TicketID, kanban.WorktreePoolStatusMerging)
+			// A merge after dev sign-off leaves the review stages still working in
+			// the worktree, so it stays active until the ticket is done
+			if ticket, found := store.GetTicket(merge.TicketID); found && !pipelineComplete(ticket) {
+				_ = store.UpdateWorktreeStatus(merge.TicketID, kanban.WorktreePoolStatusActive)
+			} else {
+				_ = store.UpdateWorktreeStatus(merge.TicketID, kanban.WorktreePoolStatusMerging)
+			}
 
 			// Log success event
 			_ = store.LogWorktreeEvent(kanban.WorktreeEvent{
@@ -268,6 +342,19 @@ func (m *BackgroundAgentManager) processMergeQueue(ctx context.Context, store Wo
 	return nil
 }
 
+// mergeRetryBackoff returns the delay before retrying a merge that has failed
+// the given number of times: base, 2*base, 4*base, ... capped at maxMergeRetryBackoff.
+func mergeRetryBackoff(base time.Duration, attempts int) time.Duration {
+	backoff := base
+	for i := 1; i < attempts && backoff < maxMergeRetryBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > maxMergeRetryBackoff {
+		backoff = maxMergeRetryBackoff
+	}
+	return backoff
+}
+
 // performMerge executes the actual git merge operation.
 func (m *BackgroundAgentManager) performMerge(ctx context.Context, store WorktreeStore, merge *kanban.MergeQueueEntry) error {
 	// Get ticket for commit message
@@ -281,12 +368,12 @@ func (m *BackgroundAgentManager) performMerge(ctx context.Context, store Worktre
 		ticket.Domain, ticket.Title, ticket.ID)
 
 	// Perform squash merge using the orchestrator's worktree manager
-	if err := m.orchestrator.worktree.SquashMerge(merge.Branch, commitMsg); err != nil {
+	if err := m.merger.SquashMerge(merge.Branch, commitMsg); err != nil {
 		return fmt.Errorf("squash merge failed: %w", err)
 	}
 
 	// Push to main
-	if err := m.orchestrator.worktree.PushMain(); err != nil {
+	if err := m.merger.PushMain(); err != nil {
 		return fmt.Errorf("push to main failed: %w", err)
 	}
 
@@ -402,12 +489,16 @@ func (m *BackgroundAgentManager) enforceWorktreeLimits(ctx context.Context, stor
 	return nil
 }
 
-// cleanupCompletedWorktrees removes worktrees for DONE tickets if configured.
-func (m *BackgroundAgentManager) cleanupCompletedWorktrees(ctx context.Context, store WorktreeStore, config WorktreeManagerConfig) error {
-	if !config.CleanupWorktreeOnMerge {
-		return nil
-	}
+// pipelineComplete reports whether a ticket has passed the final stage of its
+// pipeline. Until then review agents work in its worktree, so it is kept.
+func pipelineComplete(ticket *kanban.Ticket) bool {
+	return ticket.Status == kanban.StatusDone
+}
 
+// cleanupCompletedWorktrees removes worktrees for DONE tickets if configured,
+// and merged worktrees kept for inspection once their retention has elapsed.
+// A worktree whose ticket is back in review, e.g. after a reopen, is kept.
+func (m *BackgroundAgentManager) cleanupCompletedWorktrees(ctx context.Context, store WorktreeStore, config WorktreeManagerConfig) error {
 	// Get pool entries in cleanup_pending status
 	pool, err := store.GetWorktreePool()
 	if err != nil {
@@ -419,16 +510,25 @@ func (m *BackgroundAgentManager) cleanupCompletedWorktrees(ctx context.Context,
 			continue
 		}
 
-		// Get ticket to check if DONE
+		if entry.CleanupAfter != nil {
+			if time.Now().Before(*entry.CleanupAfter) {
+				continue // Still within the post-merge retention
+			}
+		} else if !config.CleanupWorktreeOnMerge {
+			continue
+		}
+
 		ticket, found := store.GetTicket(entry.TicketID)
-		if !found {
+		if !found && entry.CleanupAfter == nil {
 			// Ticket not found, remove from pool
 			_ = store.RemoveFromPool(entry.TicketID)
 			continue
 		}
-
-		if ticket.Status != kanban.StatusDone {
-			continue // Not ready for cleanup
+		if found && !pipelineComplete(ticket) {
+			m.orchestrator.logger.Debug("Keeping worktree for ticket still in its pipeline",
+				"ticket", entry.TicketID,
+				"status", ticket.Status)
+			continue
 		}
 
 		// Remove the worktree
@@ -509,7 +609,7 @@ func (m *BackgroundAgentManager) notifyQAMainUpdated(store WorktreeStore, ticket
 }
 
 // createMergeFailureConversation creates an escalation conversation when merge fails.
-func (m *BackgroundAgentManager) createMergeFailureConversation(store WorktreeStore, ticketID, lastError string) {
+func (m *BackgroundAgentManager) createMergeFailureConversation(store WorktreeStore, ticketID, lastError string, attempts int) {
 	convID := fmt.Sprintf("conv-merge-fail-%s-%d", ticketID, time.Now().Unix())
 
 	// Create the conversation thread (escalated status)
@@ -542,7 +642,7 @@ func (m *BackgroundAgentManager) createMergeFailureConversation(store WorktreeSt
 		ConversationID: convID,
 		Agent:          "WorktreeManager",
 		MessageType:    kanban.MessageTypeQuestion,
-		Content: fmt.Sprintf(`Failed to merge feature branch to main after 3 attempts.
+		Content: fmt.Sprintf(`Failed to merge feature branch to main after %d attempts.
 
 **Error:** %s
 
@@ -551,7 +651,7 @@ func (m *BackgroundAgentManager) createMergeFailureConversation(store WorktreeSt
 2. Resolve any conflicts in the feature branch
 3. Manually merge to main or re-queue the merge
 
-This ticket has been marked as BLOCKED until the merge issue is resolved.`, lastError),
+This ticket has been marked as BLOCKED until the merge issue is resolved.`, attempts, lastError),
 		Metadata:  string(metadataJSON),
 		CreatedAt: time.Now(),
 	}
@@ -563,9 +663,10 @@ This ticket has been marked as BLOCKED until the merge issue is resolved.`, last
 	}
 }
 
-// CanStartDevWork checks if a new dev agent can start based on worktree limits.
+// CanStartDevWork checks if a new dev agent can start on a ticket in the given
+// domain, based on the global worktree limit and the domain's own cap if set.
 // This is called by the orchestrator before spawning a dev agent.
-func (m *BackgroundAgentManager) CanStartDevWork() bool {
+func (m *BackgroundAgentManager) CanStartDevWork(domain kanban.Domain) bool {
 	worktreeStore, ok := m.orchestrator.state.(WorktreeStore)
 	if !ok {
 		return true // No limit enforcement if store doesn't support it
@@ -577,8 +678,30 @@ func (m *BackgroundAgentManager) CanStartDevWork() bool {
 		m.orchestrator.logger.Warn("Failed to check worktree count, allowing dev work", "error", err)
 		return true
 	}
+	if activeCount >= config.MaxGlobalWorktrees {
+		return false
+	}
 
-	return activeCount < config.MaxGlobalWorktrees
+	limit, ok := config.MaxWorktreesPerDomain[domain]
+	if !ok || limit <= 0 {
+		return true
+	}
+
+	pool, err := worktreeStore.GetWorktreePool()
+	if err != nil {
+		m.orchestrator.logger.Warn("Failed to check domain worktree count, allowing dev work", "domain", domain, "error", err)
+		return true
+	}
+
+	// Dev worktrees are registered under the agent type, which identifies the domain
+	agent := string(agents.GetAgentTypeForDomain(domain))
+	domainCount := 0
+	for _, entry := range pool {
+		if entry.Status == kanban.WorktreePoolStatusActive && entry.Agent == agent {
+			domainCount++
+		}
+	}
+	return domainCount < limit
 }
 
 // RegisterDevWorktree registers a new dev worktree in the global pool.